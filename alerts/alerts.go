@@ -2,8 +2,11 @@ package alerts
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
@@ -12,9 +15,17 @@ import (
 	rhpv4 "go.sia.tech/core/rhp/v4"
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/object"
+	"go.uber.org/zap"
 	"lukechampine.com/frand"
 )
 
+var (
+	// ErrMaxAlertsReached is returned when an alert can't be registered
+	// because the maximum number of stored alerts was reached and all of them
+	// are critical.
+	ErrMaxAlertsReached = errors.New("maximum number of stored alerts reached")
+)
+
 const (
 	// SeverityInfo indicates that the alert is informational.
 	SeverityInfo Severity = iota + 1
@@ -49,29 +60,105 @@ type (
 		Severity Severity `json:"severity"`
 		// Message is a human-readable message describing the alert.
 		Message string `json:"message"`
+		// Description is a human-readable explanation of what caused the
+		// alert, it refers to the affected entities by their identifiers.
+		Description string `json:"description,omitempty"`
+		// Suggestion is a human-readable suggestion on how to resolve the
+		// alert.
+		Suggestion string `json:"suggestion,omitempty"`
 		// Data is a map of arbitrary data that can be used to provide
 		// additional context to the alert.
 		Data      map[string]any `json:"data,omitempty"`
 		Timestamp time.Time      `json:"timestamp"`
+
+		// GroupKey is an optional fingerprint, registering an alert with the
+		// same group key as a stored alert updates the stored alert instead
+		// of adding a new one. Count is the number of alerts that were
+		// registered with the group key and UpdatedAt the time of the most
+		// recent one.
+		GroupKey  string    `json:"groupKey,omitempty"`
+		Count     int       `json:"count,omitempty"`
+		UpdatedAt time.Time `json:"updatedAt,omitzero"`
+	}
+
+	// DismissAlertRequest is a request to dismiss an alert. If either a
+	// reason or the name of whoever dismissed the alert is set, the
+	// dismissal is recorded for auditing purposes.
+	DismissAlertRequest struct {
+		AlertID     types.Hash256 `json:"alertID"`
+		Reason      string        `json:"reason,omitempty"`
+		DismissedBy string        `json:"dismissedBy,omitempty"`
+	}
+
+	// DismissedAlert is the record of an alert that was dismissed.
+	DismissedAlert struct {
+		AlertID     types.Hash256 `json:"alertID"`
+		Reason      string        `json:"reason"`
+		DismissedBy string        `json:"dismissedBy"`
+		Timestamp   time.Time     `json:"timestamp"`
+
+		// Alert is the alert at the time it was dismissed, it is only set
+		// if the alert was active.
+		Alert *Alert `json:"alert,omitempty"`
+	}
+
+	// Config contains the configuration for an alerts manager.
+	Config struct {
+		// MinAlertInterval is the minimum amount of time that has to pass
+		// before an alert with the same ID can be registered again. A zero
+		// value disables throttling.
+		MinAlertInterval time.Duration
+		// MaxStoredAlerts is the maximum number of alerts that are stored at
+		// the same time. When it is reached, the oldest non-critical alert is
+		// removed to make room for a new one. A zero value disables the limit.
+		MaxStoredAlerts int
+		Logger          *zap.Logger
 	}
 
 	// A Manager manages the host's alerts.
 	Manager struct {
+		minAlertInterval time.Duration
+		maxStoredAlerts  int
+		logger           *zap.SugaredLogger
+
 		mu sync.Mutex
 		// alerts is a map of alert IDs to their current alert.
 		alerts map[types.Hash256]Alert
+		// lastFired is a map of alert IDs to the time they were last
+		// registered, used to throttle alerts.
+		lastFired map[types.Hash256]time.Time
+		// skipped is a map of alert IDs to the number of registrations that
+		// were skipped due to throttling since the alert last fired.
+		skipped map[types.Hash256]uint64
+		// groups is a map of group keys to the ID of the stored alert of
+		// that group.
+		groups map[string]types.Hash256
+		// members is a map of the IDs of alerts that were merged into a
+		// grouped alert to the ID of the stored alert of their group.
+		members map[types.Hash256]types.Hash256
 	}
 
 	AlertsOpts struct {
 		Offset   int
 		Limit    int
 		Severity Severity
+
+		// GroupBy is a list of data keys, alerts with the same message and
+		// equal values for all of these keys are returned as a single alert,
+		// the most recent one, with their counts summed up.
+		GroupBy []string
 	}
 
 	AlertsResponse struct {
 		Alerts  []Alert `json:"alerts"`
 		HasMore bool    `json:"hasMore"`
-		Totals  struct {
+
+		// Count is the number of stored alerts, MaxStored is the maximum
+		// number of alerts that can be stored or zero if there is no limit.
+		Count     int `json:"count"`
+		MaxStored int `json:"maxStored"`
+
+		Totals struct {
 			Info     int `json:"info"`
 			Warning  int `json:"warning"`
 			Error    int `json:"error"`
@@ -146,43 +233,199 @@ func (s *Severity) UnmarshalJSON(b []byte) error {
 	return s.LoadString(strings.Trim(string(b), `"`))
 }
 
+// Record returns whether the dismissal should be recorded.
+func (req DismissAlertRequest) Record() bool {
+	return req.Reason != "" || req.DismissedBy != ""
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. For backwards
+// compatibility a plain alert ID is accepted as well.
+func (req *DismissAlertRequest) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		*req = DismissAlertRequest{}
+		return json.Unmarshal(b, &req.AlertID)
+	}
+	type alias DismissAlertRequest
+	return json.Unmarshal(b, (*alias)(req))
+}
+
 // RegisterAlert implements the Alerter interface.
 func (m *Manager) RegisterAlert(ctx context.Context, alert Alert) error {
+	_, _, err := m.registerAlert(alert)
+	return err
+}
+
+// registerAlert registers the given alert, it returns the stored alert and
+// whether it was newly stored or changed. Throttled alerts and alerts that
+// equal the stored alert are not considered changed.
+func (m *Manager) registerAlert(alert Alert) (Alert, bool, error) {
 	if alert.ID == (types.Hash256{}) {
-		return errors.New("cannot register alert with zero id")
+		return Alert{}, false, errors.New("cannot register alert with zero id")
 	} else if alert.Timestamp.IsZero() {
-		return errors.New("cannot register alert with zero timestamp")
+		return Alert{}, false, errors.New("cannot register alert with zero timestamp")
 	} else if alert.Severity == 0 {
-		return errors.New("cannot register alert without severity")
+		return Alert{}, false, errors.New("cannot register alert without severity")
 	} else if alert.Message == "" {
-		return errors.New("cannot register alert without a message")
+		return Alert{}, false, errors.New("cannot register alert without a message")
 	} else if alert.Data == nil || alert.Data["origin"] == "" {
-		return errors.New("caannot register alert without origin")
+		return Alert{}, false, errors.New("caannot register alert without origin")
 	}
 
 	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// throttle alerts that fire too often
+	if m.minAlertInterval > 0 {
+		if lastFired, ok := m.lastFired[alert.ID]; ok && time.Since(lastFired) < m.minAlertInterval {
+			m.skipped[alert.ID]++
+			m.logger.Debugw("skipped throttled alert", "id", alert.ID, "skipped", m.skipped[alert.ID])
+			return Alert{}, false, nil
+		}
+	}
+
+	// update the stored alert of the same group
+	if alert.GroupKey != "" {
+		if id, ok := m.groups[alert.GroupKey]; ok {
+			if m.minAlertInterval > 0 {
+				m.lastFired[alert.ID] = time.Now()
+				delete(m.skipped, alert.ID)
+			}
+			if alert.ID != id {
+				m.members[alert.ID] = id
+			}
+			return m.updateGroupedAlert(id, alert), true, nil
+		}
+		alert.Count = 1
+		alert.UpdatedAt = alert.Timestamp
+	}
+
+	// make room for the alert if necessary
+	if _, exists := m.alerts[alert.ID]; !exists && m.maxStoredAlerts > 0 && len(m.alerts) >= m.maxStoredAlerts {
+		if !m.removeOldestNonCriticalAlert() {
+			m.logger.Warnw("failed to register alert, all stored alerts are critical", "id", alert.ID, "message", alert.Message, "maxStoredAlerts", m.maxStoredAlerts)
+			return Alert{}, false, ErrMaxAlertsReached
+		}
+	}
+
+	if m.minAlertInterval > 0 {
+		m.lastFired[alert.ID] = time.Now()
+		delete(m.skipped, alert.ID)
+	}
+	existing, exists := m.alerts[alert.ID]
+	if exists && existing.GroupKey != alert.GroupKey {
+		delete(m.groups, existing.GroupKey)
+	}
+	if alert.GroupKey != "" {
+		m.groups[alert.GroupKey] = alert.ID
+	}
+	delete(m.members, alert.ID)
+	alert.Data = maps.Clone(alert.Data)
 	m.alerts[alert.ID] = alert
-	m.mu.Unlock()
-	return nil
+
+	changed := !exists || !existing.equal(alert)
+	alert.Data = maps.Clone(alert.Data)
+	return alert, changed, nil
+}
+
+// updateGroupedAlert merges the given alert into the stored alert with the
+// given id, the data of the new alert replaces the stored data and the count
+// is recorded under the "count" key. It returns a copy of the updated alert.
+func (m *Manager) updateGroupedAlert(id types.Hash256, alert Alert) Alert {
+	existing := m.alerts[id]
+	existing.Data = maps.Clone(alert.Data)
+	existing.Count++
+	existing.Data["count"] = existing.Count
+	existing.UpdatedAt = alert.Timestamp
+	m.alerts[id] = existing
+
+	existing.Data = maps.Clone(existing.Data)
+	return existing
+}
+
+// equal returns whether the alerts are equal, ignoring the time they were
+// registered at.
+func (a Alert) equal(b Alert) bool {
+	return a.ID == b.ID &&
+		a.Severity == b.Severity &&
+		a.Message == b.Message &&
+		a.Description == b.Description &&
+		a.Suggestion == b.Suggestion &&
+		a.GroupKey == b.GroupKey &&
+		reflect.DeepEqual(a.Data, b.Data)
+}
+
+// lastUpdated returns the time the alert was last registered, for grouped
+// alerts that is the time the most recent alert of the group was registered.
+func (a Alert) lastUpdated() time.Time {
+	if a.UpdatedAt.After(a.Timestamp) {
+		return a.UpdatedAt
+	}
+	return a.Timestamp
+}
+
+// removeAlert removes the alert with the given id and its group.
+func (m *Manager) removeAlert(id types.Hash256) {
+	if a, ok := m.alerts[id]; ok && a.GroupKey != "" {
+		delete(m.groups, a.GroupKey)
+		maps.DeleteFunc(m.members, func(_, stored types.Hash256) bool {
+			return stored == id
+		})
+	}
+	delete(m.alerts, id)
+}
+
+// removeOldestNonCriticalAlert removes the oldest stored alert that isn't
+// critical, it returns false if there is no such alert.
+func (m *Manager) removeOldestNonCriticalAlert() bool {
+	var oldest *Alert
+	for _, a := range m.alerts {
+		if a.Severity == SeverityCritical {
+			continue
+		} else if oldest == nil || a.lastUpdated().Before(oldest.lastUpdated()) {
+			oldest = &a
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	m.removeAlert(oldest.ID)
+	return true
 }
 
 // DismissAlerts implements the Alerter interface.
 func (m *Manager) DismissAlerts(ctx context.Context, ids ...types.Hash256) error {
-	var dismissed []types.Hash256
+	m.dismissAlerts(ids...)
+	return nil
+}
+
+// dismissAlerts dismisses the alerts with the given ids and returns the ids of
+// the alerts that were actually dismissed. Dismissing an alert that was merged
+// into a grouped alert dismisses the whole group, the returned id is the one of
+// the stored alert.
+func (m *Manager) dismissAlerts(ids ...types.Hash256) (dismissed []types.Hash256) {
 	m.mu.Lock()
 	for _, id := range ids {
+		if stored, ok := m.members[id]; ok {
+			id = stored
+		}
 		_, exists := m.alerts[id]
 		if !exists {
 			continue
 		}
-		delete(m.alerts, id)
+		m.removeAlert(id)
 		dismissed = append(dismissed, id)
 	}
 	if len(m.alerts) == 0 {
 		m.alerts = make(map[types.Hash256]Alert) // reclaim memory
 	}
+	for id, lastFired := range m.lastFired {
+		if time.Since(lastFired) >= m.minAlertInterval {
+			delete(m.lastFired, id)
+			delete(m.skipped, id)
+		}
+	}
 	m.mu.Unlock()
-	return nil
+	return dismissed
 }
 
 // Alerts returns the host's active alerts.
@@ -190,7 +433,10 @@ func (m *Manager) Alerts(_ context.Context, opts AlertsOpts) (AlertsResponse, er
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	resp := AlertsResponse{}
+	resp := AlertsResponse{
+		Count:     len(m.alerts),
+		MaxStored: m.maxStoredAlerts,
+	}
 	filtered := make([]Alert, 0, len(m.alerts))
 	for _, a := range m.alerts {
 		if a.Severity == SeverityInfo {
@@ -205,11 +451,15 @@ func (m *Manager) Alerts(_ context.Context, opts AlertsOpts) (AlertsResponse, er
 		if opts.Severity != 0 && a.Severity != opts.Severity {
 			continue // filter by severity
 		}
+		a.Data = maps.Clone(a.Data) // prevent callers from modifying stored alerts
 		filtered = append(filtered, a)
 	}
 	sort.Slice(filtered, func(i, j int) bool {
-		return filtered[i].Timestamp.After(filtered[j].Timestamp)
+		return filtered[i].lastUpdated().After(filtered[j].lastUpdated())
 	})
+	if len(opts.GroupBy) > 0 {
+		filtered = groupAlerts(filtered, opts.GroupBy)
+	}
 
 	// handle offset
 	if opts.Offset >= len(filtered) {
@@ -227,10 +477,61 @@ func (m *Manager) Alerts(_ context.Context, opts AlertsOpts) (AlertsResponse, er
 	return resp, nil
 }
 
+// groupAlerts collapses alerts with the same message and equal values for all
+// of the given data keys into the first alert of the group, alerts that are
+// missing one of the keys are not grouped. The order of the alerts is
+// preserved.
+func groupAlerts(alerts []Alert, keys []string) []Alert {
+	groups := make(map[string]int)
+	grouped := alerts[:0:0]
+	for _, a := range alerts {
+		fingerprint := []string{a.Message}
+		for _, k := range keys {
+			v, ok := a.Data[k]
+			if !ok {
+				fingerprint = nil
+				break
+			}
+			fingerprint = append(fingerprint, fmt.Sprint(v))
+		}
+
+		count := a.Count
+		if count == 0 {
+			count = 1
+		}
+		if fingerprint == nil {
+			grouped = append(grouped, a)
+			continue
+		}
+
+		key := strings.Join(fingerprint, "\x00")
+		if i, ok := groups[key]; ok {
+			grouped[i].Count += count
+			continue
+		}
+		a.Count = count
+		groups[key] = len(grouped)
+		grouped = append(grouped, a)
+	}
+	return grouped
+}
+
 // NewManager initializes a new alerts manager.
-func NewManager() *Manager {
+func NewManager(cfg Config) *Manager {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
 	return &Manager{
-		alerts: make(map[types.Hash256]Alert),
+		minAlertInterval: cfg.MinAlertInterval,
+		maxStoredAlerts:  cfg.MaxStoredAlerts,
+		logger:           logger.Named("alerts").Sugar(),
+
+		alerts:    make(map[types.Hash256]Alert),
+		lastFired: make(map[types.Hash256]time.Time),
+		skipped:   make(map[types.Hash256]uint64),
+		groups:    make(map[string]types.Hash256),
+		members:   make(map[types.Hash256]types.Hash256),
 	}
 }
 
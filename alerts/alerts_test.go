@@ -2,16 +2,18 @@ package alerts
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/internal/test"
 )
 
 func TestAlertManager(t *testing.T) {
-	mgr := NewManager()
+	mgr := NewManager(Config{})
 
 	var cnt uint8
 	newAlert := func(severity Severity) Alert {
@@ -81,3 +83,322 @@ func TestAlertManager(t *testing.T) {
 		}
 	}
 }
+
+func TestAlertManagerThrottle(t *testing.T) {
+	mgr := NewManager(Config{MinAlertInterval: 100 * time.Millisecond})
+
+	alert := func(msg string) Alert {
+		return Alert{
+			ID:        types.Hash256{1},
+			Severity:  SeverityInfo,
+			Message:   msg,
+			Timestamp: time.Now(),
+			Data:      map[string]any{"origin": t.Name()},
+		}
+	}
+	assertMessage := func(msg string) {
+		t.Helper()
+		res, err := mgr.Alerts(context.Background(), AlertsOpts{})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(res.Alerts) != 1 {
+			t.Fatalf("wrong number of alerts: %v != 1", len(res.Alerts))
+		} else if res.Alerts[0].Message != msg {
+			t.Fatalf("unexpected message: %v != %v", res.Alerts[0].Message, msg)
+		}
+	}
+
+	// register an alert twice, the second registration should be skipped
+	if err := mgr.RegisterAlert(context.Background(), alert("first")); err != nil {
+		t.Fatal(err)
+	} else if err := mgr.RegisterAlert(context.Background(), alert("second")); err != nil {
+		t.Fatal(err)
+	}
+	assertMessage("first")
+	if mgr.skipped[types.Hash256{1}] != 1 {
+		t.Fatal("unexpected skipped count", mgr.skipped[types.Hash256{1}])
+	}
+
+	// after the interval passed the alert should be registered again
+	if err := test.Retry(100, 10*time.Millisecond, func() error {
+		if err := mgr.RegisterAlert(context.Background(), alert("third")); err != nil {
+			t.Fatal(err)
+		}
+		res, err := mgr.Alerts(context.Background(), AlertsOpts{})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(res.Alerts) != 1 || res.Alerts[0].Message != "third" {
+			return errors.New("alert is still throttled")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	assertMessage("third")
+	if _, ok := mgr.skipped[types.Hash256{1}]; ok {
+		t.Fatal("expected skipped count to be reset")
+	}
+}
+
+func TestAlertManagerMaxStoredAlerts(t *testing.T) {
+	mgr := NewManager(Config{MaxStoredAlerts: 3})
+
+	start := time.Now()
+	alert := func(id byte, severity Severity) Alert {
+		return Alert{
+			ID:        types.Hash256{id},
+			Severity:  severity,
+			Message:   fmt.Sprintf("alert %d", id),
+			Timestamp: start.Add(time.Duration(id) * time.Second),
+			Data:      map[string]any{"origin": t.Name()},
+		}
+	}
+	assertAlerts := func(ids ...byte) {
+		t.Helper()
+		res, err := mgr.Alerts(context.Background(), AlertsOpts{})
+		if err != nil {
+			t.Fatal(err)
+		} else if res.Count != len(ids) || res.MaxStored != 3 {
+			t.Fatalf("unexpected count %v/%v", res.Count, res.MaxStored)
+		}
+		for _, id := range ids {
+			if _, ok := mgr.alerts[types.Hash256{id}]; !ok {
+				t.Fatalf("alert %d not found", id)
+			}
+		}
+	}
+
+	// fill up the manager
+	for i, severity := range []Severity{SeverityCritical, SeverityInfo, SeverityWarning} {
+		if err := mgr.RegisterAlert(context.Background(), alert(byte(i+1), severity)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertAlerts(1, 2, 3)
+
+	// updating an existing alert doesn't remove any alerts
+	if err := mgr.RegisterAlert(context.Background(), alert(2, SeverityInfo)); err != nil {
+		t.Fatal(err)
+	}
+	assertAlerts(1, 2, 3)
+
+	// registering new alerts removes the oldest non-critical ones
+	if err := mgr.RegisterAlert(context.Background(), alert(4, SeverityCritical)); err != nil {
+		t.Fatal(err)
+	}
+	assertAlerts(1, 3, 4)
+	if err := mgr.RegisterAlert(context.Background(), alert(5, SeverityCritical)); err != nil {
+		t.Fatal(err)
+	}
+	assertAlerts(1, 4, 5)
+
+	// once all alerts are critical, registering fails
+	if err := mgr.RegisterAlert(context.Background(), alert(6, SeverityInfo)); !errors.Is(err, ErrMaxAlertsReached) {
+		t.Fatal("unexpected error", err)
+	}
+	assertAlerts(1, 4, 5)
+}
+
+func TestAlertManagerGroupKey(t *testing.T) {
+	mgr := NewManager(Config{})
+
+	newAlert := func(id byte, groupKey string, data map[string]any) Alert {
+		data["origin"] = t.Name()
+		return Alert{
+			ID:        types.Hash256{id},
+			Severity:  SeverityWarning,
+			Message:   "alert",
+			Timestamp: time.Now(),
+			Data:      data,
+			GroupKey:  groupKey,
+		}
+	}
+
+	// register three alerts of the same group and one without a group
+	for i := byte(1); i <= 3; i++ {
+		if err := mgr.RegisterAlert(context.Background(), newAlert(i, "group", map[string]any{"last": i, fmt.Sprint(i): i})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mgr.RegisterAlert(context.Background(), newAlert(4, "", map[string]any{})); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the group was stored as a single alert
+	res, err := mgr.Alerts(context.Background(), AlertsOpts{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(res.Alerts) != 2 {
+		t.Fatalf("expected 2 alerts, got %d", len(res.Alerts))
+	}
+	var grouped Alert
+	for _, a := range res.Alerts {
+		if a.GroupKey == "group" {
+			grouped = a
+		} else if a.Count != 0 {
+			t.Fatalf("ungrouped alert should have no count, got %d", a.Count)
+		}
+	}
+	if grouped.ID != (types.Hash256{1}) {
+		t.Fatalf("expected the first alert of the group to be stored, got %v", grouped.ID)
+	} else if grouped.Count != 3 || grouped.Data["count"] != 3 {
+		t.Fatalf("unexpected count %d %v", grouped.Count, grouped.Data["count"])
+	} else if grouped.Data["last"] != byte(3) {
+		t.Fatalf("expected data to be replaced, got %v", grouped.Data["last"])
+	} else if _, ok := grouped.Data["1"]; ok {
+		t.Fatal("expected data of previous alerts to be dropped")
+	} else if !grouped.UpdatedAt.After(grouped.Timestamp) {
+		t.Fatal("expected updatedAt to be after timestamp")
+	}
+
+	// register another alert of the group, it should be sorted by the time
+	// it was last updated
+	if err := mgr.RegisterAlert(context.Background(), newAlert(6, "group", map[string]any{"last": 6})); err != nil {
+		t.Fatal(err)
+	}
+	res, err = mgr.Alerts(context.Background(), AlertsOpts{})
+	if err != nil {
+		t.Fatal(err)
+	} else if a := res.Alerts[0]; a.ID != grouped.ID || a.Count != 4 {
+		t.Fatalf("expected the group to be the most recent alert, got %v with count %d", a.ID, a.Count)
+	}
+
+	// assert modifying the returned alert doesn't modify the stored one
+	res.Alerts[0].Data["last"] = "modified"
+	res, err = mgr.Alerts(context.Background(), AlertsOpts{})
+	if err != nil {
+		t.Fatal(err)
+	} else if res.Alerts[0].Data["last"] != 6 {
+		t.Fatalf("expected stored alert to be unmodified, got %v", res.Alerts[0].Data["last"])
+	}
+
+	// dismiss the group by the id of one of its members, the next alert
+	// should start a new group
+	if dismissed := mgr.dismissAlerts(types.Hash256{3}); len(dismissed) != 1 || dismissed[0] != grouped.ID {
+		t.Fatalf("expected the group to be dismissed, got %v", dismissed)
+	} else if len(mgr.members) != 0 {
+		t.Fatalf("expected members to be removed, got %v", mgr.members)
+	} else if err := mgr.DismissAlerts(context.Background(), grouped.ID); err != nil {
+		t.Fatal(err)
+	} else if err := mgr.RegisterAlert(context.Background(), newAlert(5, "group", map[string]any{})); err != nil {
+		t.Fatal(err)
+	}
+	res, err = mgr.Alerts(context.Background(), AlertsOpts{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(res.Alerts) != 2 {
+		t.Fatalf("expected 2 alerts, got %d", len(res.Alerts))
+	} else if a := res.Alerts[0]; a.ID != (types.Hash256{5}) || a.Count != 1 {
+		t.Fatalf("unexpected alert %v with count %d", a.ID, a.Count)
+	}
+}
+
+func TestAlertManagerGroupKeyThrottle(t *testing.T) {
+	mgr := NewManager(Config{MinAlertInterval: time.Hour})
+
+	alert := func(id byte) Alert {
+		return Alert{
+			ID:        types.Hash256{id},
+			Severity:  SeverityWarning,
+			Message:   "alert",
+			Timestamp: time.Now(),
+			Data:      map[string]any{"origin": t.Name()},
+			GroupKey:  "group",
+		}
+	}
+
+	// register two alerts of the same group and re-register the second one,
+	// the re-registration should be throttled
+	for _, id := range []byte{1, 2, 2} {
+		if err := mgr.RegisterAlert(context.Background(), alert(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := mgr.Alerts(context.Background(), AlertsOpts{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(res.Alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(res.Alerts))
+	} else if res.Alerts[0].Count != 2 {
+		t.Fatalf("expected count to be 2, got %d", res.Alerts[0].Count)
+	} else if mgr.skipped[types.Hash256{2}] != 1 {
+		t.Fatal("unexpected skipped count", mgr.skipped[types.Hash256{2}])
+	}
+}
+
+func TestAlertManagerGroupBy(t *testing.T) {
+	mgr := NewManager(Config{})
+
+	now := time.Now()
+	register := func(id byte, message string, data map[string]any) {
+		t.Helper()
+		data["origin"] = t.Name()
+		if err := mgr.RegisterAlert(context.Background(), Alert{
+			ID:        types.Hash256{id},
+			Severity:  SeverityWarning,
+			Message:   message,
+			Timestamp: now.Add(time.Duration(id) * time.Second),
+			Data:      data,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	register(1, "lost sectors", map[string]any{"hostKey": "a"})
+	register(2, "lost sectors", map[string]any{"hostKey": "a"})
+	register(3, "lost sectors", map[string]any{"hostKey": "b"})
+	register(4, "renewal failed", map[string]any{"hostKey": "a"})
+	register(5, "lost sectors", map[string]any{})
+
+	res, err := mgr.Alerts(context.Background(), AlertsOpts{GroupBy: []string{"hostKey"}})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(res.Alerts) != 4 {
+		t.Fatalf("expected 4 alerts, got %d", len(res.Alerts))
+	} else if res.Count != 5 {
+		t.Fatalf("expected count to include all stored alerts, got %d", res.Count)
+	}
+
+	// alerts are sorted by timestamp, the group is represented by its most
+	// recent alert and alerts without the key aren't grouped
+	expected := []struct {
+		id    byte
+		count int
+	}{{5, 0}, {4, 1}, {3, 1}, {2, 2}}
+	for i, a := range res.Alerts {
+		if a.ID != (types.Hash256{expected[i].id}) || a.Count != expected[i].count {
+			t.Fatalf("%d: unexpected alert %v with count %d", i, a.ID, a.Count)
+		}
+	}
+}
+
+func TestDismissAlertRequestUnmarshalJSON(t *testing.T) {
+	id := types.Hash256{1, 2, 3}
+
+	// plain IDs are still supported
+	js, err := json.Marshal([]types.Hash256{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reqs []DismissAlertRequest
+	if err := json.Unmarshal(js, &reqs); err != nil {
+		t.Fatal(err)
+	} else if len(reqs) != 1 || reqs[0] != (DismissAlertRequest{AlertID: id}) {
+		t.Fatal("unexpected request", reqs)
+	} else if reqs[0].Record() {
+		t.Fatal("plain dismissal shouldn't be recorded")
+	}
+
+	// requests with a reason
+	req := DismissAlertRequest{AlertID: id, Reason: "false positive", DismissedBy: "operator"}
+	js, err = json.Marshal([]DismissAlertRequest{req})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(js, &reqs); err != nil {
+		t.Fatal(err)
+	} else if len(reqs) != 1 || reqs[0] != req {
+		t.Fatal("unexpected request", reqs)
+	} else if !reqs[0].Record() {
+		t.Fatal("dismissal should be recorded")
+	}
+}
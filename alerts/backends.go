@@ -0,0 +1,402 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/internal/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// AlerterTypeLog is the type of a backend that logs alerts.
+	AlerterTypeLog = "log"
+	// AlerterTypeWebhook is the type of a backend that posts alerts to a
+	// webhook.
+	AlerterTypeWebhook = "webhook"
+	// AlerterTypePagerDuty is the type of a backend that sends alerts to
+	// PagerDuty using its Events API.
+	AlerterTypePagerDuty = "pagerduty"
+
+	// WebhookEventRegister is the event posted to a webhook when an alert is
+	// registered.
+	WebhookEventRegister = "register"
+	// WebhookEventDismiss is the event posted to a webhook when alerts are
+	// dismissed.
+	WebhookEventDismiss = "dismiss"
+
+	// PagerDutyEventsURL is the endpoint of the PagerDuty Events API v2.
+	PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+	backendRequestTimeout = 10 * time.Second
+
+	// backendQueueSize is the number of events that can be queued for a
+	// backend before events are dropped.
+	backendQueueSize = 1000
+)
+
+type (
+	// A Backend is notified about alerts that are registered and dismissed.
+	Backend interface {
+		RegisterAlert(_ context.Context, a Alert) error
+		DismissAlerts(_ context.Context, ids ...types.Hash256) error
+	}
+
+	// AlerterConfig configures a backend alerts are sent to. Type is one of
+	// the AlerterType constants, the other fields only apply to some types.
+	AlerterConfig struct {
+		Type string
+
+		// URL is the url of the webhook for webhook backends. For PagerDuty
+		// backends it overrides the url of the Events API.
+		URL string
+		// RoutingKey is the integration key of the PagerDuty service.
+		RoutingKey string
+	}
+
+	// A MultiAlerter stores alerts in a primary Manager and forwards alerts
+	// that were newly stored or changed, and alerts that were dismissed, to a
+	// set of secondary backends. Backends are notified asynchronously, failing
+	// to notify a backend is logged but doesn't fail the operation.
+	MultiAlerter struct {
+		primary  *Manager
+		backends []Backend
+		logger   *zap.SugaredLogger
+
+		shutdownCtx       context.Context
+		shutdownCtxCancel context.CancelFunc
+		wg                sync.WaitGroup
+
+		mu     sync.Mutex
+		closed bool
+		queues []chan backendEvent
+	}
+
+	// backendEvent is either a registered alert or a list of dismissed alert
+	// ids that is forwarded to a backend.
+	backendEvent struct {
+		alert *Alert
+		ids   []types.Hash256
+	}
+
+	// A LogAlerter logs alerts at the level that corresponds to their
+	// severity.
+	LogAlerter struct {
+		logger *zap.SugaredLogger
+	}
+
+	// A WebhookAlerter posts a WebhookEvent to a url whenever alerts are
+	// registered or dismissed.
+	WebhookAlerter struct {
+		url string
+	}
+
+	// A PagerDutyAlerter triggers a PagerDuty incident for every registered
+	// alert and resolves it when the alert is dismissed.
+	PagerDutyAlerter struct {
+		url        string
+		routingKey string
+	}
+
+	// WebhookEvent is the payload posted by a WebhookAlerter.
+	WebhookEvent struct {
+		Event string          `json:"event"`
+		Alert *Alert          `json:"alert,omitempty"`
+		IDs   []types.Hash256 `json:"ids,omitempty"`
+	}
+
+	pagerDutyEvent struct {
+		RoutingKey  string            `json:"routing_key"`
+		EventAction string            `json:"event_action"`
+		DedupKey    string            `json:"dedup_key"`
+		Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	}
+
+	pagerDutyPayload struct {
+		Summary       string         `json:"summary"`
+		Source        string         `json:"source"`
+		Severity      string         `json:"severity"`
+		Timestamp     time.Time      `json:"timestamp"`
+		CustomDetails map[string]any `json:"custom_details,omitempty"`
+	}
+)
+
+var (
+	_ Alerter = (*MultiAlerter)(nil)
+	_ Backend = (*LogAlerter)(nil)
+	_ Backend = (*WebhookAlerter)(nil)
+	_ Backend = (*PagerDutyAlerter)(nil)
+)
+
+// NewMultiAlerter returns an Alerter that stores alerts in the primary
+// manager and forwards them to the given backends.
+func NewMultiAlerter(primary *Manager, logger *zap.Logger, backends ...Backend) *MultiAlerter {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &MultiAlerter{
+		primary:  primary,
+		backends: backends,
+		logger:   logger.Named("alerts").Sugar(),
+
+		shutdownCtx:       ctx,
+		shutdownCtxCancel: cancel,
+	}
+	for _, b := range backends {
+		q := make(chan backendEvent, backendQueueSize)
+		a.queues = append(a.queues, q)
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.processEvents(b, q)
+		}()
+	}
+	return a
+}
+
+// NewBackend creates the alerts backend described by the given config.
+func NewBackend(cfg AlerterConfig, logger *zap.Logger) (Backend, error) {
+	switch cfg.Type {
+	case AlerterTypeLog:
+		return NewLogAlerter(logger), nil
+	case AlerterTypeWebhook:
+		if cfg.URL == "" {
+			return nil, errors.New("webhook alerter requires a url")
+		}
+		return NewWebhookAlerter(cfg.URL), nil
+	case AlerterTypePagerDuty:
+		if cfg.RoutingKey == "" {
+			return nil, errors.New("pagerduty alerter requires a routing key")
+		}
+		url := cfg.URL
+		if url == "" {
+			url = PagerDutyEventsURL
+		}
+		return NewPagerDutyAlerter(url, cfg.RoutingKey), nil
+	default:
+		return nil, fmt.Errorf("unknown alerter type '%s', must be one of [%s, %s, %s]", cfg.Type, AlerterTypeLog, AlerterTypeWebhook, AlerterTypePagerDuty)
+	}
+}
+
+// NewLogAlerter returns a backend that logs alerts using the given logger.
+func NewLogAlerter(logger *zap.Logger) *LogAlerter {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &LogAlerter{logger: logger.Named("alerts").Sugar()}
+}
+
+// NewWebhookAlerter returns a backend that posts alerts to the given url.
+func NewWebhookAlerter(url string) *WebhookAlerter {
+	return &WebhookAlerter{url: url}
+}
+
+// NewPagerDutyAlerter returns a backend that sends alerts to the PagerDuty
+// Events API at the given url using the given routing key.
+func NewPagerDutyAlerter(url, routingKey string) *PagerDutyAlerter {
+	return &PagerDutyAlerter{
+		url:        url,
+		routingKey: routingKey,
+	}
+}
+
+// Alerts implements the Alerter interface.
+func (a *MultiAlerter) Alerts(ctx context.Context, opts AlertsOpts) (AlertsResponse, error) {
+	return a.primary.Alerts(ctx, opts)
+}
+
+// RegisterAlert implements the Alerter interface.
+func (a *MultiAlerter) RegisterAlert(ctx context.Context, alert Alert) error {
+	stored, changed, err := a.primary.registerAlert(alert)
+	if err != nil {
+		return err
+	} else if changed {
+		a.forward(backendEvent{alert: &stored})
+	}
+	return nil
+}
+
+// DismissAlerts implements the Alerter interface.
+func (a *MultiAlerter) DismissAlerts(ctx context.Context, ids ...types.Hash256) error {
+	if dismissed := a.primary.dismissAlerts(ids...); len(dismissed) > 0 {
+		a.forward(backendEvent{ids: dismissed})
+	}
+	return nil
+}
+
+// Shutdown stops forwarding alerts and waits for the queued events to be
+// forwarded to the backends.
+func (a *MultiAlerter) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		for _, q := range a.queues {
+			close(q)
+		}
+	}
+	a.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+
+	defer a.shutdownCtxCancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// forward queues the event for every backend, backends with a full queue
+// miss the event.
+func (a *MultiAlerter) forward(e backendEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return
+	}
+	for i, q := range a.queues {
+		select {
+		case q <- e:
+		default:
+			a.logger.Warnw("failed to forward alert event, queue is full", "backend", fmt.Sprintf("%T", a.backends[i]))
+		}
+	}
+}
+
+// processEvents forwards the events of the given queue to the backend until
+// the queue is closed.
+func (a *MultiAlerter) processEvents(b Backend, q <-chan backendEvent) {
+	for e := range q {
+		if e.alert != nil {
+			if err := b.RegisterAlert(a.shutdownCtx, *e.alert); err != nil {
+				a.logger.Warnw("failed to forward alert", "id", e.alert.ID, "backend", fmt.Sprintf("%T", b), zap.Error(err))
+			}
+		} else if err := b.DismissAlerts(a.shutdownCtx, e.ids...); err != nil {
+			a.logger.Warnw("failed to forward alert dismissal", "ids", e.ids, "backend", fmt.Sprintf("%T", b), zap.Error(err))
+		}
+	}
+}
+
+// RegisterAlert implements the Backend interface.
+func (a *LogAlerter) RegisterAlert(_ context.Context, alert Alert) error {
+	fields := []any{"id", alert.ID, "severity", alert.Severity.String()}
+	if alert.Description != "" {
+		fields = append(fields, "description", alert.Description)
+	}
+	for k, v := range alert.Data {
+		fields = append(fields, k, v)
+	}
+
+	switch alert.Severity {
+	case SeverityCritical, SeverityError:
+		a.logger.Errorw(alert.Message, fields...)
+	case SeverityWarning:
+		a.logger.Warnw(alert.Message, fields...)
+	default:
+		a.logger.Infow(alert.Message, fields...)
+	}
+	return nil
+}
+
+// DismissAlerts implements the Backend interface.
+func (a *LogAlerter) DismissAlerts(_ context.Context, ids ...types.Hash256) error {
+	a.logger.Infow("alerts dismissed", "ids", ids)
+	return nil
+}
+
+// RegisterAlert implements the Backend interface.
+func (a *WebhookAlerter) RegisterAlert(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, a.url, WebhookEvent{
+		Event: WebhookEventRegister,
+		Alert: &alert,
+	})
+}
+
+// DismissAlerts implements the Backend interface.
+func (a *WebhookAlerter) DismissAlerts(ctx context.Context, ids ...types.Hash256) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return postJSON(ctx, a.url, WebhookEvent{
+		Event: WebhookEventDismiss,
+		IDs:   ids,
+	})
+}
+
+// RegisterAlert implements the Backend interface. The alert's ID is used as
+// the deduplication key of the incident.
+func (a *PagerDutyAlerter) RegisterAlert(ctx context.Context, alert Alert) error {
+	source := "renterd"
+	if origin, ok := alert.Data["origin"].(string); ok && origin != "" {
+		source = origin
+	}
+	details := make(map[string]any, len(alert.Data)+2)
+	for k, v := range alert.Data {
+		details[k] = v
+	}
+	if alert.Description != "" {
+		details["description"] = alert.Description
+	}
+	if alert.Suggestion != "" {
+		details["suggestion"] = alert.Suggestion
+	}
+	return postJSON(ctx, a.url, pagerDutyEvent{
+		RoutingKey:  a.routingKey,
+		EventAction: "trigger",
+		DedupKey:    alert.ID.String(),
+		Payload: &pagerDutyPayload{
+			Summary:       alert.Message,
+			Source:        source,
+			Severity:      alert.Severity.String(),
+			Timestamp:     alert.Timestamp,
+			CustomDetails: details,
+		},
+	})
+}
+
+// DismissAlerts implements the Backend interface, it resolves the incidents
+// of the dismissed alerts.
+func (a *PagerDutyAlerter) DismissAlerts(ctx context.Context, ids ...types.Hash256) error {
+	var errs []error
+	for _, id := range ids {
+		if err := postJSON(ctx, a.url, pagerDutyEvent{
+			RoutingKey:  a.routingKey,
+			EventAction: "resolve",
+			DedupKey:    id.String(),
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to resolve alert %v: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func postJSON(ctx context.Context, url string, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, backendRequestTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, _, err = utils.DoRequest(req, nil)
+	return err
+}
@@ -0,0 +1,134 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+)
+
+func TestMultiAlerter(t *testing.T) {
+	// create a server that records the requests it receives
+	var mu sync.Mutex
+	var webhookEvents []WebhookEvent
+	var pagerDutyEvents []pagerDutyEvent
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {
+		var event WebhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		webhookEvents = append(webhookEvents, event)
+		mu.Unlock()
+	})
+	mux.HandleFunc("/pagerduty", func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		pagerDutyEvents = append(pagerDutyEvents, event)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// create the backends, the broken webhook comes first to assert its
+	// failure doesn't prevent the other backends from being notified
+	var backends []Backend
+	for _, cfg := range []AlerterConfig{
+		{Type: AlerterTypeWebhook, URL: srv.URL + "/broken"},
+		{Type: AlerterTypeLog},
+		{Type: AlerterTypeWebhook, URL: srv.URL + "/webhook"},
+		{Type: AlerterTypePagerDuty, URL: srv.URL + "/pagerduty", RoutingKey: "key"},
+	} {
+		b, err := NewBackend(cfg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backends = append(backends, b)
+	}
+	mgr := NewManager(Config{})
+	a := NewMultiAlerter(mgr, nil, backends...)
+
+	// assert invalid configs are rejected
+	if _, err := NewBackend(AlerterConfig{Type: "foo"}, nil); err == nil {
+		t.Fatal("expected error")
+	} else if _, err := NewBackend(AlerterConfig{Type: AlerterTypeWebhook}, nil); err == nil {
+		t.Fatal("expected error")
+	} else if _, err := NewBackend(AlerterConfig{Type: AlerterTypePagerDuty}, nil); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// register an alert
+	alert := Alert{
+		ID:        types.Hash256{1},
+		Severity:  SeverityError,
+		Message:   "alert",
+		Timestamp: time.Now(),
+		Data:      map[string]any{"origin": t.Name(), "foo": "bar"},
+	}
+	if err := a.RegisterAlert(context.Background(), alert); err != nil {
+		t.Fatal(err)
+	}
+
+	// register it again, it's unchanged so it shouldn't be forwarded
+	alert.Timestamp = time.Now()
+	if err := a.RegisterAlert(context.Background(), alert); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert it was stored
+	if res, err := a.Alerts(context.Background(), AlertsOpts{}); err != nil {
+		t.Fatal(err)
+	} else if len(res.Alerts) != 1 || res.Alerts[0].ID != alert.ID {
+		t.Fatalf("unexpected alerts %+v", res.Alerts)
+	}
+
+	// dismiss it together with an unknown alert, only the alert that was
+	// actually dismissed should be forwarded
+	if err := a.DismissAlerts(context.Background(), alert.ID, types.Hash256{2}); err != nil {
+		t.Fatal(err)
+	} else if err := a.DismissAlerts(context.Background(), alert.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// wait for the events to be forwarded
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the webhook was notified
+	mu.Lock()
+	defer mu.Unlock()
+	if len(webhookEvents) != 2 {
+		t.Fatalf("expected 2 webhook events, got %d", len(webhookEvents))
+	} else if e := webhookEvents[0]; e.Event != WebhookEventRegister || e.Alert == nil || e.Alert.ID != alert.ID {
+		t.Fatalf("unexpected event %+v", e)
+	} else if e := webhookEvents[1]; e.Event != WebhookEventDismiss || len(e.IDs) != 1 || e.IDs[0] != alert.ID {
+		t.Fatalf("unexpected event %+v", e)
+	}
+
+	// assert PagerDuty was notified
+	if len(pagerDutyEvents) != 2 {
+		t.Fatalf("expected 2 pagerduty events, got %d", len(pagerDutyEvents))
+	} else if e := pagerDutyEvents[0]; e.EventAction != "trigger" || e.RoutingKey != "key" || e.DedupKey != alert.ID.String() || e.Payload == nil {
+		t.Fatalf("unexpected event %+v", e)
+	} else if e.Payload.Summary != alert.Message || e.Payload.Severity != "error" || e.Payload.Source != t.Name() || e.Payload.CustomDetails["foo"] != "bar" {
+		t.Fatalf("unexpected payload %+v", e.Payload)
+	} else if e := pagerDutyEvents[1]; e.EventAction != "resolve" || e.DedupKey != alert.ID.String() || e.Payload != nil {
+		t.Fatalf("unexpected event %+v", e)
+	}
+}
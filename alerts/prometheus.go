@@ -20,5 +20,12 @@ func (a Alert) PrometheusMetric() (metrics []prometheus.Metric) {
 // PrometheusMetric implements prometheus.Marshaller.
 func (a AlertsResponse) PrometheusMetric() (metrics []prometheus.Metric) {
 	metrics = prometheus.Slice(a.Alerts).PrometheusMetric()
+	metrics = append(metrics, prometheus.Metric{
+		Name:  "renterd_alerts_count",
+		Value: float64(a.Count),
+	}, prometheus.Metric{
+		Name:  "renterd_alerts_maxstored",
+		Value: float64(a.MaxStored),
+	})
 	return
 }
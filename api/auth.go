@@ -1,15 +1,20 @@
 package api
 
 import (
+	"crypto/subtle"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
-	"go.sia.tech/jape"
+	"go.sia.tech/core/types"
 	"lukechampine.com/frand"
 )
 
@@ -18,6 +23,13 @@ const (
 	authQueryParam = "apikey"
 )
 
+// APIKeyRotateResponse is the response type for the /admin/apikeys/rotate
+// endpoint.
+type APIKeyRotateResponse struct {
+	Key               string      `json:"key"`
+	PreviousKeyExpiry TimeRFC3339 `json:"previousKeyExpiry"`
+}
+
 type TokenStore struct {
 	mu     sync.Mutex
 	tokens map[string]time.Time
@@ -48,10 +60,199 @@ func (s *TokenStore) Validate(token string) bool {
 	return validToken
 }
 
+// Clear invalidates all tokens.
+func (s *TokenStore) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]time.Time)
+}
+
 func NewTokenStore() *TokenStore {
 	return &TokenStore{tokens: make(map[string]time.Time)}
 }
 
+// A KeyStore keeps track of the API keys that are accepted for basic auth.
+// Only the hashes of the keys are kept. When a key is rotated, the previous key
+// remains valid until its grace period expires. If the store was loaded from a
+// file, rotated keys are persisted and remain valid after a restart as long as
+// the configured password doesn't change.
+type KeyStore struct {
+	path     string
+	password types.Hash256
+
+	mu             sync.Mutex
+	current        types.Hash256
+	previous       types.Hash256
+	previousExpiry time.Time
+
+	// internal is a key for clients within the same process, it is never
+	// rotated to prevent rotations from locking out these clients.
+	internal string
+}
+
+// persistedKeys is the on-disk representation of a KeyStore, only the hashes
+// of the keys are persisted.
+type persistedKeys struct {
+	Password       types.Hash256 `json:"password"`
+	Current        types.Hash256 `json:"current"`
+	Previous       types.Hash256 `json:"previous"`
+	PreviousExpiry time.Time     `json:"previousExpiry"`
+}
+
+// NewKeyStore returns a KeyStore that accepts the given password, rotated keys
+// are not persisted.
+func NewKeyStore(password string) *KeyStore {
+	return &KeyStore{
+		password: hashKey(password),
+		current:  hashKey(password),
+		internal: hex.EncodeToString(frand.Bytes(16)),
+	}
+}
+
+// LoadKeyStore returns a KeyStore that persists rotated keys to the given
+// path. If the file contains keys that were rotated from the given password,
+// the rotated keys are loaded, otherwise the password is the only valid key.
+func LoadKeyStore(password, path string) (*KeyStore, error) {
+	ks := NewKeyStore(password)
+	ks.path = path
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ks, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read keys: %w", err)
+	}
+
+	var pk persistedKeys
+	if err := json.Unmarshal(b, &pk); err != nil {
+		return nil, fmt.Errorf("failed to decode keys: %w", err)
+	} else if pk.Password == ks.password {
+		ks.current = pk.Current
+		ks.previous = pk.Previous
+		ks.previousExpiry = pk.PreviousExpiry
+	}
+	return ks, nil
+}
+
+// InternalKey returns the key used by clients within the same process.
+func (ks *KeyStore) InternalKey() string {
+	return ks.internal
+}
+
+// Rotate generates a new key and replaces the current one. The current key
+// remains valid for the given grace period, any key that was rotated before is
+// invalidated immediately.
+func (ks *KeyStore) Rotate(gracePeriod time.Duration) (key string, previousExpiry time.Time, _ error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	key = hex.EncodeToString(frand.Bytes(32))
+	pk := persistedKeys{
+		Password:       ks.password,
+		Current:        hashKey(key),
+		Previous:       ks.current,
+		PreviousExpiry: time.Now().Add(gracePeriod),
+	}
+	if err := ks.persist(pk); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to persist keys: %w", err)
+	}
+
+	ks.current = pk.Current
+	ks.previous = pk.Previous
+	ks.previousExpiry = pk.PreviousExpiry
+	return key, ks.previousExpiry, nil
+}
+
+// persist writes the given keys to the store's path, it's a no-op if the
+// store wasn't loaded from a file.
+func (ks *KeyStore) persist(pk persistedKeys) error {
+	if ks.path == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(pk)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ks.path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmpFilePath := ks.path + ".tmp"
+	f, err := os.OpenFile(tmpFilePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	} else if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync file: %w", err)
+	} else if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	} else if err := os.Rename(tmpFilePath, ks.path); err != nil {
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+	return nil
+}
+
+// Validate returns true if the given key is valid.
+func (ks *KeyStore) Validate(key string) bool {
+	if subtle.ConstantTimeCompare([]byte(key), []byte(ks.internal)) == 1 {
+		return true
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	h := hashKey(key)
+	if subtle.ConstantTimeCompare(h[:], ks.current[:]) == 1 {
+		return true
+	}
+	return time.Now().Before(ks.previousExpiry) && subtle.ConstantTimeCompare(h[:], ks.previous[:]) == 1
+}
+
+// ValidateCurrent returns true if the given key is either the current or the
+// internal key, unlike Validate it doesn't accept the previous key during its
+// grace period.
+func (ks *KeyStore) ValidateCurrent(key string) bool {
+	if subtle.ConstantTimeCompare([]byte(key), []byte(ks.internal)) == 1 {
+		return true
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	h := hashKey(key)
+	return subtle.ConstantTimeCompare(h[:], ks.current[:]) == 1
+}
+
+func hashKey(key string) types.Hash256 {
+	return types.HashBytes([]byte(key))
+}
+
+// basicAuth wraps an http.Handler to force authentication with a basic auth
+// password that is accepted by the given KeyStore.
+func basicAuth(keys *KeyStore) func(http.Handler) http.Handler {
+	return basicAuthFn(keys.Validate)
+}
+
+// basicAuthFn wraps an http.Handler to force authentication with a basic auth
+// password that is accepted by the given validation function.
+func basicAuthFn(validate func(string) bool) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if _, p, ok := req.BasicAuth(); !ok || !validate(p) {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
 func httpWriteError(w http.ResponseWriter, msg string, statusCode int) {
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(statusCode)
@@ -60,7 +261,7 @@ func httpWriteError(w http.ResponseWriter, msg string, statusCode int) {
 
 // Auth wraps an http.Handler to force authentication with either a basic auth
 // password or a cookie.
-func Auth(tokens *TokenStore, password string) func(http.Handler) http.Handler {
+func Auth(tokens *TokenStore, keys *KeyStore) func(http.Handler) http.Handler {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			if req.URL.Query().Has(authQueryParam) {
@@ -84,16 +285,16 @@ func Auth(tokens *TokenStore, password string) func(http.Handler) http.Handler {
 				}
 			} else {
 				// try basic auth
-				jape.BasicAuth(password)(h).ServeHTTP(w, req)
+				basicAuth(keys)(h).ServeHTTP(w, req)
 			}
 		})
 	}
 }
 
-func AuthHandler(tokens *TokenStore, password string) http.Handler {
-	// NOTE: we use BasicAuth instead of Auth since only basic auth should be
+func AuthHandler(tokens *TokenStore, keys *KeyStore) http.Handler {
+	// NOTE: we use basicAuth instead of Auth since only basic auth should be
 	// allowed to create a new token
-	return jape.BasicAuth(password)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	return basicAuth(keys)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if req.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return // only POST is allowed
@@ -150,15 +351,45 @@ func AuthHandler(tokens *TokenStore, password string) http.Handler {
 	}))
 }
 
+// RotateKeyHandler returns a handler that rotates the API key. The new key is
+// returned in the response, the previous key remains valid for the given grace
+// period. Only the current key is allowed to rotate. All tokens are invalidated, so sessions that were authenticated with
+// the previous key have to authenticate again.
+func RotateKeyHandler(tokens *TokenStore, keys *KeyStore, gracePeriod time.Duration) http.Handler {
+	// NOTE: we use basicAuth instead of Auth since only basic auth should be
+	// allowed to rotate the key, the previous key is not accepted to prevent a
+	// leaked key from being used to lock out the owner's current key
+	return basicAuthFn(keys.ValidateCurrent)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return // only POST is allowed
+		}
+
+		key, expiry, err := keys.Rotate(gracePeriod)
+		if err != nil {
+			httpWriteError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		tokens.Clear()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(APIKeyRotateResponse{
+			Key:               key,
+			PreviousKeyExpiry: TimeRFC3339(expiry),
+		})
+	}))
+}
+
 // WorkerAuth is a wrapper for Auth that allows unauthenticated downloads if
 // 'unauthenticatedDownloads' is true.
-func WorkerAuth(tokens *TokenStore, password string, unauthenticatedDownloads bool) func(http.Handler) http.Handler {
+func WorkerAuth(tokens *TokenStore, keys *KeyStore, unauthenticatedDownloads bool) func(http.Handler) http.Handler {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			if unauthenticatedDownloads && req.Method == http.MethodGet && strings.HasPrefix(req.URL.Path, "/object/") {
 				h.ServeHTTP(w, req)
 			} else {
-				Auth(tokens, password)(h).ServeHTTP(w, req)
+				Auth(tokens, keys)(h).ServeHTTP(w, req)
 			}
 		})
 	}
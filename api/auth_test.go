@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -13,7 +14,7 @@ func TestAuth(t *testing.T) {
 	// test server with authenticated route that returns '200 OK'
 	pw := "password"
 	tokens := NewTokenStore()
-	srv := httptest.NewServer(Auth(tokens, pw)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	srv := httptest.NewServer(Auth(tokens, NewKeyStore(pw))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})))
 	defer srv.Close()
@@ -57,7 +58,7 @@ func TestAuth(t *testing.T) {
 		t.Helper()
 
 		// fake a server to get the auth token from
-		authSrv := httptest.NewServer(AuthHandler(tokens, pw))
+		authSrv := httptest.NewServer(AuthHandler(tokens, NewKeyStore(pw)))
 		defer authSrv.Close()
 		req, err := http.NewRequest("POST", authSrv.URL+"?validity=1000", http.NoBody)
 		if err != nil {
@@ -137,3 +138,151 @@ func TestAuth(t *testing.T) {
 		req.AddCookie(cookie)
 	})
 }
+
+func TestKeyStoreRotate(t *testing.T) {
+	keys := NewKeyStore("password")
+	if !keys.Validate("password") {
+		t.Fatal("expected password to be valid")
+	} else if keys.Validate("foo") {
+		t.Fatal("expected invalid password")
+	} else if !keys.Validate(keys.InternalKey()) {
+		t.Fatal("expected internal key to be valid")
+	}
+
+	// rotate the key, both keys should be valid during the grace period
+	key, expiry, err := keys.Rotate(100 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	} else if key == "password" || len(key) != 64 {
+		t.Fatal("unexpected key", key)
+	} else if time.Until(expiry) > 100*time.Millisecond {
+		t.Fatal("unexpected expiry", expiry)
+	} else if !keys.Validate(key) || !keys.Validate("password") {
+		t.Fatal("expected both keys to be valid")
+	}
+
+	// after the grace period only the new key is valid
+	time.Sleep(time.Until(expiry))
+	if !keys.Validate(key) {
+		t.Fatal("expected new key to be valid")
+	} else if keys.Validate("password") {
+		t.Fatal("expected old key to have expired")
+	}
+
+	// rotating again invalidates any key but the current one immediately
+	key2, _, err := keys.Rotate(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key3, _, err := keys.Rotate(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	} else if !keys.Validate(key3) || !keys.Validate(key2) {
+		t.Fatal("expected current and previous key to be valid")
+	} else if keys.Validate(key) {
+		t.Fatal("expected key to be invalid")
+	} else if !keys.Validate(keys.InternalKey()) {
+		t.Fatal("expected internal key to remain valid")
+	}
+
+	// only the current and internal key are accepted for rotating
+	if !keys.ValidateCurrent(key3) || !keys.ValidateCurrent(keys.InternalKey()) {
+		t.Fatal("expected current and internal key to be valid")
+	} else if keys.ValidateCurrent(key2) {
+		t.Fatal("expected previous key to be invalid")
+	}
+
+	// assert the handler rotates the key, requires basic auth with the current
+	// key and invalidates all tokens
+	tokens := NewTokenStore()
+	token := tokens.GenerateNew(time.Hour)
+	srv := httptest.NewServer(RotateKeyHandler(tokens, keys, time.Hour))
+	defer srv.Close()
+	rotate := func(key string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest("POST", srv.URL, http.NoBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetBasicAuth("", key)
+		res, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+
+	// the previous key is still valid but can't be used to rotate
+	if res := rotate(key2); res.StatusCode != http.StatusUnauthorized {
+		t.Fatal("expected status code 401, got", res.StatusCode)
+	} else if !keys.Validate(key3) || !keys.Validate(key2) {
+		t.Fatal("expected current and previous key to be valid")
+	}
+
+	res := rotate(key3)
+	if res.StatusCode != http.StatusOK {
+		t.Fatal("expected status code 200, got", res.StatusCode)
+	}
+	var resp APIKeyRotateResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	} else if !keys.Validate(resp.Key) || !keys.Validate(key3) {
+		t.Fatal("expected new and previous key to be valid")
+	} else if keys.Validate(key2) {
+		t.Fatal("expected key to be invalid")
+	} else if tokens.Validate(token) {
+		t.Fatal("expected token to be invalidated")
+	}
+
+	// key3 is now the previous key
+	if res := rotate(key3); res.StatusCode != http.StatusUnauthorized {
+		t.Fatal("expected status code 401, got", res.StatusCode)
+	}
+}
+
+func TestKeyStorePersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apikeys.json")
+	keys, err := LoadKeyStore("password", path)
+	if err != nil {
+		t.Fatal(err)
+	} else if !keys.Validate("password") {
+		t.Fatal("expected password to be valid")
+	}
+
+	// rotate the key and reload the store
+	key, _, err := keys.Rotate(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err = LoadKeyStore("password", path)
+	if err != nil {
+		t.Fatal(err)
+	} else if !keys.Validate(key) || !keys.Validate("password") {
+		t.Fatal("expected rotated key and previous key to be valid")
+	}
+
+	// rotate again, the password is no longer valid after a reload
+	key2, _, err := keys.Rotate(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err = LoadKeyStore("password", path)
+	if err != nil {
+		t.Fatal(err)
+	} else if !keys.Validate(key2) || !keys.Validate(key) {
+		t.Fatal("expected rotated keys to be valid")
+	} else if keys.Validate("password") {
+		t.Fatal("expected password to be invalid")
+	}
+
+	// changing the configured password discards the rotated keys
+	keys, err = LoadKeyStore("newpassword", path)
+	if err != nil {
+		t.Fatal(err)
+	} else if !keys.Validate("newpassword") {
+		t.Fatal("expected new password to be valid")
+	} else if keys.Validate(key2) {
+		t.Fatal("expected rotated key to be invalid")
+	}
+}
@@ -1,9 +1,14 @@
 package api
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/internal/utils"
 )
 
@@ -15,6 +20,15 @@ var (
 	// ErrInvalidReleaseVersion is returned if the version is an invalid release
 	// string.
 	ErrInvalidReleaseVersion = errors.New("invalid release version")
+
+	// ErrAutopilotConfigModified is returned if the autopilot config is
+	// updated with a precondition that doesn't match the current config.
+	ErrAutopilotConfigModified = errors.New("autopilot config was modified")
+
+	// ErrContractLifetimeConflict is returned if the contracts config
+	// requires a minimum contract lifetime that contracts formed for the
+	// configured period can't satisfy.
+	ErrContractLifetimeConflict = errors.New("contract lifetime conflict")
 )
 
 type (
@@ -34,6 +48,46 @@ type (
 		Upload      uint64 `json:"upload"`
 		Storage     uint64 `json:"storage"`
 		Prune       bool   `json:"prune"`
+
+		// MaxContractsPerSubnet is the maximum number of hosts within the
+		// same subnet the autopilot forms contracts with, zero is treated as
+		// one.
+		MaxContractsPerSubnet uint64 `json:"maxContractsPerSubnet"`
+
+		// MaxContractsPerHost is the maximum number of active contracts the
+		// autopilot forms with a single host, zero is treated as one.
+		MaxContractsPerHost uint64 `json:"maxContractsPerHost"`
+
+		// PruneAlertThreshold is the amount of prunable data in bytes above
+		// which the autopilot registers an alert, zero disables the alert.
+		PruneAlertThreshold uint64 `json:"pruneAlertThreshold"`
+
+		// MinWalletBalance is the confirmed wallet balance below which the
+		// autopilot pauses contract maintenance, migrations and pruning,
+		// zero disables the check.
+		MinWalletBalance types.Currency `json:"minWalletBalance"`
+
+		// StorageProjection maps object categories to their expected
+		// storage growth in bytes per day. The autopilot forms additional
+		// contracts and reserves funds to accommodate the projected growth
+		// over the next period.
+		StorageProjection map[string]uint64 `json:"storageProjection,omitempty"`
+
+		// MinContractLifetimeBlocks is the minimum number of blocks between
+		// the formation of a contract and its end height, zero disables the
+		// minimum.
+		MinContractLifetimeBlocks uint64 `json:"minContractLifetimeBlocks"`
+
+		// PeriodMultiplier is the factor the period is multiplied by when
+		// the observed data growth rate exceeds the growth rate expected by
+		// the storage projection by more than the
+		// PeriodMultiplierGrowthFactor, zero is treated as one.
+		PeriodMultiplier float64 `json:"periodMultiplier"`
+
+		// PeriodMultiplierGrowthFactor is the factor by which the observed
+		// data growth rate has to exceed the expected growth rate for the
+		// PeriodMultiplier to be applied, zero is treated as one.
+		PeriodMultiplierGrowthFactor float64 `json:"periodMultiplierGrowthFactor"`
 	}
 
 	// HostsConfig contains all hosts settings used in the autopilot.
@@ -55,6 +109,13 @@ var (
 			Upload:      1e12, // 1 TB
 			Storage:     4e12, // 4 TB
 			Prune:       false,
+
+			MaxContractsPerSubnet: 1,
+			MaxContractsPerHost:   1,
+			PruneAlertThreshold:   10e9, // 10 GB
+
+			PeriodMultiplier:             1,
+			PeriodMultiplierGrowthFactor: 2,
 		},
 		Hosts: HostsConfig{
 			MaxConsecutiveScanFailures: 10,
@@ -89,10 +150,31 @@ type (
 		ScanningLastStart  TimeRFC3339 `json:"scanningLastStart"`
 		UptimeMS           DurationMS  `json:"uptimeMs"`
 
+		// SubnetDistribution contains the number of hosts with good contracts
+		// per subnet as of the last contract maintenance.
+		SubnetDistribution map[string]uint64 `json:"subnetDistribution,omitempty"`
+
 		StartTime TimeRFC3339 `json:"startTime"`
 		BuildState
 	}
 
+	// WalletMaintenanceStatusResponse is the response type for the
+	// /autopilot/wallet/maintenance/status endpoint. BackoffUntil is set when
+	// wallet maintenance is skipped after a failed redistribution.
+	WalletMaintenanceStatusResponse struct {
+		BackoffUntil TimeRFC3339 `json:"backoffUntil"`
+		LastError    string      `json:"lastError,omitempty"`
+	}
+
+	// SlabRepairResponse is the response type for the /autopilot/slabs/:key/repair
+	// endpoint. RemainingMissing is the number of shards that still need to
+	// be repaired, Error is set if the repair failed.
+	SlabRepairResponse struct {
+		RepairedShards   int    `json:"repairedShards"`
+		RemainingMissing int    `json:"remainingMissing"`
+		Error            string `json:"error,omitempty"`
+	}
+
 	ConfigEvaluationRequest struct {
 		AutopilotConfig    AutopilotConfig    `json:"autopilotConfig"`
 		GougingSettings    GougingSettings    `json:"gougingSettings"`
@@ -124,12 +206,96 @@ type (
 	}
 )
 
+// ProjectedGrowth returns the number of bytes the stored data is expected to
+// grow by over the next period according to the storage projection.
+func (cc ContractsConfig) ProjectedGrowth() uint64 {
+	var perDay uint64
+	for _, rate := range cc.StorageProjection {
+		if perDay+rate < perDay {
+			return math.MaxUint64
+		}
+		perDay += rate
+	}
+	days := cc.Period / 144 // blocks per day
+	if days > 0 && perDay > math.MaxUint64/days {
+		return math.MaxUint64
+	}
+	return perDay * days
+}
+
+// ExpectedGrowthPerBlock returns the number of bytes the stored data is
+// expected to grow by per block according to the storage projection.
+func (cc ContractsConfig) ExpectedGrowthPerBlock() float64 {
+	var perDay float64
+	for _, rate := range cc.StorageProjection {
+		perDay += float64(rate)
+	}
+	return perDay / 144 // blocks per day
+}
+
+// AdjustedPeriod returns the period contracts should be formed and renewed
+// for given the observed data growth in bytes per block. The period is
+// multiplied by the PeriodMultiplier if the observed growth exceeds the
+// expected growth by more than the PeriodMultiplierGrowthFactor, the boolean
+// indicates whether the period was adjusted.
+func (cc ContractsConfig) AdjustedPeriod(observedGrowthPerBlock float64) (uint64, bool) {
+	expected := cc.ExpectedGrowthPerBlock()
+	if expected == 0 || cc.PeriodMultiplier <= 1 {
+		return cc.Period, false
+	}
+	factor := cc.PeriodMultiplierGrowthFactor
+	if factor == 0 {
+		factor = 1
+	}
+	if observedGrowthPerBlock <= expected*factor {
+		return cc.Period, false
+	}
+	adjusted := math.Ceil(float64(cc.Period) * cc.PeriodMultiplier)
+	if adjusted >= math.MaxUint64 {
+		return math.MaxUint64, true
+	}
+	return uint64(adjusted), true
+}
+
+// WantedContracts returns the number of contracts the autopilot should
+// maintain. The configured amount is scaled up proportionally to the
+// projected growth relative to the expected storage, the result is capped at
+// twice the configured amount.
+func (cc ContractsConfig) WantedContracts() uint64 {
+	growth := cc.ProjectedGrowth()
+	if growth == 0 || cc.Storage == 0 {
+		return cc.Amount
+	}
+	extra := uint64(math.Ceil(float64(cc.Amount) * float64(growth) / float64(cc.Storage)))
+	return cc.Amount + min(extra, cc.Amount)
+}
+
 func (cc ContractsConfig) Validate() error {
 	if cc.Period == 0 {
 		return errors.New("period must be greater than 0")
 	} else if cc.RenewWindow == 0 {
 		return errors.New("renewWindow must be greater than 0")
 	}
+	for category := range cc.StorageProjection {
+		if category == "" {
+			return errors.New("storageProjection categories must not be empty")
+		}
+	}
+	if cc.PeriodMultiplier != 0 && !(cc.PeriodMultiplier >= 1) {
+		return errors.New("periodMultiplier must be at least 1")
+	} else if !(cc.PeriodMultiplierGrowthFactor >= 0) {
+		return errors.New("periodMultiplierGrowthFactor must not be negative")
+	}
+	return cc.ValidateContractLifetime()
+}
+
+// ValidateContractLifetime returns an error if contracts formed for the
+// configured period and renew window don't satisfy the minimum contract
+// lifetime.
+func (cc ContractsConfig) ValidateContractLifetime() error {
+	if lifetime := cc.Period + cc.RenewWindow; lifetime < cc.MinContractLifetimeBlocks {
+		return fmt.Errorf("%w: contracts are formed for %d blocks, period and renewWindow must add up to at least minContractLifetimeBlocks %d", ErrContractLifetimeConflict, lifetime, cc.MinContractLifetimeBlocks)
+	}
 	return nil
 }
 
@@ -141,3 +307,71 @@ func (hc HostsConfig) Validate() error {
 	}
 	return nil
 }
+
+// ETag returns an entity tag for the config which changes whenever the config
+// changes.
+func (cfg AutopilotConfig) ETag() string {
+	js, err := json.Marshal(cfg)
+	if err != nil {
+		panic(err) // should never happen
+	}
+	h := types.HashBytes(js)
+	return hex.EncodeToString(h[:8])
+}
+
+// MergePatch applies the given JSON Merge Patch (RFC 7396) to the config and
+// returns the result. Fields that are set to null in the patch are reset to
+// their zero value.
+func (cfg AutopilotConfig) MergePatch(patch []byte) (AutopilotConfig, error) {
+	var p any
+	if err := decodeJSONNumbers(patch, &p); err != nil {
+		return AutopilotConfig{}, fmt.Errorf("failed to decode patch: %w", err)
+	}
+
+	js, err := json.Marshal(cfg)
+	if err != nil {
+		return AutopilotConfig{}, err
+	}
+	var target any
+	if err := decodeJSONNumbers(js, &target); err != nil {
+		return AutopilotConfig{}, err
+	}
+
+	js, err = json.Marshal(mergePatch(target, p))
+	if err != nil {
+		return AutopilotConfig{}, err
+	}
+
+	var patched AutopilotConfig
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patched); err != nil {
+		return AutopilotConfig{}, fmt.Errorf("failed to apply patch: %w", err)
+	}
+	return patched, nil
+}
+
+func decodeJSONNumbers(b []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestAutopilotConfigMergePatch(t *testing.T) {
+	cfg := DefaultAutopilotConfig
+	cfg.Contracts.Storage = math.MaxUint64
+
+	// patch a nested field and the top-level enabled flag
+	patched, err := cfg.MergePatch([]byte(`{"enabled":true,"contracts":{"amount":10}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := cfg
+	expected.Enabled = true
+	expected.Contracts.Amount = 10
+	if !reflect.DeepEqual(patched, expected) {
+		t.Fatalf("unexpected config %+v", patched)
+	} else if patched.ETag() == cfg.ETag() {
+		t.Fatal("expected etag to change")
+	}
+
+	// null resets a field to its zero value
+	patched, err = cfg.MergePatch([]byte(`{"hosts":{"minProtocolVersion":null}}`))
+	if err != nil {
+		t.Fatal(err)
+	} else if patched.Hosts.MinProtocolVersion != "" {
+		t.Fatal("expected min protocol version to be reset")
+	} else if patched.Hosts.MaxDowntimeHours != cfg.Hosts.MaxDowntimeHours {
+		t.Fatal("expected max downtime to be unchanged")
+	}
+
+	// an empty patch is a no-op
+	patched, err = cfg.MergePatch([]byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(patched, cfg) || patched.ETag() != cfg.ETag() {
+		t.Fatal("expected config to be unchanged")
+	}
+
+	// unknown fields and invalid types are rejected
+	if _, err := cfg.MergePatch([]byte(`{"foo":1}`)); err == nil {
+		t.Fatal("expected error")
+	} else if _, err := cfg.MergePatch([]byte(`{"contracts":{"amount":"ten"}}`)); err == nil {
+		t.Fatal("expected error")
+	} else if _, err := cfg.MergePatch([]byte(`[]`)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestContractsConfigWantedContracts(t *testing.T) {
+	cfg := ContractsConfig{
+		Amount:  10,
+		Period:  144 * 10, // 10 days
+		Storage: 100e9,
+	}
+
+	// no projection
+	if n := cfg.WantedContracts(); n != cfg.Amount {
+		t.Fatalf("expected %v contracts, got %v", cfg.Amount, n)
+	}
+
+	// 5GB/day over 10 days is 50% of the expected storage
+	cfg.StorageProjection = map[string]uint64{"backups": 2e9, "media": 3e9}
+	if growth := cfg.ProjectedGrowth(); growth != 50e9 {
+		t.Fatalf("expected 50GB of growth, got %v", growth)
+	} else if n := cfg.WantedContracts(); n != 15 {
+		t.Fatalf("expected 15 contracts, got %v", n)
+	}
+
+	// the additional contracts are capped at the configured amount
+	cfg.StorageProjection["media"] = 100e9
+	if n := cfg.WantedContracts(); n != 2*cfg.Amount {
+		t.Fatalf("expected %v contracts, got %v", 2*cfg.Amount, n)
+	}
+
+	// the projected growth saturates instead of overflowing
+	cfg.StorageProjection["media"] = math.MaxUint64
+	if growth := cfg.ProjectedGrowth(); growth != math.MaxUint64 {
+		t.Fatalf("expected growth to saturate, got %v", growth)
+	}
+}
+
+func TestContractsConfigAdjustedPeriod(t *testing.T) {
+	cfg := ContractsConfig{
+		Period:                       1000,
+		PeriodMultiplier:             1.5,
+		PeriodMultiplierGrowthFactor: 2,
+	}
+
+	// no projection means there's no expected growth to compare against
+	if period, adjusted := cfg.AdjustedPeriod(1e9); adjusted || period != cfg.Period {
+		t.Fatalf("unexpected period %v", period)
+	}
+
+	// 144MB/day is 1MB per block
+	cfg.StorageProjection = map[string]uint64{"media": 144e6}
+	if rate := cfg.ExpectedGrowthPerBlock(); rate != 1e6 {
+		t.Fatalf("expected 1MB per block, got %v", rate)
+	}
+
+	// growth within the factor doesn't adjust the period
+	if period, adjusted := cfg.AdjustedPeriod(2e6); adjusted || period != cfg.Period {
+		t.Fatalf("unexpected period %v", period)
+	}
+
+	// growth exceeding the factor multiplies the period
+	if period, adjusted := cfg.AdjustedPeriod(2e6 + 1); !adjusted || period != 1500 {
+		t.Fatalf("unexpected period %v", period)
+	}
+
+	// a factor of zero is treated as one
+	cfg.PeriodMultiplierGrowthFactor = 0
+	if period, adjusted := cfg.AdjustedPeriod(1e6 + 1); !adjusted || period != 1500 {
+		t.Fatalf("unexpected period %v", period)
+	}
+
+	// a multiplier of zero is treated as one
+	cfg.PeriodMultiplier = 0
+	if period, adjusted := cfg.AdjustedPeriod(1e9); adjusted || period != cfg.Period {
+		t.Fatalf("unexpected period %v", period)
+	}
+
+	// assert the multiplier and factor are validated
+	cfg = DefaultAutopilotConfig.Contracts
+	cfg.PeriodMultiplier = 0.5
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error")
+	}
+	cfg.PeriodMultiplier = 1
+	cfg.PeriodMultiplierGrowthFactor = -1
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -2,8 +2,11 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
+
+	"go.sia.tech/core/types"
 )
 
 var (
@@ -25,26 +28,68 @@ type (
 		CreatedAt TimeRFC3339  `json:"createdAt"`
 		Name      string       `json:"name"`
 		Policy    BucketPolicy `json:"policy"`
+
+		// Redundancy overrides the global redundancy settings for uploads to
+		// the bucket, if not set the global settings are used.
+		Redundancy *RedundancySettings `json:"redundancy,omitempty"`
 	}
 
 	BucketPolicy struct {
 		PublicReadAccess bool `json:"publicReadAccess"`
+
+		// CopyOnWrite preserves the slabs of overwritten objects until the
+		// next garbage collection. VersionRetention is the minimum amount of
+		// time the preserved versions are kept, versions that are younger are
+		// skipped by the garbage collection.
+		CopyOnWrite      bool       `json:"copyOnWrite"`
+		VersionRetention DurationMS `json:"versionRetention,omitempty"`
+
+		// AllowedHosts restricts the hosts that store the bucket's data, an
+		// empty list means any host is allowed. BlockedHosts are never used
+		// to store the bucket's data.
+		AllowedHosts []types.PublicKey `json:"allowedHosts,omitempty"`
+		BlockedHosts []types.PublicKey `json:"blockedHosts,omitempty"`
+
+		// MaxDownloadBandwidthBytesPerSec limits the rate at which a worker
+		// serves downloads from the bucket, 0 means unlimited.
+		MaxDownloadBandwidthBytesPerSec uint64 `json:"maxDownloadBandwidthBytesPerSec,omitempty"`
+	}
+
+	// BucketUsage contains the number of objects in a bucket and their
+	// combined size.
+	BucketUsage struct {
+		ObjectCount int64       `json:"objectCount"`
+		TotalBytes  int64       `json:"totalBytes"`
+		LastUpdated TimeRFC3339 `json:"lastUpdated"`
 	}
 
 	CreateBucketOptions struct {
-		Policy BucketPolicy
+		Policy     BucketPolicy
+		Redundancy *RedundancySettings
 	}
 )
 
 type (
 	BucketCreateRequest struct {
-		Name   string       `json:"name"`
-		Policy BucketPolicy `json:"policy"`
+		Name       string              `json:"name"`
+		Policy     BucketPolicy        `json:"policy"`
+		Redundancy *RedundancySettings `json:"redundancy,omitempty"`
+	}
+
+	BucketRedundancyResponse struct {
+		Redundancy *RedundancySettings `json:"redundancy"`
 	}
 
 	BucketUpdatePolicyRequest struct {
 		Policy BucketPolicy `json:"policy"`
 	}
+
+	// BucketUpdateRedundancyRequest is the request type for the
+	// /bucket/:name/redundancy endpoint, a nil redundancy means the bucket
+	// uses the global redundancy settings.
+	BucketUpdateRedundancyRequest struct {
+		Redundancy *RedundancySettings `json:"redundancy"`
+	}
 )
 
 var validBucketExp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$`)
@@ -57,6 +102,49 @@ func (req BucketCreateRequest) Validate() error {
 		strings.HasSuffix(req.Name, "-s3alias") ||
 		!validBucketExp.MatchString(req.Name) {
 		return errors.New("the bucket name doesn't comply with the S3 bucket naming convention (https://docs.aws.amazon.com/AmazonS3/latest/userguide/bucketnamingrules.html)")
+	} else if req.Redundancy != nil {
+		if err := req.Redundancy.Validate(); err != nil {
+			return err
+		}
+	}
+	return req.Policy.Validate()
+}
+
+// HostAllowed returns whether the policy allows the bucket's data to be stored
+// on the host with the given key.
+func (bp BucketPolicy) HostAllowed(hk types.PublicKey) bool {
+	for _, blocked := range bp.BlockedHosts {
+		if blocked == hk {
+			return false
+		}
+	}
+	if len(bp.AllowedHosts) == 0 {
+		return true
+	}
+	for _, allowed := range bp.AllowedHosts {
+		if allowed == hk {
+			return true
+		}
+	}
+	return false
+}
+
+// RestrictsHosts returns true if the policy limits the hosts the bucket's data
+// can be stored on.
+func (bp BucketPolicy) RestrictsHosts() bool {
+	return len(bp.AllowedHosts) > 0 || len(bp.BlockedHosts) > 0
+}
+
+// Validate returns an error if the policy is invalid.
+func (bp BucketPolicy) Validate() error {
+	allowed := make(map[types.PublicKey]struct{}, len(bp.AllowedHosts))
+	for _, hk := range bp.AllowedHosts {
+		allowed[hk] = struct{}{}
+	}
+	for _, hk := range bp.BlockedHosts {
+		if _, ok := allowed[hk]; ok {
+			return fmt.Errorf("host %v can't be both allowed and blocked", hk)
+		}
 	}
 	return nil
 }
@@ -3,6 +3,8 @@ package api
 import (
 	"strings"
 	"testing"
+
+	"go.sia.tech/core/types"
 )
 
 func TestBucketNameValidation(t *testing.T) {
@@ -66,3 +68,33 @@ func TestBucketNameValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestBucketPolicyHosts(t *testing.T) {
+	h1, h2, h3 := types.PublicKey{1}, types.PublicKey{2}, types.PublicKey{3}
+
+	// no restrictions
+	var policy BucketPolicy
+	if policy.RestrictsHosts() || !policy.HostAllowed(h1) {
+		t.Fatal("unexpected")
+	}
+
+	// allowlist
+	policy.AllowedHosts = []types.PublicKey{h1, h2}
+	if !policy.RestrictsHosts() {
+		t.Fatal("expected policy to restrict hosts")
+	} else if !policy.HostAllowed(h1) || !policy.HostAllowed(h2) || policy.HostAllowed(h3) {
+		t.Fatal("unexpected")
+	}
+
+	// blocklist takes precedence
+	policy.BlockedHosts = []types.PublicKey{h3}
+	if err := policy.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	policy.BlockedHosts = []types.PublicKey{h2}
+	if policy.HostAllowed(h2) {
+		t.Fatal("expected blocked host to be rejected")
+	} else if err := policy.Validate(); err == nil {
+		t.Fatal("expected error for host in both lists")
+	}
+}
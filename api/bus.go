@@ -73,6 +73,71 @@ type (
 		Explorer ExplorerState `json:"explorer"`
 	}
 
+	// BusStatsResponse is the response type for the /bus/stats endpoint.
+	BusStatsResponse struct {
+		HostSectorPruning HostSectorPruneStats `json:"hostSectorPruning"`
+		RHP4Pool          RHP4PoolStats        `json:"rhp4Pool"`
+	}
+
+	// DBPoolStats contains the utilization of a database connection pool.
+	DBPoolStats struct {
+		MaxOpenConns int        `json:"maxOpenConns"`
+		Open         int        `json:"open"`
+		InUse        int        `json:"inUse"`
+		Idle         int        `json:"idle"`
+		WaitCount    int64      `json:"waitCount"`
+		WaitDuration DurationMS `json:"waitDuration"`
+	}
+
+	// DBDurationHistogram is a histogram of durations in seconds, the counts of
+	// the buckets are cumulative.
+	DBDurationHistogram struct {
+		Buckets []DBDurationBucket `json:"buckets"`
+		Count   uint64             `json:"count"`
+		Sum     float64            `json:"sum"`
+	}
+
+	// DBDurationBucket is a bucket of a DBDurationHistogram, it contains the
+	// number of durations less than or equal to its upper bound.
+	DBDurationBucket struct {
+		UpperBound float64 `json:"le"`
+		Count      uint64  `json:"count"`
+	}
+
+	// DBDurationStats contains the duration histograms of the queries,
+	// labeled by their normalized query text, and the transactions of a
+	// database.
+	DBDurationStats struct {
+		Queries      map[string]DBDurationHistogram `json:"queries"`
+		Transactions DBDurationHistogram            `json:"transactions"`
+	}
+
+	// DBStatsResponse is the response type for the /admin/db/stats
+	// endpoint.
+	DBStatsResponse struct {
+		Main    DBPoolStats `json:"main"`
+		Metrics DBPoolStats `json:"metrics"`
+
+		MainDurations    DBDurationStats `json:"mainDurations"`
+		MetricsDurations DBDurationStats `json:"metricsDurations"`
+	}
+
+	// HostSectorPruneStats contains the progress of the current host sector
+	// pruning cycle.
+	HostSectorPruneStats struct {
+		PrunedThisCycle uint64 `json:"prunedThisCycle"`
+		Remaining       uint64 `json:"remaining"`
+	}
+
+	// RHP4PoolStats contains the utilization of the pool that bounds the
+	// number of concurrent RHP4 operations performed by the bus. A size of 0
+	// indicates the pool is disabled.
+	RHP4PoolStats struct {
+		Size    int `json:"size"`
+		InUse   int `json:"inUse"`
+		Waiting int `json:"waiting"`
+	}
+
 	// ExplorerState contains static information about explorer data sources.
 	ExplorerState struct {
 		Enabled bool   `json:"enabled"`
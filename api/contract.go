@@ -3,10 +3,44 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 
 	"go.sia.tech/core/types"
 )
 
+// The contract states form a state machine, the valid transitions are:
+//
+//	     ┌─────────────────────────────────────┐
+//	     ▼                                     ▼
+//	┌─────────┐          ┌────────┐          ┌──────────┐
+//	│ pending │ ◄──────► │ active │ ◄──────► │ complete │
+//	└─────────┘          └────────┘          └──────────┘
+//	     ▲                ▲    │                  ▲
+//	     │                │    ▼                  │
+//	     │               ┌────────┐               │
+//	     └─────────────► │ failed │ ──────────────┘
+//	                     └────────┘
+//
+//	pending  -> active    the formation transaction was confirmed
+//	pending  -> complete  the formation and the resolution were confirmed in the same block
+//	pending  -> failed    the formation transaction wasn't confirmed in time
+//	active   -> pending   the formation transaction was reverted
+//	active   -> complete  a storage proof or renewal was confirmed
+//	active   -> failed    an expiration was confirmed or the proof window ended
+//	complete -> active    the resolution was reverted
+//	complete -> pending   the formation transaction was reverted together
+//	                      with its resolution
+//	failed   -> active    the resolution was reverted or a contract that wasn't
+//	                      confirmed in time was confirmed after all
+//	failed   -> complete  a contract that wasn't confirmed in time was confirmed
+//	                      together with its resolution
+//	failed   -> pending   the formation transaction was reverted
+//	unknown  -> active    an unarchived contract is still active on chain
+//	unknown  -> any       the chain updated the state of an unarchived contract
+//
+// Unarchived contracts are reset to the unknown state, the invalid state has no
+// transitions.
 const (
 	ContractStateInvalid  = "invalid"
 	ContractStateUnknown  = "unknown"
@@ -22,25 +56,135 @@ const (
 )
 
 const (
-	ContractArchivalReasonHostPruned = "hostpruned"
-	ContractArchivalReasonRemoved    = "removed"
-	ContractArchivalReasonRenewed    = "renewed"
+	ContractVerificationStatusMismatch   = "mismatch"
+	ContractVerificationStatusUnverified = "unverified"
+	ContractVerificationStatusVerified   = "verified"
+)
+
+const (
+	ContractArchivalReasonExpired      = "expired"
+	ContractArchivalReasonHostPruned   = "hostpruned"
+	ContractArchivalReasonNotConfirmed = "notconfirmed"
+	ContractArchivalReasonRemoved      = "removed"
+	ContractArchivalReasonRenewed      = "renewed"
+)
+
+const (
+	ContractsPrunableSortByHostKey  = "hostKey"
+	ContractsPrunableSortByPrunable = "prunable"
+	ContractsPrunableSortBySize     = "size"
+
+	// DefaultContractsPrunableLimit is the default number of contracts
+	// returned by the /contracts/prunable endpoint.
+	DefaultContractsPrunableLimit = 100
+
+	// MaxContractsPrunableLimit is the maximum number of contracts that can
+	// be requested from the /contracts/prunable endpoint at once.
+	MaxContractsPrunableLimit = 1000
+
+	// DefaultContractsRefreshParallelism is the default number of contracts
+	// the /contracts/refresh endpoint refreshes in parallel.
+	DefaultContractsRefreshParallelism = 10
+)
+
+const (
+	ContractEventTypeFormed       = "formed"
+	ContractEventTypeRenewed      = "renewed"
+	ContractEventTypeArchived     = "archived"
+	ContractEventTypePruned       = "pruned"
+	ContractEventTypeStateChanged = "stateChanged"
+	ContractEventTypeUnarchived   = "unarchived"
 )
 
 var (
 	// ErrContractNotFound is returned when a contract can't be retrieved from
 	// the database.
 	ErrContractNotFound = errors.New("couldn't find contract")
+
+	// ErrContractSpendingCapExceeded is returned when recording spending
+	// would push a contract's total spending beyond its spending cap.
+	ErrContractSpendingCapExceeded = errors.New("contract spending cap exceeded")
+
+	// ErrInsufficientRenterFunds is returned when the funds used to renew a
+	// contract don't cover the host's contract price and the miner fee.
+	ErrInsufficientRenterFunds = errors.New("insufficient renter funds")
+
+	// ErrInvalidArchivalReason is returned when a contract is archived with a
+	// reason that isn't one of the known archival reasons.
+	ErrInvalidArchivalReason = errors.New("invalid archival reason")
+
+	// ErrInvalidContractStateTransition is returned when a contract is moved
+	// from one state to another state that isn't reachable from it.
+	ErrInvalidContractStateTransition = errors.New("invalid contract state transition")
 )
 
+// contractStateTransitions contains the states that are reachable from every
+// contract state.
+var contractStateTransitions = map[ContractState]map[ContractState]struct{}{
+	ContractStatePending:  {ContractStateActive: {}, ContractStateComplete: {}, ContractStateFailed: {}},
+	ContractStateActive:   {ContractStatePending: {}, ContractStateComplete: {}, ContractStateFailed: {}},
+	ContractStateComplete: {ContractStatePending: {}, ContractStateActive: {}},
+	ContractStateFailed:   {ContractStatePending: {}, ContractStateActive: {}, ContractStateComplete: {}},
+	ContractStateUnknown:  {ContractStatePending: {}, ContractStateActive: {}, ContractStateComplete: {}, ContractStateFailed: {}},
+}
+
 type ContractState string
 
+// ValidateStateTransition returns an error if a contract can't move from one
+// state to the other.
+func ValidateStateTransition(from, to ContractState) error {
+	if _, ok := contractStateTransitions[from][to]; !ok {
+		return fmt.Errorf("%w: %v -> %v", ErrInvalidContractStateTransition, from, to)
+	}
+	return nil
+}
+
+// ValidateArchivalReason returns an error if the given reason isn't one of the
+// known contract archival reasons.
+func ValidateArchivalReason(reason string) error {
+	switch reason {
+	case ContractArchivalReasonExpired,
+		ContractArchivalReasonHostPruned,
+		ContractArchivalReasonNotConfirmed,
+		ContractArchivalReasonRemoved,
+		ContractArchivalReasonRenewed:
+		return nil
+	default:
+		return fmt.Errorf("%w: '%s'", ErrInvalidArchivalReason, reason)
+	}
+}
+
 type (
 	// ContractSize contains information about the size of the contract and
 	// about how much of the contract data can be pruned.
 	ContractSize struct {
 		Prunable uint64 `json:"prunable"`
 		Size     uint64 `json:"size"`
+
+		// Estimated is true if the prunable size was computed from the
+		// database without consulting the host, in which case it might be
+		// off if the contract has pending uploads or its recorded size is
+		// outdated.
+		Estimated bool `json:"estimated,omitempty"`
+	}
+
+	// ContractEvent describes a milestone in the lifecycle of a contract.
+	ContractEvent struct {
+		ContractID types.FileContractID `json:"contractID"`
+		Timestamp  TimeRFC3339          `json:"timestamp"`
+		Type       string               `json:"type"`
+		Details    json.RawMessage      `json:"details,omitempty"`
+	}
+
+	// ContractStateEvent describes a transition of a contract from one state
+	// to another.
+	ContractStateEvent struct {
+		ContractID types.FileContractID `json:"contractID"`
+		FromState  ContractState        `json:"fromState"`
+		ToState    ContractState        `json:"toState"`
+		Reason     string               `json:"reason"`
+		Height     uint64               `json:"height"`
+		Timestamp  TimeRFC3339          `json:"timestamp"`
 	}
 
 	// ContractMetadata contains all metadata for a contract.
@@ -59,26 +203,68 @@ type (
 		WindowStart    uint64               `json:"windowStart"`
 		WindowEnd      uint64               `json:"windowEnd"`
 
+		// UsabilityOverride is true if the usability was set by the user, in
+		// which case the autopilot doesn't update it.
+		UsabilityOverride bool `json:"usabilityOverride"`
+
+		// HostVersion is the release of the host's software as reported in
+		// its settings, it's updated whenever the host is scanned.
+		HostVersion string `json:"hostVersion"`
+
+		// VerificationStatus indicates whether the contract's revision
+		// number matches the revision found on chain. It's 'unverified' if
+		// the latest revision wasn't broadcast, and 'mismatch' if the chain
+		// holds a revision that is newer than the one we stored.
+		VerificationStatus string `json:"verificationStatus"`
+
 		// costs & spending
 		ContractPrice      types.Currency   `json:"contractPrice"`
 		InitialRenterFunds types.Currency   `json:"initialRenterFunds"`
+		NetworkFees        types.Currency   `json:"networkFees"`
 		Spending           ContractSpending `json:"spending"`
 
+		// SpendingCap is the maximum total spending allowed on the contract,
+		// zero means unlimited.
+		SpendingCap types.Currency `json:"spendingCap"`
+
 		// following fields are only set on archived contracts
 		ArchivalReason string               `json:"archivalReason,omitempty"`
 		RenewedTo      types.FileContractID `json:"renewedTo,omitempty"`
+
+		// GougingCheckResult is only set when contracts are fetched through
+		// the /contracts endpoint
+		GougingCheckResult *GougingCheckResult `json:"gougingCheckResult,omitempty"`
+	}
+
+	// GougingCheckResult contains the result of checking a contract's host
+	// against the gouging settings using the host's last scanned settings. If
+	// the host hasn't been scanned, Warning is set and the result is empty.
+	GougingCheckResult struct {
+		Gouging        bool     `json:"gouging"`
+		FailureReasons []string `json:"failureReasons,omitempty"`
+		Warning        string   `json:"warning,omitempty"`
 	}
 
 	// ContractPrunableData wraps a contract's size information with its id.
 	ContractPrunableData struct {
-		ID types.FileContractID `json:"id"`
+		ID      types.FileContractID `json:"id"`
+		HostKey types.PublicKey      `json:"hostKey"`
 		ContractSize
 	}
 
+	// ContractRevisionRecord contains the revision number and size of a
+	// contract at the time the revision was recorded.
+	ContractRevisionRecord struct {
+		RevisionNumber uint64      `json:"revisionNumber"`
+		Size           uint64      `json:"size"`
+		Timestamp      TimeRFC3339 `json:"timestamp"`
+	}
+
 	// ContractSpending contains all spending details for a contract.
 	ContractSpending struct {
 		Deletions   types.Currency `json:"deletions"`
 		FundAccount types.Currency `json:"fundAccount"`
+		NetworkFees types.Currency `json:"networkFees"`
 		SectorRoots types.Currency `json:"sectorRoots"`
 		Uploads     types.Currency `json:"uploads"`
 	}
@@ -126,6 +312,13 @@ type (
 		HostKey        types.PublicKey `json:"hostKey"`
 		RenterFunds    types.Currency  `json:"renterFunds"`
 		RenterAddress  types.Address   `json:"renterAddress"`
+
+		// CollateralMultiplier is applied to the host collateral when
+		// constructing the formation request. Values above 1.0 request extra
+		// collateral from the host, if the host can't provide it the contract
+		// is formed with the standard collateral. Defaults to 1.0 if not set,
+		// must be greater than 0 and at most 100.
+		CollateralMultiplier float64 `json:"collateralMultiplier,omitempty"`
 	}
 
 	// ContractKeepaliveRequest is the request type for the /contract/:id/keepalive
@@ -139,6 +332,14 @@ type (
 	// endpoint.
 	ContractPruneRequest struct {
 		Timeout DurationMS `json:"timeout"`
+
+		// DryRun indicates whether the prunable sectors should only be
+		// computed without actually freeing them on the host.
+		DryRun bool `json:"dryRun,omitempty"`
+
+		// MaxSectors caps the number of sectors freed in a single prune
+		// operation, 0 means the default batch size is used.
+		MaxSectors uint64 `json:"maxSectors,omitempty"`
 	}
 
 	// ContractPruneResponse is the response type for the /contract/:id/prune
@@ -147,7 +348,20 @@ type (
 		ContractSize uint64 `json:"size"`
 		Pruned       uint64 `json:"pruned"`
 		Remaining    uint64 `json:"remaining"`
-		Error        string `json:"error,omitempty"`
+
+		// Error is set if pruning was interrupted after the contract's
+		// sector roots were fetched, the other fields then describe the
+		// partial result.
+		Error string `json:"error,omitempty"`
+
+		// TotalPrunable is the total number of prunable bytes in the contract
+		// at the time of pruning, if it exceeds the number of bytes pruned
+		// additional prune operations are necessary.
+		TotalPrunable uint64 `json:"totalPrunable"`
+
+		// WouldPrune is only set on dry runs and contains the number of
+		// bytes that would have been pruned.
+		WouldPrune uint64 `json:"wouldPrune,omitempty"`
 	}
 
 	// ContractAcquireRequest is the request type for the /contract/:id/release
@@ -162,27 +376,98 @@ type (
 		EndHeight        uint64         `json:"endHeight"`
 		MinNewCollateral types.Currency `json:"minNewCollateral"`
 		RenterFunds      types.Currency `json:"renterFunds"`
+
+		// FeeMultiplier is applied to the recommended fee when constructing
+		// the renewal transaction. Values above 1.0 increase the speed of
+		// confirmation at a higher cost. Defaults to 1.0 if not set.
+		FeeMultiplier float64 `json:"feeMultiplier,omitempty"`
+	}
+
+	// ContractRenewEstimateResponse is the response type for the
+	// /contract/:id/renewestimate endpoint.
+	ContractRenewEstimateResponse struct {
+		// MinRenterFunds is the minimum amount of renter funds accepted
+		// when renewing the contract.
+		MinRenterFunds types.Currency `json:"minRenterFunds"`
+
+		// StorageCost is the estimated cost of storing the contract's
+		// current data until the end height, it's paid in addition to the
+		// renter funds.
+		StorageCost types.Currency `json:"storageCost"`
+	}
+
+	// ContractUsabilityOverrideRequest is the request type for the
+	// /contract/:id/usability/override endpoint.
+	ContractUsabilityOverrideRequest struct {
+		Usability string `json:"usability"`
+	}
+
+	// ContractSpendingCapRequest is the request type for the
+	// /contract/:id/spendingcap endpoint.
+	ContractSpendingCapRequest struct {
+		SpendingCap types.Currency `json:"spendingCap"`
 	}
 
 	// ContractsArchiveRequest is the request type for the /contracts/archive endpoint.
 	ContractsArchiveRequest = map[types.FileContractID]string
 
+	// ContractsRefreshRequest is the request type for the /contracts/refresh
+	// endpoint.
+	ContractsRefreshRequest = []types.FileContractID
+
+	// ContractRefreshResult is the outcome of refreshing a single contract
+	// through the /contracts/refresh endpoint. Either Contract or Error is
+	// set.
+	ContractRefreshResult struct {
+		ContractID types.FileContractID `json:"contractID"`
+		Contract   *ContractMetadata    `json:"contract,omitempty"`
+		Error      string               `json:"error,omitempty"`
+	}
+
 	// ContractsPrunableDataResponse is the response type for the
-	// /contracts/prunable endpoint.
+	// /contracts/prunable endpoint. The totals cover all contracts, not only
+	// the returned page.
 	ContractsPrunableDataResponse struct {
 		Contracts     []ContractPrunableData `json:"contracts"`
 		TotalPrunable uint64                 `json:"totalPrunable"`
 		TotalSize     uint64                 `json:"totalSize"`
 	}
 
+	ContractsPrunableDataOpts struct {
+		SortBy string
+		Offset int
+		Limit  int
+	}
+
+	ContractRevisionsOpts struct {
+		Start uint64
+		End   uint64
+	}
+
 	ContractsOpts struct {
 		FilterMode string `json:"filterMode"`
+
+		// MinRemainingFunds excludes contracts whose remaining renter funds
+		// are below the given amount.
+		MinRemainingFunds types.Currency `json:"minRemainingFunds"`
+		// MaxSpendingRatio excludes contracts that spent less than the given
+		// fraction of their initial renter funds, it must be between 0 and 1.
+		MaxSpendingRatio float64 `json:"maxSpendingRatio"`
+		// ExpiringWithinBlocks excludes contracts that don't end within the
+		// given number of blocks from the current height.
+		ExpiringWithinBlocks uint64 `json:"expiringWithinBlocks"`
+		// HostVersionBelow excludes contracts whose host runs a version that
+		// is equal to or newer than the given version. Contracts whose host
+		// version is unknown are excluded as well.
+		HostVersionBelow string `json:"hostVersionBelow"`
+		// HostKey excludes contracts with hosts other than the given one.
+		HostKey types.PublicKey `json:"hostKey"`
 	}
 )
 
 // Total returns the total cost of the contract spending.
 func (x ContractSpending) Total() types.Currency {
-	return x.Uploads.Add(x.FundAccount).Add(x.Deletions).Add(x.SectorRoots)
+	return x.Uploads.Add(x.FundAccount).Add(x.Deletions).Add(x.SectorRoots).Add(x.NetworkFees)
 }
 
 // Add returns the sum of the current and given contract spending.
@@ -191,6 +476,7 @@ func (x ContractSpending) Add(y ContractSpending) (z ContractSpending) {
 	z.FundAccount = x.FundAccount.Add(y.FundAccount)
 	z.Deletions = x.Deletions.Add(y.Deletions)
 	z.SectorRoots = x.SectorRoots.Add(y.SectorRoots)
+	z.NetworkFees = x.NetworkFees.Add(y.NetworkFees)
 	return
 }
 
@@ -198,10 +484,49 @@ func (cm ContractMetadata) EndHeight() uint64 {
 	return cm.WindowStart
 }
 
+// RemainingFunds returns the contract's initial renter funds minus its total
+// spending.
+func (cm ContractMetadata) RemainingFunds() types.Currency {
+	remaining, underflow := cm.InitialRenterFunds.SubWithUnderflow(cm.Spending.Total())
+	if underflow {
+		return types.ZeroCurrency
+	}
+	return remaining
+}
+
+// SpendingRatio returns the fraction of the contract's initial renter funds
+// that has been spent.
+func (cm ContractMetadata) SpendingRatio() float64 {
+	if cm.InitialRenterFunds.IsZero() {
+		return 0
+	}
+	spent, _ := new(big.Rat).SetFrac(cm.Spending.Total().Big(), cm.InitialRenterFunds.Big()).Float64()
+	return spent
+}
+
+// HostVersionBelow returns true if the version of the contract's host is known
+// and lower than the given version.
+func (cm ContractMetadata) HostVersionBelow(version HostVersion) bool {
+	hv, err := ParseHostVersion(cm.HostVersion)
+	return err == nil && hv.Cmp(version) < 0
+}
+
 func (cm ContractMetadata) IsGood() bool {
 	return cm.Usability == ContractUsabilityGood
 }
 
+// SpendingCapReached returns true if the contract's total spending reached its
+// spending cap.
+func (cm ContractMetadata) SpendingCapReached() bool {
+	return !cm.SpendingCap.IsZero() && cm.Spending.Total().Cmp(cm.SpendingCap) >= 0
+}
+
+// SpendingCapExceeded returns true if recording the given spending would push
+// the contract's total spending beyond its spending cap.
+func (cm ContractMetadata) SpendingCapExceeded(newSpending ContractSpending) bool {
+	return !cm.SpendingCap.IsZero() && cm.Spending.Total().Add(newSpending.Total()).Cmp(cm.SpendingCap) > 0
+}
+
 type (
 	Revision struct {
 		ContractID      types.FileContractID `json:"contractID"`
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestContractStateFSM(t *testing.T) {
+	states := []ContractState{
+		ContractStateInvalid,
+		ContractStateUnknown,
+		ContractStatePending,
+		ContractStateActive,
+		ContractStateComplete,
+		ContractStateFailed,
+	}
+
+	valid := map[[2]ContractState]bool{
+		{ContractStatePending, ContractStateActive}:   true,
+		{ContractStatePending, ContractStateComplete}: true,
+		{ContractStatePending, ContractStateFailed}:   true,
+		{ContractStateActive, ContractStatePending}:   true,
+		{ContractStateActive, ContractStateComplete}:  true,
+		{ContractStateActive, ContractStateFailed}:    true,
+		{ContractStateComplete, ContractStatePending}: true,
+		{ContractStateComplete, ContractStateActive}:  true,
+		{ContractStateFailed, ContractStatePending}:   true,
+		{ContractStateFailed, ContractStateActive}:    true,
+		{ContractStateFailed, ContractStateComplete}:  true,
+		{ContractStateUnknown, ContractStatePending}:  true,
+		{ContractStateUnknown, ContractStateActive}:   true,
+		{ContractStateUnknown, ContractStateComplete}: true,
+		{ContractStateUnknown, ContractStateFailed}:   true,
+	}
+
+	for _, from := range states {
+		for _, to := range states {
+			err := ValidateStateTransition(from, to)
+			if valid[[2]ContractState{from, to}] && err != nil {
+				t.Errorf("%v -> %v: unexpected error %v", from, to, err)
+			} else if !valid[[2]ContractState{from, to}] && !errors.Is(err, ErrInvalidContractStateTransition) {
+				t.Errorf("%v -> %v: expected ErrInvalidContractStateTransition, got %v", from, to, err)
+			}
+		}
+	}
+}
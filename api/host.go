@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -65,6 +67,28 @@ type (
 )
 
 type (
+	// HostScoreResponse is the response type for the /host/:hostkey/score
+	// endpoint. It contains the host's score breakdown, as computed by the
+	// autopilot during its last host check, and the resulting overall score.
+	HostScoreResponse struct {
+		HostScoreBreakdown
+		Overall float64 `json:"overall"`
+	}
+
+	// HostSettingsResponse is the response type for the
+	// /host/:hostkey/settings endpoint. It contains the settings fetched from
+	// the host and the time they were fetched at.
+	HostSettingsResponse struct {
+		Settings rhp4.HostSettings `json:"settings"`
+		CachedAt time.Time         `json:"cachedAt"`
+	}
+
+	// HostSectorSizeRequest is the request type for the
+	// /host/:hostkey/sectorsize endpoint.
+	HostSectorSizeRequest struct {
+		SectorSize uint64 `json:"sectorSize"`
+	}
+
 	// UpdateAllowlistRequest is the request type for /hosts/allowlist endpoint.
 	UpdateAllowlistRequest struct {
 		Add    []types.PublicKey `json:"add"`
@@ -105,6 +129,11 @@ type (
 		Checks            HostChecks        `json:"checks,omitempty"`
 		StoredData        uint64            `json:"storedData"`
 		V2SiamuxAddresses []string          `json:"v2SiamuxAddresses"`
+
+		// SectorSize is the size of the host's sectors, it defaults to
+		// rhpv4.SectorSize and only needs to be overridden for hosts that
+		// use a non-standard sector size.
+		SectorSize uint64 `json:"sectorSize"`
 	}
 
 	HostInfo struct {
@@ -236,8 +265,8 @@ func (hgb HostGougingBreakdown) Gouging() bool {
 	return false
 }
 
-func (hgb HostGougingBreakdown) String() string {
-	var reasons []string
+// Reasons returns the reasons for why the host is considered to be gouging.
+func (hgb HostGougingBreakdown) Reasons() (reasons []string) {
 	for _, errStr := range []string{
 		hgb.DownloadErr,
 		hgb.GougingErr,
@@ -248,7 +277,11 @@ func (hgb HostGougingBreakdown) String() string {
 			reasons = append(reasons, errStr)
 		}
 	}
-	return strings.Join(reasons, ";")
+	return
+}
+
+func (hgb HostGougingBreakdown) String() string {
+	return strings.Join(hgb.Reasons(), ";")
 }
 
 func (sb HostScoreBreakdown) Score() float64 {
@@ -291,3 +324,46 @@ func (ub HostUsabilityBreakdown) UnusableReasons() []string {
 	}
 	return reasons
 }
+
+// hostVersionRegex matches the version in a host's release string, e.g.
+// "hostd v2.1.0", the patch version is optional.
+var hostVersionRegex = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// HostVersion is the semantic version of a host's software.
+type HostVersion [3]uint64
+
+// ParseHostVersion extracts the version from a host's release string.
+func ParseHostVersion(release string) (v HostVersion, _ error) {
+	match := hostVersionRegex.FindStringSubmatch(release)
+	if match == nil {
+		return HostVersion{}, fmt.Errorf("no version found in '%s'", release)
+	}
+	for i, part := range match[1:] {
+		if part == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return HostVersion{}, fmt.Errorf("invalid version '%s': %w", release, err)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// Cmp compares v and w and returns -1 if v < w, 0 if v == w and 1 if v > w.
+func (v HostVersion) Cmp(w HostVersion) int {
+	for i := range v {
+		if v[i] < w[i] {
+			return -1
+		} else if v[i] > w[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// String implements fmt.Stringer.
+func (v HostVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
@@ -25,3 +25,35 @@ func TestMarshalHostScoreBreakdownJSON(t *testing.T) {
 		t.Fatal("expected a score field")
 	}
 }
+
+func TestParseHostVersion(t *testing.T) {
+	tests := []struct {
+		release  string
+		expected HostVersion
+		valid    bool
+	}{
+		{"hostd v2.1.0", HostVersion{2, 1, 0}, true},
+		{"1.6.0", HostVersion{1, 6, 0}, true},
+		{"v1.6", HostVersion{1, 6, 0}, true},
+		{"hostd v2.0.0-beta.1", HostVersion{2, 0, 0}, true},
+		{"", HostVersion{}, false},
+		{"test", HostVersion{}, false},
+	}
+	for _, test := range tests {
+		v, err := ParseHostVersion(test.release)
+		if test.valid != (err == nil) {
+			t.Fatalf("%q: unexpected error %v", test.release, err)
+		} else if v != test.expected {
+			t.Fatalf("%q: expected %v, got %v", test.release, test.expected, v)
+		}
+	}
+
+	// assert versions are compared numerically
+	if (HostVersion{1, 10, 0}).Cmp(HostVersion{1, 9, 0}) != 1 {
+		t.Fatal("expected 1.10.0 > 1.9.0")
+	} else if (HostVersion{1, 9, 9}).Cmp(HostVersion{2, 0, 0}) != -1 {
+		t.Fatal("expected 1.9.9 < 2.0.0")
+	} else if (HostVersion{2, 0, 0}).Cmp(HostVersion{2, 0, 0}) != 0 {
+		t.Fatal("expected 2.0.0 == 2.0.0")
+	}
+}
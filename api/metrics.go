@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,10 @@ import (
 
 var (
 	ErrMaxIntervalsExceeded = fmt.Errorf("max number of intervals exceeds maximum of %v", MetricMaxIntervals)
+
+	// ErrInvalidSpendingGranularity is returned when the granularity of a
+	// contract spending history query is not one of the supported values.
+	ErrInvalidSpendingGranularity = errors.New("invalid granularity, must be one of 'hour', 'day' or 'week'")
 )
 
 const (
@@ -20,6 +25,12 @@ const (
 	MetricWallet        = "wallet"
 )
 
+const (
+	SpendingGranularityHour = "hour"
+	SpendingGranularityDay  = "day"
+	SpendingGranularityWeek = "week"
+)
+
 type (
 	PerformanceMetricsQueryOpts struct {
 		Action  string
@@ -48,6 +59,13 @@ type (
 		HostKey    types.PublicKey
 	}
 
+	// ContractSpendingBucket contains the amount spent on a contract within
+	// the time window starting at Timestamp.
+	ContractSpendingBucket struct {
+		Timestamp TimeRFC3339 `json:"timestamp"`
+		ContractSpending
+	}
+
 	ContractPruneMetric struct {
 		Timestamp TimeRFC3339 `json:"timestamp"`
 
@@ -87,3 +105,18 @@ type (
 		Metrics []ContractMetric `json:"metrics"`
 	}
 )
+
+// SpendingGranularityInterval returns the duration of a bucket of the given
+// spending granularity.
+func SpendingGranularityInterval(granularity string) (time.Duration, error) {
+	switch granularity {
+	case SpendingGranularityHour:
+		return time.Hour, nil
+	case SpendingGranularityDay:
+		return 24 * time.Hour, nil
+	case SpendingGranularityWeek:
+		return 7 * 24 * time.Hour, nil
+	default:
+		return 0, ErrInvalidSpendingGranularity
+	}
+}
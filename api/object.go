@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/object"
 )
 
@@ -25,6 +26,15 @@ const (
 
 	SortDirAsc  = "asc"
 	SortDirDesc = "desc"
+
+	// MaxObjectUserMetadataEntries is the maximum number of user metadata
+	// entries an object can have.
+	MaxObjectUserMetadataEntries = 50
+
+	// MaxObjectUserMetadataKeyLen and MaxObjectUserMetadataValueLen are the
+	// maximum lengths, in bytes, of a user metadata key and value.
+	MaxObjectUserMetadataKeyLen   = 128
+	MaxObjectUserMetadataValueLen = 256
 )
 
 var (
@@ -36,6 +46,10 @@ var (
 	// database.
 	ErrObjectNotFound = errors.New("object not found")
 
+	// ErrObjectVersionNotFound is returned when an object version can't be
+	// retrieved from the database.
+	ErrObjectVersionNotFound = errors.New("object version not found")
+
 	// ErrObjectCorrupted is returned if we were unable to retrieve the object
 	// from the database.
 	ErrObjectCorrupted = errors.New("object corrupted")
@@ -55,6 +69,10 @@ var (
 	// ErrUnsupportedDelimiter is returned when an unsupported delimiter is
 	// provided.
 	ErrUnsupportedDelimiter = errors.New("unsupported delimiter")
+
+	// ErrInvalidObjectUserMetadata is returned when user metadata exceeds the
+	// limits on the number of entries or the length of keys and values.
+	ErrInvalidObjectUserMetadata = errors.New("invalid object user metadata")
 )
 
 type (
@@ -85,6 +103,38 @@ type (
 	// well
 	ObjectUserMetadata map[string]string
 
+	// UpdateObjectUserMetadataRequest is the request type for the PUT
+	// /bus/metadata/*key endpoint.
+	UpdateObjectUserMetadataRequest struct {
+		Bucket   string             `json:"bucket"`
+		Metadata ObjectUserMetadata `json:"metadata"`
+	}
+
+	// UpdateObjectMimeTypeRequest is the request type for the PUT
+	// /bus/mimetype/*key endpoint. An empty mime type resets it, in which case
+	// the content type is deduced from the object's extension.
+	UpdateObjectMimeTypeRequest struct {
+		Bucket   string `json:"bucket"`
+		MimeType string `json:"mimeType"`
+	}
+
+	// CostEstimate is the response type for the GET /bus/costestimate/*key
+	// endpoint. All costs are in Hastings. Estimates are based on the current
+	// prices of the hosts the renter has good contracts with, weighted by how
+	// much was spent on uploads to them since their contracts were formed or
+	// last renewed, prices may change so the actual cost can differ.
+	CostEstimate struct {
+		// UploadCost is paid upfront when the object is uploaded.
+		UploadCost types.Currency `json:"uploadCost"`
+		// StorageCost is the ongoing cost of storing the object for the
+		// requested duration, it is paid as the object is stored.
+		StorageCost types.Currency `json:"storageCost"`
+		// DownloadCost is the cost of downloading the object once.
+		DownloadCost types.Currency `json:"downloadCost"`
+		// TotalCost is the sum of the upload, storage and download cost.
+		TotalCost types.Currency `json:"totalCost"`
+	}
+
 	// GetObjectResponse is the response type for the GET /worker/object endpoint.
 	GetObjectResponse struct {
 		Content io.ReadCloser `json:"content"`
@@ -109,6 +159,44 @@ type (
 		Objects    []ObjectMetadata `json:"objects"`
 	}
 
+	// ObjectsGCResponse is the response type for the /bus/objects/gc endpoint.
+	ObjectsGCResponse struct {
+		Deleted int64 `json:"deleted"`
+	}
+
+	// ObjectVersion contains the metadata of an object that was preserved when
+	// the object was overwritten in a bucket with copy-on-write enabled.
+	ObjectVersion struct {
+		ID        int64       `json:"id"`
+		Bucket    string      `json:"bucket"`
+		Key       string      `json:"key"`
+		CreatedAt TimeRFC3339 `json:"createdAt"`
+		ETag      string      `json:"eTag,omitempty"`
+		MimeType  string      `json:"mimeType,omitempty"`
+		Size      int64       `json:"size"`
+	}
+
+	// ObjectVersionsRequest is the request type for the /bus/objects/versions
+	// endpoint.
+	ObjectVersionsRequest struct {
+		Bucket string `json:"bucket"`
+		Key    string `json:"key"`
+	}
+
+	// ObjectVersionsResponse is the response type for the
+	// /bus/objects/versions endpoint.
+	ObjectVersionsResponse struct {
+		Versions []ObjectVersion `json:"versions"`
+	}
+
+	// ObjectVersionRestoreRequest is the request type for the
+	// /bus/objects/versions/restore endpoint.
+	ObjectVersionRestoreRequest struct {
+		Bucket string `json:"bucket"`
+		Key    string `json:"key"`
+		ID     int64  `json:"id"`
+	}
+
 	// ObjectsRemoveRequest is the request type for the /bus/objects/remove endpoint.
 	ObjectsRemoveRequest struct {
 		Bucket string `json:"bucket"`
@@ -122,6 +210,36 @@ type (
 		From   string `json:"from"`
 		To     string `json:"to"`
 		Mode   string `json:"mode"`
+
+		// ToBucket moves the object to another bucket, it defaults to Bucket
+		// and is only supported when renaming a single object.
+		ToBucket string `json:"toBucket,omitempty"`
+	}
+
+	// ObjectsVerifyRequest is the request type for the /worker/objects/verify
+	// endpoint.
+	ObjectsVerifyRequest struct {
+		Bucket  string     `json:"bucket"`
+		Key     string     `json:"key"`
+		Timeout DurationMS `json:"timeout"`
+	}
+
+	// ObjectVerifyResponse is the response type for the /worker/objects/verify
+	// endpoint. Healthy is the lowest number of retrievable shards across all
+	// slabs of the object and Total is the number of shards of that slab, the
+	// object can be downloaded as long as Healthy is at least MinShards.
+	ObjectVerifyResponse struct {
+		Healthy      int           `json:"healthy"`
+		Total        int           `json:"total"`
+		MinShards    int           `json:"minShards"`
+		FailedShards []ShardHealth `json:"failedShards"`
+	}
+
+	// ShardHealth describes the outcome of probing a host for a shard.
+	ShardHealth struct {
+		HostKey types.PublicKey `json:"hostKey"`
+		Root    types.Hash256   `json:"root"`
+		Error   string          `json:"error,omitempty"`
 	}
 
 	ObjectsStatsOpts struct {
@@ -140,6 +258,12 @@ type (
 	}
 )
 
+// IsHealthy returns true if every slab of the object has at least MinShards
+// retrievable shards.
+func (r ObjectVerifyResponse) IsHealthy() bool {
+	return r.Healthy >= r.MinShards
+}
+
 func ExtractObjectUserMetadataFrom(metadata map[string]string) ObjectUserMetadata {
 	oum := make(map[string]string)
 	for k, v := range metadata {
@@ -184,8 +308,9 @@ type (
 
 	// CopyObjectOptions is the options type for the bus client.
 	CopyObjectOptions struct {
-		MimeType string
-		Metadata ObjectUserMetadata
+		MimeType  string
+		Metadata  ObjectUserMetadata
+		Overwrite bool
 	}
 
 	// CopyObjectsRequest is the request type for the /bus/objects/copy endpoint.
@@ -198,6 +323,10 @@ type (
 
 		MimeType string             `json:"mimeType"`
 		Metadata ObjectUserMetadata `json:"metadata"`
+
+		// Overwrite indicates whether an existing object at the destination
+		// should be replaced, if false ErrObjectExists is returned instead.
+		Overwrite bool `json:"overwrite"`
 	}
 
 	HeadObjectOptions struct {
@@ -208,6 +337,11 @@ type (
 	DownloadObjectOptions struct {
 		Download *bool
 		Range    *DownloadRange
+
+		// RedundancyFactor is the number of shards that are downloaded in
+		// parallel on top of the slab's MinShards, the first MinShards
+		// shards to arrive are used to recover the slab.
+		RedundancyFactor int
 	}
 
 	GetObjectOptions struct {
@@ -223,6 +357,10 @@ type (
 		SortDir           string
 		Substring         string
 		SlabEncryptionKey object.EncryptionKey
+
+		// Metadata filters the objects by user metadata, only objects that
+		// have all the given entries are returned.
+		Metadata ObjectUserMetadata
 	}
 
 	// UploadObjectOptions is the options type for the worker client.
@@ -328,6 +466,53 @@ func (opts ListObjectOptions) Apply(values url.Values) {
 	if opts.SlabEncryptionKey != (object.EncryptionKey{}) {
 		values.Set("slabencryptionkey", opts.SlabEncryptionKey.String())
 	}
+	if len(opts.Metadata) > 0 {
+		values.Set("metadata", opts.Metadata.encode())
+	}
+}
+
+// LoadString implements jape.LoadString, the metadata is expected to be
+// encoded as a URL query string.
+func (md *ObjectUserMetadata) LoadString(s string) error {
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return err
+	}
+	*md = make(ObjectUserMetadata, len(values))
+	for k, v := range values {
+		if len(v) != 1 {
+			return fmt.Errorf("metadata key %q must have exactly one value", k)
+		}
+		(*md)[k] = v[0]
+	}
+	return nil
+}
+
+// encode encodes the metadata as a URL query string.
+func (md ObjectUserMetadata) encode() string {
+	values := url.Values{}
+	for k, v := range md {
+		values.Set(k, v)
+	}
+	return values.Encode()
+}
+
+// Validate returns an error if the metadata exceeds the limits on the number
+// of entries or the length of its keys and values.
+func (md ObjectUserMetadata) Validate() error {
+	if len(md) > MaxObjectUserMetadataEntries {
+		return fmt.Errorf("%w: %d entries exceed the maximum of %d", ErrInvalidObjectUserMetadata, len(md), MaxObjectUserMetadataEntries)
+	}
+	for k, v := range md {
+		if k == "" {
+			return fmt.Errorf("%w: keys must not be empty", ErrInvalidObjectUserMetadata)
+		} else if len(k) > MaxObjectUserMetadataKeyLen {
+			return fmt.Errorf("%w: key %q exceeds the maximum length of %d", ErrInvalidObjectUserMetadata, k, MaxObjectUserMetadataKeyLen)
+		} else if len(v) > MaxObjectUserMetadataValueLen {
+			return fmt.Errorf("%w: value of key %q exceeds the maximum length of %d", ErrInvalidObjectUserMetadata, k, MaxObjectUserMetadataValueLen)
+		}
+	}
+	return nil
 }
 
 func FormatETag(eTag string) string {
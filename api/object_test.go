@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestObjectUserMetadataValidation(t *testing.T) {
+	tooMany := make(ObjectUserMetadata)
+	for i := 0; i <= MaxObjectUserMetadataEntries; i++ {
+		tooMany[fmt.Sprint(i)] = "value"
+	}
+
+	tests := []struct {
+		md    ObjectUserMetadata
+		valid bool
+		desc  string
+	}{
+		{
+			md:    nil,
+			valid: true,
+			desc:  "no metadata",
+		},
+		{
+			md:    ObjectUserMetadata{strings.Repeat("k", MaxObjectUserMetadataKeyLen): strings.Repeat("v", MaxObjectUserMetadataValueLen)},
+			valid: true,
+			desc:  "max lengths",
+		},
+		{
+			md:    ObjectUserMetadata{"": "value"},
+			valid: false,
+			desc:  "empty key",
+		},
+		{
+			md:    ObjectUserMetadata{strings.Repeat("k", MaxObjectUserMetadataKeyLen+1): "value"},
+			valid: false,
+			desc:  "key too long",
+		},
+		{
+			md:    ObjectUserMetadata{"key": strings.Repeat("v", MaxObjectUserMetadataValueLen+1)},
+			valid: false,
+			desc:  "value too long",
+		},
+		{
+			md:    tooMany,
+			valid: false,
+			desc:  "too many entries",
+		},
+	}
+	for _, test := range tests {
+		err := test.md.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", test.desc, err)
+		} else if !test.valid && !errors.Is(err, ErrInvalidObjectUserMetadata) {
+			t.Errorf("%s: expected ErrInvalidObjectUserMetadata, got %v", test.desc, err)
+		}
+	}
+}
+
+func TestObjectUserMetadataEncoding(t *testing.T) {
+	md := ObjectUserMetadata{"a=b": "c&d", "e:f": "", "g h": "i\nj"}
+
+	var decoded ObjectUserMetadata
+	if err := decoded.LoadString(md.encode()); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(decoded, md) {
+		t.Fatalf("unexpected metadata %v", decoded)
+	}
+
+	// a key with multiple values is rejected
+	if err := decoded.LoadString("a=b&a=c"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -27,6 +27,10 @@ type (
 	// milliseconds.
 	DurationMS time.Duration
 
+	// ParamFloat64 is a helper type since jape expects float query params to
+	// implement the TextUnmarshaler interface.
+	ParamFloat64 float64
+
 	// ParamString is a helper type since jape expects query params to
 	// implement the TextMarshaler interface.
 	ParamString string
@@ -58,6 +62,21 @@ func (c *ParamCurrency) UnmarshalText(b []byte) error {
 	return err
 }
 
+// String implements fmt.Stringer.
+func (f ParamFloat64) String() string { return strconv.FormatFloat(float64(f), 'f', -1, 64) }
+
+// MarshalText implements encoding.TextMarshaler.
+func (f ParamFloat64) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *ParamFloat64) UnmarshalText(b []byte) error {
+	v, err := strconv.ParseFloat(string(b), 64)
+	*f = ParamFloat64(v)
+	return err
+}
+
 // String implements fmt.Stringer.
 func (s ParamString) String() string { return string(s) }
 
@@ -503,6 +503,92 @@ func formatSettingsMetricName(gp GougingParams, name string) (metrics []promethe
 	return
 }
 
+func (dsr DBStatsResponse) PrometheusMetric() (metrics []prometheus.Metric) {
+	for _, db := range []struct {
+		name      string
+		pool      DBPoolStats
+		durations DBDurationStats
+	}{
+		{"main", dsr.Main, dsr.MainDurations},
+		{"metrics", dsr.Metrics, dsr.MetricsDurations},
+	} {
+		labels := map[string]any{"db": db.name}
+		metrics = append(metrics, []prometheus.Metric{
+			{
+				Name:   "renterd_db_pool_maxopenconns",
+				Labels: labels,
+				Value:  float64(db.pool.MaxOpenConns),
+			},
+			{
+				Name:   "renterd_db_pool_open",
+				Labels: labels,
+				Value:  float64(db.pool.Open),
+			},
+			{
+				Name:   "renterd_db_pool_inuse",
+				Labels: labels,
+				Value:  float64(db.pool.InUse),
+			},
+			{
+				Name:   "renterd_db_pool_idle",
+				Labels: labels,
+				Value:  float64(db.pool.Idle),
+			},
+			{
+				Name:   "renterd_db_pool_waitcount",
+				Labels: labels,
+				Value:  float64(db.pool.WaitCount),
+			},
+			{
+				Name:   "renterd_db_pool_waitdurationms",
+				Labels: labels,
+				Value:  float64(time.Duration(db.pool.WaitDuration).Milliseconds()),
+			},
+		}...)
+
+		for query, h := range db.durations.Queries {
+			metrics = append(metrics, h.prometheusMetric("renterd_db_query_duration_seconds", map[string]any{"db": db.name, "query": query})...)
+		}
+		metrics = append(metrics, db.durations.Transactions.prometheusMetric("renterd_db_transaction_duration_seconds", labels)...)
+	}
+	return
+}
+
+func (h DBDurationHistogram) prometheusMetric(name string, labels map[string]any) (metrics []prometheus.Metric) {
+	withLabel := func(k string, v any) map[string]any {
+		l := make(map[string]any, len(labels)+1)
+		for k, v := range labels {
+			l[k] = v
+		}
+		l[k] = v
+		return l
+	}
+	for _, b := range h.Buckets {
+		metrics = append(metrics, prometheus.Metric{
+			Name:   name + "_bucket",
+			Labels: withLabel("le", b.UpperBound),
+			Value:  float64(b.Count),
+		})
+	}
+	return append(metrics, []prometheus.Metric{
+		{
+			Name:   name + "_bucket",
+			Labels: withLabel("le", "+Inf"),
+			Value:  float64(h.Count),
+		},
+		{
+			Name:   name + "_sum",
+			Labels: labels,
+			Value:  h.Sum,
+		},
+		{
+			Name:   name + "_count",
+			Labels: labels,
+			Value:  float64(h.Count),
+		},
+	}...)
+}
+
 func (gp GougingParams) PrometheusMetric() (metrics []prometheus.Metric) {
 	metrics = formatSettingsMetricName(gp, "gouging")
 	return
@@ -730,7 +816,14 @@ func (m MemoryResponse) PrometheusMetric() (metrics []prometheus.Metric) {
 }
 
 func (m DownloadStatsResponse) PrometheusMetric() (metrics []prometheus.Metric) {
-	return []prometheus.Metric{
+	for _, b := range m.BucketsStats {
+		metrics = append(metrics, prometheus.Metric{
+			Name:   "renterd_worker_stats_bucketdownloadspeedbytespersec",
+			Labels: map[string]any{"bucket": b.Bucket},
+			Value:  b.DownloadSpeedBytesPerSec,
+		})
+	}
+	return append(metrics, []prometheus.Metric{
 		{
 			Name:  "renterd_worker_stats_avgdownloadspeedmbps",
 			Value: m.AvgDownloadSpeedMBPS,
@@ -746,7 +839,7 @@ func (m DownloadStatsResponse) PrometheusMetric() (metrics []prometheus.Metric)
 		{
 			Name:  "renterd_worker_stats_numdownloaders",
 			Value: float64(m.NumDownloaders),
-		}}
+		}}...)
 }
 
 func (m UploadStatsResponse) PrometheusMetric() (metrics []prometheus.Metric) {
@@ -766,6 +859,30 @@ func (m UploadStatsResponse) PrometheusMetric() (metrics []prometheus.Metric) {
 		{
 			Name:  "renterd_worker_stats_numuploaders",
 			Value: float64(m.NumUploaders),
+		},
+		{
+			Name:  "renterd_worker_stats_slabuploadtimeouts",
+			Value: float64(m.SlabUploadTimeouts),
+		}}
+}
+
+func (m ObjectCacheStatsResponse) PrometheusMetric() (metrics []prometheus.Metric) {
+	return []prometheus.Metric{
+		{
+			Name:  "renterd_worker_stats_objectcache_entries",
+			Value: float64(m.Entries),
+		},
+		{
+			Name:  "renterd_worker_stats_objectcache_hits",
+			Value: float64(m.Hits),
+		},
+		{
+			Name:  "renterd_worker_stats_objectcache_misses",
+			Value: float64(m.Misses),
+		},
+		{
+			Name:  "renterd_worker_stats_objectcache_hitrate",
+			Value: m.HitRate,
 		}}
 }
 
@@ -20,9 +20,22 @@ type (
 		Locked   bool   `json:"locked"`   // whether the slab buffer is locked for uploading
 	}
 
+	// SlabBufferInfo contains metadata about an in-progress slab buffer.
+	SlabBufferInfo struct {
+		ID                   uint        `json:"id"`
+		Filename             string      `json:"filename"`
+		SizeBytesOnDisk      int64       `json:"sizeBytesOnDisk"`
+		CompletionThreshold  int64       `json:"completionThreshold"`
+		CreatedAt            TimeRFC3339 `json:"createdAt"`
+		LastWrittenAt        TimeRFC3339 `json:"lastWrittenAt"`
+		AssociatedObjectKeys []string    `json:"associatedObjectKeys"`
+	}
+
 	UnhealthySlab struct {
 		EncryptionKey object.EncryptionKey `json:"encryptionKey"`
 		Health        float64              `json:"health"`
+		HealthyShards uint8                `json:"healthyShards"`
+		TotalShards   uint8                `json:"totalShards"`
 	}
 
 	UploadedPackedSlab struct {
@@ -44,6 +44,15 @@ type (
 		DependsOn   []types.Transaction `json:"dependsOn"`
 	}
 
+	// WalletPendingOpts contains the options for filtering the events returned
+	// by the /wallet/pending endpoint. If Types is set, only events of the given
+	// types are returned. If MinAmount is set, only events with a siacoin
+	// inflow or outflow of at least MinAmount are returned.
+	WalletPendingOpts struct {
+		Types     []string
+		MinAmount types.Currency
+	}
+
 	// WalletRedistributeRequest is the request type for the /wallet/redistribute
 	// endpoint.
 	WalletRedistributeRequest struct {
@@ -59,6 +68,14 @@ type (
 		ScanHeight uint64        `json:"scanHeight"`
 	}
 
+	// WalletUnconfirmedEvent is an unconfirmed wallet event annotated with
+	// the fee it pays and an estimate of when it will be confirmed.
+	WalletUnconfirmedEvent struct {
+		Event                       wallet.Event   `json:"event"`
+		FeePerByte                  types.Currency `json:"feePerByte"`
+		EstimatedConfirmationBlocks uint64         `json:"estimatedConfirmationBlocks"`
+	}
+
 	WalletSendRequest struct {
 		Address          types.Address  `json:"address"`
 		Amount           types.Currency `json:"amount"`
@@ -87,28 +87,70 @@ type (
 	DownloadStatsResponse struct {
 		AvgDownloadSpeedMBPS float64           `json:"avgDownloadSpeedMbps"`
 		AvgOverdrivePct      float64           `json:"avgOverdrivePct"`
+		AutoRepairedSectors  uint64            `json:"autoRepairedSectors"`
 		HealthyDownloaders   uint64            `json:"healthyDownloaders"`
 		NumDownloaders       uint64            `json:"numDownloaders"`
 		DownloadersStats     []DownloaderStats `json:"downloadersStats"`
+
+		// BucketsStats contains the current download rate of every bucket
+		// the worker served downloads from.
+		BucketsStats []BucketDownloadStats `json:"bucketsStats"`
+	}
+	BucketDownloadStats struct {
+		Bucket                          string  `json:"bucket"`
+		DownloadSpeedBytesPerSec        float64 `json:"downloadSpeedBytesPerSec"`
+		MaxDownloadBandwidthBytesPerSec uint64  `json:"maxDownloadBandwidthBytesPerSec"`
 	}
 	DownloaderStats struct {
 		AvgSectorDownloadSpeedMBPS float64         `json:"avgSectorDownloadSpeedMbps"`
+		Deprioritized              uint64          `json:"deprioritized"`
 		HostKey                    types.PublicKey `json:"hostKey"`
 	}
 
+	// ObjectCacheStatsResponse is the response type for the /stats/cache
+	// endpoint.
+	ObjectCacheStatsResponse struct {
+		Entries int     `json:"entries"`
+		Hits    uint64  `json:"hits"`
+		Misses  uint64  `json:"misses"`
+		HitRate float64 `json:"hitRate"`
+	}
+
 	// UploadStatsResponse is the response type for the /stats/uploads endpoint.
 	UploadStatsResponse struct {
 		AvgSlabUploadSpeedMBPS float64         `json:"avgSlabUploadSpeedMbps"`
 		AvgOverdrivePct        float64         `json:"avgOverdrivePct"`
 		HealthyUploaders       uint64          `json:"healthyUploaders"`
 		NumUploaders           uint64          `json:"numUploaders"`
+		SlabUploadTimeouts     uint64          `json:"slabUploadTimeouts"`
 		UploadersStats         []UploaderStats `json:"uploadersStats"`
+
+		// SectorFilter is only set if the worker filters duplicate sector
+		// uploads.
+		SectorFilter *SectorFilterStats `json:"sectorFilter,omitempty"`
+	}
+	SectorFilterStats struct {
+		Lookups uint64 `json:"lookups"`
+		Hits    uint64 `json:"hits"`
 	}
 	UploaderStats struct {
 		HostKey                  types.PublicKey `json:"hostKey"`
 		AvgSectorUploadSpeedMBPS float64         `json:"avgSectorUploadSpeedMbps"`
 	}
 
+	// WorkerReadyCheck describes the outcome of a single readiness check.
+	WorkerReadyCheck struct {
+		Name   string `json:"name"`
+		Passed bool   `json:"passed"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	// WorkerReadyResponse is the response type for the /worker/ready endpoint.
+	WorkerReadyResponse struct {
+		Ready  bool               `json:"ready"`
+		Checks []WorkerReadyCheck `json:"checks"`
+	}
+
 	// WorkerStateResponse is the response type for the /worker/state endpoint.
 	WorkerStateResponse struct {
 		ID        string      `json:"id"`
@@ -0,0 +1,29 @@
+package autopilot
+
+import (
+	"fmt"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/alerts"
+)
+
+var (
+	alertLowWalletBalanceID = alerts.RandomAlertID() // constant until restarted
+)
+
+func newLowWalletBalanceAlert(balance, minBalance types.Currency) alerts.Alert {
+	return alerts.Alert{
+		ID:          alertLowWalletBalanceID,
+		Severity:    alerts.SeverityCritical,
+		Message:     "Autopilot operations are paused",
+		Description: fmt.Sprintf("The confirmed wallet balance of %v is below the configured minimum of %v, contract maintenance, migrations and pruning are paused until the balance is restored.", balance, minBalance),
+		Suggestion:  "Send funds to the wallet or lower the configured 'minWalletBalance'.",
+		Data: map[string]any{
+			"balance":          balance,
+			"minWalletBalance": minBalance,
+			"hint":             "The autopilot doesn't make any spending decisions while the confirmed wallet balance is below the configured minimum, only wallet maintenance is performed.",
+		},
+		Timestamp: time.Now(),
+	}
+}
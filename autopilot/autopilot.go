@@ -11,11 +11,13 @@ import (
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/jape"
+	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/autopilot/contractor"
 	"go.sia.tech/renterd/v2/autopilot/scanner"
 	"go.sia.tech/renterd/v2/build"
 	"go.sia.tech/renterd/v2/internal/utils"
+	"go.sia.tech/renterd/v2/object"
 	"go.uber.org/zap"
 )
 
@@ -38,17 +40,20 @@ type (
 
 	Contractor interface {
 		PerformContractMaintenance(context.Context, *contractor.MaintenanceState) (bool, error)
+		SubnetDistribution() map[string]uint64
 	}
 
 	Migrator interface {
 		Migrate(ctx context.Context)
+		RepairSlab(ctx context.Context, key object.EncryptionKey) (api.SlabRepairResponse, error)
 		SignalMaintenanceFinished()
 		Shutdown(ctx context.Context) error
 		Status() (bool, time.Time)
 	}
 
 	Pruner interface {
-		PerformContractPruning(context.Context)
+		PerformContractPruning(ctx context.Context, alertThreshold uint64)
+		UpdatePrunableDataAlert(ctx context.Context, threshold uint64)
 		Shutdown(ctx context.Context) error
 		Status() (bool, time.Time)
 	}
@@ -62,10 +67,12 @@ type (
 
 	WalletMaintainer interface {
 		PerformWalletMaintenance(ctx context.Context, cfg api.AutopilotConfig) error
+		Status() (backoffUntil time.Time, lastErr error)
 	}
 )
 
 type Autopilot struct {
+	alerts alerts.Alerter
 	bus    Bus
 	logger *zap.SugaredLogger
 
@@ -88,8 +95,9 @@ type Autopilot struct {
 }
 
 // New initializes an Autopilot.
-func New(ctx context.Context, cancel context.CancelCauseFunc, b Bus, c Contractor, m Migrator, p Pruner, s Scanner, w WalletMaintainer, heartbeat time.Duration, logger *zap.Logger) *Autopilot {
+func New(ctx context.Context, cancel context.CancelCauseFunc, a alerts.Alerter, b Bus, c Contractor, m Migrator, p Pruner, s Scanner, w WalletMaintainer, heartbeat time.Duration, logger *zap.Logger) *Autopilot {
 	return &Autopilot{
+		alerts: a,
 		bus:    b,
 		logger: logger.Named("autopilot").Sugar(),
 
@@ -109,9 +117,12 @@ func New(ctx context.Context, cancel context.CancelCauseFunc, b Bus, c Contracto
 // Handler returns an HTTP handler that serves the autopilot api.
 func (ap *Autopilot) Handler() http.Handler {
 	return jape.Mux(map[string]jape.Handler{
-		"POST   /config/evaluate": ap.configEvaluateHandlerPOST,
-		"GET    /state":           ap.stateHandlerGET,
-		"POST   /trigger":         ap.triggerHandlerPOST,
+		"POST   /config/evaluate":   ap.configEvaluateHandlerPOST,
+		"GET    /state":             ap.stateHandlerGET,
+		"POST   /slabs/:key/repair": ap.slabsRepairHandlerPOST,
+		"POST   /trigger":           ap.triggerHandlerPOST,
+
+		"GET    /wallet/maintenance/status": ap.walletMaintenanceStatusHandlerGET,
 	})
 }
 
@@ -391,6 +402,14 @@ func (ap *Autopilot) performMaintenance(forceScan bool, tickerFired chan struct{
 		ap.logger.Errorf("wallet maintenance failed, err: %v", err)
 	}
 
+	// pause all other operations if the wallet balance is too low
+	if paused, err := ap.pauseForLowBalance(apCfg.Contracts.MinWalletBalance); err != nil {
+		ap.logger.Errorw("aborting maintenance, failed to check wallet balance", zap.Error(err))
+		return
+	} else if paused {
+		return
+	}
+
 	// build maintenance state
 	buildState, err := ap.buildState(ap.shutdownCtx)
 	if err != nil {
@@ -418,10 +437,36 @@ func (ap *Autopilot) performMaintenance(forceScan bool, tickerFired chan struct{
 
 	// pruning
 	if apCfg.Contracts.Prune {
-		ap.pruner.PerformContractPruning(ap.shutdownCtx)
+		ap.pruner.PerformContractPruning(ap.shutdownCtx, apCfg.Contracts.PruneAlertThreshold)
 	} else {
 		ap.logger.Info("pruning disabled")
+		ap.pruner.UpdatePrunableDataAlert(ap.shutdownCtx, apCfg.Contracts.PruneAlertThreshold)
+	}
+}
+
+// pauseForLowBalance returns true if the confirmed wallet balance is below the
+// given minimum, in which case an alert is registered. The alert is dismissed
+// once the balance is restored.
+func (ap *Autopilot) pauseForLowBalance(minBalance types.Currency) (bool, error) {
+	ctx, cancel := context.WithTimeout(ap.shutdownCtx, time.Minute)
+	defer cancel()
+
+	if minBalance.IsZero() {
+		return false, ap.alerts.DismissAlerts(ctx, alertLowWalletBalanceID)
+	}
+
+	wallet, err := ap.bus.Wallet(ctx)
+	if err != nil {
+		return false, err
+	} else if wallet.Confirmed.Cmp(minBalance) >= 0 {
+		return false, ap.alerts.DismissAlerts(ctx, alertLowWalletBalanceID)
 	}
+
+	ap.logger.Warnw("autopilot operations are paused, the confirmed wallet balance is below the configured minimum", "balance", wallet.Confirmed, "minWalletBalance", minBalance)
+	if err := ap.alerts.RegisterAlert(ctx, newLowWalletBalanceAlert(wallet.Confirmed, minBalance)); err != nil {
+		ap.logger.Errorw("failed to register alert", zap.Error(err))
+	}
+	return true, nil
 }
 
 func (ap *Autopilot) tryScheduleTriggerWhenFunded() error {
@@ -484,6 +529,21 @@ func (ap *Autopilot) isStopped() bool {
 	}
 }
 
+func (ap *Autopilot) slabsRepairHandlerPOST(jc jape.Context) {
+	var key object.EncryptionKey
+	if jc.DecodeParam("key", &key) != nil {
+		return
+	}
+	resp, err := ap.migrator.RepairSlab(jc.Request.Context(), key)
+	if utils.IsErr(err, api.ErrSlabNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to repair slab", err) != nil {
+		return
+	}
+	jc.Encode(resp)
+}
+
 func (ap *Autopilot) triggerHandlerPOST(jc jape.Context) {
 	var req api.AutopilotTriggerRequest
 	if jc.Decode(&req) != nil {
@@ -515,6 +575,8 @@ func (ap *Autopilot) stateHandlerGET(jc jape.Context) {
 		ScanningLastStart:  api.TimeRFC3339(sLastStart),
 		UptimeMS:           api.DurationMS(ap.Uptime()),
 
+		SubnetDistribution: ap.contractor.SubnetDistribution(),
+
 		StartTime: api.TimeRFC3339(ap.StartTime()),
 		BuildState: api.BuildState{
 			Version:   build.Version(),
@@ -525,6 +587,19 @@ func (ap *Autopilot) stateHandlerGET(jc jape.Context) {
 	})
 }
 
+func (ap *Autopilot) walletMaintenanceStatusHandlerGET(jc jape.Context) {
+	backoffUntil, lastErr := ap.maintainer.Status()
+
+	var errStr string
+	if lastErr != nil {
+		errStr = lastErr.Error()
+	}
+	jc.Encode(api.WalletMaintenanceStatusResponse{
+		BackoffUntil: api.TimeRFC3339(backoffUntil),
+		LastError:    errStr,
+	})
+}
+
 func (ap *Autopilot) buildState(ctx context.Context) (*contractor.MaintenanceState, error) {
 	// fetch autopilot config
 	apCfg, err := ap.bus.AutopilotConfig(ctx)
@@ -2,9 +2,11 @@ package autopilot
 
 import (
 	"context"
+	"fmt"
 
 	"go.sia.tech/jape"
 	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/object"
 )
 
 // A Client provides methods for interacting with an autopilot.
@@ -26,6 +28,13 @@ func (c *Client) State(ctx context.Context) (state api.AutopilotStateResponse, e
 	return
 }
 
+// RepairSlab repairs the slab with the given key by uploading its missing
+// shards to good hosts.
+func (c *Client) RepairSlab(ctx context.Context, key object.EncryptionKey) (resp api.SlabRepairResponse, err error) {
+	err = c.c.POST(ctx, fmt.Sprintf("/slabs/%s/repair", key), nil, &resp)
+	return
+}
+
 // Trigger triggers an iteration of the autopilot's main loop.
 func (c *Client) Trigger(ctx context.Context, forceScan bool) (_ bool, err error) {
 	var resp api.AutopilotTriggerResponse
@@ -33,6 +42,13 @@ func (c *Client) Trigger(ctx context.Context, forceScan bool) (_ bool, err error
 	return resp.Triggered, err
 }
 
+// WalletMaintenanceStatus returns the status of the autopilot's wallet
+// maintenance.
+func (c *Client) WalletMaintenanceStatus(ctx context.Context) (resp api.WalletMaintenanceStatusResponse, err error) {
+	err = c.c.GET(ctx, "/wallet/maintenance/status", &resp)
+	return
+}
+
 // EvaluateConfig evaluates an autopilot config using the given gouging and
 // redundancy settings.
 func (c *Client) EvaluateConfig(ctx context.Context, cfg api.AutopilotConfig, gs api.GougingSettings, rs api.RedundancySettings) (resp api.ConfigEvaluationResponse, err error) {
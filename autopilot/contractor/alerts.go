@@ -1,6 +1,7 @@
 package contractor
 
 import (
+	"fmt"
 	"time"
 
 	"go.sia.tech/core/types"
@@ -17,22 +18,28 @@ const (
 
 var (
 	alertChurnID                      = alerts.RandomAlertID() // constant until restarted
+	alertContractLifetimeConflictID   = alerts.RandomAlertID() // constant until restarted
 	alertContractMaintenanceSkippedID = alerts.RandomAlertID() // constant until restarted
 	alertContractUsabilityUpdated     = alerts.RandomAlertID() // constant until restarted
+	alertContractsConcentratedID      = alerts.RandomAlertID() // constant until restarted
 	alertLostSectorsID                = alerts.RandomAlertID() // constant until restarted
 	alertRenewalFailedID              = alerts.RandomAlertID() // constant until restarted
 )
 
 func newContractRenewalFailedAlert(contract api.ContractMetadata, ourFault bool, err error) alerts.Alert {
 	severity := alerts.SeverityWarning
+	suggestion := "The host failed to renew the contract, if the issue persists the contract will eventually be replaced with a contract with another host."
 	if ourFault {
 		severity = alerts.SeverityCritical
+		suggestion = "Make sure the wallet has enough funds and the node is synced, if the contract isn't renewed before it expires the data stored with the host has to be migrated."
 	}
 
 	return alerts.Alert{
-		ID:       alerts.IDForContract(alertRenewalFailedID, contract.ID),
-		Severity: severity,
-		Message:  "Contract renewal failed",
+		ID:          alerts.IDForContract(alertRenewalFailedID, contract.ID),
+		Severity:    severity,
+		Message:     "Contract renewal failed",
+		Description: fmt.Sprintf("Renewing contract %v with host %v failed: %v", contract.ID, contract.HostKey, err),
+		Suggestion:  suggestion,
 		Data: map[string]interface{}{
 			"error":      err.Error(),
 			"hostError":  !ourFault,
@@ -45,9 +52,11 @@ func newContractRenewalFailedAlert(contract api.ContractMetadata, ourFault bool,
 
 func newLostSectorsAlert(hk types.PublicKey, version [3]uint8, release string, lostSectors uint64) alerts.Alert {
 	return alerts.Alert{
-		ID:       alerts.IDForHost(alertLostSectorsID, hk),
-		Severity: alerts.SeverityWarning,
-		Message:  "Host has lost sectors",
+		ID:          alerts.IDForHost(alertLostSectorsID, hk),
+		Severity:    alerts.SeverityWarning,
+		Message:     "Host has lost sectors",
+		Description: fmt.Sprintf("Host %v running version %v (%v) has reported that it lost %d sectors.", hk, version, release, lostSectors),
+		Suggestion:  "Consider blocking this host through the blocklist feature. If you think this was a mistake and you want to ignore this warning for now you can reset the lost sector count.",
 		Data: map[string]interface{}{
 			"lostSectors": lostSectors,
 			"hostKey":     hk.String(),
@@ -59,11 +68,44 @@ func newLostSectorsAlert(hk types.PublicKey, version [3]uint8, release string, l
 	}
 }
 
+func newContractsConcentratedAlert(below, total int, maxPerHost uint64) alerts.Alert {
+	return alerts.Alert{
+		ID:          alertContractsConcentratedID,
+		Severity:    alerts.SeverityWarning,
+		Message:     "Contracts are concentrated on few hosts",
+		Description: fmt.Sprintf("Only %d out of %d usable hosts have fewer than %d contracts.", below, total, maxPerHost),
+		Suggestion:  "Consider lowering 'maxContractsPerHost' or allowing contracts with more hosts to reduce the impact of a single host going offline.",
+		Data: map[string]interface{}{
+			"hostsBelowLimit":     below,
+			"hosts":               total,
+			"maxContractsPerHost": maxPerHost,
+			"hint":                fmt.Sprintf("Only %d out of %d usable hosts have fewer than %d contracts. Consider lowering 'maxContractsPerHost' or allowing contracts with more hosts to reduce the impact of a single host going offline.", below, total, maxPerHost),
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+func newContractLifetimeConflictAlert(err error) alerts.Alert {
+	return alerts.Alert{
+		ID:          alertContractLifetimeConflictID,
+		Severity:    alerts.SeverityWarning,
+		Message:     "Contract formations are skipped",
+		Description: fmt.Sprintf("Contracts can't be formed with the current configuration: %v", err),
+		Suggestion:  "Increase 'period' or 'renewWindow', or lower 'minContractLifetimeBlocks' in the contracts config.",
+		Data: map[string]interface{}{
+			"error": err.Error(),
+		},
+		Timestamp: time.Now(),
+	}
+}
+
 func newContractMaintenanceSkippedAlert(reason string) alerts.Alert {
 	return alerts.Alert{
-		ID:       alertContractMaintenanceSkippedID,
-		Severity: alerts.SeverityWarning,
-		Message:  "Contract maintenance is skipped",
+		ID:          alertContractMaintenanceSkippedID,
+		Severity:    alerts.SeverityWarning,
+		Message:     "Contract maintenance is skipped",
+		Description: fmt.Sprintf("Contract maintenance was skipped: %v", reason),
+		Suggestion:  "Contracts are not formed, renewed or refreshed until the reason for skipping maintenance is resolved.",
 		Data: map[string]interface{}{
 			"reason": reason,
 		},
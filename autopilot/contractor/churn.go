@@ -1,6 +1,7 @@
 package contractor
 
 import (
+	"fmt"
 	"time"
 
 	"go.sia.tech/core/types"
@@ -44,16 +45,20 @@ func (c accumulatedChurn) ApplyUpdates(updates []usabilityUpdate) alerts.Alert {
 	}
 
 	var hint string
+	var bad int
 	for _, updates := range c {
 		if updates[len(updates)-1].To == api.ContractUsabilityBad {
+			bad++
 			hint = "High usability churn can lead to a lot of unnecessary migrations, it might be necessary to tweak your configuration depending on the reason hosts are being discarded."
 		}
 	}
 
 	return alerts.Alert{
-		ID:       alertContractUsabilityUpdated,
-		Severity: alerts.SeverityInfo,
-		Message:  "Contract usability updated",
+		ID:          alertContractUsabilityUpdated,
+		Severity:    alerts.SeverityInfo,
+		Message:     "Contract usability updated",
+		Description: fmt.Sprintf("The usability of %d contracts was updated, %d of them are now bad.", len(c), bad),
+		Suggestion:  hint,
 		Data: map[string]interface{}{
 			"churn": c,
 			"hint":  hint,
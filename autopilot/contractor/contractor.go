@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"maps"
 	"math"
 	"sort"
 	"strings"
@@ -48,6 +49,11 @@ const (
 	// timeoutBroadcastRevision is the amount of time we wait for the broadcast
 	// of a revision to succeed.
 	timeoutBroadcastRevision = time.Minute
+
+	// urgentRenewalFeeMultiplier is the fee multiplier used for renewals of
+	// contracts that are within the second half of the renew window, these
+	// renewals need to be confirmed quickly.
+	urgentRenewalFeeMultiplier = 1.5
 )
 
 var (
@@ -63,8 +69,8 @@ type ConsensusStore interface {
 type ContractManager interface {
 	BroadcastContract(ctx context.Context, fcid types.FileContractID) (types.TransactionID, error)
 	ContractRevision(ctx context.Context, fcid types.FileContractID) (api.Revision, error)
-	FormContract(ctx context.Context, renterAddress types.Address, renterFunds types.Currency, hostKey types.PublicKey, hostCollateral types.Currency, endHeight uint64) (api.ContractMetadata, error)
-	RenewContract(ctx context.Context, fcid types.FileContractID, endHeight uint64, renterFunds, minNewCollateral types.Currency) (api.ContractMetadata, error)
+	FormContract(ctx context.Context, renterAddress types.Address, renterFunds types.Currency, hostKey types.PublicKey, hostCollateral types.Currency, endHeight uint64, collateralMultiplier float64) (api.ContractMetadata, error)
+	RenewContract(ctx context.Context, fcid types.FileContractID, endHeight uint64, renterFunds, minNewCollateral types.Currency, feeMultiplier float64) (api.ContractMetadata, error)
 }
 
 type Database interface {
@@ -105,6 +111,7 @@ type (
 		hs      HostScanner
 
 		churn  accumulatedChurn
+		growth growthTracker
 		logger *zap.SugaredLogger
 
 		allowRedundantHostIPs bool
@@ -114,6 +121,9 @@ type (
 		revisionSubmissionBuffer  uint64
 
 		firstRefreshFailure map[types.FileContractID]time.Time
+
+		mu                 sync.Mutex
+		subnetDistribution map[string]uint64
 	}
 
 	scoredHost struct {
@@ -145,7 +155,65 @@ func New(alerter alerts.Alerter, cs ConsensusStore, cm ContractManager, db Datab
 }
 
 func (c *Contractor) PerformContractMaintenance(ctx context.Context, state *MaintenanceState) (bool, error) {
-	return performContractMaintenance(newMaintenanceCtx(ctx, state), c.alerter, c.db, c.churn, c, c.cm, c, c.cs, c.hs, c, c.allowRedundantHostIPs, c.logger)
+	state = c.adjustPeriod(ctx, state)
+	mCtx := newMaintenanceCtx(ctx, state)
+	hf := newHostFilter(c.allowRedundantHostIPs, mCtx.ContractsConfig().MaxContractsPerSubnet, c.logger)
+	defer func() {
+		c.mu.Lock()
+		c.subnetDistribution = hf.SubnetDistribution()
+		c.mu.Unlock()
+	}()
+	return performContractMaintenance(mCtx, c.alerter, c.db, c.churn, c, c.cm, c, c.cs, c.hs, c, hf, c.logger)
+}
+
+// adjustPeriod tracks the growth of the data stored in active contracts and
+// returns a copy of the state with its period multiplied by the configured
+// period multiplier if the observed growth rate exceeds the expected one.
+func (c *Contractor) adjustPeriod(ctx context.Context, state *MaintenanceState) *MaintenanceState {
+	cs, err := c.cs.ConsensusState(ctx)
+	if err != nil {
+		c.logger.Warnw("failed to fetch consensus state to measure data growth", zap.Error(err))
+		return state
+	}
+	contracts, err := c.db.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeActive})
+	if err != nil {
+		c.logger.Warnw("failed to fetch contracts to measure data growth", zap.Error(err))
+		return state
+	}
+	var size uint64
+	for _, contract := range contracts {
+		size += contract.Size
+	}
+
+	// the storage projection is expressed in bytes of data, so the data
+	// stored in contracts is corrected for redundancy
+	growth := c.growth.Track(cs.BlockHeight, size)
+	if redundancy := state.RS.Redundancy(); redundancy >= 1 {
+		growth /= redundancy
+	}
+
+	cfg := state.ContractsConfig()
+	period, adjusted := cfg.AdjustedPeriod(growth)
+	if !adjusted {
+		return state
+	}
+	c.logger.Infow("adjusting period due to data growth",
+		"growthRateBytesPerBlock", growth,
+		"expectedGrowthRateBytesPerBlock", cfg.ExpectedGrowthPerBlock(),
+		"period", cfg.Period,
+		"adjustedPeriod", period,
+	)
+	adjustedState := *state
+	adjustedState.AP.Contracts.Period = period
+	return &adjustedState
+}
+
+// SubnetDistribution returns the number of hosts with good contracts per
+// subnet as of the last contract maintenance.
+func (c *Contractor) SubnetDistribution() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.subnetDistribution)
 }
 
 func (c *Contractor) formContract(ctx *mCtx, hs HostScanner, host api.Host, minInitialContractFunds types.Currency, logger *zap.SugaredLogger) (cm api.ContractMetadata, proceed bool, err error) {
@@ -187,7 +255,7 @@ func (c *Contractor) formContract(ctx *mCtx, hs HostScanner, host api.Host, minI
 	}
 
 	// form contract
-	contract, err := c.cm.FormContract(ctx, ctx.state.Address, renterFunds, hk, hostCollateral, endHeight)
+	contract, err := c.cm.FormContract(ctx, ctx.state.Address, renterFunds, hk, hostCollateral, endHeight, 1)
 	if err != nil {
 		return api.ContractMetadata{}, !utils.IsErr(err, wallet.ErrNotEnoughFunds), err
 	}
@@ -227,7 +295,7 @@ func (c *Contractor) refreshContract(ctx *mCtx, contract contract, host api.Host
 	minNewCollateral := MinCollateral.Mul64(2).Add(contractPrice)
 
 	// renew the contract
-	renewal, err := c.cm.RenewContract(ctx, contract.ID, contract.EndHeight(), renterFunds, minNewCollateral)
+	renewal, err := c.cm.RenewContract(ctx, contract.ID, contract.EndHeight(), renterFunds, minNewCollateral, 1)
 	if err != nil {
 		logger.Errorw(
 			"refresh failed",
@@ -284,13 +352,15 @@ func (c *Contractor) renewContract(ctx *mCtx, contract contract, host api.Host,
 	minNewCollateral := types.ZeroCurrency
 
 	// renew the contract
-	renewal, err := c.cm.RenewContract(ctx, fcid, endHeight, renterFunds, minNewCollateral)
+	feeMultiplier := renewalFeeMultiplier(ctx.AutopilotConfig(), cs.BlockHeight, contract.EndHeight())
+	renewal, err := c.cm.RenewContract(ctx, fcid, endHeight, renterFunds, minNewCollateral, feeMultiplier)
 	if err != nil {
 		logger.Errorw(
 			"renewal failed",
 			zap.Error(err),
 			"endHeight", endHeight,
 			"renterFunds", renterFunds,
+			"feeMultiplier", feeMultiplier,
 		)
 		if utils.IsErr(err, wallet.ErrNotEnoughFunds) && !utils.IsErrHost(err) {
 			return api.ContractMetadata{}, false, err
@@ -307,6 +377,17 @@ func (c *Contractor) renewContract(ctx *mCtx, contract contract, host api.Host,
 	return renewal, true, nil
 }
 
+// renewalFeeMultiplier returns the fee multiplier for the renewal of a contract
+// with the given end height. Every renewal happens within the renew window,
+// only renewals in the second half of it, which have been failing for a while,
+// use an increased fee to make sure they are confirmed quickly.
+func renewalFeeMultiplier(cfg api.AutopilotConfig, blockHeight, endHeight uint64) float64 {
+	if _, secondHalf := isUpForRenewal(cfg, endHeight, blockHeight); secondHalf {
+		return urgentRenewalFeeMultiplier
+	}
+	return 1
+}
+
 // broadcastRevisions broadcasts contract revisions, we only broadcast the
 // revision of good contracts since we're migrating away from bad contracts.
 func (c *Contractor) broadcastRevisions(ctx context.Context, contracts []api.ContractMetadata, logger *zap.SugaredLogger) {
@@ -398,6 +479,21 @@ func (c *Contractor) shouldArchive(contract contract, bh uint64, n consensus.Net
 	return nil
 }
 
+// archivalReason returns the archival reason for an error returned by
+// shouldArchive.
+func archivalReason(err error) string {
+	switch {
+	case errors.Is(err, errContractExpired):
+		return api.ContractArchivalReasonExpired
+	case errors.Is(err, errContractNotConfirmed):
+		return api.ContractArchivalReasonNotConfirmed
+	case errors.Is(err, errContractRenewed):
+		return api.ContractArchivalReasonRenewed
+	default:
+		return err.Error()
+	}
+}
+
 func (c *Contractor) shouldForgiveFailedRefresh(fcid types.FileContractID) bool {
 	lastFailure, exists := c.firstRefreshFailure[fcid]
 	if !exists {
@@ -611,6 +707,9 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, s Database, churn
 	// define a helper to a contract's usability
 	log := logger.Named("usability")
 	updateUsability := func(ctx context.Context, h api.Host, c api.ContractMetadata, usability, context string) {
+		if c.UsabilityOverride {
+			usability = c.Usability // the user overrode the usability
+		}
 		if c.Usability != usability {
 			log = log.
 				With("contractID", c.ID).
@@ -662,7 +761,7 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, s Database, churn
 
 		// check if contract is ready to be archived.
 		if reason := cc.shouldArchive(c, cs.BlockHeight, network); reason != nil {
-			if err := s.ArchiveContracts(ctx, map[types.FileContractID]string{c.ID: reason.Error()}); err != nil {
+			if err := s.ArchiveContracts(ctx, map[types.FileContractID]string{c.ID: archivalReason(reason)}); err != nil {
 				logger.With(zap.Error(err)).Error("failed to archive contract")
 			} else {
 				logger.With("reason", reason).Info("successfully archived contract")
@@ -688,6 +787,13 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, s Database, churn
 			continue
 		}
 
+		// check if contract reached its spending cap
+		if cm.SpendingCapReached() {
+			logger.Info("contract reached its spending cap")
+			updateUsability(ctx, host, cm, api.ContractUsabilityBad, api.ErrContractSpendingCapExceeded.Error())
+			continue
+		}
+
 		// check if host has a redundant ip
 		if hf.HasRedundantIP(ctx, host) {
 			logger.Info("host has redundant IP")
@@ -815,8 +921,19 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, s Database, churn
 
 // performContractFormations forms up to 'wanted' new contracts with hosts. The
 // 'ipFilter' and 'remainingFunds' are updated with every new contract.
-func performContractFormations(ctx *mCtx, bus Database, cr contractReviser, hf hostFilter, hs HostScanner, logger *zap.SugaredLogger) (uint64, error) {
+func performContractFormations(ctx *mCtx, alerter alerts.Alerter, bus Database, cr contractReviser, hf hostFilter, hs HostScanner, logger *zap.SugaredLogger) (uint64, error) {
+	// make sure the contracts we form satisfy the minimum lifetime
+	if err := ctx.ContractsConfig().ValidateContractLifetime(); err != nil {
+		logger.Warnw("skipping contract formations", zap.Error(err))
+		if err := alerter.RegisterAlert(ctx, newContractLifetimeConflictAlert(err)); err != nil {
+			logger.With(zap.Error(err)).Error("failed to register contract lifetime conflict alert")
+		}
+		return 0, nil
+	}
+	alerter.DismissAlerts(ctx, alertContractLifetimeConflictID)
+
 	wanted := int(ctx.WantedContracts())
+	maxPerHost := max(ctx.ContractsConfig().MaxContractsPerHost, 1)
 
 	// fetch all active contracts
 	contracts, err := bus.Contracts(ctx, api.ContractsOpts{
@@ -826,19 +943,13 @@ func performContractFormations(ctx *mCtx, bus Database, cr contractReviser, hf h
 		return 0, fmt.Errorf("failed to fetch contracts: %w", err)
 	}
 
-	// collect all hosts
-	usedHosts := make(map[types.PublicKey]struct{})
+	// count the contracts per host
+	contractsPerHost := make(map[types.PublicKey]uint64)
 	for _, c := range contracts {
 		if c.IsGood() {
 			wanted--
 		}
-		usedHosts[c.HostKey] = struct{}{}
-	}
-
-	// return early if no more contracts are needed
-	if wanted <= 0 {
-		logger.Info("already have enough contracts, no need to form new ones")
-		return 0, nil
+		contractsPerHost[c.HostKey]++
 	}
 
 	// fetch all good hosts
@@ -850,28 +961,23 @@ func performContractFormations(ctx *mCtx, bus Database, cr contractReviser, hf h
 		return 0, fmt.Errorf("failed to fetch good hosts: %w", err)
 	}
 
-	// filter them
-	var candidates scoredHosts
-	for _, host := range allHosts {
-		logger := logger.With("hostKey", host.PublicKey)
-		if host.Checks == (api.HostChecks{}) {
-			logger.Warnf("missing host check %v", host.PublicKey)
-			continue
-		}
-		if _, used := usedHosts[host.PublicKey]; used {
-			logger.Debug("host already used")
-			continue
-		} else if score := host.Checks.ScoreBreakdown.Score(); score == 0 {
-			logger.Error("host has a score of 0")
-			continue
-		}
-		candidates = append(candidates, newScoredHost(host, host.Checks.ScoreBreakdown))
+	// register an alert if contracts are concentrated on too few hosts
+	if n := hostsBelowContractLimit(allHosts, contractsPerHost, maxPerHost); maxPerHost > 1 && len(allHosts) > 0 && 2*n < len(allHosts) {
+		alerter.RegisterAlert(ctx, newContractsConcentratedAlert(n, len(allHosts), maxPerHost))
+	} else {
+		alerter.DismissAlerts(ctx, alertContractsConcentratedID)
+	}
+
+	// return early if no more contracts are needed
+	if wanted <= 0 {
+		logger.Info("already have enough contracts, no need to form new ones")
+		return 0, nil
 	}
-	logger = logger.With("candidates", len(candidates))
 
-	// select hosts, since we already have all of them in memory we select
-	// len(candidates)
-	candidates = candidates.randSelectByScore(len(candidates))
+	// filter and select hosts, since we already have all of them in memory we
+	// select all candidates
+	candidates := formationCandidates(allHosts, contractsPerHost, maxPerHost, logger)
+	logger = logger.With("candidates", len(candidates))
 	if len(candidates) < wanted {
 		logger.Warn("insufficient candidate hosts to form the desired amount of new contracts")
 	}
@@ -926,6 +1032,44 @@ func performContractFormations(ctx *mCtx, bus Database, cr contractReviser, hf h
 	return nFormed, nil
 }
 
+// formationCandidates returns the hosts that are eligible for forming a new
+// contract with, hosts that have fewer than 'maxPerHost' active contracts. The
+// candidates are randomly ordered by score, hosts without any contracts always
+// come first.
+func formationCandidates(hosts []api.Host, contractsPerHost map[types.PublicKey]uint64, maxPerHost uint64, logger *zap.SugaredLogger) []scoredHost {
+	var unused, used scoredHosts
+	for _, host := range hosts {
+		logger := logger.With("hostKey", host.PublicKey)
+		if host.Checks == (api.HostChecks{}) {
+			logger.Warnf("missing host check %v", host.PublicKey)
+			continue
+		}
+		if n := contractsPerHost[host.PublicKey]; n >= maxPerHost {
+			logger.Debug("host already has the maximum number of contracts")
+			continue
+		} else if score := host.Checks.ScoreBreakdown.Score(); score == 0 {
+			logger.Error("host has a score of 0")
+			continue
+		} else if n == 0 {
+			unused = append(unused, newScoredHost(host, host.Checks.ScoreBreakdown))
+		} else {
+			used = append(used, newScoredHost(host, host.Checks.ScoreBreakdown))
+		}
+	}
+	return append(unused.randSelectByScore(len(unused)), used.randSelectByScore(len(used))...)
+}
+
+// hostsBelowContractLimit returns the number of hosts that have fewer than
+// 'maxPerHost' active contracts.
+func hostsBelowContractLimit(hosts []api.Host, contractsPerHost map[types.PublicKey]uint64, maxPerHost uint64) (n int) {
+	for _, host := range hosts {
+		if contractsPerHost[host.PublicKey] < maxPerHost {
+			n++
+		}
+	}
+	return
+}
+
 // performHostChecks performs scoring and usability checks on all hosts,
 // updating their state in the database.
 func performHostChecks(ctx *mCtx, bus Database, cs ConsensusStore, logger *zap.SugaredLogger) error {
@@ -1021,7 +1165,7 @@ func performPostMaintenanceTasks(ctx *mCtx, bus Database, alerter alerts.Alerter
 	return nil
 }
 
-func performContractMaintenance(ctx *mCtx, alerter alerts.Alerter, s Database, churn accumulatedChurn, cc contractChecker, cm ContractManager, cr contractReviser, cs ConsensusStore, hs HostScanner, rb revisionBroadcaster, allowRedundantHostIPs bool, logger *zap.SugaredLogger) (bool, error) {
+func performContractMaintenance(ctx *mCtx, alerter alerts.Alerter, s Database, churn accumulatedChurn, cc contractChecker, cm ContractManager, cr contractReviser, cs ConsensusStore, hs HostScanner, rb revisionBroadcaster, hf hostFilter, logger *zap.SugaredLogger) (bool, error) {
 	logger = logger.Named("performContractMaintenance").
 		Named(hex.EncodeToString(frand.Bytes(16))) // uuid for this iteration
 
@@ -1042,14 +1186,13 @@ func performContractMaintenance(ctx *mCtx, alerter alerts.Alerter, s Database, c
 	}
 
 	// STEP 2: perform contract maintenance
-	hf := newHostFilter(allowRedundantHostIPs, logger)
 	nUpdated, err := performContractChecks(ctx, alerter, s, churn, cc, cm, cr, cs, hf, logger)
 	if err != nil {
 		return false, err
 	}
 
 	// STEP 3: perform contract formation
-	nFormed, err := performContractFormations(ctx, s, cr, hf, hs, logger)
+	nFormed, err := performContractFormations(ctx, alerter, s, cr, hf, hs, logger)
 	if err != nil {
 		return false, err
 	}
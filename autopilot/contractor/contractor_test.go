@@ -37,6 +37,53 @@ func TestCalculateMinScore(t *testing.T) {
 	}
 }
 
+func TestFormationCandidates(t *testing.T) {
+	newHost := func(score float64) api.Host {
+		return api.Host{
+			PublicKey: types.GeneratePrivateKey().PublicKey(),
+			Checks: api.HostChecks{
+				ScoreBreakdown: api.HostScoreBreakdown{Age: score, Collateral: 1, Interactions: 1, StorageRemaining: 1, Uptime: 1, Version: 1, Prices: 1},
+			},
+		}
+	}
+
+	// create hosts with 0, 1, 2 and 3 contracts, a host without checks and a
+	// host with a score of 0
+	hosts := []api.Host{newHost(1), newHost(1), newHost(1), newHost(1), {PublicKey: types.GeneratePrivateKey().PublicKey()}, newHost(0)}
+	contractsPerHost := map[types.PublicKey]uint64{
+		hosts[1].PublicKey: 1,
+		hosts[2].PublicKey: 2,
+		hosts[3].PublicKey: 3,
+	}
+
+	// hosts at the limit are excluded, unused hosts come first
+	for i := 0; i < 10; i++ {
+		candidates := formationCandidates(hosts, contractsPerHost, 3, zap.NewNop().Sugar())
+		if len(candidates) != 3 {
+			t.Fatalf("expected 3 candidates, got %d", len(candidates))
+		} else if candidates[0].host.PublicKey != hosts[0].PublicKey {
+			t.Fatal("expected unused host to come first")
+		}
+		for _, c := range candidates {
+			if c.host.PublicKey == hosts[3].PublicKey {
+				t.Fatal("host at the contract limit should not be a candidate")
+			}
+		}
+	}
+
+	// a limit of 1 only allows unused hosts
+	if candidates := formationCandidates(hosts, contractsPerHost, 1, zap.NewNop().Sugar()); len(candidates) != 1 {
+		t.Fatalf("expected 1 candidate, got %d", len(candidates))
+	}
+
+	// assert the number of hosts below the limit
+	if n := hostsBelowContractLimit(hosts, contractsPerHost, 3); n != 5 {
+		t.Fatalf("expected 5 hosts below the limit, got %d", n)
+	} else if n := hostsBelowContractLimit(hosts, contractsPerHost, 2); n != 4 {
+		t.Fatalf("expected 4 hosts below the limit, got %d", n)
+	}
+}
+
 func TestRenewFundingEstimate(t *testing.T) {
 	tests := []struct {
 		name                 string
@@ -192,3 +239,26 @@ func TestShouldForgiveFailedRenewal(t *testing.T) {
 		t.Fatal("expected no failures")
 	}
 }
+
+func TestRenewalFeeMultiplier(t *testing.T) {
+	const renewWindow = 144
+	var cfg api.AutopilotConfig
+	cfg.Contracts.RenewWindow = renewWindow
+
+	tests := []struct {
+		blockHeight uint64
+		endHeight   uint64
+		want        float64
+	}{
+		{100, 100 + renewWindow + 1, 1},
+		{100, 100 + renewWindow, 1},
+		{100, 100 + renewWindow/2 + 1, 1},
+		{100, 100 + renewWindow/2, urgentRenewalFeeMultiplier},
+		{100, 101, urgentRenewalFeeMultiplier},
+	}
+	for _, test := range tests {
+		if got := renewalFeeMultiplier(cfg, test.blockHeight, test.endHeight); got != test.want {
+			t.Fatalf("bh %d, endHeight %d: expected %v, got %v", test.blockHeight, test.endHeight, test.want, got)
+		}
+	}
+}
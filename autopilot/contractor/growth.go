@@ -0,0 +1,57 @@
+package contractor
+
+import (
+	"sync"
+)
+
+const (
+	// growthRateWindow is the number of blocks over which the data growth
+	// rate is measured.
+	growthRateWindow = 144 // 1 day
+)
+
+type (
+	// growthTracker keeps track of the amount of data stored in contracts
+	// over time to measure the rate at which it grows.
+	growthTracker struct {
+		mu      sync.Mutex
+		samples []growthSample
+	}
+
+	growthSample struct {
+		height uint64
+		size   uint64
+	}
+)
+
+// Track adds a sample of the amount of data stored at the given height and
+// returns the observed growth in bytes per block. Samples that fall outside of
+// the measurement window are discarded, the growth is zero until samples
+// spanning at least one block have been tracked.
+func (gt *growthTracker) Track(height, size uint64) float64 {
+	gt.mu.Lock()
+	defer gt.mu.Unlock()
+
+	// a reorg or a reset invalidates all samples
+	if n := len(gt.samples); n > 0 && gt.samples[n-1].height > height {
+		gt.samples = gt.samples[:0]
+	}
+
+	if n := len(gt.samples); n > 0 && gt.samples[n-1].height == height {
+		gt.samples[n-1].size = size
+	} else {
+		gt.samples = append(gt.samples, growthSample{height: height, size: size})
+	}
+
+	// drop samples until the oldest one is the last sample at or before the
+	// start of the window
+	for len(gt.samples) > 1 && height-gt.samples[1].height >= growthRateWindow {
+		gt.samples = gt.samples[1:]
+	}
+
+	oldest := gt.samples[0]
+	if oldest.height == height || oldest.size >= size {
+		return 0
+	}
+	return float64(size-oldest.size) / float64(height-oldest.height)
+}
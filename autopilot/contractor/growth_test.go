@@ -0,0 +1,38 @@
+package contractor
+
+import "testing"
+
+func TestGrowthTracker(t *testing.T) {
+	var gt growthTracker
+
+	// a single sample doesn't allow for measuring growth
+	if rate := gt.Track(100, 1000); rate != 0 {
+		t.Fatalf("unexpected rate %v", rate)
+	}
+
+	// the rate is measured against the oldest sample
+	if rate := gt.Track(110, 2000); rate != 100 {
+		t.Fatalf("unexpected rate %v", rate)
+	} else if rate := gt.Track(120, 5000); rate != 200 {
+		t.Fatalf("unexpected rate %v", rate)
+	}
+
+	// shrinking data doesn't count as growth
+	if rate := gt.Track(130, 500); rate != 0 {
+		t.Fatalf("unexpected rate %v", rate)
+	}
+
+	// samples outside of the window are discarded
+	if rate := gt.Track(130+growthRateWindow, 500+growthRateWindow*10); rate != 10 {
+		t.Fatalf("unexpected rate %v", rate)
+	} else if len(gt.samples) != 2 {
+		t.Fatalf("expected 2 samples, got %v", len(gt.samples))
+	}
+
+	// a lower height resets the tracker
+	if rate := gt.Track(50, 1000); rate != 0 {
+		t.Fatalf("unexpected rate %v", rate)
+	} else if len(gt.samples) != 1 {
+		t.Fatalf("expected 1 sample, got %v", len(gt.samples))
+	}
+}
@@ -41,9 +41,10 @@ func hostScore(cfg api.AutopilotConfig, gs api.GougingSettings, h api.Host, expe
 	cCfg := cfg.Contracts
 
 	// idealDataPerHost is the amount of data that we would have to put on each
-	// host assuming that our storage requirements were spread evenly across
-	// every single host.
-	idealDataPerHost := float64(cCfg.Storage) * expectedRedundancy / float64(cCfg.Amount)
+	// host assuming that our storage requirements, including the projected
+	// growth, were spread evenly across every single host.
+	expectedStorage := float64(cCfg.Storage) + float64(cCfg.ProjectedGrowth())
+	idealDataPerHost := expectedStorage * expectedRedundancy / float64(cCfg.WantedContracts())
 
 	// allocationPerHost is the amount of data that we would like to be able to
 	// put on each host, because data is not always spread evenly across the
@@ -14,11 +14,13 @@ type (
 	hostFilter interface {
 		Add(ctx context.Context, host api.Host)
 		HasRedundantIP(ctx context.Context, host api.Host) bool
+		SubnetDistribution() map[string]uint64
 	}
 
 	hostSet struct {
+		maxPerSubnet      uint64
 		resolvedAddresses map[types.PublicKey][]net.IPAddr
-		subnetToHostKey   map[string]string
+		subnetToHostKeys  map[string]map[types.PublicKey]struct{}
 
 		logger *zap.SugaredLogger
 	}
@@ -27,14 +29,19 @@ type (
 
 func (n noopFilter) Add(context.Context, api.Host)                 {}
 func (n noopFilter) HasRedundantIP(context.Context, api.Host) bool { return false }
+func (n noopFilter) SubnetDistribution() map[string]uint64         { return nil }
 
-func newHostFilter(allowRedundantHostIPs bool, l *zap.SugaredLogger) hostFilter {
+// newHostFilter returns a filter that considers a host redundant if adding it
+// would exceed the maximum number of hosts per subnet, a zero maximum is
+// treated as one.
+func newHostFilter(allowRedundantHostIPs bool, maxPerSubnet uint64, l *zap.SugaredLogger) hostFilter {
 	if allowRedundantHostIPs {
 		return noopFilter{}
 	}
 	return &hostSet{
+		maxPerSubnet:      max(maxPerSubnet, 1),
 		resolvedAddresses: make(map[types.PublicKey][]net.IPAddr),
-		subnetToHostKey:   make(map[string]string),
+		subnetToHostKeys:  make(map[string]map[types.PublicKey]struct{}),
 		logger:            l,
 	}
 }
@@ -91,17 +98,16 @@ func (hs *hostSet) HasRedundantIP(ctx context.Context, host api.Host) bool {
 		return true
 	}
 
-	// check if we know about this subnet
-	var knownHost string
+	// the host is redundant if any of its subnets already contains the max
+	// number of other hosts
 	for _, subnet := range subnets {
-		if knownHost = hs.subnetToHostKey[subnet]; knownHost != "" {
-			break
+		others := uint64(len(hs.subnetToHostKeys[subnet]))
+		if _, ok := hs.subnetToHostKeys[subnet][host.PublicKey]; ok {
+			others--
+		}
+		if others >= hs.maxPerSubnet {
+			return true
 		}
-	}
-
-	// if we know about the subnet, the host is redundant if it's not the same
-	if knownHost != "" {
-		return host.PublicKey.String() != knownHost
 	}
 	return false
 }
@@ -118,6 +124,18 @@ func (hs *hostSet) Add(ctx context.Context, host api.Host) {
 		return
 	}
 	for _, subnet := range subnets {
-		hs.subnetToHostKey[subnet] = host.PublicKey.String()
+		if _, ok := hs.subnetToHostKeys[subnet]; !ok {
+			hs.subnetToHostKeys[subnet] = make(map[types.PublicKey]struct{})
+		}
+		hs.subnetToHostKeys[subnet][host.PublicKey] = struct{}{}
+	}
+}
+
+// SubnetDistribution returns the number of hosts that were added per subnet.
+func (hs *hostSet) SubnetDistribution() map[string]uint64 {
+	distribution := make(map[string]uint64, len(hs.subnetToHostKeys))
+	for subnet, hks := range hs.subnetToHostKeys {
+		distribution[subnet] = uint64(len(hks))
 	}
+	return distribution
 }
@@ -10,7 +10,7 @@ import (
 )
 
 func TestHostSet(t *testing.T) {
-	hs := newHostFilter(false, zap.NewNop().Sugar())
+	hs := newHostFilter(false, 1, zap.NewNop().Sugar())
 
 	// Host with no subnets
 	host1 := api.Host{
@@ -81,3 +81,40 @@ func TestHostSet(t *testing.T) {
 		t.Fatal("Expected host with one overlapping subnet to be considered redundant")
 	}
 }
+
+func TestHostSetMaxPerSubnet(t *testing.T) {
+	hs := newHostFilter(false, 2, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	newHost := func(addr string) api.Host {
+		return api.Host{
+			PublicKey:         types.GeneratePrivateKey().PublicKey(),
+			V2SiamuxAddresses: []string{addr},
+		}
+	}
+
+	// two hosts in the same subnet are allowed
+	host1 := newHost("4.4.4.4:4444")
+	host2 := newHost("4.4.4.5:4444")
+	if hs.HasRedundantIP(ctx, host1) {
+		t.Fatal("expected first host to not be redundant")
+	}
+	hs.Add(ctx, host1)
+	if hs.HasRedundantIP(ctx, host2) {
+		t.Fatal("expected second host to not be redundant")
+	}
+	hs.Add(ctx, host2)
+
+	// a third host in the same subnet is redundant, the others aren't
+	if !hs.HasRedundantIP(ctx, newHost("4.4.4.6:4444")) {
+		t.Fatal("expected third host to be redundant")
+	} else if hs.HasRedundantIP(ctx, host1) || hs.HasRedundantIP(ctx, host2) {
+		t.Fatal("expected added hosts to not be redundant")
+	}
+
+	// assert the distribution
+	distribution := hs.SubnetDistribution()
+	if len(distribution) != 1 || distribution["4.4.4.0/24"] != 2 {
+		t.Fatal("unexpected distribution", distribution)
+	}
+}
@@ -88,7 +88,7 @@ func (ctx *mCtx) Value(key interface{}) interface{} {
 }
 
 func (ctx *mCtx) WantedContracts() uint64 {
-	return ctx.state.AP.Contracts.Amount
+	return ctx.state.AP.Contracts.WantedContracts()
 }
 
 func (ctx *mCtx) WithTimeout(t time.Duration) (*mCtx, context.CancelFunc) {
@@ -64,11 +64,13 @@ func newMigrationFailedAlert(slabKey object.EncryptionKey, health float64, objec
 	}
 
 	return alerts.Alert{
-		ID:        alerts.IDForSlab(alertMigrationID, slabKey),
-		Severity:  severity,
-		Message:   "Slab migration failed",
-		Data:      data,
-		Timestamp: time.Now(),
+		ID:          alerts.IDForSlab(alertMigrationID, slabKey),
+		Severity:    severity,
+		Message:     "Slab migration failed",
+		Description: fmt.Sprintf("Migrating slab %v with a health of %.2f failed: %v", slabKey, health, err),
+		Suggestion:  "Migration failures can be temporary, but if they persist it can eventually lead to data loss and should therefore be taken very seriously.",
+		Data:        data,
+		Timestamp:   time.Now(),
 	}
 }
 
@@ -79,19 +81,22 @@ func newOngoingMigrationsAlert(n int, estimate time.Duration) alerts.Alert {
 	}
 
 	return alerts.Alert{
-		ID:        alertOngoingMigrationsID,
-		Severity:  alerts.SeverityInfo,
-		Message:   fmt.Sprintf("Migrating %d slabs", n),
-		Timestamp: time.Now(),
-		Data:      data,
+		ID:          alertOngoingMigrationsID,
+		Severity:    alerts.SeverityInfo,
+		Message:     fmt.Sprintf("Migrating %d slabs", n),
+		Description: fmt.Sprintf("%d slabs with a degraded health are queued for migration.", n),
+		Timestamp:   time.Now(),
+		Data:        data,
 	}
 }
 
 func newRefreshHealthFailedAlert(err error) alerts.Alert {
 	return alerts.Alert{
-		ID:       alertHealthRefreshID,
-		Severity: alerts.SeverityCritical,
-		Message:  "Health refresh failed",
+		ID:          alertHealthRefreshID,
+		Severity:    alerts.SeverityCritical,
+		Message:     "Health refresh failed",
+		Description: fmt.Sprintf("Refreshing the health of all slabs failed: %v", err),
+		Suggestion:  "Slabs are not migrated until their health was refreshed successfully, check the bus logs for database errors.",
 		Data: map[string]interface{}{
 			"error": err.Error(),
 		},
@@ -4,7 +4,6 @@ import (
 	"context"
 	"math"
 	"net"
-	"sort"
 	"sync"
 	"time"
 
@@ -52,6 +51,7 @@ type (
 		GougingParams(ctx context.Context) (api.GougingParams, error)
 		Host(ctx context.Context, hostKey types.PublicKey) (api.Host, error)
 		KeepaliveContract(ctx context.Context, fcid types.FileContractID, lockID uint64, d time.Duration) (err error)
+		ListBuckets(ctx context.Context) ([]api.Bucket, error)
 		MarkPackedSlabsUploaded(ctx context.Context, slabs []api.UploadedPackedSlab) error
 		Objects(ctx context.Context, prefix string, opts api.ListObjectOptions) (resp api.ObjectsResponse, err error)
 		RecordContractSpending(ctx context.Context, records []api.ContractSpendingRecord) error
@@ -78,8 +78,9 @@ type (
 		bus    Bus
 		ss     SlabStore
 
-		healthCutoff float64
-		numThreads   uint64
+		healthCutoff          float64
+		repairHealthThreshold float64
+		numThreads            uint64
 
 		accounts        *accounts.Manager
 		downloadManager *download.Manager
@@ -101,18 +102,20 @@ type (
 		mu                 sync.Mutex
 		migrating          bool
 		migratingLastStart time.Time
+		migratingSlabs     map[object.EncryptionKey]chan struct{}
 	}
 )
 
-func New(ctx context.Context, masterKey [32]byte, alerts alerts.Alerter, ss SlabStore, b Bus, healthCutoff float64, numThreads, downloadMaxOverdrive, uploadMaxOverdrive uint64, downloadOverdriveTimeout, uploadOverdriveTimeout, accountsRefillInterval time.Duration, logger *zap.Logger) (*Migrator, error) {
+func New(ctx context.Context, masterKey [32]byte, alerts alerts.Alerter, ss SlabStore, b Bus, healthCutoff, repairHealthThreshold float64, numThreads, downloadMaxOverdrive, uploadMaxOverdrive uint64, downloadOverdriveTimeout, uploadOverdriveTimeout, accountsRefillInterval time.Duration, logger *zap.Logger) (*Migrator, error) {
 	logger = logger.Named("migrator")
 	m := &Migrator{
 		alerts: alerts,
 		bus:    b,
 		ss:     ss,
 
-		healthCutoff: healthCutoff,
-		numThreads:   numThreads,
+		healthCutoff:          healthCutoff,
+		repairHealthThreshold: repairHealthThreshold,
+		numThreads:            numThreads,
 
 		signalConsensusNotSynced:  make(chan struct{}, 1),
 		signalMaintenanceFinished: make(chan struct{}, 1),
@@ -122,6 +125,8 @@ func New(ctx context.Context, masterKey [32]byte, alerts alerts.Alerter, ss Slab
 		shutdownCtx: ctx,
 
 		logger: logger.Sugar(),
+
+		migratingSlabs: make(map[object.EncryptionKey]chan struct{}),
 	}
 
 	// derive keys
@@ -139,13 +144,13 @@ func New(ctx context.Context, masterKey [32]byte, alerts alerts.Alerter, ss Slab
 	// create host manager
 	dialer := rhp.NewFallbackDialer(b, net.Dialer{}, logger)
 	csr := contracts.NewSpendingRecorder(ctx, b, 5*time.Second, logger)
-	m.hostManager = hosts.NewManager(masterKey, am, csr, dialer, logger)
 	m.rhp4Client = rhp4.New(dialer)
+	m.hostManager = hosts.NewManager(masterKey, am, csr, m.rhp4Client, logger)
 
 	// create upload & download manager
 	mm := memory.NewManager(math.MaxInt64, logger)
-	m.downloadManager = download.NewManager(ctx, &uk, m.hostManager, mm, b, downloadMaxOverdrive, downloadOverdriveTimeout, logger)
-	m.uploadManager = upload.NewManager(ctx, &uk, m.hostManager, mm, b, b, b, uploadMaxOverdrive, uploadOverdriveTimeout, logger)
+	m.downloadManager = download.NewManager(ctx, &uk, m.hostManager, mm, b, downloadMaxOverdrive, downloadOverdriveTimeout, uploadMaxOverdrive, logger)
+	m.uploadManager = upload.NewManager(ctx, &uk, m.hostManager, mm, b, b, b, nil, uploadMaxOverdrive, uploadOverdriveTimeout, 0, 0, logger)
 
 	return m, nil
 }
@@ -170,6 +175,56 @@ func (m *Migrator) Migrate(ctx context.Context) {
 	}()
 }
 
+// RepairSlab downloads the recoverable shards of the slab with the given key,
+// reconstructs the slab and uploads the missing shards to good hosts. Errors
+// that occur during the repair are reported in the response.
+func (m *Migrator) RepairSlab(ctx context.Context, key object.EncryptionKey) (api.SlabRepairResponse, error) {
+	m.wg.Add(1)
+	defer m.wg.Done()
+
+	repaired, remaining, err := m.migrateSlab(ctx, key)
+	if utils.IsErr(err, api.ErrSlabNotFound) {
+		return api.SlabRepairResponse{}, err
+	}
+
+	resp := api.SlabRepairResponse{
+		RepairedShards:   repaired,
+		RemainingMissing: remaining,
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp, nil
+}
+
+// lockSlab blocks until no other migration of the slab with the given key is in
+// progress and marks the slab as being migrated. The returned function must be
+// called once the migration is done.
+func (m *Migrator) lockSlab(ctx context.Context, key object.EncryptionKey) (func(), error) {
+	for {
+		m.mu.Lock()
+		done, ok := m.migratingSlabs[key]
+		if !ok {
+			done = make(chan struct{})
+			m.migratingSlabs[key] = done
+			m.mu.Unlock()
+			return func() {
+				m.mu.Lock()
+				delete(m.migratingSlabs, key)
+				m.mu.Unlock()
+				close(done)
+			}, nil
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-done:
+		}
+	}
+}
+
 func (m *Migrator) Shutdown(ctx context.Context) error {
 	m.wg.Wait()
 
@@ -228,7 +283,7 @@ func (m *Migrator) performMigrations(ctx context.Context) {
 			// process jobs
 			for j := range jobs {
 				start := time.Now()
-				err := m.migrateSlab(ctx, j.EncryptionKey)
+				_, _, err := m.migrateSlab(ctx, j.EncryptionKey)
 				m.statsSlabMigrationSpeedMS.Track(float64(time.Since(start).Milliseconds()))
 				if utils.IsErr(err, api.ErrConsensusNotSynced) {
 					// interrupt migrations if consensus is not synced
@@ -245,7 +300,7 @@ func (m *Migrator) performMigrations(ctx context.Context) {
 			}
 		}()
 	}
-	var toMigrate []api.UnhealthySlab
+	queue := newMigrationQueue(m.repairHealthThreshold)
 
 	// ignore a potential signal before the first iteration of the 'OUTER' loop
 	select {
@@ -253,46 +308,22 @@ func (m *Migrator) performMigrations(ctx context.Context) {
 	default:
 	}
 
-	// helper to update 'toMigrate'
-	updateToMigrate := func() {
+	// helper to update the migration queue
+	updateQueue := func() {
 		// fetch slabs for migration
-		toMigrateNew, err := m.ss.SlabsForMigration(ctx, m.healthCutoff, migratorBatchSize)
+		toMigrate, err := m.ss.SlabsForMigration(ctx, m.healthCutoff, migratorBatchSize)
 		if err != nil {
 			m.logger.Errorf("failed to fetch slabs for migration, err: %v", err)
 			return
 		}
-		m.logger.Infof("%d potential slabs fetched for migration", len(toMigrateNew))
-
-		// merge toMigrateNew with toMigrate
-		// NOTE: when merging, we remove all slabs from toMigrate that don't
-		// require migration anymore. However, slabs that have been in toMigrate
-		// before will be repaired before any new slabs. This is to prevent
-		// starvation.
-		migrateNewMap := make(map[object.EncryptionKey]*api.UnhealthySlab)
-		for i, slab := range toMigrateNew {
-			migrateNewMap[slab.EncryptionKey] = &toMigrateNew[i]
-		}
-		removed := 0
-		for i := 0; i < len(toMigrate)-removed; {
-			slab := toMigrate[i]
-			if _, exists := migrateNewMap[slab.EncryptionKey]; exists {
-				delete(migrateNewMap, slab.EncryptionKey) // delete from map to leave only new slabs
-				i++
-			} else {
-				toMigrate[i] = toMigrate[len(toMigrate)-1-removed]
-				removed++
-			}
-		}
-		toMigrate = toMigrate[:len(toMigrate)-removed]
-		for _, slab := range migrateNewMap {
-			toMigrate = append(toMigrate, *slab)
-		}
-
-		// sort the newly added slabs by health
-		newSlabs := toMigrate[len(toMigrate)-len(migrateNewMap):]
-		sort.Slice(newSlabs, func(i, j int) bool {
-			return newSlabs[i].Health < newSlabs[j].Health
-		})
+		m.logger.Infof("%d potential slabs fetched for migration", len(toMigrate))
+
+		// update the queue
+		// NOTE: slabs that don't require migration anymore are removed from
+		// the queue. Critical slabs are always repaired first, but other slabs
+		// that have been in the queue before will be repaired before any new
+		// slabs. This is to prevent starvation.
+		queue.Update(toMigrate)
 	}
 
 	// unregister the ongoing migrations alert when we're done
@@ -316,14 +347,14 @@ OUTER:
 				m.logger.Errorf("failed to dismiss alert: %v", err)
 			}
 			m.logger.Infof("recomputed slab health in %v", time.Since(start))
-			updateToMigrate()
+			updateQueue()
 		}
 
 		// log the updated list of slabs to migrate
-		m.logger.Infof("%d slabs to migrate", len(toMigrate))
+		m.logger.Infof("%d slabs to migrate", queue.Len())
 
 		// return if there are no slabs to migrate
-		if len(toMigrate) == 0 {
+		if queue.Len() == 0 {
 			res, err := m.alerts.Alerts(ctx, alerts.AlertsOpts{Offset: 0, Limit: -1})
 			if err != nil {
 				m.logger.Errorf("failed to get alerts: %v", err)
@@ -338,10 +369,10 @@ OUTER:
 		}
 
 		var lastRegister time.Time
-		for i, slab := range toMigrate {
+		for queue.Len() > 0 {
 			if time.Since(lastRegister) > migrationAlertRegisterInterval {
 				// register an alert to notify users about ongoing migrations
-				remaining := len(toMigrate) - i
+				remaining := queue.Len()
 				if err := m.alerts.RegisterAlert(ctx, newOngoingMigrationsAlert(remaining, m.slabMigrationEstimate(remaining))); err != nil {
 					m.logger.Errorf("failed to register alert: %v", err)
 				}
@@ -356,7 +387,8 @@ OUTER:
 			case <-m.signalMaintenanceFinished:
 				m.logger.Info("migrations interrupted - updating slabs for migration")
 				continue OUTER
-			case jobs <- slab:
+			case jobs <- queue.Peek():
+				queue.Pop()
 			}
 		}
 
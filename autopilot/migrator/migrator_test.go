@@ -0,0 +1,57 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.sia.tech/renterd/v2/object"
+)
+
+func TestLockSlab(t *testing.T) {
+	m := &Migrator{migratingSlabs: make(map[object.EncryptionKey]chan struct{})}
+	key := object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted)
+	other := object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted)
+
+	// lock the slab
+	unlock, err := m.lockSlab(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert other slabs can be locked
+	if unlockOther, err := m.lockSlab(context.Background(), other); err != nil {
+		t.Fatal(err)
+	} else {
+		unlockOther()
+	}
+
+	// assert locking the slab again fails once the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := m.lockSlab(ctx, key); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+
+	// assert a second lock is acquired once the slab is unlocked
+	locked := make(chan func())
+	go func() {
+		unlock, err := m.lockSlab(context.Background(), key)
+		if err != nil {
+			panic(err)
+		}
+		locked <- unlock
+	}()
+	select {
+	case <-locked:
+		t.Fatal("slab was locked twice")
+	default:
+	}
+	unlock()
+	(<-locked)()
+
+	// assert the lock was released
+	if len(m.migratingSlabs) != 0 {
+		t.Fatalf("expected no locked slabs, got %d", len(m.migratingSlabs))
+	}
+}
@@ -0,0 +1,145 @@
+package migrator
+
+import (
+	"container/heap"
+
+	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/object"
+)
+
+type (
+	// migrationQueue is a priority queue of slabs to migrate. Critical slabs,
+	// whose fraction of healthy shards is below the repair threshold, are
+	// migrated first, least healthy first. All other slabs are migrated in the
+	// order they were enqueued in to prevent starvation, slabs that were
+	// enqueued in the same update are ordered least healthy first.
+	migrationQueue struct {
+		repairThreshold float64
+		round           uint64
+
+		items migrationHeap
+		slabs map[object.EncryptionKey]*migrationItem
+	}
+
+	migrationItem struct {
+		slab     api.UnhealthySlab
+		critical bool
+		fraction float64
+		round    uint64
+		index    int
+	}
+
+	migrationHeap []*migrationItem
+)
+
+func newMigrationQueue(repairThreshold float64) *migrationQueue {
+	return &migrationQueue{
+		repairThreshold: repairThreshold,
+		slabs:           make(map[object.EncryptionKey]*migrationItem),
+	}
+}
+
+// Len returns the number of slabs in the queue.
+func (q *migrationQueue) Len() int {
+	return len(q.items)
+}
+
+// Peek returns the slab with the highest priority without removing it, the
+// queue must not be empty.
+func (q *migrationQueue) Peek() api.UnhealthySlab {
+	return q.items[0].slab
+}
+
+// Pop removes and returns the slab with the highest priority, the queue must
+// not be empty.
+func (q *migrationQueue) Pop() api.UnhealthySlab {
+	item := heap.Pop(&q.items).(*migrationItem)
+	delete(q.slabs, item.slab.EncryptionKey)
+	return item.slab
+}
+
+// Update replaces the slabs in the queue with the given slabs. Slabs that are
+// no longer unhealthy are removed, slabs that are already queued keep their
+// position relative to newly enqueued slabs but have their health updated.
+func (q *migrationQueue) Update(slabs []api.UnhealthySlab) {
+	q.round++
+
+	updated := make(map[object.EncryptionKey]api.UnhealthySlab, len(slabs))
+	for _, slab := range slabs {
+		updated[slab.EncryptionKey] = slab
+	}
+
+	// remove slabs that don't require migration anymore
+	for key, item := range q.slabs {
+		if _, ok := updated[key]; !ok {
+			heap.Remove(&q.items, item.index)
+			delete(q.slabs, key)
+		}
+	}
+
+	// update queued slabs and enqueue new ones
+	for key, slab := range updated {
+		fraction := healthyFraction(slab)
+		if item, ok := q.slabs[key]; ok {
+			item.slab = slab
+			item.fraction = fraction
+			item.critical = fraction < q.repairThreshold
+			heap.Fix(&q.items, item.index)
+			continue
+		}
+		item := &migrationItem{
+			slab:     slab,
+			critical: fraction < q.repairThreshold,
+			fraction: fraction,
+			round:    q.round,
+		}
+		heap.Push(&q.items, item)
+		q.slabs[key] = item
+	}
+}
+
+func (h migrationHeap) Len() int { return len(h) }
+
+func (h migrationHeap) Less(i, j int) bool {
+	a, b := h[i], h[j]
+	if a.critical != b.critical {
+		return a.critical
+	} else if a.critical {
+		if a.fraction != b.fraction {
+			return a.fraction < b.fraction
+		}
+		return a.round < b.round
+	} else if a.round != b.round {
+		return a.round < b.round
+	}
+	return a.fraction < b.fraction
+}
+
+func (h migrationHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *migrationHeap) Push(x any) {
+	item := x.(*migrationItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *migrationHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+// healthyFraction returns the fraction of the slab's shards that are healthy.
+func healthyFraction(slab api.UnhealthySlab) float64 {
+	if slab.TotalShards == 0 {
+		return 0
+	}
+	return float64(slab.HealthyShards) / float64(slab.TotalShards)
+}
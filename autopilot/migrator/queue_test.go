@@ -0,0 +1,54 @@
+package migrator
+
+import (
+	"testing"
+
+	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/object"
+)
+
+func TestMigrationQueue(t *testing.T) {
+	newSlab := func(healthy uint8) api.UnhealthySlab {
+		return api.UnhealthySlab{
+			EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+			HealthyShards: healthy,
+			TotalShards:   10,
+		}
+	}
+
+	// assert slabs in the same round are ordered by health
+	q := newMigrationQueue(0.5)
+	s1, s2, s3 := newSlab(8), newSlab(6), newSlab(9)
+	q.Update([]api.UnhealthySlab{s1, s2, s3})
+	if q.Len() != 3 {
+		t.Fatalf("expected 3 slabs, got %d", q.Len())
+	} else if q.Peek().EncryptionKey != s2.EncryptionKey {
+		t.Fatal("unexpected slab")
+	}
+
+	// add a new slab and a critical one, assert the critical slab is first and
+	// the new slab is migrated after the existing ones
+	s4, s5 := newSlab(5), newSlab(3)
+	q.Update([]api.UnhealthySlab{s1, s2, s3, s4, s5})
+	for _, expected := range []api.UnhealthySlab{s5, s2, s1, s3, s4} {
+		if slab := q.Pop(); slab.EncryptionKey != expected.EncryptionKey {
+			t.Fatalf("unexpected slab, %v != %v", slab.HealthyShards, expected.HealthyShards)
+		}
+	}
+	if q.Len() != 0 {
+		t.Fatalf("expected empty queue, got %d", q.Len())
+	}
+
+	// assert slabs that no longer require migration are removed and that a
+	// slab becoming critical is prioritized
+	q.Update([]api.UnhealthySlab{s1, s2, s3})
+	s3.HealthyShards = 1
+	q.Update([]api.UnhealthySlab{s1, s3})
+	if q.Len() != 2 {
+		t.Fatalf("expected 2 slabs, got %d", q.Len())
+	} else if slab := q.Pop(); slab.EncryptionKey != s3.EncryptionKey || slab.HealthyShards != 1 {
+		t.Fatal("unexpected slab", slab)
+	} else if slab := q.Pop(); slab.EncryptionKey != s1.EncryptionKey {
+		t.Fatal("unexpected slab", slab)
+	}
+}
@@ -16,27 +16,40 @@ import (
 	"go.uber.org/zap"
 )
 
-func (m *Migrator) migrateSlab(ctx context.Context, key object.EncryptionKey) error {
+// migrateSlab migrates the shards of the slab with the given key that are not
+// stored on good hosts. It returns the number of shards that were repaired and
+// the number of shards that still need to be repaired. Migrations of the same
+// slab are serialized, so a repair requested through the API doesn't race
+// with the background migration of the slab.
+func (m *Migrator) migrateSlab(ctx context.Context, key object.EncryptionKey) (repaired, remaining int, _ error) {
 	// apply sane timeout
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
+	// wait for ongoing migrations of the slab, the slab is fetched after the
+	// lock is acquired so a finished migration is taken into account
+	unlock, err := m.lockSlab(ctx, key)
+	if err != nil {
+		return 0, 0, fmt.Errorf("couldn't lock slab for migration: %w", err)
+	}
+	defer unlock()
+
 	// fetch slab
 	slab, err := m.ss.Slab(ctx, key)
 	if err != nil {
-		return fmt.Errorf("couldn't fetch slab from bus: %w", err)
+		return 0, 0, fmt.Errorf("couldn't fetch slab from bus: %w", err)
 	}
 
 	// fetch the upload parameters
 	up, err := m.bus.UploadParams(ctx)
 	if err != nil {
-		return fmt.Errorf("couldn't fetch upload parameters from bus: %w", err)
+		return 0, 0, fmt.Errorf("couldn't fetch upload parameters from bus: %w", err)
 	}
 
 	// cancel the upload if consensus is not synced
 	if !up.ConsensusState.Synced {
 		m.logger.Errorf("migration cancelled, err: %v", api.ErrConsensusNotSynced)
-		return api.ErrConsensusNotSynced
+		return 0, 0, api.ErrConsensusNotSynced
 	}
 
 	// attach gouging checker to the context
@@ -45,7 +58,7 @@ func (m *Migrator) migrateSlab(ctx context.Context, key object.EncryptionKey) er
 	// fetch hosts
 	dlHosts, err := m.bus.UsableHosts(ctx)
 	if err != nil {
-		return fmt.Errorf("couldn't fetch hosts from bus: %w", err)
+		return 0, 0, fmt.Errorf("couldn't fetch hosts from bus: %w", err)
 	}
 
 	hmap := make(map[types.PublicKey]api.HostInfo)
@@ -55,7 +68,7 @@ func (m *Migrator) migrateSlab(ctx context.Context, key object.EncryptionKey) er
 
 	contracts, err := m.bus.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeGood})
 	if err != nil {
-		return fmt.Errorf("couldn't fetch contracts from bus: %v", err)
+		return 0, 0, fmt.Errorf("couldn't fetch contracts from bus: %v", err)
 	}
 
 	var ulHosts []upload.HostInfo
@@ -70,8 +83,14 @@ func (m *Migrator) migrateSlab(ctx context.Context, key object.EncryptionKey) er
 		}
 	}
 
+	// respect the host restrictions of the buckets the slab belongs to
+	ulHosts, err = m.applyBucketPolicies(ctx, slab.EncryptionKey, ulHosts)
+	if err != nil {
+		return 0, 0, fmt.Errorf("couldn't apply bucket policies: %w", err)
+	}
+
 	// migrate the slab and handle alerts
-	err = m.migrate(ctx, slab, dlHosts, ulHosts, up.CurrentHeight)
+	repaired, remaining, err = m.migrate(ctx, slab, dlHosts, ulHosts, up.CurrentHeight)
 	if err != nil && !utils.IsErr(err, api.ErrSlabNotFound) {
 		var objects []api.ObjectMetadata
 		if res, err := m.bus.Objects(ctx, "", api.ListObjectOptions{SlabEncryptionKey: slab.EncryptionKey}); err != nil {
@@ -89,12 +108,43 @@ func (m *Migrator) migrateSlab(ctx context.Context, key object.EncryptionKey) er
 			zap.Error(err),
 			zap.Stringer("slab", slab.EncryptionKey),
 		)
-		return err
+		return repaired, remaining, err
+	}
+	return repaired, remaining, nil
+}
+
+// applyBucketPolicies filters the given upload hosts so that they satisfy the
+// policies of all buckets that contain an object referencing the slab.
+func (m *Migrator) applyBucketPolicies(ctx context.Context, key object.EncryptionKey, hosts []upload.HostInfo) ([]upload.HostInfo, error) {
+	buckets, err := m.bus.ListBuckets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't fetch buckets: %w", err)
+	}
+
+	restricted := make(map[string]api.BucketPolicy)
+	for _, b := range buckets {
+		if b.Policy.RestrictsHosts() {
+			restricted[b.Name] = b.Policy
+		}
+	}
+	if len(restricted) == 0 {
+		return hosts, nil
+	}
+
+	res, err := m.bus.Objects(ctx, "", api.ListObjectOptions{SlabEncryptionKey: key})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't fetch objects for slab: %w", err)
+	}
+	for _, o := range res.Objects {
+		if policy, ok := restricted[o.Bucket]; ok {
+			hosts = upload.FilterHosts(hosts, policy.HostAllowed)
+			delete(restricted, o.Bucket)
+		}
 	}
-	return nil
+	return hosts, nil
 }
 
-func (m *Migrator) migrate(ctx context.Context, s object.Slab, dlHosts []api.HostInfo, ulHosts []upload.HostInfo, bh uint64) error {
+func (m *Migrator) migrate(ctx context.Context, s object.Slab, dlHosts []api.HostInfo, ulHosts []upload.HostInfo, bh uint64) (repaired, remaining int, _ error) {
 	// map usable hosts
 	usableHosts := make(map[types.PublicKey]struct{})
 	for _, h := range dlHosts {
@@ -136,7 +186,7 @@ SHARDS:
 
 	// if all shards are on good hosts, we're done
 	if len(shardIndices) == 0 {
-		return nil
+		return 0, 0, nil
 	}
 
 	// calculate the number of missing shards and take into account hosts for
@@ -153,16 +203,16 @@ SHARDS:
 
 	// perform some sanity checks
 	if len(ulHosts) < int(s.MinShards) {
-		return fmt.Errorf("not enough hosts to repair unhealthy shard to minimum redundancy, %d<%d", len(ulHosts), int(s.MinShards))
+		return 0, len(shardIndices), fmt.Errorf("not enough hosts to repair unhealthy shard to minimum redundancy, %d<%d", len(ulHosts), int(s.MinShards))
 	}
 	if len(s.Shards)-missingShards < int(s.MinShards) {
-		return fmt.Errorf("not enough hosts to download unhealthy shard, %d<%d", len(s.Shards)-missingShards, int(s.MinShards))
+		return 0, len(shardIndices), fmt.Errorf("not enough hosts to download unhealthy shard, %d<%d", len(s.Shards)-missingShards, int(s.MinShards))
 	}
 
 	// acquire memory for the migration
 	mem := m.uploadManager.AcquireMemory(ctx, uint64(len(shardIndices))*rhpv4.SectorSize)
 	if mem == nil {
-		return fmt.Errorf("failed to acquire memory for migration")
+		return 0, len(shardIndices), fmt.Errorf("failed to acquire memory for migration")
 	}
 	defer mem.Release()
 
@@ -174,7 +224,7 @@ SHARDS:
 			zap.Stringer("slab", s.EncryptionKey),
 			zap.Int("numShardsMigrated", len(shards)),
 		)
-		return fmt.Errorf("failed to download slab for migration: %w", err)
+		return 0, len(shardIndices), fmt.Errorf("failed to download slab for migration: %w", err)
 	}
 	s.Encrypt(shards)
 
@@ -201,7 +251,7 @@ SHARDS:
 			zap.Stringer("slab", s.EncryptionKey),
 			zap.Int("numShardsMigrated", len(shards)),
 		)
-		return fmt.Errorf("failed to upload slab for migration: %w", err)
+		return 0, len(shardIndices), fmt.Errorf("failed to upload slab for migration: %w", err)
 	}
 
 	// debug log migration result
@@ -210,5 +260,5 @@ SHARDS:
 		zap.Int("numShardsMigrated", len(shards)),
 	)
 
-	return nil
+	return len(shards), 0, nil
 }
@@ -0,0 +1,39 @@
+package pruner
+
+import (
+	"fmt"
+	"time"
+
+	"go.sia.tech/renterd/v2/alerts"
+	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/internal/utils"
+)
+
+var (
+	alertPrunableDataID = alerts.RandomAlertID() // constant until restarted
+)
+
+func newPrunableDataAlert(totalPrunable, threshold uint64, top []api.ContractPrunableData) alerts.Alert {
+	contracts := make([]map[string]any, 0, len(top))
+	for _, c := range top {
+		contracts = append(contracts, map[string]any{
+			"contractID": c.ID,
+			"hostKey":    c.HostKey,
+			"prunable":   c.Prunable,
+		})
+	}
+
+	return alerts.Alert{
+		ID:          alertPrunableDataID,
+		Severity:    alerts.SeverityWarning,
+		Message:     "Contracts contain a lot of prunable data",
+		Description: fmt.Sprintf("The contracts of the autopilot contain %s of prunable data, which exceeds the configured threshold of %s.", utils.HumanReadableSize(int(totalPrunable)), utils.HumanReadableSize(int(threshold))),
+		Suggestion:  "Make sure pruning is enabled in the autopilot config. If it is, check the logs for hosts that fail to prune their contracts.",
+		Data: map[string]any{
+			"totalPrunable": totalPrunable,
+			"threshold":     threshold,
+			"contracts":     contracts,
+		},
+		Timestamp: time.Now(),
+	}
+}
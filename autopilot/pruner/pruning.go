@@ -8,15 +8,21 @@ import (
 	"time"
 
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/internal/utils"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
 	// timeoutPruneContract defines the maximum amount of time we lock a
 	// contract for pruning
 	timeoutPruneContract = 10 * time.Minute
+
+	// numAlertContracts is the number of contracts with the most prunable
+	// data that are listed in the prunable data alert
+	numAlertContracts = 5
 )
 
 type (
@@ -24,15 +30,17 @@ type (
 		Contract(ctx context.Context, id types.FileContractID) (api.ContractMetadata, error)
 		Contracts(ctx context.Context, opts api.ContractsOpts) (contracts []api.ContractMetadata, err error)
 		Host(ctx context.Context, hostKey types.PublicKey) (api.Host, error)
-		PrunableData(ctx context.Context) (prunableData api.ContractsPrunableDataResponse, err error)
+		PrunableData(ctx context.Context, opts api.ContractsPrunableDataOpts) (prunableData api.ContractsPrunableDataResponse, err error)
 		PruneContract(ctx context.Context, id types.FileContractID, timeout time.Duration) (api.ContractPruneResponse, error)
 		RecordContractPruneMetric(ctx context.Context, metrics ...api.ContractPruneMetric) error
 	}
 )
 
 type Pruner struct {
-	bus    Bus
-	logger *zap.SugaredLogger
+	alerter     alerts.Alerter
+	bus         Bus
+	logger      *zap.SugaredLogger
+	parallelism int
 
 	wg sync.WaitGroup
 
@@ -41,14 +49,21 @@ type Pruner struct {
 	pruningLastStart time.Time
 }
 
-func New(bus Bus, logger *zap.Logger) *Pruner {
-	return &Pruner{
-		bus:    bus,
-		logger: logger.Named("pruner").Sugar(),
+func New(alerter alerts.Alerter, bus Bus, parallelism uint64, logger *zap.Logger) (*Pruner, error) {
+	if parallelism == 0 {
+		return nil, errors.New("pruner parallelism has to be greater than zero")
 	}
+	return &Pruner{
+		alerter:     alerter,
+		bus:         bus,
+		logger:      logger.Named("pruner").Sugar(),
+		parallelism: int(parallelism),
+	}, nil
 }
 
-func (p *Pruner) PerformContractPruning(ctx context.Context) {
+// PerformContractPruning prunes all good contracts in the background and
+// updates the prunable data alert using the given threshold once done.
+func (p *Pruner) PerformContractPruning(ctx context.Context, alertThreshold uint64) {
 	p.mu.Lock()
 	if p.pruning {
 		p.mu.Unlock()
@@ -62,12 +77,51 @@ func (p *Pruner) PerformContractPruning(ctx context.Context) {
 	go func() {
 		defer p.wg.Done()
 		p.performContractPruning(ctx)
+		p.UpdatePrunableDataAlert(ctx, alertThreshold)
 		p.mu.Lock()
 		p.pruning = false
 		p.mu.Unlock()
 	}()
 }
 
+// UpdatePrunableDataAlert registers an alert if the total amount of prunable
+// data exceeds the given threshold and dismisses it otherwise, a threshold of
+// zero disables the alert.
+func (p *Pruner) UpdatePrunableDataAlert(ctx context.Context, threshold uint64) {
+	if threshold == 0 {
+		p.dismissAlert(ctx, alertPrunableDataID)
+		return
+	}
+
+	// use a sane timeout
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	// fetch the contracts with the most prunable data
+	res, err := p.bus.PrunableData(ctx, api.ContractsPrunableDataOpts{
+		SortBy: api.ContractsPrunableSortByPrunable,
+		Limit:  numAlertContracts,
+	})
+	if err != nil {
+		p.logger.Errorw("failed to fetch prunable data", zap.Error(err))
+		return
+	} else if res.TotalPrunable <= threshold {
+		p.dismissAlert(ctx, alertPrunableDataID)
+		return
+	}
+
+	// only list contracts that actually have prunable data
+	var top []api.ContractPrunableData
+	for _, c := range res.Contracts {
+		if c.Prunable > 0 {
+			top = append(top, c)
+		}
+	}
+	if err := p.alerter.RegisterAlert(ctx, newPrunableDataAlert(res.TotalPrunable, threshold, top)); err != nil {
+		p.logger.Errorw("failed to register prunable data alert", zap.Error(err))
+	}
+}
+
 func (p *Pruner) Status() (bool, time.Time) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -79,19 +133,39 @@ func (p *Pruner) Shutdown(_ context.Context) error {
 	return nil
 }
 
+func (p *Pruner) dismissAlert(ctx context.Context, id types.Hash256) {
+	if err := p.alerter.DismissAlerts(ctx, id); err != nil {
+		p.logger.Errorw("failed to dismiss alert", zap.Error(err), "id", id)
+	}
+}
+
 func (p *Pruner) fetchPrunableContracts(ctx context.Context) (prunable []api.ContractPrunableData, _ error) {
 	// use a sane timeout
 	ctx, cancel := context.WithTimeout(ctx, time.Minute)
 	defer cancel()
 
 	// fetch prunable data
-	res, err := p.bus.PrunableData(ctx)
+	res, err := p.bus.PrunableData(ctx, api.ContractsPrunableDataOpts{Limit: api.MaxContractsPrunableLimit})
 	if err != nil {
 		return nil, err
 	} else if res.TotalPrunable == 0 {
 		return nil, nil
 	}
 
+	// fetch the remaining pages, contracts are sorted by prunable data so we
+	// stop once a page isn't full or ends with a contract without any
+	for page := res.Contracts; len(page) == api.MaxContractsPrunableLimit && page[len(page)-1].Prunable > 0; {
+		next, err := p.bus.PrunableData(ctx, api.ContractsPrunableDataOpts{
+			Offset: len(res.Contracts),
+			Limit:  api.MaxContractsPrunableLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		page = next.Contracts
+		res.Contracts = append(res.Contracts, page...)
+	}
+
 	// fetch good contracts
 	contracts, err := p.bus.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeGood})
 	if err != nil {
@@ -141,30 +215,51 @@ func (p *Pruner) performContractPruning(ctx context.Context) {
 	}
 	log.Debugf("found %d prunable contracts", len(prunable))
 
-	// loop prunable contracts
+	// prune contracts in parallel
+	var mu sync.Mutex
 	var total uint64
+	var metrics []api.ContractPruneMetric
+
+	var g errgroup.Group
+	g.SetLimit(p.parallelism)
 	for _, contract := range prunable {
-		// fetch host
-		h, _, err := p.fetchHostContract(ctx, contract.ID)
-		if utils.IsErr(err, api.ErrContractNotFound) {
-			log.Debugw("contract got archived", "contract", contract.ID)
-			continue // contract got archived
-		} else if err != nil {
-			log.Errorw("failed to fetch host", zap.Error(err), "contract", contract.ID)
-			continue
-		}
+		g.Go(func() error {
+			// fetch host
+			h, _, err := p.fetchHostContract(ctx, contract.ID)
+			if utils.IsErr(err, api.ErrContractNotFound) {
+				log.Debugw("contract got archived", "contract", contract.ID)
+				return nil // contract got archived
+			} else if err != nil {
+				log.Errorw("failed to fetch host", zap.Error(err), "contract", contract.ID)
+				return nil
+			}
+
+			// prune contract
+			settings := h.V2Settings
+			version := fmt.Sprintf("%d.%d.%d", settings.ProtocolVersion[0], settings.ProtocolVersion[1], settings.ProtocolVersion[2])
+			metric, err := p.pruneContract(ctx, contract.ID, h.PublicKey, version, settings.Release, log)
+			if err != nil {
+				log.Errorw("failed to prune contract", zap.Error(err), "contract", contract.ID)
+				return nil
+			}
+
+			// adjust total and collect metric
+			mu.Lock()
+			total += metric.Pruned
+			if metric.Pruned > 0 {
+				metrics = append(metrics, metric)
+			}
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait() // workers never return an error
 
-		// prune contract
-		settings := h.V2Settings
-		version := fmt.Sprintf("%d.%d.%d", settings.ProtocolVersion[0], settings.ProtocolVersion[1], settings.ProtocolVersion[2])
-		n, err := p.pruneContract(ctx, contract.ID, h.PublicKey, version, settings.Release, log)
-		if err != nil {
-			log.Errorw("failed to prune contract", zap.Error(err), "contract", contract.ID)
-			continue
+	// record prune metrics
+	if len(metrics) > 0 {
+		if err := p.bus.RecordContractPruneMetric(ctx, metrics...); err != nil {
+			log.Errorw("failed to record prune metrics", zap.Error(err))
 		}
-
-		// adjust total
-		total += n
 	}
 
 	// log total pruned
@@ -173,7 +268,9 @@ func (p *Pruner) performContractPruning(ctx context.Context) {
 	}
 }
 
-func (p *Pruner) pruneContract(ctx context.Context, fcid types.FileContractID, hk types.PublicKey, hostVersion, hostRelease string, logger *zap.SugaredLogger) (uint64, error) {
+// pruneContract prunes the given contract and returns a metric describing the
+// outcome, the metric is not recorded so the caller can batch them.
+func (p *Pruner) pruneContract(ctx context.Context, fcid types.FileContractID, hk types.PublicKey, hostVersion, hostRelease string, logger *zap.SugaredLogger) (api.ContractPruneMetric, error) {
 	// define logger
 	log := logger.With(
 		zap.Stringer("contract", fcid),
@@ -181,11 +278,15 @@ func (p *Pruner) pruneContract(ctx context.Context, fcid types.FileContractID, h
 		zap.String("release", hostRelease),
 		zap.Stringer("host", hk))
 
-	// prune the contract
+	// prune the contract, the bus enforces the timeout but we give each
+	// contract its own context to avoid hanging on an unresponsive bus
+	ctx, cancel := context.WithTimeout(ctx, timeoutPruneContract+time.Minute)
+	defer cancel()
+
 	start := time.Now()
 	res, err := p.bus.PruneContract(ctx, fcid, timeoutPruneContract)
 	if err != nil {
-		return 0, err
+		return api.ContractPruneMetric{}, err
 	}
 
 	// decorate logger
@@ -201,23 +302,6 @@ func (p *Pruner) pruneContract(ctx context.Context, fcid types.FileContractID, h
 		res.Error = ""
 	}
 
-	// handle metrics
-	if res.Pruned > 0 {
-		if err := p.bus.RecordContractPruneMetric(ctx, api.ContractPruneMetric{
-			Timestamp: api.TimeRFC3339(start),
-
-			ContractID:  fcid,
-			HostKey:     hk,
-			HostVersion: hostVersion,
-
-			Pruned:    res.Pruned,
-			Remaining: res.Remaining,
-			Duration:  time.Since(start),
-		}); err != nil {
-			log.Error(err)
-		}
-	}
-
 	// handle logs
 	if res.Error != "" {
 		log.Errorw("unexpected error interrupted pruning", zap.Error(errors.New(res.Error)))
@@ -225,5 +309,15 @@ func (p *Pruner) pruneContract(ctx context.Context, fcid types.FileContractID, h
 		log.Info("successfully pruned contract")
 	}
 
-	return res.Pruned, nil
+	return api.ContractPruneMetric{
+		Timestamp: api.TimeRFC3339(start),
+
+		ContractID:  fcid,
+		HostKey:     hk,
+		HostVersion: hostVersion,
+
+		Pruned:    res.Pruned,
+		Remaining: res.Remaining,
+		Duration:  time.Since(start),
+	}, nil
 }
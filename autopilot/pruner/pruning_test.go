@@ -0,0 +1,95 @@
+package pruner
+
+import (
+	"context"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/alerts"
+	"go.sia.tech/renterd/v2/api"
+	"go.uber.org/zap"
+)
+
+type mockBus struct {
+	Bus
+	prunable []api.ContractPrunableData
+}
+
+func (b *mockBus) PrunableData(_ context.Context, opts api.ContractsPrunableDataOpts) (res api.ContractsPrunableDataResponse, _ error) {
+	for _, c := range b.prunable {
+		res.TotalPrunable += c.Prunable
+		res.TotalSize += c.Size
+	}
+	res.Contracts = b.prunable
+	if opts.Limit > 0 && opts.Limit < len(res.Contracts) {
+		res.Contracts = res.Contracts[:opts.Limit]
+	}
+	return
+}
+
+func TestUpdatePrunableDataAlert(t *testing.T) {
+	bus := &mockBus{}
+	alerter := alerts.NewManager(alerts.Config{})
+	p, err := New(alerter, bus, 1, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// helper to fetch the prunable data alert
+	alert := func() (alerts.Alert, bool) {
+		t.Helper()
+		res, err := alerter.Alerts(context.Background(), alerts.AlertsOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range res.Alerts {
+			if a.ID == alertPrunableDataID {
+				return a, true
+			}
+		}
+		return alerts.Alert{}, false
+	}
+
+	// add contracts, sorted by prunable data like the bus returns them
+	for i := 7; i > 0; i-- {
+		bus.prunable = append(bus.prunable, api.ContractPrunableData{
+			ID:           types.FileContractID{byte(i)},
+			HostKey:      types.PublicKey{byte(i)},
+			ContractSize: api.ContractSize{Prunable: uint64(i) * 100, Size: 1000},
+		})
+	}
+
+	// below the threshold there should be no alert
+	p.UpdatePrunableDataAlert(context.Background(), 2800)
+	if _, ok := alert(); ok {
+		t.Fatal("unexpected alert")
+	}
+
+	// above the threshold the alert lists the top contracts
+	p.UpdatePrunableDataAlert(context.Background(), 2000)
+	if a, ok := alert(); !ok {
+		t.Fatal("expected alert")
+	} else if a.Severity != alerts.SeverityWarning {
+		t.Fatal("unexpected severity", a.Severity)
+	} else if a.Data["totalPrunable"] != uint64(2800) {
+		t.Fatal("unexpected total prunable", a.Data["totalPrunable"])
+	} else if contracts := a.Data["contracts"].([]map[string]any); len(contracts) != numAlertContracts {
+		t.Fatal("unexpected number of contracts", len(contracts))
+	} else if contracts[0]["contractID"] != (types.FileContractID{7}) {
+		t.Fatal("unexpected contract", contracts[0]["contractID"])
+	}
+
+	// once pruned below the threshold the alert is dismissed
+	bus.prunable = bus.prunable[5:]
+	p.UpdatePrunableDataAlert(context.Background(), 2000)
+	if _, ok := alert(); ok {
+		t.Fatal("expected alert to be dismissed")
+	}
+
+	// a threshold of zero disables the alert
+	bus.prunable = []api.ContractPrunableData{{ContractSize: api.ContractSize{Prunable: 1}}}
+	p.UpdatePrunableDataAlert(context.Background(), 0)
+	if _, ok := alert(); ok {
+		t.Fatal("unexpected alert")
+	}
+}
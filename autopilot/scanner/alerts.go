@@ -0,0 +1,31 @@
+package scanner
+
+import (
+	"fmt"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/alerts"
+)
+
+var (
+	alertHostPriceChangeID = alerts.RandomAlertID() // constant until restarted
+)
+
+func newHostPriceChangeAlert(hk types.PublicKey, oldPrice, newPrice types.Currency, ratio, threshold float64, contractIDs []types.FileContractID) alerts.Alert {
+	return alerts.Alert{
+		ID:          alerts.IDForHost(alertHostPriceChangeID, hk),
+		Severity:    alerts.SeverityWarning,
+		Message:     "Host raised its storage price",
+		Description: fmt.Sprintf("Host %v raised its storage price from %v to %v, which is %.2f times the price of the previous scan and exceeds the configured threshold of %.2f.", hk, oldPrice, newPrice, ratio, threshold),
+		Suggestion:  "Consider blocking this host through the blocklist feature to stop forming and renewing contracts with it.",
+		Data: map[string]any{
+			"hostKey":     hk.String(),
+			"oldPrice":    oldPrice,
+			"newPrice":    newPrice,
+			"contractIDs": contractIDs,
+			"hint":        fmt.Sprintf("The storage price of the host increased by a factor of %.2f since the previous scan, the contracts with this host will become more expensive to use.", ratio),
+		},
+		Timestamp: time.Now(),
+	}
+}
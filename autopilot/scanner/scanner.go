@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/internal/utils"
 	"go.uber.org/zap"
@@ -28,6 +29,7 @@ type (
 	}
 
 	HostStore interface {
+		Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error)
 		Hosts(ctx context.Context, opts api.HostOptions) ([]api.Host, error)
 		RemoveOfflineHosts(ctx context.Context, maxConsecutiveScanFailures uint64, maxDowntime time.Duration) (uint64, error)
 	}
@@ -35,14 +37,22 @@ type (
 
 type (
 	Scanner struct {
-		hs HostStore
+		alerter alerts.Alerter
+		hs      HostStore
 
-		scanBatchSize int
-		scanThreads   int
-		scanInterval  time.Duration
+		scanBatchSize        int
+		scanThreads          int
+		scanInterval         time.Duration
+		priceChangeThreshold float64
 
 		statsHostPingMS *utils.DataPoints
 
+		// priceAlertsMu guards priceAlerts, which contains the storage price
+		// from before the increase of every host we registered a price change
+		// alert for
+		priceAlertsMu sync.Mutex
+		priceAlerts   map[types.PublicKey]types.Currency
+
 		wg sync.WaitGroup
 
 		logger *zap.SugaredLogger
@@ -56,12 +66,16 @@ type (
 	}
 
 	scanJob struct {
-		hostKey types.PublicKey
-		hostIP  string
+		hostKey      types.PublicKey
+		hostIP       string
+		storagePrice types.Currency
 	}
 )
 
-func New(hs HostStore, scanBatchSize, scanThreads uint64, scanMinInterval time.Duration, logger *zap.Logger) (*Scanner, error) {
+// New returns a new scanner. If priceChangeThreshold is non-zero, an alert is
+// registered when a host we have active contracts with raises its storage
+// price by more than the given factor between two scans.
+func New(alerter alerts.Alerter, hs HostStore, scanBatchSize, scanThreads uint64, scanMinInterval time.Duration, priceChangeThreshold float64, logger *zap.Logger) (*Scanner, error) {
 	logger = logger.Named("scanner")
 	if scanBatchSize == 0 {
 		return nil, errors.New("scanner batch size has to be greater than zero")
@@ -69,14 +83,20 @@ func New(hs HostStore, scanBatchSize, scanThreads uint64, scanMinInterval time.D
 	if scanThreads == 0 {
 		return nil, errors.New("scanner threads has to be greater than zero")
 	}
+	if priceChangeThreshold < 0 {
+		return nil, errors.New("scanner price change threshold can't be negative")
+	}
 	return &Scanner{
-		hs: hs,
+		alerter: alerter,
+		hs:      hs,
 
-		scanBatchSize: int(scanBatchSize),
-		scanThreads:   int(scanThreads),
-		scanInterval:  scanMinInterval,
+		scanBatchSize:        int(scanBatchSize),
+		scanThreads:          int(scanThreads),
+		scanInterval:         scanMinInterval,
+		priceChangeThreshold: priceChangeThreshold,
 
 		statsHostPingMS: utils.NewDataPoints(0),
+		priceAlerts:     make(map[types.PublicKey]types.Currency),
 		logger:          logger.Sugar(),
 	}, nil
 }
@@ -188,6 +208,7 @@ func (s *Scanner) scanHosts(ctx context.Context, hs HostScanner, cutoff time.Tim
 				s.logger.Debugw("host scan failed", zap.Error(err), "hk", h.hostKey, "ip", h.hostIP)
 			} else {
 				s.statsHostPingMS.Track(float64(time.Duration(scan.Ping).Milliseconds()))
+				s.checkPriceChange(ctx, h, scan.V2Settings.Prices.StoragePrice)
 				atomic.AddUint64(&scanned, 1)
 			}
 		}
@@ -238,8 +259,9 @@ func (s *Scanner) scanHosts(ctx context.Context, hs HostScanner, cutoff time.Tim
 		for _, h := range hosts {
 			select {
 			case jobs <- scanJob{
-				hostKey: h.PublicKey,
-				hostIP:  h.SiamuxAddr(),
+				hostKey:      h.PublicKey,
+				hostIP:       h.SiamuxAddr(),
+				storagePrice: h.V2Settings.Prices.StoragePrice,
 			}:
 			case <-ctx.Done():
 				continue
@@ -254,6 +276,66 @@ func (s *Scanner) scanHosts(ctx context.Context, hs HostScanner, cutoff time.Tim
 	return
 }
 
+// checkPriceChange registers an alert if the storage price of the scanned host
+// increased by more than the configured threshold compared to the previous
+// scan and we have active contracts with the host. The alert is dismissed once
+// the price drops back within the threshold of the price before the increase.
+func (s *Scanner) checkPriceChange(ctx context.Context, h scanJob, newPrice types.Currency) {
+	if s.priceChangeThreshold == 0 {
+		return
+	}
+
+	// if we registered an alert for the host, compare against the price from
+	// before the increase to figure out whether we can dismiss it
+	s.priceAlertsMu.Lock()
+	oldPrice, alerted := s.priceAlerts[h.hostKey]
+	s.priceAlertsMu.Unlock()
+	if alerted {
+		if newPrice.Siacoins()/oldPrice.Siacoins() > s.priceChangeThreshold {
+			return
+		} else if err := s.alerter.DismissAlerts(ctx, alerts.IDForHost(alertHostPriceChangeID, h.hostKey)); err != nil {
+			s.logger.Errorw("failed to dismiss alert", zap.Error(err), "hk", h.hostKey)
+			return
+		}
+		s.priceAlertsMu.Lock()
+		delete(s.priceAlerts, h.hostKey)
+		s.priceAlertsMu.Unlock()
+		return
+	}
+
+	if h.storagePrice.IsZero() {
+		return
+	}
+	ratio := newPrice.Siacoins() / h.storagePrice.Siacoins()
+	if ratio <= s.priceChangeThreshold {
+		return
+	}
+
+	contracts, err := s.hs.Contracts(ctx, api.ContractsOpts{
+		FilterMode: api.ContractFilterModeActive,
+		HostKey:    h.hostKey,
+	})
+	if err != nil {
+		s.logger.Errorw("failed to fetch contracts", zap.Error(err), "hk", h.hostKey)
+		return
+	} else if len(contracts) == 0 {
+		return
+	}
+	contractIDs := make([]types.FileContractID, 0, len(contracts))
+	for _, c := range contracts {
+		contractIDs = append(contractIDs, c.ID)
+	}
+
+	s.logger.Infow("host raised its storage price", "hk", h.hostKey, "oldPrice", h.storagePrice, "newPrice", newPrice, "ratio", ratio)
+	if err := s.alerter.RegisterAlert(ctx, newHostPriceChangeAlert(h.hostKey, h.storagePrice, newPrice, ratio, s.priceChangeThreshold, contractIDs)); err != nil {
+		s.logger.Errorw("failed to register alert", zap.Error(err), "hk", h.hostKey)
+		return
+	}
+	s.priceAlertsMu.Lock()
+	s.priceAlerts[h.hostKey] = h.storagePrice
+	s.priceAlertsMu.Unlock()
+}
+
 func (s *Scanner) removeOfflineHosts(ctx context.Context) (removed uint64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/internal/test"
 	"go.uber.org/zap"
@@ -19,13 +20,24 @@ const (
 )
 
 type mockHostStore struct {
-	hosts []api.Host
+	contracts []api.ContractMetadata
+	hosts     []api.Host
 
 	mu       sync.Mutex
 	scans    []string
 	removals []string
 }
 
+func (hs *mockHostStore) Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error) {
+	var contracts []api.ContractMetadata
+	for _, c := range hs.contracts {
+		if opts.HostKey == (types.PublicKey{}) || c.HostKey == opts.HostKey {
+			contracts = append(contracts, c)
+		}
+	}
+	return contracts, nil
+}
+
 func (hs *mockHostStore) Hosts(ctx context.Context, opts api.HostOptions) ([]api.Host, error) {
 	hs.mu.Lock()
 	defer hs.mu.Unlock()
@@ -78,8 +90,9 @@ func (hs *mockHostStore) state() ([]string, []string) {
 }
 
 type mockHostScanner struct {
-	blockChan chan struct{}
-	hs        *mockHostStore
+	blockChan    chan struct{}
+	hs           *mockHostStore
+	storagePrice types.Currency
 
 	mu        sync.Mutex
 	scanCount int
@@ -99,7 +112,9 @@ func (w *mockHostScanner) ScanHost(ctx context.Context, hostKey types.PublicKey,
 	defer w.mu.Unlock()
 	w.scanCount++
 
-	return api.HostScanResponse{}, nil
+	var resp api.HostScanResponse
+	resp.V2Settings.Prices.StoragePrice = w.storagePrice
+	return resp, nil
 }
 
 func TestScanner(t *testing.T) {
@@ -107,7 +122,7 @@ func TestScanner(t *testing.T) {
 	hs := &mockHostStore{hosts: test.NewHosts(100)}
 
 	// create test scanner
-	s, err := New(hs, testBatchSize, testNumThreads, time.Minute, zap.NewNop())
+	s, err := New(alerts.NewManager(alerts.Config{}), hs, testBatchSize, testNumThreads, time.Minute, 0, zap.NewNop())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -217,3 +232,68 @@ func TestScanner(t *testing.T) {
 		t.Fatal("unexpected")
 	}
 }
+
+func TestScannerPriceChange(t *testing.T) {
+	// create mock store with two hosts, we only have a contract with the
+	// first one
+	hs := &mockHostStore{hosts: test.NewHosts(2)}
+	for i := range hs.hosts {
+		hs.hosts[i].V2Settings.Prices.StoragePrice = types.Siacoins(1)
+	}
+	hs.contracts = []api.ContractMetadata{{ID: types.FileContractID{1}, HostKey: hs.hosts[0].PublicKey}}
+
+	// create test scanner
+	a := alerts.NewManager(alerts.Config{})
+	s, err := New(a, hs, testBatchSize, testNumThreads, time.Minute, 2, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+
+	// scan the hosts, doubling the price shouldn't trigger an alert
+	b := &mockHostScanner{hs: hs, storagePrice: types.Siacoins(2)}
+	s.scanHosts(context.Background(), b, time.Now())
+	if resp, err := a.Alerts(context.Background(), alerts.AlertsOpts{}); err != nil {
+		t.Fatal(err)
+	} else if len(resp.Alerts) != 0 {
+		t.Fatalf("expected no alerts, got %v", resp.Alerts)
+	}
+
+	// tripling the price should trigger an alert for the first host only
+	b.storagePrice = types.Siacoins(3)
+	s.scanHosts(context.Background(), b, time.Now())
+	resp, err := a.Alerts(context.Background(), alerts.AlertsOpts{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(resp.Alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %v", resp.Alerts)
+	} else if alert := resp.Alerts[0]; alert.ID != alerts.IDForHost(alertHostPriceChangeID, hs.hosts[0].PublicKey) {
+		t.Fatal("unexpected alert", alert)
+	} else if fcids := alert.Data["contractIDs"].([]types.FileContractID); len(fcids) != 1 || fcids[0] != hs.contracts[0].ID {
+		t.Fatal("unexpected contract ids", fcids)
+	}
+
+	// update the prices in the store to the scanned ones
+	for i := range hs.hosts {
+		hs.hosts[i].V2Settings.Prices.StoragePrice = b.storagePrice
+	}
+
+	// the alert should remain as long as the price stays above the threshold
+	// compared to the price before the increase
+	b.storagePrice = types.Siacoins(5).Div64(2)
+	s.scanHosts(context.Background(), b, time.Now())
+	if resp, err := a.Alerts(context.Background(), alerts.AlertsOpts{}); err != nil {
+		t.Fatal(err)
+	} else if len(resp.Alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %v", resp.Alerts)
+	}
+
+	// the alert should be dismissed once the price is back within bounds
+	b.storagePrice = types.Siacoins(2)
+	s.scanHosts(context.Background(), b, time.Now())
+	if resp, err := a.Alerts(context.Background(), alerts.AlertsOpts{}); err != nil {
+		t.Fatal(err)
+	} else if len(resp.Alerts) != 0 {
+		t.Fatalf("expected no alerts, got %v", resp.Alerts)
+	}
+}
@@ -14,9 +14,11 @@ var (
 
 func newAccountLowBalanceAlert(address types.Address, balance, initialFunding types.Currency) alerts.Alert {
 	return alerts.Alert{
-		ID:       alertLowBalanceID,
-		Severity: alerts.SeverityWarning,
-		Message:  "Wallet is low on funds",
+		ID:          alertLowBalanceID,
+		Severity:    alerts.SeverityWarning,
+		Message:     "Wallet is low on funds",
+		Description: fmt.Sprintf("The balance of wallet %v is %v, which is less than the configured initialFunding of %v times the number of contracts to form.", address, balance, initialFunding),
+		Suggestion:  "Send funds to the wallet's address. Ideally, a wallet holds at least enough funds to make sure it can form a fresh set of contracts.",
 		Data: map[string]any{
 			"address":        address,
 			"balance":        balance,
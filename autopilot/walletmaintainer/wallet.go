@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/coreutils/wallet"
@@ -13,6 +14,18 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// initialRedistributeBackoff is the time wallet maintenance is skipped
+	// for after the first failed redistribution, every consecutive failure
+	// doubles it up to maxRedistributeBackoff.
+	initialRedistributeBackoff = 30 * time.Second
+	maxRedistributeBackoff     = time.Hour
+)
+
+// maintenanceEventTypes are the types of the pending events that can contain
+// a maintenance transaction.
+var maintenanceEventTypes = []string{wallet.EventTypeV1Transaction, wallet.EventTypeV2Transaction}
+
 type WalletMaintainerOption func(*walletMaintainer)
 
 func WithNumOutputs(minNumOutputs, desiredNumOutputs uint64) WalletMaintainerOption {
@@ -22,10 +35,21 @@ func WithNumOutputs(minNumOutputs, desiredNumOutputs uint64) WalletMaintainerOpt
 	}
 }
 
+// WithOutputAmount sets the value of the outputs the wallet is redistributed
+// into, it defaults to the initial contract funding. A zero amount keeps the
+// default.
+func WithOutputAmount(amount types.Currency) WalletMaintainerOption {
+	return func(w *walletMaintainer) {
+		if !amount.IsZero() {
+			w.outputAmount = amount
+		}
+	}
+}
+
 type (
 	Bus interface {
 		Wallet(ctx context.Context) (api.WalletResponse, error)
-		WalletPending(ctx context.Context) (resp []wallet.Event, err error)
+		WalletPending(ctx context.Context, opts api.WalletPendingOpts) (resp []wallet.Event, err error)
 		WalletRedistribute(ctx context.Context, outputs int, amount types.Currency) (ids []types.TransactionID, err error)
 	}
 )
@@ -38,9 +62,13 @@ type (
 
 		minNumOutputs     uint64
 		desiredNumOutputs uint64
+		outputAmount      types.Currency
 
 		mu                sync.Mutex
 		maintenanceTxnIDs []types.TransactionID
+		backoff           time.Duration
+		backoffUntil      time.Time
+		lastErr           error
 	}
 )
 
@@ -50,6 +78,7 @@ func New(alerter alerts.Alerter, bus Bus, logger *zap.Logger, opts ...WalletMain
 		bus:               bus,
 		minNumOutputs:     10,
 		desiredNumOutputs: 100,
+		outputAmount:      contractor.InitialContractFunding,
 		logger:            logger.Named("wallet").Sugar(),
 	}
 	for _, opt := range opts {
@@ -59,6 +88,15 @@ func New(alerter alerts.Alerter, bus Bus, logger *zap.Logger, opts ...WalletMain
 }
 
 func (w *walletMaintainer) PerformWalletMaintenance(ctx context.Context, cfg api.AutopilotConfig) error {
+	// skip maintenance if we're backing off after a failed redistribution
+	w.mu.Lock()
+	backoffUntil := w.backoffUntil
+	w.mu.Unlock()
+	if time.Now().Before(backoffUntil) {
+		w.logger.Debugf("wallet maintenance skipped, backing off until %v", backoffUntil)
+		return nil
+	}
+
 	w.logger.Info("performing wallet maintenance")
 
 	wallet, err := w.bus.Wallet(ctx)
@@ -69,8 +107,8 @@ func (w *walletMaintainer) PerformWalletMaintenance(ctx context.Context, cfg api
 
 	// register an alert if balance is low
 	balance := wallet.Confirmed
-	if balance.Cmp(contractor.InitialContractFunding.Mul64(cfg.Contracts.Amount)) < 0 {
-		if err := w.alerter.RegisterAlert(ctx, newAccountLowBalanceAlert(wallet.Address, balance, contractor.InitialContractFunding)); err != nil {
+	if balance.Cmp(w.outputAmount.Mul64(cfg.Contracts.WantedContracts())) < 0 {
+		if err := w.alerter.RegisterAlert(ctx, newAccountLowBalanceAlert(wallet.Address, balance, w.outputAmount)); err != nil {
 			w.logger.Warnf("failed to register low balance alert: %v", err)
 		}
 	} else {
@@ -84,21 +122,28 @@ func (w *walletMaintainer) PerformWalletMaintenance(ctx context.Context, cfg api
 	w.mu.Unlock()
 
 	// pending maintenance transaction - nothing to do
-	pending, err := w.bus.WalletPending(ctx)
-	if err != nil {
-		return nil
-	}
-	for _, txn := range pending {
-		for _, mTxnID := range maintenanceTxnIDs {
-			if mTxnID == types.TransactionID(txn.ID) {
-				w.logger.Debugf("wallet maintenance skipped, pending transaction found with id %v", mTxnID)
+	if len(maintenanceTxnIDs) > 0 {
+		pending, err := w.bus.WalletPending(ctx, api.WalletPendingOpts{
+			Types:     maintenanceEventTypes,
+			MinAmount: w.outputAmount,
+		})
+		if err != nil {
+			return nil
+		}
+		isMaintenanceTxn := make(map[types.TransactionID]struct{})
+		for _, id := range maintenanceTxnIDs {
+			isMaintenanceTxn[id] = struct{}{}
+		}
+		for _, txn := range pending {
+			if _, ok := isMaintenanceTxn[types.TransactionID(txn.ID)]; ok {
+				w.logger.Debugf("wallet maintenance skipped, pending transaction found with id %v", txn.ID)
 				return nil
 			}
 		}
 	}
 
 	// calculate number of outputs
-	amount := contractor.InitialContractFunding
+	amount := w.outputAmount
 	numOutputs := min(balance.Div(amount).Big().Uint64(), w.desiredNumOutputs)
 
 	// skip maintenance if wallet balance is too low
@@ -110,14 +155,31 @@ func (w *walletMaintainer) PerformWalletMaintenance(ctx context.Context, cfg api
 	// redistribute outputs
 	ids, err := w.bus.WalletRedistribute(ctx, int(numOutputs), amount)
 	if err != nil {
-		return fmt.Errorf("failed to redistribute wallet into %d outputs of amount %v, balance %v, err %v", int(numOutputs), amount, balance, err)
+		err = fmt.Errorf("failed to redistribute wallet into %d outputs of amount %v, balance %v, err %v", int(numOutputs), amount, balance, err)
+		w.mu.Lock()
+		w.backoff = min(max(2*w.backoff, initialRedistributeBackoff), maxRedistributeBackoff)
+		w.backoffUntil = time.Now().Add(w.backoff)
+		w.lastErr = err
+		w.mu.Unlock()
+		return err
 	}
 
 	w.logger.Infof("wallet maintenance succeeded, txns %v", ids)
 
 	w.mu.Lock()
-	maintenanceTxnIDs = ids
+	w.maintenanceTxnIDs = ids
+	w.backoff = 0
+	w.backoffUntil = time.Time{}
+	w.lastErr = nil
 	w.mu.Unlock()
 
 	return nil
 }
+
+// Status returns the time until which wallet maintenance is skipped due to a
+// failed redistribution and the error of that redistribution.
+func (w *walletMaintainer) Status() (backoffUntil time.Time, lastErr error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.backoffUntil, w.lastErr
+}
@@ -0,0 +1,95 @@
+package walletmaintainer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/renterd/v2/alerts"
+	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/autopilot/contractor"
+	"go.uber.org/zap"
+)
+
+type mockBus struct {
+	redistributeErr   error
+	redistributeCalls int
+}
+
+func (b *mockBus) Wallet(ctx context.Context) (api.WalletResponse, error) {
+	return api.WalletResponse{
+		Balance: wallet.Balance{Confirmed: types.Siacoins(1000)},
+	}, nil
+}
+
+func (b *mockBus) WalletPending(ctx context.Context, opts api.WalletPendingOpts) ([]wallet.Event, error) {
+	return nil, nil
+}
+
+func (b *mockBus) WalletRedistribute(ctx context.Context, outputs int, amount types.Currency) ([]types.TransactionID, error) {
+	b.redistributeCalls++
+	if b.redistributeErr != nil {
+		return nil, b.redistributeErr
+	}
+	return []types.TransactionID{{1}}, nil
+}
+
+func TestRedistributeBackoff(t *testing.T) {
+	b := &mockBus{redistributeErr: errors.New("redistribution failed")}
+	w := New(alerts.NewManager(alerts.Config{}), b, zap.NewNop(), WithNumOutputs(1, 1), WithOutputAmount(types.Siacoins(1)))
+
+	// assert a failed redistribution causes a backoff
+	if err := w.PerformWalletMaintenance(context.Background(), api.AutopilotConfig{}); err == nil {
+		t.Fatal("expected error")
+	} else if backoffUntil, lastErr := w.Status(); time.Until(backoffUntil) <= 0 || time.Until(backoffUntil) > initialRedistributeBackoff {
+		t.Fatalf("unexpected backoff %v", time.Until(backoffUntil))
+	} else if lastErr == nil || lastErr.Error() != err.Error() {
+		t.Fatalf("unexpected error %v", lastErr)
+	}
+
+	// assert maintenance is skipped while backing off
+	if err := w.PerformWalletMaintenance(context.Background(), api.AutopilotConfig{}); err != nil {
+		t.Fatal(err)
+	} else if b.redistributeCalls != 1 {
+		t.Fatalf("expected 1 redistribution, got %d", b.redistributeCalls)
+	}
+
+	// assert consecutive failures double the backoff up to the max
+	for i := 0; i < 10; i++ {
+		w.backoffUntil = time.Time{}
+		if err := w.PerformWalletMaintenance(context.Background(), api.AutopilotConfig{}); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if w.backoff != maxRedistributeBackoff {
+		t.Fatalf("unexpected backoff %v", w.backoff)
+	}
+
+	// assert a successful redistribution resets the backoff
+	b.redistributeErr = nil
+	w.backoffUntil = time.Time{}
+	if err := w.PerformWalletMaintenance(context.Background(), api.AutopilotConfig{}); err != nil {
+		t.Fatal(err)
+	} else if backoffUntil, lastErr := w.Status(); !backoffUntil.IsZero() || lastErr != nil {
+		t.Fatalf("expected backoff to be reset, %v %v", backoffUntil, lastErr)
+	} else if w.backoff != 0 {
+		t.Fatalf("unexpected backoff %v", w.backoff)
+	}
+}
+
+func TestWithOutputAmount(t *testing.T) {
+	// a zero amount keeps the default
+	w := New(alerts.NewManager(alerts.Config{}), &mockBus{}, zap.NewNop(), WithOutputAmount(types.ZeroCurrency))
+	if !w.outputAmount.Equals(contractor.InitialContractFunding) {
+		t.Fatalf("expected default output amount, got %v", w.outputAmount)
+	}
+
+	// a non-zero amount overrides it
+	w = New(alerts.NewManager(alerts.Config{}), &mockBus{}, zap.NewNop(), WithOutputAmount(types.Siacoins(25)))
+	if !w.outputAmount.Equals(types.Siacoins(25)) {
+		t.Fatalf("expected 25 SC output amount, got %v", w.outputAmount)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"go.sia.tech/core/consensus"
@@ -41,12 +42,23 @@ const (
 	defaultPinUpdateInterval          = 5 * time.Minute
 	defaultPinRateWindow              = 6 * time.Hour
 
+	defaultPendingContractsCheckInterval = 10 * time.Minute
+	defaultContractVerificationInterval  = time.Hour
+
 	lockingPriorityPruning   = 20
 	lockingPriorityFunding   = 40
 	lockingPriorityRenew     = 80
 	lockingPriorityBroadcast = 100
 
 	stdTxnSize = 1200 // bytes
+
+	// renewalTxnWeight is the weight used to estimate the miner fee of a
+	// renewal transaction, it matches the weight used by the RHP4 client.
+	renewalTxnWeight = 1000
+
+	// maxCollateralMultiplier is the maximum multiplier that can be applied
+	// to the host collateral when forming a contract.
+	maxCollateralMultiplier = 100
 )
 
 // Client re-exports the client from the client package.
@@ -104,6 +116,13 @@ type (
 		UnconfirmedParents(txn types.Transaction) ([]types.Transaction, error)
 	}
 
+	HostSettingsCache interface {
+		Get(hk types.PublicKey) (rhp4.HostSettings, time.Time, bool)
+		GougingBreakdown(hk types.PublicKey, gs api.GougingSettings) (rhp4.HostSettings, api.HostGougingBreakdown, bool)
+		Set(hk types.PublicKey, settings rhp4.HostSettings) time.Time
+		SetGougingBreakdown(hk types.PublicKey, cachedAt time.Time, gs api.GougingSettings, breakdown api.HostGougingBreakdown)
+	}
+
 	UploadingSectorsCache interface {
 		AddSectors(uID api.UploadID, roots ...types.Hash256) error
 		FinishUpload(uID api.UploadID)
@@ -147,9 +166,11 @@ type (
 	// Store is a collection of stores used by the bus.
 	Store interface {
 		AccountStore
+		AlertStore
 		AutopilotStore
 		BackupStore
 		ChainStore
+		DBStatsStore
 		HostStore
 		MetadataStore
 		MetricsStore
@@ -164,6 +185,13 @@ type (
 		SaveAccounts(context.Context, []api.Account) error
 	}
 
+	// An AlertStore keeps a record of dismissed alerts for auditing
+	// purposes.
+	AlertStore interface {
+		DismissedAlerts(ctx context.Context, offset, limit int) ([]alerts.DismissedAlert, error)
+		RecordDismissedAlerts(ctx context.Context, dismissed ...alerts.DismissedAlert) error
+	}
+
 	// A AutopilotStore stores autopilot state.
 	AutopilotStore interface {
 		AutopilotConfig(ctx context.Context) (api.AutopilotConfig, error)
@@ -176,6 +204,12 @@ type (
 		Backup(ctx context.Context, dbID, dst string) error
 	}
 
+	// DBStatsStore is the interface of a store that reports the utilization
+	// of its database connection pools.
+	DBStatsStore interface {
+		DBStats() api.DBStatsResponse
+	}
+
 	// A ChainStore stores information about the chain.
 	ChainStore interface {
 		ChainIndex(ctx context.Context) (types.ChainIndex, error)
@@ -191,8 +225,9 @@ type (
 		HostBlocklist(ctx context.Context) ([]string, error)
 		Hosts(ctx context.Context, opts api.HostOptions) ([]api.Host, error)
 		RecordHostScans(ctx context.Context, scans []api.HostScan) error
-		RemoveOfflineHosts(ctx context.Context, maxConsecutiveScanFailures uint64, maxDowntime time.Duration) (uint64, error)
+		RemoveOfflineHosts(ctx context.Context, maxConsecutiveScanFailures uint64, maxDowntime time.Duration, limit, maxEligible int) (removed, eligible uint64, _ error)
 		ResetLostSectors(ctx context.Context, hk types.PublicKey) error
+		UpdateHostSectorSize(ctx context.Context, hk types.PublicKey, sectorSize uint64) error
 		UpdateHostAllowlistEntries(ctx context.Context, add, remove []types.PublicKey, clear bool) error
 		UpdateHostBlocklistEntries(ctx context.Context, add, remove []string, clear bool) error
 		UpdateHostCheck(ctx context.Context, hk types.PublicKey, check api.HostChecks) error
@@ -206,36 +241,57 @@ type (
 		ArchiveContract(ctx context.Context, id types.FileContractID, reason string) error
 		ArchiveContracts(ctx context.Context, toArchive map[types.FileContractID]string) error
 		ArchiveAllContracts(ctx context.Context, reason string) error
+		UnarchiveContracts(ctx context.Context, ids []types.FileContractID) error
 		Contract(ctx context.Context, id types.FileContractID) (api.ContractMetadata, error)
 		Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error)
+		FailPendingContracts(ctx context.Context, maxStartHeight, height uint64) ([]types.FileContractID, error)
+		RecordContractEvent(ctx context.Context, id types.FileContractID, eventType string, details any) error
+		RecordContractFormation(ctx context.Context, c api.ContractMetadata) error
 		RecordContractSpending(ctx context.Context, records []api.ContractSpendingRecord) error
 		PutContract(ctx context.Context, c api.ContractMetadata) error
 		RenewedContract(ctx context.Context, renewedFrom types.FileContractID) (api.ContractMetadata, error)
+		UpdateContractSpendingCap(ctx context.Context, id types.FileContractID, spendingCap types.Currency) error
 		UpdateContractUsability(ctx context.Context, id types.FileContractID, usability string) error
+		UpdateContractUsabilityOverride(ctx context.Context, id types.FileContractID, usability string) error
+		DeleteContractUsabilityOverride(ctx context.Context, id types.FileContractID) error
+		UpdateContractVerificationStatus(ctx context.Context, id types.FileContractID, status string) error
 
+		ContractEvents(ctx context.Context, id types.FileContractID) ([]api.ContractEvent, error)
+		ContractRevisions(ctx context.Context, id types.FileContractID, opts api.ContractRevisionsOpts) ([]api.ContractRevisionRecord, error)
 		ContractRoots(ctx context.Context, id types.FileContractID) ([]types.Hash256, error)
-		ContractSizes(ctx context.Context) (map[types.FileContractID]api.ContractSize, error)
+		ContractStateHistory(ctx context.Context, id types.FileContractID) ([]api.ContractStateEvent, error)
+		ContractsPrunableData(ctx context.Context, opts api.ContractsPrunableDataOpts) (api.ContractsPrunableDataResponse, error)
 		ContractSize(ctx context.Context, id types.FileContractID) (api.ContractSize, error)
-		PrunableContractRoots(ctx context.Context, id types.FileContractID, roots []types.Hash256) ([]uint64, error)
+		PrunableContractRootsStream(ctx context.Context, id types.FileContractID, roots <-chan types.Hash256) (<-chan uint64, <-chan error, error)
 
 		DeleteHostSector(ctx context.Context, hk types.PublicKey, root types.Hash256) (int, error)
+		HostSectorPruneStats() api.HostSectorPruneStats
 
 		Bucket(_ context.Context, bucketName string) (api.Bucket, error)
+		BucketPolicy(_ context.Context, bucketName string) (api.BucketPolicy, error)
+		BucketUsage(_ context.Context, bucketName string) (api.BucketUsage, error)
 		Buckets(_ context.Context) ([]api.Bucket, error)
-		CreateBucket(_ context.Context, bucketName string, policy api.BucketPolicy) error
+		CreateBucket(_ context.Context, bucketName string, policy api.BucketPolicy, rs *api.RedundancySettings) error
 		DeleteBucket(_ context.Context, bucketName string) error
+		RecountBucketUsage(_ context.Context, bucketName string) (api.BucketUsage, error)
 		UpdateBucketPolicy(ctx context.Context, bucketName string, policy api.BucketPolicy) error
+		UpdateBucketRedundancy(ctx context.Context, bucketName string, rs *api.RedundancySettings) error
 
-		CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, dstKey, mimeType string, metadata api.ObjectUserMetadata) (api.ObjectMetadata, error)
+		CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, dstKey, mimeType string, metadata api.ObjectUserMetadata, overwrite bool) (api.ObjectMetadata, error)
+		GarbageCollect(ctx context.Context) (int64, error)
 		Object(ctx context.Context, bucketName, key string) (api.Object, error)
-		Objects(ctx context.Context, bucketName, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey) (api.ObjectsResponse, error)
+		Objects(ctx context.Context, bucketName, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey, metadata api.ObjectUserMetadata) (api.ObjectsResponse, error)
 		ObjectMetadata(ctx context.Context, bucketName, key string) (api.Object, error)
+		ObjectVersions(ctx context.Context, bucketName, key string) ([]api.ObjectVersion, error)
 		ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts) (api.ObjectsStatsResponse, error)
 		RemoveObject(ctx context.Context, bucketName, key string) error
 		RemoveObjects(ctx context.Context, bucketName, prefix string) error
-		RenameObject(ctx context.Context, bucketName, from, to string, force bool) error
+		RenameObject(ctx context.Context, srcBucket, dstBucket, from, to string, force bool) error
 		RenameObjects(ctx context.Context, bucketName, from, to string, force bool) error
+		RestoreObjectVersion(ctx context.Context, bucketName, key string, id int64) error
 		UpdateObject(ctx context.Context, bucketName, key, ETag, mimeType string, metadata api.ObjectUserMetadata, o object.Object) error
+		UpdateObjectMimeType(ctx context.Context, bucketName, key, mimeType string) error
+		UpdateObjectUserMetadata(ctx context.Context, bucketName, key string, metadata api.ObjectUserMetadata) error
 
 		AbortMultipartUpload(ctx context.Context, bucketName, key string, uploadID string) (err error)
 		AddMultipartPart(ctx context.Context, bucketName, key, eTag, uploadID string, partNumber int, slices []object.SlabSlice) (err error)
@@ -245,6 +301,7 @@ type (
 		MultipartUploads(ctx context.Context, bucketName, prefix, keyMarker, uploadIDMarker string, maxUploads int) (resp api.MultipartListUploadsResponse, _ error)
 		MultipartUploadParts(ctx context.Context, bucketName, object string, uploadID string, marker int, limit int64) (resp api.MultipartListPartsResponse, _ error)
 
+		ListSlabBuffers(ctx context.Context) ([]api.SlabBufferInfo, error)
 		MarkPackedSlabsUploaded(ctx context.Context, slabs []api.UploadedPackedSlab) error
 		PackedSlabsForUpload(ctx context.Context, lockingDuration time.Duration, minShards, totalShards uint8, limit int) ([]api.PackedSlab, error)
 		SlabBuffers(ctx context.Context) ([]api.SlabBuffer, error)
@@ -263,6 +320,7 @@ type (
 		RecordContractPruneMetric(ctx context.Context, metrics ...api.ContractPruneMetric) error
 
 		ContractMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractMetricsQueryOpts) ([]api.ContractMetric, error)
+		ContractSpendingHistory(ctx context.Context, fcid types.FileContractID, from, to time.Time, interval time.Duration) ([]api.ContractSpendingBucket, error)
 		RecordContractMetric(ctx context.Context, metrics ...api.ContractMetric) error
 
 		WalletMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.WalletMetricsQueryOpts) ([]api.WalletMetric, error)
@@ -289,6 +347,14 @@ type (
 	WalletMetricsRecorder interface {
 		Shutdown(context.Context) error
 	}
+
+	PendingContractsMonitor interface {
+		Shutdown(context.Context) error
+	}
+
+	ContractVerifier interface {
+		Shutdown(context.Context) error
+	}
 )
 
 type Bus struct {
@@ -306,13 +372,30 @@ type Bus struct {
 	store    Store
 
 	rhp4Client *rhp4.Client
+	rhp4Pool   *rhp4.WorkerPool
 
 	contractLocker        ContractLocker
 	explorer              *ibus.Explorer
+	hostSettingsCache     HostSettingsCache
 	sectors               UploadingSectorsCache
 	walletMetricsRecorder WalletMetricsRecorder
+	pendingContracts      PendingContractsMonitor
+	contractVerifier      ContractVerifier
 
 	logger *zap.SugaredLogger
+
+	// autopilotMu serializes autopilot config updates
+	autopilotMu sync.Mutex
+
+	maxHostsPerPruneBatch     int
+	hostPruneSafetyMultiplier int
+	maxRevisionGap            uint64
+	dbWriteRetry              config.DBWriteRetryPolicy
+
+	// hostPruneMu serializes offline host removals
+	hostPruneMu        sync.Mutex
+	hostPruneHalted    bool
+	hostPruneConfirmed bool
 }
 
 // New returns a new Bus
@@ -335,8 +418,18 @@ func New(cfg config.Bus, masterKey [32]byte, am AlertManager, cm ChainManager, s
 		alertMgr: am,
 		logger:   l.Sugar(),
 
-		rhp4Client: rhp4.New(dialer),
+		maxHostsPerPruneBatch:     cfg.MaxHostsPerPruneBatch,
+		hostPruneSafetyMultiplier: cfg.HostPruneSafetyMultiplier,
+		maxRevisionGap:            cfg.MaxRevisionGap,
+		dbWriteRetry:              cfg.DBWriteRetry,
+	}
+
+	// create rhp4 client, all RPCs performed by the bus share a bounded pool
+	// of workers
+	if cfg.RHP4PoolSize > 0 {
+		b.rhp4Pool = rhp4.NewWorkerPool(cfg.RHP4PoolSize)
 	}
+	b.rhp4Client = rhp4.NewWithWorkerPool(dialer, b.rhp4Pool)
 
 	// initialize autopilot config
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
@@ -352,16 +445,25 @@ func New(cfg config.Bus, masterKey [32]byte, am AlertManager, cm ChainManager, s
 	// create sectors cache
 	b.sectors = ibus.NewSectorsCache()
 
+	// create host settings cache
+	b.hostSettingsCache = ibus.NewHostSettingsCache(cfg.GougingCacheTTL)
+
 	// create pin manager
 	b.pinMgr = ibus.NewPinManager(b.alerts, b.explorer, store, defaultPinUpdateInterval, defaultPinRateWindow, l)
 
 	// create chain subscriber
 	announcementMaxAge := time.Duration(cfg.AnnouncementMaxAgeHours) * time.Hour
-	b.cs = ibus.NewChainSubscriber(cm, store, w, announcementMaxAge, l)
+	b.cs = ibus.NewChainSubscriber(b.alerts, cm, store, w, announcementMaxAge, l)
 
 	// create wallet metrics recorder
 	b.walletMetricsRecorder = ibus.NewWalletMetricRecorder(store, w, defaultWalletRecordMetricInterval, l)
 
+	// create pending contracts monitor
+	b.pendingContracts = ibus.NewPendingContractsMonitor(b.alerts, store, cfg.PendingContractTimeoutBlocks, defaultPendingContractsCheckInterval, l)
+
+	// create contract verifier
+	b.contractVerifier = ibus.NewContractVerifier(b.alerts, store, b.refreshContractRevision, defaultContractVerificationInterval, l)
+
 	return b, nil
 }
 
@@ -372,18 +474,27 @@ func (b *Bus) Handler() http.Handler {
 		"POST   /accounts":      b.accountsHandlerPOST,
 		"POST   /accounts/fund": b.accountsFundHandler,
 
-		"GET    /alerts":          b.handleGETAlerts,
-		"POST   /alerts/dismiss":  b.handlePOSTAlertsDismiss,
-		"POST   /alerts/register": b.handlePOSTAlertsRegister,
+		"POST   /admin/buckets/:name/recount": b.adminBucketRecountHandlerPOST,
+		"GET    /admin/db/stats":              b.adminDBStatsHandlerGET,
+
+		"GET    /alerts":           b.handleGETAlerts,
+		"POST   /alerts/dismiss":   b.handlePOSTAlertsDismiss,
+		"GET    /alerts/dismissed": b.handleGETAlertsDismissed,
+		"POST   /alerts/register":  b.handlePOSTAlertsRegister,
 
 		"GET    /autopilot": b.autopilotHandlerGET,
 		"PUT    /autopilot": b.autopilotHandlerPUT,
-
-		"GET    /buckets":             b.bucketsHandlerGET,
-		"POST   /buckets":             b.bucketsHandlerPOST,
-		"PUT    /bucket/:name/policy": b.bucketsHandlerPolicyPUT,
-		"DELETE /bucket/:name":        b.bucketHandlerDELETE,
-		"GET    /bucket/:name":        b.bucketHandlerGET,
+		"PATCH  /autopilot": b.autopilotHandlerPATCH,
+
+		"GET    /buckets":                 b.bucketsHandlerGET,
+		"POST   /buckets":                 b.bucketsHandlerPOST,
+		"GET    /buckets/:name/usage":     b.bucketsUsageHandlerGET,
+		"GET    /bucket/:name/policy":     b.bucketsHandlerPolicyGET,
+		"PUT    /bucket/:name/policy":     b.bucketsHandlerPolicyPUT,
+		"GET    /bucket/:name/redundancy": b.bucketsHandlerRedundancyGET,
+		"PUT    /bucket/:name/redundancy": b.bucketsHandlerRedundancyPUT,
+		"DELETE /bucket/:name":            b.bucketHandlerDELETE,
+		"GET    /bucket/:name":            b.bucketHandlerGET,
 
 		"POST   /consensus/acceptblock":        b.consensusAcceptBlock,
 		"GET    /consensus/network":            b.consensusNetworkHandler,
@@ -393,38 +504,52 @@ func (b *Bus) Handler() http.Handler {
 		"PUT    /contracts":             b.contractsHandlerPUT,
 		"GET    /contracts":             b.contractsHandlerGET,
 		"DELETE /contracts/all":         b.contractsAllHandlerDELETE,
+		"DELETE /contracts/archive":     b.contractsArchiveHandlerDELETE,
 		"POST   /contracts/archive":     b.contractsArchiveHandlerPOST,
 		"POST   /contracts/form":        b.contractsFormHandler,
 		"GET    /contracts/prunable":    b.contractsPrunableDataHandlerGET,
+		"POST   /contracts/refresh":     b.contractsRefreshHandlerPOST,
 		"GET    /contracts/renewed/:id": b.contractsRenewedIDHandlerGET,
 		"POST   /contracts/spending":    b.contractsSpendingHandlerPOST,
 
-		"GET    /contract/:id":           b.contractIDHandlerGET,
-		"DELETE /contract/:id":           b.contractIDHandlerDELETE,
-		"POST   /contract/:id/acquire":   b.contractAcquireHandlerPOST,
-		"GET    /contract/:id/ancestors": b.contractIDAncestorsHandler,
-		"POST   /contract/:id/broadcast": b.contractIDBroadcastHandler,
-		"POST   /contract/:id/keepalive": b.contractKeepaliveHandlerPOST,
-		"GET    /contract/:id/revision":  b.contractLatestRevisionHandlerGET,
-		"POST   /contract/:id/prune":     b.contractPruneHandlerPOST,
-		"POST   /contract/:id/renew":     b.contractIDRenewHandlerPOST,
-		"POST   /contract/:id/release":   b.contractReleaseHandlerPOST,
-		"GET    /contract/:id/roots":     b.contractIDRootsHandlerGET,
-		"GET    /contract/:id/size":      b.contractSizeHandlerGET,
-		"PUT    /contract/:id/usability": b.contractUsabilityHandlerPUT,
-
-		"GET    /hosts":           b.hostsHandlerGET,
-		"POST   /hosts":           b.hostsHandlerPOST,
-		"GET    /hosts/allowlist": b.hostsAllowlistHandlerGET,
-		"PUT    /hosts/allowlist": b.hostsAllowlistHandlerPUT,
-		"GET    /hosts/blocklist": b.hostsBlocklistHandlerGET,
-		"PUT    /hosts/blocklist": b.hostsBlocklistHandlerPUT,
-		"POST   /hosts/remove":    b.hostsRemoveHandlerPOST,
+		"GET    /contract/:id":                    b.contractIDHandlerGET,
+		"DELETE /contract/:id":                    b.contractIDHandlerDELETE,
+		"POST   /contract/:id/acquire":            b.contractAcquireHandlerPOST,
+		"GET    /contract/:id/ancestors":          b.contractIDAncestorsHandler,
+		"POST   /contract/:id/broadcast":          b.contractIDBroadcastHandler,
+		"GET    /contract/:id/events":             b.contractIDEventsHandlerGET,
+		"GET    /contract/:id/history":            b.contractIDHistoryHandlerGET,
+		"POST   /contract/:id/keepalive":          b.contractKeepaliveHandlerPOST,
+		"GET    /contract/:id/revision":           b.contractLatestRevisionHandlerGET,
+		"GET    /contract/:id/revisions":          b.contractIDRevisionsHandlerGET,
+		"POST   /contract/:id/prune":              b.contractPruneHandlerPOST,
+		"POST   /contract/:id/renew":              b.contractIDRenewHandlerPOST,
+		"GET    /contract/:id/renewestimate":      b.contractIDRenewEstimateHandlerGET,
+		"POST   /contract/:id/release":            b.contractReleaseHandlerPOST,
+		"GET    /contract/:id/roots":              b.contractIDRootsHandlerGET,
+		"GET    /contract/:id/size":               b.contractSizeHandlerGET,
+		"GET    /contract/:id/spending":           b.contractIDSpendingHandlerGET,
+		"PUT    /contract/:id/spendingcap":        b.contractSpendingCapHandlerPUT,
+		"PUT    /contract/:id/usability":          b.contractUsabilityHandlerPUT,
+		"PUT    /contract/:id/usability/override": b.contractUsabilityOverrideHandlerPUT,
+		"DELETE /contract/:id/usability/override": b.contractUsabilityOverrideHandlerDELETE,
+
+		"GET    /hosts":                b.hostsHandlerGET,
+		"POST   /hosts":                b.hostsHandlerPOST,
+		"GET    /hosts/allowlist":      b.hostsAllowlistHandlerGET,
+		"PUT    /hosts/allowlist":      b.hostsAllowlistHandlerPUT,
+		"GET    /hosts/blocklist":      b.hostsBlocklistHandlerGET,
+		"PUT    /hosts/blocklist":      b.hostsBlocklistHandlerPUT,
+		"POST   /hosts/remove":         b.hostsRemoveHandlerPOST,
+		"POST   /hosts/remove/confirm": b.hostsRemoveConfirmHandlerPOST,
 
 		"GET    /host/:hostkey":                  b.hostsPubkeyHandlerGET,
 		"PUT    /host/:hostkey/check":            b.hostsCheckHandlerPUT,
 		"POST   /host/:hostkey/resetlostsectors": b.hostsResetLostSectorsPOST,
 		"POST   /host/:hostkey/scan":             b.hostsScanHandlerPOST,
+		"PUT    /host/:hostkey/sectorsize":       b.hostsSectorSizeHandlerPUT,
+		"GET    /host/:hostkey/settings":         b.hostsSettingsHandlerGET,
+		"GET    /host/:hostkey/score":            b.hostsScoreHandlerGET,
 
 		"PUT    /metric/:key": b.metricsHandlerPUT,
 		"GET    /metric/:key": b.metricsHandlerGET,
@@ -438,10 +563,20 @@ func (b *Bus) Handler() http.Handler {
 		"POST   /multipart/listuploads": b.multipartHandlerListUploadsPOST,
 		"POST   /multipart/listparts":   b.multipartHandlerListPartsPOST,
 
-		"GET    /objects/*prefix": b.objectsHandlerGET,
-		"POST   /objects/copy":    b.objectsCopyHandlerPOST,
-		"POST   /objects/remove":  b.objectsRemoveHandlerPOST,
-		"POST   /objects/rename":  b.objectsRenameHandlerPOST,
+		"GET    /costestimate/*key": b.costEstimateHandlerGET,
+
+		"GET    /metadata/*key": b.metadataHandlerGET,
+		"PUT    /metadata/*key": b.metadataHandlerPUT,
+
+		"PUT    /mimetype/*key": b.mimeTypeHandlerPUT,
+
+		"GET    /objects/*prefix":          b.objectsHandlerGET,
+		"POST   /objects/copy":             b.objectsCopyHandlerPOST,
+		"POST   /objects/gc":               b.objectsGCHandlerPOST,
+		"POST   /objects/remove":           b.objectsRemoveHandlerPOST,
+		"POST   /objects/rename":           b.objectsRenameHandlerPOST,
+		"POST   /objects/versions":         b.objectsVersionsHandlerPOST,
+		"POST   /objects/versions/restore": b.objectsVersionsRestoreHandlerPOST,
 
 		"GET    /object/*key": b.objectHandlerGET,
 		"PUT    /object/*key": b.objectHandlerPUT,
@@ -465,6 +600,7 @@ func (b *Bus) Handler() http.Handler {
 		"POST   /slabbuffer/done":  b.packedSlabsHandlerDonePOST,
 		"POST   /slabbuffer/fetch": b.packedSlabsHandlerFetchPOST,
 
+		"GET    /slabs/buffers":       b.slabsBuffersHandlerGET,
 		"POST   /slabs/migration":     b.slabsMigrationHandlerPOST,
 		"GET    /slabs/partial/:key":  b.slabsPartialHandlerGET,
 		"POST   /slabs/partial":       b.slabsPartialHandlerPOST,
@@ -474,6 +610,7 @@ func (b *Bus) Handler() http.Handler {
 
 		"GET    /state": b.stateHandlerGET,
 
+		"GET    /stats":         b.statsHandlerGET,
 		"GET    /stats/objects": b.objectsStatshandlerGET,
 
 		"GET    /syncer/address": b.syncerAddrHandler,
@@ -495,6 +632,7 @@ func (b *Bus) Handler() http.Handler {
 		"GET  /wallet/pending":      b.walletPendingHandler,
 		"POST /wallet/redistribute": b.walletRedistributeHandler,
 		"POST /wallet/send":         b.walletSendSiacoinsHandler,
+		"GET  /wallet/unconfirmed":  b.walletUnconfirmedHandler,
 	})
 }
 
@@ -502,13 +640,15 @@ func (b *Bus) Handler() http.Handler {
 func (b *Bus) Shutdown(ctx context.Context) error {
 	return errors.Join(
 		b.walletMetricsRecorder.Shutdown(ctx),
+		b.pendingContracts.Shutdown(ctx),
+		b.contractVerifier.Shutdown(ctx),
 		b.pinMgr.Shutdown(ctx),
 		b.cs.Shutdown(ctx),
 	)
 }
 
 func (b *Bus) addContract(ctx context.Context, contract api.ContractMetadata) (api.ContractMetadata, error) {
-	if err := b.store.PutContract(ctx, contract); err != nil {
+	if err := b.store.RecordContractFormation(ctx, contract); err != nil {
 		return api.ContractMetadata{}, err
 	}
 	return b.store.Contract(ctx, contract.ID)
@@ -615,14 +755,15 @@ func (b *Bus) formContract(ctx context.Context, hk types.PublicKey, hostIP strin
 		WindowEnd:          contract.Revision.ProofHeight + rhpv4.ProofWindow,
 		ContractPrice:      res.Usage.RenterCost(),
 		InitialRenterFunds: renterFunds,
+		NetworkFees:        networkFees(res.FormationSet.Transactions),
 		Usability:          api.ContractUsabilityGood,
 	}, nil
 }
 
-func (b *Bus) refreshContract(ctx context.Context, cs consensus.State, h api.Host, gp api.GougingParams, c api.ContractMetadata, renterFunds, minNewCollateral types.Currency) (api.ContractMetadata, error) {
+func (b *Bus) refreshContract(ctx context.Context, cs consensus.State, h api.Host, gp api.GougingParams, c api.ContractMetadata, renterFunds, minNewCollateral types.Currency, feeMultiplier float64) (api.ContractMetadata, error) {
 	// derive the renter key
 	renterKey := b.masterKey.DeriveContractKey(c.HostKey)
-	signer := ibus.NewFormContractSigner(b.w, renterKey)
+	signer := ibus.NewFormContractSignerWithFeeMultiplier(b.w, renterKey, feeMultiplier)
 
 	// fetch the revision
 	rev, err := b.rhp4Client.LatestRevision(ctx, h.PublicKey, h.SiamuxAddr(), c.ID)
@@ -688,14 +829,16 @@ func (b *Bus) refreshContract(ctx context.Context, cs consensus.State, h api.Hos
 		WindowEnd:          contract.Revision.ExpirationHeight,
 		ContractPrice:      settings.Prices.ContractPrice,
 		InitialRenterFunds: contract.Revision.RenterOutput.Value,
+		NetworkFees:        networkFees(res.RenewalSet.Transactions),
 		Usability:          api.ContractUsabilityGood,
+		HostVersion:        settings.Release,
 	}, nil
 }
 
-func (b *Bus) renewContract(ctx context.Context, cs consensus.State, h api.Host, gp api.GougingParams, c api.ContractMetadata, renterFunds types.Currency, endHeight uint64) (api.ContractMetadata, error) {
+func (b *Bus) renewContract(ctx context.Context, cs consensus.State, h api.Host, gp api.GougingParams, c api.ContractMetadata, renterFunds types.Currency, endHeight uint64, feeMultiplier float64) (api.ContractMetadata, error) {
 	// derive the renter key
 	renterKey := b.masterKey.DeriveContractKey(c.HostKey)
-	signer := ibus.NewFormContractSigner(b.w, renterKey)
+	signer := ibus.NewFormContractSignerWithFeeMultiplier(b.w, renterKey, feeMultiplier)
 
 	// fetch the revision
 	rev, err := b.rhp4Client.LatestRevision(ctx, h.PublicKey, h.SiamuxAddr(), c.ID)
@@ -753,10 +896,56 @@ func (b *Bus) renewContract(ctx context.Context, cs consensus.State, h api.Host,
 		WindowEnd:          contract.Revision.ExpirationHeight,
 		ContractPrice:      settings.Prices.ContractPrice,
 		InitialRenterFunds: contract.Revision.RenterOutput.Value,
+		NetworkFees:        networkFees(res.RenewalSet.Transactions),
 		Usability:          api.ContractUsabilityGood,
+		HostVersion:        settings.Release,
 	}, nil
 }
 
+// minRenterFunds returns the minimum renter funds accepted when renewing a
+// contract with the given host. Renter funds below the host's contract price
+// plus the estimated miner fee of the renewal are too small for the renewal to
+// be of any use. The cost of storing the contract's existing data is paid on
+// top of the renter funds, so it's not included.
+func minRenterFunds(h api.Host, fee types.Currency) types.Currency {
+	return h.V2Settings.Prices.ContractPrice.Add(fee.Mul64(renewalTxnWeight))
+}
+
+// renewalStorageCost estimates the cost of storing the contract's current data
+// until the given end height using the prices of the host's last scan. It's
+// paid by the renter in addition to the renter funds of the renewal.
+func renewalStorageCost(h api.Host, c api.ContractMetadata, height, endHeight uint64) types.Currency {
+	var duration uint64
+	if endHeight > height {
+		duration = endHeight - height
+	}
+	return h.V2Settings.Prices.StoragePrice.Mul64(c.Size).Mul64(duration)
+}
+
+// networkFees returns the miner fee of the contract transaction in the given
+// set, which is always the last transaction in the set. The fee is set by the
+// signer using the chain manager's fee estimate.
+func networkFees(txnSet []types.V2Transaction) types.Currency {
+	if len(txnSet) == 0 {
+		return types.ZeroCurrency
+	}
+	return txnSet[len(txnSet)-1].MinerFee
+}
+
+// multiplyCollateral applies the given multiplier to the collateral, the
+// multiplier is applied with a precision of 3 decimals. It returns false if
+// the multiplied collateral overflows.
+func multiplyCollateral(collateral types.Currency, multiplier float64) (types.Currency, bool) {
+	if multiplier <= 0 || multiplier == 1 {
+		return collateral, true
+	}
+	multiplied, overflow := collateral.Mul64WithOverflow(uint64(math.Round(multiplier * 1000)))
+	if overflow {
+		return types.ZeroCurrency, false
+	}
+	return multiplied.Div64(1000), true
+}
+
 func isErrHostUnreachable(err error) bool {
 	return utils.IsErr(err, os.ErrDeadlineExceeded) ||
 		utils.IsErr(err, context.DeadlineExceeded) ||
@@ -0,0 +1,33 @@
+package bus
+
+import (
+	"math"
+	"testing"
+
+	"go.sia.tech/core/types"
+)
+
+func TestMultiplyCollateral(t *testing.T) {
+	sc := types.Siacoins(1)
+	tests := []struct {
+		collateral types.Currency
+		multiplier float64
+		want       types.Currency
+		ok         bool
+	}{
+		{sc, 0, sc, true},
+		{sc, 1, sc, true},
+		{sc, 1.5, sc.Mul64(3).Div64(2), true},
+		{sc, 100, sc.Mul64(100), true},
+		{types.MaxCurrency, 2, types.ZeroCurrency, false},
+		{types.NewCurrency(math.MaxUint64, math.MaxUint64/1000), 1.001, types.ZeroCurrency, false},
+	}
+	for _, test := range tests {
+		got, ok := multiplyCollateral(test.collateral, test.multiplier)
+		if ok != test.ok {
+			t.Fatalf("expected ok %v for %v * %v, got %v", test.ok, test.collateral, test.multiplier, ok)
+		} else if !got.Equals(test.want) {
+			t.Fatalf("expected %v for %v * %v, got %v", test.want, test.collateral, test.multiplier, got)
+		}
+	}
+}
@@ -19,6 +19,9 @@ func (c *Client) Alerts(ctx context.Context, opts alerts.AlertsOpts) (resp alert
 	if opts.Severity != 0 {
 		values.Set("severity", opts.Severity.String())
 	}
+	for _, key := range opts.GroupBy {
+		values.Add("groupby", key)
+	}
 	err = c.c.GET(ctx, "/alerts?"+values.Encode(), &resp)
 	return
 }
@@ -28,6 +31,22 @@ func (c *Client) DismissAlerts(ctx context.Context, ids ...types.Hash256) error
 	return c.dismissAlerts(ctx, false, ids...)
 }
 
+// DismissAlertsWithReason dismisses the alerts in the given requests and
+// records why they were dismissed.
+func (c *Client) DismissAlertsWithReason(ctx context.Context, reqs ...alerts.DismissAlertRequest) error {
+	return c.c.POST(ctx, "/alerts/dismiss", reqs, nil)
+}
+
+// DismissedAlerts returns the recorded alert dismissals, the most recent ones
+// first.
+func (c *Client) DismissedAlerts(ctx context.Context, offset, limit int) (dismissed []alerts.DismissedAlert, err error) {
+	values := url.Values{}
+	values.Set("offset", fmt.Sprint(offset))
+	values.Set("limit", fmt.Sprint(limit))
+	err = c.c.GET(ctx, "/alerts/dismissed?"+values.Encode(), &dismissed)
+	return
+}
+
 func (c *Client) dismissAlerts(ctx context.Context, all bool, ids ...types.Hash256) error {
 	values := url.Values{}
 	if all {
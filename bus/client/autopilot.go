@@ -1,9 +1,14 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
 
 	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/internal/utils"
 )
 
 type UpdateAutopilotOption func(*api.UpdateAutopilotRequest)
@@ -38,3 +43,27 @@ func (c *Client) UpdateAutopilotConfig(ctx context.Context, opts ...UpdateAutopi
 	}
 	return c.c.PUT(ctx, "/autopilot", req)
 }
+
+// PatchAutopilotConfig applies a JSON Merge Patch to the autopilot
+// configuration and returns the updated configuration. If eTag is not empty,
+// the update is only applied if the configuration wasn't modified since it was
+// fetched, see api.AutopilotConfig.ETag.
+func (c *Client) PatchAutopilotConfig(ctx context.Context, patch map[string]any, eTag string) (cfg api.AutopilotConfig, err error) {
+	c.c.Custom("PATCH", "/autopilot", map[string]any{}, &cfg)
+
+	js, err := json.Marshal(patch)
+	if err != nil {
+		return api.AutopilotConfig{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "PATCH", fmt.Sprintf("%s/autopilot", c.c.BaseURL), bytes.NewReader(js))
+	if err != nil {
+		panic(err)
+	}
+	req.SetBasicAuth("", c.c.Password)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	if eTag != "" {
+		req.Header.Set("If-Match", api.FormatETag(eTag))
+	}
+	_, _, err = utils.DoRequest(req, &cfg)
+	return
+}
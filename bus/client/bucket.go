@@ -13,11 +13,33 @@ func (c *Client) Bucket(ctx context.Context, bucketName string) (resp api.Bucket
 	return
 }
 
+// BucketPolicy returns the policy of a specific bucket.
+func (c *Client) BucketPolicy(ctx context.Context, bucketName string) (policy api.BucketPolicy, err error) {
+	err = c.c.GET(ctx, fmt.Sprintf("/bucket/%s/policy", bucketName), &policy)
+	return
+}
+
+// BucketRedundancy returns the redundancy settings of a specific bucket, nil
+// means the bucket uses the global redundancy settings.
+func (c *Client) BucketRedundancy(ctx context.Context, bucketName string) (*api.RedundancySettings, error) {
+	var resp api.BucketRedundancyResponse
+	err := c.c.GET(ctx, fmt.Sprintf("/bucket/%s/redundancy", bucketName), &resp)
+	return resp.Redundancy, err
+}
+
+// BucketUsage returns the number of objects in a bucket and their combined
+// size.
+func (c *Client) BucketUsage(ctx context.Context, bucketName string) (resp api.BucketUsage, err error) {
+	err = c.c.GET(ctx, fmt.Sprintf("/buckets/%s/usage", bucketName), &resp)
+	return
+}
+
 // CreateBucket creates a new bucket.
 func (c *Client) CreateBucket(ctx context.Context, bucketName string, opts api.CreateBucketOptions) error {
 	return c.c.POST(ctx, "/buckets", api.BucketCreateRequest{
-		Name:   bucketName,
-		Policy: opts.Policy,
+		Name:       bucketName,
+		Policy:     opts.Policy,
+		Redundancy: opts.Redundancy,
 	}, nil)
 }
 
@@ -32,9 +54,25 @@ func (c *Client) ListBuckets(ctx context.Context) (buckets []api.Bucket, err err
 	return
 }
 
+// RecountBucketUsage rebuilds the usage counters of a bucket by counting its
+// objects.
+func (c *Client) RecountBucketUsage(ctx context.Context, bucketName string) (resp api.BucketUsage, err error) {
+	err = c.c.POST(ctx, fmt.Sprintf("/admin/buckets/%s/recount", bucketName), nil, &resp)
+	return
+}
+
 // UpdateBucketPolicy updates the policy of an existing bucket.
 func (c *Client) UpdateBucketPolicy(ctx context.Context, bucketName string, policy api.BucketPolicy) error {
 	return c.c.PUT(ctx, fmt.Sprintf("/bucket/%s/policy", bucketName), api.BucketUpdatePolicyRequest{
 		Policy: policy,
 	})
 }
+
+// UpdateBucketRedundancy updates the redundancy settings of an existing
+// bucket, nil resets them to the global redundancy settings. Only future
+// uploads are affected.
+func (c *Client) UpdateBucketRedundancy(ctx context.Context, bucketName string, rs *api.RedundancySettings) error {
+	return c.c.PUT(ctx, fmt.Sprintf("/bucket/%s/redundancy", bucketName), api.BucketUpdateRedundancyRequest{
+		Redundancy: rs,
+	})
+}
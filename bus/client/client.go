@@ -31,6 +31,13 @@ func (c *Client) Backup(ctx context.Context, database, dstPath string) (err erro
 	return
 }
 
+// DBStats returns the connection pool utilization of the bus' databases and
+// the durations of the queries and transactions executed on them.
+func (c *Client) DBStats(ctx context.Context) (resp api.DBStatsResponse, err error) {
+	err = c.c.GET(ctx, "/admin/db/stats", &resp)
+	return
+}
+
 // ScanHost scans a host, returning its current settings and prices.
 func (c *Client) ScanHost(ctx context.Context, hostKey types.PublicKey, timeout time.Duration) (resp api.HostScanResponse, err error) {
 	err = c.c.POST(ctx, fmt.Sprintf("/host/%s/scan", hostKey), api.HostScanRequest{
@@ -44,3 +51,10 @@ func (c *Client) State(ctx context.Context) (state api.BusStateResponse, err err
 	err = c.c.GET(ctx, "/state", &state)
 	return
 }
+
+// Stats returns runtime statistics of the bus, such as the progress of host
+// sector pruning.
+func (c *Client) Stats(ctx context.Context) (stats api.BusStatsResponse, err error) {
+	err = c.c.GET(ctx, "/stats", &stats)
+	return
+}
@@ -42,6 +42,17 @@ func (c *Client) ArchiveContracts(ctx context.Context, toArchive map[types.FileC
 	return
 }
 
+// UnarchiveContracts moves the archived contracts with the given IDs back to
+// the regular contracts.
+func (c *Client) UnarchiveContracts(ctx context.Context, ids []types.FileContractID) (err error) {
+	values := url.Values{}
+	for _, id := range ids {
+		values.Add("id", id.String())
+	}
+	err = c.c.DELETE(ctx, "/contracts/archive?"+values.Encode())
+	return
+}
+
 // BroadcastContract broadcasts the latest revision for a contract.
 func (c *Client) BroadcastContract(ctx context.Context, contractID types.FileContractID) (txnID types.TransactionID, err error) {
 	err = c.c.POST(ctx, fmt.Sprintf("/contract/%s/broadcast", contractID), nil, &txnID)
@@ -54,6 +65,33 @@ func (c *Client) Contract(ctx context.Context, id types.FileContractID) (contrac
 	return
 }
 
+// ContractEvents returns the lifecycle events of the contract with given id in
+// chronological order.
+func (c *Client) ContractEvents(ctx context.Context, contractID types.FileContractID) (events []api.ContractEvent, err error) {
+	err = c.c.GET(ctx, fmt.Sprintf("/contract/%s/events", contractID), &events)
+	return
+}
+
+// ContractStateHistory returns the state transitions of the contract with
+// given id in chronological order.
+func (c *Client) ContractStateHistory(ctx context.Context, contractID types.FileContractID) (history []api.ContractStateEvent, err error) {
+	err = c.c.GET(ctx, fmt.Sprintf("/contract/%s/history", contractID), &history)
+	return
+}
+
+// ContractRevisions returns the recorded revision history of the contract with
+// given id. The opts can be used to limit the result to a range of revision
+// numbers, if End is zero no upper bound is applied.
+func (c *Client) ContractRevisions(ctx context.Context, contractID types.FileContractID, opts api.ContractRevisionsOpts) (revisions []api.ContractRevisionRecord, err error) {
+	values := url.Values{}
+	values.Set("start", fmt.Sprint(opts.Start))
+	if opts.End > 0 {
+		values.Set("end", fmt.Sprint(opts.End))
+	}
+	err = c.c.GET(ctx, fmt.Sprintf("/contract/%s/revisions?%s", contractID, values.Encode()), &revisions)
+	return
+}
+
 // ContractRoots returns the sector roots, as well as the ones that are still
 // uploading, for the contract with given id.
 func (c *Client) ContractRoots(ctx context.Context, contractID types.FileContractID) (roots []types.Hash256, err error) {
@@ -61,9 +99,30 @@ func (c *Client) ContractRoots(ctx context.Context, contractID types.FileContrac
 	return
 }
 
-// ContractSize returns the contract's size.
+// ContractSize returns the contract's size together with an estimate of how
+// much of it can be pruned, the estimate is computed without contacting the
+// host.
 func (c *Client) ContractSize(ctx context.Context, contractID types.FileContractID) (size api.ContractSize, err error) {
-	err = c.c.GET(ctx, fmt.Sprintf("/contract/%s/size", contractID), &size)
+	err = c.c.GET(ctx, fmt.Sprintf("/contract/%s/size?estimate=true", contractID), &size)
+	return
+}
+
+// ContractSizeExact returns the contract's size together with the exact
+// amount of data that can be pruned. This requires fetching the contract's
+// sector roots from the host, which is paid for using the contract.
+func (c *Client) ContractSizeExact(ctx context.Context, contractID types.FileContractID) (size api.ContractSize, err error) {
+	err = c.c.GET(ctx, fmt.Sprintf("/contract/%s/size?estimate=false", contractID), &size)
+	return
+}
+
+// ContractSpendingHistory returns the spending of the contract with given id
+// between 'from' and 'to', grouped in buckets of the given granularity.
+func (c *Client) ContractSpendingHistory(ctx context.Context, contractID types.FileContractID, from, to time.Time, granularity string) (buckets []api.ContractSpendingBucket, err error) {
+	values := url.Values{}
+	values.Set("from", api.TimeRFC3339(from).String())
+	values.Set("to", api.TimeRFC3339(to).String())
+	values.Set("granularity", granularity)
+	err = c.c.GET(ctx, fmt.Sprintf("/contract/%s/spending?%s", contractID, values.Encode()), &buckets)
 	return
 }
 
@@ -74,6 +133,21 @@ func (c *Client) Contracts(ctx context.Context, opts api.ContractsOpts) (contrac
 	if opts.FilterMode != "" {
 		values.Set("filtermode", opts.FilterMode)
 	}
+	if !opts.MinRemainingFunds.IsZero() {
+		values.Set("minremainingfunds", opts.MinRemainingFunds.ExactString())
+	}
+	if opts.MaxSpendingRatio > 0 {
+		values.Set("maxspendingratio", api.ParamFloat64(opts.MaxSpendingRatio).String())
+	}
+	if opts.ExpiringWithinBlocks > 0 {
+		values.Set("expiringwithinblocks", fmt.Sprint(opts.ExpiringWithinBlocks))
+	}
+	if opts.HostVersionBelow != "" {
+		values.Set("hostversionbelow", opts.HostVersionBelow)
+	}
+	if opts.HostKey != (types.PublicKey{}) {
+		values.Set("hostkey", opts.HostKey.String())
+	}
 	err = c.c.GET(ctx, "/contracts?"+values.Encode(), &contracts)
 	return
 }
@@ -101,14 +175,17 @@ func (c *Client) DeleteAllContracts(ctx context.Context) (err error) {
 	return
 }
 
-// FormContract forms a contract with a host and adds it to the bus.
-func (c *Client) FormContract(ctx context.Context, renterAddress types.Address, renterFunds types.Currency, hostKey types.PublicKey, hostCollateral types.Currency, endHeight uint64) (contract api.ContractMetadata, err error) {
+// FormContract forms a contract with a host and adds it to the bus. The
+// collateral multiplier is applied to the host collateral, hosts that can't
+// provide the extra collateral are offered the standard collateral instead.
+func (c *Client) FormContract(ctx context.Context, renterAddress types.Address, renterFunds types.Currency, hostKey types.PublicKey, hostCollateral types.Currency, endHeight uint64, collateralMultiplier float64) (contract api.ContractMetadata, err error) {
 	err = c.c.POST(ctx, "/contracts/form", api.ContractFormRequest{
-		EndHeight:      endHeight,
-		HostCollateral: hostCollateral,
-		HostKey:        hostKey,
-		RenterFunds:    renterFunds,
-		RenterAddress:  renterAddress,
+		EndHeight:            endHeight,
+		HostCollateral:       hostCollateral,
+		HostKey:              hostKey,
+		RenterFunds:          renterFunds,
+		RenterAddress:        renterAddress,
+		CollateralMultiplier: collateralMultiplier,
 	}, &contract)
 	return
 }
@@ -130,10 +207,32 @@ func (c *Client) ContractRevision(ctx context.Context, contractID types.FileCont
 	return
 }
 
-// PrunableData returns an overview of all contract sizes, the total size and
-// the amount of data that can be pruned.
-func (c *Client) PrunableData(ctx context.Context) (prunableData api.ContractsPrunableDataResponse, err error) {
-	err = c.c.GET(ctx, "/contracts/prunable", &prunableData)
+// RefreshContracts fetches the latest revision of the given contracts from
+// their hosts and updates their revision number and size, a parallelism of
+// zero uses the bus' default.
+func (c *Client) RefreshContracts(ctx context.Context, ids []types.FileContractID, parallelism int) (results []api.ContractRefreshResult, err error) {
+	values := url.Values{}
+	if parallelism > 0 {
+		values.Set("parallelism", fmt.Sprint(parallelism))
+	}
+	err = c.c.POST(ctx, "/contracts/refresh?"+values.Encode(), ids, &results)
+	return
+}
+
+// PrunableData returns a page of contract sizes, the total size and the amount
+// of data that can be pruned.
+func (c *Client) PrunableData(ctx context.Context, opts api.ContractsPrunableDataOpts) (prunableData api.ContractsPrunableDataResponse, err error) {
+	values := url.Values{}
+	if opts.SortBy != "" {
+		values.Set("sortby", opts.SortBy)
+	}
+	if opts.Offset > 0 {
+		values.Set("offset", fmt.Sprint(opts.Offset))
+	}
+	if opts.Limit > 0 {
+		values.Set("limit", fmt.Sprint(opts.Limit))
+	}
+	err = c.c.GET(ctx, "/contracts/prunable?"+values.Encode(), &prunableData)
 	return
 }
 
@@ -143,17 +242,44 @@ func (c *Client) PruneContract(ctx context.Context, contractID types.FileContrac
 	return
 }
 
+// PruneContractMaxSectors prunes the given contract, freeing at most
+// maxSectors sectors.
+func (c *Client) PruneContractMaxSectors(ctx context.Context, contractID types.FileContractID, timeout time.Duration, maxSectors uint64) (res api.ContractPruneResponse, err error) {
+	err = c.c.POST(ctx, fmt.Sprintf("/contract/%s/prune", contractID), api.ContractPruneRequest{Timeout: api.DurationMS(timeout), MaxSectors: maxSectors}, &res)
+	return
+}
+
+// PruneContractDryRun returns the amount of data that would be pruned from the
+// contract with given id without actually pruning it.
+func (c *Client) PruneContractDryRun(ctx context.Context, contractID types.FileContractID, timeout time.Duration) (res api.ContractPruneResponse, err error) {
+	err = c.c.POST(ctx, fmt.Sprintf("/contract/%s/prune", contractID), api.ContractPruneRequest{Timeout: api.DurationMS(timeout), DryRun: true}, &res)
+	return
+}
+
 // RenewContract renews an existing contract with a host and adds it to the bus.
-func (c *Client) RenewContract(ctx context.Context, contractID types.FileContractID, endHeight uint64, renterFunds, minNewCollateral types.Currency) (renewal api.ContractMetadata, err error) {
+// The fee multiplier is applied to the recommended fee of the renewal
+// transaction, values above 1.0 increase the speed of confirmation at a higher
+// cost.
+func (c *Client) RenewContract(ctx context.Context, contractID types.FileContractID, endHeight uint64, renterFunds, minNewCollateral types.Currency, feeMultiplier float64) (renewal api.ContractMetadata, err error) {
 	req := api.ContractRenewRequest{
 		EndHeight:        endHeight,
 		MinNewCollateral: minNewCollateral,
 		RenterFunds:      renterFunds,
+		FeeMultiplier:    feeMultiplier,
 	}
 	err = c.c.POST(ctx, fmt.Sprintf("/contract/%s/renew", contractID), req, &renewal)
 	return
 }
 
+// RenewContractEstimate returns the minimum renter funds required to renew the
+// contract with the given id until the given end height.
+func (c *Client) RenewContractEstimate(ctx context.Context, contractID types.FileContractID, endHeight uint64) (resp api.ContractRenewEstimateResponse, err error) {
+	values := url.Values{}
+	values.Set("endHeight", fmt.Sprint(endHeight))
+	err = c.c.GET(ctx, fmt.Sprintf("/contract/%s/renewestimate?%s", contractID, values.Encode()), &resp)
+	return
+}
+
 // RenewedContract returns the renewed contract for the given ID.
 func (c *Client) RenewedContract(ctx context.Context, renewedFrom types.FileContractID) (contract api.ContractMetadata, err error) {
 	err = c.c.GET(ctx, fmt.Sprintf("/contracts/renewed/%s", renewedFrom), &contract)
@@ -174,8 +300,29 @@ func (c *Client) ReleaseContract(ctx context.Context, contractID types.FileContr
 	return
 }
 
+// UpdateContractSpendingCap updates the spending cap of the given contract, a
+// zero cap means unlimited.
+func (c *Client) UpdateContractSpendingCap(ctx context.Context, contractID types.FileContractID, spendingCap types.Currency) (err error) {
+	err = c.c.PUT(ctx, fmt.Sprintf("/contract/%s/spendingcap", contractID), api.ContractSpendingCapRequest{SpendingCap: spendingCap})
+	return
+}
+
 // UpdateContractUsability updates the usability of the given contract.
 func (c *Client) UpdateContractUsability(ctx context.Context, contractID types.FileContractID, usability string) (err error) {
 	err = c.c.PUT(ctx, fmt.Sprintf("/contract/%s/usability", contractID), usability)
 	return
 }
+
+// OverrideContractUsability sets the usability of the given contract and
+// prevents the autopilot from updating it until the override is removed.
+func (c *Client) OverrideContractUsability(ctx context.Context, contractID types.FileContractID, usability string) (err error) {
+	err = c.c.PUT(ctx, fmt.Sprintf("/contract/%s/usability/override", contractID), api.ContractUsabilityOverrideRequest{Usability: usability})
+	return
+}
+
+// RemoveContractUsabilityOverride removes the usability override of the given
+// contract, allowing the autopilot to update its usability again.
+func (c *Client) RemoveContractUsabilityOverride(ctx context.Context, contractID types.FileContractID) (err error) {
+	err = c.c.DELETE(ctx, fmt.Sprintf("/contract/%s/usability/override", contractID))
+	return
+}
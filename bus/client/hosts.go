@@ -15,6 +15,19 @@ func (c *Client) Host(ctx context.Context, hostKey types.PublicKey) (h api.Host,
 	return
 }
 
+// HostScore returns the score breakdown of the host with the given public key.
+func (c *Client) HostScore(ctx context.Context, hostKey types.PublicKey) (resp api.HostScoreResponse, err error) {
+	err = c.c.GET(ctx, fmt.Sprintf("/host/%s/score", hostKey), &resp)
+	return
+}
+
+// HostSettings returns the settings of the host with the given public key. The
+// settings are fetched from the host unless they were fetched recently.
+func (c *Client) HostSettings(ctx context.Context, hostKey types.PublicKey) (resp api.HostSettingsResponse, err error) {
+	err = c.c.GET(ctx, fmt.Sprintf("/host/%s/settings", hostKey), &resp)
+	return
+}
+
 // Hosts returns all hosts that match certain search criteria.
 func (c *Client) Hosts(ctx context.Context, opts api.HostOptions) (hosts []api.Host, err error) {
 	err = c.c.POST(ctx, "/hosts", api.HostsRequest{
@@ -50,12 +63,26 @@ func (c *Client) RemoveOfflineHosts(ctx context.Context, maxConsecutiveScanFailu
 	return
 }
 
+// ConfirmOfflineHostsRemoval confirms the removal of offline hosts after it
+// was halted because too many hosts were eligible for removal.
+func (c *Client) ConfirmOfflineHostsRemoval(ctx context.Context) (err error) {
+	err = c.c.POST(ctx, "/hosts/remove/confirm", nil, nil)
+	return
+}
+
 // ResetLostSectors resets the lost sector count for a host.
 func (c *Client) ResetLostSectors(ctx context.Context, hostKey types.PublicKey) (err error) {
 	err = c.c.POST(ctx, fmt.Sprintf("/host/%s/resetlostsectors", hostKey), nil, nil)
 	return
 }
 
+// UpdateHostSectorSize overrides the sector size of a host, it only needs to be
+// set for hosts that use a non-standard sector size.
+func (c *Client) UpdateHostSectorSize(ctx context.Context, hostKey types.PublicKey, sectorSize uint64) (err error) {
+	err = c.c.PUT(ctx, fmt.Sprintf("/host/%s/sectorsize", hostKey), api.HostSectorSizeRequest{SectorSize: sectorSize})
+	return
+}
+
 // UpdateHostAllowlist updates the host allowlist, adding and removing the given entries.
 func (c *Client) UpdateHostAllowlist(ctx context.Context, add, remove []types.PublicKey, clear bool) (err error) {
 	err = c.c.PUT(ctx, "/hosts/allowlist", api.UpdateAllowlistRequest{Add: add, Remove: remove, Clear: clear})
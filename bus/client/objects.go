@@ -23,7 +23,8 @@ func (c *Client) AddObject(ctx context.Context, bucket, path string, o object.Ob
 }
 
 // CopyObject copies the object from the source bucket and path to the
-// destination bucket and path.
+// destination bucket and path. Only the object's metadata is copied, the
+// copy references the same slabs as the source object.
 func (c *Client) CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, dstKey string, opts api.CopyObjectOptions) (om api.ObjectMetadata, err error) {
 	err = c.c.POST(ctx, "/objects/copy", api.CopyObjectsRequest{
 		SourceBucket:      srcBucket,
@@ -32,6 +33,7 @@ func (c *Client) CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, d
 		DestinationKey:    dstKey,
 		MimeType:          opts.MimeType,
 		Metadata:          opts.Metadata,
+		Overwrite:         opts.Overwrite,
 	}, &om)
 	return
 }
@@ -46,6 +48,24 @@ func (c *Client) DeleteObject(ctx context.Context, bucket, key string) (err erro
 	return
 }
 
+// GarbageCollect deletes the object versions preserved by copy-on-write
+// buckets, allowing the slabs they reference to be pruned.
+func (c *Client) GarbageCollect(ctx context.Context) (res api.ObjectsGCResponse, err error) {
+	err = c.c.POST(ctx, "/objects/gc", nil, &res)
+	return
+}
+
+// ObjectVersions returns the versions that were preserved when the object at
+// given key was overwritten, the most recent version comes first.
+func (c *Client) ObjectVersions(ctx context.Context, bucket, key string) (versions []api.ObjectVersion, err error) {
+	var res api.ObjectVersionsResponse
+	err = c.c.POST(ctx, "/objects/versions", api.ObjectVersionsRequest{
+		Bucket: bucket,
+		Key:    key,
+	}, &res)
+	return res.Versions, err
+}
+
 // RemoveObjects removes objects with given prefix.
 func (c *Client) RemoveObjects(ctx context.Context, bucket, prefix string) (err error) {
 	err = c.c.POST(ctx, "/objects/remove", api.ObjectsRemoveRequest{
@@ -68,6 +88,52 @@ func (c *Client) Object(ctx context.Context, bucket, key string, opts api.GetObj
 	return
 }
 
+// ObjectCostEstimate estimates the cost of uploading an object of the given
+// size to the given key, storing it for the given number of blocks and
+// downloading it once. If size is zero, the size of the existing object at
+// the given key is used.
+func (c *Client) ObjectCostEstimate(ctx context.Context, bucket, key string, size, duration uint64) (estimate api.CostEstimate, err error) {
+	values := url.Values{}
+	values.Set("bucket", bucket)
+	values.Set("sizeBytes", fmt.Sprint(size))
+	values.Set("durationBlocks", fmt.Sprint(duration))
+
+	key = api.ObjectKeyEscape(key)
+	err = c.c.GET(ctx, fmt.Sprintf("/costestimate/%s?"+values.Encode(), key), &estimate)
+	return
+}
+
+// ObjectUserMetadata returns the user metadata of the object at given key.
+func (c *Client) ObjectUserMetadata(ctx context.Context, bucket, key string) (md api.ObjectUserMetadata, err error) {
+	values := url.Values{}
+	values.Set("bucket", bucket)
+
+	key = api.ObjectKeyEscape(key)
+	err = c.c.GET(ctx, fmt.Sprintf("/metadata/%s?"+values.Encode(), key), &md)
+	return
+}
+
+// UpdateObjectMimeType updates the mime type of the object at given key.
+func (c *Client) UpdateObjectMimeType(ctx context.Context, bucket, key, mimeType string) (err error) {
+	key = api.ObjectKeyEscape(key)
+	err = c.c.PUT(ctx, fmt.Sprintf("/mimetype/%s", key), api.UpdateObjectMimeTypeRequest{
+		Bucket:   bucket,
+		MimeType: mimeType,
+	})
+	return
+}
+
+// UpdateObjectUserMetadata replaces the user metadata of the object at given
+// key.
+func (c *Client) UpdateObjectUserMetadata(ctx context.Context, bucket, key string, md api.ObjectUserMetadata) (err error) {
+	key = api.ObjectKeyEscape(key)
+	err = c.c.PUT(ctx, fmt.Sprintf("/metadata/%s", key), api.UpdateObjectUserMetadataRequest{
+		Bucket:   bucket,
+		Metadata: md,
+	})
+	return
+}
+
 // Objects lists objects in the given bucket.
 func (c *Client) Objects(ctx context.Context, prefix string, opts api.ListObjectOptions) (resp api.ObjectsResponse, err error) {
 	values := url.Values{}
@@ -95,11 +161,35 @@ func (c *Client) RenameObject(ctx context.Context, bucket, from, to string, forc
 	return c.renameObjects(ctx, bucket, from, to, api.ObjectsRenameModeSingle, force)
 }
 
+// MoveObject moves a single object to another bucket and key.
+func (c *Client) MoveObject(ctx context.Context, srcBucket, dstBucket, from, to string, force bool) (err error) {
+	err = c.c.POST(ctx, "/objects/rename", api.ObjectsRenameRequest{
+		Bucket:   srcBucket,
+		ToBucket: dstBucket,
+		Force:    force,
+		From:     from,
+		To:       to,
+		Mode:     api.ObjectsRenameModeSingle,
+	}, nil)
+	return
+}
+
 // RenameObjects renames all objects with the prefix 'from' to the prefix 'to'.
 func (c *Client) RenameObjects(ctx context.Context, bucket, from, to string, force bool) (err error) {
 	return c.renameObjects(ctx, bucket, from, to, api.ObjectsRenameModeMulti, force)
 }
 
+// RestoreObjectVersion restores the object version with given id, the object
+// that is currently stored under the key is overwritten.
+func (c *Client) RestoreObjectVersion(ctx context.Context, bucket, key string, id int64) (err error) {
+	err = c.c.POST(ctx, "/objects/versions/restore", api.ObjectVersionRestoreRequest{
+		Bucket: bucket,
+		Key:    key,
+		ID:     id,
+	}, nil)
+	return
+}
+
 func (c *Client) renameObjects(ctx context.Context, bucket, from, to, mode string, force bool) (err error) {
 	err = c.c.POST(ctx, "/objects/rename", api.ObjectsRenameRequest{
 		Bucket: bucket,
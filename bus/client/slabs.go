@@ -100,6 +100,13 @@ func (c *Client) Slab(ctx context.Context, key object.EncryptionKey) (slab objec
 	return
 }
 
+// ListSlabBuffers returns metadata about all slab buffers, the most recently
+// written to buffers come first.
+func (c *Client) ListSlabBuffers(ctx context.Context) (buffers []api.SlabBufferInfo, err error) {
+	err = c.c.GET(ctx, "/slabs/buffers", &buffers)
+	return
+}
+
 // SlabBuffers returns information about the number of objects and their size.
 func (c *Client) SlabBuffers(ctx context.Context) (buffers []api.SlabBuffer, err error) {
 	err = c.c.GET(ctx, "/slabbuffers", &buffers)
@@ -28,8 +28,23 @@ func (c *Client) Wallet(ctx context.Context) (resp api.WalletResponse, err error
 
 // WalletPending returns the txpool transactions that are relevant to the
 // wallet.
-func (c *Client) WalletPending(ctx context.Context) (resp []wallet.Event, err error) {
-	err = c.c.GET(ctx, "/wallet/pending", &resp)
+func (c *Client) WalletPending(ctx context.Context, opts api.WalletPendingOpts) (resp []wallet.Event, err error) {
+	values := url.Values{}
+	for _, typ := range opts.Types {
+		values.Add("type", typ)
+	}
+	if !opts.MinAmount.IsZero() {
+		values.Set("minamount", opts.MinAmount.ExactString())
+	}
+	err = c.c.GET(ctx, "/wallet/pending?"+values.Encode(), &resp)
+	return
+}
+
+// WalletUnconfirmedEvents returns the txpool transactions that are relevant to
+// the wallet, annotated with their fee per byte and the estimated number of
+// blocks until they are confirmed.
+func (c *Client) WalletUnconfirmedEvents(ctx context.Context) (resp []api.WalletUnconfirmedEvent, err error) {
+	err = c.c.GET(ctx, "/wallet/unconfirmed", &resp)
 	return
 }
 
@@ -0,0 +1,188 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/alerts"
+	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/internal/gouging"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	alertSpendingCapExceededID = alerts.RandomAlertID() // constant until restarted
+)
+
+// checkContractsGouging sets the gouging check result on the given contracts
+// using the settings of their hosts as of the last successful scan. Hosts are
+// not scanned on demand, contracts with hosts that weren't scanned yet get a
+// result that only contains a warning.
+func (b *Bus) checkContractsGouging(ctx context.Context, contracts []api.ContractMetadata) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	// fetch the hosts
+	seen := make(map[types.PublicKey]struct{})
+	var hks []types.PublicKey
+	for _, c := range contracts {
+		if _, ok := seen[c.HostKey]; !ok {
+			seen[c.HostKey] = struct{}{}
+			hks = append(hks, c.HostKey)
+		}
+	}
+	hosts, err := b.store.Hosts(ctx, api.HostOptions{
+		FilterMode:    api.HostFilterModeAll,
+		UsabilityMode: api.UsabilityFilterModeAll,
+		KeyIn:         hks,
+		Limit:         -1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch hosts: %w", err)
+	}
+	scanned := make(map[types.PublicKey]api.Host)
+	for _, h := range hosts {
+		if h.Scanned {
+			scanned[h.PublicKey] = h
+		}
+	}
+
+	// build the gouging checker
+	gp, err := b.gougingParams(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch gouging params: %w", err)
+	}
+	gc := gouging.NewChecker(gp.GougingSettings, gp.ConsensusState)
+	bh := b.cm.TipState().Index.Height
+
+	for i := range contracts {
+		h, ok := scanned[contracts[i].HostKey]
+		if !ok {
+			contracts[i].GougingCheckResult = &api.GougingCheckResult{
+				Warning: "host settings unavailable, host has not been scanned yet",
+			}
+			continue
+		}
+
+		// ignore height
+		h.V2Settings.Prices.TipHeight = bh
+		gb := gc.Check(h.V2Settings)
+		contracts[i].GougingCheckResult = &api.GougingCheckResult{
+			Gouging:        gb.Gouging(),
+			FailureReasons: gb.Reasons(),
+		}
+	}
+	return nil
+}
+
+// refreshContracts fetches the latest revision of the given contracts from
+// their hosts and records the revision number and size of every contract that
+// was refreshed successfully. The results are returned in the order of the
+// given ids, failures don't abort the refresh of other contracts.
+func (b *Bus) refreshContracts(ctx context.Context, ids []types.FileContractID, parallelism int) []api.ContractRefreshResult {
+	results := make([]api.ContractRefreshResult, len(ids))
+
+	var g errgroup.Group
+	g.SetLimit(parallelism)
+	for i, fcid := range ids {
+		g.Go(func() error {
+			results[i].ContractID = fcid
+			c, err := b.refreshContractRevision(ctx, fcid)
+			if err != nil {
+				results[i].Error = err.Error()
+			} else {
+				results[i].Contract = &c
+			}
+			return nil
+		})
+	}
+	_ = g.Wait() // workers never return an error
+	return results
+}
+
+func (b *Bus) refreshContractRevision(ctx context.Context, fcid types.FileContractID) (api.ContractMetadata, error) {
+	c, err := b.store.Contract(ctx, fcid)
+	if err != nil {
+		return api.ContractMetadata{}, fmt.Errorf("failed to fetch contract: %w", err)
+	}
+	host, err := b.store.Host(ctx, c.HostKey)
+	if err != nil {
+		return api.ContractMetadata{}, fmt.Errorf("failed to fetch host: %w", err)
+	}
+
+	rev, err := b.rhp4Client.LatestRevision(ctx, c.HostKey, host.SiamuxAddr(), fcid)
+	if err != nil {
+		return api.ContractMetadata{}, fmt.Errorf("failed to fetch revision: %w", err)
+	}
+
+	// record the revision without any spending
+	if err := b.retryDBWrite(ctx, "record contract revision", func(ctx context.Context) error {
+		return b.store.RecordContractSpending(ctx, []api.ContractSpendingRecord{{
+			ContractID:        fcid,
+			RevisionNumber:    rev.RevisionNumber,
+			Size:              rev.Filesize,
+			MissedHostPayout:  rev.MissedHostValue,
+			ValidRenterPayout: rev.RenterOutput.Value,
+		}})
+	}); err != nil {
+		return api.ContractMetadata{}, fmt.Errorf("failed to record revision: %w", err)
+	}
+
+	c.RevisionNumber = rev.RevisionNumber
+	c.Size = rev.Filesize
+	return c, nil
+}
+
+// recordContractSpending records the given spending records. The store always
+// records the spending but marks contracts that exceed their spending cap as
+// bad, an alert is registered for each of those contracts.
+func (b *Bus) recordContractSpending(ctx context.Context, records []api.ContractSpendingRecord) error {
+	err := b.retryDBWrite(ctx, "record contract spending", func(ctx context.Context) error {
+		return b.store.RecordContractSpending(ctx, records)
+	})
+	if !errors.Is(err, api.ErrContractSpendingCapExceeded) {
+		return err
+	}
+
+	seen := make(map[types.FileContractID]struct{})
+	for _, r := range records {
+		if _, ok := seen[r.ContractID]; ok {
+			continue
+		}
+		seen[r.ContractID] = struct{}{}
+
+		c, cErr := b.store.Contract(ctx, r.ContractID)
+		if cErr != nil {
+			b.logger.Errorw("failed to fetch contract", "fcid", r.ContractID, zap.Error(cErr))
+			continue
+		} else if !c.SpendingCapExceeded(api.ContractSpending{}) {
+			continue
+		}
+		if err := b.alerts.RegisterAlert(ctx, newSpendingCapExceededAlert(c)); err != nil {
+			b.logger.Errorw("failed to register alert", "fcid", c.ID, zap.Error(err))
+		}
+	}
+	return err
+}
+
+func newSpendingCapExceededAlert(c api.ContractMetadata) alerts.Alert {
+	return alerts.Alert{
+		ID:          alerts.IDForContract(alertSpendingCapExceededID, c.ID),
+		Severity:    alerts.SeverityWarning,
+		Message:     "Contract spending cap exceeded",
+		Description: fmt.Sprintf("The total spending of contract %v is %v, which exceeds its cap of %v, the contract was marked as bad.", c.ID, c.Spending.Total(), c.SpendingCap),
+		Suggestion:  "Raise the contract's spending cap if the spending is expected, otherwise check the workers for runaway uploads.",
+		Data: map[string]any{
+			"contractID":  c.ID.String(),
+			"hostKey":     c.HostKey.String(),
+			"spendingCap": c.SpendingCap.String(),
+			"spending":    c.Spending.Total().String(),
+		},
+		Timestamp: time.Now(),
+	}
+}
@@ -0,0 +1,159 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	rhpv4 "go.sia.tech/core/rhp/v4"
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/api"
+)
+
+var (
+	// errNoPricedContracts is returned when a cost estimate is requested but
+	// there are no good contracts with hosts whose prices are known.
+	errNoPricedContracts = errors.New("no good contracts with scanned hosts to base the estimate on")
+
+	// errCostEstimateOverflow is returned when the estimated cost doesn't
+	// fit in a currency.
+	errCostEstimateOverflow = errors.New("cost estimate overflows")
+)
+
+// objectCostEstimate estimates the cost of uploading an object of the given
+// size to the given bucket, storing it for the given number of blocks and
+// downloading it once. A size of zero defaults to the size of the existing
+// object with the given key.
+func (b *Bus) objectCostEstimate(ctx context.Context, bucket, key string, size, duration uint64) (api.CostEstimate, error) {
+	// use the bucket's redundancy if it overrides the global one
+	bkt, err := b.store.Bucket(ctx, bucket)
+	if err != nil {
+		return api.CostEstimate{}, err
+	}
+
+	// default to the size of the object if it exists
+	if size == 0 {
+		o, err := b.store.ObjectMetadata(ctx, bucket, key)
+		if err != nil {
+			return api.CostEstimate{}, err
+		}
+		size = uint64(o.Size)
+	}
+	rs := bkt.Redundancy
+	if rs == nil {
+		us, err := b.uploadSettings(ctx)
+		if err != nil {
+			return api.CostEstimate{}, fmt.Errorf("failed to fetch upload settings: %w", err)
+		}
+		rs = &us.Redundancy
+	}
+
+	// weigh the prices of every host by the amount spent on uploads to it
+	// since its contracts were formed or last renewed
+	contracts, err := b.store.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeActive})
+	if err != nil {
+		return api.CostEstimate{}, fmt.Errorf("failed to fetch contracts: %w", err)
+	}
+	weights := make(map[types.PublicKey]types.Currency)
+	for _, c := range contracts {
+		if c.IsGood() {
+			weights[c.HostKey] = weights[c.HostKey].Add(c.Spending.Uploads)
+		}
+	}
+
+	if len(weights) == 0 {
+		return api.CostEstimate{}, errNoPricedContracts
+	}
+
+	hks := make([]types.PublicKey, 0, len(weights))
+	for hk := range weights {
+		hks = append(hks, hk)
+	}
+	hosts, err := b.store.Hosts(ctx, api.HostOptions{
+		FilterMode:    api.HostFilterModeAll,
+		UsabilityMode: api.UsabilityFilterModeAll,
+		KeyIn:         hks,
+		Limit:         -1,
+	})
+	if err != nil {
+		return api.CostEstimate{}, fmt.Errorf("failed to fetch hosts: %w", err)
+	}
+
+	var prices []rhpv4.HostPrices
+	var priceWeights []types.Currency
+	for _, h := range hosts {
+		if !h.Scanned {
+			continue
+		}
+		prices = append(prices, h.V2Settings.Prices)
+		priceWeights = append(priceWeights, weights[h.PublicKey])
+	}
+	return estimateCost(prices, priceWeights, *rs, size, duration)
+}
+
+// estimateCost estimates the cost of an object of the given size using the
+// weighted average of the given prices. Objects are erasure coded into slabs
+// of MinShards sectors, each of which is uploaded to TotalShards sectors. If
+// none of the hosts has a weight, all prices are weighted equally.
+func estimateCost(prices []rhpv4.HostPrices, weights []types.Currency, rs api.RedundancySettings, size, duration uint64) (api.CostEstimate, error) {
+	if len(prices) == 0 {
+		return api.CostEstimate{}, errNoPricedContracts
+	} else if len(prices) != len(weights) {
+		panic("number of prices and weights must match") // developer error
+	}
+
+	// fall back to equal weights if nothing was uploaded yet
+	w := make([]*big.Int, len(weights))
+	total := new(big.Int)
+	for i, weight := range weights {
+		w[i] = weight.Big()
+		total.Add(total, w[i])
+	}
+	if total.Sign() == 0 {
+		for i := range w {
+			w[i] = big.NewInt(1)
+		}
+		total.SetInt64(int64(len(w)))
+	}
+	average := func(price func(rhpv4.HostPrices) types.Currency) *big.Int {
+		sum := new(big.Int)
+		for i, p := range prices {
+			sum.Add(sum, new(big.Int).Mul(price(p).Big(), w[i]))
+		}
+		return sum.Quo(sum, total)
+	}
+
+	// compute the number of bytes uploaded and downloaded, slabs are padded
+	// to full sectors
+	slabSize := new(big.Int).SetUint64(uint64(rs.MinShards) * rhpv4.SectorSize)
+	slabs := new(big.Int).SetUint64(size)
+	slabs.Add(slabs, new(big.Int).Sub(slabSize, big.NewInt(1)))
+	slabs.Quo(slabs, slabSize)
+	uploaded := new(big.Int).Mul(slabs, new(big.Int).SetUint64(uint64(rs.TotalShards)*rhpv4.SectorSize))
+	downloaded := new(big.Int).Mul(slabs, slabSize)
+
+	upload := new(big.Int).Mul(average(func(p rhpv4.HostPrices) types.Currency { return p.IngressPrice }), uploaded)
+	storage := new(big.Int).Mul(average(func(p rhpv4.HostPrices) types.Currency { return p.StoragePrice }), uploaded)
+	storage.Mul(storage, new(big.Int).SetUint64(duration))
+	download := new(big.Int).Mul(average(func(p rhpv4.HostPrices) types.Currency { return p.EgressPrice }), downloaded)
+	totalCost := new(big.Int).Add(upload, storage)
+	totalCost.Add(totalCost, download)
+
+	var estimate api.CostEstimate
+	for _, c := range []struct {
+		dst *types.Currency
+		src *big.Int
+	}{
+		{&estimate.UploadCost, upload},
+		{&estimate.StorageCost, storage},
+		{&estimate.DownloadCost, download},
+		{&estimate.TotalCost, totalCost},
+	} {
+		if c.src.BitLen() > 128 {
+			return api.CostEstimate{}, errCostEstimateOverflow
+		}
+		*c.dst = types.NewCurrency(c.src.Uint64(), new(big.Int).Rsh(c.src, 64).Uint64())
+	}
+	return estimate, nil
+}
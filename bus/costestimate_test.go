@@ -0,0 +1,56 @@
+package bus
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	rhpv4 "go.sia.tech/core/rhp/v4"
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/api"
+)
+
+func TestEstimateCost(t *testing.T) {
+	rs := api.RedundancySettings{MinShards: 2, TotalShards: 6}
+	prices := []rhpv4.HostPrices{
+		{IngressPrice: types.NewCurrency64(1), StoragePrice: types.NewCurrency64(1), EgressPrice: types.NewCurrency64(1)},
+		{IngressPrice: types.NewCurrency64(5), StoragePrice: types.NewCurrency64(9), EgressPrice: types.NewCurrency64(13)},
+	}
+
+	// a single byte is padded to a full slab
+	const uploaded = 6 * rhpv4.SectorSize
+	const downloaded = 2 * rhpv4.SectorSize
+
+	// assert prices are weighted by the upload spending
+	estimate, err := estimateCost(prices, []types.Currency{types.NewCurrency64(1), types.NewCurrency64(3)}, rs, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	} else if !estimate.UploadCost.Equals(types.NewCurrency64(4 * uploaded)) {
+		t.Fatalf("unexpected upload cost %v", estimate.UploadCost)
+	} else if !estimate.StorageCost.Equals(types.NewCurrency64(7 * uploaded * 10)) {
+		t.Fatalf("unexpected storage cost %v", estimate.StorageCost)
+	} else if !estimate.DownloadCost.Equals(types.NewCurrency64(10 * downloaded)) {
+		t.Fatalf("unexpected download cost %v", estimate.DownloadCost)
+	} else if !estimate.TotalCost.Equals(estimate.UploadCost.Add(estimate.StorageCost).Add(estimate.DownloadCost)) {
+		t.Fatalf("unexpected total cost %v", estimate.TotalCost)
+	}
+
+	// assert prices are weighted equally without upload spending
+	estimate, err = estimateCost(prices, []types.Currency{types.ZeroCurrency, types.ZeroCurrency}, rs, 2*rhpv4.SectorSize+1, 10)
+	if err != nil {
+		t.Fatal(err)
+	} else if !estimate.UploadCost.Equals(types.NewCurrency64(3 * 2 * uploaded)) {
+		t.Fatalf("unexpected upload cost %v", estimate.UploadCost)
+	}
+
+	// assert the estimate fails without prices
+	if _, err := estimateCost(nil, nil, rs, 1, 10); !errors.Is(err, errNoPricedContracts) {
+		t.Fatal("expected errNoPricedContracts, got", err)
+	}
+
+	// assert overflows are caught
+	prices[0].StoragePrice = types.MaxCurrency
+	if _, err := estimateCost(prices, []types.Currency{types.NewCurrency64(1), types.ZeroCurrency}, rs, math.MaxUint64, math.MaxUint64); !errors.Is(err, errCostEstimateOverflow) {
+		t.Fatal("expected errCostEstimateOverflow, got", err)
+	}
+}
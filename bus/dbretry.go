@@ -0,0 +1,57 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.sia.tech/renterd/v2/alerts"
+	isql "go.sia.tech/renterd/v2/internal/sql"
+	"go.uber.org/zap"
+)
+
+var (
+	alertDBWriteFailedID = alerts.RandomAlertID() // constant until restarted
+)
+
+// retryDBWrite performs the given critical database write with a context that
+// makes the SQL layer retry its transactions according to the bus' retry
+// policy, instead of the default retry behaviour, if they fail because the
+// database is locked. A policy without a max number of attempts keeps the
+// default behaviour. If the write still fails after the last attempt, a
+// critical error is logged and an alert is registered.
+//
+// NOTE: the contract state changes applied by the chain subscriber and the
+// pending contracts monitor are not wrapped, they are part of larger
+// transactions that are already reapplied on the next chain update or check.
+func (b *Bus) retryDBWrite(ctx context.Context, op string, fn func(context.Context) error) error {
+	if b.dbWriteRetry.MaxAttempts > 0 {
+		ctx = isql.WithRetryPolicy(ctx, b.dbWriteRetry.MaxAttempts, b.dbWriteRetry.BackoffBase)
+	}
+	err := fn(ctx)
+	if err == nil || !errors.Is(err, isql.ErrDatabaseLocked) {
+		return err
+	}
+
+	b.logger.Errorw("critical database write failed", "op", op, zap.Error(err))
+	if err := b.alerts.RegisterAlert(ctx, newDBWriteFailedAlert(op, err)); err != nil {
+		b.logger.Errorw("failed to register alert", zap.Error(err))
+	}
+	return err
+}
+
+func newDBWriteFailedAlert(op string, err error) alerts.Alert {
+	return alerts.Alert{
+		ID:          alertDBWriteFailedID,
+		Severity:    alerts.SeverityCritical,
+		Message:     "Critical database write failed",
+		Description: fmt.Sprintf("Failed to %s because the database remained locked after the last retry, the contract state stored by the bus might be out of date.", op),
+		Suggestion:  "The database is under heavy write load, consider reducing the load or switching to MySQL. Increasing the bus' dbWriteRetry settings allows for more retries.",
+		Data: map[string]any{
+			"op":    op,
+			"error": err.Error(),
+		},
+		Timestamp: time.Now(),
+	}
+}
@@ -0,0 +1,48 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/v2/alerts"
+	"go.sia.tech/renterd/v2/config"
+	isql "go.sia.tech/renterd/v2/internal/sql"
+	"go.uber.org/zap"
+)
+
+func TestRetryDBWrite(t *testing.T) {
+	am := alerts.NewManager(alerts.Config{})
+	b := &Bus{
+		alerts:       am,
+		dbWriteRetry: config.DBWriteRetryPolicy{MaxAttempts: 3, BackoffBase: time.Millisecond},
+		logger:       zap.NewNop().Sugar(),
+	}
+
+	assertAlerts := func(n int) {
+		t.Helper()
+		if resp, err := am.Alerts(context.Background(), alerts.AlertsOpts{}); err != nil {
+			t.Fatal(err)
+		} else if len(resp.Alerts) != n {
+			t.Fatalf("expected %d alerts, got %d", n, len(resp.Alerts))
+		}
+	}
+
+	// assert successful writes and other errors don't register an alert
+	errOther := errors.New("other")
+	if err := b.retryDBWrite(context.Background(), "write", func(context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	} else if err := b.retryDBWrite(context.Background(), "write", func(context.Context) error { return errOther }); !errors.Is(err, errOther) {
+		t.Fatal("expected errOther, got", err)
+	}
+	assertAlerts(0)
+
+	// assert a write that remains locked registers an alert
+	errLocked := fmt.Errorf("transaction failed: %w", isql.ErrDatabaseLocked)
+	if err := b.retryDBWrite(context.Background(), "write", func(context.Context) error { return errLocked }); !errors.Is(err, isql.ErrDatabaseLocked) {
+		t.Fatal("expected ErrDatabaseLocked, got", err)
+	}
+	assertAlerts(1)
+}
@@ -0,0 +1,89 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.sia.tech/renterd/v2/alerts"
+	"go.uber.org/zap"
+)
+
+var (
+	alertHostPruneHaltedID = alerts.RandomAlertID() // constant until restarted
+
+	// errNoHostPruneHalted is returned when confirming the removal of offline
+	// hosts while no removal was halted.
+	errNoHostPruneHalted = errors.New("no offline host removal is awaiting confirmation")
+)
+
+// removeOfflineHosts removes at most one batch of offline hosts. If the number
+// of hosts eligible for removal exceeds the safety threshold, no hosts are
+// removed and an alert is registered until the removal is confirmed. A
+// confirmation holds until the number of eligible hosts drops below the
+// threshold again.
+func (b *Bus) removeOfflineHosts(ctx context.Context, maxConsecutiveScanFailures uint64, maxDowntime time.Duration) (uint64, error) {
+	b.hostPruneMu.Lock()
+	defer b.hostPruneMu.Unlock()
+
+	threshold := b.maxHostsPerPruneBatch * b.hostPruneSafetyMultiplier
+	maxEligible := threshold
+	if b.hostPruneConfirmed {
+		maxEligible = 0
+	}
+
+	removed, eligible, err := b.store.RemoveOfflineHosts(ctx, maxConsecutiveScanFailures, maxDowntime, b.maxHostsPerPruneBatch, maxEligible)
+	if err != nil {
+		return 0, err
+	} else if maxEligible > 0 && eligible > uint64(maxEligible) {
+		b.hostPruneHalted = true
+		b.logger.Warnw("offline host removal halted, too many hosts are eligible for removal", "eligible", eligible, "threshold", threshold)
+		if err := b.alerts.RegisterAlert(ctx, newHostPruneHaltedAlert(eligible, threshold, maxConsecutiveScanFailures, maxDowntime)); err != nil {
+			b.logger.Errorw("failed to register alert", zap.Error(err))
+		}
+		return 0, nil
+	}
+
+	// once the remaining hosts are within the threshold, pruning continues
+	// without the need for a confirmation and future mass removals halt again
+	b.hostPruneHalted = false
+	if eligible-removed <= uint64(threshold) {
+		b.hostPruneConfirmed = false
+	}
+	if err := b.alerts.DismissAlerts(ctx, alertHostPruneHaltedID); err != nil {
+		b.logger.Errorw("failed to dismiss alert", zap.Error(err))
+	}
+	return removed, nil
+}
+
+// confirmOfflineHostsRemoval confirms a halted removal of offline hosts, the
+// hosts are removed in batches by subsequent pruning runs.
+func (b *Bus) confirmOfflineHostsRemoval(ctx context.Context) error {
+	b.hostPruneMu.Lock()
+	defer b.hostPruneMu.Unlock()
+
+	if !b.hostPruneHalted {
+		return errNoHostPruneHalted
+	}
+	b.hostPruneConfirmed = true
+	b.hostPruneHalted = false
+	return b.alerts.DismissAlerts(ctx, alertHostPruneHaltedID)
+}
+
+func newHostPruneHaltedAlert(eligible uint64, threshold int, maxConsecutiveScanFailures uint64, maxDowntime time.Duration) alerts.Alert {
+	return alerts.Alert{
+		ID:          alertHostPruneHaltedID,
+		Severity:    alerts.SeverityCritical,
+		Message:     "Offline host removal halted",
+		Description: fmt.Sprintf("%d hosts are eligible for removal, which is more than the safety threshold of %d hosts. No hosts were removed.", eligible, threshold),
+		Suggestion:  "Check the node's network connectivity, a large number of hosts going offline at once usually indicates a problem on the renter's side. If the removal is expected, confirm it using [POST] /bus/hosts/remove/confirm.",
+		Data: map[string]any{
+			"eligible":                   eligible,
+			"threshold":                  threshold,
+			"maxConsecutiveScanFailures": maxConsecutiveScanFailures,
+			"maxDowntime":                maxDowntime.String(),
+		},
+		Timestamp: time.Now(),
+	}
+}
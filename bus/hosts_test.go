@@ -0,0 +1,100 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/v2/alerts"
+	"go.uber.org/zap"
+)
+
+type mockHostStore struct {
+	Store
+	offline int
+}
+
+func (s *mockHostStore) RemoveOfflineHosts(_ context.Context, _ uint64, _ time.Duration, limit, maxEligible int) (uint64, uint64, error) {
+	eligible := s.offline
+	if maxEligible > 0 && eligible > maxEligible {
+		return 0, uint64(eligible), nil
+	}
+	removed := eligible
+	if limit > 0 && removed > limit {
+		removed = limit
+	}
+	s.offline -= removed
+	return uint64(removed), uint64(eligible), nil
+}
+
+func TestRemoveOfflineHostsSafetyThreshold(t *testing.T) {
+	store := &mockHostStore{offline: 7}
+	alerter := alerts.NewManager(alerts.Config{})
+	b := &Bus{
+		alerts:                    alerter,
+		store:                     store,
+		logger:                    zap.NewNop().Sugar(),
+		maxHostsPerPruneBatch:     2,
+		hostPruneSafetyMultiplier: 3,
+	}
+
+	// helper to assert whether the halted alert is registered
+	assertAlert := func(registered bool) {
+		t.Helper()
+		res, err := alerter.Alerts(context.Background(), alerts.AlertsOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found bool
+		for _, a := range res.Alerts {
+			if a.ID == alertHostPruneHaltedID {
+				found = a.Severity == alerts.SeverityCritical
+			}
+		}
+		if found != registered {
+			t.Fatalf("expected alert to be registered: %v", registered)
+		}
+	}
+
+	// helper to remove offline hosts
+	remove := func(expected uint64) {
+		t.Helper()
+		removed, err := b.removeOfflineHosts(context.Background(), 1, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		} else if removed != expected {
+			t.Fatalf("expected %v hosts to be removed, got %v", expected, removed)
+		}
+	}
+
+	// confirming without a halted removal fails
+	if err := b.confirmOfflineHostsRemoval(context.Background()); !errors.Is(err, errNoHostPruneHalted) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// 7 hosts exceed the threshold of 6, nothing is removed
+	remove(0)
+	assertAlert(true)
+
+	// confirm the removal and assert hosts are removed in batches
+	if err := b.confirmOfflineHostsRemoval(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assertAlert(false)
+	remove(2)
+	remove(2)
+
+	// once below the threshold the confirmation is reset
+	if b.hostPruneConfirmed {
+		t.Fatal("expected confirmation to be reset")
+	}
+	store.offline += 4
+	remove(0)
+	assertAlert(true)
+
+	// if hosts come back online, the alert is dismissed
+	store.offline = 6
+	remove(2)
+	assertAlert(false)
+}
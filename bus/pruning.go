@@ -2,93 +2,275 @@ package bus
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sync"
 	"time"
 
+	"go.sia.tech/core/consensus"
 	rhpv4 "go.sia.tech/core/rhp/v4"
 	"go.sia.tech/core/types"
 	cRHP4 "go.sia.tech/coreutils/rhp/v4"
+	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	ibus "go.sia.tech/renterd/v2/internal/bus"
 	"go.sia.tech/renterd/v2/internal/gouging"
+	"go.uber.org/zap"
 )
 
-func (b *Bus) pruneContract(ctx context.Context, rk types.PrivateKey, cm api.ContractMetadata, hostIP string, gc gouging.Checker, pendingUploads map[types.Hash256]struct{}) (api.ContractPruneResponse, error) {
+var (
+	alertRevisionGapID = alerts.RandomAlertID() // constant until restarted
+)
+
+var (
+	// errInvalidRevision is returned when the revision a host reports for a
+	// contract is not the revision we expect.
+	errInvalidRevision = errors.New("invalid contract revision")
+
+	// errRevisionGapExceeded is returned when the host's revision of a
+	// contract is too far ahead of the revision we have stored.
+	errRevisionGapExceeded = errors.New("revision gap exceeded")
+)
+
+// estimatePrunableSize returns the size of the given contract and an estimate
+// of how much of it can be pruned. The estimate is computed from the database
+// alone by comparing the contract size to the size of the sectors we know are
+// stored in the contract. It doesn't account for pending uploads and relies
+// on the contract size we last recorded, but unlike prunableSize it doesn't
+// require interacting with the host.
+func (b *Bus) estimatePrunableSize(ctx context.Context, fcid types.FileContractID) (api.ContractSize, error) {
+	size, err := b.store.ContractSize(ctx, fcid)
+	if err != nil {
+		return api.ContractSize{}, err
+	}
+	size.Estimated = true
+	return size, nil
+}
+
+// prunableSize returns the size of the given contract and the exact amount of
+// data that can be pruned from it. It performs a dry run of pruning the
+// contract, which requires fetching all of the contract's sector roots from
+// the host and therefore isn't free.
+func (b *Bus) prunableSize(ctx context.Context, fcid types.FileContractID) (api.ContractSize, error) {
+	res, err := b.pruneContractWithID(ctx, fcid, 0, true)
+	if err != nil {
+		return api.ContractSize{}, err
+	} else if res.Error != "" {
+		return api.ContractSize{}, errors.New(res.Error)
+	}
+	return api.ContractSize{
+		Prunable: res.TotalPrunable,
+		Size:     res.ContractSize,
+	}, nil
+}
+
+// pruneContractWithID acquires a lock on the contract with the given id and
+// prunes it, see pruneContract.
+func (b *Bus) pruneContractWithID(ctx context.Context, fcid types.FileContractID, maxSectors uint64, dryRun bool) (api.ContractPruneResponse, error) {
+	// fetch gouging parameters
+	gp, err := b.gougingParams(ctx)
+	if err != nil {
+		return api.ContractPruneResponse{}, fmt.Errorf("couldn't fetch gouging parameters: %w", err)
+	}
+
+	// acquire contract lock indefinitely and defer the release
+	lockID, err := b.contractLocker.Acquire(ctx, lockingPriorityPruning, fcid, time.Duration(math.MaxInt64))
+	if err != nil {
+		return api.ContractPruneResponse{}, fmt.Errorf("couldn't acquire contract lock: %w", err)
+	}
+	defer func() {
+		if err := b.contractLocker.Release(fcid, lockID); err != nil {
+			b.logger.Errorw("failed to release contract lock", zap.Error(err))
+		}
+	}()
+
+	// fetch the contract from the bus
+	c, err := b.store.Contract(ctx, fcid)
+	if err != nil {
+		return api.ContractPruneResponse{}, fmt.Errorf("couldn't fetch contract: %w", err)
+	}
+
+	// fetch the corresponding host
+	host, err := b.store.Host(ctx, c.HostKey)
+	if err != nil {
+		return api.ContractPruneResponse{}, fmt.Errorf("failed to fetch host for pruning: %w", err)
+	}
+
+	// build map of uploading sectors
+	pending := make(map[types.Hash256]struct{})
+	for _, root := range b.sectors.Sectors() {
+		pending[root] = struct{}{}
+	}
+
+	// prune the contract
+	rk := b.masterKey.DeriveContractKey(c.HostKey)
+	return b.pruneContract(ctx, rk, c, host.SiamuxAddr(), host.SectorSize, gp, pending, maxSectors, dryRun)
+}
+
+// pruneContract frees the sectors of the given contract that are no longer
+// referenced by any object, freeing at most maxSectors sectors. If dryRun is
+// set, the prunable sectors are computed but not freed on the host. The
+// host's sector size is used to convert between sectors and bytes, if it's
+// zero rhpv4.SectorSize is used.
+func (b *Bus) pruneContract(ctx context.Context, rk types.PrivateKey, cm api.ContractMetadata, hostIP string, sectorSize uint64, gp api.GougingParams, pendingUploads map[types.Hash256]struct{}, maxSectors uint64, dryRun bool) (api.ContractPruneResponse, error) {
 	signer := ibus.NewFormContractSigner(b.w, rk)
+	if sectorSize == 0 {
+		sectorSize = rhpv4.SectorSize
+	}
+
+	// pruning isn't free, don't prune contracts that reached their cap
+	if cm.SpendingCapReached() {
+		return api.ContractPruneResponse{}, fmt.Errorf("%w: contract %v reached its cap of %v", api.ErrContractSpendingCapExceeded, cm.ID, cm.SpendingCap)
+	}
 
 	// get latest revision
 	rev, err := b.rhp4Client.LatestRevision(ctx, cm.HostKey, hostIP, cm.ID)
 	if err != nil {
 		return api.ContractPruneResponse{}, fmt.Errorf("failed to fetch revision for pruning: %w", err)
-	} else if rev.RevisionNumber < cm.RevisionNumber {
-		return api.ContractPruneResponse{}, fmt.Errorf("latest known revision %d is less than contract revision %d", rev.RevisionNumber, cm.RevisionNumber)
+	} else if err := verifyRevision(b.cm.TipState(), cm, rk.PublicKey(), rev); err != nil {
+		return api.ContractPruneResponse{}, err
 	}
 
-	// get prices
-	settings, err := b.rhp4Client.Settings(ctx, cm.HostKey, hostIP)
-	if err != nil {
-		return api.ContractPruneResponse{}, fmt.Errorf("failed to fetch prices for pruning: %w", err)
+	// don't act on stale state, if we missed a lot of revisions the contract
+	// metadata we base pruning on can't be trusted
+	if err := checkRevisionGap(cm, rev, b.maxRevisionGap); err != nil {
+		if err := b.alerts.RegisterAlert(ctx, newRevisionGapAlert(cm, rev, b.maxRevisionGap)); err != nil {
+			b.logger.Errorw("failed to register alert", "fcid", cm.ID, zap.Error(err))
+		}
+		return api.ContractPruneResponse{}, err
+	}
+
+	// get prices, reusing the settings recently fetched from the same host
+	settings, gb, ok := b.hostSettingsCache.GougingBreakdown(cm.HostKey, gp.GougingSettings)
+	if !ok {
+		var cachedAt time.Time
+		settings, cachedAt, ok = b.hostSettingsCache.Get(cm.HostKey)
+		if !ok {
+			settings, err = b.rhp4Client.Settings(ctx, cm.HostKey, hostIP)
+			if err != nil {
+				return api.ContractPruneResponse{}, fmt.Errorf("failed to fetch prices for pruning: %w", err)
+			}
+			cachedAt = b.hostSettingsCache.Set(cm.HostKey, settings)
+		}
+		gb = gouging.NewChecker(gp.GougingSettings, gp.ConsensusState).Check(settings)
+		b.hostSettingsCache.SetGougingBreakdown(cm.HostKey, cachedAt, gp.GougingSettings, gb)
 	}
 	prices := settings.Prices
 
 	// make sure they are sane
-	if gb := gc.Check(settings); gb.Gouging() {
+	if gb.Gouging() {
 		return api.ContractPruneResponse{}, fmt.Errorf("host for pruning is gouging: %v", gb.String())
 	}
 
-	// fetch all contract roots
-	numsectors := rev.Filesize / rhpv4.SectorSize
-	sectorRoots := make([]types.Hash256, 0, numsectors)
+	// stream the contract roots to the store, which streams back the indices
+	// of the roots that are prunable
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	roots := make(chan types.Hash256, rhpv4.MaxSectorBatchSize)
+	indices, storeErr, err := b.store.PrunableContractRootsStream(streamCtx, cm.ID, roots)
+	if err != nil {
+		return api.ContractPruneResponse{}, fmt.Errorf("failed to stream prunable roots: %w", err)
+	}
+
+	// fetch all contract roots in batches, keeping track of the indices of
+	// the roots that are pending uploads
 	var rootsUsage rhpv4.Usage
-	for offset := uint64(0); offset < numsectors; {
-		// calculate the batch size
-		length := uint64(rhpv4.MaxSectorBatchSize)
-		if offset+length > numsectors {
-			length = numsectors - offset
-		}
+	var pendingMu sync.Mutex
+	pending := make(map[uint64]struct{})
+	fetchErr := make(chan error, 1)
+	go func() {
+		defer close(roots)
 
-		// fetch the batch
-		res, err := b.rhp4Client.SectorRoots(ctx, cm.HostKey, hostIP, b.cm.TipState(), prices, signer, cRHP4.ContractRevision{
-			ID:       cm.ID,
-			Revision: rev,
-		}, offset, length)
-		if err != nil {
-			return api.ContractPruneResponse{}, fmt.Errorf("failed to fetch contract sectors: %w", err)
-		}
+		numsectors := rev.Filesize / sectorSize
+		for offset := uint64(0); offset < numsectors; {
+			// calculate the batch size
+			length := uint64(rhpv4.MaxSectorBatchSize)
+			if offset+length > numsectors {
+				length = numsectors - offset
+			}
 
-		// update revision since it was revised
-		rev = res.Revision
+			// fetch the batch
+			res, err := b.rhp4Client.SectorRoots(streamCtx, cm.HostKey, hostIP, b.cm.TipState(), prices, signer, cRHP4.ContractRevision{
+				ID:       cm.ID,
+				Revision: rev,
+			}, offset, length)
+			if err != nil {
+				fetchErr <- fmt.Errorf("failed to fetch contract sectors: %w", err)
+				return
+			}
 
-		// collect roots
-		sectorRoots = append(sectorRoots, res.Roots...)
-		offset += uint64(len(res.Roots))
+			// update revision since it was revised
+			rev = res.Revision
 
-		// update the cost
-		rootsUsage = rootsUsage.Add(res.Usage)
-	}
+			// update the cost
+			rootsUsage = rootsUsage.Add(res.Usage)
 
-	// fetch indices to prune
-	indices, err := b.store.PrunableContractRoots(ctx, cm.ID, sectorRoots)
-	if err != nil {
-		return api.ContractPruneResponse{}, fmt.Errorf("failed to fetch prunable roots: %w", err)
-	}
+			// stream roots
+			for i, root := range res.Roots {
+				if _, ok := pendingUploads[root]; ok {
+					pendingMu.Lock()
+					pending[offset+uint64(i)] = struct{}{}
+					pendingMu.Unlock()
+				}
+				select {
+				case roots <- root:
+				case <-streamCtx.Done():
+					fetchErr <- fmt.Errorf("failed to stream contract sectors: %w", context.Cause(streamCtx))
+					return
+				}
+			}
+			offset += uint64(len(res.Roots))
+		}
+		fetchErr <- nil
+	}()
 
-	// avoid pruning pending uploads
-	toPrune := indices[:0]
-	for _, index := range indices {
-		_, ok := pendingUploads[sectorRoots[index]]
-		if !ok {
+	// collect the indices to prune, avoid pruning pending uploads and cap at
+	// max sectors
+	if maxSectors == 0 || maxSectors > rhpv4.MaxSectorBatchSize {
+		maxSectors = rhpv4.MaxSectorBatchSize
+	}
+	var totalToPrune uint64
+	toPrune := make([]uint64, 0, maxSectors)
+	for index := range indices {
+		pendingMu.Lock()
+		_, isPending := pending[index]
+		pendingMu.Unlock()
+		if isPending {
+			continue
+		}
+		totalToPrune++
+		if uint64(len(toPrune)) < maxSectors {
 			toPrune = append(toPrune, index)
 		}
 	}
-	totalToPrune := uint64(len(toPrune))
 
-	// cap at max batch size
-	batchSize := rhpv4.MaxSectorBatchSize
-	if batchSize > len(toPrune) {
-		batchSize = len(toPrune)
+	// the indices channel is closed early if the store fails to process the
+	// roots, so we cancel the stream before waiting for the roots to be
+	// fetched, a store error takes precedence since it caused the cancellation
+	cancel()
+	if err := <-fetchErr; err != nil {
+		if sErr := <-storeErr; sErr != nil {
+			err = fmt.Errorf("failed to fetch prunable roots: %w", sErr)
+		}
+		return b.partialPruneResponse(cm, rev, rootsUsage, totalToPrune*sectorSize, dryRun, err)
+	} else if err := <-storeErr; err != nil {
+		return b.partialPruneResponse(cm, rev, rootsUsage, totalToPrune*sectorSize, dryRun, fmt.Errorf("failed to fetch prunable roots: %w", err))
+	}
+
+	// on a dry run we only report what would have been pruned, fetching the
+	// roots revised the contract though so we record the new revision
+	if dryRun {
+		if err := b.recordPruneSpending(cm, rev, rhpv4.Usage{}, rhpv4.Usage{}); err != nil {
+			return api.ContractPruneResponse{}, err
+		}
+		return api.ContractPruneResponse{
+			ContractSize:  rev.Filesize,
+			Remaining:     totalToPrune * sectorSize,
+			TotalPrunable: totalToPrune * sectorSize,
+			WouldPrune:    uint64(len(toPrune)) * sectorSize,
+		}, nil
 	}
-	toPrune = toPrune[:batchSize]
 
 	// prune the batch
 	res, err := b.rhp4Client.FreeSectors(ctx, cm.HostKey, hostIP, b.cm.TipState(), prices, rk, cRHP4.ContractRevision{
@@ -96,34 +278,141 @@ func (b *Bus) pruneContract(ctx context.Context, rk types.PrivateKey, cm api.Con
 		Revision: rev,
 	}, toPrune)
 	if err != nil {
-		return api.ContractPruneResponse{}, fmt.Errorf("failed to free sectors: %w", err)
+		return b.partialPruneResponse(cm, rev, rootsUsage, totalToPrune*sectorSize, dryRun, fmt.Errorf("failed to free sectors: %w", err))
 	}
 	deleteUsage := res.Usage
 	rev = res.Revision // update rev
 
 	// record spending
-	if !rootsUsage.Add(deleteUsage).RenterCost().IsZero() {
+	if err := b.recordPruneSpending(cm, rev, rootsUsage, deleteUsage); err != nil {
+		return api.ContractPruneResponse{}, err
+	}
+
+	// the contract was pruned, so the revision gap is no longer an issue
+	if err := b.alerts.DismissAlerts(ctx, alerts.IDForContract(alertRevisionGapID, cm.ID)); err != nil {
+		b.logger.Errorw("failed to dismiss alert", "fcid", cm.ID, zap.Error(err))
+	}
+
+	resp := api.ContractPruneResponse{
+		ContractSize:  rev.Filesize,
+		Pruned:        uint64(len(toPrune)) * sectorSize,
+		Remaining:     (totalToPrune - uint64(len(toPrune))) * sectorSize,
+		TotalPrunable: totalToPrune * sectorSize,
+	}
+
+	// record the event
+	if resp.Pruned > 0 {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 		defer cancel()
-		b.store.RecordContractSpending(ctx, []api.ContractSpendingRecord{
-			{
-				ContractSpending: api.ContractSpending{
-					Deletions:   deleteUsage.RenterCost(),
-					SectorRoots: rootsUsage.RenterCost(),
-				},
-				ContractID:     cm.ID,
-				RevisionNumber: rev.RevisionNumber,
-				Size:           rev.Filesize,
-
-				MissedHostPayout:  rev.MissedHostOutput().Value,
-				ValidRenterPayout: rev.RenterOutput.Value,
-			},
-		})
+		if err := b.store.RecordContractEvent(ctx, cm.ID, api.ContractEventTypePruned, resp); err != nil {
+			b.logger.Errorw("failed to record contract event", zap.Error(err))
+		}
 	}
+	return resp, nil
+}
 
+// partialPruneResponse is returned when pruning is interrupted after the sector
+// roots were fetched from the host, the roots were paid for so the revision is
+// recorded together with their spending, unless it's a dry run. The response
+// contains the prunable data found so far together with the error that
+// interrupted pruning.
+func (b *Bus) partialPruneResponse(cm api.ContractMetadata, rev types.V2FileContract, rootsUsage rhpv4.Usage, prunable uint64, dryRun bool, pruneErr error) (api.ContractPruneResponse, error) {
+	if dryRun {
+		rootsUsage = rhpv4.Usage{}
+	}
+	if err := b.recordPruneSpending(cm, rev, rootsUsage, rhpv4.Usage{}); err != nil {
+		return api.ContractPruneResponse{}, errors.Join(pruneErr, err)
+	}
 	return api.ContractPruneResponse{
-		ContractSize: rev.Filesize,
-		Pruned:       uint64(len(toPrune) * rhpv4.SectorSize),
-		Remaining:    (totalToPrune - uint64(len(toPrune))) * rhpv4.SectorSize,
+		ContractSize:  rev.Filesize,
+		Remaining:     prunable,
+		TotalPrunable: prunable,
+		Error:         pruneErr.Error(),
 	}, nil
 }
+
+// recordPruneSpending records the cost of fetching the contract's sector roots
+// and freeing its sectors as contract spending. The given revision is recorded
+// even if there is no cost, every RPC revises the contract and not storing the
+// new revision would widen the gap with the host's revision.
+func (b *Bus) recordPruneSpending(cm api.ContractMetadata, rev types.V2FileContract, rootsUsage, deleteUsage rhpv4.Usage) error {
+	if rev.RevisionNumber <= cm.RevisionNumber && rootsUsage.Add(deleteUsage).RenterCost().IsZero() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err := b.recordContractSpending(ctx, []api.ContractSpendingRecord{
+		{
+			ContractSpending: api.ContractSpending{
+				Deletions:   deleteUsage.RenterCost(),
+				SectorRoots: rootsUsage.RenterCost(),
+			},
+			ContractID:     cm.ID,
+			RevisionNumber: rev.RevisionNumber,
+			Size:           rev.Filesize,
+
+			MissedHostPayout:  rev.MissedHostOutput().Value,
+			ValidRenterPayout: rev.RenterOutput.Value,
+		},
+	})
+	if errors.Is(err, api.ErrContractSpendingCapExceeded) {
+		return err
+	} else if err != nil {
+		b.logger.Errorw("failed to record prune spending", "fcid", cm.ID, zap.Error(err))
+	}
+	return nil
+}
+
+// checkRevisionGap returns an error if the given revision is more than maxGap
+// revisions ahead of the contract's stored revision, a maxGap of 0 disables
+// the check.
+func checkRevisionGap(cm api.ContractMetadata, rev types.V2FileContract, maxGap uint64) error {
+	if maxGap == 0 || rev.RevisionNumber <= cm.RevisionNumber {
+		return nil
+	} else if gap := rev.RevisionNumber - cm.RevisionNumber; gap > maxGap {
+		return fmt.Errorf("%w: host revision %d is %d revisions ahead of stored revision %d for contract %v, max gap is %d", errRevisionGapExceeded, rev.RevisionNumber, gap, cm.RevisionNumber, cm.ID, maxGap)
+	}
+	return nil
+}
+
+func newRevisionGapAlert(cm api.ContractMetadata, rev types.V2FileContract, maxGap uint64) alerts.Alert {
+	gap := rev.RevisionNumber - cm.RevisionNumber
+	return alerts.Alert{
+		ID:          alerts.IDForContract(alertRevisionGapID, cm.ID),
+		Severity:    alerts.SeverityWarning,
+		Message:     "Contract revision is far behind the host's revision",
+		Description: fmt.Sprintf("The host's revision of contract %v is %d revisions ahead of the stored revision, which exceeds the max gap of %d. The contract was not pruned.", cm.ID, gap, maxGap),
+		Suggestion:  "The bus likely missed revision updates from its workers, check the worker logs for failures to record contract spending.",
+		Data: map[string]any{
+			"contractID":             cm.ID.String(),
+			"hostKey":                cm.HostKey.String(),
+			"gap":                    gap,
+			"maxGap":                 maxGap,
+			"hostRevisionNumber":     rev.RevisionNumber,
+			"contractRevisionNumber": cm.RevisionNumber,
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+// verifyRevision verifies that the revision reported by the host belongs to the
+// given contract and is signed by both the host and the renter. This prevents
+// a host from tricking us into pruning sectors using a forged revision.
+func verifyRevision(cs consensus.State, cm api.ContractMetadata, renterKey types.PublicKey, rev types.V2FileContract) error {
+	if rev.RevisionNumber < cm.RevisionNumber {
+		return fmt.Errorf("latest known revision %d is less than contract revision %d", rev.RevisionNumber, cm.RevisionNumber)
+	} else if rev.HostPublicKey != cm.HostKey {
+		return fmt.Errorf("%w: host public key %v doesn't match contract host key %v", errInvalidRevision, rev.HostPublicKey, cm.HostKey)
+	} else if rev.RenterPublicKey != renterKey {
+		return fmt.Errorf("%w: renter public key %v doesn't match expected renter key %v", errInvalidRevision, rev.RenterPublicKey, renterKey)
+	}
+
+	sigHash := cs.ContractSigHash(rev)
+	if !rev.HostPublicKey.VerifyHash(sigHash, rev.HostSignature) {
+		return fmt.Errorf("%w: invalid host signature", errInvalidRevision)
+	} else if !rev.RenterPublicKey.VerifyHash(sigHash, rev.RenterSignature) {
+		return fmt.Errorf("%w: invalid renter signature", errInvalidRevision)
+	}
+	return nil
+}
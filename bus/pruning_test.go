@@ -0,0 +1,97 @@
+package bus
+
+import (
+	"errors"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/chain"
+	"go.sia.tech/renterd/v2/api"
+)
+
+func TestVerifyRevision(t *testing.T) {
+	n, _ := chain.TestnetZen()
+	cs := n.GenesisState()
+
+	hostKey, renterKey := types.GeneratePrivateKey(), types.GeneratePrivateKey()
+	cm := api.ContractMetadata{
+		ID:             types.FileContractID{1},
+		HostKey:        hostKey.PublicKey(),
+		RevisionNumber: 10,
+	}
+
+	// helper to create a signed revision
+	newRevision := func(revisionNumber uint64, hk, rk types.PrivateKey) types.V2FileContract {
+		rev := types.V2FileContract{
+			HostPublicKey:   hk.PublicKey(),
+			RenterPublicKey: rk.PublicKey(),
+			RevisionNumber:  revisionNumber,
+		}
+		sigHash := cs.ContractSigHash(rev)
+		rev.HostSignature = hk.SignHash(sigHash)
+		rev.RenterSignature = rk.SignHash(sigHash)
+		return rev
+	}
+
+	// valid revision
+	rev := newRevision(11, hostKey, renterKey)
+	if err := verifyRevision(cs, cm, renterKey.PublicKey(), rev); err != nil {
+		t.Fatal(err)
+	}
+
+	// revision number too low
+	if err := verifyRevision(cs, cm, renterKey.PublicKey(), newRevision(9, hostKey, renterKey)); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// malicious revision with a valid-looking revision number but signed by
+	// a different key
+	rev = newRevision(11, hostKey, renterKey)
+	rev.HostSignature = types.GeneratePrivateKey().SignHash(cs.ContractSigHash(rev))
+	if err := verifyRevision(cs, cm, renterKey.PublicKey(), rev); !errors.Is(err, errInvalidRevision) {
+		t.Fatal("expected errInvalidRevision, got", err)
+	}
+
+	// revision that was tampered with after signing
+	rev = newRevision(11, hostKey, renterKey)
+	rev.Filesize = 1 << 40
+	if err := verifyRevision(cs, cm, renterKey.PublicKey(), rev); !errors.Is(err, errInvalidRevision) {
+		t.Fatal("expected errInvalidRevision, got", err)
+	}
+
+	// revision that is signed by both parties but for a different host
+	if err := verifyRevision(cs, cm, renterKey.PublicKey(), newRevision(11, types.GeneratePrivateKey(), renterKey)); !errors.Is(err, errInvalidRevision) {
+		t.Fatal("expected errInvalidRevision, got", err)
+	}
+
+	// revision that isn't signed by the renter
+	rev = newRevision(11, hostKey, renterKey)
+	rev.RenterSignature = types.Signature{}
+	if err := verifyRevision(cs, cm, renterKey.PublicKey(), rev); !errors.Is(err, errInvalidRevision) {
+		t.Fatal("expected errInvalidRevision, got", err)
+	}
+}
+
+func TestCheckRevisionGap(t *testing.T) {
+	cm := api.ContractMetadata{ID: types.FileContractID{1}, RevisionNumber: 10}
+
+	// a gap of 0 disables the check
+	if err := checkRevisionGap(cm, types.V2FileContract{RevisionNumber: 1000}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// a gap of exactly the max is allowed
+	if err := checkRevisionGap(cm, types.V2FileContract{RevisionNumber: 15}, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	// a gap exceeding the max is not
+	if err := checkRevisionGap(cm, types.V2FileContract{RevisionNumber: 16}, 5); !errors.Is(err, errRevisionGapExceeded) {
+		t.Fatal("expected errRevisionGapExceeded, got", err)
+	}
+
+	// a revision behind the stored one is left to verifyRevision
+	if err := checkRevisionGap(cm, types.V2FileContract{RevisionNumber: 5}, 1); err != nil {
+		t.Fatal(err)
+	}
+}
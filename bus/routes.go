@@ -1,6 +1,7 @@
 package bus
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -94,18 +95,20 @@ func (b *Bus) accountsFundHandler(jc jape.Context) {
 
 	// record spending
 	rev = res.Revision
-	err = b.store.RecordContractSpending(jc.Request.Context(), []api.ContractSpendingRecord{
-		{
-			ContractSpending: api.ContractSpending{
-				FundAccount: deposit,
+	err = b.retryDBWrite(jc.Request.Context(), "record contract spending", func(ctx context.Context) error {
+		return b.store.RecordContractSpending(ctx, []api.ContractSpendingRecord{
+			{
+				ContractSpending: api.ContractSpending{
+					FundAccount: deposit,
+				},
+				ContractID:     req.ContractID,
+				RevisionNumber: rev.RevisionNumber,
+				Size:           rev.Filesize,
+
+				MissedHostPayout:  rev.MissedHostValue,
+				ValidRenterPayout: rev.RenterOutput.Value,
 			},
-			ContractID:     req.ContractID,
-			RevisionNumber: rev.RevisionNumber,
-			Size:           rev.Filesize,
-
-			MissedHostPayout:  rev.MissedHostValue,
-			ValidRenterPayout: rev.RenterOutput.Value,
-		},
+		})
 	})
 	if err != nil {
 		b.logger.Errorw("failed to record contract spending", zap.Error(err))
@@ -169,6 +172,28 @@ func (b *Bus) consensusNetworkHandler(jc jape.Context) {
 	jc.Encode(b.cm.TipState().Network)
 }
 
+func (b *Bus) adminBucketRecountHandlerPOST(jc jape.Context) {
+	var name string
+	if jc.DecodeParam("name", &name) != nil {
+		return
+	} else if name == "" {
+		jc.Error(errors.New("parameter 'name' is required"), http.StatusBadRequest)
+		return
+	}
+	usage, err := b.store.RecountBucketUsage(jc.Request.Context(), name)
+	if errors.Is(err, api.ErrBucketNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to recount bucket usage", err) != nil {
+		return
+	}
+	jc.Encode(usage)
+}
+
+func (b *Bus) adminDBStatsHandlerGET(jc jape.Context) {
+	api.WriteResponse(jc, b.store.DBStats())
+}
+
 func (b *Bus) postSystemSQLite3BackupHandler(jc jape.Context) {
 	var req api.BackupRequest
 	if jc.Decode(&req) != nil {
@@ -226,12 +251,31 @@ func (b *Bus) bucketsHandlerPOST(jc jape.Context) {
 		return
 	}
 
-	err := b.store.CreateBucket(jc.Request.Context(), req.Name, req.Policy)
+	err := b.store.CreateBucket(jc.Request.Context(), req.Name, req.Policy, req.Redundancy)
 	if errors.Is(err, api.ErrBucketExists) {
 		jc.Error(err, http.StatusConflict)
 		return
+	} else if jc.Check("failed to create bucket", err) != nil {
+		return
 	}
-	jc.Check("failed to create bucket", err)
+}
+
+func (b *Bus) bucketsUsageHandlerGET(jc jape.Context) {
+	var name string
+	if jc.DecodeParam("name", &name) != nil {
+		return
+	} else if name == "" {
+		jc.Error(errors.New("parameter 'name' is required"), http.StatusBadRequest)
+		return
+	}
+	usage, err := b.store.BucketUsage(jc.Request.Context(), name)
+	if errors.Is(err, api.ErrBucketNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to fetch bucket usage", err) != nil {
+		return
+	}
+	jc.Encode(usage)
 }
 
 func (b *Bus) bucketsHandlerPolicyPUT(jc jape.Context) {
@@ -243,6 +287,9 @@ func (b *Bus) bucketsHandlerPolicyPUT(jc jape.Context) {
 	if bucket == "" {
 		jc.Error(errors.New("no bucket name provided"), http.StatusBadRequest)
 		return
+	} else if err := req.Policy.Validate(); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
 	}
 
 	err := b.store.UpdateBucketPolicy(jc.Request.Context(), bucket, req.Policy)
@@ -250,7 +297,67 @@ func (b *Bus) bucketsHandlerPolicyPUT(jc jape.Context) {
 		jc.Error(err, http.StatusNotFound)
 		return
 	}
-	jc.Check("failed to create bucket", err)
+	jc.Check("failed to update bucket policy", err)
+}
+
+func (b *Bus) bucketsHandlerPolicyGET(jc jape.Context) {
+	var name string
+	if jc.DecodeParam("name", &name) != nil {
+		return
+	} else if name == "" {
+		jc.Error(errors.New("parameter 'name' is required"), http.StatusBadRequest)
+		return
+	}
+	policy, err := b.store.BucketPolicy(jc.Request.Context(), name)
+	if errors.Is(err, api.ErrBucketNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to fetch bucket policy", err) != nil {
+		return
+	}
+	jc.Encode(policy)
+}
+
+func (b *Bus) bucketsHandlerRedundancyGET(jc jape.Context) {
+	var name string
+	if jc.DecodeParam("name", &name) != nil {
+		return
+	} else if name == "" {
+		jc.Error(errors.New("parameter 'name' is required"), http.StatusBadRequest)
+		return
+	}
+	bucket, err := b.store.Bucket(jc.Request.Context(), name)
+	if errors.Is(err, api.ErrBucketNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to fetch bucket", err) != nil {
+		return
+	}
+	jc.Encode(api.BucketRedundancyResponse{Redundancy: bucket.Redundancy})
+}
+
+func (b *Bus) bucketsHandlerRedundancyPUT(jc jape.Context) {
+	var req api.BucketUpdateRedundancyRequest
+	if jc.Decode(&req) != nil {
+		return
+	}
+	bucket := jc.PathParam("name")
+	if bucket == "" {
+		jc.Error(errors.New("no bucket name provided"), http.StatusBadRequest)
+		return
+	} else if req.Redundancy != nil {
+		if err := req.Redundancy.Validate(); err != nil {
+			jc.Error(err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := b.store.UpdateBucketRedundancy(jc.Request.Context(), bucket, req.Redundancy)
+	if errors.Is(err, api.ErrBucketNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("failed to update bucket redundancy", err)
 }
 
 func (b *Bus) bucketHandlerDELETE(jc jape.Context) {
@@ -441,10 +548,23 @@ func (b *Bus) walletRedistributeHandler(jc jape.Context) {
 }
 
 func (b *Bus) walletPendingHandler(jc jape.Context) {
+	opts := api.WalletPendingOpts{Types: jc.Request.URL.Query()["type"]}
+	if jc.DecodeForm("minamount", (*api.ParamCurrency)(&opts.MinAmount)) != nil {
+		return
+	}
+
 	events, err := b.w.UnconfirmedEvents()
 	if jc.Check("couldn't fetch unconfirmed events", err) != nil {
 		return
 	}
+	jc.Encode(filterPendingEvents(events, opts))
+}
+
+func (b *Bus) walletUnconfirmedHandler(jc jape.Context) {
+	events, err := b.walletUnconfirmedEvents()
+	if jc.Check("couldn't fetch unconfirmed events", err) != nil {
+		return
+	}
 	jc.Encode(events)
 }
 
@@ -542,13 +662,22 @@ func (b *Bus) hostsRemoveHandlerPOST(jc jape.Context) {
 		jc.Error(errors.New("maxConsecutiveScanFailures must be non-zero"), http.StatusBadRequest)
 		return
 	}
-	removed, err := b.store.RemoveOfflineHosts(jc.Request.Context(), hrr.MaxConsecutiveScanFailures, time.Duration(hrr.MaxDowntimeHours))
+	removed, err := b.removeOfflineHosts(jc.Request.Context(), hrr.MaxConsecutiveScanFailures, time.Duration(hrr.MaxDowntimeHours))
 	if jc.Check("couldn't remove offline hosts", err) != nil {
 		return
 	}
 	jc.Encode(removed)
 }
 
+func (b *Bus) hostsRemoveConfirmHandlerPOST(jc jape.Context) {
+	err := b.confirmOfflineHostsRemoval(jc.Request.Context())
+	if errors.Is(err, errNoHostPruneHalted) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+	jc.Check("couldn't confirm offline hosts removal", err)
+}
+
 func (b *Bus) hostsPubkeyHandlerGET(jc jape.Context) {
 	var hostKey types.PublicKey
 	if jc.DecodeParam("hostkey", &hostKey) != nil {
@@ -563,6 +692,23 @@ func (b *Bus) hostsPubkeyHandlerGET(jc jape.Context) {
 	}
 }
 
+func (b *Bus) hostsScoreHandlerGET(jc jape.Context) {
+	var hostKey types.PublicKey
+	if jc.DecodeParam("hostkey", &hostKey) != nil {
+		return
+	}
+	host, err := b.store.Host(jc.Request.Context(), hostKey)
+	if errors.Is(err, api.ErrHostNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't load host", err) == nil {
+		jc.Encode(api.HostScoreResponse{
+			HostScoreBreakdown: host.Checks.ScoreBreakdown,
+			Overall:            host.Checks.ScoreBreakdown.Score(),
+		})
+	}
+}
+
 func (b *Bus) hostsScanHandlerPOST(jc jape.Context) {
 	// only scan hosts if we are online
 	if len(b.s.Peers()) == 0 {
@@ -623,6 +769,61 @@ func (b *Bus) hostsScanHandlerPOST(jc jape.Context) {
 	})
 }
 
+func (b *Bus) hostsSectorSizeHandlerPUT(jc jape.Context) {
+	var hostKey types.PublicKey
+	if jc.DecodeParam("hostkey", &hostKey) != nil {
+		return
+	}
+	var req api.HostSectorSizeRequest
+	if jc.Decode(&req) != nil {
+		return
+	} else if req.SectorSize == 0 || req.SectorSize%rhpv4.LeafSize != 0 {
+		jc.Error(fmt.Errorf("sector size must be a positive multiple of %d", rhpv4.LeafSize), http.StatusBadRequest)
+		return
+	}
+	err := b.store.UpdateHostSectorSize(jc.Request.Context(), hostKey, req.SectorSize)
+	if errors.Is(err, api.ErrHostNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("couldn't update sector size", err)
+}
+
+func (b *Bus) hostsSettingsHandlerGET(jc jape.Context) {
+	var hostKey types.PublicKey
+	if jc.DecodeParam("hostkey", &hostKey) != nil {
+		return
+	}
+
+	// check the cache first
+	if settings, cachedAt, ok := b.hostSettingsCache.Get(hostKey); ok {
+		jc.Encode(api.HostSettingsResponse{
+			Settings: settings,
+			CachedAt: cachedAt,
+		})
+		return
+	}
+
+	// fetch host
+	host, err := b.store.Host(jc.Request.Context(), hostKey)
+	if errors.Is(err, api.ErrHostNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't load host", err) != nil {
+		return
+	}
+
+	// fetch settings
+	settings, err := b.rhp4Client.Settings(jc.Request.Context(), hostKey, host.SiamuxAddr())
+	if jc.Check("failed to fetch host settings", err) != nil {
+		return
+	}
+	jc.Encode(api.HostSettingsResponse{
+		Settings: settings,
+		CachedAt: b.hostSettingsCache.Set(hostKey, settings),
+	})
+}
+
 func (b *Bus) hostsResetLostSectorsPOST(jc jape.Context) {
 	var hostKey types.PublicKey
 	if jc.DecodeParam("hostkey", &hostKey) != nil {
@@ -639,9 +840,12 @@ func (b *Bus) contractsSpendingHandlerPOST(jc jape.Context) {
 	if jc.Decode(&records) != nil {
 		return
 	}
-	if jc.Check("failed to record spending metrics for contract", b.store.RecordContractSpending(jc.Request.Context(), records)) != nil {
+	err := b.recordContractSpending(jc.Request.Context(), records)
+	if errors.Is(err, api.ErrContractSpendingCapExceeded) {
+		jc.Error(err, http.StatusBadRequest)
 		return
 	}
+	jc.Check("failed to record spending metrics for contract", err)
 }
 
 func (b *Bus) hostsAllowlistHandlerGET(jc jape.Context) {
@@ -690,6 +894,27 @@ func (b *Bus) contractsHandlerGET(jc jape.Context) {
 		return
 	}
 
+	var minRemainingFunds types.Currency
+	if jc.DecodeForm("minremainingfunds", (*api.ParamCurrency)(&minRemainingFunds)) != nil {
+		return
+	}
+	var maxSpendingRatio float64
+	if jc.DecodeForm("maxspendingratio", (*api.ParamFloat64)(&maxSpendingRatio)) != nil {
+		return
+	}
+	var expiringWithinBlocks uint64
+	if jc.DecodeForm("expiringwithinblocks", &expiringWithinBlocks) != nil {
+		return
+	}
+	var hostVersionBelow string
+	if jc.DecodeForm("hostversionbelow", &hostVersionBelow) != nil {
+		return
+	}
+	var hostKey types.PublicKey
+	if jc.DecodeForm("hostkey", &hostKey) != nil {
+		return
+	}
+
 	switch filterMode {
 	case api.ContractFilterModeAll:
 	case api.ContractFilterModeActive:
@@ -699,13 +924,31 @@ func (b *Bus) contractsHandlerGET(jc jape.Context) {
 		jc.Error(fmt.Errorf("invalid filter mode '%v', must be one of [active, archived, all, good]", filterMode), http.StatusBadRequest)
 		return
 	}
+	if maxSpendingRatio < 0 || maxSpendingRatio > 1 {
+		jc.Error(fmt.Errorf("invalid max spending ratio %v, must be between 0 and 1", maxSpendingRatio), http.StatusBadRequest)
+		return
+	}
+	if hostVersionBelow != "" {
+		if _, err := api.ParseHostVersion(hostVersionBelow); err != nil {
+			jc.Error(fmt.Errorf("invalid host version: %w", err), http.StatusBadRequest)
+			return
+		}
+	}
 
 	contracts, err := b.store.Contracts(jc.Request.Context(), api.ContractsOpts{
-		FilterMode: filterMode,
+		FilterMode:           filterMode,
+		MinRemainingFunds:    minRemainingFunds,
+		MaxSpendingRatio:     maxSpendingRatio,
+		ExpiringWithinBlocks: expiringWithinBlocks,
+		HostVersionBelow:     hostVersionBelow,
+		HostKey:              hostKey,
 	})
-	if jc.Check("couldn't load contracts", err) == nil {
-		api.WriteResponse(jc, prometheus.Slice(contracts))
+	if jc.Check("couldn't load contracts", err) != nil {
+		return
+	} else if jc.Check("couldn't check contracts for gouging", b.checkContractsGouging(jc.Request.Context(), contracts)) != nil {
+		return
 	}
+	api.WriteResponse(jc, prometheus.Slice(contracts))
 }
 
 func (b *Bus) contractsRenewedIDHandlerGET(jc jape.Context) {
@@ -726,7 +969,41 @@ func (b *Bus) contractsArchiveHandlerPOST(jc jape.Context) {
 		return
 	}
 
-	jc.Check("failed to archive contracts", b.store.ArchiveContracts(jc.Request.Context(), toArchive))
+	// validate the archival reasons
+	var invalid []string
+	for fcid, reason := range toArchive {
+		if err := api.ValidateArchivalReason(reason); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%v: '%s'", fcid, reason))
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		jc.Error(fmt.Errorf("%w: %s", api.ErrInvalidArchivalReason, strings.Join(invalid, ", ")), http.StatusUnprocessableEntity)
+		return
+	}
+
+	jc.Check("failed to archive contracts", b.retryDBWrite(jc.Request.Context(), "archive contracts", func(ctx context.Context) error {
+		return b.store.ArchiveContracts(ctx, toArchive)
+	}))
+}
+
+func (b *Bus) contractsArchiveHandlerDELETE(jc jape.Context) {
+	var ids []types.FileContractID
+	for _, id := range jc.Request.URL.Query()["id"] {
+		var fcid types.FileContractID
+		if err := fcid.UnmarshalText([]byte(id)); err != nil {
+			jc.Error(fmt.Errorf("invalid contract id '%s': %w", id, err), http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, fcid)
+	}
+
+	err := b.store.UnarchiveContracts(jc.Request.Context(), ids)
+	if errors.Is(err, api.ErrContractNotFound) || errors.Is(err, api.ErrHostNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("failed to unarchive contracts", err)
 }
 
 func (b *Bus) contractAcquireHandlerPOST(jc jape.Context) {
@@ -803,101 +1080,88 @@ func (b *Bus) contractPruneHandlerPOST(jc jape.Context) {
 		return
 	}
 
-	// decode timeout
+	// decode request
 	var req api.ContractPruneRequest
 	if jc.Decode(&req) != nil {
 		return
-	}
-
-	// create gouging checker
-	gp, err := b.gougingParams(ctx)
-	if jc.Check("couldn't fetch gouging parameters", err) != nil {
+	} else if req.MaxSectors > rhpv4.MaxSectorBatchSize {
+		jc.Error(fmt.Errorf("max sectors can't exceed %d", rhpv4.MaxSectorBatchSize), http.StatusBadRequest)
 		return
 	}
-	gc := gouging.NewChecker(gp.GougingSettings, gp.ConsensusState)
 
 	// apply timeout
-	pruneCtx := ctx
 	if req.Timeout > 0 {
 		var cancel context.CancelFunc
-		pruneCtx, cancel = context.WithTimeout(ctx, time.Duration(req.Timeout))
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.Timeout))
 		defer cancel()
 	}
 
-	// acquire contract lock indefinitely and defer the release
-	lockID, err := b.contractLocker.Acquire(pruneCtx, lockingPriorityPruning, fcid, time.Duration(math.MaxInt64))
-	if jc.Check("couldn't acquire contract lock", err) != nil {
-		return
-	}
-	defer func() {
-		if err := b.contractLocker.Release(fcid, lockID); err != nil {
-			b.logger.Errorw("failed to release contract lock", zap.Error(err))
-		}
-	}()
-
-	// fetch the contract from the bus
-	c, err := b.store.Contract(ctx, fcid)
+	// prune the contract
+	res, err := b.pruneContractWithID(ctx, fcid, req.MaxSectors, req.DryRun)
 	if errors.Is(err, api.ErrContractNotFound) {
 		jc.Error(err, http.StatusNotFound)
 		return
-	} else if jc.Check("couldn't fetch contract", err) != nil {
+	} else if jc.Check("failed to prune contract", err) != nil {
 		return
 	}
+	jc.Encode(res)
+}
 
-	// fetch the corresponding host
-	host, err := b.store.Host(ctx, c.HostKey)
-	if jc.Check("failed to fetch host for pruning", err) != nil {
+func (b *Bus) contractsPrunableDataHandlerGET(jc jape.Context) {
+	sortBy := api.ContractsPrunableSortByPrunable
+	if jc.DecodeForm("sortby", &sortBy) != nil {
+		return
+	}
+	switch sortBy {
+	case api.ContractsPrunableSortByHostKey:
+	case api.ContractsPrunableSortByPrunable:
+	case api.ContractsPrunableSortBySize:
+	default:
+		jc.Error(fmt.Errorf("invalid sortby '%v', must be one of [%v, %v, %v]", sortBy, api.ContractsPrunableSortByPrunable, api.ContractsPrunableSortBySize, api.ContractsPrunableSortByHostKey), http.StatusBadRequest)
 		return
 	}
 
-	// build map of uploading sectors
-	pending := make(map[types.Hash256]struct{})
-	for _, root := range b.sectors.Sectors() {
-		pending[root] = struct{}{}
+	var offset int
+	if jc.DecodeForm("offset", &offset) != nil {
+		return
+	} else if offset < 0 {
+		jc.Error(api.ErrInvalidOffset, http.StatusBadRequest)
+		return
 	}
 
-	// prune the contract
-	rk := b.masterKey.DeriveContractKey(c.HostKey)
-	res, err := b.pruneContract(pruneCtx, rk, c, host.SiamuxAddr(), gc, pending)
-	if jc.Check("failed to prune contract", err) != nil {
+	limit := api.DefaultContractsPrunableLimit
+	if jc.DecodeForm("limit", &limit) != nil {
+		return
+	} else if limit <= 0 || limit > api.MaxContractsPrunableLimit {
+		jc.Error(fmt.Errorf("limit must be between 1 and %d", api.MaxContractsPrunableLimit), http.StatusBadRequest)
 		return
 	}
-	jc.Encode(res)
-}
 
-func (b *Bus) contractsPrunableDataHandlerGET(jc jape.Context) {
-	sizes, err := b.store.ContractSizes(jc.Request.Context())
+	resp, err := b.store.ContractsPrunableData(jc.Request.Context(), api.ContractsPrunableDataOpts{
+		SortBy: sortBy,
+		Offset: offset,
+		Limit:  limit,
+	})
 	if jc.Check("failed to fetch contract sizes", err) != nil {
 		return
 	}
+	api.WriteResponse(jc, resp)
+}
 
-	// prepare the response
-	var contracts []api.ContractPrunableData
-	var totalPrunable, totalSize uint64
-
-	// build the response
-	for fcid, size := range sizes {
-		contracts = append(contracts, api.ContractPrunableData{
-			ID:           fcid,
-			ContractSize: size,
-		})
-		totalPrunable += size.Prunable
-		totalSize += size.Size
+func (b *Bus) contractsRefreshHandlerPOST(jc jape.Context) {
+	parallelism := api.DefaultContractsRefreshParallelism
+	if jc.DecodeForm("parallelism", &parallelism) != nil {
+		return
+	} else if parallelism <= 0 {
+		jc.Error(errors.New("parallelism must be greater than zero"), http.StatusBadRequest)
+		return
 	}
 
-	// sort contracts by the amount of prunable data
-	sort.Slice(contracts, func(i, j int) bool {
-		if contracts[i].Prunable == contracts[j].Prunable {
-			return contracts[i].Size > contracts[j].Size
-		}
-		return contracts[i].Prunable > contracts[j].Prunable
-	})
-
-	api.WriteResponse(jc, api.ContractsPrunableDataResponse{
-		Contracts:     contracts,
-		TotalPrunable: totalPrunable,
-		TotalSize:     totalSize,
-	})
+	var ids api.ContractsRefreshRequest
+	if jc.Decode(&ids) != nil {
+		return
+	}
+	jc.Encode(b.refreshContracts(jc.Request.Context(), ids, parallelism))
 }
 
 func (b *Bus) contractSizeHandlerGET(jc jape.Context) {
@@ -906,7 +1170,18 @@ func (b *Bus) contractSizeHandlerGET(jc jape.Context) {
 		return
 	}
 
-	size, err := b.store.ContractSize(jc.Request.Context(), id)
+	estimate := true
+	if jc.DecodeForm("estimate", &estimate) != nil {
+		return
+	}
+
+	var size api.ContractSize
+	var err error
+	if estimate {
+		size, err = b.estimatePrunableSize(jc.Request.Context(), id)
+	} else {
+		size, err = b.prunableSize(jc.Request.Context(), id)
+	}
 	if errors.Is(err, api.ErrContractNotFound) {
 		jc.Error(err, http.StatusNotFound)
 		return
@@ -916,6 +1191,67 @@ func (b *Bus) contractSizeHandlerGET(jc jape.Context) {
 	jc.Encode(size)
 }
 
+func (b *Bus) contractIDSpendingHandlerGET(jc jape.Context) {
+	var id types.FileContractID
+	if jc.DecodeParam("id", &id) != nil {
+		return
+	}
+
+	var from time.Time
+	if jc.DecodeForm("from", (*api.TimeRFC3339)(&from)) != nil {
+		return
+	} else if from.IsZero() {
+		jc.Error(errors.New("parameter 'from' is required"), http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now()
+	if jc.DecodeForm("to", (*api.TimeRFC3339)(&to)) != nil {
+		return
+	} else if !from.Before(to) {
+		jc.Error(errors.New("'from' has to be before 'to'"), http.StatusBadRequest)
+		return
+	}
+
+	granularity := api.SpendingGranularityDay
+	if jc.DecodeForm("granularity", &granularity) != nil {
+		return
+	}
+	interval, err := api.SpendingGranularityInterval(granularity)
+	if err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := b.store.ContractSpendingHistory(jc.Request.Context(), id, from, to, interval)
+	if errors.Is(err, api.ErrMaxIntervalsExceeded) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	} else if jc.Check("failed to fetch contract spending history", err) != nil {
+		return
+	}
+	jc.Encode(buckets)
+}
+
+func (b *Bus) contractSpendingCapHandlerPUT(jc jape.Context) {
+	var id types.FileContractID
+	if jc.DecodeParam("id", &id) != nil {
+		return
+	}
+
+	var req api.ContractSpendingCapRequest
+	if jc.Decode(&req) != nil {
+		return
+	}
+
+	err := b.store.UpdateContractSpendingCap(jc.Request.Context(), id, req.SpendingCap)
+	if errors.Is(err, api.ErrContractNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("failed to update contract spending cap", err)
+}
+
 func (b *Bus) contractUsabilityHandlerPUT(jc jape.Context) {
 	var id types.FileContractID
 	if jc.DecodeParam("id", &id) != nil {
@@ -927,7 +1263,32 @@ func (b *Bus) contractUsabilityHandlerPUT(jc jape.Context) {
 		return
 	}
 
-	err := b.store.UpdateContractUsability(jc.Request.Context(), id, usability)
+	err := b.retryDBWrite(jc.Request.Context(), "update contract usability", func(ctx context.Context) error {
+		return b.store.UpdateContractUsability(ctx, id, usability)
+	})
+	if errors.Is(err, api.ErrContractNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if errors.Is(err, sql.ErrInvalidContractUsability) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+
+	jc.Check("failed to update contract usability", err)
+}
+
+func (b *Bus) contractUsabilityOverrideHandlerPUT(jc jape.Context) {
+	var id types.FileContractID
+	if jc.DecodeParam("id", &id) != nil {
+		return
+	}
+
+	var req api.ContractUsabilityOverrideRequest
+	if jc.Decode(&req) != nil {
+		return
+	}
+
+	err := b.store.UpdateContractUsabilityOverride(jc.Request.Context(), id, req.Usability)
 	if errors.Is(err, api.ErrContractNotFound) {
 		jc.Error(err, http.StatusNotFound)
 		return
@@ -935,8 +1296,21 @@ func (b *Bus) contractUsabilityHandlerPUT(jc jape.Context) {
 		jc.Error(err, http.StatusBadRequest)
 		return
 	}
-
-	jc.Check("failed to update contract usability", err)
+	jc.Check("failed to override contract usability", err)
+}
+
+func (b *Bus) contractUsabilityOverrideHandlerDELETE(jc jape.Context) {
+	var id types.FileContractID
+	if jc.DecodeParam("id", &id) != nil {
+		return
+	}
+
+	err := b.store.DeleteContractUsabilityOverride(jc.Request.Context(), id)
+	if errors.Is(err, api.ErrContractNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("failed to remove contract usability override", err)
 }
 
 func (b *Bus) contractReleaseHandlerPOST(jc jape.Context) {
@@ -999,6 +1373,11 @@ func (b *Bus) contractIDRenewHandlerPOST(jc jape.Context) {
 	} else if rrr.RenterFunds.IsZero() {
 		http.Error(jc.ResponseWriter, "RenterFunds can not be zero", http.StatusBadRequest)
 		return
+	} else if rrr.FeeMultiplier < 0 {
+		http.Error(jc.ResponseWriter, "FeeMultiplier can not be negative", http.StatusBadRequest)
+		return
+	} else if rrr.FeeMultiplier == 0 {
+		rrr.FeeMultiplier = 1
 	}
 
 	// fetch the contract
@@ -1012,13 +1391,22 @@ func (b *Bus) contractIDRenewHandlerPOST(jc jape.Context) {
 
 	// fetch the host
 	h, err := b.store.Host(ctx, c.HostKey)
-	if jc.Check("couldn't fetch host", err) != nil {
+	if errors.Is(err, api.ErrHostNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't fetch host", err) != nil {
 		return
 	}
 
 	// fetch consensus state
 	cs := b.cm.TipState()
 
+	// validate the renter funds aren't too small to be of any use
+	if minFunds := minRenterFunds(h, b.w.RecommendedFee()); rrr.RenterFunds.Cmp(minFunds) < 0 {
+		jc.Error(fmt.Errorf("%w: %v < %v", api.ErrInsufficientRenterFunds, rrr.RenterFunds, minFunds), http.StatusBadRequest)
+		return
+	}
+
 	// fetch gouging parameters
 	gp, err := b.gougingParams(ctx)
 	if jc.Check("could not get gouging parameters", err) != nil {
@@ -1038,9 +1426,9 @@ func (b *Bus) contractIDRenewHandlerPOST(jc jape.Context) {
 
 	var contract api.ContractMetadata
 	if c.EndHeight() == rrr.EndHeight {
-		contract, err = b.refreshContract(ctx, cs, h, gp, c, rrr.RenterFunds, rrr.MinNewCollateral)
+		contract, err = b.refreshContract(ctx, cs, h, gp, c, rrr.RenterFunds, rrr.MinNewCollateral, rrr.FeeMultiplier)
 	} else {
-		contract, err = b.renewContract(ctx, cs, h, gp, c, rrr.RenterFunds, rrr.EndHeight)
+		contract, err = b.renewContract(ctx, cs, h, gp, c, rrr.RenterFunds, rrr.EndHeight, rrr.FeeMultiplier)
 	}
 	if jc.Check("couldn't renew/refresh contract", err) != nil {
 		return
@@ -1053,6 +1441,46 @@ func (b *Bus) contractIDRenewHandlerPOST(jc jape.Context) {
 	}
 }
 
+func (b *Bus) contractIDRenewEstimateHandlerGET(jc jape.Context) {
+	// decode contract id
+	var fcid types.FileContractID
+	if jc.DecodeParam("id", &fcid) != nil {
+		return
+	}
+
+	// decode end height
+	var endHeight uint64
+	if jc.DecodeForm("endHeight", &endHeight) != nil {
+		return
+	} else if endHeight == 0 {
+		http.Error(jc.ResponseWriter, "EndHeight can not be zero", http.StatusBadRequest)
+		return
+	}
+
+	// fetch the contract
+	c, err := b.store.Contract(jc.Request.Context(), fcid)
+	if errors.Is(err, api.ErrContractNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't fetch contract", err) != nil {
+		return
+	}
+
+	// fetch the host
+	h, err := b.store.Host(jc.Request.Context(), c.HostKey)
+	if errors.Is(err, api.ErrHostNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't fetch host", err) != nil {
+		return
+	}
+
+	jc.Encode(api.ContractRenewEstimateResponse{
+		MinRenterFunds: minRenterFunds(h, b.w.RecommendedFee()),
+		StorageCost:    renewalStorageCost(h, c, b.cm.TipState().Index.Height, endHeight),
+	})
+}
+
 func (b *Bus) contractIDRootsHandlerGET(jc jape.Context) {
 	var id types.FileContractID
 	if jc.DecodeParam("id", &id) != nil {
@@ -1065,6 +1493,60 @@ func (b *Bus) contractIDRootsHandlerGET(jc jape.Context) {
 	}
 }
 
+func (b *Bus) contractIDEventsHandlerGET(jc jape.Context) {
+	var id types.FileContractID
+	if jc.DecodeParam("id", &id) != nil {
+		return
+	}
+
+	events, err := b.store.ContractEvents(jc.Request.Context(), id)
+	if errors.Is(err, api.ErrContractNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to fetch contract events", err) != nil {
+		return
+	}
+	jc.Encode(events)
+}
+
+func (b *Bus) contractIDHistoryHandlerGET(jc jape.Context) {
+	var id types.FileContractID
+	if jc.DecodeParam("id", &id) != nil {
+		return
+	}
+
+	history, err := b.store.ContractStateHistory(jc.Request.Context(), id)
+	if jc.Check("failed to fetch contract state history", err) != nil {
+		return
+	}
+	jc.Encode(history)
+}
+
+func (b *Bus) contractIDRevisionsHandlerGET(jc jape.Context) {
+	var id types.FileContractID
+	if jc.DecodeParam("id", &id) != nil {
+		return
+	}
+	opts := api.ContractRevisionsOpts{End: math.MaxUint64}
+	if jc.DecodeForm("start", &opts.Start) != nil {
+		return
+	} else if jc.DecodeForm("end", &opts.End) != nil {
+		return
+	} else if opts.Start > opts.End {
+		jc.Error(errors.New("start can't be greater than end"), http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := b.store.ContractRevisions(jc.Request.Context(), id, opts)
+	if errors.Is(err, api.ErrContractNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to fetch contract revisions", err) != nil {
+		return
+	}
+	jc.Encode(revisions)
+}
+
 func (b *Bus) contractIDHandlerDELETE(jc jape.Context) {
 	var id types.FileContractID
 	if jc.DecodeParam("id", &id) != nil {
@@ -1138,8 +1620,12 @@ func (b *Bus) objectsHandlerGET(jc jape.Context) {
 	if jc.DecodeForm("slabencryptionkey", &slabEncryptionKey) != nil {
 		return
 	}
+	var metadata api.ObjectUserMetadata
+	if jc.DecodeForm("metadata", &metadata) != nil {
+		return
+	}
 
-	resp, err := b.store.Objects(jc.Request.Context(), bucket, jc.PathParam("prefix"), substring, delim, sortBy, sortDir, marker, limit, slabEncryptionKey)
+	resp, err := b.store.Objects(jc.Request.Context(), bucket, jc.PathParam("prefix"), substring, delim, sortBy, sortDir, marker, limit, slabEncryptionKey, metadata)
 	if errors.Is(err, api.ErrUnsupportedDelimiter) {
 		jc.Error(err, http.StatusBadRequest)
 		return
@@ -1156,17 +1642,120 @@ func (b *Bus) objectHandlerPUT(jc jape.Context) {
 	} else if aor.Bucket == "" {
 		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
 		return
+	} else if err := aor.Metadata.Validate(); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
 	}
 	jc.Check("couldn't store object", b.store.UpdateObject(jc.Request.Context(), aor.Bucket, jc.PathParam("key"), aor.ETag, aor.MimeType, aor.Metadata, aor.Object))
 }
 
+func (b *Bus) costEstimateHandlerGET(jc jape.Context) {
+	var bucket string
+	var size, duration uint64
+	if jc.DecodeForm("bucket", &bucket) != nil {
+		return
+	} else if bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	} else if jc.DecodeForm("sizeBytes", &size) != nil {
+		return
+	} else if jc.DecodeForm("durationBlocks", &duration) != nil {
+		return
+	} else if duration == 0 {
+		jc.Error(errors.New("durationBlocks must be greater than zero"), http.StatusBadRequest)
+		return
+	}
+
+	estimate, err := b.objectCostEstimate(jc.Request.Context(), bucket, jc.PathParam("key"), size, duration)
+	if errors.Is(err, api.ErrBucketNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if errors.Is(err, api.ErrObjectNotFound) {
+		jc.Error(errors.New("sizeBytes is required for objects that don't exist"), http.StatusBadRequest)
+		return
+	} else if errors.Is(err, errCostEstimateOverflow) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	} else if errors.Is(err, errNoPricedContracts) {
+		jc.Error(err, http.StatusServiceUnavailable)
+		return
+	} else if jc.Check("couldn't estimate cost", err) != nil {
+		return
+	}
+	jc.Encode(estimate)
+}
+
+func (b *Bus) metadataHandlerGET(jc jape.Context) {
+	var bucket string
+	if jc.DecodeForm("bucket", &bucket) != nil {
+		return
+	} else if bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	}
+	o, err := b.store.ObjectMetadata(jc.Request.Context(), bucket, jc.PathParam("key"))
+	if errors.Is(err, api.ErrObjectNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't load object metadata", err) != nil {
+		return
+	}
+	jc.Encode(o.Metadata)
+}
+
+func (b *Bus) metadataHandlerPUT(jc jape.Context) {
+	var req api.UpdateObjectUserMetadataRequest
+	if jc.Decode(&req) != nil {
+		return
+	} else if req.Bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	} else if err := req.Metadata.Validate(); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+	err := b.store.UpdateObjectUserMetadata(jc.Request.Context(), req.Bucket, jc.PathParam("key"), req.Metadata)
+	if errors.Is(err, api.ErrObjectNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("couldn't update object metadata", err)
+}
+
+func (b *Bus) mimeTypeHandlerPUT(jc jape.Context) {
+	var req api.UpdateObjectMimeTypeRequest
+	if jc.Decode(&req) != nil {
+		return
+	} else if req.Bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	} else if req.MimeType != "" {
+		if _, _, err := mime.ParseMediaType(req.MimeType); err != nil {
+			jc.Error(fmt.Errorf("invalid mime type '%s': %w", req.MimeType, err), http.StatusBadRequest)
+			return
+		}
+	}
+	err := b.store.UpdateObjectMimeType(jc.Request.Context(), req.Bucket, jc.PathParam("key"), req.MimeType)
+	if errors.Is(err, api.ErrObjectNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("couldn't update object mime type", err)
+}
+
 func (b *Bus) objectsCopyHandlerPOST(jc jape.Context) {
 	var orr api.CopyObjectsRequest
 	if jc.Decode(&orr) != nil {
 		return
+	} else if err := orr.Metadata.Validate(); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
 	}
-	om, err := b.store.CopyObject(jc.Request.Context(), orr.SourceBucket, orr.DestinationBucket, orr.SourceKey, orr.DestinationKey, orr.MimeType, orr.Metadata)
-	if jc.Check("couldn't copy object", err) != nil {
+	om, err := b.store.CopyObject(jc.Request.Context(), orr.SourceBucket, orr.DestinationBucket, orr.SourceKey, orr.DestinationKey, orr.MimeType, orr.Metadata, orr.Overwrite)
+	if errors.Is(err, api.ErrObjectExists) {
+		jc.Error(err, http.StatusConflict)
+		return
+	} else if jc.Check("couldn't copy object", err) != nil {
 		return
 	}
 
@@ -1175,6 +1764,50 @@ func (b *Bus) objectsCopyHandlerPOST(jc jape.Context) {
 	jc.Encode(om)
 }
 
+func (b *Bus) objectsGCHandlerPOST(jc jape.Context) {
+	deleted, err := b.store.GarbageCollect(jc.Request.Context())
+	if jc.Check("failed to garbage collect object versions", err) != nil {
+		return
+	}
+	jc.Encode(api.ObjectsGCResponse{Deleted: deleted})
+}
+
+func (b *Bus) objectsVersionsHandlerPOST(jc jape.Context) {
+	var ovr api.ObjectVersionsRequest
+	if jc.Decode(&ovr) != nil {
+		return
+	} else if ovr.Bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	}
+
+	versions, err := b.store.ObjectVersions(jc.Request.Context(), ovr.Bucket, ovr.Key)
+	if errors.Is(err, api.ErrBucketNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to fetch object versions", err) != nil {
+		return
+	}
+	jc.Encode(api.ObjectVersionsResponse{Versions: versions})
+}
+
+func (b *Bus) objectsVersionsRestoreHandlerPOST(jc jape.Context) {
+	var ovrr api.ObjectVersionRestoreRequest
+	if jc.Decode(&ovrr) != nil {
+		return
+	} else if ovrr.Bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	}
+
+	err := b.store.RestoreObjectVersion(jc.Request.Context(), ovrr.Bucket, ovrr.Key, ovrr.ID)
+	if errors.Is(err, api.ErrObjectVersionNotFound) || errors.Is(err, api.ErrBucketNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("failed to restore object version", err)
+}
+
 func (b *Bus) objectsRemoveHandlerPOST(jc jape.Context) {
 	var orr api.ObjectsRemoveRequest
 	if jc.Decode(&orr) != nil {
@@ -1199,6 +1832,8 @@ func (b *Bus) objectsRenameHandlerPOST(jc jape.Context) {
 	} else if orr.Bucket == "" {
 		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
 		return
+	} else if orr.ToBucket == "" {
+		orr.ToBucket = orr.Bucket
 	}
 	if orr.Mode == api.ObjectsRenameModeSingle {
 		// Single object rename.
@@ -1206,13 +1841,24 @@ func (b *Bus) objectsRenameHandlerPOST(jc jape.Context) {
 			jc.Error(fmt.Errorf("can't rename dirs with mode %v", orr.Mode), http.StatusBadRequest)
 			return
 		}
-		jc.Check("couldn't rename object", b.store.RenameObject(jc.Request.Context(), orr.Bucket, orr.From, orr.To, orr.Force))
+		err := b.store.RenameObject(jc.Request.Context(), orr.Bucket, orr.ToBucket, orr.From, orr.To, orr.Force)
+		if errors.Is(err, api.ErrObjectNotFound) || errors.Is(err, api.ErrBucketNotFound) {
+			jc.Error(err, http.StatusNotFound)
+			return
+		} else if errors.Is(err, api.ErrObjectExists) {
+			jc.Error(err, http.StatusConflict)
+			return
+		}
+		jc.Check("couldn't rename object", err)
 		return
 	} else if orr.Mode == api.ObjectsRenameModeMulti {
 		// Multi object rename.
 		if !strings.HasSuffix(orr.From, "/") || !strings.HasSuffix(orr.To, "/") {
 			jc.Error(fmt.Errorf("can't rename file with mode %v", orr.Mode), http.StatusBadRequest)
 			return
+		} else if orr.ToBucket != orr.Bucket {
+			jc.Error(fmt.Errorf("can't move objects to another bucket with mode %v", orr.Mode), http.StatusBadRequest)
+			return
 		}
 		jc.Check("couldn't rename objects", b.store.RenameObjects(jc.Request.Context(), orr.Bucket, orr.From, orr.To, orr.Force))
 		return
@@ -1247,6 +1893,14 @@ func (b *Bus) slabbuffersHandlerGET(jc jape.Context) {
 	api.WriteResponse(jc, api.SlabBuffersResp(buffers))
 }
 
+func (b *Bus) slabsBuffersHandlerGET(jc jape.Context) {
+	buffers, err := b.store.ListSlabBuffers(jc.Request.Context())
+	if jc.Check("couldn't list slab buffers", err) != nil {
+		return
+	}
+	jc.Encode(buffers)
+}
+
 func (b *Bus) objectsStatshandlerGET(jc jape.Context) {
 	opts := api.ObjectsStatsOpts{}
 	if jc.DecodeForm("bucket", &opts.Bucket) != nil {
@@ -1546,9 +2200,55 @@ func (b *Bus) autopilotHandlerGET(jc jape.Context) {
 	if jc.Check("failed to fetch autopilot config", err) != nil {
 		return
 	}
+	jc.ResponseWriter.Header().Set("ETag", api.FormatETag(ap.ETag()))
 	jc.Encode(ap)
 }
 
+func (b *Bus) autopilotHandlerPATCH(jc jape.Context) {
+	// read the patch
+	patch, err := io.ReadAll(jc.Request.Body)
+	if jc.Check("failed to read request body", err) != nil {
+		return
+	} else if len(bytes.TrimSpace(patch)) == 0 {
+		jc.Error(errors.New("request body is empty"), http.StatusBadRequest)
+		return
+	}
+
+	b.autopilotMu.Lock()
+	defer b.autopilotMu.Unlock()
+
+	// fetch current config
+	cfg, err := b.store.AutopilotConfig(jc.Request.Context())
+	if jc.Check("failed to fetch current configuration", err) != nil {
+		return
+	}
+
+	// check the precondition
+	if ifMatch := jc.Request.Header.Get("If-Match"); ifMatch != "" && ifMatch != "*" && ifMatch != api.FormatETag(cfg.ETag()) {
+		jc.Error(api.ErrAutopilotConfigModified, http.StatusPreconditionFailed)
+		return
+	}
+
+	// apply the patch
+	cfg, err = cfg.MergePatch(patch)
+	if err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	} else if err := cfg.Contracts.Validate(); err != nil {
+		jc.Error(fmt.Errorf("failed to update autopilot, contracts config is invalid: %w", err), http.StatusBadRequest)
+		return
+	} else if err := cfg.Hosts.Validate(); err != nil {
+		jc.Error(fmt.Errorf("failed to update autopilot, hosts config is invalid: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if jc.Check("failed to update autopilot config", b.store.UpdateAutopilotConfig(jc.Request.Context(), cfg)) != nil {
+		return
+	}
+	jc.ResponseWriter.Header().Set("ETag", api.FormatETag(cfg.ETag()))
+	jc.Encode(cfg)
+}
+
 func (b *Bus) autopilotHandlerPUT(jc jape.Context) {
 	// decode request
 	var req api.UpdateAutopilotRequest
@@ -1559,6 +2259,9 @@ func (b *Bus) autopilotHandlerPUT(jc jape.Context) {
 		return
 	}
 
+	b.autopilotMu.Lock()
+	defer b.autopilotMu.Unlock()
+
 	// fetch current config
 	cfg, err := b.store.AutopilotConfig(jc.Request.Context())
 	if jc.Check("failed to fetch current configuration", err) != nil {
@@ -1711,6 +2414,7 @@ func (b *Bus) handleGETAlerts(jc jape.Context) {
 		Offset:   offset,
 		Limit:    limit,
 		Severity: severity,
+		GroupBy:  jc.Request.URL.Query()["groupby"],
 	})
 	if jc.Check("failed to fetch alerts", err) != nil {
 		return
@@ -1719,13 +2423,77 @@ func (b *Bus) handleGETAlerts(jc jape.Context) {
 }
 
 func (b *Bus) handlePOSTAlertsDismiss(jc jape.Context) {
-	var ids []types.Hash256
-	if jc.Decode(&ids) != nil {
+	var reqs []alerts.DismissAlertRequest
+	if jc.Decode(&reqs) != nil {
 		return
 	}
+
+	var ids []types.Hash256
+	var toRecord []alerts.DismissAlertRequest
+	for _, req := range reqs {
+		ids = append(ids, req.AlertID)
+		if req.Record() {
+			toRecord = append(toRecord, req)
+		}
+	}
+
+	// record the dismissals before dismissing the alerts to make sure the
+	// audit trail is complete
+	if len(toRecord) > 0 {
+		active, err := b.alertMgr.Alerts(jc.Request.Context(), alerts.AlertsOpts{Limit: -1})
+		if jc.Check("failed to fetch alerts", err) != nil {
+			return
+		}
+		activeAlerts := make(map[types.Hash256]alerts.Alert, len(active.Alerts))
+		for _, a := range active.Alerts {
+			activeAlerts[a.ID] = a
+		}
+
+		now := time.Now()
+		dismissed := make([]alerts.DismissedAlert, 0, len(toRecord))
+		for _, req := range toRecord {
+			da := alerts.DismissedAlert{
+				AlertID:     req.AlertID,
+				Reason:      req.Reason,
+				DismissedBy: req.DismissedBy,
+				Timestamp:   now,
+			}
+			if a, ok := activeAlerts[req.AlertID]; ok {
+				da.Alert = &a
+			}
+			dismissed = append(dismissed, da)
+		}
+		if jc.Check("failed to record dismissed alerts", b.store.RecordDismissedAlerts(jc.Request.Context(), dismissed...)) != nil {
+			return
+		}
+	}
 	jc.Check("failed to dismiss alerts", b.alertMgr.DismissAlerts(jc.Request.Context(), ids...))
 }
 
+func (b *Bus) handleGETAlertsDismissed(jc jape.Context) {
+	var offset int
+	if jc.DecodeForm("offset", &offset) != nil {
+		return
+	} else if offset < 0 {
+		jc.Error(api.ErrInvalidOffset, http.StatusBadRequest)
+		return
+	}
+
+	limit := -1
+	if jc.DecodeForm("limit", &limit) != nil {
+		return
+	} else if limit < -1 {
+		jc.Error(api.ErrInvalidLimit, http.StatusBadRequest)
+		return
+	}
+
+	dismissed, err := b.store.DismissedAlerts(jc.Request.Context(), offset, limit)
+	if jc.Check("failed to fetch dismissed alerts", err) != nil {
+		return
+	}
+	jc.Encode(dismissed)
+}
+
 func (b *Bus) handlePOSTAlertsRegister(jc jape.Context) {
 	var alert alerts.Alert
 	if jc.Decode(&alert) != nil {
@@ -1803,6 +2571,22 @@ func (b *Bus) stateHandlerGET(jc jape.Context) {
 	})
 }
 
+func (b *Bus) statsHandlerGET(jc jape.Context) {
+	var pool api.RHP4PoolStats
+	if b.rhp4Pool != nil {
+		stats := b.rhp4Pool.Stats()
+		pool = api.RHP4PoolStats{
+			Size:    stats.Size,
+			InUse:   stats.InUse,
+			Waiting: stats.Waiting,
+		}
+	}
+	jc.Encode(api.BusStatsResponse{
+		HostSectorPruning: b.store.HostSectorPruneStats(),
+		RHP4Pool:          pool,
+	})
+}
+
 func (b *Bus) uploadTrackHandlerPOST(jc jape.Context) {
 	var id api.UploadID
 	if jc.DecodeParam("id", &id) == nil {
@@ -1945,6 +2729,9 @@ func (b *Bus) multipartHandlerCreatePOST(jc jape.Context) {
 	var req api.MultipartCreateRequest
 	if jc.Decode(&req) != nil {
 		return
+	} else if err := req.Metadata.Validate(); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
 	}
 
 	var key object.EncryptionKey
@@ -2076,6 +2863,11 @@ func (b *Bus) contractsFormHandler(jc jape.Context) {
 	} else if rfr.RenterAddress == (types.Address{}) {
 		http.Error(jc.ResponseWriter, "RenterAddress must be provided", http.StatusBadRequest)
 		return
+	} else if rfr.CollateralMultiplier == 0 {
+		rfr.CollateralMultiplier = 1
+	} else if !(rfr.CollateralMultiplier > 0 && rfr.CollateralMultiplier <= maxCollateralMultiplier) {
+		http.Error(jc.ResponseWriter, fmt.Sprintf("CollateralMultiplier must be greater than 0 and at most %d", maxCollateralMultiplier), http.StatusBadRequest)
+		return
 	}
 
 	// fetch host to form a contract with to get its netaddress
@@ -2106,6 +2898,18 @@ func (b *Bus) contractsFormHandler(jc jape.Context) {
 		jc.Error(fmt.Errorf("failed to form contract, gouging check failed: %v", breakdown), http.StatusBadRequest)
 		return
 	}
+
+	// apply the collateral multiplier, if the host can't provide the requested
+	// collateral we fall back to the standard collateral
+	collateral, ok := multiplyCollateral(rfr.HostCollateral, rfr.CollateralMultiplier)
+	if !ok || collateral.Cmp(settings.MaxCollateral) > 0 {
+		b.logger.Debugw("host can't provide the requested collateral, falling back to standard collateral", "hk", rfr.HostKey, "requested", collateral, "max", settings.MaxCollateral)
+		collateral = rfr.HostCollateral
+	} else if rfr.RenterFunds.Cmp(rhpv4.MinRenterAllowance(settings.Prices, collateral)) < 0 {
+		b.logger.Debugw("renter funds are insufficient for the requested collateral, falling back to standard collateral", "hk", rfr.HostKey, "requested", collateral, "renterFunds", rfr.RenterFunds)
+		collateral = rfr.HostCollateral
+	}
+
 	contract, err := b.formContract(
 		ctx,
 		rfr.HostKey,
@@ -2114,14 +2918,29 @@ func (b *Bus) contractsFormHandler(jc jape.Context) {
 		rfr.RenterAddress,
 		settings.Prices,
 		rfr.RenterFunds,
-		rfr.HostCollateral,
+		collateral,
 		rfr.EndHeight,
 	)
+	if err != nil && collateral != rfr.HostCollateral && rhpv4.ErrorCode(err) == rhpv4.ErrorCodeBadRequest {
+		b.logger.Debugw("host rejected the requested collateral, falling back to standard collateral", "hk", rfr.HostKey, "requested", collateral, zap.Error(err))
+		contract, err = b.formContract(
+			ctx,
+			rfr.HostKey,
+			h.SiamuxAddr(),
+			settings.WalletAddress,
+			rfr.RenterAddress,
+			settings.Prices,
+			rfr.RenterFunds,
+			rfr.HostCollateral,
+			rfr.EndHeight,
+		)
+	}
 	if jc.Check("couldn't form contract", err) != nil {
 		return
 	}
 
 	// add the contract
+	contract.HostVersion = settings.Release
 	metadata, err := b.addContract(ctx, contract)
 	if jc.Check("couldn't add contract", err) != nil {
 		return
@@ -8,7 +8,7 @@ import (
 	"go.sia.tech/renterd/v2/stores/sql"
 )
 
-func (b Bus) gougingSettings(ctx context.Context) (api.GougingSettings, error) {
+func (b *Bus) gougingSettings(ctx context.Context) (api.GougingSettings, error) {
 	gs, err := b.store.GougingSettings(ctx)
 	if errors.Is(err, sql.ErrSettingNotFound) {
 		gs = api.DefaultGougingSettings
@@ -18,7 +18,7 @@ func (b Bus) gougingSettings(ctx context.Context) (api.GougingSettings, error) {
 	return gs, nil
 }
 
-func (b Bus) pinnedSettings(ctx context.Context) (api.PinnedSettings, error) {
+func (b *Bus) pinnedSettings(ctx context.Context) (api.PinnedSettings, error) {
 	ps, err := b.store.PinnedSettings(ctx)
 	if errors.Is(err, sql.ErrSettingNotFound) {
 		ps = api.DefaultPinnedSettings
@@ -28,7 +28,7 @@ func (b Bus) pinnedSettings(ctx context.Context) (api.PinnedSettings, error) {
 	return ps, nil
 }
 
-func (b Bus) s3Settings(ctx context.Context) (api.S3Settings, error) {
+func (b *Bus) s3Settings(ctx context.Context) (api.S3Settings, error) {
 	s3s, err := b.store.S3Settings(ctx)
 	if errors.Is(err, sql.ErrSettingNotFound) {
 		s3s = api.DefaultS3Settings
@@ -38,7 +38,7 @@ func (b Bus) s3Settings(ctx context.Context) (api.S3Settings, error) {
 	return s3s, nil
 }
 
-func (b Bus) uploadSettings(ctx context.Context) (api.UploadSettings, error) {
+func (b *Bus) uploadSettings(ctx context.Context) (api.UploadSettings, error) {
 	us, err := b.store.UploadSettings(ctx)
 	if errors.Is(err, sql.ErrSettingNotFound) {
 		us = api.DefaultUploadSettings(b.cm.TipState().Network.Name)
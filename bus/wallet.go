@@ -0,0 +1,101 @@
+package bus
+
+import (
+	"sort"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/renterd/v2/api"
+)
+
+type poolTxn struct {
+	id         types.Hash256
+	feePerByte types.Currency
+	weight     uint64
+}
+
+// walletUnconfirmedEvents returns the wallet's unconfirmed events annotated
+// with their fee per byte and an estimate of the number of blocks it takes for
+// them to be confirmed. The estimate assumes miners include the transactions
+// in the pool ordered by fee per byte, descending.
+func (b *Bus) walletUnconfirmedEvents() ([]api.WalletUnconfirmedEvent, error) {
+	events, err := b.w.UnconfirmedEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	// compute the fee per byte of all transactions in the pool
+	cs := b.cm.TipState()
+	var pool []poolTxn
+	for _, txn := range b.cm.PoolTransactions() {
+		var fee types.Currency
+		for _, mf := range txn.MinerFees {
+			fee = fee.Add(mf)
+		}
+		pool = append(pool, newPoolTxn(types.Hash256(txn.ID()), fee, cs.TransactionWeight(txn)))
+	}
+	for _, txn := range b.cm.V2PoolTransactions() {
+		pool = append(pool, newPoolTxn(types.Hash256(txn.ID()), txn.MinerFee, cs.V2TransactionWeight(txn)))
+	}
+	sort.SliceStable(pool, func(i, j int) bool {
+		return pool[i].feePerByte.Cmp(pool[j].feePerByte) > 0
+	})
+
+	// estimate the number of blocks it takes for every transaction to be
+	// confirmed by summing up the weight of all transactions paying a higher
+	// fee
+	maxWeight := cs.MaxBlockWeight()
+	fees := make(map[types.Hash256]types.Currency)
+	confirmations := make(map[types.Hash256]uint64)
+	var cumulative uint64
+	for _, txn := range pool {
+		cumulative += txn.weight
+		fees[txn.id] = txn.feePerByte
+		confirmations[txn.id] = max((cumulative+maxWeight-1)/maxWeight, 1)
+	}
+
+	unconfirmed := make([]api.WalletUnconfirmedEvent, 0, len(events))
+	for _, event := range events {
+		ue := api.WalletUnconfirmedEvent{
+			Event:                       event,
+			FeePerByte:                  fees[event.ID],
+			EstimatedConfirmationBlocks: 1,
+		}
+		if n, ok := confirmations[event.ID]; ok {
+			ue.EstimatedConfirmationBlocks = n
+		}
+		unconfirmed = append(unconfirmed, ue)
+	}
+	return unconfirmed, nil
+}
+
+func newPoolTxn(id types.Hash256, fee types.Currency, weight uint64) poolTxn {
+	txn := poolTxn{id: id, weight: weight}
+	if weight > 0 {
+		txn.feePerByte = fee.Div64(weight)
+	}
+	return txn
+}
+
+// filterPendingEvents returns the events that match the given options.
+func filterPendingEvents(events []wallet.Event, opts api.WalletPendingOpts) []wallet.Event {
+	if len(opts.Types) == 0 && opts.MinAmount.IsZero() {
+		return events
+	}
+
+	eventTypes := make(map[string]struct{})
+	for _, typ := range opts.Types {
+		eventTypes[typ] = struct{}{}
+	}
+
+	filtered := make([]wallet.Event, 0, len(events))
+	for _, event := range events {
+		if _, ok := eventTypes[event.Type]; len(eventTypes) > 0 && !ok {
+			continue
+		} else if event.SiacoinInflow().Cmp(opts.MinAmount) < 0 && event.SiacoinOutflow().Cmp(opts.MinAmount) < 0 {
+			continue
+		}
+		filtered = append(filtered, event)
+	}
+	return filtered
+}
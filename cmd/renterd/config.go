@@ -16,6 +16,7 @@ import (
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/renterd/v2/autopilot/contractor"
 	"go.sia.tech/renterd/v2/config"
 	"golang.org/x/term"
 )
@@ -47,8 +48,9 @@ var cfg = config.Config{
 	AutoOpenWebUI: true,
 	Network:       "mainnet",
 	HTTP: config.HTTP{
-		Address:  "localhost:9980",
-		Password: os.Getenv(apiPasswordEnvVar),
+		Address:                   "localhost:9980",
+		Password:                  os.Getenv(apiPasswordEnvVar),
+		APIKeyRotationGracePeriod: time.Hour,
 	},
 	ShutdownTimeout: 5 * time.Minute,
 	Database: config.Database{
@@ -80,9 +82,21 @@ var cfg = config.Config{
 		},
 	},
 	Bus: config.Bus{
-		AnnouncementMaxAgeHours:       24 * 7 * 52, // 1 year
-		Bootstrap:                     true,
+		AnnouncementMaxAgeHours: 24 * 7 * 52, // 1 year
+		Bootstrap:               true,
+		DBWriteRetry: config.DBWriteRetryPolicy{
+			MaxAttempts: 3,
+			BackoffBase: 100 * time.Millisecond,
+		},
+		ExemptActiveContractHosts:     true,
 		GatewayAddr:                   ":9981",
+		GougingCacheTTL:               5 * time.Minute,
+		MaxStoredAlerts:               10000,
+		HostPruneSafetyMultiplier:     3,
+		MaxHostsPerPruneBatch:         100,
+		ObjectVersionGCInterval:       time.Hour,
+		PendingContractTimeoutBlocks:  1008, // 1 week
+		RHP4PoolSize:                  50 * runtime.NumCPU(),
 		UsedUTXOExpiry:                3 * time.Hour,
 		SlabBufferCompletionThreshold: 1 << 12,
 	},
@@ -93,6 +107,7 @@ var cfg = config.Config{
 		AccountsRefillInterval: defaultAccountRefillInterval,
 		BusFlushInterval:       5 * time.Second,
 		CacheExpiry:            5 * time.Minute,
+		ObjectCacheTTL:         30 * time.Second,
 
 		DownloadMaxOverdrive:     5,
 		DownloadOverdriveTimeout: 3 * time.Second,
@@ -101,6 +116,10 @@ var cfg = config.Config{
 		UploadMaxMemory:        1 << 30, // 1 GiB
 		UploadMaxOverdrive:     5,
 		UploadOverdriveTimeout: 3 * time.Second,
+
+		UploadSectorFilterFPRate: 1e-9,
+
+		ConnPrewarmInterval: 10 * time.Minute,
 	},
 	Autopilot: config.Autopilot{
 		Enabled: true,
@@ -110,17 +129,23 @@ var cfg = config.Config{
 		MigratorAccountsRefillInterval:   defaultAccountRefillInterval,
 		MigratorHealthCutoff:             0.75,
 		MigratorNumThreads:               4,
+		MigratorRepairHealthThreshold:    0.5,
 		MigratorDownloadMaxOverdrive:     5,
 		MigratorDownloadOverdriveTimeout: 3 * time.Second,
 		MigratorUploadMaxOverdrive:       5,
 		MigratorUploadOverdriveTimeout:   3 * time.Second,
 
+		PruneParallelism: 1,
+
 		RevisionBroadcastInterval: 7 * 24 * time.Hour,
 		RevisionSubmissionBuffer:  150, // 144 + 6 blocks leeway
 
-		ScannerBatchSize:  100,
-		ScannerInterval:   4 * time.Hour,
-		ScannerNumThreads: 10,
+		ScannerBatchSize:            100,
+		ScannerInterval:             4 * time.Hour,
+		ScannerNumThreads:           10,
+		ScannerPriceChangeThreshold: 2,
+
+		WalletOutputAmount: contractor.InitialContractFunding,
 	},
 	S3: config.S3{
 		Address:     "localhost:8080",
@@ -199,6 +224,7 @@ func sanitizeConfig() error {
 func parseCLIFlags() {
 	// node
 	flag.StringVar(&cfg.HTTP.Address, "http", cfg.HTTP.Address, "Address for serving the API")
+	flag.DurationVar(&cfg.HTTP.APIKeyRotationGracePeriod, "http.apiKeyRotationGracePeriod", cfg.HTTP.APIKeyRotationGracePeriod, "Time the previous API key remains valid after rotating the key")
 	flag.StringVar(&cfg.Directory, "dir", cfg.Directory, "Directory for storing node state")
 	flag.BoolVar(&disableStdin, "env", false, "disable stdin prompts for environment variables (default false)")
 	flag.BoolVar(&cfg.AutoOpenWebUI, "openui", cfg.AutoOpenWebUI, "automatically open the web UI on startup")
@@ -222,12 +248,30 @@ func parseCLIFlags() {
 	flag.StringVar(&cfg.Database.MySQL.User, "db.user", cfg.Database.MySQL.User, "Database username for the bus (overrides with RENTERD_DB_USER)")
 	flag.StringVar(&cfg.Database.MySQL.Database, "db.name", cfg.Database.MySQL.Database, "Database name for the bus (overrides with RENTERD_DB_NAME)")
 	flag.StringVar(&cfg.Database.MySQL.MetricsDatabase, "db.metricsName", cfg.Database.MySQL.MetricsDatabase, "Database for metrics (overrides with RENTERD_DB_METRICS_NAME)")
+	flag.DurationVar(&cfg.Database.TxTimeout, "db.txTimeout", cfg.Database.TxTimeout, "Maximum duration of a database transaction before it is rolled back, 0 disables the timeout")
+	flag.IntVar(&cfg.Database.Pool.MaxOpenConns, "db.pool.maxOpenConns", cfg.Database.Pool.MaxOpenConns, "Maximum number of open connections per database, 0 means unlimited")
+	flag.IntVar(&cfg.Database.Pool.MaxIdleConns, "db.pool.maxIdleConns", cfg.Database.Pool.MaxIdleConns, "Maximum number of idle connections per database, 0 uses the driver default")
+	flag.DurationVar(&cfg.Database.Pool.ConnMaxLifetime, "db.pool.connMaxLifetime", cfg.Database.Pool.ConnMaxLifetime, "Maximum amount of time a connection may be reused, 0 means forever")
+	flag.DurationVar(&cfg.Database.Pool.ConnMaxIdleTime, "db.pool.connMaxIdleTime", cfg.Database.Pool.ConnMaxIdleTime, "Maximum amount of time a connection may be idle before it is closed, 0 means forever")
 
 	// bus
 	flag.BoolVar(&cfg.Bus.AllowPrivateIPs, "bus.allowPrivateIPs", cfg.Bus.AllowPrivateIPs, "Allows hosts with private IPs")
 	flag.Uint64Var(&cfg.Bus.AnnouncementMaxAgeHours, "bus.announcementMaxAgeHours", cfg.Bus.AnnouncementMaxAgeHours, "Max age for announcements")
 	flag.BoolVar(&cfg.Bus.Bootstrap, "bus.bootstrap", cfg.Bus.Bootstrap, "Bootstraps gateway and consensus modules")
+	flag.BoolVar(&cfg.Bus.ExemptActiveContractHosts, "bus.exemptActiveContractHosts", cfg.Bus.ExemptActiveContractHosts, "Prevents offline hosts with pending or active contracts from being removed")
 	flag.StringVar(&cfg.Bus.GatewayAddr, "bus.gatewayAddr", cfg.Bus.GatewayAddr, "Address for Sia peer connections (overrides with RENTERD_BUS_GATEWAY_ADDR)")
+	flag.IntVar(&cfg.Bus.HostPruneSafetyMultiplier, "bus.hostPruneSafetyMultiplier", cfg.Bus.HostPruneSafetyMultiplier, "Offline host pruning halts until confirmed when more than this many batches of hosts are eligible for removal, 0 disables the check")
+	flag.IntVar(&cfg.Bus.MaxHostsPerPruneBatch, "bus.maxHostsPerPruneBatch", cfg.Bus.MaxHostsPerPruneBatch, "Max number of offline hosts removed per pruning run, 0 means no limit")
+	flag.IntVar(&cfg.Bus.MaxHostSectorPrunePerRun, "bus.maxHostSectorPrunePerRun", cfg.Bus.MaxHostSectorPrunePerRun, "Max number of host sectors pruned per run of the prune loop, 0 means no limit")
+	flag.DurationVar(&cfg.Bus.MinAlertInterval, "bus.minAlertInterval", cfg.Bus.MinAlertInterval, "Minimum interval between registrations of the same alert, 0 disables throttling")
+	flag.IntVar(&cfg.Bus.MaxStoredAlerts, "bus.maxStoredAlerts", cfg.Bus.MaxStoredAlerts, "Maximum number of alerts stored by the bus, the oldest non-critical alert is removed when it is reached, 0 disables the limit")
+	flag.DurationVar(&cfg.Bus.ObjectVersionGCInterval, "bus.objectVersionGCInterval", cfg.Bus.ObjectVersionGCInterval, "Interval at which object versions of copy-on-write buckets that exceeded their retention are deleted, 0 disables the background garbage collection")
+	flag.Uint64Var(&cfg.Bus.MaxRevisionGap, "bus.maxRevisionGap", cfg.Bus.MaxRevisionGap, "Max number of revisions the host's revision of a contract may be ahead of the stored revision for the contract to be pruned, 0 disables the check")
+	flag.IntVar(&cfg.Bus.DBWriteRetry.MaxAttempts, "bus.dbWriteRetry.maxAttempts", cfg.Bus.DBWriteRetry.MaxAttempts, "Max number of attempts of critical database writes that fail because the database is locked")
+	flag.DurationVar(&cfg.Bus.DBWriteRetry.BackoffBase, "bus.dbWriteRetry.backoffBase", cfg.Bus.DBWriteRetry.BackoffBase, "Base backoff between attempts of critical database writes, doubled after every attempt")
+	flag.DurationVar(&cfg.Bus.GougingCacheTTL, "bus.gougingCacheTTL", cfg.Bus.GougingCacheTTL, "Duration host settings and their gouging check are cached for, 0 disables the cache")
+	flag.Uint64Var(&cfg.Bus.PendingContractTimeoutBlocks, "bus.pendingContractTimeoutBlocks", cfg.Bus.PendingContractTimeoutBlocks, "Number of blocks after which a contract that is still pending is marked as failed, 0 disables the check")
+	flag.IntVar(&cfg.Bus.RHP4PoolSize, "bus.rhp4PoolSize", cfg.Bus.RHP4PoolSize, "Max number of concurrent RHP4 operations performed by the bus, 0 means no limit")
 	flag.DurationVar(&cfg.Bus.UsedUTXOExpiry, "bus.usedUTXOExpiry", cfg.Bus.UsedUTXOExpiry, "Expiry for used UTXOs in transactions")
 	flag.Int64Var(&cfg.Bus.SlabBufferCompletionThreshold, "bus.slabBufferCompletionThreshold", cfg.Bus.SlabBufferCompletionThreshold, "Threshold for slab buffer upload (overrides with RENTERD_BUS_SLAB_BUFFER_COMPLETION_THRESHOLD)")
 
@@ -238,23 +282,35 @@ func parseCLIFlags() {
 	flag.Uint64Var(&cfg.Worker.DownloadMaxOverdrive, "worker.downloadMaxOverdrive", cfg.Worker.DownloadMaxOverdrive, "Max overdrive workers for downloads")
 	flag.StringVar(&cfg.Worker.ID, "worker.id", cfg.Worker.ID, "Unique ID for worker (overrides with RENTERD_WORKER_ID)")
 	flag.DurationVar(&cfg.Worker.DownloadOverdriveTimeout, "worker.downloadOverdriveTimeout", cfg.Worker.DownloadOverdriveTimeout, "Timeout for overdriving slab downloads")
+	flag.DurationVar(&cfg.Worker.MaxLastOperationAge, "worker.maxLastOperationAge", cfg.Worker.MaxLastOperationAge, "Max age of the last successful upload or download before the worker reports as not ready, 0 disables the check")
+	flag.IntVar(&cfg.Worker.MinUploadConfirmations, "worker.minUploadConfirmations", cfg.Worker.MinUploadConfirmations, "Number of hosts that have to confirm a slab upload, defaults to TotalShards and is bounded by MinShards and TotalShards")
 	flag.Uint64Var(&cfg.Worker.UploadMaxMemory, "worker.uploadMaxMemory", cfg.Worker.UploadMaxMemory, "Max amount of RAM the worker allocates for slabs when uploading (overrides with RENTERD_WORKER_UPLOAD_MAX_MEMORY)")
 	flag.Uint64Var(&cfg.Worker.UploadMaxOverdrive, "worker.uploadMaxOverdrive", cfg.Worker.UploadMaxOverdrive, "Max overdrive workers for uploads")
 	flag.DurationVar(&cfg.Worker.UploadOverdriveTimeout, "worker.uploadOverdriveTimeout", cfg.Worker.UploadOverdriveTimeout, "Timeout for overdriving slab uploads")
+	flag.DurationVar(&cfg.Worker.SlabUploadTimeout, "worker.slabUploadTimeout", cfg.Worker.SlabUploadTimeout, "Timeout for uploading all shards of a slab, 0 derives the timeout from the slab size")
+	flag.Uint64Var(&cfg.Worker.UploadSectorFilterCapacity, "worker.uploadSectorFilterCapacity", cfg.Worker.UploadSectorFilterCapacity, "Number of recently uploaded sectors the worker remembers to skip duplicate uploads, 0 disables the filter")
+	flag.Float64Var(&cfg.Worker.UploadSectorFilterFPRate, "worker.uploadSectorFilterFPRate", cfg.Worker.UploadSectorFilterFPRate, "False positive rate of the uploaded sectors filter, a false positive costs a sector verification on the host")
+	flag.IntVar(&cfg.Worker.PrewarmHostConnections, "worker.prewarmHostConnections", cfg.Worker.PrewarmHostConnections, "Number of hosts, ranked by contract count, the worker keeps idle connections to, 0 disables prewarming")
+	flag.DurationVar(&cfg.Worker.ConnPrewarmInterval, "worker.connPrewarmInterval", cfg.Worker.ConnPrewarmInterval, "Interval at which prewarmed host connections are replaced")
+	flag.DurationVar(&cfg.Worker.ObjectCacheTTL, "worker.objectCacheTTL", cfg.Worker.ObjectCacheTTL, "Duration object metadata is cached for HEAD requests, 0 disables the cache")
 	flag.BoolVar(&cfg.Worker.Enabled, "worker.enabled", cfg.Worker.Enabled, "Enables/disables worker (overrides with RENTERD_WORKER_ENABLED)")
 	flag.BoolVar(&cfg.Worker.AllowUnauthenticatedDownloads, "worker.unauthenticatedDownloads", cfg.Worker.AllowUnauthenticatedDownloads, "Allows unauthenticated downloads (overrides with RENTERD_WORKER_UNAUTHENTICATED_DOWNLOADS)")
 
 	// autopilot
 	flag.DurationVar(&cfg.Autopilot.Heartbeat, "autopilot.heartbeat", cfg.Autopilot.Heartbeat, "Interval for autopilot loop execution")
 	flag.DurationVar(&cfg.Autopilot.RevisionBroadcastInterval, "autopilot.revisionBroadcastInterval", cfg.Autopilot.RevisionBroadcastInterval, "Interval for broadcasting contract revisions (overrides with RENTERD_AUTOPILOT_REVISION_BROADCAST_INTERVAL)")
+	flag.Uint64Var(&cfg.Autopilot.PruneParallelism, "autopilot.pruneParallelism", cfg.Autopilot.PruneParallelism, "Number of contracts pruned concurrently")
 	flag.Uint64Var(&cfg.Autopilot.ScannerBatchSize, "autopilot.scannerBatchSize", cfg.Autopilot.ScannerBatchSize, "Batch size for host scanning")
 	flag.DurationVar(&cfg.Autopilot.ScannerInterval, "autopilot.scannerInterval", cfg.Autopilot.ScannerInterval, "Interval for scanning hosts")
 	flag.Uint64Var(&cfg.Autopilot.ScannerNumThreads, "autopilot.scannerNumThreads", cfg.Autopilot.ScannerNumThreads, "Number of threads for scanning hosts")
+	flag.Float64Var(&cfg.Autopilot.ScannerPriceChangeThreshold, "autopilot.scannerPriceChangeThreshold", cfg.Autopilot.ScannerPriceChangeThreshold, "Factor by which a host's storage price has to increase between scans to register an alert, 0 disables the alert")
+	flag.TextVar(&cfg.Autopilot.WalletOutputAmount, "autopilot.walletOutputAmount", cfg.Autopilot.WalletOutputAmount, "Value of the outputs the wallet is redistributed into, e.g. '10 SC'")
 	flag.BoolVar(&cfg.Autopilot.Enabled, "autopilot.enabled", cfg.Autopilot.Enabled, "Enables/disables autopilot (overrides with RENTERD_AUTOPILOT_ENABLED)")
 	flag.DurationVar(&cfg.ShutdownTimeout, "node.shutdownTimeout", cfg.ShutdownTimeout, "Timeout for node shutdown")
 
 	flag.DurationVar(&cfg.Autopilot.MigratorAccountsRefillInterval, "autopilot.migratorAccountRefillInterval", cfg.Autopilot.MigratorAccountsRefillInterval, "Interval for refilling migrator' account balances")
 	flag.Float64Var(&cfg.Autopilot.MigratorHealthCutoff, "autopilot.migratorHealthCutoff", cfg.Autopilot.MigratorHealthCutoff, "Threshold for migrating slabs based on health")
+	flag.Float64Var(&cfg.Autopilot.MigratorRepairHealthThreshold, "autopilot.migratorRepairHealthThreshold", cfg.Autopilot.MigratorRepairHealthThreshold, "Fraction of healthy shards below which slabs are migrated before all other slabs")
 	flag.Uint64Var(&cfg.Autopilot.MigratorNumThreads, "autopilot.migratorNumThreads", cfg.Autopilot.MigratorNumThreads, "Parallel slab migrations per worker (overrides with RENTERD_MIGRATOR_PARALLEL_SLABS_PER_WORKER)")
 	flag.Uint64Var(&cfg.Autopilot.MigratorDownloadMaxOverdrive, "autopilot.migratorDownloadMaxOverdrive", cfg.Autopilot.MigratorDownloadMaxOverdrive, "Max overdrive workers for migration downloads")
 	flag.DurationVar(&cfg.Autopilot.MigratorDownloadOverdriveTimeout, "autopilot.migratorDownloadOverdriveTimeout", cfg.Autopilot.MigratorDownloadOverdriveTimeout, "Timeout for overdriving migration downloads")
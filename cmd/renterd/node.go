@@ -130,7 +130,11 @@ func newNode(cfg config.Config, configPath string, network *consensus.Network, g
 
 	// initialise auth handler
 	tokens := api.NewTokenStore()
-	auth := api.Auth(tokens, cfg.HTTP.Password)
+	keys, err := api.LoadKeyStore(cfg.HTTP.Password, filepath.Join(cfg.Directory, "apikeys.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load api keys: %w", err)
+	}
+	auth := api.Auth(tokens, keys)
 
 	// generate private key from seed
 	var pk types.PrivateKey
@@ -143,9 +147,10 @@ func newNode(cfg config.Config, configPath string, network *consensus.Network, g
 		pk = cwallet.KeyFromSeed(&rawSeed, 0)
 	}
 
-	// add auth route
+	// add auth routes
 	if cfg.HTTP.Password != "" {
-		mux.Sub["/api/auth"] = api.TreeMux{Handler: api.AuthHandler(tokens, cfg.HTTP.Password)}
+		mux.Sub["/api/auth"] = api.TreeMux{Handler: api.AuthHandler(tokens, keys)}
+		mux.Sub["/api/admin/apikeys/rotate"] = api.TreeMux{Handler: api.RotateKeyHandler(tokens, keys, cfg.HTTP.APIKeyRotationGracePeriod)}
 	}
 
 	// initialise bus
@@ -164,7 +169,7 @@ func newNode(cfg config.Config, configPath string, network *consensus.Network, g
 
 		mux.Sub["/api/bus"] = api.TreeMux{Handler: auth(b.Handler())}
 		busAddr = cfg.HTTP.Address + "/api/bus"
-		busPassword = cfg.HTTP.Password
+		busPassword = keys.InternalKey()
 
 		// only serve the UI if a bus is created
 		mux.Handler = renterd.Handler()
@@ -187,7 +192,7 @@ func newNode(cfg config.Config, configPath string, network *consensus.Network, g
 			fn:   w.Shutdown,
 		})
 
-		mux.Sub["/api/worker"] = api.TreeMux{Handler: api.WorkerAuth(tokens, cfg.HTTP.Password, cfg.Worker.AllowUnauthenticatedDownloads)(w.Handler())}
+		mux.Sub["/api/worker"] = api.TreeMux{Handler: api.WorkerAuth(tokens, keys, cfg.Worker.AllowUnauthenticatedDownloads)(w.Handler())}
 
 		if cfg.S3.Enabled {
 			s3Handler, err := s3.New(bc, w, logger, s3.Opts{
@@ -257,28 +262,36 @@ func newAutopilot(masterKey [32]byte, cfg config.Autopilot, bus *bus.Client, l *
 	l = l.Named("autopilot")
 
 	ctx, cancel := context.WithCancelCause(context.Background())
-	m, err := migrator.New(ctx, masterKey, a, bus, bus, cfg.MigratorHealthCutoff, cfg.MigratorNumThreads, cfg.MigratorDownloadMaxOverdrive, cfg.MigratorUploadMaxOverdrive, cfg.MigratorDownloadOverdriveTimeout, cfg.MigratorUploadOverdriveTimeout, cfg.MigratorAccountsRefillInterval, l)
+	m, err := migrator.New(ctx, masterKey, a, bus, bus, cfg.MigratorHealthCutoff, cfg.MigratorRepairHealthThreshold, cfg.MigratorNumThreads, cfg.MigratorDownloadMaxOverdrive, cfg.MigratorUploadMaxOverdrive, cfg.MigratorDownloadOverdriveTimeout, cfg.MigratorUploadOverdriveTimeout, cfg.MigratorAccountsRefillInterval, l)
 	if err != nil {
 		cancel(nil)
 		return nil, err
 	}
 
-	s, err := scanner.New(bus, cfg.ScannerBatchSize, cfg.ScannerNumThreads, cfg.ScannerInterval, l)
+	s, err := scanner.New(a, bus, cfg.ScannerBatchSize, cfg.ScannerNumThreads, cfg.ScannerInterval, cfg.ScannerPriceChangeThreshold, l)
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+
+	p, err := pruner.New(a, bus, cfg.PruneParallelism, l)
 	if err != nil {
 		cancel(nil)
 		return nil, err
 	}
 
 	c := contractor.New(bus, bus, bus, bus, bus, cfg.RevisionSubmissionBuffer, cfg.RevisionBroadcastInterval, cfg.AllowRedundantHostIPs, l)
-	p := pruner.New(bus, l)
-	w := walletmaintainer.New(a, bus, l)
+	w := walletmaintainer.New(a, bus, l, walletmaintainer.WithOutputAmount(cfg.WalletOutputAmount))
 
-	return autopilot.New(ctx, cancel, bus, c, m, p, s, w, cfg.Heartbeat, l), nil
+	return autopilot.New(ctx, cancel, a, bus, c, m, p, s, w, cfg.Heartbeat, l), nil
 }
 
 func newBus(cfg config.Config, pk types.PrivateKey, network *consensus.Network, genesis types.Block, logger *zap.Logger) (*bus.Bus, func(ctx context.Context) error, error) {
 	// create store
-	alertsMgr := alerts.NewManager()
+	alertsMgr, alertsShutdown, err := buildAlerter(cfg.Bus, logger)
+	if err != nil {
+		return nil, nil, err
+	}
 	storeCfg, err := buildStoreConfig(alertsMgr, cfg, pk, logger)
 	if err != nil {
 		return nil, nil, err
@@ -398,6 +411,7 @@ func newBus(cfg config.Config, pk types.PrivateKey, network *consensus.Network,
 			s.Close(),
 			w.Close(),
 			b.Shutdown(ctx),
+			alertsShutdown(ctx),
 			sqlStore.Close(),
 			bdb.Close(),
 			syncerShutdown(ctx),
@@ -471,6 +485,32 @@ func (n *node) Shutdown() error {
 }
 
 // TODO: needs a better spot
+func buildAlerter(cfg config.Bus, logger *zap.Logger) (alerts.Alerter, func(context.Context) error, error) {
+	mgr := alerts.NewManager(alerts.Config{
+		MinAlertInterval: cfg.MinAlertInterval,
+		MaxStoredAlerts:  cfg.MaxStoredAlerts,
+		Logger:           logger,
+	})
+	if len(cfg.Alerters) == 0 {
+		return mgr, func(context.Context) error { return nil }, nil
+	}
+
+	var backends []alerts.Backend
+	for _, ac := range cfg.Alerters {
+		backend, err := alerts.NewBackend(alerts.AlerterConfig{
+			Type:       ac.Type,
+			URL:        ac.URL,
+			RoutingKey: ac.RoutingKey,
+		}, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create alerter: %w", err)
+		}
+		backends = append(backends, backend)
+	}
+	ma := alerts.NewMultiAlerter(mgr, logger, backends...)
+	return ma, ma.Shutdown, nil
+}
+
 func buildStoreConfig(am alerts.Alerter, cfg config.Config, pk types.PrivateKey, logger *zap.Logger) (stores.Config, error) {
 	partialSlabDir := filepath.Join(cfg.Directory, "partial_slabs")
 
@@ -547,7 +587,16 @@ func buildStoreConfig(am alerts.Alerter, cfg config.Config, pk types.PrivateKey,
 		Logger:                        logger,
 		WalletAddress:                 types.StandardUnlockHash(pk.PublicKey()),
 		LongQueryDuration:             cfg.Log.Database.SlowThreshold,
-		LongTxDuration:                cfg.Log.Database.SlowThreshold,
+		LongTxDuration:                cfg.Database.TxTimeout,
+		MaxHostSectorPrunePerRun:      cfg.Bus.MaxHostSectorPrunePerRun,
+		ExemptActiveContractHosts:     cfg.Bus.ExemptActiveContractHosts,
+		ObjectVersionGCInterval:       cfg.Bus.ObjectVersionGCInterval,
+		Pool: stores.PoolConfig{
+			MaxOpenConns:    cfg.Database.Pool.MaxOpenConns,
+			MaxIdleConns:    cfg.Database.Pool.MaxIdleConns,
+			ConnMaxLifetime: cfg.Database.Pool.ConnMaxLifetime,
+			ConnMaxIdleTime: cfg.Database.Pool.ConnMaxIdleTime,
+		},
 	}, nil
 }
 
@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"go.sia.tech/core/types"
 	"gopkg.in/yaml.v3"
 )
 
@@ -42,6 +43,10 @@ type (
 	HTTP struct {
 		Address  string `yaml:"address,omitempty"`
 		Password string `yaml:"password,omitempty"`
+
+		// APIKeyRotationGracePeriod is the time the previous API key
+		// remains valid after the key was rotated.
+		APIKeyRotationGracePeriod time.Duration `yaml:"apiKeyRotationGracePeriod,omitempty"`
 	}
 
 	DatabaseLog struct {
@@ -54,18 +59,68 @@ type (
 	Database struct {
 		// optional fields depending on backend
 		MySQL MySQL `yaml:"mysql,omitempty"`
+
+		Pool DatabasePool `yaml:"pool,omitempty"`
+
+		// TxTimeout is the maximum duration of a transaction attempt,
+		// attempts that take longer are rolled back. Attempts retried
+		// because the database was locked get a fresh timeout. Zero
+		// disables the timeout.
+		TxTimeout time.Duration `yaml:"txTimeout,omitempty"`
+	}
+
+	// DatabasePool contains the connection pool settings that are applied to
+	// both the main and the metrics database. Zero values leave the defaults
+	// of the database driver in place.
+	DatabasePool struct {
+		MaxOpenConns    int           `yaml:"maxOpenConns,omitempty"`
+		MaxIdleConns    int           `yaml:"maxIdleConns,omitempty"`
+		ConnMaxLifetime time.Duration `yaml:"connMaxLifetime,omitempty"`
+		ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime,omitempty"`
+	}
+
+	// DBWriteRetryPolicy configures how the bus retries critical database
+	// writes that fail because the database is locked. It replaces the
+	// default retry behaviour of the SQL layer for those writes, a MaxAttempts
+	// of zero keeps the default.
+	DBWriteRetryPolicy struct {
+		MaxAttempts int           `yaml:"maxAttempts,omitempty"`
+		BackoffBase time.Duration `yaml:"backoffBase,omitempty"`
 	}
 
 	// Bus contains the configuration for a bus.
 	Bus struct {
-		AllowPrivateIPs               bool          `yaml:"allowPrivateIPs,omitempty"`
-		AnnouncementMaxAgeHours       uint64        `yaml:"announcementMaxAgeHours,omitempty"`
-		Bootstrap                     bool          `yaml:"bootstrap,omitempty"`
-		GatewayAddr                   string        `yaml:"gatewayAddr,omitempty"`
-		RemoteAddr                    string        `yaml:"remoteAddr,omitempty"`
-		RemotePassword                string        `yaml:"remotePassword,omitempty"`
-		UsedUTXOExpiry                time.Duration `yaml:"usedUtxoExpiry,omitempty"`
-		SlabBufferCompletionThreshold int64         `yaml:"slabBufferCompleionThreshold,omitempty"`
+		Alerters                      []Alerter          `yaml:"alerters,omitempty"`
+		AllowPrivateIPs               bool               `yaml:"allowPrivateIPs,omitempty"`
+		AnnouncementMaxAgeHours       uint64             `yaml:"announcementMaxAgeHours,omitempty"`
+		Bootstrap                     bool               `yaml:"bootstrap,omitempty"`
+		DBWriteRetry                  DBWriteRetryPolicy `yaml:"dbWriteRetry,omitempty"`
+		ExemptActiveContractHosts     bool               `yaml:"exemptActiveContractHosts,omitempty"`
+		GatewayAddr                   string             `yaml:"gatewayAddr,omitempty"`
+		GougingCacheTTL               time.Duration      `yaml:"gougingCacheTTL,omitempty"`
+		HostPruneSafetyMultiplier     int                `yaml:"hostPruneSafetyMultiplier,omitempty"`
+		MaxHostsPerPruneBatch         int                `yaml:"maxHostsPerPruneBatch,omitempty"`
+		MaxHostSectorPrunePerRun      int                `yaml:"maxHostSectorPrunePerRun,omitempty"`
+		MaxRevisionGap                uint64             `yaml:"maxRevisionGap,omitempty"`
+		MaxStoredAlerts               int                `yaml:"maxStoredAlerts,omitempty"`
+		MinAlertInterval              time.Duration      `yaml:"minAlertInterval,omitempty"`
+		ObjectVersionGCInterval       time.Duration      `yaml:"objectVersionGCInterval,omitempty"`
+		PendingContractTimeoutBlocks  uint64             `yaml:"pendingContractTimeoutBlocks,omitempty"`
+		RemoteAddr                    string             `yaml:"remoteAddr,omitempty"`
+		RemotePassword                string             `yaml:"remotePassword,omitempty"`
+		RHP4PoolSize                  int                `yaml:"rhp4PoolSize,omitempty"`
+		UsedUTXOExpiry                time.Duration      `yaml:"usedUtxoExpiry,omitempty"`
+		SlabBufferCompletionThreshold int64              `yaml:"slabBufferCompleionThreshold,omitempty"`
+	}
+
+	// Alerter configures a backend the bus forwards alerts to in addition to
+	// storing them. Type is either 'log', 'webhook' or 'pagerduty', URL is
+	// the url of a webhook and RoutingKey the integration key of a PagerDuty
+	// service.
+	Alerter struct {
+		Type       string `yaml:"type,omitempty"`
+		URL        string `yaml:"url,omitempty"`
+		RoutingKey string `yaml:"routingKey,omitempty"`
 	}
 
 	// LogFile configures the file output of the logger.
@@ -129,25 +184,37 @@ type (
 		UploadMaxOverdrive            uint64        `yaml:"uploadMaxOverdrive,omitempty"`
 		AllowUnauthenticatedDownloads bool          `yaml:"allowUnauthenticatedDownloads,omitempty"`
 		CacheExpiry                   time.Duration `yaml:"cacheExpiry,omitempty"`
+		MaxLastOperationAge           time.Duration `yaml:"maxLastOperationAge,omitempty"`
+		MinUploadConfirmations        int           `yaml:"minUploadConfirmations,omitempty"`
+		ObjectCacheTTL                time.Duration `yaml:"objectCacheTTL,omitempty"`
+		SlabUploadTimeout             time.Duration `yaml:"slabUploadTimeout,omitempty"`
+		UploadSectorFilterCapacity    uint64        `yaml:"uploadSectorFilterCapacity,omitempty"`
+		UploadSectorFilterFPRate      float64       `yaml:"uploadSectorFilterFPRate,omitempty"`
+		PrewarmHostConnections        int           `yaml:"prewarmHostConnections,omitempty"`
+		ConnPrewarmInterval           time.Duration `yaml:"connPrewarmInterval,omitempty"`
 	}
 
 	// Autopilot contains the configuration for an autopilot.
 	Autopilot struct {
-		Enabled                          bool          `yaml:"enabled,omitempty"`
-		AllowRedundantHostIPs            bool          `yaml:"allowRedundantHostIPs,omitempty"`
-		Heartbeat                        time.Duration `yaml:"heartbeat,omitempty"`
-		MigratorAccountsRefillInterval   time.Duration `yaml:"migratorAccountsRefillInterval,omitempty"`
-		MigratorDownloadMaxOverdrive     uint64        `yaml:"migratorDownloadMaxOverdrive,omitempty"`
-		MigratorDownloadOverdriveTimeout time.Duration `yaml:"migratorDownloadOverdriveTimeout,omitempty"`
-		MigratorHealthCutoff             float64       `yaml:"migratorHealthCutoff,omitempty"`
-		MigratorNumThreads               uint64        `yaml:"migratorNumThreads,omitempty"`
-		MigratorUploadMaxOverdrive       uint64        `yaml:"migratorUploadMaxOverdrive,omitempty"`
-		MigratorUploadOverdriveTimeout   time.Duration `yaml:"migratorUploadOverdriveTimeout,omitempty"`
-		RevisionBroadcastInterval        time.Duration `yaml:"revisionBroadcastInterval,omitempty"`
-		RevisionSubmissionBuffer         uint64        `yaml:"revisionSubmissionBuffer,omitempty"`
-		ScannerInterval                  time.Duration `yaml:"scannerInterval,omitempty"`
-		ScannerBatchSize                 uint64        `yaml:"scannerBatchSize,omitempty"`
-		ScannerNumThreads                uint64        `yaml:"scannerNumThreads,omitempty"`
+		Enabled                          bool           `yaml:"enabled,omitempty"`
+		AllowRedundantHostIPs            bool           `yaml:"allowRedundantHostIPs,omitempty"`
+		Heartbeat                        time.Duration  `yaml:"heartbeat,omitempty"`
+		MigratorAccountsRefillInterval   time.Duration  `yaml:"migratorAccountsRefillInterval,omitempty"`
+		MigratorDownloadMaxOverdrive     uint64         `yaml:"migratorDownloadMaxOverdrive,omitempty"`
+		MigratorDownloadOverdriveTimeout time.Duration  `yaml:"migratorDownloadOverdriveTimeout,omitempty"`
+		MigratorHealthCutoff             float64        `yaml:"migratorHealthCutoff,omitempty"`
+		MigratorNumThreads               uint64         `yaml:"migratorNumThreads,omitempty"`
+		MigratorRepairHealthThreshold    float64        `yaml:"migratorRepairHealthThreshold,omitempty"`
+		MigratorUploadMaxOverdrive       uint64         `yaml:"migratorUploadMaxOverdrive,omitempty"`
+		MigratorUploadOverdriveTimeout   time.Duration  `yaml:"migratorUploadOverdriveTimeout,omitempty"`
+		PruneParallelism                 uint64         `yaml:"pruneParallelism,omitempty"`
+		RevisionBroadcastInterval        time.Duration  `yaml:"revisionBroadcastInterval,omitempty"`
+		RevisionSubmissionBuffer         uint64         `yaml:"revisionSubmissionBuffer,omitempty"`
+		ScannerInterval                  time.Duration  `yaml:"scannerInterval,omitempty"`
+		ScannerBatchSize                 uint64         `yaml:"scannerBatchSize,omitempty"`
+		ScannerNumThreads                uint64         `yaml:"scannerNumThreads,omitempty"`
+		ScannerPriceChangeThreshold      float64        `yaml:"scannerPriceChangeThreshold,omitempty"`
+		WalletOutputAmount               types.Currency `yaml:"walletOutputAmount,omitempty"`
 	}
 )
 
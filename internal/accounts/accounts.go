@@ -644,10 +644,12 @@ func newAccountRefillAlert(id rhpv4.Account, contract api.ContractMetadata, err
 	}
 
 	return alerts.Alert{
-		ID:        alerts.IDForAccount(alertAccountRefillID, id),
-		Severity:  alerts.SeverityError,
-		Message:   "Ephemeral account refill failed",
-		Data:      data,
-		Timestamp: time.Now(),
+		ID:          alerts.IDForAccount(alertAccountRefillID, id),
+		Severity:    alerts.SeverityError,
+		Message:     "Ephemeral account refill failed",
+		Description: fmt.Sprintf("Refilling ephemeral account %v with host %v using contract %v failed: %v", id, contract.HostKey, contract.ID, err),
+		Suggestion:  "Refill failures are usually caused by the host being offline or the contract running out of funds, the contract will be refreshed during the next contract maintenance.",
+		Data:        data,
+		Timestamp:   time.Now(),
 	}
 }
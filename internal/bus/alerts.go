@@ -4,18 +4,56 @@ import (
 	"fmt"
 	"time"
 
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/alerts"
+	"go.sia.tech/renterd/v2/api"
 )
 
 var (
-	alertPricePinningID = alerts.RandomAlertID() // constant until restarted
+	alertExpiredContractFailedID = alerts.RandomAlertID() // constant until restarted
+	alertPendingContractFailedID = alerts.RandomAlertID() // constant until restarted
+	alertPricePinningID          = alerts.RandomAlertID() // constant until restarted
+	alertRevisionMismatchID      = alerts.RandomAlertID() // constant until restarted
 )
 
+func newExpiredContractFailedAlert(fcid types.FileContractID, height uint64) alerts.Alert {
+	return alerts.Alert{
+		ID:          alerts.IDForContract(alertExpiredContractFailedID, fcid),
+		Severity:    alerts.SeverityWarning,
+		Message:     "Expired contract failed",
+		Description: fmt.Sprintf("Contract %v expired at height %d without a storage proof being submitted.", fcid, height),
+		Suggestion:  "The data stored in the contract might be lost, check the health of the affected objects and consider blocking the host if this happens repeatedly.",
+		Data: map[string]any{
+			"contractID": fcid.String(),
+			"height":     height,
+			"hint":       "The contract's proof window ended without the host submitting a storage proof, the data stored in the contract might be lost.",
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+func newPendingContractFailedAlert(fcid types.FileContractID, timeoutBlocks uint64) alerts.Alert {
+	return alerts.Alert{
+		ID:          alerts.IDForContract(alertPendingContractFailedID, fcid),
+		Severity:    alerts.SeverityWarning,
+		Message:     "Pending contract marked as failed",
+		Description: fmt.Sprintf("The formation transaction of contract %v was not confirmed within %d blocks of its start height.", fcid, timeoutBlocks),
+		Suggestion:  "Make sure the wallet has enough funds and the node is synced, the contractor will form a replacement contract during the next maintenance.",
+		Data: map[string]any{
+			"contractID": fcid.String(),
+			"hint":       fmt.Sprintf("The contract's formation transaction was not confirmed within %d blocks of its start height.", timeoutBlocks),
+		},
+		Timestamp: time.Now(),
+	}
+}
+
 func newPricePinningFailedAlert(err error) alerts.Alert {
 	return alerts.Alert{
-		ID:       alertPricePinningID,
-		Severity: alerts.SeverityWarning,
-		Message:  "Price pinning failed",
+		ID:          alertPricePinningID,
+		Severity:    alerts.SeverityWarning,
+		Message:     "Price pinning failed",
+		Description: fmt.Sprintf("Updating the pinned prices from the forex API failed: %v", err),
+		Suggestion:  "Make sure the configured forex endpoint is reachable. This alert will disappear the next time prices were updated successfully.",
 		Data: map[string]any{
 			"error": err.Error(),
 			"hint":  fmt.Sprintf("This might happen when the forex API is temporarily unreachable. This alert will disappear the next time prices were updated successfully"),
@@ -23,3 +61,25 @@ func newPricePinningFailedAlert(err error) alerts.Alert {
 		Timestamp: time.Now(),
 	}
 }
+
+func newRevisionMismatchAlert(c api.ContractMetadata, onChain uint64, refreshErr error) alerts.Alert {
+	data := map[string]any{
+		"contractID":     c.ID.String(),
+		"hostKey":        c.HostKey.String(),
+		"revisionNumber": c.RevisionNumber,
+		"onChain":        onChain,
+		"hint":           "The chain holds a newer revision of the contract than the one stored by the renter, the contract's spending and size might be out of date.",
+	}
+	if refreshErr != nil {
+		data["error"] = refreshErr.Error()
+	}
+	return alerts.Alert{
+		ID:          alerts.IDForContract(alertRevisionMismatchID, c.ID),
+		Severity:    alerts.SeverityWarning,
+		Message:     "Contract revision mismatch",
+		Description: fmt.Sprintf("Contract %v has revision number %d on chain but only revision %d is stored.", c.ID, onChain, c.RevisionNumber),
+		Suggestion:  "Make sure the host is reachable, the latest revision will be fetched from the host during the next verification.",
+		Data:        data,
+		Timestamp:   time.Now(),
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"go.sia.tech/coreutils/chain"
 	"go.sia.tech/coreutils/rhp/v4/siamux"
 	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/stores/sql"
 	"go.uber.org/zap"
@@ -66,6 +67,7 @@ type (
 	}
 
 	chainSubscriber struct {
+		alerts alerts.Alerter
 		cm     ChainManager
 		cs     ChainStore
 		logger *zap.SugaredLogger
@@ -85,10 +87,11 @@ type (
 // NewChainSubscriber creates a new chain subscriber that will sync with the
 // given chain manager and chain store. The returned subscriber is already
 // running and can be stopped by calling Shutdown.
-func NewChainSubscriber(cm ChainManager, cs ChainStore, w Wallet, announcementMaxAge time.Duration, logger *zap.Logger) *chainSubscriber {
+func NewChainSubscriber(alerter alerts.Alerter, cm ChainManager, cs ChainStore, w Wallet, announcementMaxAge time.Duration, logger *zap.Logger) *chainSubscriber {
 	logger = logger.Named("chainsubscriber")
 	ctx, cancel := context.WithCancelCause(context.Background())
 	subscriber := &chainSubscriber{
+		alerts: alerter,
 		cm:     cm,
 		cs:     cs,
 		logger: logger.Sugar(),
@@ -209,7 +212,7 @@ func (s *chainSubscriber) applyChainUpdate(tx sql.ChainUpdateTx, cau chain.Apply
 			return fmt.Errorf("failed to prune file contract elements: %w", err)
 		}
 		// mark contracts as failed a prune window after the window end
-		if err := tx.UpdateFailedContracts(cau.State.Index.Height - contractElementPruneWindow); err != nil {
+		if err := tx.UpdateFailedContracts(cau.State.Index.Height-contractElementPruneWindow, cau.State.Index.Height); err != nil {
 			return fmt.Errorf("failed to update failed contracts: %w", err)
 		}
 	}
@@ -235,7 +238,7 @@ func (s *chainSubscriber) revertChainUpdate(tx sql.ChainUpdateTx, cru chain.Reve
 				revertedContracts = append(revertedContracts, rev)
 			}
 		}
-		if err := s.revertV2ContractUpdate(tx, fce, diff.Created, diff.Resolution); err != nil {
+		if err := s.revertV2ContractUpdate(tx, cru.State.Index, fce, diff.Created, diff.Resolution); err != nil {
 			return fmt.Errorf("failed to revert v2 contract update: %w", err)
 		}
 	}
@@ -330,6 +333,7 @@ func (s *chainSubscriber) sync() error {
 }
 
 func (s *chainSubscriber) processUpdates(ctx context.Context, crus []chain.RevertUpdate, caus []chain.ApplyUpdate) (index types.ChainIndex, err error) {
+	var failed []types.FileContractID
 	err = s.cs.ProcessChainUpdate(ctx, func(tx sql.ChainUpdateTx) error {
 		// process wallet updates
 		if err := s.wallet.UpdateChainState(tx, crus, caus); err != nil {
@@ -359,8 +363,34 @@ func (s *chainSubscriber) processUpdates(ctx context.Context, crus []chain.Rever
 		if err := tx.UpdateChainIndex(index); err != nil {
 			return fmt.Errorf("failed to update chain index: %w", err)
 		}
+
+		// resolve the state of unarchived contracts that are still active
+		if err := tx.UpdateUnknownContracts(index.Height); err != nil {
+			return fmt.Errorf("failed to update unknown contracts: %w", err)
+		}
+
+		// archive contracts whose window ended
+		if len(caus) > 0 {
+			failed, err = tx.ArchiveExpiredContracts(index.Height)
+			if err != nil {
+				return fmt.Errorf("failed to archive expired contracts: %w", err)
+			}
+		}
 		return nil
 	})
+	if err != nil {
+		return
+	}
+
+	// alert on contracts that failed while still containing data, this is
+	// done after the updates were committed to avoid alerting on contracts
+	// that were not actually marked as failed
+	for _, fcid := range failed {
+		s.logger.Warnw("expired contract failed", "fcid", fcid, "height", index.Height)
+		if err := s.alerts.RegisterAlert(ctx, newExpiredContractFailedAlert(fcid, index.Height)); err != nil {
+			s.logger.Errorw("failed to register alert", zap.Error(err))
+		}
+	}
 	return
 }
 
@@ -461,7 +491,7 @@ func (s *chainSubscriber) applyV2ContractUpdate(tx sql.ChainUpdateTx, index type
 		}
 
 		// record new state
-		if err := tx.UpdateContractState(fcid, newState); err != nil {
+		if err := tx.UpdateContractState(fcid, newState, reason, index.Height); err != nil {
 			return fmt.Errorf("failed to update contract state: %w", err)
 		}
 
@@ -473,7 +503,7 @@ func (s *chainSubscriber) applyV2ContractUpdate(tx sql.ChainUpdateTx, index type
 
 	// contract was created -> 'active'
 	if created {
-		if err := tx.UpdateContractState(fcid, api.ContractStateActive); err != nil {
+		if err := tx.UpdateContractState(fcid, api.ContractStateActive, "contract confirmed", index.Height); err != nil {
 			return fmt.Errorf("failed to update contract state: %w", err)
 		}
 		s.logger.Infow(fmt.Sprintf("contract state changed: %s -> active", state),
@@ -485,7 +515,7 @@ func (s *chainSubscriber) applyV2ContractUpdate(tx sql.ChainUpdateTx, index type
 	return nil
 }
 
-func (s *chainSubscriber) revertV2ContractUpdate(tx sql.ChainUpdateTx, fce types.V2FileContractElement, created bool, res types.V2FileContractResolutionType) error {
+func (s *chainSubscriber) revertV2ContractUpdate(tx sql.ChainUpdateTx, index types.ChainIndex, fce types.V2FileContractElement, created bool, res types.V2FileContractResolutionType) error {
 	fcid := fce.ID
 
 	// ignore unknown contracts
@@ -503,7 +533,7 @@ func (s *chainSubscriber) revertV2ContractUpdate(tx sql.ChainUpdateTx, fce types
 
 	// contract was reverted -> 'pending'
 	if created {
-		if err := tx.UpdateContractState(fcid, api.ContractStatePending); err != nil {
+		if err := tx.UpdateContractState(fcid, api.ContractStatePending, "contract was reverted", index.Height); err != nil {
 			return fmt.Errorf("failed to update contract state: %w", err)
 		} else if err := tx.DeleteFileContractElement(fcid); err != nil {
 			return fmt.Errorf("failed to delete file contract element: %w", err)
@@ -522,7 +552,7 @@ func (s *chainSubscriber) revertV2ContractUpdate(tx sql.ChainUpdateTx, fce types
 		}
 
 		// record new state
-		if err := tx.UpdateContractState(fcid, api.ContractStateActive); err != nil {
+		if err := tx.UpdateContractState(fcid, api.ContractStateActive, "resolution was reverted", index.Height); err != nil {
 			return fmt.Errorf("failed to update contract state: %w", err)
 		}
 
@@ -1,6 +1,8 @@
 package bus
 
 import (
+	"math"
+
 	"go.sia.tech/core/types"
 	"go.sia.tech/coreutils/rhp/v4"
 )
@@ -15,14 +17,23 @@ type formContractSignerWallet interface {
 }
 
 type formContractSigner struct {
-	renterKey types.PrivateKey
-	w         formContractSignerWallet
+	feeMultiplier float64
+	renterKey     types.PrivateKey
+	w             formContractSignerWallet
 }
 
 func NewFormContractSigner(w formContractSignerWallet, renterKey types.PrivateKey) rhp.FormContractSigner {
+	return NewFormContractSignerWithFeeMultiplier(w, renterKey, 1)
+}
+
+// NewFormContractSignerWithFeeMultiplier returns a signer that multiplies the
+// wallet's recommended fee by the given multiplier, the multiplier is precise
+// up to three decimal places.
+func NewFormContractSignerWithFeeMultiplier(w formContractSignerWallet, renterKey types.PrivateKey, feeMultiplier float64) rhp.FormContractSigner {
 	return &formContractSigner{
-		renterKey: renterKey,
-		w:         w,
+		feeMultiplier: feeMultiplier,
+		renterKey:     renterKey,
+		w:             w,
 	}
 }
 
@@ -31,7 +42,11 @@ func (s *formContractSigner) FundV2Transaction(txn *types.V2Transaction, amount
 }
 
 func (s *formContractSigner) RecommendedFee() types.Currency {
-	return s.w.RecommendedFee()
+	fee := s.w.RecommendedFee()
+	if s.feeMultiplier <= 0 || s.feeMultiplier == 1 {
+		return fee
+	}
+	return fee.Mul64(uint64(math.Round(s.feeMultiplier * 1000))).Div64(1000)
 }
 
 func (s *formContractSigner) ReleaseInputs(txns []types.V2Transaction) {
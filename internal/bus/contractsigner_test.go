@@ -0,0 +1,34 @@
+package bus
+
+import (
+	"testing"
+
+	"go.sia.tech/core/types"
+)
+
+type feeWallet struct {
+	formContractSignerWallet
+	fee types.Currency
+}
+
+func (w feeWallet) RecommendedFee() types.Currency { return w.fee }
+
+func TestFormContractSignerFeeMultiplier(t *testing.T) {
+	w := feeWallet{fee: types.NewCurrency64(1000)}
+	tests := []struct {
+		multiplier float64
+		want       types.Currency
+	}{
+		{0, types.NewCurrency64(1000)},
+		{1, types.NewCurrency64(1000)},
+		{1.5, types.NewCurrency64(1500)},
+		{2.0005, types.NewCurrency64(2001)},
+		{0.5, types.NewCurrency64(500)},
+	}
+	for _, test := range tests {
+		signer := NewFormContractSignerWithFeeMultiplier(w, types.GeneratePrivateKey(), test.multiplier)
+		if fee := signer.RecommendedFee(); !fee.Equals(test.want) {
+			t.Fatalf("multiplier %v: expected fee %v, got %v", test.multiplier, test.want, fee)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/alerts"
+	"go.sia.tech/renterd/v2/api"
+	"go.uber.org/zap"
+)
+
+const (
+	// contractVerificationTimeout is the maximum amount of time a single
+	// verification of all active contracts may take.
+	contractVerificationTimeout = 5 * time.Minute
+)
+
+type (
+	ContractVerifier struct {
+		alerts  alerts.Alerter
+		store   ContractVerifierStore
+		refresh RevisionRefresher
+
+		shutdownChan chan struct{}
+		wg           sync.WaitGroup
+
+		logger *zap.SugaredLogger
+	}
+
+	ContractVerifierStore interface {
+		Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error)
+		FileContractElement(ctx context.Context, fcid types.FileContractID) (types.V2FileContractElement, error)
+		UpdateContractVerificationStatus(ctx context.Context, fcid types.FileContractID, status string) error
+	}
+
+	// RevisionRefresher fetches the latest revision of a contract from its
+	// host and records it.
+	RevisionRefresher func(ctx context.Context, fcid types.FileContractID) (api.ContractMetadata, error)
+)
+
+// NewContractVerifier returns a verifier that periodically compares the
+// revision numbers of all active contracts to the revision numbers found on
+// chain and updates their verification status accordingly. If the chain holds
+// a newer revision than the one we stored, the latest revision is fetched from
+// the host and an alert is registered if that doesn't resolve the mismatch.
+// The verifier is already running and can be stopped by calling Shutdown.
+func NewContractVerifier(alerts alerts.Alerter, store ContractVerifierStore, refresh RevisionRefresher, interval time.Duration, logger *zap.Logger) *ContractVerifier {
+	logger = logger.Named("contractverifier")
+	verifier := &ContractVerifier{
+		alerts:       alerts,
+		store:        store,
+		refresh:      refresh,
+		shutdownChan: make(chan struct{}),
+		logger:       logger.Sugar(),
+	}
+	verifier.run(interval)
+	return verifier
+}
+
+func (cv *ContractVerifier) run(interval time.Duration) {
+	cv.wg.Add(1)
+	go func() {
+		defer cv.wg.Done()
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), contractVerificationTimeout)
+			if err := cv.verifyContracts(ctx); err != nil {
+				cv.logger.Errorw("failed to verify contracts", zap.Error(err))
+			}
+			cancel()
+
+			select {
+			case <-cv.shutdownChan:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+func (cv *ContractVerifier) verifyContracts(ctx context.Context) error {
+	contracts, err := cv.store.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeActive})
+	if err != nil {
+		return err
+	}
+
+	var dismiss []types.Hash256
+	for _, c := range contracts {
+		if c.State != api.ContractStateActive {
+			continue // only active contracts have a revision on chain
+		}
+
+		status, err := cv.verifyContract(ctx, c)
+		if err != nil {
+			cv.logger.Errorw("failed to verify contract", "fcid", c.ID, zap.Error(err))
+			continue
+		} else if status != api.ContractVerificationStatusMismatch {
+			dismiss = append(dismiss, alerts.IDForContract(alertRevisionMismatchID, c.ID))
+		}
+
+		if status == c.VerificationStatus {
+			continue
+		} else if err := cv.store.UpdateContractVerificationStatus(ctx, c.ID, status); err != nil {
+			cv.logger.Errorw("failed to update contract verification status", "fcid", c.ID, zap.Error(err))
+		}
+	}
+
+	if len(dismiss) > 0 {
+		if err := cv.alerts.DismissAlerts(ctx, dismiss...); err != nil {
+			cv.logger.Errorw("failed to dismiss alerts", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// verifyContract returns the verification status of the given contract, if
+// the chain holds a newer revision the latest revision is fetched from the
+// host before an alert is registered.
+func (cv *ContractVerifier) verifyContract(ctx context.Context, c api.ContractMetadata) (string, error) {
+	fce, err := cv.store.FileContractElement(ctx, c.ID)
+	if errors.Is(err, api.ErrContractNotFound) {
+		return api.ContractVerificationStatusUnverified, nil
+	} else if err != nil {
+		return "", err
+	}
+	onChain := fce.V2FileContract.RevisionNumber
+
+	status := verificationStatus(c.RevisionNumber, onChain)
+	if status != api.ContractVerificationStatusMismatch {
+		return status, nil
+	}
+
+	cv.logger.Warnw("chain holds a newer revision than the one we stored", "fcid", c.ID, "revisionNumber", c.RevisionNumber, "onChain", onChain)
+	refreshed, err := cv.refresh(ctx, c.ID)
+	if err == nil {
+		status = verificationStatus(refreshed.RevisionNumber, onChain)
+	}
+	if status == api.ContractVerificationStatusMismatch {
+		if err := cv.alerts.RegisterAlert(ctx, newRevisionMismatchAlert(c, onChain, err)); err != nil {
+			cv.logger.Errorw("failed to register alert", zap.Error(err))
+		}
+	}
+	return status, nil
+}
+
+func (cv *ContractVerifier) Shutdown(ctx context.Context) error {
+	close(cv.shutdownChan)
+
+	waitChan := make(chan struct{})
+	go func() {
+		cv.wg.Wait()
+		close(waitChan)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-waitChan:
+		return nil
+	}
+}
+
+func verificationStatus(revisionNumber, onChain uint64) string {
+	switch {
+	case revisionNumber == onChain:
+		return api.ContractVerificationStatusVerified
+	case revisionNumber > onChain:
+		return api.ContractVerificationStatusUnverified
+	default:
+		return api.ContractVerificationStatusMismatch
+	}
+}
@@ -0,0 +1,126 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/alerts"
+	"go.sia.tech/renterd/v2/api"
+	"go.uber.org/zap"
+)
+
+type mockContractVerifierStore struct {
+	contracts map[types.FileContractID]api.ContractMetadata
+	onChain   map[types.FileContractID]uint64
+}
+
+func (s *mockContractVerifierStore) Contracts(_ context.Context, _ api.ContractsOpts) (contracts []api.ContractMetadata, _ error) {
+	for _, c := range s.contracts {
+		contracts = append(contracts, c)
+	}
+	return
+}
+
+func (s *mockContractVerifierStore) FileContractElement(_ context.Context, fcid types.FileContractID) (types.V2FileContractElement, error) {
+	revisionNumber, ok := s.onChain[fcid]
+	if !ok {
+		return types.V2FileContractElement{}, api.ErrContractNotFound
+	}
+	return types.V2FileContractElement{V2FileContract: types.V2FileContract{RevisionNumber: revisionNumber}}, nil
+}
+
+func (s *mockContractVerifierStore) UpdateContractVerificationStatus(_ context.Context, fcid types.FileContractID, status string) error {
+	c := s.contracts[fcid]
+	c.VerificationStatus = status
+	s.contracts[fcid] = c
+	return nil
+}
+
+func TestContractVerifier(t *testing.T) {
+	verified := types.FileContractID{1}
+	unverified := types.FileContractID{2}
+	mismatch := types.FileContractID{3}
+	notFound := types.FileContractID{4}
+
+	store := &mockContractVerifierStore{
+		contracts: make(map[types.FileContractID]api.ContractMetadata),
+		onChain: map[types.FileContractID]uint64{
+			verified:   5,
+			unverified: 5,
+			mismatch:   5,
+		},
+	}
+	for fcid, revisionNumber := range map[types.FileContractID]uint64{
+		verified:   5,
+		unverified: 6,
+		mismatch:   4,
+		notFound:   1,
+	} {
+		store.contracts[fcid] = api.ContractMetadata{
+			ID:                 fcid,
+			RevisionNumber:     revisionNumber,
+			State:              api.ContractStateActive,
+			VerificationStatus: api.ContractVerificationStatusUnverified,
+		}
+	}
+
+	// refreshing the contract fails until the host is reachable
+	var reachable bool
+	refresh := func(_ context.Context, fcid types.FileContractID) (api.ContractMetadata, error) {
+		if !reachable {
+			return api.ContractMetadata{}, errors.New("host unreachable")
+		}
+		c := store.contracts[fcid]
+		c.RevisionNumber = store.onChain[fcid]
+		store.contracts[fcid] = c
+		return c, nil
+	}
+
+	alerter := &mockAlerter{}
+	cv := &ContractVerifier{
+		alerts:  alerter,
+		store:   store,
+		refresh: refresh,
+		logger:  zap.NewNop().Sugar(),
+	}
+
+	// helper to assert the verification status of a contract
+	assertStatus := func(fcid types.FileContractID, status string) {
+		t.Helper()
+		if c := store.contracts[fcid]; c.VerificationStatus != status {
+			t.Fatalf("expected status %v for contract %v, got %v", status, fcid, c.VerificationStatus)
+		}
+	}
+
+	// helper to assert whether the mismatch alert is registered
+	assertAlert := func(registered bool) {
+		t.Helper()
+		res, _ := alerter.Alerts(context.Background(), alerts.AlertsOpts{})
+		var found bool
+		for _, a := range res.Alerts {
+			found = found || a.ID == alerts.IDForContract(alertRevisionMismatchID, mismatch)
+		}
+		if found != registered {
+			t.Fatalf("expected alert to be registered: %v", registered)
+		}
+	}
+
+	if err := cv.verifyContracts(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(verified, api.ContractVerificationStatusVerified)
+	assertStatus(unverified, api.ContractVerificationStatusUnverified)
+	assertStatus(mismatch, api.ContractVerificationStatusMismatch)
+	assertStatus(notFound, api.ContractVerificationStatusUnverified)
+	assertAlert(true)
+
+	// once the host is reachable the revision is refreshed
+	reachable = true
+	if err := cv.verifyContracts(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(mismatch, api.ContractVerificationStatusVerified)
+	assertAlert(false)
+}
@@ -0,0 +1,136 @@
+package bus
+
+import (
+	"sync"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/api"
+	rhp4 "go.sia.tech/renterd/v2/internal/rhp/v4"
+)
+
+// pricesValidityLeeway is subtracted from the time the host's prices are valid
+// until, so cached settings aren't used when they are about to expire.
+const pricesValidityLeeway = 60 * time.Second
+
+type (
+	// HostSettingsCache caches the settings fetched from hosts together with
+	// the time they were fetched at and, optionally, the result of the gouging
+	// check performed on them. A gouging check is only returned for the
+	// gouging settings it was performed with, so updating the gouging settings
+	// invalidates it.
+	HostSettingsCache struct {
+		ttl time.Duration
+
+		mu      sync.Mutex
+		entries map[types.PublicKey]hostSettingsCacheEntry
+	}
+
+	hostSettingsCacheEntry struct {
+		settings rhp4.HostSettings
+		cachedAt time.Time
+		expiry   time.Time
+
+		checked   bool
+		gs        api.GougingSettings
+		breakdown api.HostGougingBreakdown
+	}
+)
+
+// NewHostSettingsCache returns a cache whose entries expire after the given ttl
+// or shortly before the host's prices expire, whichever happens first. A ttl
+// of zero disables the cache.
+func NewHostSettingsCache(ttl time.Duration) *HostSettingsCache {
+	return &HostSettingsCache{
+		ttl:     ttl,
+		entries: make(map[types.PublicKey]hostSettingsCacheEntry),
+	}
+}
+
+// Get returns the cached settings of the host with the given key and the time
+// they were cached at if they haven't expired yet.
+func (hc *HostSettingsCache) Get(hk types.PublicKey) (rhp4.HostSettings, time.Time, bool) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	entry, ok := hc.entry(hk)
+	if !ok {
+		return rhp4.HostSettings{}, time.Time{}, false
+	}
+	return entry.settings, entry.cachedAt, true
+}
+
+// GougingBreakdown returns the cached settings of the host with the given key
+// together with the result of checking them against the given gouging
+// settings, if the settings haven't expired yet and were checked against the
+// given gouging settings.
+func (hc *HostSettingsCache) GougingBreakdown(hk types.PublicKey, gs api.GougingSettings) (rhp4.HostSettings, api.HostGougingBreakdown, bool) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	entry, ok := hc.entry(hk)
+	if !ok || !entry.checked || entry.gs != gs {
+		return rhp4.HostSettings{}, api.HostGougingBreakdown{}, false
+	}
+	return entry.settings, entry.breakdown, true
+}
+
+// Set caches the settings of the host with the given key and returns the time
+// they were cached at.
+func (hc *HostSettingsCache) Set(hk types.PublicKey, settings rhp4.HostSettings) time.Time {
+	now := time.Now()
+	if hc.ttl == 0 {
+		return now
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	// prune expired entries
+	for hk, entry := range hc.entries {
+		if !now.Before(entry.expiry) {
+			delete(hc.entries, hk)
+		}
+	}
+
+	expiry := now.Add(hc.ttl)
+	if validUntil := settings.Prices.ValidUntil.Add(-pricesValidityLeeway); validUntil.Before(expiry) {
+		expiry = validUntil
+	}
+	hc.entries[hk] = hostSettingsCacheEntry{
+		settings: settings,
+		cachedAt: now,
+		expiry:   expiry,
+	}
+	return now
+}
+
+// SetGougingBreakdown caches the result of checking the host's settings
+// against the given gouging settings. The result is only cached if the
+// settings that were cached at the given time are still in the cache.
+func (hc *HostSettingsCache) SetGougingBreakdown(hk types.PublicKey, cachedAt time.Time, gs api.GougingSettings, breakdown api.HostGougingBreakdown) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	entry, ok := hc.entry(hk)
+	if !ok || !entry.cachedAt.Equal(cachedAt) {
+		return
+	}
+	entry.checked = true
+	entry.gs = gs
+	entry.breakdown = breakdown
+	hc.entries[hk] = entry
+}
+
+// entry returns the entry of the host with the given key if it hasn't expired
+// yet, expired entries are removed. The caller must hold the lock.
+func (hc *HostSettingsCache) entry(hk types.PublicKey) (hostSettingsCacheEntry, bool) {
+	entry, ok := hc.entries[hk]
+	if !ok {
+		return hostSettingsCacheEntry{}, false
+	} else if !time.Now().Before(entry.expiry) {
+		delete(hc.entries, hk)
+		return hostSettingsCacheEntry{}, false
+	}
+	return entry, true
+}
@@ -0,0 +1,107 @@
+package bus
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/api"
+	rhp4 "go.sia.tech/renterd/v2/internal/rhp/v4"
+)
+
+func TestHostSettingsCache(t *testing.T) {
+	hc := NewHostSettingsCache(time.Minute)
+
+	hk := types.PublicKey{1}
+	var settings rhp4.HostSettings
+	settings.Release = "test"
+	settings.Prices.ValidUntil = time.Now().Add(time.Hour)
+
+	// assert the cache is empty
+	if _, _, ok := hc.Get(hk); ok {
+		t.Fatal("expected cache miss")
+	}
+
+	// add an entry and assert it's returned
+	cachedAt := hc.Set(hk, settings)
+	if cached, ts, ok := hc.Get(hk); !ok {
+		t.Fatal("expected cache hit")
+	} else if cached.Release != settings.Release || !ts.Equal(cachedAt) {
+		t.Fatal("unexpected entry")
+	}
+
+	// assert entries expire with the host's prices
+	settings.Prices.ValidUntil = time.Now().Add(-time.Second)
+	hc.Set(hk, settings)
+	if _, _, ok := hc.Get(hk); ok {
+		t.Fatal("expected cache miss")
+	}
+
+	// assert entries expire before the host's prices do
+	settings.Prices.ValidUntil = time.Now().Add(pricesValidityLeeway / 2)
+	hc.Set(hk, settings)
+	if _, _, ok := hc.Get(hk); ok {
+		t.Fatal("expected cache miss")
+	}
+
+	// assert entries expire after the ttl
+	hc.ttl = time.Millisecond
+	settings.Prices.ValidUntil = time.Now().Add(time.Hour)
+	hc.Set(hk, settings)
+	time.Sleep(10 * time.Millisecond)
+	if _, _, ok := hc.Get(hk); ok {
+		t.Fatal("expected cache miss")
+	}
+
+	// assert a ttl of zero disables the cache
+	hc = NewHostSettingsCache(0)
+	hc.Set(hk, settings)
+	if _, _, ok := hc.Get(hk); ok {
+		t.Fatal("expected cache miss")
+	}
+}
+
+func TestHostSettingsCacheGougingBreakdown(t *testing.T) {
+	hc := NewHostSettingsCache(time.Minute)
+
+	hk := types.PublicKey{1}
+	gs := api.GougingSettings{MaxRPCPrice: types.Siacoins(1)}
+	var settings rhp4.HostSettings
+	settings.Prices.ValidUntil = time.Now().Add(time.Hour)
+	gb := api.HostGougingBreakdown{GougingErr: "gouging"}
+
+	// assert settings that weren't checked have no breakdown
+	cachedAt := hc.Set(hk, settings)
+	if _, _, ok := hc.GougingBreakdown(hk, gs); ok {
+		t.Fatal("expected cache miss")
+	}
+
+	// add a breakdown and assert it's returned
+	hc.SetGougingBreakdown(hk, cachedAt, gs, gb)
+	if cached, breakdown, ok := hc.GougingBreakdown(hk, gs); !ok {
+		t.Fatal("expected cache hit")
+	} else if cached.Prices.ValidUntil != settings.Prices.ValidUntil || breakdown != gb {
+		t.Fatal("unexpected entry")
+	}
+
+	// assert the breakdown is ignored when the gouging settings change
+	gs2 := gs
+	gs2.MaxRPCPrice = types.Siacoins(2)
+	if _, _, ok := hc.GougingBreakdown(hk, gs2); ok {
+		t.Fatal("expected cache miss")
+	} else if _, _, ok := hc.Get(hk); !ok {
+		t.Fatal("expected settings to remain cached")
+	}
+
+	// assert the breakdown is dropped when the settings are replaced
+	hc.Set(hk, settings)
+	if _, _, ok := hc.GougingBreakdown(hk, gs); ok {
+		t.Fatal("expected cache miss")
+	}
+
+	// assert a breakdown of replaced settings isn't cached
+	hc.SetGougingBreakdown(hk, cachedAt, gs, gb)
+	if _, _, ok := hc.GougingBreakdown(hk, gs); ok {
+		t.Fatal("expected cache miss")
+	}
+}
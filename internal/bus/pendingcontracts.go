@@ -0,0 +1,115 @@
+package bus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/alerts"
+	"go.uber.org/zap"
+)
+
+type (
+	PendingContractsMonitor struct {
+		alerts alerts.Alerter
+		store  PendingContractsStore
+
+		timeoutBlocks uint64
+
+		shutdownChan chan struct{}
+		wg           sync.WaitGroup
+
+		logger *zap.SugaredLogger
+	}
+
+	PendingContractsStore interface {
+		ChainIndex(ctx context.Context) (types.ChainIndex, error)
+		FailPendingContracts(ctx context.Context, maxStartHeight, height uint64) ([]types.FileContractID, error)
+	}
+)
+
+// NewPendingContractsMonitor returns a monitor that periodically marks
+// contracts that have been pending for more than timeoutBlocks blocks as
+// failed. The monitor is already running and can be stopped by calling
+// Shutdown. A timeout of zero disables the monitor.
+func NewPendingContractsMonitor(alerts alerts.Alerter, store PendingContractsStore, timeoutBlocks uint64, interval time.Duration, logger *zap.Logger) *PendingContractsMonitor {
+	logger = logger.Named("pendingcontractsmonitor")
+	monitor := &PendingContractsMonitor{
+		alerts:        alerts,
+		store:         store,
+		timeoutBlocks: timeoutBlocks,
+		shutdownChan:  make(chan struct{}),
+		logger:        logger.Sugar(),
+	}
+	if timeoutBlocks > 0 {
+		monitor.run(interval)
+	}
+	return monitor
+}
+
+func (pcm *PendingContractsMonitor) run(interval time.Duration) {
+	pcm.wg.Add(1)
+	go func() {
+		defer pcm.wg.Done()
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			if err := pcm.failPendingContracts(ctx); err != nil {
+				pcm.logger.Errorw("failed to fail pending contracts", zap.Error(err))
+			}
+			cancel()
+
+			select {
+			case <-pcm.shutdownChan:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+func (pcm *PendingContractsMonitor) failPendingContracts(ctx context.Context) error {
+	ci, err := pcm.store.ChainIndex(ctx)
+	if err != nil {
+		return err
+	} else if ci.Height <= pcm.timeoutBlocks {
+		return nil
+	}
+
+	// contracts that started at or before the max start height have been
+	// pending for at least timeoutBlocks + 1 blocks
+	maxStartHeight := ci.Height - pcm.timeoutBlocks - 1
+	failed, err := pcm.store.FailPendingContracts(ctx, maxStartHeight, ci.Height)
+	if err != nil {
+		return err
+	}
+
+	for _, fcid := range failed {
+		pcm.logger.Infow("marked pending contract as failed", "fcid", fcid, "height", ci.Height)
+		if err := pcm.alerts.RegisterAlert(ctx, newPendingContractFailedAlert(fcid, pcm.timeoutBlocks)); err != nil {
+			pcm.logger.Errorw("failed to register alert", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (pcm *PendingContractsMonitor) Shutdown(ctx context.Context) error {
+	close(pcm.shutdownChan)
+
+	waitChan := make(chan struct{})
+	go func() {
+		pcm.wg.Wait()
+		close(waitChan)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-waitChan:
+		return nil
+	}
+}
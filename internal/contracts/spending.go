@@ -9,6 +9,7 @@ import (
 	"go.sia.tech/core/types"
 	rhp "go.sia.tech/coreutils/rhp/v4"
 	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/internal/utils"
 	"go.uber.org/zap"
 )
 
@@ -104,7 +105,12 @@ func (r *contractSpendingRecorder) flush() {
 		for _, cs := range r.contractSpendings {
 			records = append(records, cs)
 		}
-		if err := r.bus.RecordContractSpending(r.flushCtx, records); err != nil {
+		if err := r.bus.RecordContractSpending(r.flushCtx, records); utils.IsErr(err, api.ErrContractSpendingCapExceeded) {
+			// the bus recorded all spending and marked the contracts that
+			// exceeded their cap as bad, retrying would record it twice
+			r.logger.Errorw(fmt.Sprintf("failed to record contract spending: %v", err))
+			r.contractSpendings = make(map[types.FileContractID]api.ContractSpendingRecord)
+		} else if err != nil {
 			r.logger.Errorw(fmt.Sprintf("failed to record contract spending: %v", err))
 		} else {
 			r.contractSpendings = make(map[types.FileContractID]api.ContractSpendingRecord)
@@ -9,6 +9,7 @@ import (
 	"math"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	rhpv4 "go.sia.tech/core/rhp/v4"
@@ -31,6 +32,14 @@ type ObjectStore interface {
 
 const (
 	downloadMemoryLimitDenom = 6 // 1/6th of the available download memory can be used by a single download
+
+	// slowHostDecay is the amount of time after which a slow host is no
+	// longer considered slow, unless it was slow again in the meantime
+	slowHostDecay = 10 * time.Minute
+
+	// sectorRepairTimeout is the timeout for re-uploading a sector to a host
+	// that served invalid data for it
+	sectorRepairTimeout = time.Minute
 )
 
 var (
@@ -54,19 +63,30 @@ type (
 
 		statsOverdrivePct                *utils.DataPoints
 		statsSlabDownloadSpeedBytesPerMS *utils.DataPoints
+		statsSectorsRepaired             atomic.Uint64
 
-		shutdownCtx context.Context
+		shutdownCtx   context.Context
+		now           func() time.Time
+		sectorRepairs chan struct{}
 
 		mu          sync.Mutex
 		downloaders map[types.PublicKey]*downloader.Downloader
+		hosts       map[types.PublicKey]api.HostInfo
+
+		// slowHosts holds the time at which a host last took longer than the
+		// overdrive timeout to download a sector, slow hosts are sorted to
+		// the back when downloading slabs until their status decays
+		slowHosts     map[types.PublicKey]time.Time
+		deprioritized map[types.PublicKey]uint64
 	}
 
 	slabDownload struct {
 		mgr *Manager
 
-		minShards int
-		offset    uint64
-		length    uint64
+		minShards        int
+		redundancyFactor int
+		offset           uint64
+		length           uint64
 
 		created time.Time
 
@@ -77,8 +97,9 @@ type (
 		numLaunched    uint64
 		numOverdriving uint64
 
-		sectors []*sectorInfo
-		errs    utils.HostErrorSet
+		sectors  []*sectorInfo
+		errs     utils.HostErrorSet
+		launched map[*downloader.SectorDownloadReq]time.Time
 	}
 
 	slabDownloadResponse struct {
@@ -89,19 +110,26 @@ type (
 	}
 
 	sectorInfo struct {
-		root     types.Hash256
-		data     []byte
-		hks      []types.PublicKey
-		index    int
-		selected int
+		root      types.Hash256
+		data      []byte
+		hks       []types.PublicKey
+		contracts map[types.PublicKey][]types.FileContractID
+		index     int
+		selected  int
+
+		// invalid holds the hosts that returned data which didn't match the
+		// sector's root
+		invalid []types.PublicKey
 	}
 
 	Stats struct {
 		AvgDownloadSpeedMBPS float64
 		AvgOverdrivePct      float64
+		AutoRepairedSectors  uint64
 		HealthyDownloaders   uint64
 		NumDownloaders       uint64
 		DownloadSpeedsMBPS   map[types.PublicKey]float64
+		Deprioritized        map[types.PublicKey]uint64
 	}
 )
 
@@ -115,7 +143,11 @@ func (s *sectorInfo) selectHost(h types.PublicKey) {
 	}
 }
 
-func NewManager(ctx context.Context, uploadKey *utils.UploadKey, hm hosts.Manager, mm memory.MemoryManager, os ObjectStore, maxOverdrive uint64, overdriveTimeout time.Duration, logger *zap.Logger) *Manager {
+// NewManager returns a download manager. Sectors for which a host returned
+// invalid data are re-uploaded to that host once they were downloaded from
+// another one, at most maxSectorRepairs of these uploads run at the same time
+// and any further repairs are skipped, a value of 0 disables them.
+func NewManager(ctx context.Context, uploadKey *utils.UploadKey, hm hosts.Manager, mm memory.MemoryManager, os ObjectStore, maxOverdrive uint64, overdriveTimeout time.Duration, maxSectorRepairs uint64, logger *zap.Logger) *Manager {
 	logger = logger.Named("downloadmanager")
 	return &Manager{
 		hm:        hm,
@@ -130,13 +162,23 @@ func NewManager(ctx context.Context, uploadKey *utils.UploadKey, hm hosts.Manage
 		statsOverdrivePct:                utils.NewDataPoints(0),
 		statsSlabDownloadSpeedBytesPerMS: utils.NewDataPoints(0),
 
-		shutdownCtx: ctx,
+		shutdownCtx:   ctx,
+		now:           time.Now,
+		sectorRepairs: make(chan struct{}, maxSectorRepairs),
 
 		downloaders: make(map[types.PublicKey]*downloader.Downloader),
+		hosts:       make(map[types.PublicKey]api.HostInfo),
+
+		slowHosts:     make(map[types.PublicKey]time.Time),
+		deprioritized: make(map[types.PublicKey]uint64),
 	}
 }
 
-func (mgr *Manager) DownloadObject(ctx context.Context, w io.Writer, o object.Object, offset, length uint64, hosts []api.HostInfo) (err error) {
+// DownloadObject downloads the given range of the object and writes it to w.
+// For every slab, MinShards+redundancyFactor sectors are downloaded in
+// parallel and the first MinShards sectors that arrive are used to recover
+// the slab.
+func (mgr *Manager) DownloadObject(ctx context.Context, w io.Writer, o object.Object, offset, length uint64, hosts []api.HostInfo, redundancyFactor int) (err error) {
 	// calculate what slabs we need
 	var ss []slabSlice
 	for _, s := range o.Slabs {
@@ -256,7 +298,7 @@ func (mgr *Manager) DownloadObject(ctx context.Context, w io.Writer, o object.Ob
 			wg.Add(1)
 			go func(index int) {
 				defer wg.Done()
-				shards, err := mgr.downloadSlab(ctx, next.SlabSlice)
+				shards, err := mgr.downloadSlab(ctx, next.SlabSlice, redundancyFactor)
 				select {
 				case responseChan <- &slabDownloadResponse{
 					mem:    mem,
@@ -385,7 +427,7 @@ func (mgr *Manager) DownloadSlab(ctx context.Context, slab object.Slab, hosts []
 		Offset: 0,
 		Length: uint32(slab.MinShards) * rhpv4.SectorSize,
 	}
-	shards, err := mgr.downloadSlab(ctx, slice)
+	shards, err := mgr.downloadSlab(ctx, slice, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -411,8 +453,10 @@ func (mgr *Manager) Stats() Stats {
 	// collect stats
 	var numHealthy uint64
 	speeds := make(map[types.PublicKey]float64)
-	for _, d := range mgr.downloaders {
-		speeds[d.PublicKey()] = d.AvgDownloadSpeedBytesPerMS()
+	deprioritized := make(map[types.PublicKey]uint64)
+	for hk, d := range mgr.downloaders {
+		speeds[hk] = d.AvgDownloadSpeedBytesPerMS()
+		deprioritized[hk] = mgr.deprioritized[hk]
 		if d.Healthy() {
 			numHealthy++
 		}
@@ -421,9 +465,11 @@ func (mgr *Manager) Stats() Stats {
 	return Stats{
 		AvgDownloadSpeedMBPS: mgr.statsSlabDownloadSpeedBytesPerMS.Average() * 0.008, // convert bytes per ms to mbps,
 		AvgOverdrivePct:      mgr.statsOverdrivePct.Average(),
+		AutoRepairedSectors:  mgr.statsSectorsRepaired.Load(),
 		HealthyDownloaders:   numHealthy,
 		NumDownloaders:       uint64(len(mgr.downloaders)),
 		DownloadSpeedsMBPS:   speeds,
+		Deprioritized:        deprioritized,
 	}
 }
 
@@ -465,8 +511,10 @@ func (mgr *Manager) refreshDownloaders(hosts []api.HostInfo) {
 
 	// build map
 	want := make(map[types.PublicKey]api.HostInfo)
+	mgr.hosts = make(map[types.PublicKey]api.HostInfo)
 	for _, h := range hosts {
 		want[h.PublicKey] = h
+		mgr.hosts[h.PublicKey] = h
 	}
 
 	// prune downloaders
@@ -475,6 +523,8 @@ func (mgr *Manager) refreshDownloaders(hosts []api.HostInfo) {
 		if !wanted {
 			mgr.downloaders[hk].Stop(errHostNoLongerUsable)
 			delete(mgr.downloaders, hk)
+			delete(mgr.slowHosts, hk)
+			delete(mgr.deprioritized, hk)
 			continue
 		}
 
@@ -492,7 +542,7 @@ func (mgr *Manager) refreshDownloaders(hosts []api.HostInfo) {
 	}
 }
 
-func (mgr *Manager) newSlabDownload(slice object.SlabSlice) *slabDownload {
+func (mgr *Manager) newSlabDownload(slice object.SlabSlice, redundancyFactor int) *slabDownload {
 	// calculate the offset and length
 	offset, length := slice.SectorRegion()
 
@@ -504,9 +554,10 @@ func (mgr *Manager) newSlabDownload(slice object.SlabSlice) *slabDownload {
 			hks = append(hks, hk)
 		}
 		sectors = append(sectors, &sectorInfo{
-			root:  s.Root,
-			index: sI,
-			hks:   hks,
+			root:      s.Root,
+			index:     sI,
+			hks:       hks,
+			contracts: s.Contracts,
 		})
 	}
 
@@ -514,20 +565,22 @@ func (mgr *Manager) newSlabDownload(slice object.SlabSlice) *slabDownload {
 	return &slabDownload{
 		mgr: mgr,
 
-		minShards: int(slice.MinShards),
-		offset:    offset,
-		length:    length,
+		minShards:        int(slice.MinShards),
+		redundancyFactor: redundancyFactor,
+		offset:           offset,
+		length:           length,
 
 		created: time.Now(),
 
-		sectors: sectors,
-		errs:    make(utils.HostErrorSet),
+		sectors:  sectors,
+		errs:     make(utils.HostErrorSet),
+		launched: make(map[*downloader.SectorDownloadReq]time.Time),
 	}
 }
 
-func (mgr *Manager) downloadSlab(ctx context.Context, slice object.SlabSlice) ([][]byte, error) {
+func (mgr *Manager) downloadSlab(ctx context.Context, slice object.SlabSlice, redundancyFactor int) ([][]byte, error) {
 	// prepare new download
-	slab := mgr.newSlabDownload(slice)
+	slab := mgr.newSlabDownload(slice, redundancyFactor)
 
 	// execute download
 	return slab.download(ctx)
@@ -628,7 +681,12 @@ func (s *slabDownload) nextRequest(ctx context.Context, resps *downloader.Sector
 		if pending[i].selected != pending[j].selected {
 			return pending[i].selected < pending[j].selected
 		}
-		// both have been selected the same number of times, pick the faster one
+		// both have been selected the same number of times, sort sectors on
+		// slow hosts to the back
+		if iSlow, jSlow := s.mgr.isSlow(iFastest.PublicKey()), s.mgr.isSlow(jFastest.PublicKey()); iSlow != jSlow {
+			return jSlow
+		}
+		// both are equally slow, pick the faster one
 		return iFastest.Estimate() < jFastest.Estimate()
 	})
 
@@ -672,6 +730,9 @@ func (s *slabDownload) download(ctx context.Context) ([][]byte, error) {
 	// launch overdrive
 	resetOverdrive := s.overdrive(ctx, resps)
 
+	// track the slow hosts that are sorted to the back for this slab
+	s.mgr.trackDeprioritized(s.sectors)
+
 	// launch 'MinShard' requests
 	for i := 0; i < int(s.minShards); {
 		req := s.nextRequest(ctx, resps, false)
@@ -682,6 +743,16 @@ func (s *slabDownload) download(ctx context.Context) ([][]byte, error) {
 		i++
 	}
 
+	// launch redundant requests, these are best effort so we stop when we
+	// run out of hosts
+	for i := 0; i < s.redundancyFactor; i++ {
+		req := s.nextRequest(ctx, resps, false)
+		if req == nil {
+			break
+		}
+		s.launch(req)
+	}
+
 	// collect responses
 	var done bool
 	for s.inflight() > 0 && !done {
@@ -726,6 +797,10 @@ func (s *slabDownload) download(ctx context.Context) ([][]byte, error) {
 	// track stats
 	s.mgr.statsOverdrivePct.Track(s.overdrivePct())
 	s.mgr.statsSlabDownloadSpeedBytesPerMS.Track(float64(s.downloadSpeed()))
+	s.trackInflight()
+
+	// replace the invalid sectors with the ones downloaded from other hosts
+	s.repairInvalidSectors()
 	return s.finish()
 }
 
@@ -780,6 +855,7 @@ func (s *slabDownload) launch(req *downloader.SectorDownloadReq) {
 	req.Host.Enqueue(req)
 
 	// update the state
+	s.launched[req] = s.mgr.now()
 	s.numInflight++
 	if req.Overdrive {
 		s.numOverdriving++
@@ -798,11 +874,20 @@ func (s *slabDownload) receive(resp downloader.SectorDownloadResp) (finished boo
 
 	// failed reqs can't complete the upload
 	s.numInflight--
+	launched := s.launched[resp.Req]
+	delete(s.launched, resp.Req)
 	if resp.Err != nil {
 		s.errs[resp.Req.Host.PublicKey()] = resp.Err
+		if rhp4.IsInvalidProof(resp.Err) {
+			sector := s.sectors[resp.Req.SectorIndex]
+			sector.invalid = append(sector.invalid, resp.Req.Host.PublicKey())
+		}
 		return false
 	}
 
+	// track how long the host took to download the sector
+	s.mgr.trackSectorDownload(resp.Req.Host.PublicKey(), s.mgr.now().Sub(launched))
+
 	// store the sector
 	if len(s.sectors[resp.Req.SectorIndex].data) == 0 {
 		s.sectors[resp.Req.SectorIndex].data = resp.Sector
@@ -812,21 +897,155 @@ func (s *slabDownload) receive(resp downloader.SectorDownloadResp) (finished boo
 	return s.numCompleted >= s.minShards
 }
 
+// repairInvalidSectors re-uploads the sectors that were downloaded after a host
+// returned invalid data for them to that host. Sectors are only repaired if
+// they were downloaded in full.
+func (s *slabDownload) repairInvalidSectors() {
+	if s.offset != 0 || s.length != rhpv4.SectorSize {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sector := range s.sectors {
+		if len(sector.data) != rhpv4.SectorSize {
+			continue
+		}
+		for _, hk := range sector.invalid {
+			if fcids := sector.contracts[hk]; len(fcids) > 0 {
+				s.mgr.repairSector(hk, fcids[len(fcids)-1], sector.root, sector.data)
+			}
+		}
+	}
+}
+
+// trackInflight records the sector downloads that were still inflight when the
+// slab download finished, they took at least as long as they were inflight.
+func (s *slabDownload) trackInflight() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for req, launched := range s.launched {
+		if elapsed := s.mgr.now().Sub(launched); s.mgr.overdriveTimeout > 0 && elapsed > s.mgr.overdriveTimeout {
+			s.mgr.trackSectorDownload(req.Host.PublicKey(), elapsed)
+		}
+	}
+}
+
+// fastest returns the downloader with the lowest estimate for the given hosts,
+// slow hosts are only returned if none of the other hosts are available.
 func (mgr *Manager) fastest(hosts []types.PublicKey) (fastest *downloader.Downloader) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 	lowest := math.MaxFloat64
+	var fastestSlow bool
 	for _, h := range hosts {
-		if d, ok := mgr.downloaders[h]; !ok {
+		d, ok := mgr.downloaders[h]
+		if !ok {
 			continue
-		} else if estimate := d.Estimate(); estimate < lowest {
+		}
+		slow := mgr.isSlowUnlocked(h)
+		if estimate := d.Estimate(); fastest == nil || (fastestSlow && !slow) || (slow == fastestSlow && estimate < lowest) {
 			lowest = estimate
 			fastest = d
+			fastestSlow = slow
 		}
 	}
 	return
 }
 
+// isSlow returns true if the host's last sector download took longer than the
+// overdrive timeout and that happened less than slowHostDecay ago.
+func (mgr *Manager) isSlow(hk types.PublicKey) bool {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	return mgr.isSlowUnlocked(hk)
+}
+
+func (mgr *Manager) isSlowUnlocked(hk types.PublicKey) bool {
+	slowAt, ok := mgr.slowHosts[hk]
+	if !ok {
+		return false
+	} else if mgr.now().Sub(slowAt) >= slowHostDecay {
+		delete(mgr.slowHosts, hk)
+		return false
+	}
+	return true
+}
+
+// trackDeprioritized increments the deprioritized counter of every slow host
+// that holds a sector of the slab, unless all of the slab's hosts are slow.
+func (mgr *Manager) trackDeprioritized(sectors []*sectorInfo) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	var fast bool
+	slow := make(map[types.PublicKey]struct{})
+	for _, sector := range sectors {
+		for _, hk := range sector.hks {
+			if _, ok := mgr.downloaders[hk]; !ok {
+				continue
+			} else if mgr.isSlowUnlocked(hk) {
+				slow[hk] = struct{}{}
+			} else {
+				fast = true
+			}
+		}
+	}
+	if !fast {
+		return
+	}
+	for hk := range slow {
+		mgr.deprioritized[hk]++
+	}
+}
+
+// repairSector uploads the given sector to the host in the background. The
+// sector is copied since the caller's buffer is decrypted in place after the
+// download.
+func (mgr *Manager) repairSector(hk types.PublicKey, fcid types.FileContractID, root types.Hash256, data []byte) {
+	mgr.mu.Lock()
+	hi, ok := mgr.hosts[hk]
+	mgr.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	select {
+	case mgr.sectorRepairs <- struct{}{}:
+	default:
+		mgr.logger.Debugw("skipping sector repair, too many repairs in progress", "hk", hk, "root", root)
+		return
+	}
+
+	sector := new([rhpv4.SectorSize]byte)
+	copy(sector[:], data)
+	go func() {
+		defer func() { <-mgr.sectorRepairs }()
+
+		ctx, cancel := context.WithTimeout(mgr.shutdownCtx, sectorRepairTimeout)
+		defer cancel()
+		if err := mgr.hm.Uploader(hi, fcid).UploadSector(ctx, root, sector); err != nil {
+			mgr.logger.Debugw("failed to repair sector", "hk", hk, "root", root, zap.Error(err))
+			return
+		}
+		mgr.statsSectorsRepaired.Add(1)
+	}()
+}
+
+// trackSectorDownload marks the host as slow if the sector download took
+// longer than the overdrive timeout, a fast download clears the status.
+func (mgr *Manager) trackSectorDownload(hk types.PublicKey, d time.Duration) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if _, ok := mgr.downloaders[hk]; !ok {
+		return
+	} else if mgr.overdriveTimeout > 0 && d > mgr.overdriveTimeout {
+		mgr.slowHosts[hk] = mgr.now()
+	} else {
+		delete(mgr.slowHosts, hk)
+	}
+}
+
 type slabSlice struct {
 	object.SlabSlice
 	PartialSlab bool
@@ -0,0 +1,90 @@
+package download
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/internal/download/downloader"
+	"go.sia.tech/renterd/v2/internal/test/mocks"
+	"go.sia.tech/renterd/v2/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestSlowHosts(t *testing.T) {
+	// create a manager with a clock we control
+	const overdriveTimeout = 100 * time.Millisecond
+	var uk utils.UploadKey
+	mgr := NewManager(context.Background(), &uk, mocks.NewHostManager(), nil, nil, 0, overdriveTimeout, 0, zap.NewNop())
+	defer mgr.Stop()
+
+	now := time.Now()
+	mgr.now = func() time.Time { return now }
+
+	// add two downloaders
+	slow, fast := types.PublicKey{1}, types.PublicKey{2}
+	mgr.refreshDownloaders([]api.HostInfo{{PublicKey: slow}, {PublicKey: fast}})
+
+	assertSlow := func(expected bool) {
+		t.Helper()
+		if mgr.isSlow(slow) != expected {
+			t.Fatalf("expected slow to be %v", expected)
+		} else if mgr.isSlow(fast) {
+			t.Fatal("fast host shouldn't be slow")
+		}
+	}
+	assertDeprioritized := func(expected uint64) {
+		t.Helper()
+		stats := mgr.Stats()
+		if stats.Deprioritized[slow] != expected {
+			t.Fatalf("expected slow host to be deprioritized %d times, got %d", expected, stats.Deprioritized[slow])
+		} else if stats.Deprioritized[fast] != 0 {
+			t.Fatalf("expected fast host not to be deprioritized, got %d", stats.Deprioritized[fast])
+		}
+	}
+
+	// a download within the timeout doesn't make a host slow
+	mgr.trackSectorDownload(slow, overdriveTimeout)
+	mgr.trackSectorDownload(fast, overdriveTimeout/2)
+	assertSlow(false)
+
+	// a download that exceeds the timeout does
+	mgr.trackSectorDownload(slow, 2*overdriveTimeout)
+	assertSlow(true)
+
+	// the slow host is only picked if there are no other hosts
+	if d := mgr.fastest([]types.PublicKey{slow, fast}); d.PublicKey() != fast {
+		t.Fatal("expected the fast host to be picked")
+	} else if d := mgr.fastest([]types.PublicKey{slow}); d.PublicKey() != slow {
+		t.Fatal("expected the slow host to be picked")
+	}
+
+	// the slow host is only deprioritized if there are fast hosts
+	mgr.trackDeprioritized([]*sectorInfo{{hks: []types.PublicKey{slow}}, {hks: []types.PublicKey{fast}}})
+	assertDeprioritized(1)
+	mgr.trackDeprioritized([]*sectorInfo{{hks: []types.PublicKey{slow}}})
+	assertDeprioritized(1)
+
+	// the status decays over time
+	now = now.Add(slowHostDecay - time.Nanosecond)
+	assertSlow(true)
+	now = now.Add(time.Nanosecond)
+	assertSlow(false)
+	mgr.trackDeprioritized([]*sectorInfo{{hks: []types.PublicKey{slow}}, {hks: []types.PublicKey{fast}}})
+	assertDeprioritized(1)
+
+	// a request that is still inflight when the slab finishes counts
+	s := &slabDownload{
+		mgr:      mgr,
+		launched: map[*downloader.SectorDownloadReq]time.Time{{Host: mgr.downloaders[slow]}: now},
+	}
+	now = now.Add(2 * overdriveTimeout)
+	s.trackInflight()
+	assertSlow(true)
+
+	// a fast download clears the status
+	mgr.trackSectorDownload(slow, overdriveTimeout/2)
+	assertSlow(false)
+}
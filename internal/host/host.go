@@ -16,6 +16,7 @@ type (
 
 	Uploader interface {
 		UploadSector(context.Context, types.Hash256, *[rhpv4.SectorSize]byte) error
+		VerifySector(context.Context, types.Hash256) error
 		PublicKey() types.PublicKey
 	}
 
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/api"
@@ -32,10 +31,6 @@ type (
 		ForHost(pk types.PublicKey) *accounts.Account
 	}
 
-	Dialer interface {
-		Dial(ctx context.Context, hk types.PublicKey, address string) (net.Conn, error)
-	}
-
 	Manager interface {
 		Downloader(hi api.HostInfo) host.Downloader
 		Uploader(hi api.HostInfo, fcid types.FileContractID) host.Uploader
@@ -73,12 +68,12 @@ type (
 	}
 )
 
-func NewManager(masterKey utils.MasterKey, as AccountStore, csr contracts.SpendingRecorder, dialer Dialer, logger *zap.Logger) Manager {
+func NewManager(masterKey utils.MasterKey, as AccountStore, csr contracts.SpendingRecorder, rhp4Client *rhp4.Client, logger *zap.Logger) Manager {
 	logger = logger.Named("hostmanager")
 	return &hostManager{
 		masterKey: masterKey,
 
-		rhp4Client: rhp4.New(dialer),
+		rhp4Client: rhp4Client,
 
 		accounts:    as,
 		contracts:   csr,
@@ -169,6 +164,21 @@ func (c *hostV2UploadClient) UploadSector(ctx context.Context, sectorRoot types.
 	})
 }
 
+func (c *hostV2UploadClient) VerifySector(ctx context.Context, sectorRoot types.Hash256) error {
+	return c.acc.WithWithdrawal(func() (types.Currency, error) {
+		prices, err := c.pts.Fetch(ctx, c)
+		if err != nil {
+			return types.ZeroCurrency, err
+		}
+
+		res, err := c.rhp4.VerifySector(ctx, c.hi.PublicKey, c.hi.SiamuxAddr(), prices, c.acc.Token(), sectorRoot)
+		if err != nil {
+			return types.ZeroCurrency, fmt.Errorf("failed to verify sector: %w", err)
+		}
+		return res.Usage.RenterCost(), nil
+	})
+}
+
 func (c *hostV2UploadClient) Prices(ctx context.Context) (rhpv4.HostPrices, error) {
 	settings, err := c.rhp4.Settings(ctx, c.hi.PublicKey, c.hi.SiamuxAddr())
 	if err != nil {
@@ -37,15 +37,32 @@ type Client struct {
 }
 
 func New(dialer Dialer) *Client {
+	return NewWithWorkerPool(dialer, nil)
+}
+
+// NewWithWorkerPool returns a client that acquires a slot from the given
+// worker pool before performing any RPC, a nil pool doesn't bound the number
+// of concurrent RPCs.
+func NewWithWorkerPool(dialer Dialer, workers *WorkerPool) *Client {
 	return &Client{
-		tpool: newTransportPool(dialer),
+		tpool: newTransportPool(dialer, workers),
 	}
 }
 
+// Prewarm establishes a connection to the host that is kept open until the
+// returned release function is called.
+func (c *Client) Prewarm(ctx context.Context, hk types.PublicKey, addr string) (release func(), _ error) {
+	return c.tpool.prewarm(ctx, hk, addr)
+}
+
 func IsSectorNotFound(err error) bool {
 	return utils.IsErr(err, rhp4.ErrSectorNotFound)
 }
 
+func IsInvalidProof(err error) bool {
+	return utils.IsErr(err, rhp.ErrInvalidProof)
+}
+
 func (c *Client) Settings(ctx context.Context, hk types.PublicKey, addr string) (hs HostSettings, _ error) {
 	err := c.tpool.withTransport(ctx, hk, addr, func(c rhp.TransportClient) error {
 		var settings rhp4.HostSettings
@@ -85,8 +102,12 @@ func (c *Client) WriteSector(ctx context.Context, hk types.PublicKey, hostIP str
 }
 
 // VerifySector verifies that the host is properly storing a sector
-func (c *Client) VerifySector(ctx context.Context, prices rhp4.HostPrices, token rhp4.AccountToken, root types.Hash256) (rhp.RPCVerifySectorResult, error) {
-	panic("not implemented")
+func (c *Client) VerifySector(ctx context.Context, hk types.PublicKey, hostIP string, prices rhp4.HostPrices, token rhp4.AccountToken, root types.Hash256) (res rhp.RPCVerifySectorResult, _ error) {
+	err := c.tpool.withTransport(ctx, hk, hostIP, func(t rhp.TransportClient) (err error) {
+		res, err = rhp.RPCVerifySector(ctx, t, prices, token, root)
+		return
+	})
+	return res, err
 }
 
 // FreeSectors removes sectors from a contract.
@@ -14,20 +14,30 @@ import (
 )
 
 type transportPool struct {
-	dialer Dialer
+	dialer  Dialer
+	workers *WorkerPool
 
 	mu   sync.Mutex
 	pool map[string]*transport
 }
 
-func newTransportPool(dialer Dialer) *transportPool {
+func newTransportPool(dialer Dialer, workers *WorkerPool) *transportPool {
 	return &transportPool{
-		dialer: dialer,
-		pool:   make(map[string]*transport),
+		dialer:  dialer,
+		workers: workers,
+		pool:    make(map[string]*transport),
 	}
 }
 
 func (p *transportPool) withTransport(ctx context.Context, hk types.PublicKey, addr string, fn func(rhp.TransportClient) error) (err error) {
+	// acquire a slot from the worker pool
+	if p.workers != nil {
+		if err := p.workers.Acquire(ctx); err != nil {
+			return err
+		}
+		defer p.workers.Release()
+	}
+
 	// fetch or create transport
 	p.mu.Lock()
 	t, found := p.pool[addr]
@@ -72,6 +82,42 @@ func (p *transportPool) withTransport(ctx context.Context, hk types.PublicKey, a
 	return err
 }
 
+// prewarm dials a transport to the host and keeps it open until the returned
+// release function is called, RPCs to the host reuse the transport in the
+// meantime.
+func (p *transportPool) prewarm(ctx context.Context, hk types.PublicKey, addr string) (release func(), err error) {
+	p.mu.Lock()
+	t, found := p.pool[addr]
+	if !found {
+		t = &transport{}
+		p.pool[addr] = t
+	}
+	t.refCount++
+	p.mu.Unlock()
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			t.refCount--
+			if t.refCount == 0 {
+				if t.t != nil {
+					_ = t.t.Close()
+					t.t = nil
+				}
+				delete(p.pool, addr)
+			}
+		})
+	}
+
+	if _, err := t.Dial(ctx, p.dialer, hk, addr); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
 type transport struct {
 	refCount uint64 // locked by pool
 
@@ -0,0 +1,73 @@
+package rhp
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// WorkerPool bounds the number of RHP4 operations that can be in flight
+	// at the same time. Operations that can't acquire a slot block until one
+	// is released.
+	WorkerPool struct {
+		slots chan struct{}
+
+		mu      sync.Mutex
+		waiting int
+	}
+
+	// WorkerPoolStats contains the utilization of a worker pool.
+	WorkerPoolStats struct {
+		Size    int
+		InUse   int
+		Waiting int
+	}
+)
+
+// NewWorkerPool returns a worker pool with the given number of slots.
+func NewWorkerPool(size int) *WorkerPool {
+	return &WorkerPool{
+		slots: make(chan struct{}, size),
+	}
+}
+
+// Acquire blocks until a slot is available or the context is done.
+func (p *WorkerPool) Acquire(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	p.mu.Lock()
+	p.waiting++
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.waiting--
+		p.mu.Unlock()
+	}()
+
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// Release releases a slot that was previously acquired.
+func (p *WorkerPool) Release() {
+	<-p.slots
+}
+
+// Stats returns the current utilization of the pool.
+func (p *WorkerPool) Stats() WorkerPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return WorkerPoolStats{
+		Size:    cap(p.slots),
+		InUse:   len(p.slots),
+		Waiting: p.waiting,
+	}
+}
@@ -0,0 +1,45 @@
+package rhp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	p := NewWorkerPool(2)
+	if stats := p.Stats(); stats.Size != 2 || stats.InUse != 0 || stats.Waiting != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// exhaust the pool
+	for i := 0; i < 2; i++ {
+		if err := p.Acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := p.Stats(); stats.InUse != 2 {
+		t.Fatalf("expected 2 slots in use, got %d", stats.InUse)
+	}
+
+	// assert acquiring blocks until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	// assert a blocked acquire succeeds once a slot is released
+	acquired := make(chan error)
+	go func() { acquired <- p.Acquire(context.Background()) }()
+	for p.Stats().Waiting != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	p.Release()
+	if err := <-acquired; err != nil {
+		t.Fatal(err)
+	} else if stats := p.Stats(); stats.InUse != 2 || stats.Waiting != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
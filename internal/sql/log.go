@@ -3,6 +3,7 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -16,12 +17,17 @@ type (
 		query             string
 		log               *zap.Logger
 		longQueryDuration time.Duration
+		metrics           *durationMetrics
 	}
 
 	loggedTxn struct {
 		*sql.Tx
 		log               *zap.Logger
 		longQueryDuration time.Duration
+		metrics           *durationMetrics
+
+		mu        sync.Mutex
+		lastQuery string
 	}
 
 	LoggedRow struct {
@@ -67,6 +73,7 @@ func (lr *LoggedRow) Scan(dest ...any) error {
 func (ls *LoggedStmt) Exec(ctx context.Context, args ...any) (sql.Result, error) {
 	start := time.Now()
 	result, err := ls.Stmt.ExecContext(ctx, args...)
+	ls.metrics.recordQuery(ls.query, start)
 	if dur := time.Since(start); dur > ls.longQueryDuration {
 		ls.log.Warn("slow exec", zap.String("query", ls.query), zap.Duration("elapsed", dur), zap.Stack("stack"))
 	}
@@ -76,6 +83,7 @@ func (ls *LoggedStmt) Exec(ctx context.Context, args ...any) (sql.Result, error)
 func (ls *LoggedStmt) Query(ctx context.Context, args ...any) (*LoggedRows, error) {
 	start := time.Now()
 	rows, err := ls.Stmt.QueryContext(ctx, args...)
+	ls.metrics.recordQuery(ls.query, start)
 	if dur := time.Since(start); dur > ls.longQueryDuration {
 		ls.log.Warn("slow query", zap.String("query", ls.query), zap.Duration("elapsed", dur), zap.Stack("stack"))
 	}
@@ -85,17 +93,33 @@ func (ls *LoggedStmt) Query(ctx context.Context, args ...any) (*LoggedRows, erro
 func (ls *LoggedStmt) QueryRow(ctx context.Context, args ...any) *LoggedRow {
 	start := time.Now()
 	row := ls.Stmt.QueryRowContext(ctx, args...)
+	ls.metrics.recordQuery(ls.query, start)
 	if dur := time.Since(start); dur > ls.longQueryDuration {
 		ls.log.Warn("slow query row", zap.String("query", ls.query), zap.Duration("elapsed", dur), zap.Stack("stack"))
 	}
 	return &LoggedRow{row, ls.log.Named("row"), ls.longQueryDuration}
 }
 
+// LastQuery returns the last query that was executed within the transaction.
+func (lt *loggedTxn) LastQuery() string {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	return lt.lastQuery
+}
+
+func (lt *loggedTxn) setLastQuery(query string) {
+	lt.mu.Lock()
+	lt.lastQuery = query
+	lt.mu.Unlock()
+}
+
 // Exec executes a query without returning any rows. The args are for
 // any placeholder parameters in the query.
 func (lt *loggedTxn) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	lt.setLastQuery(query)
 	start := time.Now()
 	result, err := lt.Tx.ExecContext(ctx, query, args...)
+	lt.metrics.recordQuery(query, start)
 	if dur := time.Since(start); dur > lt.longQueryDuration {
 		lt.log.Warn("slow exec", zap.String("query", query), zap.Duration("elapsed", dur), zap.Stack("stack"))
 	}
@@ -107,6 +131,7 @@ func (lt *loggedTxn) Exec(ctx context.Context, query string, args ...any) (sql.R
 // returned statement. The caller must call the statement's Close method
 // when the statement is no longer needed.
 func (lt *loggedTxn) Prepare(ctx context.Context, query string) (*LoggedStmt, error) {
+	lt.setLastQuery(query)
 	start := time.Now()
 	stmt, err := lt.Tx.PrepareContext(ctx, query)
 	if err != nil {
@@ -119,14 +144,17 @@ func (lt *loggedTxn) Prepare(ctx context.Context, query string) (*LoggedStmt, er
 		query:             query,
 		log:               lt.log.Named("statement"),
 		longQueryDuration: lt.longQueryDuration,
+		metrics:           lt.metrics,
 	}, nil
 }
 
 // Query executes a query that returns rows, typically a SELECT. The
 // args are for any placeholder parameters in the query.
 func (lt *loggedTxn) Query(ctx context.Context, query string, args ...any) (*LoggedRows, error) {
+	lt.setLastQuery(query)
 	start := time.Now()
 	rows, err := lt.Tx.QueryContext(ctx, query, args...)
+	lt.metrics.recordQuery(query, start)
 	if dur := time.Since(start); dur > lt.longQueryDuration {
 		lt.log.Warn("slow query", zap.String("query", query), zap.Duration("elapsed", dur), zap.Stack("stack"))
 	}
@@ -139,8 +167,10 @@ func (lt *loggedTxn) Query(ctx context.Context, query string, args ...any) (*Log
 // Scan will return ErrNoRows. Otherwise, the *Row's Scan scans the
 // first selected row and discards the rest.
 func (lt *loggedTxn) QueryRow(ctx context.Context, query string, args ...any) *LoggedRow {
+	lt.setLastQuery(query)
 	start := time.Now()
 	row := lt.Tx.QueryRowContext(ctx, query, args...)
+	lt.metrics.recordQuery(query, start)
 	if dur := time.Since(start); dur > lt.longQueryDuration {
 		lt.log.Warn("slow query row", zap.String("query", query), zap.Duration("elapsed", dur), zap.Stack("stack"))
 	}
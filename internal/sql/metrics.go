@@ -0,0 +1,139 @@
+package sql
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the buckets that query
+// and transaction durations are recorded in.
+var durationBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type (
+	// A Histogram is a snapshot of recorded durations. Counts holds the
+	// cumulative number of durations that were less than or equal to the
+	// upper bound at the same index in Buckets, Sum is the total of all
+	// durations in seconds.
+	Histogram struct {
+		Buckets []float64
+		Counts  []uint64
+		Count   uint64
+		Sum     float64
+	}
+
+	// DurationStats contains the duration histograms of the queries, labeled
+	// by their normalized query text, and of the transactions executed on a
+	// database.
+	DurationStats struct {
+		Queries      map[string]Histogram
+		Transactions Histogram
+	}
+
+	// durationMetrics records durations without taking a lock, the histogram
+	// of a query is looked up in a sync.Map and its counters are updated
+	// atomically.
+	durationMetrics struct {
+		queries sync.Map // normalized query -> *histogram
+		txns    *histogram
+	}
+
+	// histogram counts durations using atomic counters, the sum is recorded
+	// in nanoseconds. A snapshot taken while durations are being recorded
+	// might be off by the durations that are being recorded.
+	histogram struct {
+		counts []atomic.Uint64
+		count  atomic.Uint64
+		sumNS  atomic.Uint64
+	}
+)
+
+func newDurationMetrics() *durationMetrics {
+	return &durationMetrics{
+		txns: newHistogram(),
+	}
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]atomic.Uint64, len(durationBuckets))}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	secs := d.Seconds()
+	for i, bound := range durationBuckets {
+		if secs <= bound {
+			h.counts[i].Add(1)
+		}
+	}
+	h.count.Add(1)
+	h.sumNS.Add(uint64(max(d, 0)))
+}
+
+func (h *histogram) snapshot() Histogram {
+	counts := make([]uint64, len(h.counts))
+	for i := range h.counts {
+		counts[i] = h.counts[i].Load()
+	}
+	return Histogram{
+		Buckets: append([]float64(nil), durationBuckets...),
+		Counts:  counts,
+		Count:   h.count.Load(),
+		Sum:     time.Duration(h.sumNS.Load()).Seconds(),
+	}
+}
+
+// recordQuery records the duration of the given query, queries are labeled by
+// their normalized text.
+func (m *durationMetrics) recordQuery(query string, start time.Time) {
+	d := time.Since(start)
+	key := normalizeQuery(query)
+
+	h, ok := m.queries.Load(key)
+	if !ok {
+		h, _ = m.queries.LoadOrStore(key, newHistogram())
+	}
+	h.(*histogram).observe(d)
+}
+
+func (m *durationMetrics) recordTransaction(start time.Time) {
+	m.txns.observe(time.Since(start))
+}
+
+func (m *durationMetrics) stats() DurationStats {
+	queries := make(map[string]Histogram)
+	m.queries.Range(func(key, value any) bool {
+		queries[key.(string)] = value.(*histogram).snapshot()
+		return true
+	})
+	return DurationStats{
+		Queries:      queries,
+		Transactions: m.txns.snapshot(),
+	}
+}
+
+// normalizeQuery collapses the whitespace of the given query and replaces
+// lists of placeholders and of rows of placeholders with a single one, so
+// queries that only differ in the number of arguments, e.g. 'IN (?, ?)' and
+// 'IN (?, ?, ?)', are recorded together.
+func normalizeQuery(query string) string {
+	var sb strings.Builder
+	sb.Grow(len(query))
+	for _, field := range strings.Fields(query) {
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(field)
+	}
+	normalized := sb.String()
+	for strings.Contains(normalized, "?, ?") {
+		normalized = strings.ReplaceAll(normalized, "?, ?", "?")
+	}
+	for strings.Contains(normalized, "?,?") {
+		normalized = strings.ReplaceAll(normalized, "?,?", "?")
+	}
+	for strings.Contains(normalized, "(?), (?)") {
+		normalized = strings.ReplaceAll(normalized, "(?), (?)", "(?)")
+	}
+	return normalized
+}
@@ -0,0 +1,49 @@
+package sql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"\n\tSELECT a,   b\nFROM t WHERE id = ?\n", "SELECT a, b FROM t WHERE id = ?"},
+		{"SELECT a FROM t WHERE id IN (?, ?, ?)", "SELECT a FROM t WHERE id IN (?)"},
+		{"SELECT a FROM t WHERE id IN (?,?)", "SELECT a FROM t WHERE id IN (?)"},
+		{"INSERT INTO t (a, b) VALUES (?, ?), (?, ?), (?, ?)", "INSERT INTO t (a, b) VALUES (?)"},
+		{"UPDATE t SET a = ?, b = ? WHERE id = ?", "UPDATE t SET a = ?, b = ? WHERE id = ?"},
+	}
+	for _, test := range tests {
+		if got := normalizeQuery(test.query); got != test.want {
+			t.Errorf("normalizeQuery(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestDurationMetrics(t *testing.T) {
+	m := newDurationMetrics()
+
+	// record the same query with a different number of placeholders
+	start := time.Now().Add(-2 * time.Millisecond)
+	m.recordQuery("SELECT a FROM t WHERE id IN (?, ?)", start)
+	m.recordQuery("SELECT a FROM t WHERE id IN (?, ?, ?)", start)
+	m.recordTransaction(start)
+
+	stats := m.stats()
+	h, ok := stats.Queries["SELECT a FROM t WHERE id IN (?)"]
+	if !ok || len(stats.Queries) != 1 {
+		t.Fatalf("unexpected queries %v", stats.Queries)
+	} else if h.Count != 2 {
+		t.Fatalf("expected 2 queries, got %d", h.Count)
+	} else if h.Counts[0] != 0 || h.Counts[len(h.Counts)-1] != 2 {
+		t.Fatalf("unexpected counts %v", h.Counts)
+	} else if h.Sum < 0.004 {
+		t.Fatalf("unexpected sum %v", h.Sum)
+	} else if stats.Transactions.Count != 1 {
+		t.Fatalf("expected 1 transaction, got %d", stats.Transactions.Count)
+	}
+}
@@ -561,6 +561,138 @@ var (
 					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00037_remove_legacy", log)
 				},
 			},
+			{
+				ID: "00038_contract_revisions",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00038_contract_revisions", log)
+				},
+			},
+			{
+				ID: "00039_contract_events",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00039_contract_events", log)
+				},
+			},
+			{
+				ID: "00040_autopilot_max_contracts_per_subnet",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00040_autopilot_max_contracts_per_subnet", log)
+				},
+			},
+			{
+				ID: "00041_autopilot_max_contracts_per_host",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00041_autopilot_max_contracts_per_host", log)
+				},
+			},
+			{
+				ID: "00042_dismissed_alerts",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00042_dismissed_alerts", log)
+				},
+			},
+			{
+				ID: "00043_bucket_usage",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00043_bucket_usage", log)
+				},
+			},
+			{
+				ID: "00044_contract_network_fees",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00044_contract_network_fees", log)
+				},
+			},
+			{
+				ID: "00045_autopilot_storage_projection",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00045_autopilot_storage_projection", log)
+				},
+			},
+			{
+				ID: "00046_object_versions",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00046_object_versions", log)
+				},
+			},
+			{
+				ID: "00047_contract_state_changes",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00047_contract_state_changes", log)
+				},
+			},
+			{
+				ID: "00048_bucket_redundancy",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00048_bucket_redundancy", log)
+				},
+			},
+			{
+				ID: "00049_contract_spending_cap",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00049_contract_spending_cap", log)
+				},
+			},
+			{
+				ID: "00050_host_sector_size",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00050_host_sector_size", log)
+				},
+			},
+			{
+				ID: "00051_autopilot_prune_alert_threshold",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00051_autopilot_prune_alert_threshold", log)
+				},
+			},
+			{
+				ID: "00052_contract_usability_override",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00052_contract_usability_override", log)
+				},
+			},
+			{
+				ID: "00053_contract_verification_status",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00053_contract_verification_status", log)
+				},
+			},
+			{
+				ID: "00054_autopilot_min_wallet_balance",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00054_autopilot_min_wallet_balance", log)
+				},
+			},
+			{
+				ID: "00055_autopilot_min_contract_lifetime",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00055_autopilot_min_contract_lifetime", log)
+				},
+			},
+			{
+				ID: "00056_contract_host_version",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00056_contract_host_version", log)
+				},
+			},
+			{
+				ID: "00057_autopilot_period_multiplier",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00057_autopilot_period_multiplier", log)
+				},
+			},
+			{
+				ID: "00058_contract_state_changes_fk",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00058_contract_state_changes_fk", log)
+				},
+			},
+			{
+				ID: "00059_contract_events_fk",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00059_contract_events_fk", log)
+				},
+			},
 		}
 	}
 	MetricsMigrations = func(ctx context.Context, migrationsFs embed.FS, log *zap.SugaredLogger) []Migration {
@@ -28,6 +28,15 @@ const (
 var (
 	ErrRunV072               = errors.New("can't upgrade to >=v1.0.0 from your current version - please upgrade to v0.7.2 first (https://github.com/SiaFoundation/renterd/releases/tag/v0.7.2)")
 	ErrMySQLNoSuperPrivilege = errors.New("You do not have the SUPER privilege and binary logging is enabled")
+
+	// ErrDatabaseLocked is returned when a transaction keeps failing
+	// because the database is locked.
+	ErrDatabaseLocked = errors.New("database locked")
+
+	// ErrTransactionTimeout is returned when a transaction is rolled back
+	// because it exceeded its deadline. It is used as the cause of the
+	// transaction's context.
+	ErrTransactionTimeout = errors.New("transaction timed out")
 )
 
 type (
@@ -38,6 +47,7 @@ type (
 		log               *zap.Logger
 		longQueryDuration time.Duration
 		longTxDuration    time.Duration
+		metrics           *durationMetrics
 	}
 
 	// A txn is an interface for executing queries within a transaction.
@@ -72,6 +82,7 @@ func NewDB(db *sql.DB, log *zap.Logger, dbLockedMsgs []string, longQueryDuration
 		log:               log,
 		longQueryDuration: longQueryDuration,
 		longTxDuration:    longTxDuration,
+		metrics:           newDurationMetrics(),
 	}, nil
 }
 
@@ -79,11 +90,18 @@ func (s *DB) DB() *sql.DB {
 	return s.db
 }
 
+// DurationStats returns the duration histograms of the queries and
+// transactions executed on the database.
+func (s *DB) DurationStats() DurationStats {
+	return s.metrics.stats()
+}
+
 // exec executes a query without returning any rows. The args are for
 // any placeholder parameters in the query.
 func (s *DB) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
 	start := time.Now()
 	result, err := s.db.ExecContext(ctx, query, args...)
+	s.metrics.recordQuery(query, start)
 	if dur := time.Since(start); dur > s.longQueryDuration {
 		s.log.Debug("slow exec", zap.String("query", query), zap.Duration("elapsed", dur), zap.Stack("stack"))
 	}
@@ -107,6 +125,7 @@ func (s *DB) Prepare(ctx context.Context, query string) (*LoggedStmt, error) {
 		query:             query,
 		log:               s.log.Named("statement"),
 		longQueryDuration: s.longQueryDuration,
+		metrics:           s.metrics,
 	}, nil
 }
 
@@ -115,6 +134,7 @@ func (s *DB) Prepare(ctx context.Context, query string) (*LoggedStmt, error) {
 func (s *DB) Query(ctx context.Context, query string, args ...any) (*LoggedRows, error) {
 	start := time.Now()
 	rows, err := s.db.QueryContext(ctx, query, args...)
+	s.metrics.recordQuery(query, start)
 	if dur := time.Since(start); dur > s.longQueryDuration {
 		s.log.Debug("slow query", zap.String("query", query), zap.Duration("elapsed", dur), zap.Stack("stack"))
 	}
@@ -129,24 +149,74 @@ func (s *DB) Query(ctx context.Context, query string, args ...any) (*LoggedRows,
 func (s *DB) QueryRow(ctx context.Context, query string, args ...any) *LoggedRow {
 	start := time.Now()
 	row := s.db.QueryRowContext(ctx, query, args...)
+	s.metrics.recordQuery(query, start)
 	if dur := time.Since(start); dur > s.longQueryDuration {
 		s.log.Debug("slow query row", zap.String("query", query), zap.Duration("elapsed", dur), zap.Stack("stack"))
 	}
 	return &LoggedRow{row, s.log.Named("row"), s.longQueryDuration}
 }
 
+type (
+	// retryPolicyKey is the context key of the retry policy set by
+	// WithRetryPolicy.
+	retryPolicyKey struct{}
+
+	// txTimeoutKey is the context key of the timeout set by
+	// WithTransactionTimeout.
+	txTimeoutKey struct{}
+)
+
+// retryPolicy overrides the default retry behaviour of transactions that fail
+// because the database is locked.
+type retryPolicy struct {
+	maxAttempts int
+	backoffBase time.Duration
+}
+
+// WithRetryPolicy returns a context that makes transactions which fail
+// because the database is locked retry up to maxAttempts times in total
+// instead of 'maxRetryAttempts' times. The backoff between attempts starts at
+// backoffBase, which is at least a millisecond, and doubles after every
+// attempt.
+func WithRetryPolicy(ctx context.Context, maxAttempts int, backoffBase time.Duration) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, retryPolicy{
+		maxAttempts: max(maxAttempts, 1),
+		backoffBase: max(backoffBase, time.Millisecond),
+	})
+}
+
+// WithTransactionTimeout returns a context that makes every attempt of a
+// transaction roll back if it takes longer than the given timeout. The
+// resulting error wraps ErrTransactionTimeout. A timeout of zero disables it.
+func WithTransactionTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, txTimeoutKey{}, timeout)
+}
+
 // transaction executes a function within a database transaction. If the
 // function returns an error, the transaction is rolled back. Otherwise, the
 // transaction is committed. If the transaction fails due to a busy error, it is
-// retried up to 'maxRetryAttempts' times before returning.
+// retried up to 'maxRetryAttempts' times, or as often as the retry policy set
+// on the context allows, before returning an error that wraps
+// ErrDatabaseLocked.
 func (s *DB) Transaction(ctx context.Context, fn func(Tx) error) error {
+	maxAttempts := maxRetryAttempts
+	backoff := func(attempt int) time.Duration {
+		return time.Duration(math.Pow(factor, float64(attempt))) * time.Millisecond
+	}
+	if policy, ok := ctx.Value(retryPolicyKey{}).(retryPolicy); ok {
+		maxAttempts = policy.maxAttempts
+		backoff = func(attempt int) time.Duration {
+			return policy.backoffBase << (attempt - 1)
+		}
+	}
+
 	var err error
 	txnID := hex.EncodeToString(frand.Bytes(4))
 	log := s.log.Named("transaction").With(zap.String("id", txnID))
 	start := time.Now()
 	attempt := 1
 LOOP:
-	for ; attempt < maxRetryAttempts; attempt++ {
+	for ; ; attempt++ {
 		attemptStart := time.Now()
 		log := log.With(zap.Int("attempt", attempt))
 		err = s.transaction(ctx, fn)
@@ -168,10 +238,14 @@ LOOP:
 		}
 		if !locked {
 			return err
+		} else if attempt >= maxAttempts {
+			err = fmt.Errorf("%w: %w", ErrDatabaseLocked, err)
+			break LOOP
 		}
+
 		// exponential backoff
-		sleep := time.Duration(math.Pow(factor, float64(attempt))) * time.Millisecond
-		if sleep > maxBackoff {
+		sleep := backoff(attempt)
+		if sleep <= 0 || sleep > maxBackoff {
 			sleep = maxBackoff
 		}
 		lvl := zapcore.DebugLevel
@@ -197,8 +271,15 @@ func (s *DB) Close() error {
 
 // transaction is a helper function to execute a function within a transaction.
 // If fn returns an error, the transaction is rolled back. Otherwise, the
-// transaction is committed.
+// transaction is committed. The timeout set on the context applies to this
+// attempt only.
 func (s *DB) transaction(ctx context.Context, fn func(tx Tx) error) error {
+	if timeout, ok := ctx.Value(txTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, ErrTransactionTimeout)
+		defer cancel()
+	}
+
 	start := time.Now()
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -210,6 +291,8 @@ func (s *DB) transaction(ctx context.Context, fn func(tx Tx) error) error {
 		}
 	}()
 	defer func() {
+		s.metrics.recordTransaction(start)
+
 		// log the transaction if it took longer than txn duration
 		if time.Since(start) > s.longTxDuration {
 			s.log.Debug("long transaction", zap.Duration("elapsed", time.Since(start)), zap.Stack("stack"), zap.Bool("failed", err != nil))
@@ -220,15 +303,27 @@ func (s *DB) transaction(ctx context.Context, fn func(tx Tx) error) error {
 		Tx:                tx,
 		log:               s.log,
 		longQueryDuration: s.longQueryDuration,
+		metrics:           s.metrics,
 	}
 	if err := fn(ltx); err != nil {
-		return err
+		return s.checkTimeout(ctx, ltx, start, err)
 	} else if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return s.checkTimeout(ctx, ltx, start, fmt.Errorf("failed to commit transaction: %w", err))
 	}
 	return nil
 }
 
+// checkTimeout wraps the given error with ErrTransactionTimeout if the
+// transaction failed because its context timed out with ErrTransactionTimeout
+// as its cause.
+func (s *DB) checkTimeout(ctx context.Context, ltx *loggedTxn, start time.Time, err error) error {
+	if errors.Is(err, ErrTransactionTimeout) || !errors.Is(context.Cause(ctx), ErrTransactionTimeout) {
+		return err
+	}
+	s.log.Warn("transaction timed out and was rolled back, see the slow query log for queries that exceeded the slow threshold", zap.String("query", ltx.LastQuery()), zap.Duration("elapsed", time.Since(start)), zap.Stack("stack"))
+	return fmt.Errorf("%w: %w", ErrTransactionTimeout, err)
+}
+
 // jitterSleep sleeps for a random duration between t and t*1.5.
 func jitterAfter(t time.Duration) <-chan time.Time {
 	return time.After(t + time.Duration(rand.Int63n(int64(t/2))))
@@ -0,0 +1,78 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+func TestTransactionRetryPolicy(t *testing.T) {
+	sdb, err := sql.Open("sqlite3", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewDB(sdb, zap.NewNop(), []string{"database is locked"}, time.Second, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// helper that fails the first n attempts with the given error
+	failing := func(n int, err error) (func(Tx) error, *int) {
+		var calls int
+		return func(Tx) error {
+			calls++
+			if calls <= n {
+				return err
+			}
+			return nil
+		}, &calls
+	}
+	errLocked := errors.New("database is locked")
+	ctx := WithRetryPolicy(context.Background(), 3, time.Millisecond)
+
+	// assert locked transactions are retried until they succeed
+	fn, calls := failing(2, errLocked)
+	if err := db.Transaction(ctx, fn); err != nil {
+		t.Fatal(err)
+	} else if *calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", *calls)
+	}
+
+	// assert no more than the policy's max attempts are made
+	fn, calls = failing(3, errLocked)
+	if err := db.Transaction(ctx, fn); !errors.Is(err, ErrDatabaseLocked) {
+		t.Fatal("expected ErrDatabaseLocked, got", err)
+	} else if *calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", *calls)
+	}
+
+	// assert other errors aren't retried
+	errOther := errors.New("other")
+	fn, calls = failing(1, errOther)
+	if err := db.Transaction(ctx, fn); !errors.Is(err, errOther) || errors.Is(err, ErrDatabaseLocked) {
+		t.Fatal("expected errOther, got", err)
+	} else if *calls != 1 {
+		t.Fatalf("expected 1 attempt, got %d", *calls)
+	}
+
+	// assert retries stop when the context is cancelled and the error isn't
+	// reported as the database being locked
+	cancelCtx, cancel := context.WithCancel(WithRetryPolicy(context.Background(), 3, time.Hour))
+	fn, calls = failing(3, errLocked)
+	if err := db.Transaction(cancelCtx, func(tx Tx) error {
+		cancel()
+		return fn(tx)
+	}); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected context.Canceled, got", err)
+	} else if errors.Is(err, ErrDatabaseLocked) {
+		t.Fatal("unexpected ErrDatabaseLocked", err)
+	} else if *calls != 1 {
+		t.Fatalf("expected 1 attempt, got %d", *calls)
+	}
+}
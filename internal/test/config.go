@@ -22,6 +22,9 @@ var (
 			Storage:  rhpv4.SectorSize * 5e3,
 
 			Prune: false,
+
+			MaxContractsPerSubnet: 1,
+			MaxContractsPerHost:   1,
 		},
 		Hosts: api.HostsConfig{
 			MaxDowntimeHours:           10,
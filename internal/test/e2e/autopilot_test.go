@@ -2,9 +2,14 @@ package e2e
 
 import (
 	"context"
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/bus/client"
 	"go.sia.tech/renterd/v2/internal/test"
@@ -26,7 +31,7 @@ func TestAutopilot(t *testing.T) {
 	}
 
 	// assert hosts and contracts config are defaulted
-	if ap.Contracts != test.AutopilotConfig.Contracts {
+	if !reflect.DeepEqual(ap.Contracts, test.AutopilotConfig.Contracts) {
 		t.Fatalf("contracts config should be defaulted, got %v", ap.Contracts)
 	} else if ap.Hosts != test.AutopilotConfig.Hosts {
 		t.Fatalf("hosts config should be defaulted, got %v", ap.Hosts)
@@ -57,10 +62,43 @@ func TestAutopilot(t *testing.T) {
 	if err := b.UpdateAutopilotConfig(context.Background(), client.WithContractsConfig(c)); err == nil || !strings.Contains(err.Error(), "renewWindow must be greater than 0") {
 		t.Fatal("unexpected", err)
 	}
-	c.RenewWindow = 1 // valid renew window
+	c.RenewWindow = 1                                // valid renew window
+	c.StorageProjection = map[string]uint64{"": 1e9} // invalid category
+	if err := b.UpdateAutopilotConfig(context.Background(), client.WithContractsConfig(c)); err == nil || !strings.Contains(err.Error(), "storageProjection categories must not be empty") {
+		t.Fatal("unexpected", err)
+	}
+	c.StorageProjection = map[string]uint64{"backups": 1e9, "media": 2e9} // valid projection
 	if err := b.UpdateAutopilotConfig(context.Background(), client.WithContractsConfig(c)); err != nil {
 		t.Fatal(err)
 	}
+	ap, err = b.AutopilotConfig(context.Background())
+	tt.OK(err)
+	if !reflect.DeepEqual(ap.Contracts.StorageProjection, c.StorageProjection) {
+		t.Fatalf("unexpected storage projection %v", ap.Contracts.StorageProjection)
+	}
+	c.MinContractLifetimeBlocks = c.Period + c.RenewWindow + 1 // exceeds lifetime
+	if err := b.UpdateAutopilotConfig(context.Background(), client.WithContractsConfig(c)); !utils.IsErr(err, api.ErrContractLifetimeConflict) {
+		t.Fatal("unexpected", err)
+	}
+	c.MinContractLifetimeBlocks = c.Period + c.RenewWindow // allowed max
+	tt.OK(b.UpdateAutopilotConfig(context.Background(), client.WithContractsConfig(c)))
+	ap, err = b.AutopilotConfig(context.Background())
+	tt.OK(err)
+	if ap.Contracts.MinContractLifetimeBlocks != c.MinContractLifetimeBlocks {
+		t.Fatalf("unexpected min contract lifetime %v", ap.Contracts.MinContractLifetimeBlocks)
+	}
+	c.PeriodMultiplier = 0.5 // invalid multiplier
+	if err := b.UpdateAutopilotConfig(context.Background(), client.WithContractsConfig(c)); err == nil || !strings.Contains(err.Error(), "periodMultiplier must be at least 1") {
+		t.Fatal("unexpected", err)
+	}
+	c.PeriodMultiplier = 1.5 // valid multiplier
+	c.PeriodMultiplierGrowthFactor = 3
+	tt.OK(b.UpdateAutopilotConfig(context.Background(), client.WithContractsConfig(c)))
+	ap, err = b.AutopilotConfig(context.Background())
+	tt.OK(err)
+	if ap.Contracts.PeriodMultiplier != 1.5 || ap.Contracts.PeriodMultiplierGrowthFactor != 3 {
+		t.Fatalf("unexpected period multiplier %v %v", ap.Contracts.PeriodMultiplier, ap.Contracts.PeriodMultiplierGrowthFactor)
+	}
 
 	// assert we can disable the autopilot
 	tt.OK(b.UpdateAutopilotConfig(context.Background(), client.WithAutopilotEnabled(false)))
@@ -69,4 +107,76 @@ func TestAutopilot(t *testing.T) {
 	if ap.Enabled {
 		t.Fatal("autopilot should be disabled")
 	}
+
+	// assert we can patch the config
+	patched, err := b.PatchAutopilotConfig(context.Background(), map[string]any{
+		"enabled":   true,
+		"contracts": map[string]any{"amount": ap.Contracts.Amount + 1},
+	}, ap.ETag())
+	tt.OK(err)
+	if !patched.Enabled {
+		t.Fatal("autopilot should be enabled")
+	} else if patched.Contracts.Amount != ap.Contracts.Amount+1 {
+		t.Fatal("unexpected amount", patched.Contracts.Amount)
+	} else if patched.Contracts.Period != ap.Contracts.Period || patched.Hosts != ap.Hosts {
+		t.Fatal("unpatched fields should be unchanged")
+	}
+
+	// assert patching with a stale etag fails
+	if _, err := b.PatchAutopilotConfig(context.Background(), map[string]any{"enabled": false}, ap.ETag()); !utils.IsErr(err, api.ErrAutopilotConfigModified) {
+		t.Fatal("unexpected", err)
+	}
+
+	// assert patches are validated
+	if _, err := b.PatchAutopilotConfig(context.Background(), map[string]any{"contracts": map[string]any{"period": 0}}, ""); err == nil || !strings.Contains(err.Error(), "period must be greater than 0") {
+		t.Fatal("unexpected", err)
+	}
+}
+
+func TestAutopilotMinWalletBalance(t *testing.T) {
+	// create test cluster that requires more funds than the wallet has
+	apCfg := test.AutopilotConfig
+	apCfg.Contracts.MinWalletBalance = types.Siacoins(1e9)
+	cluster := newTestCluster(t, testClusterOptions{
+		autopilotConfig: &apCfg,
+	})
+	defer cluster.Shutdown()
+	tt := cluster.tt
+	b := cluster.Bus
+
+	// add a host
+	cluster.AddHosts(1)
+
+	// assert operations are paused
+	tt.Retry(100, 100*time.Millisecond, func() error {
+		res, err := b.Alerts(context.Background(), alerts.AlertsOpts{Severity: alerts.SeverityCritical})
+		tt.OK(err)
+		for _, a := range res.Alerts {
+			if a.Message == "Autopilot operations are paused" {
+				return nil
+			}
+		}
+		return errors.New("alert not registered")
+	})
+	contracts, err := b.Contracts(context.Background(), api.ContractsOpts{})
+	tt.OK(err)
+	if len(contracts) != 0 {
+		t.Fatalf("expected no contracts to be formed, got %d", len(contracts))
+	}
+
+	// lower the minimum, the autopilot should form contracts and dismiss
+	// the alert
+	apCfg.Contracts.MinWalletBalance = types.ZeroCurrency
+	tt.OK(b.UpdateAutopilotConfig(context.Background(), client.WithContractsConfig(apCfg.Contracts)))
+	cluster.WaitForContracts()
+	tt.Retry(100, 100*time.Millisecond, func() error {
+		res, err := b.Alerts(context.Background(), alerts.AlertsOpts{Severity: alerts.SeverityCritical})
+		tt.OK(err)
+		for _, a := range res.Alerts {
+			if a.Message == "Autopilot operations are paused" {
+				return errors.New("alert not dismissed")
+			}
+		}
+		return nil
+	})
 }
@@ -359,7 +359,7 @@ func newTestCluster(t *testing.T, opts testClusterOptions) *TestCluster {
 	tt.OK(err)
 
 	tokens := api.NewTokenStore()
-	busAuth := api.Auth(tokens, busPassword)
+	busAuth := api.Auth(tokens, api.NewKeyStore(busPassword))
 	busServer := &http.Server{
 		Handler: api.TreeMux{
 			Handler: renterd.Handler(), // ui
@@ -380,7 +380,7 @@ func newTestCluster(t *testing.T, opts testClusterOptions) *TestCluster {
 	w, err := worker.New(workerCfg, workerKey, busClient, logger)
 	tt.OK(err)
 
-	workerServer := http.Server{Handler: api.WorkerAuth(tokens, workerPassword, false)(w.Handler())}
+	workerServer := http.Server{Handler: api.WorkerAuth(tokens, api.NewKeyStore(workerPassword), false)(w.Handler())}
 	var workerShutdownFns []func(context.Context) error
 	workerShutdownFns = append(workerShutdownFns, workerServer.Shutdown)
 	workerShutdownFns = append(workerShutdownFns, w.Shutdown)
@@ -396,7 +396,7 @@ func newTestCluster(t *testing.T, opts testClusterOptions) *TestCluster {
 	ap, err := newTestAutopilot(workerKey, apCfg, busClient, logger)
 	tt.OK(err)
 
-	autopilotAuth := api.Auth(tokens, autopilotPassword)
+	autopilotAuth := api.Auth(tokens, api.NewKeyStore(autopilotPassword))
 	autopilotServer := http.Server{
 		Handler: autopilotAuth(ap.Handler()),
 	}
@@ -542,28 +542,33 @@ func newTestAutopilot(masterKey utils.MasterKey, cfg config.Autopilot, bus *bus.
 	l = l.Named("autopilot")
 
 	ctx, cancel := context.WithCancelCause(context.Background())
-	m, err := migrator.New(ctx, masterKey, a, bus, bus, cfg.MigratorHealthCutoff, cfg.MigratorNumThreads, cfg.MigratorDownloadMaxOverdrive, cfg.MigratorUploadMaxOverdrive, cfg.MigratorDownloadOverdriveTimeout, cfg.MigratorUploadOverdriveTimeout, cfg.MigratorAccountsRefillInterval, l)
+	m, err := migrator.New(ctx, masterKey, a, bus, bus, cfg.MigratorHealthCutoff, cfg.MigratorRepairHealthThreshold, cfg.MigratorNumThreads, cfg.MigratorDownloadMaxOverdrive, cfg.MigratorUploadMaxOverdrive, cfg.MigratorDownloadOverdriveTimeout, cfg.MigratorUploadOverdriveTimeout, cfg.MigratorAccountsRefillInterval, l)
 	if err != nil {
 		cancel(nil)
 		return nil, err
 	}
 
-	s, err := scanner.New(bus, cfg.ScannerBatchSize, cfg.ScannerNumThreads, cfg.ScannerInterval, l)
+	s, err := scanner.New(a, bus, cfg.ScannerBatchSize, cfg.ScannerNumThreads, cfg.ScannerInterval, cfg.ScannerPriceChangeThreshold, l)
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+
+	p, err := pruner.New(a, bus, cfg.PruneParallelism, l)
 	if err != nil {
 		cancel(nil)
 		return nil, err
 	}
 
 	c := contractor.New(bus, bus, bus, bus, bus, cfg.RevisionSubmissionBuffer, cfg.RevisionBroadcastInterval, cfg.AllowRedundantHostIPs, l)
-	p := pruner.New(bus, l)
-	w := walletmaintainer.New(a, bus, l, walletmaintainer.WithNumOutputs(5, 5))
+	w := walletmaintainer.New(a, bus, l, walletmaintainer.WithNumOutputs(5, 5), walletmaintainer.WithOutputAmount(cfg.WalletOutputAmount))
 
-	return autopilot.New(ctx, cancel, bus, c, m, p, s, w, cfg.Heartbeat, l), nil
+	return autopilot.New(ctx, cancel, a, bus, c, m, p, s, w, cfg.Heartbeat, l), nil
 }
 
 func newTestBus(cm *chain.Manager, genesisBlock types.Block, dir string, cfg config.Bus, cfgDb dbConfig, pk types.PrivateKey, logger *zap.Logger) (*bus.Bus, func(ctx context.Context) error, *chain.Manager, bus.Store, error) {
 	// create store config
-	alertsMgr := alerts.NewManager()
+	alertsMgr := alerts.NewManager(alerts.Config{})
 	storeCfg, err := buildStoreConfig(alertsMgr, dir, cfg.SlabBufferCompletionThreshold, cfgDb, pk, logger)
 	if err != nil {
 		return nil, nil, nil, nil, err
@@ -977,6 +982,7 @@ func testBusCfg() config.Bus {
 		AnnouncementMaxAgeHours:       24 * 7 * 52, // 1 year
 		Bootstrap:                     false,
 		GatewayAddr:                   "127.0.0.1:0",
+		PendingContractTimeoutBlocks:  1008,
 		UsedUTXOExpiry:                time.Minute,
 		SlabBufferCompletionThreshold: 0,
 	}
@@ -1024,6 +1030,8 @@ func testApCfg() config.Autopilot {
 		MigratorUploadOverdriveTimeout:   500 * time.Millisecond,
 		MigratorUploadMaxOverdrive:       5,
 
+		PruneParallelism: 2,
+
 		ScannerInterval:   10 * time.Millisecond,
 		ScannerBatchSize:  10,
 		ScannerNumThreads: 1,
@@ -1102,7 +1110,6 @@ func buildStoreConfig(am alerts.Alerter, dir string, slabBufferCompletionThresho
 		WalletAddress:                 types.StandardUnlockHash(pk.PublicKey()),
 
 		LongQueryDuration: cfg.DatabaseLog.SlowThreshold,
-		LongTxDuration:    cfg.DatabaseLog.SlowThreshold,
 	}, nil
 }
 
@@ -29,6 +29,7 @@ import (
 	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/autopilot/contractor"
+	"go.sia.tech/renterd/v2/autopilot/walletmaintainer"
 	"go.sia.tech/renterd/v2/config"
 	ibus "go.sia.tech/renterd/v2/internal/bus"
 	"go.sia.tech/renterd/v2/internal/test"
@@ -36,6 +37,7 @@ import (
 	"go.sia.tech/renterd/v2/object"
 	"go.sia.tech/renterd/v2/stores/sql"
 	"go.sia.tech/renterd/v2/stores/sql/sqlite"
+	"go.uber.org/zap"
 	"lukechampine.com/frand"
 )
 
@@ -244,6 +246,20 @@ func TestNewTestCluster(t *testing.T) {
 		return nil
 	})
 
+	// assert the renew estimate covers the contract price and renewing with
+	// less funds is rejected
+	renewal, err := cluster.Bus.Contract(context.Background(), renewalID)
+	tt.OK(err)
+	estimate, err := cluster.Bus.RenewContractEstimate(context.Background(), renewalID, renewal.EndHeight()+10)
+	tt.OK(err)
+	if estimate.MinRenterFunds.Cmp(renewal.ContractPrice) < 0 {
+		t.Fatalf("estimate %v should cover the contract price %v", estimate.MinRenterFunds, renewal.ContractPrice)
+	}
+	_, err = cluster.Bus.RenewContract(context.Background(), renewalID, renewal.EndHeight()+10, types.NewCurrency64(1), types.ZeroCurrency, 1)
+	if !utils.IsErr(err, api.ErrInsufficientRenterFunds) {
+		t.Fatalf("expected ErrInsufficientRenterFunds, got %v", err)
+	}
+
 	// Mine until before the window start to give the host time to submit the
 	// revision first.
 	cs, err := cluster.Bus.ConsensusState(context.Background())
@@ -300,6 +316,29 @@ func TestNewTestCluster(t *testing.T) {
 		} else if hi.V2Settings.Release == "" {
 			t.Fatal("release should be set")
 		}
+
+		// assert the score breakdown is exposed
+		hs, err := cluster.Bus.HostScore(context.Background(), host.PublicKey)
+		tt.OK(err)
+		if hs.HostScoreBreakdown != hi.Checks.ScoreBreakdown {
+			t.Fatalf("unexpected score breakdown %v != %v", hs.HostScoreBreakdown, hi.Checks.ScoreBreakdown)
+		} else if hs.Overall != hi.Checks.ScoreBreakdown.Score() {
+			t.Fatalf("unexpected overall score %v != %v", hs.Overall, hi.Checks.ScoreBreakdown.Score())
+		}
+
+		// assert the host's settings can be fetched, the test cluster disables
+		// the host settings cache so they are fetched again every time
+		settings, err := cluster.Bus.HostSettings(context.Background(), host.PublicKey)
+		tt.OK(err)
+		if settings.Settings.Release == "" {
+			t.Fatal("release should be set")
+		} else if settings.CachedAt.IsZero() {
+			t.Fatal("cachedAt should be set")
+		} else if refetched, err := cluster.Bus.HostSettings(context.Background(), host.PublicKey); err != nil {
+			t.Fatal(err)
+		} else if !refetched.CachedAt.After(settings.CachedAt) {
+			t.Fatalf("expected settings to be refetched, %v <= %v", refetched.CachedAt, settings.CachedAt)
+		}
 	}
 	hostInfos, err := cluster.Bus.Hosts(context.Background(), api.HostOptions{
 		FilterMode:    api.HostFilterModeAll,
@@ -634,6 +673,14 @@ func TestObjectsRename(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
+
+	// move a file to another bucket and download it from there
+	tt.OK(b.CreateBucket(context.Background(), "other", api.CreateBucketOptions{}))
+	tt.OK(b.MoveObject(context.Background(), testBucket, "other", "/bat", "/bat", false))
+	if err := w.DownloadObject(context.Background(), bytes.NewBuffer(nil), testBucket, "/bat", api.DownloadObjectOptions{}); err == nil || !strings.Contains(err.Error(), api.ErrObjectNotFound.Error()) {
+		t.Fatal("unexpected error", err)
+	}
+	tt.OK(w.DownloadObject(context.Background(), bytes.NewBuffer(nil), "other", "/bat", api.DownloadObjectOptions{}))
 }
 
 // TestUploadDownloadEmpty is an integration test that verifies empty objects
@@ -745,6 +792,23 @@ func TestUploadDownloadBasic(t *testing.T) {
 		}
 		return nil
 	})
+
+	// assert the cost of the object can be estimated using its size
+	estimate, err := cluster.Bus.ObjectCostEstimate(context.Background(), testBucket, path, 0, 144)
+	tt.OK(err)
+	if estimate.UploadCost.IsZero() || estimate.StorageCost.IsZero() || estimate.DownloadCost.IsZero() {
+		t.Fatalf("unexpected estimate %+v", estimate)
+	} else if !estimate.TotalCost.Equals(estimate.UploadCost.Add(estimate.StorageCost).Add(estimate.DownloadCost)) {
+		t.Fatalf("unexpected total cost %v", estimate.TotalCost)
+	} else if other, err := cluster.Bus.ObjectCostEstimate(context.Background(), testBucket, "other", uint64(len(data)), 144); err != nil {
+		t.Fatal(err)
+	} else if other != estimate {
+		t.Fatalf("expected estimates to match, %+v != %+v", other, estimate)
+	} else if _, err := cluster.Bus.ObjectCostEstimate(context.Background(), testBucket, "other", 0, 144); err == nil {
+		t.Fatal("expected error for missing object without size")
+	} else if _, err := cluster.Bus.ObjectCostEstimate(context.Background(), "unknown", path, 0, 144); !utils.IsErr(err, api.ErrBucketNotFound) {
+		t.Fatal("expected ErrBucketNotFound, got", err)
+	}
 }
 
 // TestDownloadContentDisposition is a test to verify the
@@ -801,6 +865,63 @@ func TestUploadDownloadContentDisposition(t *testing.T) {
 
 // TestUploadDownloadExtended is an integration test that verifies objects can
 // be uploaded and download correctly.
+// TestBucketRedundancy asserts that uploads to a bucket use the bucket's
+// redundancy settings and fall back to the global ones if it has none.
+func TestBucketRedundancy(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+
+	b := cluster.Bus
+	w := cluster.Worker
+	tt := cluster.tt
+
+	// create a bucket with custom redundancy settings
+	bucket := "redundancy"
+	rs := api.RedundancySettings{MinShards: 1, TotalShards: 3}
+	tt.OK(b.CreateBucket(context.Background(), bucket, api.CreateBucketOptions{Redundancy: &rs}))
+	if got, err := b.BucketRedundancy(context.Background(), bucket); err != nil {
+		t.Fatal(err)
+	} else if got == nil || *got != rs {
+		t.Fatalf("unexpected redundancy %v", got)
+	}
+
+	// invalid redundancy settings are rejected
+	if err := b.UpdateBucketRedundancy(context.Background(), bucket, &api.RedundancySettings{MinShards: 2, TotalShards: 1}); !utils.IsErr(err, api.ErrInvalidRedundancySettings) {
+		t.Fatal("expected ErrInvalidRedundancySettings", err)
+	}
+
+	assertRedundancy := func(key string, want api.RedundancySettings) {
+		t.Helper()
+		tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader(frand.Bytes(128)), bucket, key, api.UploadObjectOptions{}))
+		res, err := b.Object(context.Background(), bucket, key, api.GetObjectOptions{})
+		tt.OK(err)
+		for _, slab := range res.Object.Slabs {
+			if int(slab.MinShards) != want.MinShards || len(slab.Shards) != want.TotalShards {
+				t.Fatalf("expected %v-of-%v slab, got %v-of-%v", want.MinShards, want.TotalShards, slab.MinShards, len(slab.Shards))
+			}
+		}
+	}
+
+	// upload an object and assert the bucket's settings are used
+	assertRedundancy("foo", rs)
+
+	// update the settings and assert they're used for new uploads
+	rs = api.RedundancySettings{MinShards: 1, TotalShards: 2}
+	tt.OK(b.UpdateBucketRedundancy(context.Background(), bucket, &rs))
+	assertRedundancy("bar", rs)
+
+	// clear the settings and assert the global ones are used
+	tt.OK(b.UpdateBucketRedundancy(context.Background(), bucket, nil))
+	if got, err := b.BucketRedundancy(context.Background(), bucket); err != nil {
+		t.Fatal(err)
+	} else if got != nil {
+		t.Fatalf("expected no redundancy, got %v", got)
+	}
+	assertRedundancy("baz", test.RedundancySettings)
+}
+
 func TestUploadDownloadExtended(t *testing.T) {
 	// sanity check the default settings
 	if test.AutopilotConfig.Contracts.Amount < uint64(test.RedundancySettings.MinShards) {
@@ -1084,7 +1205,7 @@ func TestContractApplyChainUpdates(t *testing.T) {
 	cs, _ := b.ConsensusState(context.Background())
 	wallet, _ := b.Wallet(context.Background())
 	endHeight := cs.BlockHeight + test.AutopilotConfig.Contracts.Period + test.AutopilotConfig.Contracts.RenewWindow
-	contract, err := b.FormContract(context.Background(), wallet.Address, types.Siacoins(1), h.PublicKey, types.Siacoins(1), endHeight)
+	contract, err := b.FormContract(context.Background(), wallet.Address, types.Siacoins(1), h.PublicKey, types.Siacoins(1), endHeight, 1)
 	tt.OK(err)
 
 	// assert revision height is 0
@@ -1490,6 +1611,173 @@ func TestContractArchival(t *testing.T) {
 	})
 }
 
+func TestContractUnarchival(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: 1,
+	})
+	defer cluster.Shutdown()
+	b := cluster.Bus
+	tt := cluster.tt
+
+	contracts, err := b.Contracts(context.Background(), api.ContractsOpts{})
+	tt.OK(err)
+	if len(contracts) != 1 {
+		t.Fatal("expected 1 contract", len(contracts))
+	}
+	fcid := contracts[0].ID
+
+	// assert unknown archival reasons are rejected
+	err = b.ArchiveContracts(context.Background(), map[types.FileContractID]string{fcid: "foo"})
+	if !utils.IsErr(err, api.ErrInvalidArchivalReason) {
+		t.Fatal("expected ErrInvalidArchivalReason, got", err)
+	}
+
+	// archive the contract
+	tt.OK(b.ArchiveContracts(context.Background(), map[types.FileContractID]string{fcid: api.ContractArchivalReasonRemoved}))
+	if contracts, err := b.Contracts(context.Background(), api.ContractsOpts{FilterMode: api.ContractFilterModeArchived}); err != nil {
+		t.Fatal(err)
+	} else if len(contracts) != 1 || contracts[0].ArchivalReason != api.ContractArchivalReasonRemoved {
+		t.Fatal("expected contract to be archived", contracts)
+	}
+
+	// unarchive it
+	tt.OK(b.UnarchiveContracts(context.Background(), []types.FileContractID{fcid}))
+	if c, err := b.Contract(context.Background(), fcid); err != nil {
+		t.Fatal(err)
+	} else if c.ArchivalReason != "" {
+		t.Fatal("expected contract to be unarchived, got", c.ArchivalReason)
+	}
+
+	// unarchiving it again fails
+	if err := b.UnarchiveContracts(context.Background(), []types.FileContractID{fcid}); !utils.IsErr(err, api.ErrContractNotFound) {
+		t.Fatal("expected ErrContractNotFound, got", err)
+	}
+}
+
+func TestContractSpendingCap(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: 1,
+	})
+	defer cluster.Shutdown()
+	b := cluster.Bus
+	tt := cluster.tt
+
+	contracts, err := b.Contracts(context.Background(), api.ContractsOpts{})
+	tt.OK(err)
+	if len(contracts) != 1 {
+		t.Fatal("expected 1 contract", len(contracts))
+	}
+	c := contracts[0]
+
+	// updating the cap of an unknown contract fails
+	if err := b.UpdateContractSpendingCap(context.Background(), types.FileContractID{1}, types.Siacoins(1)); !utils.IsErr(err, api.ErrContractNotFound) {
+		t.Fatal("expected ErrContractNotFound", err)
+	}
+
+	// cap the contract at its current spending, the worker might still be
+	// recording spending so we use the spending after the cap was set as a
+	// baseline
+	spendingCap := c.Spending.Total()
+	tt.OK(b.UpdateContractSpendingCap(context.Background(), c.ID, spendingCap))
+	c, err = b.Contract(context.Background(), c.ID)
+	tt.OK(err)
+	if !c.SpendingCap.Equals(spendingCap) {
+		t.Fatalf("expected cap %v, got %v", spendingCap, c.SpendingCap)
+	}
+
+	// recording more spending exceeds the cap
+	record := api.ContractSpendingRecord{
+		ContractSpending: api.ContractSpending{Uploads: types.Siacoins(1)},
+		ContractID:       c.ID,
+		RevisionNumber:   c.RevisionNumber,
+		Size:             c.Size,
+	}
+	if err := b.RecordContractSpending(context.Background(), []api.ContractSpendingRecord{record}); !utils.IsErr(err, api.ErrContractSpendingCapExceeded) {
+		t.Fatal("expected ErrContractSpendingCapExceeded", err)
+	}
+
+	// assert the spending was recorded, the contract was marked as bad and an
+	// alert was registered
+	spending := c.Spending.Total().Add(types.Siacoins(1))
+	c, err = b.Contract(context.Background(), c.ID)
+	tt.OK(err)
+	if !c.Spending.Total().Equals(spending) {
+		t.Fatalf("expected spending %v, got %v", spending, c.Spending.Total())
+	} else if c.Usability != api.ContractUsabilityBad {
+		t.Fatalf("expected contract to be bad, got %v", c.Usability)
+	}
+	ar, err := b.Alerts(context.Background(), alerts.AlertsOpts{})
+	tt.OK(err)
+	var found bool
+	for _, a := range ar.Alerts {
+		found = found || a.Data["contractID"] == c.ID.String() && a.Message == "Contract spending cap exceeded"
+	}
+	if !found {
+		t.Fatal("expected spending cap alert")
+	}
+
+	// removing the cap allows recording spending again
+	tt.OK(b.UpdateContractSpendingCap(context.Background(), c.ID, types.ZeroCurrency))
+	tt.OK(b.RecordContractSpending(context.Background(), []api.ContractSpendingRecord{record}))
+}
+
+func TestRefreshContracts(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+	b := cluster.Bus
+	tt := cluster.tt
+
+	// upload some data to make sure the contracts were revised
+	tt.OKAll(cluster.Worker.UploadObject(context.Background(), bytes.NewReader(frand.Bytes(rhpv4.SectorSize)), testBucket, t.Name(), api.UploadObjectOptions{}))
+
+	contracts, err := b.Contracts(context.Background(), api.ContractsOpts{})
+	tt.OK(err)
+
+	// reset the revision number and size in the database
+	var ids []types.FileContractID
+	var records []api.ContractSpendingRecord
+	for _, c := range contracts {
+		ids = append(ids, c.ID)
+		records = append(records, api.ContractSpendingRecord{ContractID: c.ID})
+	}
+	tt.OK(b.RecordContractSpending(context.Background(), records))
+
+	// refresh the contracts and an unknown one
+	unknown := types.FileContractID{1}
+	results, err := b.RefreshContracts(context.Background(), append(ids, unknown), 2)
+	tt.OK(err)
+	if len(results) != len(ids)+1 {
+		t.Fatalf("expected %d results, got %d", len(ids)+1, len(results))
+	}
+
+	// assert the unknown contract failed without failing the others
+	if res := results[len(ids)]; res.ContractID != unknown || res.Contract != nil || !strings.Contains(res.Error, api.ErrContractNotFound.Error()) {
+		t.Fatalf("unexpected result for unknown contract %+v", res)
+	}
+
+	// assert the contracts match their latest revision
+	for i, res := range results[:len(ids)] {
+		if res.ContractID != ids[i] || res.Error != "" || res.Contract == nil {
+			t.Fatalf("unexpected result %+v", res)
+		}
+		rev, err := b.ContractRevision(context.Background(), res.ContractID)
+		tt.OK(err)
+		if rev.RevisionNumber == 0 || rev.Size == 0 {
+			t.Fatalf("expected contract %v to be revised", res.ContractID)
+		} else if res.Contract.RevisionNumber != rev.RevisionNumber || res.Contract.Size != rev.Size {
+			t.Fatalf("unexpected refreshed contract %+v, revision %+v", res.Contract, rev)
+		}
+
+		c, err := b.Contract(context.Background(), res.ContractID)
+		tt.OK(err)
+		if c.RevisionNumber != rev.RevisionNumber || c.Size != rev.Size {
+			t.Fatalf("refreshed revision wasn't stored, %d/%d != %d/%d", c.RevisionNumber, c.Size, rev.RevisionNumber, rev.Size)
+		}
+	}
+}
+
 func TestUnconfirmedContractArchival(t *testing.T) {
 	// create a test cluster
 	cluster := newTestCluster(t, testClusterOptions{hosts: 1})
@@ -1833,7 +2121,7 @@ func TestWallet(t *testing.T) {
 	txns, err := b.WalletEvents(context.Background())
 	tt.OK(err)
 
-	txns, err = b.WalletPending(context.Background())
+	txns, err = b.WalletPending(context.Background(), api.WalletPendingOpts{})
 	tt.OK(err)
 	if len(txns) != 1 {
 		t.Fatalf("expected 1 txn got %v", len(txns))
@@ -1851,6 +2139,19 @@ func TestWallet(t *testing.T) {
 		t.Fatalf("unexpected event %T", txn)
 	}
 
+	// The unconfirmed events should contain the same txn with a fee estimate.
+	unconfirmed, err := b.WalletUnconfirmedEvents(context.Background())
+	tt.OK(err)
+	if len(unconfirmed) != 1 {
+		t.Fatalf("expected 1 unconfirmed event got %v", len(unconfirmed))
+	} else if unconfirmed[0].Event.ID != txns[0].ID {
+		t.Fatal("unexpected event", unconfirmed[0].Event.ID)
+	} else if unconfirmed[0].FeePerByte.IsZero() {
+		t.Fatal("expected fee per byte to be set")
+	} else if unconfirmed[0].EstimatedConfirmationBlocks != 1 {
+		t.Fatal("unexpected confirmation estimate", unconfirmed[0].EstimatedConfirmationBlocks)
+	}
+
 	// The wallet should still have the same confirmed balance, a lower
 	// spendable balance and a greater unconfirmed balance.
 	tt.Retry(600, 100*time.Millisecond, func() error {
@@ -1879,6 +2180,106 @@ func TestWallet(t *testing.T) {
 	})
 }
 
+func TestWalletMaintenance(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{skipRunningAutopilot: true})
+	defer cluster.Shutdown()
+	b := cluster.Bus
+	tt := cluster.tt
+	ctx := context.Background()
+
+	// mine a few blocks to the void to make sure all block rewards matured
+	tt.OK(cluster.mineBlocks(types.Address{}, 2))
+	cluster.sync()
+
+	// drain the wallet, leaving a balance that supports fewer outputs than
+	// the minimum number of outputs the maintainer wants
+	const minNumOutputs = 5
+	amount := contractor.InitialContractFunding
+	wr, err := b.Wallet(ctx)
+	tt.OK(err)
+	_, err = b.SendSiacoins(ctx, types.Address{1, 2, 3}, wr.Spendable.Sub(amount.Mul64(minNumOutputs-1)), false)
+	tt.OK(err)
+	tt.OK(cluster.mineBlocks(types.Address{}, 1))
+	cluster.sync()
+
+	wr, err = b.Wallet(ctx)
+	tt.OK(err)
+	if numOutputs := wr.Confirmed.Div(amount).Big().Uint64(); numOutputs >= minNumOutputs {
+		t.Fatalf("expected balance to support fewer than %d outputs, got %d", minNumOutputs, numOutputs)
+	} else if numOutputs == 0 {
+		t.Fatal("expected balance to support at least one output")
+	}
+
+	// perform wallet maintenance, no redistribution should happen
+	wm := walletmaintainer.New(alerts.WithOrigin(b, "autopilot"), b, zap.NewNop(), walletmaintainer.WithNumOutputs(minNumOutputs, minNumOutputs), walletmaintainer.WithOutputAmount(amount))
+	tt.OK(wm.PerformWalletMaintenance(ctx, test.AutopilotConfig))
+	if pending, err := b.WalletPending(ctx, api.WalletPendingOpts{}); err != nil {
+		t.Fatal(err)
+	} else if len(pending) != 0 {
+		t.Fatalf("expected no pending transactions, got %d", len(pending))
+	}
+	if updated, err := b.Wallet(ctx); err != nil {
+		t.Fatal(err)
+	} else if !updated.Confirmed.Equals(wr.Confirmed) || !updated.Spendable.Equals(wr.Spendable) {
+		t.Fatalf("expected balance to be unchanged, %v != %v", updated.Balance, wr.Balance)
+	}
+
+	// add funds by mining a block to the wallet and letting it mature, at
+	// this point the wallet holds two outputs that are large enough
+	cluster.MineBlocks(1)
+	tt.OK(cluster.mineBlocks(types.Address{}, 1))
+	cluster.sync()
+
+	// perform wallet maintenance again, this time the wallet should
+	// redistribute into the missing number of outputs
+	tt.OK(wm.PerformWalletMaintenance(ctx, test.AutopilotConfig))
+	pending, err := b.WalletPending(ctx, api.WalletPendingOpts{})
+	tt.OK(err)
+	if len(pending) != 1 {
+		t.Fatalf("expected 1 pending transaction, got %d", len(pending))
+	}
+	txn, ok := pending[0].Data.(wallet.EventV2Transaction)
+	if !ok {
+		t.Fatalf("unexpected event %T", pending[0].Data)
+	}
+
+	// assert pending events can be filtered by type and amount
+	if filtered, err := b.WalletPending(ctx, api.WalletPendingOpts{Types: []string{wallet.EventTypeV2Transaction}, MinAmount: amount}); err != nil {
+		t.Fatal(err)
+	} else if len(filtered) != 1 {
+		t.Fatalf("expected 1 pending transaction, got %d", len(filtered))
+	}
+	if filtered, err := b.WalletPending(ctx, api.WalletPendingOpts{Types: []string{wallet.EventTypeMinerPayout}}); err != nil {
+		t.Fatal(err)
+	} else if len(filtered) != 0 {
+		t.Fatalf("expected no pending miner payouts, got %d", len(filtered))
+	}
+	if filtered, err := b.WalletPending(ctx, api.WalletPendingOpts{MinAmount: types.MaxCurrency}); err != nil {
+		t.Fatal(err)
+	} else if len(filtered) != 0 {
+		t.Fatalf("expected no pending transactions above the max currency, got %d", len(filtered))
+	}
+	var created int
+	for _, sco := range txn.SiacoinOutputs {
+		if sco.Value.Equals(amount) && sco.Address == wr.Address {
+			created++
+		}
+	}
+	if created != minNumOutputs-2 {
+		t.Fatalf("expected %d outputs to be created, got %d", minNumOutputs-2, created)
+	}
+
+	// mine the transaction and assert the wallet is left with enough outputs
+	// to not require any further maintenance
+	tt.OK(cluster.mineBlocks(types.Address{}, 1))
+	cluster.sync()
+	ids, err := b.WalletRedistribute(ctx, minNumOutputs, amount)
+	tt.OK(err)
+	if len(ids) != 0 {
+		t.Fatalf("expected no redistribution to be necessary, got %d transactions", len(ids))
+	}
+}
+
 func TestSlabBufferStats(t *testing.T) {
 	// sanity check the default settings
 	if test.AutopilotConfig.Contracts.Amount < uint64(test.RedundancySettings.MinShards) {
@@ -2047,6 +2448,33 @@ func TestAlerts(t *testing.T) {
 		t.Fatal("alert found")
 	}
 
+	// dismissing without a reason isn't recorded
+	if dismissed, err := b.DismissedAlerts(context.Background(), 0, -1); err != nil {
+		t.Fatal(err)
+	} else if len(dismissed) != 0 {
+		t.Fatalf("expected no dismissed alerts, got %d", len(dismissed))
+	}
+
+	// register the alert again and dismiss it with a reason
+	tt.OK(b.RegisterAlert(context.Background(), alert))
+	tt.OK(b.DismissAlertsWithReason(context.Background(), alerts.DismissAlertRequest{
+		AlertID:     alert.ID,
+		Reason:      "false positive",
+		DismissedBy: "operator",
+	}))
+	if foundAlert := findAlert(alert.ID); foundAlert != nil {
+		t.Fatal("alert found")
+	}
+	if dismissed, err := b.DismissedAlerts(context.Background(), 0, -1); err != nil {
+		t.Fatal(err)
+	} else if len(dismissed) != 1 {
+		t.Fatalf("expected 1 dismissed alert, got %d", len(dismissed))
+	} else if dismissed[0].AlertID != alert.ID || dismissed[0].Reason != "false positive" || dismissed[0].DismissedBy != "operator" {
+		t.Fatalf("unexpected dismissed alert %+v", dismissed[0])
+	} else if dismissed[0].Alert == nil || dismissed[0].Alert.Message != alert.Message {
+		t.Fatalf("expected dismissed alert to contain the alert, got %+v", dismissed[0].Alert)
+	}
+
 	// register 2 alerts
 	alert2 := alert
 	alert2.ID = frand.Entropy256()
@@ -2881,7 +3309,7 @@ func TestContractFundsReturnWhenHostOffline(t *testing.T) {
 	cs, _ := b.ConsensusState(context.Background())
 	endHeight := cs.BlockHeight + test.AutopilotConfig.Contracts.Period + test.AutopilotConfig.Contracts.RenewWindow
 	fundAmt := wallet.Confirmed.Mul64(96).Div64(100)
-	contract, err := b.FormContract(context.Background(), wallet.Address, fundAmt, h.PublicKey, types.Siacoins(1), endHeight)
+	contract, err := b.FormContract(context.Background(), wallet.Address, fundAmt, h.PublicKey, types.Siacoins(1), endHeight, 1)
 	tt.OK(err)
 
 	// mine a block to confirm the contract but burn the block reward
@@ -2910,10 +3338,22 @@ func TestContractFundsReturnWhenHostOffline(t *testing.T) {
 
 	expectedBalance := wallet.Confirmed.Add(contract.InitialRenterFunds).Sub(fee.Mul64(ibus.ContractResolutionTxnWeight))
 	cluster.tt.Retry(10, time.Second, func() error {
-		// contract state should be 'failed'
-		contract, err = b.Contract(context.Background(), contract.ID)
+		// contract should be archived and its state should be 'failed'
+		archived, err := b.Contracts(context.Background(), api.ContractsOpts{FilterMode: api.ContractFilterModeArchived})
 		tt.OK(err)
-		if contract.State != api.ContractStateFailed {
+		var found bool
+		for _, c := range archived {
+			if c.ID == contract.ID {
+				contract, found = c, true
+				break
+			}
+		}
+		if !found {
+			cluster.mineBlocks(types.VoidAddress, 1)
+			return errors.New("expected contract to be archived")
+		} else if contract.ArchivalReason != api.ContractArchivalReasonExpired {
+			t.Fatalf("expected contract to be archived as expired, got %v", contract.ArchivalReason)
+		} else if contract.State != api.ContractStateFailed {
 			cluster.mineBlocks(types.VoidAddress, 1)
 			return fmt.Errorf("expected contract to be failed, got %v", contract.State)
 		}
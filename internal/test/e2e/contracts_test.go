@@ -3,6 +3,7 @@ package e2e
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -41,12 +42,16 @@ func TestFormContract(t *testing.T) {
 	ap, err := b.AutopilotConfig(context.Background())
 	tt.OK(err)
 	endHeight := cs.BlockHeight + ap.Contracts.Period + ap.Contracts.RenewWindow
-	contract, err := b.FormContract(context.Background(), wallet.Address, types.Siacoins(1), h.PublicKey, types.Siacoins(1), endHeight)
+	contract, err := b.FormContract(context.Background(), wallet.Address, types.Siacoins(1), h.PublicKey, types.Siacoins(1), endHeight, 1)
 	tt.OK(err)
 
-	// assert the contract was added to the bus
-	_, err = b.Contract(context.Background(), contract.ID)
+	// assert the contract was added to the bus with the host's version, test
+	// hosts report 'test' as their release
+	c, err := b.Contract(context.Background(), contract.ID)
 	tt.OK(err)
+	if c.HostVersion != "test" {
+		t.Fatalf("unexpected host version %q", c.HostVersion)
+	}
 
 	// mine to the renew window
 	cluster.MineToRenewWindow()
@@ -87,3 +92,62 @@ func TestFormContract(t *testing.T) {
 		return nil
 	})
 }
+
+func TestFormContractCollateralMultiplier(t *testing.T) {
+	// configure the autopilot not to form any contracts
+	apCfg := test.AutopilotConfig
+	apCfg.Contracts.Amount = 0
+
+	// create cluster
+	opts := clusterOptsDefault
+	opts.autopilotConfig = &apCfg
+	cluster := newTestCluster(t, opts)
+	defer cluster.Shutdown()
+
+	// convenience variables
+	b := cluster.Bus
+	tt := cluster.tt
+
+	// add a host
+	hosts := cluster.AddHosts(1)
+	h, err := b.Host(context.Background(), hosts[0].PublicKey())
+	tt.OK(err)
+
+	// prepare contract formation
+	cs, err := b.ConsensusState(context.Background())
+	tt.OK(err)
+	wallet, err := b.Wallet(context.Background())
+	tt.OK(err)
+	endHeight := cs.BlockHeight + apCfg.Contracts.Period + apCfg.Contracts.RenewWindow
+	collateral := types.Siacoins(1)
+
+	// form a contract requesting twice the collateral
+	contract, err := b.FormContract(context.Background(), wallet.Address, types.Siacoins(1), h.PublicKey, collateral, endHeight, 2)
+	tt.OK(err)
+
+	// assert the host put up the extra collateral
+	rev, err := b.ContractRevision(context.Background(), contract.ID)
+	tt.OK(err)
+	if rev.MissedHostValue.Cmp(collateral.Mul64(2)) < 0 {
+		t.Fatalf("expected missed host value to be at least %v, got %v", collateral.Mul64(2), rev.MissedHostValue)
+	}
+
+	// form a contract requesting more collateral than the host is willing to
+	// put up, assert we fall back to the standard collateral
+	collateral = types.Siacoins(200)
+	contract, err = b.FormContract(context.Background(), wallet.Address, types.Siacoins(100), h.PublicKey, collateral, endHeight, 100)
+	tt.OK(err)
+	rev, err = b.ContractRevision(context.Background(), contract.ID)
+	tt.OK(err)
+	if rev.MissedHostValue.Cmp(collateral) < 0 || rev.MissedHostValue.Cmp(collateral.Mul64(2)) >= 0 {
+		t.Fatalf("expected missed host value to be close to %v, got %v", collateral, rev.MissedHostValue)
+	}
+
+	// assert invalid multipliers are rejected
+	for _, multiplier := range []float64{-1, 100.001} {
+		_, err = b.FormContract(context.Background(), wallet.Address, types.Siacoins(1), h.PublicKey, collateral, endHeight, multiplier)
+		if err == nil || !strings.Contains(err.Error(), "CollateralMultiplier") {
+			t.Fatalf("expected error for multiplier %v, got %v", multiplier, err)
+		}
+	}
+}
@@ -108,6 +108,17 @@ func TestGouging(t *testing.T) {
 	}
 	time.Sleep(testWorkerCfg().CacheExpiry) // wait for cache to refresh
 
+	// assert the contracts report their hosts as gouging
+	contracts, err := b.Contracts(context.Background(), api.ContractsOpts{})
+	tt.OK(err)
+	for _, c := range contracts {
+		if c.GougingCheckResult == nil || !c.GougingCheckResult.Gouging {
+			t.Fatalf("expected contract %v to be gouging, got %+v", c.ID, c.GougingCheckResult)
+		} else if len(c.GougingCheckResult.FailureReasons) == 0 {
+			t.Fatal("expected failure reasons")
+		}
+	}
+
 	// download the data - won't work since the hosts are not usable anymore
 	tt.FailAll(w.DownloadObject(context.Background(), io.Discard, testBucket, path, api.DownloadObjectOptions{}))
 
@@ -5,11 +5,13 @@ import (
 	"context"
 	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/internal/test"
+	"go.sia.tech/renterd/v2/internal/utils"
 )
 
 func TestObjectMetadata(t *testing.T) {
@@ -93,3 +95,121 @@ func TestObjectMetadata(t *testing.T) {
 		t.Fatal("unexpected metadata", gor.Metadata)
 	}
 }
+
+func TestObjectUserMetadataUpdate(t *testing.T) {
+	// create cluster
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+
+	// convenience variables
+	w := cluster.Worker
+	b := cluster.Bus
+	tt := cluster.tt
+
+	// upload two objects with metadata
+	for _, key := range []string{"dir/foo", "dir/bar"} {
+		tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader([]byte(key)), testBucket, key, api.UploadObjectOptions{
+			Metadata: api.ObjectUserMetadata{"Color": "blue", "Key": key},
+		}))
+	}
+
+	// replace the metadata of one object
+	tt.OK(b.UpdateObjectUserMetadata(context.Background(), testBucket, "dir/foo", api.ObjectUserMetadata{"Color": "red", "Shape": "round"}))
+	md, err := b.ObjectUserMetadata(context.Background(), testBucket, "dir/foo")
+	tt.OK(err)
+	if !reflect.DeepEqual(md, api.ObjectUserMetadata{"Color": "red", "Shape": "round"}) {
+		t.Fatal("unexpected metadata", md)
+	}
+
+	// assert objects are filtered by metadata, with and without delimiter
+	for _, delim := range []string{"", "/"} {
+		assertObjects := func(md api.ObjectUserMetadata, keys ...string) {
+			t.Helper()
+			res, err := b.Objects(context.Background(), "dir/", api.ListObjectOptions{Bucket: testBucket, Delimiter: delim, Metadata: md})
+			tt.OK(err)
+			var got []string
+			for _, o := range res.Objects {
+				got = append(got, o.Key)
+			}
+			if !reflect.DeepEqual(got, keys) {
+				t.Fatalf("unexpected objects for %v with delimiter %q: %v", md, delim, got)
+			}
+		}
+		assertObjects(api.ObjectUserMetadata{"Color": "blue"}, "/dir/bar")
+		assertObjects(api.ObjectUserMetadata{"Color": "red", "Shape": "round"}, "/dir/foo")
+		assertObjects(api.ObjectUserMetadata{"Color": "red", "Shape": "square"})
+	}
+
+	// assert invalid metadata is rejected
+	err = b.UpdateObjectUserMetadata(context.Background(), testBucket, "dir/foo", api.ObjectUserMetadata{"Color": strings.Repeat("a", api.MaxObjectUserMetadataValueLen+1)})
+	if !utils.IsErr(err, api.ErrInvalidObjectUserMetadata) {
+		t.Fatal("unexpected error", err)
+	}
+	_, err = w.UploadObject(context.Background(), bytes.NewReader([]byte(t.Name())), testBucket, t.Name(), api.UploadObjectOptions{
+		Metadata: api.ObjectUserMetadata{strings.Repeat("a", api.MaxObjectUserMetadataKeyLen+1): "value"},
+	})
+	if !utils.IsErr(err, api.ErrInvalidObjectUserMetadata) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// assert updating the metadata of an unknown object fails
+	err = b.UpdateObjectUserMetadata(context.Background(), testBucket, "dir/baz", api.ObjectUserMetadata{})
+	if !utils.IsErr(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestObjectMimeTypeUpdate(t *testing.T) {
+	// create cluster
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+
+	// convenience variables
+	w := cluster.Worker
+	b := cluster.Bus
+	tt := cluster.tt
+
+	// upload an object without a mime type
+	key := "dir/foo.txt"
+	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader([]byte(key)), testBucket, key, api.UploadObjectOptions{}))
+
+	assertContentType := func(expected string) {
+		t.Helper()
+		hor, err := w.HeadObject(context.Background(), testBucket, key, api.HeadObjectOptions{})
+		tt.OK(err)
+		if hor.ContentType != expected {
+			t.Fatalf("expected content type %q, got %q", expected, hor.ContentType)
+		}
+		gor, err := w.GetObject(context.Background(), testBucket, key, api.DownloadObjectOptions{})
+		tt.OK(err)
+		defer gor.Content.Close()
+		if gor.ContentType != expected {
+			t.Fatalf("expected content type %q, got %q", expected, gor.ContentType)
+		}
+	}
+
+	// assert the content type is deduced from the extension
+	assertContentType("text/plain; charset=utf-8")
+
+	// update the mime type and assert it's used as content type
+	tt.OK(b.UpdateObjectMimeType(context.Background(), testBucket, key, "application/json"))
+	assertContentType("application/json")
+
+	// reset the mime type and assert the content type is deduced again
+	tt.OK(b.UpdateObjectMimeType(context.Background(), testBucket, key, ""))
+	assertContentType("text/plain; charset=utf-8")
+
+	// assert invalid mime types are rejected
+	if err := b.UpdateObjectMimeType(context.Background(), testBucket, key, "not a mime type"); err == nil || !strings.Contains(err.Error(), "invalid mime type") {
+		t.Fatal("unexpected error", err)
+	}
+
+	// assert updating the mime type of an unknown object fails
+	if err := b.UpdateObjectMimeType(context.Background(), testBucket, "dir/bar.txt", "application/json"); !utils.IsErr(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+}
@@ -16,6 +16,8 @@ import (
 	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/internal/test"
+	"go.sia.tech/renterd/v2/internal/utils"
+	"go.sia.tech/renterd/v2/object"
 	"lukechampine.com/frand"
 )
 
@@ -113,6 +115,18 @@ func TestMigrations(t *testing.T) {
 		t.Fatalf("expected 4 shard hosts, got %v", shardHosts)
 	}
 
+	// assert repairing the healthy slab is a no-op
+	repair, err := cluster.Autopilot.RepairSlab(context.Background(), res.Object.Slabs[0].EncryptionKey)
+	tt.OK(err)
+	if repair != (api.SlabRepairResponse{}) {
+		t.Fatalf("unexpected repair response %+v", repair)
+	}
+
+	// assert repairing an unknown slab fails
+	if _, err := cluster.Autopilot.RepairSlab(context.Background(), object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted)); !utils.IsErr(err, api.ErrSlabNotFound) {
+		t.Fatalf("expected ErrSlabNotFound, got %v", err)
+	}
+
 	// create another bucket and add an object
 	tt.OK(b.CreateBucket(context.Background(), "newbucket", api.CreateBucketOptions{}))
 	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader(data), "newbucket", t.Name(), api.UploadObjectOptions{}))
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	rhpv4 "go.sia.tech/core/rhp/v4"
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/bus/client"
@@ -95,7 +96,7 @@ func TestContractPruning(t *testing.T) {
 	// checks whether the pruner is running or not
 	assertPrunableData := func(prunable bool) {
 		tt.Retry(100, 100*time.Millisecond, func() error {
-			res, err := b.PrunableData(context.Background())
+			res, err := b.PrunableData(context.Background(), api.ContractsPrunableDataOpts{})
 			tt.OK(err)
 			if prunable && res.TotalPrunable == 0 {
 				return errors.New("expected prunable data")
@@ -198,7 +199,7 @@ func TestSectorPruning(t *testing.T) {
 	time.Sleep(3 * testBusFlushInterval)
 
 	// assert prunable data is 0
-	res, err := b.PrunableData(context.Background())
+	res, err := b.PrunableData(context.Background(), api.ContractsPrunableDataOpts{})
 	tt.OK(err)
 	if res.TotalPrunable != 0 {
 		t.Fatal("expected 0 prunable data", n)
@@ -212,7 +213,7 @@ func TestSectorPruning(t *testing.T) {
 
 	// assert amount of prunable data
 	tt.Retry(300, 100*time.Millisecond, func() error {
-		res, err = b.PrunableData(context.Background())
+		res, err = b.PrunableData(context.Background(), api.ContractsPrunableDataOpts{})
 		tt.OK(err)
 		if res.TotalPrunable != uint64(math.Ceil(float64(numObjects)/2))*rs.SlabSize() {
 			return fmt.Errorf("unexpected prunable data %v", n)
@@ -220,6 +221,59 @@ func TestSectorPruning(t *testing.T) {
 		return nil
 	})
 
+	// assert a dry run doesn't prune anything nor record any spending
+	var wouldPrune uint64
+	for _, c := range contracts {
+		before, err := b.Contract(context.Background(), c.ID)
+		tt.OK(err)
+		res, err := b.PruneContractDryRun(context.Background(), c.ID, 0)
+		tt.OK(err)
+		if res.Pruned != 0 {
+			t.Fatal("expected pruned to be zero")
+		} else if res.WouldPrune != res.Remaining {
+			t.Fatalf("expected would prune to equal remaining, %v != %v", res.WouldPrune, res.Remaining)
+		}
+		wouldPrune += res.WouldPrune
+
+		after, err := b.Contract(context.Background(), c.ID)
+		tt.OK(err)
+		if !after.Spending.SectorRoots.Equals(before.Spending.SectorRoots) {
+			t.Fatalf("expected no sector roots spending, %v != %v", after.Spending.SectorRoots, before.Spending.SectorRoots)
+		}
+	}
+	if wouldPrune != uint64(math.Ceil(float64(numObjects)/2))*rs.SlabSize() {
+		t.Fatal("unexpected would prune", wouldPrune)
+	}
+	res, err = b.PrunableData(context.Background(), api.ContractsPrunableDataOpts{})
+	tt.OK(err)
+	if res.TotalPrunable != wouldPrune {
+		t.Fatal("expected prunable data to be unchanged", res.TotalPrunable)
+	}
+
+	// assert the estimated contract size matches the exact one
+	for _, c := range contracts {
+		estimate, err := b.ContractSize(context.Background(), c.ID)
+		tt.OK(err)
+		exact, err := b.ContractSizeExact(context.Background(), c.ID)
+		tt.OK(err)
+		if !estimate.Estimated || exact.Estimated {
+			t.Fatalf("unexpected estimated flags, %v %v", estimate.Estimated, exact.Estimated)
+		} else if estimate.Size != exact.Size || estimate.Prunable != exact.Prunable {
+			t.Fatalf("expected estimate to match exact size, %+v != %+v", estimate, exact)
+		}
+	}
+
+	// prune a single sector from every contract
+	for _, c := range contracts {
+		res, err := b.PruneContractMaxSectors(context.Background(), c.ID, 0, 1)
+		tt.OK(err)
+		if res.Pruned > rhpv4.SectorSize {
+			t.Fatal("expected at most one sector to be pruned", res.Pruned)
+		} else if res.Pruned+res.Remaining != res.TotalPrunable {
+			t.Fatalf("expected pruned and remaining to add up to total prunable, %v+%v != %v", res.Pruned, res.Remaining, res.TotalPrunable)
+		}
+	}
+
 	// prune all contracts
 	for _, c := range contracts {
 		res, err := b.PruneContract(context.Background(), c.ID, 0)
@@ -232,7 +286,7 @@ func TestSectorPruning(t *testing.T) {
 	}
 
 	// assert prunable data is 0
-	res, err = b.PrunableData(context.Background())
+	res, err = b.PrunableData(context.Background(), api.ContractsPrunableDataOpts{})
 	tt.OK(err)
 	if res.TotalPrunable != 0 {
 		t.Fatalf("unexpected no prunable data: %d", n)
@@ -258,7 +312,7 @@ func TestSectorPruning(t *testing.T) {
 
 	// assert amount of prunable data
 	tt.Retry(300, 100*time.Millisecond, func() error {
-		res, err = b.PrunableData(context.Background())
+		res, err = b.PrunableData(context.Background(), api.ContractsPrunableDataOpts{})
 		tt.OK(err)
 
 		if len(res.Contracts) != len(contracts) {
@@ -295,3 +349,72 @@ func TestSectorPruning(t *testing.T) {
 		t.Fatal("expected gouging error", err)
 	}
 }
+
+func TestPruneDryRunRevisionGap(t *testing.T) {
+	// create a cluster with a small max revision gap
+	busCfg := testBusCfg()
+	busCfg.MaxRevisionGap = 2
+	cluster := newTestCluster(t, testClusterOptions{
+		busCfg: &busCfg,
+		hosts:  test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+
+	// convenience variables
+	w := cluster.Worker
+	b := cluster.Bus
+	tt := cluster.tt
+
+	// create prunable data by adding and immediately removing an object
+	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader([]byte(t.Name())), testBucket, t.Name(), api.UploadObjectOptions{}))
+	tt.OK(b.DeleteObject(context.Background(), testBucket, t.Name()))
+
+	// shut down the autopilot to prevent it from pruning
+	cluster.ShutdownAutopilot(context.Background())
+
+	// pick a contract with prunable data
+	var c api.ContractMetadata
+	tt.Retry(100, 100*time.Millisecond, func() error {
+		res, err := b.PrunableData(context.Background(), api.ContractsPrunableDataOpts{})
+		tt.OK(err)
+		for _, cpd := range res.Contracts {
+			if cpd.Prunable > 0 {
+				c, err = b.Contract(context.Background(), cpd.ID)
+				tt.OK(err)
+				return nil
+			}
+		}
+		return errors.New("no contract with prunable data")
+	})
+
+	// perform more dry runs than the max revision gap allows, the first one
+	// is retried until the spending of the upload was flushed
+	for i := 0; i < 5; i++ {
+		tt.Retry(100, 100*time.Millisecond, func() error {
+			res, err := b.PruneContractDryRun(context.Background(), c.ID, 0)
+			if err != nil {
+				return err
+			} else if res.WouldPrune == 0 {
+				return errors.New("expected prunable data")
+			}
+			return nil
+		})
+
+		// assert the revision was recorded without any spending
+		updated, err := b.Contract(context.Background(), c.ID)
+		tt.OK(err)
+		if updated.RevisionNumber <= c.RevisionNumber {
+			t.Fatalf("expected revision number to increase, %d <= %d", updated.RevisionNumber, c.RevisionNumber)
+		} else if !updated.Spending.SectorRoots.Equals(c.Spending.SectorRoots) {
+			t.Fatalf("expected no sector roots spending, %v != %v", updated.Spending.SectorRoots, c.Spending.SectorRoots)
+		}
+		c = updated
+	}
+
+	// assert the contract can still be pruned
+	res, err := b.PruneContract(context.Background(), c.ID, 0)
+	tt.OK(err)
+	if res.Pruned == 0 {
+		t.Fatal("expected pruned to be non-zero")
+	}
+}
@@ -133,6 +133,12 @@ func (c *Contract) AddSector(root types.Hash256, sector *[rhpv4.SectorSize]byte)
 	c.mu.Unlock()
 }
 
+func (c *Contract) DeleteSector(root types.Hash256) {
+	c.mu.Lock()
+	delete(c.sectors, root)
+	c.mu.Unlock()
+}
+
 func (c *Contract) ID() types.FileContractID {
 	c.mu.Lock()
 	defer c.mu.Unlock()
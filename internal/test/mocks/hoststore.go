@@ -91,6 +91,10 @@ func (h *Host) UploadSector(ctx context.Context, sectorRoot types.Hash256, secto
 	return errors.New("implement when needed")
 }
 
+func (h *Host) VerifySector(ctx context.Context, sectorRoot types.Hash256) error {
+	return errors.New("implement when needed")
+}
+
 func (h *Host) Prices(ctx context.Context) (rhpv4.HostPrices, error) {
 	return h.hi.V2Settings.Prices, nil
 }
@@ -307,6 +307,10 @@ func (os *ObjectStore) RemoveObjects(ctx context.Context, bucket, prefix string)
 	return nil
 }
 
+func (os *ObjectStore) SlabBuffers(ctx context.Context) ([]api.SlabBuffer, error) {
+	return nil, nil
+}
+
 func (os *ObjectStore) totalSlabBufferSize() (total int) {
 	for _, p := range os.partials {
 		if time.Now().After(p.lockedUntil) {
@@ -0,0 +1,123 @@
+package uploader
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/types"
+)
+
+const (
+	// sectorFilterResetInterval is the interval after which the sector filter
+	// is cleared to limit its false positive rate and memory growth
+	sectorFilterResetInterval = 24 * time.Hour
+)
+
+type (
+	// SectorFilter is a bloom filter of recently uploaded sector roots, keyed
+	// by the contract they were uploaded to. Uploaders consult it to avoid
+	// uploading a sector to a contract it was recently uploaded to. A hit is
+	// only a hint, the uploader verifies the sector is stored by the host
+	// before skipping the upload, a false positive costs a verification.
+	SectorFilter struct {
+		k uint64
+		m uint64
+
+		mu        sync.Mutex
+		bits      []uint64
+		lastReset time.Time
+
+		lookups uint64
+		hits    uint64
+	}
+
+	// SectorFilterStats contains the lookups and hits of a sector filter.
+	SectorFilterStats struct {
+		Lookups uint64
+		Hits    uint64
+	}
+)
+
+// NewSectorFilter returns a sector filter sized to hold the given number of
+// sectors with the given false positive rate.
+func NewSectorFilter(capacity uint64, falsePositiveRate float64) *SectorFilter {
+	if capacity == 0 {
+		capacity = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 1e-9
+	}
+
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) / 64 * 64
+	k := uint64(math.Round(float64(m) / float64(capacity) * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+	return &SectorFilter{
+		k:         k,
+		m:         m,
+		bits:      make([]uint64, m/64),
+		lastReset: time.Now(),
+	}
+}
+
+// Add adds the given root to the filter.
+func (f *SectorFilter) Add(fcid types.FileContractID, root types.Hash256) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tryReset()
+
+	h1, h2 := f.hash(fcid, root)
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		f.bits[idx/64] |= 1 << (idx % 64)
+	}
+}
+
+// Contains returns true if the given root was probably uploaded to the given
+// contract since the filter was last reset.
+func (f *SectorFilter) Contains(fcid types.FileContractID, root types.Hash256) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tryReset()
+
+	f.lookups++
+	h1, h2 := f.hash(fcid, root)
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		if f.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	f.hits++
+	return true
+}
+
+// Stats returns the lookups and hits of the filter since it was created.
+func (f *SectorFilter) Stats() SectorFilterStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return SectorFilterStats{
+		Lookups: f.lookups,
+		Hits:    f.hits,
+	}
+}
+
+// hash derives the two hashes used for double hashing, sector roots are
+// uniformly distributed so there is no need to hash them again.
+func (f *SectorFilter) hash(fcid types.FileContractID, root types.Hash256) (uint64, uint64) {
+	h1 := binary.LittleEndian.Uint64(root[:8]) ^ binary.LittleEndian.Uint64(fcid[:8])
+	h2 := binary.LittleEndian.Uint64(root[8:16]) ^ binary.LittleEndian.Uint64(fcid[8:16])
+	return h1, h2 | 1
+}
+
+func (f *SectorFilter) tryReset() {
+	if time.Since(f.lastReset) < sectorFilterResetInterval {
+		return
+	}
+	clear(f.bits)
+	f.lastReset = time.Now()
+}
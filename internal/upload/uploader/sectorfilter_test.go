@@ -0,0 +1,58 @@
+package uploader
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"lukechampine.com/frand"
+)
+
+func TestSectorFilter(t *testing.T) {
+	f := NewSectorFilter(1000, 1e-6)
+
+	fcid := types.FileContractID{1}
+	root := frand.Entropy256()
+	if f.Contains(fcid, root) {
+		t.Fatal("unexpected hit")
+	}
+
+	// assert the root is found after adding it, but only for its contract
+	f.Add(fcid, root)
+	if !f.Contains(fcid, root) {
+		t.Fatal("expected hit")
+	} else if f.Contains(types.FileContractID{2}, root) {
+		t.Fatal("unexpected hit for other contract")
+	} else if stats := f.Stats(); stats.Lookups != 3 || stats.Hits != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// fill the filter and assert there are no false negatives
+	roots := make([]types.Hash256, 1000)
+	for i := range roots {
+		roots[i] = frand.Entropy256()
+		f.Add(fcid, roots[i])
+	}
+	for _, root := range roots {
+		if !f.Contains(fcid, root) {
+			t.Fatal("false negative")
+		}
+	}
+
+	// assert the false positive rate is in the right ballpark
+	var fps int
+	for i := 0; i < 10000; i++ {
+		if f.Contains(fcid, frand.Entropy256()) {
+			fps++
+		}
+	}
+	if fps > 1 {
+		t.Fatalf("too many false positives, %d", fps)
+	}
+
+	// assert the filter is cleared after the reset interval
+	f.lastReset = time.Now().Add(-sectorFilterResetInterval)
+	if f.Contains(fcid, root) {
+		t.Fatal("expected filter to be reset")
+	}
+}
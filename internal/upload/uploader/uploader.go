@@ -21,6 +21,7 @@ const (
 	lockingPriorityUpload     = 10
 	revisionFetchTimeout      = 30 * time.Second
 	sectorUploadTimeout       = 60 * time.Second
+	sectorVerifyTimeout       = 10 * time.Second
 	statsRecomputeMinInterval = 3 * time.Second
 )
 
@@ -80,6 +81,9 @@ type (
 		hm     hosts.Manager
 		logger *zap.SugaredLogger
 
+		// sectors is optional and shared between all uploaders
+		sectors *SectorFilter
+
 		hk              types.PublicKey
 		signalNewUpload chan struct{}
 		stoppedChan     chan struct{}
@@ -100,12 +104,13 @@ type (
 	}
 )
 
-func New(ctx context.Context, cl locking.ContractLocker, cs ContractStore, hm hosts.Manager, hi api.HostInfo, fcid types.FileContractID, endHeight uint64, l *zap.SugaredLogger) *Uploader {
+func New(ctx context.Context, cl locking.ContractLocker, cs ContractStore, hm hosts.Manager, sectors *SectorFilter, hi api.HostInfo, fcid types.FileContractID, endHeight uint64, l *zap.SugaredLogger) *Uploader {
 	return &Uploader{
-		cl:     cl,
-		cs:     cs,
-		hm:     hm,
-		logger: l,
+		cl:      cl,
+		cs:      cs,
+		hm:      hm,
+		logger:  l,
+		sectors: sectors,
 
 		// static
 		hk:              hi.PublicKey,
@@ -198,6 +203,18 @@ outer:
 					return true
 				}
 
+				// skip if the sector was recently uploaded to the contract, the
+				// filter might return false positives so we only skip the
+				// upload if the host can prove it's storing the sector
+				if fcid := u.ContractID(); u.sectors != nil && u.sectors.Contains(fcid, req.Root) {
+					if err := u.verify(req); err != nil {
+						u.logger.Debugw("failed to verify recently uploaded sector", "error", err, "root", req.Root, "hk", u.hk)
+					} else {
+						req.Finish(u.hk, fcid, nil)
+						return true
+					}
+				}
+
 				// execute it
 				start := time.Now()
 				duration, err := u.execute(req)
@@ -373,8 +390,24 @@ func (u *Uploader) execute(req *queuedSectorUploadReq) (_ time.Duration, err err
 	if err != nil {
 		return 0, fmt.Errorf("failed to upload sector to contract %v; %w", fcid, err)
 	}
+	elapsed := time.Since(start)
+
+	if u.sectors != nil {
+		u.sectors.Add(fcid, req.Root)
+	}
+	return elapsed, nil
+}
 
-	return time.Since(start), nil
+// verify asks the host to prove it's storing the sector of the given request
+func (u *Uploader) verify(req *queuedSectorUploadReq) error {
+	u.mu.Lock()
+	host := u.host
+	fcid := u.fcid
+	u.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(req.Ctx, sectorVerifyTimeout)
+	defer cancel()
+	return u.hm.Uploader(host, fcid).VerifySector(ctx, req.Root)
 }
 
 func (u *Uploader) pop() *queuedSectorUploadReq {
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	rhpv4 "go.sia.tech/core/rhp/v4"
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/internal/host"
 	rhp4 "go.sia.tech/renterd/v2/internal/rhp/v4"
 	"go.sia.tech/renterd/v2/internal/test/mocks"
 	"go.uber.org/zap"
@@ -24,7 +26,7 @@ func TestUploaderStopped(t *testing.T) {
 	c := mocks.NewContract(types.PublicKey{1}, types.FileContractID{1})
 	md := c.Metadata()
 
-	ul := New(context.Background(), cl, cs, hm, api.HostInfo{}, md.ID, md.WindowEnd, zap.NewNop().Sugar())
+	ul := New(context.Background(), cl, cs, hm, nil, api.HostInfo{}, md.ID, md.WindowEnd, zap.NewNop().Sugar())
 
 	// enqueue a request
 	respChan := make(chan SectorUploadResp, 1)
@@ -129,7 +131,7 @@ func TestRefreshUploader(t *testing.T) {
 	c := cs.AddContract(hi.PublicKey).Metadata()
 
 	// create uploader
-	ul := New(context.Background(), cl, cs, hm, hi, c.ID, c.WindowEnd, zap.NewNop().Sugar())
+	ul := New(context.Background(), cl, cs, hm, nil, hi, c.ID, c.WindowEnd, zap.NewNop().Sugar())
 
 	// assert state
 	if ul.expiry != c.WindowEnd {
@@ -177,7 +179,7 @@ func TestUploaderQueue(t *testing.T) {
 	// create uploader
 	hk := types.PublicKey{1}
 	fcid := types.FileContractID{1}
-	ul := New(context.Background(), cl, cs, hm, api.HostInfo{PublicKey: hk}, fcid, 0, zap.NewNop().Sugar())
+	ul := New(context.Background(), cl, cs, hm, nil, api.HostInfo{PublicKey: hk}, fcid, 0, zap.NewNop().Sugar())
 
 	respChan := make(chan SectorUploadResp, 3)
 	cancelledCtx, cancel := context.WithCancel(context.Background())
@@ -217,3 +219,78 @@ func TestUploaderQueue(t *testing.T) {
 	case <-stoppedChan: // asserts the uploader stopped
 	}
 }
+
+type verifyingHost struct {
+	mocks.Host
+
+	mu      sync.Mutex
+	stored  map[types.Hash256]struct{}
+	uploads int
+}
+
+func (h *verifyingHost) UploadSector(_ context.Context, root types.Hash256, _ *[rhpv4.SectorSize]byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stored[root] = struct{}{}
+	h.uploads++
+	return nil
+}
+
+func (h *verifyingHost) VerifySector(_ context.Context, root types.Hash256) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.stored[root]; !ok {
+		return rhpv4.ErrSectorNotFound
+	}
+	return nil
+}
+
+type verifyingHostManager struct {
+	*mocks.HostManager
+	h *verifyingHost
+}
+
+func (hm *verifyingHostManager) Uploader(api.HostInfo, types.FileContractID) host.Uploader {
+	return hm.h
+}
+
+func TestUploaderSectorFilterHint(t *testing.T) {
+	h := &verifyingHost{stored: make(map[types.Hash256]struct{})}
+	hm := &verifyingHostManager{HostManager: mocks.NewHostManager(), h: h}
+	sectors := NewSectorFilter(100, 1e-9)
+
+	fcid := types.FileContractID{1}
+	ul := New(context.Background(), mocks.NewContractLocker(), mocks.NewContractStore(), hm, sectors, api.HostInfo{}, fcid, 100, zap.NewNop().Sugar())
+	go ul.Start()
+	defer ul.Stop(ErrStopped)
+
+	upload := func(root types.Hash256) {
+		t.Helper()
+		respChan := make(chan SectorUploadResp, 1)
+		ul.Enqueue(NewUploadRequest(context.Background(), new([rhpv4.SectorSize]byte), 0, respChan, root, false))
+		if resp := <-respChan; resp.Err != nil {
+			t.Fatal(resp.Err)
+		}
+	}
+
+	// a filter hit for a sector the host doesn't store should not skip the
+	// upload
+	root := types.Hash256{1}
+	sectors.Add(fcid, root)
+	upload(root)
+	if h.uploads != 1 {
+		t.Fatal("expected sector to be uploaded", h.uploads)
+	}
+
+	// a filter hit for a sector the host stores should skip the upload
+	upload(root)
+	if h.uploads != 1 {
+		t.Fatal("expected upload to be skipped", h.uploads)
+	}
+
+	// a sector that isn't in the filter is always uploaded
+	upload(types.Hash256{2})
+	if h.uploads != 2 {
+		t.Fatal("expected sector to be uploaded", h.uploads)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"math"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	rhpv4 "go.sia.tech/core/rhp/v4"
@@ -24,10 +25,18 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// minSlabUploadThroughput is the minimum throughput in bytes per second
+	// we expect when uploading all shards of a slab, it is used to derive
+	// the slab upload timeout if none is configured.
+	minSlabUploadThroughput = 1 << 20 // 1 MiB/s
+)
+
 var (
 	ErrContractExpired      = errors.New("contract expired")
 	ErrNoCandidateUploader  = errors.New("no candidate uploader found")
 	ErrShuttingDown         = errors.New("upload manager is shutting down")
+	ErrSlabUploadTimeout    = errors.New("slab upload timed out")
 	ErrUploadCancelled      = errors.New("upload was cancelled")
 	ErrUploadNotEnoughHosts = errors.New("not enough hosts to support requested upload redundancy")
 )
@@ -69,13 +78,17 @@ type (
 		cl        ContractLocker
 		cs        uploader.ContractStore
 		uploadKey *utils.UploadKey
+		sectors   *uploader.SectorFilter
 		logger    *zap.SugaredLogger
 
-		maxOverdrive     uint64
-		overdriveTimeout time.Duration
+		maxOverdrive           uint64
+		overdriveTimeout       time.Duration
+		slabUploadTimeout      time.Duration
+		minUploadConfirmations int
 
 		statsOverdrivePct              *utils.DataPoints
 		statsSlabUploadSpeedBytesPerMS *utils.DataPoints
+		statsSlabUploadTimeouts        atomic.Uint64
 
 		shutdownCtx context.Context
 
@@ -88,7 +101,9 @@ type (
 		AvgOverdrivePct        float64
 		HealthyUploaders       uint64
 		NumUploaders           uint64
+		SlabUploadTimeouts     uint64
 		UploadSpeedsMBPS       map[types.PublicKey]float64
+		SectorFilter           *uploader.SectorFilterStats
 	}
 )
 
@@ -98,6 +113,9 @@ type (
 		allowed     map[types.PublicKey]struct{}
 		os          ObjectStore
 		shutdownCtx context.Context
+
+		slabTimeout  time.Duration
+		slabTimeouts *atomic.Uint64
 	}
 
 	uploadedSector struct {
@@ -150,7 +168,21 @@ type (
 	}
 )
 
-func NewManager(ctx context.Context, uploadKey *utils.UploadKey, hm hosts.Manager, mm memory.MemoryManager, os ObjectStore, cl ContractLocker, cs uploader.ContractStore, maxOverdrive uint64, overdriveTimeout time.Duration, logger *zap.Logger) *Manager {
+// FilterHosts returns the hosts whose public key is accepted by the given
+// function.
+func FilterHosts(hosts []HostInfo, accept func(types.PublicKey) bool) (filtered []HostInfo) {
+	for _, h := range hosts {
+		if accept(h.PublicKey) {
+			filtered = append(filtered, h)
+		}
+	}
+	return
+}
+
+// NewManager returns a new upload manager, the sector filter is optional and
+// used to skip uploading sectors that were recently uploaded to a contract. If
+// the slab upload timeout is zero, it is derived from the size of the slab.
+func NewManager(ctx context.Context, uploadKey *utils.UploadKey, hm hosts.Manager, mm memory.MemoryManager, os ObjectStore, cl ContractLocker, cs uploader.ContractStore, sectors *uploader.SectorFilter, maxOverdrive uint64, overdriveTimeout, slabUploadTimeout time.Duration, minUploadConfirmations int, logger *zap.Logger) *Manager {
 	logger = logger.Named("uploadmanager")
 	return &Manager{
 		hm:        hm,
@@ -159,10 +191,13 @@ func NewManager(ctx context.Context, uploadKey *utils.UploadKey, hm hosts.Manage
 		cl:        cl,
 		cs:        cs,
 		uploadKey: uploadKey,
+		sectors:   sectors,
 		logger:    logger.Sugar(),
 
-		maxOverdrive:     maxOverdrive,
-		overdriveTimeout: overdriveTimeout,
+		maxOverdrive:           maxOverdrive,
+		overdriveTimeout:       overdriveTimeout,
+		slabUploadTimeout:      slabUploadTimeout,
+		minUploadConfirmations: minUploadConfirmations,
 
 		statsOverdrivePct:              utils.NewDataPoints(0),
 		statsSlabUploadSpeedBytesPerMS: utils.NewDataPoints(0),
@@ -181,6 +216,21 @@ func (mgr *Manager) MemoryStatus() memory.Status {
 	return mgr.mm.Status()
 }
 
+// minConfirmations returns the number of shards that have to be uploaded for a
+// slab with the given redundancy settings to be considered uploaded. It
+// defaults to TotalShards and is capped to [MinShards, TotalShards].
+func (mgr *Manager) minConfirmations(rs api.RedundancySettings) uint64 {
+	n := mgr.minUploadConfirmations
+	if n == 0 {
+		n = rs.TotalShards
+	} else if n < rs.MinShards {
+		n = rs.MinShards
+	} else if n > rs.TotalShards {
+		n = rs.TotalShards
+	}
+	return uint64(n)
+}
+
 func (mgr *Manager) Stats() Stats {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
@@ -195,13 +245,19 @@ func (mgr *Manager) Stats() Stats {
 	}
 
 	// prepare stats
-	return Stats{
+	stats := Stats{
 		AvgSlabUploadSpeedMBPS: mgr.statsSlabUploadSpeedBytesPerMS.Average() * 0.008, // convert bytes per ms to mbps,
 		AvgOverdrivePct:        mgr.statsOverdrivePct.Average(),
 		HealthyUploaders:       numHealthy,
 		NumUploaders:           uint64(len(speeds)),
+		SlabUploadTimeouts:     mgr.statsSlabUploadTimeouts.Load(),
 		UploadSpeedsMBPS:       speeds,
 	}
+	if mgr.sectors != nil {
+		sfs := mgr.sectors.Stats()
+		stats.SectorFilter = &sfs
+	}
+	return stats
 }
 
 func (mgr *Manager) Stop() {
@@ -314,7 +370,7 @@ func (mgr *Manager) Upload(ctx context.Context, r io.Reader, hosts []HostInfo, u
 			} else {
 				// regular upload
 				go func(rs api.RedundancySettings, data []byte, length, slabIndex int) {
-					uploadSpeed, overdrivePct := upload.uploadSlab(ctx, rs, data, length, slabIndex, respChan, mgr.candidates(upload.allowed), mem, mgr.maxOverdrive, mgr.overdriveTimeout)
+					uploadSpeed, overdrivePct := upload.uploadSlab(ctx, rs, data, length, slabIndex, respChan, mgr.candidates(upload.allowed), mem, mgr.maxOverdrive, mgr.overdriveTimeout, mgr.minConfirmations(rs))
 
 					// track stats
 					mgr.statsSlabUploadSpeedBytesPerMS.Track(float64(uploadSpeed))
@@ -416,7 +472,7 @@ func (mgr *Manager) UploadPackedSlab(ctx context.Context, rs api.RedundancySetti
 	}()
 
 	// upload the shards
-	uploaded, uploadSpeed, overdrivePct, err := upload.uploadShards(ctx, shards, mgr.candidates(upload.allowed), mem, mgr.maxOverdrive, mgr.overdriveTimeout)
+	uploaded, uploadSpeed, overdrivePct, err := upload.uploadShards(ctx, shards, mgr.candidates(upload.allowed), mem, mgr.maxOverdrive, mgr.overdriveTimeout, upload.slabUploadTimeout(len(shards)), mgr.minConfirmations(rs))
 	if err != nil {
 		return err
 	}
@@ -469,8 +525,10 @@ func (mgr *Manager) UploadShards(ctx context.Context, s object.Slab, shards [][]
 		cancel()
 	}()
 
-	// upload the shards
-	uploaded, uploadSpeed, overdrivePct, err := upload.uploadShards(ctx, shards, mgr.candidates(upload.allowed), mem, mgr.maxOverdrive, mgr.overdriveTimeout)
+	// upload the shards, migrations always require all shards to be uploaded
+	// NOTE: migrations don't time out, the slab timeout only applies to user
+	// uploads
+	uploaded, uploadSpeed, overdrivePct, err := upload.uploadShards(ctx, shards, mgr.candidates(upload.allowed), mem, mgr.maxOverdrive, mgr.overdriveTimeout, 0, uint64(len(shards)))
 
 	// build sectors
 	var sectors []api.UploadedSector
@@ -539,6 +597,9 @@ func (mgr *Manager) newUpload(totalShards int, hosts []HostInfo, bh uint64) (*up
 		allowed:     allowed,
 		os:          mgr.os,
 		shutdownCtx: mgr.shutdownCtx,
+
+		slabTimeout:  mgr.slabUploadTimeout,
+		slabTimeouts: &mgr.statsSlabUploadTimeouts,
 	}, nil
 }
 
@@ -577,7 +638,7 @@ func (mgr *Manager) refreshUploaders(hosts []HostInfo, bh uint64) {
 	// add missing uploaders
 	for _, h := range hosts {
 		if _, exists := existing[h.ContractID]; !exists && bh < h.ContractEndHeight {
-			uploader := uploader.New(mgr.shutdownCtx, mgr.cl, mgr.cs, mgr.hm, h.HostInfo, h.ContractID, h.ContractEndHeight, mgr.logger)
+			uploader := uploader.New(mgr.shutdownCtx, mgr.cl, mgr.cs, mgr.hm, mgr.sectors, h.HostInfo, h.ContractID, h.ContractEndHeight, mgr.logger)
 			refreshed = append(refreshed, uploader)
 			go uploader.Start()
 		}
@@ -640,7 +701,7 @@ func (u *upload) newSlabUpload(ctx context.Context, shards [][]byte, uploaders [
 	}, responseChan
 }
 
-func (u *upload) uploadSlab(ctx context.Context, rs api.RedundancySettings, data []byte, length, index int, respChan chan slabUploadResponse, candidates []*uploader.Uploader, mem memory.Memory, maxOverdrive uint64, overdriveTimeout time.Duration) (int64, float64) {
+func (u *upload) uploadSlab(ctx context.Context, rs api.RedundancySettings, data []byte, length, index int, respChan chan slabUploadResponse, candidates []*uploader.Uploader, mem memory.Memory, maxOverdrive uint64, overdriveTimeout time.Duration, minConfirmations uint64) (int64, float64) {
 	// create the response
 	resp := slabUploadResponse{
 		slab: object.SlabSlice{
@@ -657,7 +718,7 @@ func (u *upload) uploadSlab(ctx context.Context, rs api.RedundancySettings, data
 	resp.slab.Slab.Encrypt(shards)
 
 	// upload the shards
-	uploaded, uploadSpeed, overdrivePct, err := u.uploadShards(ctx, shards, candidates, mem, maxOverdrive, overdriveTimeout)
+	uploaded, uploadSpeed, overdrivePct, err := u.uploadShards(ctx, shards, candidates, mem, maxOverdrive, overdriveTimeout, u.slabUploadTimeout(len(shards)), minConfirmations)
 
 	// build the sectors
 	var sectors []object.Sector
@@ -678,14 +739,39 @@ func (u *upload) uploadSlab(ctx context.Context, rs api.RedundancySettings, data
 	return uploadSpeed, overdrivePct
 }
 
-// uploadShards uploads the shards to the provided candidates. It returns an
-// error if it fails to upload all shards but len(sectors) will be > 0 if some
-// shards were uploaded successfully.
-func (u *upload) uploadShards(ctx context.Context, shards [][]byte, candidates []*uploader.Uploader, mem memory.Memory, maxOverdrive uint64, overdriveTimeout time.Duration) (sectors []uploadedSector, uploadSpeed int64, overdrivePct float64, err error) {
+// slabUploadTimeout returns the time it may take to upload the given number of
+// shards, if no timeout is configured it is derived from the size of the slab.
+func (u *upload) slabUploadTimeout(numShards int) time.Duration {
+	if u.slabTimeout > 0 {
+		return u.slabTimeout
+	}
+	return time.Duration(numShards) * rhpv4.SectorSize * time.Second / minSlabUploadThroughput
+}
+
+// uploadShards uploads the shards to the provided candidates. It tries to
+// upload all shards but only returns an error if fewer than minConfirmations
+// shards were uploaded, in which case len(sectors) will be > 0 if some shards
+// were uploaded successfully. On success a sector is returned for every shard,
+// shards that failed to upload have a root but no host or contract. If
+// slabTimeout is non-zero, the upload fails with ErrSlabUploadTimeout if it
+// doesn't finish in time.
+func (u *upload) uploadShards(ctx context.Context, shards [][]byte, candidates []*uploader.Uploader, mem memory.Memory, maxOverdrive uint64, overdriveTimeout, slabTimeout time.Duration, minConfirmations uint64) (sectors []uploadedSector, uploadSpeed int64, overdrivePct float64, err error) {
 	// ensure inflight uploads get cancelled
-	ctx, cancel := context.WithCancel(ctx)
+	var cancel context.CancelFunc
+	if slabTimeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, slabTimeout, ErrSlabUploadTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
 	defer cancel()
 
+	// only count timeouts that failed the upload
+	defer func() {
+		if err != nil && errors.Is(context.Cause(ctx), ErrSlabUploadTimeout) {
+			u.slabTimeouts.Add(1)
+		}
+	}()
+
 	// prepare the upload
 	slab, respChan := u.newSlabUpload(ctx, shards, candidates, mem, maxOverdrive)
 
@@ -802,12 +888,22 @@ loop:
 	}
 	overdrivePct = float64(numOverdrive) / float64(slab.numSectors)
 
-	if slab.numUploaded < slab.numSectors {
+	if slab.numUploaded < minConfirmations {
 		remaining := slab.numSectors - slab.numUploaded
 		err = fmt.Errorf("failed to upload slab: launched=%d uploaded=%d remaining=%d inflight=%d pending=%d uploaders=%d errors=%d %w", slab.numLaunched, slab.numUploaded, remaining, slab.numInflight, len(buffer), len(slab.candidates), len(slab.errs), slab.errs)
 		return
 	}
 
+	// enough shards were uploaded, include the ones that weren't so the
+	// sectors line up with the shards, they get repaired by migrations
+	sectors = sectors[:0]
+	for _, sector := range slab.sectors {
+		if sector.isUploaded() {
+			sectors = append(sectors, sector.uploaded)
+		} else {
+			sectors = append(sectors, uploadedSector{root: sector.root})
+		}
+	}
 	return
 }
 
@@ -957,8 +1053,12 @@ func (s *sectorUpload) isUploaded() bool {
 }
 
 func (us uploadedSector) toObjectSector() object.Sector {
+	contracts := make(map[types.PublicKey][]types.FileContractID)
+	if us.fcid != (types.FileContractID{}) {
+		contracts[us.hk] = []types.FileContractID{us.fcid}
+	}
 	return object.Sector{
-		Contracts: map[types.PublicKey][]types.FileContractID{us.hk: {us.fcid}},
+		Contracts: contracts,
 		Root:      us.root,
 	}
 }
@@ -3,6 +3,7 @@ package upload
 import (
 	"context"
 	"testing"
+	"time"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/api"
@@ -22,7 +23,7 @@ func (hm *hostManager) Host(hk types.PublicKey, fcid types.FileContractID, siamu
 
 func TestRefreshUploaders(t *testing.T) {
 	hm := &hostManager{}
-	ul := NewManager(context.Background(), nil, hm, nil, nil, nil, nil, 0, 0, zap.NewNop())
+	ul := NewManager(context.Background(), nil, hm, nil, nil, nil, nil, nil, 0, 0, 0, 0, zap.NewNop())
 
 	// prepare host info
 	hi := HostInfo{
@@ -65,3 +66,39 @@ func TestRefreshUploaders(t *testing.T) {
 		t.Fatalf("unexpected number of uploaders, %v != 0", len(ul.uploaders))
 	}
 }
+
+func TestMinConfirmations(t *testing.T) {
+	rs := api.RedundancySettings{MinShards: 2, TotalShards: 6}
+	tests := []struct {
+		configured int
+		want       uint64
+	}{
+		{0, 6},
+		{1, 2},
+		{4, 4},
+		{6, 6},
+		{10, 6},
+	}
+	for _, test := range tests {
+		mgr := NewManager(context.Background(), nil, &hostManager{}, nil, nil, nil, nil, nil, 0, 0, 0, test.configured, zap.NewNop())
+		if got := mgr.minConfirmations(rs); got != test.want {
+			t.Fatalf("configured %d: expected %d, got %d", test.configured, test.want, got)
+		}
+	}
+}
+
+func TestSlabUploadTimeout(t *testing.T) {
+	// assert the configured timeout is used
+	u := &upload{slabTimeout: time.Minute}
+	if timeout := u.slabUploadTimeout(30); timeout != time.Minute {
+		t.Fatalf("expected configured timeout, got %v", timeout)
+	}
+
+	// assert the timeout is derived from the slab size by default
+	u.slabTimeout = 0
+	if timeout := u.slabUploadTimeout(30); timeout != 2*time.Minute {
+		t.Fatalf("expected 2m, got %v", timeout)
+	} else if timeout := u.slabUploadTimeout(15); timeout != time.Minute {
+		t.Fatalf("expected 1m, got %v", timeout)
+	}
+}
@@ -12,7 +12,8 @@ import (
 )
 
 const (
-	cacheKeyUsableHosts = "usablehosts"
+	cacheKeyBucketPrefix = "bucket_"
+	cacheKeyUsableHosts  = "usablehosts"
 )
 
 type memoryCache struct {
@@ -65,10 +66,12 @@ func (c *memoryCache) Set(key string, value interface{}) {
 
 type (
 	Bus interface {
+		Bucket(ctx context.Context, bucket string) (api.Bucket, error)
 		UsableHosts(ctx context.Context) ([]api.HostInfo, error)
 	}
 
 	WorkerCache interface {
+		Bucket(ctx context.Context, bucket string) (api.Bucket, error)
 		UsableHosts(ctx context.Context) ([]api.HostInfo, error)
 	}
 )
@@ -89,6 +92,26 @@ func NewCache(b Bus, expiry time.Duration, logger *zap.Logger) WorkerCache {
 	}
 }
 
+// Bucket returns the bucket with the given name. If the bucket can't be
+// fetched from the bus, an expired entry is returned if there is one.
+func (c *cache) Bucket(ctx context.Context, bucket string) (api.Bucket, error) {
+	key := cacheKeyBucketPrefix + bucket
+	value, found, expired := c.cache.Get(key)
+	if found && !expired {
+		return value.(api.Bucket), nil
+	}
+
+	b, err := c.b.Bucket(ctx, bucket)
+	if err != nil && found {
+		c.logger.Warnw("failed to refresh bucket, using expired entry", "bucket", bucket, zap.Error(err))
+		return value.(api.Bucket), nil
+	} else if err != nil {
+		return api.Bucket{}, err
+	}
+	c.cache.Set(key, b)
+	return b, nil
+}
+
 func (c *cache) UsableHosts(ctx context.Context) (hosts []api.HostInfo, err error) {
 	value, found, expired := c.cache.Get(cacheKeyUsableHosts)
 	if !found || expired {
@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/v2/api"
+	"go.uber.org/zap"
+)
+
+type bucketBus struct {
+	calls int
+	err   error
+}
+
+func (b *bucketBus) Bucket(_ context.Context, bucket string) (api.Bucket, error) {
+	b.calls++
+	if b.err != nil {
+		return api.Bucket{}, b.err
+	}
+	return api.Bucket{Name: bucket}, nil
+}
+
+func (b *bucketBus) UsableHosts(context.Context) ([]api.HostInfo, error) {
+	return nil, nil
+}
+
+func TestCacheBucket(t *testing.T) {
+	b := &bucketBus{}
+	c := NewCache(b, 0, zap.NewNop()).(*cache)
+
+	// assert the bucket is fetched from the bus
+	if bucket, err := c.Bucket(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	} else if bucket.Name != "foo" || b.calls != 1 {
+		t.Fatalf("unexpected bucket %v or calls %d", bucket.Name, b.calls)
+	}
+
+	// assert an expired entry is returned if the bus fails
+	b.err = errors.New("bus unavailable")
+	if bucket, err := c.Bucket(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	} else if bucket.Name != "foo" || b.calls != 2 {
+		t.Fatalf("unexpected bucket %v or calls %d", bucket.Name, b.calls)
+	}
+
+	// assert the error is returned if there is no entry
+	if _, err := c.Bucket(context.Background(), "bar"); !errors.Is(err, b.err) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// assert unexpired entries are served from the cache
+	b.err = nil
+	c.cache.cacheEntryExpiry = time.Minute
+	c.Bucket(context.Background(), "baz")
+	if _, err := c.Bucket(context.Background(), "baz"); err != nil {
+		t.Fatal(err)
+	} else if b.calls != 4 {
+		t.Fatalf("expected 4 calls, got %d", b.calls)
+	}
+}
@@ -0,0 +1,152 @@
+package worker
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"go.sia.tech/renterd/v2/api"
+)
+
+type (
+	// ObjectCache is an LRU cache for object metadata, entries expire after a
+	// configurable TTL. A TTL of 0 disables the cache.
+	ObjectCache struct {
+		ttl        time.Duration
+		maxEntries int
+
+		mu     sync.Mutex
+		ll     *list.List
+		items  map[objectCacheKey]*list.Element
+		hits   uint64
+		misses uint64
+	}
+
+	objectCacheKey struct {
+		bucket string
+		key    string
+	}
+
+	objectCacheEntry struct {
+		key    objectCacheKey
+		obj    api.Object
+		expiry time.Time
+	}
+)
+
+// NewObjectCache returns a new object metadata cache that holds at most
+// maxEntries entries for the given TTL.
+func NewObjectCache(ttl time.Duration, maxEntries int) *ObjectCache {
+	return &ObjectCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+
+		ll:    list.New(),
+		items: make(map[objectCacheKey]*list.Element),
+	}
+}
+
+// Get returns the cached metadata for the given object.
+func (c *ObjectCache) Get(bucket, key string) (api.Object, bool) {
+	if c.ttl == 0 {
+		return api.Object{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[newObjectCacheKey(bucket, key)]
+	if !ok {
+		c.misses++
+		return api.Object{}, false
+	}
+
+	entry := el.Value.(*objectCacheEntry)
+	if time.Now().After(entry.expiry) {
+		c.removeElement(el)
+		c.misses++
+		return api.Object{}, false
+	}
+
+	c.ll.MoveToFront(el)
+	c.hits++
+	return entry.obj, true
+}
+
+// Set caches the metadata for the given object, evicting the least recently
+// used entry if the cache is full.
+func (c *ObjectCache) Set(bucket, key string, obj api.Object) {
+	if c.ttl == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ck := newObjectCacheKey(bucket, key)
+	if el, ok := c.items[ck]; ok {
+		c.removeElement(el)
+	}
+	c.items[ck] = c.ll.PushFront(&objectCacheEntry{
+		key:    ck,
+		obj:    obj,
+		expiry: time.Now().Add(c.ttl),
+	})
+	for c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// Invalidate removes the cached metadata for the given object.
+func (c *ObjectCache) Invalidate(bucket, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[newObjectCacheKey(bucket, key)]; ok {
+		c.removeElement(el)
+	}
+}
+
+// InvalidatePrefix removes the cached metadata for all objects in the given
+// bucket whose key starts with prefix.
+func (c *ObjectCache) InvalidatePrefix(bucket, prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix = newObjectCacheKey(bucket, prefix).key
+	for ck, el := range c.items {
+		if ck.bucket == bucket && strings.HasPrefix(ck.key, prefix) {
+			c.removeElement(el)
+		}
+	}
+}
+
+// Stats returns the cache's hit and miss counters.
+func (c *ObjectCache) Stats() api.ObjectCacheStatsResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var hitRate float64
+	if total := c.hits + c.misses; total > 0 {
+		hitRate = float64(c.hits) / float64(total)
+	}
+	return api.ObjectCacheStatsResponse{
+		Entries: c.ll.Len(),
+		Hits:    c.hits,
+		Misses:  c.misses,
+		HitRate: hitRate,
+	}
+}
+
+// newObjectCacheKey returns the cache key for the given object, keys are
+// normalized to start with a slash like they do in the bus so that keys with
+// and without leading slash refer to the same object.
+func newObjectCacheKey(bucket, key string) objectCacheKey {
+	return objectCacheKey{bucket: bucket, key: "/" + strings.TrimPrefix(key, "/")}
+}
+
+func (c *ObjectCache) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*objectCacheEntry).key)
+}
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/v2/api"
+)
+
+func TestObjectCache(t *testing.T) {
+	c := NewObjectCache(time.Minute, 2)
+
+	// assert miss
+	if _, ok := c.Get("bucket", "foo"); ok {
+		t.Fatal("expected miss")
+	}
+
+	// assert hit
+	c.Set("bucket", "foo", api.Object{ObjectMetadata: api.ObjectMetadata{Key: "foo"}})
+	if obj, ok := c.Get("bucket", "foo"); !ok || obj.ObjectMetadata.Key != "foo" {
+		t.Fatal("expected hit")
+	} else if _, ok := c.Get("other", "foo"); ok {
+		t.Fatal("expected miss for other bucket")
+	}
+
+	// assert the least recently used entry is evicted
+	c.Set("bucket", "bar", api.Object{})
+	c.Get("bucket", "foo")
+	c.Set("bucket", "baz", api.Object{})
+	if _, ok := c.Get("bucket", "bar"); ok {
+		t.Fatal("expected bar to be evicted")
+	} else if _, ok := c.Get("bucket", "foo"); !ok {
+		t.Fatal("expected foo to be cached")
+	}
+
+	// assert invalidation
+	c.Invalidate("bucket", "foo")
+	if _, ok := c.Get("bucket", "foo"); ok {
+		t.Fatal("expected miss after invalidation")
+	}
+	c.Set("bucket", "dir/foo", api.Object{})
+	c.InvalidatePrefix("bucket", "dir/")
+	if _, ok := c.Get("bucket", "dir/foo"); ok {
+		t.Fatal("expected miss after prefix invalidation")
+	} else if _, ok := c.Get("bucket", "baz"); !ok {
+		t.Fatal("expected baz to be cached")
+	}
+
+	// assert stats
+	stats := c.Stats()
+	if stats.Hits != 4 || stats.Misses != 5 || stats.Entries != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	} else if stats.HitRate != 4.0/9.0 {
+		t.Fatal("unexpected hit rate", stats.HitRate)
+	}
+
+	// assert expiry
+	c = NewObjectCache(time.Millisecond, 2)
+	c.Set("bucket", "foo", api.Object{})
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := c.Get("bucket", "foo"); ok {
+		t.Fatal("expected entry to be expired")
+	}
+
+	// assert a TTL of 0 disables the cache
+	c = NewObjectCache(0, 2)
+	c.Set("bucket", "foo", api.Object{})
+	if _, ok := c.Get("bucket", "foo"); ok {
+		t.Fatal("expected cache to be disabled")
+	}
+
+	// assert keys with and without leading slash refer to the same object
+	c = NewObjectCache(time.Minute, 2)
+	c.Set("bucket", "/foo", api.Object{})
+	if _, ok := c.Get("bucket", "foo"); !ok {
+		t.Fatal("expected hit")
+	}
+	c.Invalidate("bucket", "foo")
+	if _, ok := c.Get("bucket", "/foo"); ok {
+		t.Fatal("expected miss after invalidation")
+	}
+	c.Set("bucket", "/dir/foo", api.Object{})
+	c.InvalidatePrefix("bucket", "dir/")
+	if _, ok := c.Get("bucket", "/dir/foo"); ok {
+		t.Fatal("expected miss after prefix invalidation")
+	}
+}
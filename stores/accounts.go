@@ -9,7 +9,7 @@ import (
 
 // Accounts returns all accounts from the db.
 func (s *SQLStore) Accounts(ctx context.Context, owner string) (accounts []api.Account, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		accounts, err = tx.Accounts(ctx, owner)
 		return err
 	})
@@ -19,7 +19,7 @@ func (s *SQLStore) Accounts(ctx context.Context, owner string) (accounts []api.A
 // SaveAccounts saves the given accounts in the db, overwriting any existing
 // ones.
 func (s *SQLStore) SaveAccounts(ctx context.Context, accounts []api.Account) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.SaveAccounts(ctx, accounts)
 	})
 }
@@ -0,0 +1,25 @@
+package stores
+
+import (
+	"context"
+
+	"go.sia.tech/renterd/v2/alerts"
+	sql "go.sia.tech/renterd/v2/stores/sql"
+)
+
+// DismissedAlerts returns the recorded alert dismissals, the most recent ones
+// first.
+func (s *SQLStore) DismissedAlerts(ctx context.Context, offset, limit int) (dismissed []alerts.DismissedAlert, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) (txErr error) {
+		dismissed, txErr = tx.DismissedAlerts(ctx, offset, limit)
+		return
+	})
+	return
+}
+
+// RecordDismissedAlerts records the given alert dismissals.
+func (s *SQLStore) RecordDismissedAlerts(ctx context.Context, dismissed ...alerts.DismissedAlert) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.RecordDismissedAlerts(ctx, dismissed...)
+	})
+}
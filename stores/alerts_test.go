@@ -0,0 +1,65 @@
+package stores
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/v2/alerts"
+)
+
+func TestDismissedAlerts(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// record two dismissals, one with the alert attached
+	now := time.Now().Round(time.Millisecond)
+	alert := alerts.Alert{
+		ID:        alerts.RandomAlertID(),
+		Severity:  alerts.SeverityWarning,
+		Message:   "test",
+		Data:      map[string]any{"foo": "bar"},
+		Timestamp: now.Add(-time.Hour),
+	}
+	first := alerts.DismissedAlert{
+		AlertID:     alert.ID,
+		Reason:      "false positive",
+		DismissedBy: "alice",
+		Timestamp:   now.Add(-time.Minute),
+		Alert:       &alert,
+	}
+	second := alerts.DismissedAlert{
+		AlertID:     alerts.RandomAlertID(),
+		Reason:      "resolved",
+		DismissedBy: "bob",
+		Timestamp:   now,
+	}
+	if err := ss.RecordDismissedAlerts(context.Background(), first, second); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert they are returned most recent first
+	dismissed, err := ss.DismissedAlerts(context.Background(), 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(dismissed) != 2 {
+		t.Fatalf("expected 2 dismissed alerts, got %d", len(dismissed))
+	} else if dismissed[0].AlertID != second.AlertID || dismissed[0].Alert != nil {
+		t.Fatalf("unexpected dismissed alert %+v", dismissed[0])
+	} else if dismissed[1].AlertID != first.AlertID || dismissed[1].Reason != first.Reason || dismissed[1].DismissedBy != first.DismissedBy {
+		t.Fatalf("unexpected dismissed alert %+v", dismissed[1])
+	} else if !dismissed[1].Timestamp.Equal(first.Timestamp) {
+		t.Fatalf("expected timestamp %v, got %v", first.Timestamp, dismissed[1].Timestamp)
+	} else if dismissed[1].Alert == nil || dismissed[1].Alert.ID != alert.ID || !reflect.DeepEqual(dismissed[1].Alert.Data, alert.Data) {
+		t.Fatalf("unexpected alert %+v", dismissed[1].Alert)
+	}
+
+	// assert offset and limit are applied
+	dismissed, err = ss.DismissedAlerts(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(dismissed) != 1 || dismissed[0].AlertID != first.AlertID {
+		t.Fatalf("unexpected dismissed alerts %+v", dismissed)
+	}
+}
@@ -8,7 +8,7 @@ import (
 )
 
 func (s *SQLStore) AutopilotConfig(ctx context.Context) (cfg api.AutopilotConfig, err error) {
-	s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		cfg, err = tx.AutopilotConfig(ctx)
 		return
 	})
@@ -16,13 +16,13 @@ func (s *SQLStore) AutopilotConfig(ctx context.Context) (cfg api.AutopilotConfig
 }
 
 func (s *SQLStore) InitAutopilotConfig(ctx context.Context) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.InitAutopilotConfig(ctx)
 	})
 }
 
 func (s *SQLStore) UpdateAutopilotConfig(ctx context.Context, cfg api.AutopilotConfig) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.UpdateAutopilotConfig(ctx, cfg)
 	})
 }
@@ -104,7 +104,7 @@ func BenchmarkPruneHostSectors(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		var n int64
 		if err := db.Transaction(context.Background(), func(tx sql.DatabaseTx) (err error) {
-			n, err = tx.PruneHostSectors(context.Background(), hostSectorPruningBatchSize)
+			n, _, err = tx.PruneHostSectors(context.Background(), sql.HostSectorCursor{}, hostSectorPruningBatchSize)
 			return
 		}); err != nil {
 			b.Fatal(err)
@@ -220,7 +220,7 @@ func BenchmarkObjects(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			if err := db.Transaction(context.Background(), func(tx sql.DatabaseTx) error {
-				_, err := tx.Objects(context.Background(), bucket, dirs[i%len(dirs)], "", "/", "", "", "", -1, object.EncryptionKey{})
+				_, err := tx.Objects(context.Background(), bucket, dirs[i%len(dirs)], "", "/", "", "", "", -1, object.EncryptionKey{}, nil)
 				return err
 			}); err != nil {
 				b.Fatal(err)
@@ -73,7 +73,7 @@ func TestProcessChainUpdate(t *testing.T) {
 
 			if err := tx.UpdateContractRevision(fcid, 1, 2, 3); err != nil {
 				return err
-			} else if err := tx.UpdateContractState(fcid, api.ContractStateActive); err != nil {
+			} else if err := tx.UpdateContractState(fcid, api.ContractStateActive, "contract confirmed", 1); err != nil {
 				return err
 			} else if err := tx.UpdateContractProofHeight(fcid, 4); err != nil {
 				return err
@@ -126,7 +126,7 @@ func TestProcessChainUpdate(t *testing.T) {
 
 	// assert update failed contracts is successful
 	if err := ss.ProcessChainUpdate(context.Background(), func(tx sql.ChainUpdateTx) error {
-		return tx.UpdateFailedContracts(we + 1)
+		return tx.UpdateFailedContracts(we+1, we+2)
 	}); err != nil {
 		t.Fatal("unexpected error", err)
 	}
@@ -136,11 +136,33 @@ func TestProcessChainUpdate(t *testing.T) {
 		t.Fatal("unexpected state", c.State)
 	}
 
+	// assert the state transitions were recorded only once
+	if history, err := ss.ContractStateHistory(context.Background(), fcid); err != nil {
+		t.Fatal(err)
+	} else if len(history) != 2 {
+		t.Fatalf("expected 2 state changes, got %+v", history)
+	} else if h := history[0]; h.FromState != api.ContractStatePending || h.ToState != api.ContractStateActive || h.Reason != "contract confirmed" || h.Height != 1 {
+		t.Fatalf("unexpected state change %+v", h)
+	} else if h := history[1]; h.FromState != api.ContractStateActive || h.ToState != api.ContractStateFailed || h.Height != we+2 {
+		t.Fatalf("unexpected state change %+v", h)
+	}
+
 	// renew the contract
 	if err = ss.renewTestContract(hks[0], fcid, types.FileContractID{2}, 1); err != nil {
 		t.Fatal(err)
 	}
 
+	// assert the state history stayed with the renewed contract
+	if history, err := ss.ContractStateHistory(context.Background(), fcid); err != nil {
+		t.Fatal(err)
+	} else if len(history) != 2 {
+		t.Fatalf("expected 2 state changes, got %+v", history)
+	} else if history, err := ss.ContractStateHistory(context.Background(), types.FileContractID{2}); err != nil {
+		t.Fatal(err)
+	} else if len(history) != 0 {
+		t.Fatalf("expected no state changes for the renewal, got %+v", history)
+	}
+
 	// assert we can fetch the state of the archived contract
 	if err := ss.ProcessChainUpdate(context.Background(), func(tx sql.ChainUpdateTx) (err error) {
 		state, err = tx.ContractState(fcid)
@@ -399,6 +421,191 @@ func TestProcessChainUpdate(t *testing.T) {
 	}
 }
 
+// TestRevertContractState asserts reverting the formation of a contract that
+// was resolved in the same block moves it back to pending.
+func TestRevertContractState(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a contract
+	hk := types.PublicKey{1}
+	fcid := types.FileContractID{1}
+	if err := ss.addTestHost(hk); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.addTestContract(fcid, hk); err != nil {
+		t.Fatal(err)
+	}
+
+	// complete the contract and revert it
+	for _, state := range []api.ContractState{api.ContractStateComplete, api.ContractStatePending} {
+		if err := ss.ProcessChainUpdate(context.Background(), func(tx sql.ChainUpdateTx) error {
+			return tx.UpdateContractState(fcid, state, "test", 1)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert both transitions were recorded
+	if c, err := ss.Contract(context.Background(), fcid); err != nil {
+		t.Fatal(err)
+	} else if c.State != api.ContractStatePending {
+		t.Fatalf("expected state %v, got %v", api.ContractStatePending, c.State)
+	} else if events, err := ss.ContractStateHistory(context.Background(), fcid); err != nil {
+		t.Fatal(err)
+	} else if len(events) != 2 || events[1].FromState != api.ContractStateComplete || events[1].ToState != api.ContractStatePending {
+		t.Fatalf("unexpected state history %+v", events)
+	}
+}
+
+func TestArchiveExpiredContracts(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+
+	// add test hosts and contracts
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, contracts, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	we := contracts[0].WindowEnd
+
+	// activate the first two contracts, the second one has a proof
+	if err := ss.ProcessChainUpdate(context.Background(), func(tx sql.ChainUpdateTx) error {
+		for _, fcid := range fcids[:2] {
+			if err := tx.UpdateContractRevision(fcid, 1, 1, 1); err != nil {
+				return err
+			} else if err := tx.UpdateContractState(fcid, api.ContractStateActive, "contract confirmed", 1); err != nil {
+				return err
+			}
+		}
+		return tx.UpdateContractProofHeight(fcids[1], 1)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// archive expired contracts at the window end, nothing should happen
+	archive := func(height uint64) (failed []types.FileContractID) {
+		t.Helper()
+		if err := ss.ProcessChainUpdate(context.Background(), func(tx sql.ChainUpdateTx) (err error) {
+			failed, err = tx.ArchiveExpiredContracts(height)
+			return
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if failed := archive(we); len(failed) != 0 {
+		t.Fatal("unexpected failed contracts", failed)
+	} else if n := ss.Count("contracts WHERE archival_reason IS NULL"); n != 3 {
+		t.Fatalf("expected 3 unarchived contracts, got %d", n)
+	}
+
+	// archive expired contracts after the window end
+	if failed := archive(we + 1); len(failed) != 1 || failed[0] != fcids[0] {
+		t.Fatal("unexpected failed contracts", failed)
+	}
+
+	// assert the active contracts were archived with the right state
+	archived, err := ss.Contracts(context.Background(), api.ContractsOpts{FilterMode: api.ContractFilterModeArchived})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(archived) != 2 {
+		t.Fatalf("expected 2 archived contracts, got %d", len(archived))
+	}
+	for i, want := range []string{api.ContractStateFailed, api.ContractStateComplete} {
+		if c := archived[i]; c.ID != fcids[i] {
+			t.Fatalf("unexpected contract %v", c.ID)
+		} else if c.State != want {
+			t.Fatalf("expected state %v, got %v", want, c.State)
+		} else if c.ArchivalReason != api.ContractArchivalReasonExpired {
+			t.Fatalf("unexpected archival reason %v", c.ArchivalReason)
+		}
+	}
+
+	// assert the pending contract was left alone
+	if c, err := ss.Contract(context.Background(), fcids[2]); err != nil {
+		t.Fatal(err)
+	} else if c.State != api.ContractStatePending || c.ArchivalReason != "" {
+		t.Fatalf("unexpected contract %+v", c)
+	}
+
+	// assert archiving again is a no-op
+	if failed := archive(we + 2); len(failed) != 0 {
+		t.Fatal("unexpected failed contracts", failed)
+	}
+}
+
+func TestUpdateUnknownContracts(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+
+	// add test hosts and contracts
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// activate all contracts, the first two are on chain and the second one
+	// has a proof
+	if err := ss.ProcessChainUpdate(context.Background(), func(tx sql.ChainUpdateTx) error {
+		for _, fcid := range fcids {
+			if err := tx.UpdateContractState(fcid, api.ContractStateActive, "contract confirmed", 1); err != nil {
+				return err
+			}
+		}
+		if err := tx.UpdateFileContractElements([]types.V2FileContractElement{{ID: fcids[0]}, {ID: fcids[1]}}); err != nil {
+			return err
+		}
+		return tx.UpdateContractProofHeight(fcids[1], 1)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// archive and unarchive the contracts, resetting their state to unknown
+	toArchive := make(map[types.FileContractID]string)
+	for _, fcid := range fcids {
+		toArchive[fcid] = "test"
+	}
+	if err := ss.ArchiveContracts(context.Background(), toArchive); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UnarchiveContracts(context.Background(), fcids); err != nil {
+		t.Fatal(err)
+	}
+
+	// update the unknown contracts twice, to assert it's idempotent
+	for i := 0; i < 2; i++ {
+		if err := ss.ProcessChainUpdate(context.Background(), func(tx sql.ChainUpdateTx) error {
+			return tx.UpdateUnknownContracts(2)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert only the unresolved contract on chain is active again
+	for i, want := range []string{api.ContractStateActive, api.ContractStateUnknown, api.ContractStateUnknown} {
+		if c, err := ss.Contract(context.Background(), fcids[i]); err != nil {
+			t.Fatal(err)
+		} else if c.State != want {
+			t.Fatalf("expected state %v for contract %d, got %v", want, i, c.State)
+		}
+	}
+
+	// assert the state change was recorded once
+	history, err := ss.ContractStateHistory(context.Background(), fcids[0])
+	if err != nil {
+		t.Fatal(err)
+	} else if len(history) != 3 {
+		t.Fatalf("expected 3 state changes, got %d", len(history))
+	} else if last := history[len(history)-1]; last.FromState != api.ContractStateUnknown || last.ToState != api.ContractStateActive || last.Height != 2 {
+		t.Fatalf("unexpected state change %+v", last)
+	}
+}
+
 func TestContractElements(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 
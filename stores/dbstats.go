@@ -0,0 +1,58 @@
+package stores
+
+import (
+	dsql "database/sql"
+
+	"go.sia.tech/renterd/v2/api"
+	isql "go.sia.tech/renterd/v2/internal/sql"
+)
+
+// DBStats returns the connection pool utilization of the main and the metrics
+// database as well as the durations of the queries and transactions executed
+// on them.
+func (s *SQLStore) DBStats() api.DBStatsResponse {
+	return api.DBStatsResponse{
+		Main:    poolStats(s.db.DB().DB().Stats()),
+		Metrics: poolStats(s.dbMetrics.DB().DB().Stats()),
+
+		MainDurations:    durationStats(s.db.DB().DurationStats()),
+		MetricsDurations: durationStats(s.dbMetrics.DB().DurationStats()),
+	}
+}
+
+func durationStats(stats isql.DurationStats) api.DBDurationStats {
+	queries := make(map[string]api.DBDurationHistogram, len(stats.Queries))
+	for name, h := range stats.Queries {
+		queries[name] = durationHistogram(h)
+	}
+	return api.DBDurationStats{
+		Queries:      queries,
+		Transactions: durationHistogram(stats.Transactions),
+	}
+}
+
+func durationHistogram(h isql.Histogram) api.DBDurationHistogram {
+	buckets := make([]api.DBDurationBucket, len(h.Buckets))
+	for i := range h.Buckets {
+		buckets[i] = api.DBDurationBucket{
+			UpperBound: h.Buckets[i],
+			Count:      h.Counts[i],
+		}
+	}
+	return api.DBDurationHistogram{
+		Buckets: buckets,
+		Count:   h.Count,
+		Sum:     h.Sum,
+	}
+}
+
+func poolStats(stats dsql.DBStats) api.DBPoolStats {
+	return api.DBPoolStats{
+		MaxOpenConns: stats.MaxOpenConnections,
+		Open:         stats.OpenConnections,
+		InUse:        stats.InUse,
+		Idle:         stats.Idle,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: api.DurationMS(stats.WaitDuration),
+	}
+}
@@ -0,0 +1,62 @@
+package stores
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDBStats(t *testing.T) {
+	cfg := defaultTestSQLStoreConfig
+	cfg.pool = PoolConfig{
+		MaxOpenConns:    5,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: time.Minute,
+		ConnMaxIdleTime: 30 * time.Second,
+	}
+	ss := newTestSQLStore(t, cfg)
+	defer ss.Close()
+
+	// perform a query to make sure there is at least one connection
+	if _, err := ss.ChainIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := ss.DBStats()
+	if stats.Main.MaxOpenConns != 5 {
+		t.Fatalf("expected max open conns to be 5, got %d", stats.Main.MaxOpenConns)
+	} else if stats.Metrics.MaxOpenConns != 5 {
+		t.Fatalf("expected max open conns to be 5, got %d", stats.Metrics.MaxOpenConns)
+	} else if stats.Main.Open == 0 || stats.Main.Open > 5 {
+		t.Fatalf("unexpected number of open connections %d", stats.Main.Open)
+	} else if stats.Main.Idle > 2 {
+		t.Fatalf("expected at most 2 idle connections, got %d", stats.Main.Idle)
+	} else if stats.Main.Open != stats.Main.InUse+stats.Main.Idle {
+		t.Fatalf("open connections %d should equal in use %d plus idle %d", stats.Main.Open, stats.Main.InUse, stats.Main.Idle)
+	}
+
+	// assert the query was recorded under its text
+	h, ok := stats.MainDurations.Queries["SELECT height, block_id FROM consensus_infos WHERE id = ?"]
+	if !ok {
+		t.Fatalf("query not recorded, %v", stats.MainDurations.Queries)
+	} else if h.Count == 0 {
+		t.Fatal("expected at least one recorded query")
+	} else if len(h.Buckets) == 0 || h.Buckets[len(h.Buckets)-1].Count > h.Count {
+		t.Fatalf("unexpected buckets %v", h.Buckets)
+	}
+	for i := 1; i < len(h.Buckets); i++ {
+		if h.Buckets[i].Count < h.Buckets[i-1].Count {
+			t.Fatalf("bucket counts should be cumulative, %v", h.Buckets)
+		}
+	}
+	if stats.MainDurations.Transactions.Count == 0 {
+		t.Fatal("expected at least one recorded transaction")
+	}
+
+	// assert zero values leave the defaults in place
+	ss2 := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss2.Close()
+	if stats := ss2.DBStats(); stats.Main.MaxOpenConns != 0 {
+		t.Fatalf("expected unlimited open conns, got %d", stats.Main.MaxOpenConns)
+	}
+}
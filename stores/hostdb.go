@@ -35,36 +35,52 @@ func (s *SQLStore) Host(ctx context.Context, hostKey types.PublicKey) (api.Host,
 }
 
 func (s *SQLStore) UpdateHostCheck(ctx context.Context, hk types.PublicKey, hc api.HostChecks) (err error) {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.UpdateHostCheck(ctx, hk, hc)
 	})
 }
 
 func (s *SQLStore) ResetLostSectors(ctx context.Context, hk types.PublicKey) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.ResetLostSectors(ctx, hk)
 	})
 }
 
+func (s *SQLStore) UpdateHostSectorSize(ctx context.Context, hk types.PublicKey, sectorSize uint64) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.UpdateHostSectorSize(ctx, hk, sectorSize)
+	})
+}
+
 func (s *SQLStore) Hosts(ctx context.Context, opts api.HostOptions) ([]api.Host, error) {
 	var hosts []api.Host
-	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	err := s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		hosts, err = tx.Hosts(ctx, opts)
 		return
 	})
 	return hosts, err
 }
 
-func (s *SQLStore) RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDowntime time.Duration) (removed uint64, err error) {
+// RemoveOfflineHosts removes up to limit offline hosts, if more than
+// maxEligible hosts are eligible for removal none are removed. Hosts that were
+// offline the longest are removed first. Both limits are ignored when zero.
+func (s *SQLStore) RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDowntime time.Duration, limit, maxEligible int) (removed, eligible uint64, err error) {
 	// sanity check 'maxDowntime'
 	if maxDowntime < 0 {
-		return 0, ErrNegativeMaxDowntime
+		return 0, 0, ErrNegativeMaxDowntime
 	}
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
-		n, err := tx.RemoveOfflineHosts(ctx, minRecentFailures, maxDowntime)
-		removed = uint64(n)
+	var exempted []types.PublicKey
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		n, e, hks, err := tx.RemoveOfflineHosts(ctx, minRecentFailures, maxDowntime, s.exemptActiveContractHosts, limit, maxEligible)
+		removed, eligible, exempted = uint64(n), uint64(e), hks
 		return err
 	})
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, hk := range exempted {
+		s.logger.Warnw("offline host was not removed because it has active contracts", "hostKey", hk)
+	}
 	return
 }
 
@@ -73,7 +89,7 @@ func (s *SQLStore) UpdateHostAllowlistEntries(ctx context.Context, add, remove [
 	if len(add)+len(remove) == 0 && !clear {
 		return nil
 	}
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.UpdateHostAllowlistEntries(ctx, add, remove, clear)
 	})
 }
@@ -83,13 +99,13 @@ func (s *SQLStore) UpdateHostBlocklistEntries(ctx context.Context, add, remove [
 	if len(add)+len(remove) == 0 && !clear {
 		return nil
 	}
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.UpdateHostBlocklistEntries(ctx, add, remove, clear)
 	})
 }
 
 func (s *SQLStore) HostAllowlist(ctx context.Context) (allowlist []types.PublicKey, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		allowlist, err = tx.HostAllowlist(ctx)
 		return err
 	})
@@ -97,7 +113,7 @@ func (s *SQLStore) HostAllowlist(ctx context.Context) (allowlist []types.PublicK
 }
 
 func (s *SQLStore) HostBlocklist(ctx context.Context) (blocklist []string, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		blocklist, err = tx.HostBlocklist(ctx)
 		return err
 	})
@@ -105,13 +121,13 @@ func (s *SQLStore) HostBlocklist(ctx context.Context) (blocklist []string, err e
 }
 
 func (s *SQLStore) RecordHostScans(ctx context.Context, scans []api.HostScan) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.RecordHostScans(ctx, scans)
 	})
 }
 
 func (s *SQLStore) UsableHosts(ctx context.Context) (hosts []sql.HostInfo, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		hosts, err = tx.UsableHosts(ctx)
 		return err
 	})
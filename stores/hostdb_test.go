@@ -684,7 +684,7 @@ func TestRemoveHosts(t *testing.T) {
 	}
 
 	// assert no hosts are removed
-	removed, err := ss.RemoveOfflineHosts(context.Background(), 0, time.Hour)
+	removed, _, err := ss.RemoveOfflineHosts(context.Background(), 0, time.Hour, 0, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -716,7 +716,7 @@ func TestRemoveHosts(t *testing.T) {
 	}
 
 	// assert no hosts are removed
-	removed, err = ss.RemoveOfflineHosts(context.Background(), 0, time.Hour)
+	removed, _, err = ss.RemoveOfflineHosts(context.Background(), 0, time.Hour, 0, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -732,7 +732,7 @@ func TestRemoveHosts(t *testing.T) {
 	}
 
 	// assert no hosts are removed at 61 minutes
-	removed, err = ss.RemoveOfflineHosts(context.Background(), 0, time.Minute*61)
+	removed, _, err = ss.RemoveOfflineHosts(context.Background(), 0, time.Minute*61, 0, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -741,7 +741,7 @@ func TestRemoveHosts(t *testing.T) {
 	}
 
 	// assert no hosts are removed at 60 minutes if we require at least 4 failed scans
-	removed, err = ss.RemoveOfflineHosts(context.Background(), 4, time.Minute*60)
+	removed, _, err = ss.RemoveOfflineHosts(context.Background(), 4, time.Minute*60, 0, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -750,7 +750,7 @@ func TestRemoveHosts(t *testing.T) {
 	}
 
 	// assert hosts gets removed at 60 minutes if we require at least 3 failed scans
-	removed, err = ss.RemoveOfflineHosts(context.Background(), 3, time.Minute*60)
+	removed, _, err = ss.RemoveOfflineHosts(context.Background(), 3, time.Minute*60, 0, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -764,6 +764,104 @@ func TestRemoveHosts(t *testing.T) {
 	}
 }
 
+func TestRemoveHostsExemptActiveContracts(t *testing.T) {
+	cfg := defaultTestSQLStoreConfig
+	cfg.exemptActiveContractHosts = true
+	ss := newTestSQLStore(t, cfg)
+	defer ss.Close()
+
+	// add two hosts and a contract with the first one
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2 := hks[0], hks[1]
+	fcids, _, err := ss.addTestContracts([]types.PublicKey{hk1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// take both hosts offline
+	now := time.Now().UTC()
+	for _, hk := range hks {
+		if err := ss.RecordHostScans(context.Background(), []api.HostScan{
+			newTestScan(hk, now.Add(-2*time.Hour), rhp4.HostSettings{}, false),
+			newTestScan(hk, now.Add(-time.Hour), rhp4.HostSettings{}, false),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert only the host without contracts is removed
+	removed, _, err := ss.RemoveOfflineHosts(context.Background(), 0, time.Hour, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if removed != 1 {
+		t.Fatalf("expected 1 host to be removed, got %v", removed)
+	} else if _, err := ss.Host(context.Background(), hk2); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("expected host to be removed", err)
+	} else if _, err := ss.Host(context.Background(), hk1); err != nil {
+		t.Fatal("expected host to be exempted", err)
+	}
+
+	// archive the contract and assert the host is removed
+	if err := ss.ArchiveContract(context.Background(), fcids[0], api.ContractArchivalReasonRemoved); err != nil {
+		t.Fatal(err)
+	}
+	removed, _, err = ss.RemoveOfflineHosts(context.Background(), 0, time.Hour, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if removed != 1 {
+		t.Fatalf("expected 1 host to be removed, got %v", removed)
+	} else if _, err := ss.Host(context.Background(), hk1); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("expected host to be removed", err)
+	}
+}
+
+func TestRemoveHostsLimits(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add five hosts and take them offline
+	hks, err := ss.addTestHosts(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for _, hk := range hks {
+		if err := ss.RecordHostScans(context.Background(), []api.HostScan{
+			newTestScan(hk, now.Add(-2*time.Hour), rhp4.HostSettings{}, false),
+			newTestScan(hk, now.Add(-time.Hour), rhp4.HostSettings{}, false),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert no hosts are removed if there are too many eligible hosts
+	removed, eligible, err := ss.RemoveOfflineHosts(context.Background(), 0, time.Hour, 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	} else if removed != 0 || eligible != 5 {
+		t.Fatalf("unexpected removed %v and eligible %v", removed, eligible)
+	}
+
+	// assert the limit is respected
+	removed, eligible, err = ss.RemoveOfflineHosts(context.Background(), 0, time.Hour, 2, 5)
+	if err != nil {
+		t.Fatal(err)
+	} else if removed != 2 || eligible != 5 {
+		t.Fatalf("unexpected removed %v and eligible %v", removed, eligible)
+	}
+
+	// assert the remaining hosts are removed without limits
+	removed, eligible, err = ss.RemoveOfflineHosts(context.Background(), 0, time.Hour, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if removed != 3 || eligible != 3 {
+		t.Fatalf("unexpected removed %v and eligible %v", removed, eligible)
+	}
+}
+
 func TestSQLHostAllowlist(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
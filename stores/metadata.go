@@ -37,6 +37,15 @@ const (
 	// we prune host sectors.
 	hostSectorPruningBatchSize = 10000
 
+	// prunableRootsBatchSize is the number of contract roots that are diffed
+	// against the database per transaction when streaming prunable roots.
+	prunableRootsBatchSize = 10000
+
+	// hostSectorPruningYieldInterval is the time the host sector prune loop
+	// waits before continuing a cycle that was interrupted because it reached
+	// the maximum number of host sectors to prune per run.
+	hostSectorPruningYieldInterval = time.Second
+
 	refreshHealthMinHealthValidity = 12 * time.Hour
 	refreshHealthMaxHealthValidity = 72 * time.Hour
 )
@@ -49,62 +58,109 @@ var (
 var objectDeleteBatchSizes = []int64{10, 50, 100, 200, 500, 1000, 5000, 10000, 50000, 100000}
 
 func (s *SQLStore) Bucket(ctx context.Context, bucket string) (b api.Bucket, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		b, err = tx.Bucket(ctx, bucket)
 		return
 	})
 	return
 }
 
+func (s *SQLStore) BucketPolicy(ctx context.Context, bucket string) (policy api.BucketPolicy, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		b, err := tx.Bucket(ctx, bucket)
+		policy = b.Policy
+		return err
+	})
+	return
+}
+
+func (s *SQLStore) BucketUsage(ctx context.Context, bucket string) (usage api.BucketUsage, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+		usage, err = tx.BucketUsage(ctx, bucket)
+		return
+	})
+	return
+}
+
 func (s *SQLStore) Buckets(ctx context.Context) (buckets []api.Bucket, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		buckets, err = tx.Buckets(ctx)
 		return
 	})
 	return
 }
 
-func (s *SQLStore) CreateBucket(ctx context.Context, bucket string, policy api.BucketPolicy) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
-		return tx.CreateBucket(ctx, bucket, policy)
+// CreateBucket creates a bucket with the given policy, if redundancy settings
+// are passed they are stored in the same transaction.
+func (s *SQLStore) CreateBucket(ctx context.Context, bucket string, policy api.BucketPolicy, rs *api.RedundancySettings) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		if err := tx.CreateBucket(ctx, bucket, policy); err != nil {
+			return err
+		} else if rs != nil {
+			return tx.UpdateBucketRedundancy(ctx, bucket, rs)
+		}
+		return nil
 	})
 }
 
 func (s *SQLStore) UpdateBucketPolicy(ctx context.Context, bucket string, policy api.BucketPolicy) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.UpdateBucketPolicy(ctx, bucket, policy)
 	})
 }
 
+func (s *SQLStore) UpdateBucketRedundancy(ctx context.Context, bucket string, rs *api.RedundancySettings) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.UpdateBucketRedundancy(ctx, bucket, rs)
+	})
+}
+
 func (s *SQLStore) DeleteBucket(ctx context.Context, bucket string) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.DeleteBucket(ctx, bucket)
 	})
 }
 
+// RecountBucketUsage rebuilds the usage counters of a bucket from its objects,
+// it's meant to recover from the counters becoming inconsistent.
+func (s *SQLStore) RecountBucketUsage(ctx context.Context, bucket string) (usage api.BucketUsage, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+		usage, err = tx.RecountBucketUsage(ctx, bucket)
+		return
+	})
+	return
+}
+
 // ObjectsStats returns some info related to the objects stored in the store. To
 // reduce locking and make sure all results are consistent, everything is done
 // within a single transaction.
 func (s *SQLStore) ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts) (resp api.ObjectsStatsResponse, _ error) {
-	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	err := s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		resp, err = tx.ObjectsStats(ctx, opts)
 		return
 	})
 	return resp, err
 }
 
+func (s *SQLStore) ListSlabBuffers(ctx context.Context) ([]api.SlabBufferInfo, error) {
+	return s.slabBufferMgr.ListBuffers(ctx)
+}
+
 func (s *SQLStore) SlabBuffers(ctx context.Context) ([]api.SlabBuffer, error) {
 	return s.slabBufferMgr.SlabBuffers(), nil
 }
 
 func (s *SQLStore) AddRenewal(ctx context.Context, c api.ContractMetadata) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		// fetch renewed contract
 		renewed, err := tx.Contract(ctx, c.RenewedFrom)
 		if err != nil {
 			return err
 		}
 
+		// carry over the spending cap of the renewed contract
+		c.SpendingCap = renewed.SpendingCap
+
 		// insert renewal by updating the renewed contract
 		err = tx.UpdateContract(ctx, c.RenewedFrom, c)
 		if err != nil {
@@ -115,12 +171,31 @@ func (s *SQLStore) AddRenewal(ctx context.Context, c api.ContractMetadata) error
 		renewed.ArchivalReason = api.ContractArchivalReasonRenewed
 		renewed.RenewedTo = c.ID
 		renewed.Usability = api.ContractUsabilityBad
-		return tx.PutContract(ctx, renewed)
+		if err := tx.PutContract(ctx, renewed); err != nil {
+			return err
+		}
+
+		// the renewal took over the row of the renewed contract, move the
+		// revision and state history as well as its events back to the
+		// renewed contract
+		if err := tx.MoveContractRevisions(ctx, c.ID, c.RenewedFrom); err != nil {
+			return err
+		} else if err := tx.MoveContractStateChanges(ctx, c.ID, c.RenewedFrom); err != nil {
+			return err
+		} else if err := tx.MoveContractEvents(ctx, c.ID, c.RenewedFrom); err != nil {
+			return err
+		}
+
+		// record the renewal for both contracts
+		if err := tx.RecordContractEvent(ctx, c.RenewedFrom, api.ContractEventTypeRenewed, map[string]types.FileContractID{"renewedTo": c.ID}); err != nil {
+			return err
+		}
+		return tx.RecordContractEvent(ctx, c.ID, api.ContractEventTypeFormed, map[string]types.FileContractID{"renewedFrom": c.RenewedFrom})
 	})
 }
 
 func (s *SQLStore) AncestorContracts(ctx context.Context, id types.FileContractID, startHeight uint64) (ancestors []api.ContractMetadata, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		ancestors, err = tx.AncestorContracts(ctx, id, startHeight)
 		return err
 	})
@@ -147,7 +222,7 @@ func (s *SQLStore) ArchiveContracts(ctx context.Context, toArchive map[types.Fil
 
 		// archive the contract but don't interrupt the process if one contract
 		// fails
-		if err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		if err := s.transaction(ctx, func(tx sql.DatabaseTx) error {
 			return tx.ArchiveContract(ctx, fcid, reason)
 		}); err != nil {
 			errs = append(errs, fmt.Sprintf("%v: %v", fcid, err))
@@ -160,6 +235,23 @@ func (s *SQLStore) ArchiveContracts(ctx context.Context, toArchive map[types.Fil
 	return nil
 }
 
+// UnarchiveContracts moves the archived contracts with the given IDs back to the
+// regular contracts and resets their state to unknown. Contracts that are still
+// active on chain are marked active again during the next chain update, others
+// remain unknown until they expire. The contracts keep their usability and the
+// sectors that were stored in them are not restored, the autopilot re-evaluates
+// them during its next contract maintenance.
+func (s *SQLStore) UnarchiveContracts(ctx context.Context, ids []types.FileContractID) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		for _, fcid := range ids {
+			if err := tx.UnarchiveContract(ctx, fcid); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (s *SQLStore) ArchiveAllContracts(ctx context.Context, reason string) error {
 	contracts, err := s.Contracts(ctx, api.ContractsOpts{})
 	if err != nil {
@@ -173,7 +265,7 @@ func (s *SQLStore) ArchiveAllContracts(ctx context.Context, reason string) error
 }
 
 func (s *SQLStore) Contract(ctx context.Context, id types.FileContractID) (cm api.ContractMetadata, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		cm, err = tx.Contract(ctx, id)
 		return err
 	})
@@ -182,7 +274,7 @@ func (s *SQLStore) Contract(ctx context.Context, id types.FileContractID) (cm ap
 
 func (s *SQLStore) Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error) {
 	var contracts []api.ContractMetadata
-	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	err := s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		contracts, err = tx.Contracts(ctx, opts)
 		return
 	})
@@ -190,15 +282,47 @@ func (s *SQLStore) Contracts(ctx context.Context, opts api.ContractsOpts) ([]api
 }
 
 func (s *SQLStore) ContractRoots(ctx context.Context, id types.FileContractID) (roots []types.Hash256, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		roots, err = tx.ContractRoots(ctx, id)
 		return err
 	})
 	return
 }
 
+func (s *SQLStore) ContractEvents(ctx context.Context, id types.FileContractID) (events []api.ContractEvent, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		events, err = tx.ContractEvents(ctx, id)
+		return err
+	})
+	return
+}
+
+func (s *SQLStore) ContractRevisions(ctx context.Context, id types.FileContractID, opts api.ContractRevisionsOpts) (revisions []api.ContractRevisionRecord, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		revisions, err = tx.ContractRevisions(ctx, id, opts.Start, opts.End)
+		return err
+	})
+	return
+}
+
+func (s *SQLStore) ContractStateHistory(ctx context.Context, id types.FileContractID) (events []api.ContractStateEvent, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		events, err = tx.ContractStateHistory(ctx, id)
+		return err
+	})
+	return
+}
+
+func (s *SQLStore) ContractsPrunableData(ctx context.Context, opts api.ContractsPrunableDataOpts) (resp api.ContractsPrunableDataResponse, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		resp, err = tx.ContractsPrunableData(ctx, opts)
+		return err
+	})
+	return
+}
+
 func (s *SQLStore) ContractSizes(ctx context.Context) (sizes map[types.FileContractID]api.ContractSize, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		sizes, err = tx.ContractSizes(ctx)
 		return err
 	})
@@ -206,22 +330,51 @@ func (s *SQLStore) ContractSizes(ctx context.Context) (sizes map[types.FileContr
 }
 
 func (s *SQLStore) ContractSize(ctx context.Context, id types.FileContractID) (cs api.ContractSize, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		cs, err = tx.ContractSize(ctx, id)
 		return
 	})
 	return cs, err
 }
 
+func (s *SQLStore) FailPendingContracts(ctx context.Context, maxStartHeight, height uint64) (failed []types.FileContractID, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		failed, err = tx.FailPendingContracts(ctx, maxStartHeight, height)
+		return err
+	})
+	return
+}
+
 func (s *SQLStore) PutContract(ctx context.Context, c api.ContractMetadata) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.PutContract(ctx, c)
 	})
 }
 
+// RecordContractFormation adds a newly formed contract, including its initial
+// spending, to the store and records the formation event in the same
+// transaction. Failing to record the event is logged but doesn't prevent the
+// contract from being added.
+func (s *SQLStore) RecordContractFormation(ctx context.Context, c api.ContractMetadata) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		if err := tx.PutContract(ctx, c); err != nil {
+			return err
+		} else if err := tx.RecordContractEvent(ctx, c.ID, api.ContractEventTypeFormed, nil); err != nil {
+			s.logger.Errorw("failed to record contract formation event", "fcid", c.ID, zap.Error(err))
+		}
+		return nil
+	})
+}
+
+func (s *SQLStore) UpdateContractSpendingCap(ctx context.Context, fcid types.FileContractID, spendingCap types.Currency) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.UpdateContractSpendingCap(ctx, fcid, spendingCap)
+	})
+}
+
 func (s *SQLStore) UpdateContractUsability(ctx context.Context, fcid types.FileContractID, usability string) error {
 	// update usability
-	if err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	if err := s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.UpdateContractUsability(ctx, fcid, usability)
 	}); err != nil {
 		return fmt.Errorf("failed to update contract usability: %w", err)
@@ -235,8 +388,34 @@ func (s *SQLStore) UpdateContractUsability(ctx context.Context, fcid types.FileC
 	return nil
 }
 
+func (s *SQLStore) UpdateContractUsabilityOverride(ctx context.Context, fcid types.FileContractID, usability string) error {
+	if err := s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.UpdateContractUsabilityOverride(ctx, fcid, usability)
+	}); err != nil {
+		return fmt.Errorf("failed to override contract usability: %w", err)
+	}
+
+	// invalidate health
+	if err := s.invalidateSlabHealthByFCID(ctx, []types.FileContractID{fcid}); err != nil {
+		return fmt.Errorf("failed to invalidate slab health: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) DeleteContractUsabilityOverride(ctx context.Context, fcid types.FileContractID) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.DeleteContractUsabilityOverride(ctx, fcid)
+	})
+}
+
+func (s *SQLStore) UpdateContractVerificationStatus(ctx context.Context, fcid types.FileContractID, status string) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.UpdateContractVerificationStatus(ctx, fcid, status)
+	})
+}
+
 func (s *SQLStore) RenewedContract(ctx context.Context, renewedFrom types.FileContractID) (cm api.ContractMetadata, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		cm, err = tx.RenewedContract(ctx, renewedFrom)
 		return err
 	})
@@ -244,13 +423,21 @@ func (s *SQLStore) RenewedContract(ctx context.Context, renewedFrom types.FileCo
 }
 
 func (s *SQLStore) Object(ctx context.Context, bucket, key string) (obj api.Object, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		obj, err = tx.Object(ctx, bucket, key)
 		return err
 	})
 	return
 }
 
+// RecordContractEvent records a lifecycle event for the contract with the given
+// id. The details are marshaled to JSON.
+func (s *SQLStore) RecordContractEvent(ctx context.Context, id types.FileContractID, eventType string, details any) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.RecordContractEvent(ctx, id, eventType, details)
+	})
+}
+
 func (s *SQLStore) RecordContractSpending(ctx context.Context, records []api.ContractSpendingRecord) error {
 	if len(records) == 0 {
 		return nil // nothing to do
@@ -274,9 +461,12 @@ func (s *SQLStore) RecordContractSpending(ctx context.Context, records []api.Con
 			latestValues[r.ContractID] = v
 		}
 	}
+	var errs []error
 	metrics := make([]api.ContractMetric, 0, len(squashedRecords))
 	for fcid, newSpending := range squashedRecords {
-		err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		var exceeded error
+		err := s.transaction(ctx, func(tx sql.DatabaseTx) error {
+			exceeded = nil
 			contract, err := tx.Contract(ctx, fcid)
 			if errors.Is(err, api.ErrContractNotFound) {
 			} else if err != nil {
@@ -314,10 +504,24 @@ func (s *SQLStore) RecordContractSpending(ctx context.Context, records []api.Con
 			if !newSpending.SectorRoots.IsZero() {
 				updates.SectorRoots = m.SectorRootsSpending
 			}
-			return tx.RecordContractSpending(ctx, fcid, latestValues[fcid].revision, latestValues[fcid].size, updates)
+			if err := tx.RecordContractSpending(ctx, fcid, latestValues[fcid].revision, latestValues[fcid].size, updates); err != nil {
+				return err
+			}
+
+			// the spending is always recorded to keep the contract in sync
+			// with the host, contracts that exceed their cap are marked as bad
+			if contract.SpendingCapExceeded(newSpending) {
+				if err := tx.UpdateContractUsability(ctx, fcid, api.ContractUsabilityBad); err != nil {
+					return fmt.Errorf("failed to mark contract as bad: %w", err)
+				}
+				exceeded = fmt.Errorf("%w: contract %v with cap %v, spent %v", api.ErrContractSpendingCapExceeded, fcid, contract.SpendingCap, contract.Spending.Total().Add(newSpending.Total()))
+			}
+			return nil
 		})
 		if err != nil {
 			return err
+		} else if exceeded != nil {
+			errs = append(errs, exceeded)
 		}
 	}
 	if len(metrics) > 0 {
@@ -325,12 +529,12 @@ func (s *SQLStore) RecordContractSpending(ctx context.Context, records []api.Con
 			s.logger.Errorw("failed to record contract metrics", zap.Error(err))
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
-func (s *SQLStore) RenameObject(ctx context.Context, bucket, keyOld, keyNew string, force bool) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
-		err := tx.RenameObject(ctx, bucket, keyOld, keyNew, force)
+func (s *SQLStore) RenameObject(ctx context.Context, srcBucket, dstBucket, keyOld, keyNew string, force bool) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		err := tx.RenameObject(ctx, srcBucket, dstBucket, keyOld, keyNew, force)
 		if err != nil {
 			return err
 		}
@@ -340,7 +544,7 @@ func (s *SQLStore) RenameObject(ctx context.Context, bucket, keyOld, keyNew stri
 }
 
 func (s *SQLStore) RenameObjects(ctx context.Context, bucket, prefixOld, prefixNew string, force bool) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		if err := tx.RenameObjects(ctx, bucket, prefixOld, prefixNew, force); err != nil {
 			return err
 		}
@@ -357,13 +561,19 @@ func (s *SQLStore) AddPartialSlab(ctx context.Context, data []byte, minShards, t
 	return s.slabBufferMgr.AddPartialSlab(ctx, data, minShards, totalShards)
 }
 
-func (s *SQLStore) CopyObject(ctx context.Context, srcBucket, dstBucket, srcPath, dstPath, mimeType string, metadata api.ObjectUserMetadata) (om api.ObjectMetadata, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
-		if srcBucket != dstBucket || srcPath != dstPath {
-			_, err = tx.DeleteObject(ctx, dstBucket, dstPath)
+func (s *SQLStore) CopyObject(ctx context.Context, srcBucket, dstBucket, srcPath, dstPath, mimeType string, metadata api.ObjectUserMetadata, overwrite bool) (om api.ObjectMetadata, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		if srcBucket == dstBucket && srcPath == dstPath {
+			// copying an object onto itself only updates its metadata
+		} else if overwrite {
+			_, err = overwriteObject(ctx, tx, dstBucket, dstPath)
 			if err != nil {
 				return fmt.Errorf("CopyObject: failed to delete object: %w", err)
 			}
+		} else if _, err := tx.ObjectMetadata(ctx, dstBucket, dstPath); err == nil {
+			return api.ErrObjectExists
+		} else if !errors.Is(err, api.ErrObjectNotFound) {
+			return fmt.Errorf("CopyObject: failed to check destination: %w", err)
 		}
 		om, err = tx.CopyObject(ctx, srcBucket, dstBucket, srcPath, dstPath, mimeType, metadata)
 		return err
@@ -371,8 +581,24 @@ func (s *SQLStore) CopyObject(ctx context.Context, srcBucket, dstBucket, srcPath
 	return
 }
 
+// overwriteObject deletes the object that is about to be overwritten and
+// returns true if an object was deleted. If the bucket has copy-on-write
+// enabled, the slices of the object are moved to an object version first so
+// its slabs aren't pruned until the version is garbage collected.
+func overwriteObject(ctx context.Context, tx sql.DatabaseTx, bucket, key string) (bool, error) {
+	b, err := tx.Bucket(ctx, bucket)
+	if err != nil {
+		return false, err
+	} else if b.Policy.CopyOnWrite {
+		if _, err := tx.InsertObjectVersion(ctx, bucket, key); err != nil {
+			return false, fmt.Errorf("failed to preserve object version: %w", err)
+		}
+	}
+	return tx.DeleteObject(ctx, bucket, key)
+}
+
 func (s *SQLStore) DeleteHostSector(ctx context.Context, hk types.PublicKey, root types.Hash256) (deletedSectors int, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		deletedSectors, err = tx.DeleteHostSector(ctx, hk, root)
 		return err
 	})
@@ -381,18 +607,27 @@ func (s *SQLStore) DeleteHostSector(ctx context.Context, hk types.PublicKey, roo
 
 func (s *SQLStore) UpdateObject(ctx context.Context, bucket, key, eTag, mimeType string, metadata api.ObjectUserMetadata, o object.Object) error {
 	// Sanity check input.
-	for _, s := range o.Slabs {
-		for i, shard := range s.Shards {
-			// Verify that all hosts have a contract.
-			if len(shard.Contracts) == 0 {
-				return fmt.Errorf("missing hosts for slab %d", i)
+	for i, s := range o.Slabs {
+		if s.IsPartial() {
+			continue
+		}
+
+		// Verify that enough shards of the slab were stored to recover it,
+		// shards without contracts are repaired by migrations.
+		var stored int
+		for _, shard := range s.Shards {
+			if len(shard.Contracts) > 0 {
+				stored++
 			}
 		}
+		if stored < int(s.MinShards) {
+			return fmt.Errorf("missing hosts for slab %d, %d/%d shards stored", i, stored, s.MinShards)
+		}
 	}
 
 	// UpdateObject is ACID.
 	var prune bool
-	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err := s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		// Try to delete. We want to get rid of the object and its slices if it
 		// exists.
 		//
@@ -404,7 +639,7 @@ func (s *SQLStore) UpdateObject(ctx context.Context, bucket, key, eTag, mimeType
 		// if we stop recreating the object we have to make sure to delete the
 		// object's metadata before trying to recreate it
 		var err error
-		prune, err = tx.DeleteObject(ctx, bucket, key)
+		prune, err = overwriteObject(ctx, tx, bucket, key)
 		if err != nil {
 			return fmt.Errorf("UpdateObject: failed to delete object: %w", err)
 		}
@@ -425,9 +660,82 @@ func (s *SQLStore) UpdateObject(ctx context.Context, bucket, key, eTag, mimeType
 	return nil
 }
 
+// GarbageCollect deletes the object versions that were preserved when
+// overwriting objects in copy-on-write buckets and triggers slab pruning to
+// reclaim the space they referenced. Versions that are younger than the version
+// retention of their bucket are kept. It returns the number of deleted
+// versions.
+func (s *SQLStore) GarbageCollect(ctx context.Context) (deleted int64, err error) {
+	deleted, err = s.deleteExpiredObjectVersions(ctx)
+	if err != nil {
+		return 0, err
+	} else if deleted > 0 {
+		s.triggerSlabPruning()
+	}
+	return deleted, nil
+}
+
+// deleteExpiredObjectVersions deletes the object versions that exceeded the
+// version retention of their bucket and returns the number of deleted versions,
+// it's up to the caller to prune the slabs they referenced.
+func (s *SQLStore) deleteExpiredObjectVersions(ctx context.Context) (deleted int64, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		deleted = 0
+		buckets, err := tx.Buckets(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch buckets: %w", err)
+		}
+		now := time.Now()
+		for _, b := range buckets {
+			n, err := tx.DeleteObjectVersions(ctx, b.Name, now.Add(-time.Duration(b.Policy.VersionRetention)))
+			if err != nil {
+				return fmt.Errorf("failed to delete object versions of bucket %v: %w", b.Name, err)
+			}
+			deleted += n
+		}
+		return nil
+	})
+	return
+}
+
+// ObjectVersions returns the versions of the given object that were preserved
+// when it was overwritten in a copy-on-write bucket, the most recent version
+// comes first.
+func (s *SQLStore) ObjectVersions(ctx context.Context, bucket, key string) (versions []api.ObjectVersion, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		if _, err := tx.Bucket(ctx, bucket); err != nil {
+			return err
+		}
+		versions, err = tx.ObjectVersions(ctx, bucket, key)
+		return err
+	})
+	return
+}
+
+// RestoreObjectVersion restores the given version of an object, the object
+// that is currently stored under the key is overwritten, which preserves it as
+// a version itself if the bucket has copy-on-write enabled.
+func (s *SQLStore) RestoreObjectVersion(ctx context.Context, bucket, key string, id int64) error {
+	var prune bool
+	err := s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+		// NOTE: the overwrite is rolled back if the version doesn't exist
+		prune, err = overwriteObject(ctx, tx, bucket, key)
+		if err != nil {
+			return fmt.Errorf("failed to overwrite object: %w", err)
+		}
+		return tx.RestoreObjectVersion(ctx, bucket, key, id)
+	})
+	if err != nil {
+		return err
+	} else if prune {
+		s.triggerSlabPruning()
+	}
+	return nil
+}
+
 func (s *SQLStore) RemoveObject(ctx context.Context, bucket, key string) error {
 	var prune bool
-	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	err := s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		prune, err = tx.DeleteObject(ctx, bucket, key)
 		return
 	})
@@ -447,7 +755,7 @@ func (s *SQLStore) RemoveObjects(ctx context.Context, bucket, prefix string) err
 		start := time.Now()
 		var done bool
 		var duration time.Duration
-		if err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		if err := s.transaction(ctx, func(tx sql.DatabaseTx) error {
 			deleted, err := tx.DeleteObjects(ctx, bucket, prefix, objectDeleteBatchSizes[batchSizeIdx])
 			if err != nil {
 				return err
@@ -475,7 +783,7 @@ func (s *SQLStore) RemoveObjects(ctx context.Context, bucket, prefix string) err
 }
 
 func (s *SQLStore) Slab(ctx context.Context, key object.EncryptionKey) (slab object.Slab, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		slab, err = tx.Slab(ctx, key)
 		return err
 	})
@@ -483,7 +791,7 @@ func (s *SQLStore) Slab(ctx context.Context, key object.EncryptionKey) (slab obj
 }
 
 func (s *SQLStore) UpdateSlab(ctx context.Context, key object.EncryptionKey, sectors []api.UploadedSector) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.UpdateSlab(ctx, key, sectors)
 	})
 }
@@ -492,7 +800,7 @@ func (s *SQLStore) RefreshHealth(ctx context.Context) error {
 	for {
 		// update slabs
 		var rowsAffected int64
-		err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+		err := s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 			rowsAffected, err = tx.UpdateSlabHealth(ctx, refreshHealthBatchSize, refreshHealthMinHealthValidity, refreshHealthMaxHealthValidity)
 			return
 		})
@@ -518,7 +826,7 @@ func (s *SQLStore) SlabsForMigration(ctx context.Context, healthCutoff float64,
 	if limit <= -1 {
 		limit = math.MaxInt
 	}
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		slabs, err = tx.SlabsForMigration(ctx, healthCutoff, limit)
 		return err
 	})
@@ -527,13 +835,27 @@ func (s *SQLStore) SlabsForMigration(ctx context.Context, healthCutoff float64,
 
 // ObjectMetadata returns an object's metadata
 func (s *SQLStore) ObjectMetadata(ctx context.Context, bucket, key string) (obj api.Object, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		obj, err = tx.ObjectMetadata(ctx, bucket, key)
 		return err
 	})
 	return
 }
 
+// UpdateObjectMimeType updates an object's mime type
+func (s *SQLStore) UpdateObjectMimeType(ctx context.Context, bucket, key, mimeType string) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.UpdateObjectMimeType(ctx, bucket, key, mimeType)
+	})
+}
+
+// UpdateObjectUserMetadata replaces an object's user metadata
+func (s *SQLStore) UpdateObjectUserMetadata(ctx context.Context, bucket, key string, metadata api.ObjectUserMetadata) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.UpdateObjectUserMetadata(ctx, bucket, key, metadata)
+	})
+}
+
 // PackedSlabsForUpload returns up to 'limit' packed slabs that are ready for
 // uploading. They are locked for 'lockingDuration' time before being handed out
 // again.
@@ -542,13 +864,84 @@ func (s *SQLStore) PackedSlabsForUpload(ctx context.Context, lockingDuration tim
 }
 
 func (s *SQLStore) PrunableContractRoots(ctx context.Context, fcid types.FileContractID, roots []types.Hash256) (indices []uint64, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		indices, err = tx.PrunableContractRoots(ctx, fcid, roots)
 		return err
 	})
 	return
 }
 
+// PrunableContractRootsStream reads the contract's roots from the given channel
+// and streams back the indices of the roots that are not referenced by any
+// object. The roots are diffed in batches, each in its own transaction, to
+// avoid having to hold all of the contract's roots in memory. The returned
+// indices channel is closed after the roots channel was closed and all roots
+// were processed, or when processing a batch fails, in which case the
+// remaining roots are not consumed. Once the indices channel is closed, the
+// error channel receives the error that stopped processing, or nil if all
+// roots were processed.
+func (s *SQLStore) PrunableContractRootsStream(ctx context.Context, fcid types.FileContractID, roots <-chan types.Hash256) (<-chan uint64, <-chan error, error) {
+	// make sure the contract exists
+	if _, err := s.Contract(ctx, fcid); err != nil {
+		return nil, nil, err
+	}
+
+	indices := make(chan uint64, prunableRootsBatchSize)
+	errs := make(chan error, 1)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		var offset uint64
+		batch := make([]types.Hash256, 0, prunableRootsBatchSize)
+		processBatch := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			batchIndices, err := s.PrunableContractRoots(ctx, fcid, batch)
+			if err != nil {
+				return err
+			}
+			for _, idx := range batchIndices {
+				select {
+				case indices <- offset + idx:
+				case <-ctx.Done():
+					return context.Cause(ctx)
+				case <-s.shutdownCtx.Done():
+					return errors.New("store was shut down")
+				}
+			}
+			offset += uint64(len(batch))
+			batch = batch[:0]
+			return nil
+		}
+
+		err := func() error {
+			for {
+				select {
+				case <-ctx.Done():
+					return context.Cause(ctx)
+				case <-s.shutdownCtx.Done():
+					return errors.New("store was shut down")
+				case root, ok := <-roots:
+					if !ok {
+						return processBatch()
+					}
+					batch = append(batch, root)
+					if len(batch) == prunableRootsBatchSize {
+						if err := processBatch(); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}()
+		errs <- err
+		close(indices)
+	}()
+	return indices, errs, nil
+}
+
 // MarkPackedSlabsUploaded marks the given slabs as uploaded and deletes them
 // from the buffer.
 func (s *SQLStore) MarkPackedSlabsUploaded(ctx context.Context, slabs []api.UploadedPackedSlab) error {
@@ -563,7 +956,7 @@ func (s *SQLStore) MarkPackedSlabsUploaded(ctx context.Context, slabs []api.Uplo
 		}
 	}
 	var fileNames []string
-	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err := s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		fileNames = make([]string, len(slabs))
 		for i, slab := range slabs {
 			fileName, err := tx.MarkPackedSlabUploaded(ctx, slab)
@@ -584,29 +977,53 @@ func (s *SQLStore) MarkPackedSlabsUploaded(ctx context.Context, slabs []api.Uplo
 }
 
 func (s *SQLStore) pruneHostSectorLoop() {
+	var cursor sql.HostSectorCursor
+	var continueChan <-chan time.Time
+	var restart bool
 	for {
 		select {
 		case <-s.hostSectorPruneSigChan:
+			// host sectors that became prunable behind the cursor of an
+			// ongoing cycle are only pruned by the next cycle
+			restart = restart || cursor != (sql.HostSectorCursor{})
+		case <-continueChan:
 		case <-s.shutdownCtx.Done():
 			return
 		}
+		continueChan = nil
+
+		// a zero cursor indicates the start of a new cycle
+		if cursor == (sql.HostSectorCursor{}) {
+			s.mu.Lock()
+			s.hostSectorPruneStats = api.HostSectorPruneStats{}
+			s.mu.Unlock()
+		}
 
 		// prune host sectors
-		pruneSuccess := true
+		var pruned int64
+		pruneSuccess, yielded := true, false
 		for {
+			batchSize := int64(hostSectorPruningBatchSize)
+			if s.maxHostSectorPrunePerRun > 0 {
+				batchSize = min(batchSize, s.maxHostSectorPrunePerRun-pruned)
+			}
+
 			var deleted int64
-			err := s.db.Transaction(s.shutdownCtx, func(dt sql.DatabaseTx) error {
+			var next sql.HostSectorCursor
+			err := s.transaction(s.shutdownCtx, func(dt sql.DatabaseTx) error {
 				var err error
-				deleted, err = dt.PruneHostSectors(s.shutdownCtx, hostSectorPruningBatchSize)
+				deleted, next, err = dt.PruneHostSectors(s.shutdownCtx, cursor, batchSize)
 				return err
 			})
 			if err != nil {
 				s.logger.Errorw("hest sector pruning failed", zap.Error(err))
 				s.alerts.RegisterAlert(s.shutdownCtx, alerts.Alert{
-					ID:        pruneHostSectorsAlertID,
-					Severity:  alerts.SeverityWarning,
-					Message:   "Failed to prune host sectors",
-					Timestamp: time.Now(),
+					ID:          pruneHostSectorsAlertID,
+					Severity:    alerts.SeverityWarning,
+					Message:     "Failed to prune host sectors",
+					Description: fmt.Sprintf("Pruning host sectors that are no longer referenced failed: %v", err),
+					Suggestion:  "This might happen when your database is under a lot of load due to deleting objects rapidly. This alert will disappear the next time host sectors are pruned successfully.",
+					Timestamp:   time.Now(),
 					Data: map[string]interface{}{
 						"error": err.Error(),
 						"hint":  "This might happen when your database is under a lot of load due to deleting objects rapidly. This alert will disappear the next time host sectors are pruned successfully.",
@@ -615,27 +1032,76 @@ func (s *SQLStore) pruneHostSectorLoop() {
 				pruneSuccess = false
 			} else {
 				s.alerts.DismissAlerts(s.shutdownCtx, pruneHostSectorsAlertID)
+				cursor = next
 			}
+			pruned += deleted
 
-			if deleted < hostSectorPruningBatchSize {
+			s.mu.Lock()
+			s.hostSectorPruneStats.PrunedThisCycle += uint64(deleted)
+			s.mu.Unlock()
+
+			if deleted < batchSize {
 				break // done
+			} else if s.maxHostSectorPrunePerRun > 0 && pruned >= s.maxHostSectorPrunePerRun {
+				yielded = true
+				break // yield
 			}
 		}
 
+		// when yielding, keep track of what's left and continue later
+		var remaining int64
+		if yielded {
+			err := s.transaction(s.shutdownCtx, func(dt sql.DatabaseTx) (err error) {
+				remaining, err = dt.PrunableHostSectors(s.shutdownCtx)
+				return
+			})
+			if err != nil {
+				s.logger.Errorw("failed to count prunable host sectors", zap.Error(err))
+			}
+			continueChan = time.After(hostSectorPruningYieldInterval)
+			s.logger.Debugw("host sector pruning yielded", "pruned", pruned, "remaining", remaining)
+		}
+		s.mu.Lock()
+		s.hostSectorPruneStats.Remaining = uint64(remaining)
+		s.mu.Unlock()
+
 		// mark the last prune time where host sectors were pruned
-		if pruneSuccess {
+		if pruneSuccess && !yielded {
 			s.mu.Lock()
 			s.lastPrunedHostSectorsAt = time.Now()
 			s.mu.Unlock()
 			s.logger.Debug("host sectors pruned successfully")
 		}
+
+		// start a new cycle if we were signaled during the last one
+		if restart && !yielded && cursor == (sql.HostSectorCursor{}) {
+			restart = false
+			s.triggerHostSectorPruning()
+		}
 	}
 }
 
 func (s *SQLStore) pruneSlabsLoop() {
+	// object versions that exceeded their retention are deleted periodically,
+	// a nil channel disables the garbage collection
+	var gcChan <-chan time.Time
+	if s.objectVersionGCInterval > 0 {
+		t := time.NewTicker(s.objectVersionGCInterval)
+		defer t.Stop()
+		gcChan = t.C
+	}
+
 	for {
 		select {
 		case <-s.slabPruneSigChan:
+		case <-gcChan:
+			if deleted, err := s.deleteExpiredObjectVersions(s.shutdownCtx); err != nil {
+				s.logger.Errorw("object version garbage collection failed", zap.Error(err))
+			} else if deleted > 0 {
+				s.logger.Debugw("garbage collected object versions", "deleted", deleted)
+			} else {
+				continue // nothing to prune
+			}
 		case <-s.shutdownCtx.Done():
 			return
 		}
@@ -644,7 +1110,7 @@ func (s *SQLStore) pruneSlabsLoop() {
 		pruneSuccess := true
 		for {
 			var deleted int64
-			err := s.db.Transaction(s.shutdownCtx, func(dt sql.DatabaseTx) error {
+			err := s.transaction(s.shutdownCtx, func(dt sql.DatabaseTx) error {
 				var err error
 				deleted, err = dt.PruneSlabs(s.shutdownCtx, slabPruningBatchSize)
 				return err
@@ -652,10 +1118,12 @@ func (s *SQLStore) pruneSlabsLoop() {
 			if err != nil {
 				s.logger.Errorw("slab pruning failed", zap.Error(err))
 				s.alerts.RegisterAlert(s.shutdownCtx, alerts.Alert{
-					ID:        pruneSlabsAlertID,
-					Severity:  alerts.SeverityWarning,
-					Message:   "Failed to prune slabs",
-					Timestamp: time.Now(),
+					ID:          pruneSlabsAlertID,
+					Severity:    alerts.SeverityWarning,
+					Message:     "Failed to prune slabs",
+					Description: fmt.Sprintf("Pruning slabs that are no longer referenced by any object failed: %v", err),
+					Suggestion:  "This might happen when your database is under a lot of load due to deleting objects rapidly. This alert will disappear the next time slabs are pruned successfully.",
+					Timestamp:   time.Now(),
 					Data: map[string]interface{}{
 						"error": err.Error(),
 						"hint":  "This might happen when your database is under a lot of load due to deleting objects rapidly. This alert will disappear the next time slabs are pruned successfully.",
@@ -680,6 +1148,14 @@ func (s *SQLStore) pruneSlabsLoop() {
 	}
 }
 
+// HostSectorPruneStats returns the progress of the current host sector pruning
+// cycle.
+func (s *SQLStore) HostSectorPruneStats() api.HostSectorPruneStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hostSectorPruneStats
+}
+
 func (s *SQLStore) triggerHostSectorPruning() {
 	select {
 	case s.hostSectorPruneSigChan <- struct{}{}:
@@ -697,7 +1173,7 @@ func (s *SQLStore) triggerSlabPruning() {
 func (s *SQLStore) invalidateSlabHealthByFCID(ctx context.Context, fcids []types.FileContractID) error {
 	for {
 		var affected int64
-		err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+		err := s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 			affected, err = tx.InvalidateSlabHealthByFCID(ctx, fcids, refreshHealthBatchSize)
 			return
 		})
@@ -710,9 +1186,9 @@ func (s *SQLStore) invalidateSlabHealthByFCID(ctx context.Context, fcids []types
 	}
 }
 
-func (s *SQLStore) Objects(ctx context.Context, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey) (resp api.ObjectsResponse, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
-		resp, err = tx.Objects(ctx, bucket, prefix, substring, delim, sortBy, sortDir, marker, limit, slabEncryptionKey)
+func (s *SQLStore) Objects(ctx context.Context, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey, metadata api.ObjectUserMetadata) (resp api.ObjectsResponse, err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
+		resp, err = tx.Objects(ctx, bucket, prefix, substring, delim, sortBy, sortDir, marker, limit, slabEncryptionKey, metadata)
 		return err
 	})
 	return
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"reflect"
 	"strings"
@@ -19,6 +20,7 @@ import (
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/config"
+	rhp4 "go.sia.tech/renterd/v2/internal/rhp/v4"
 	isql "go.sia.tech/renterd/v2/internal/sql"
 	"go.sia.tech/renterd/v2/internal/test"
 	"go.sia.tech/renterd/v2/object"
@@ -74,7 +76,7 @@ func (s *SQLStore) RemoveObjectsBlocking(ctx context.Context, bucket, prefix str
 func (s *SQLStore) RenameObjectBlocking(ctx context.Context, bucket, keyOld, keyNew string, force bool) error {
 	ts := time.Now()
 	time.Sleep(time.Millisecond)
-	if err := s.RenameObject(ctx, bucket, keyOld, keyNew, force); err != nil {
+	if err := s.RenameObject(ctx, bucket, bucket, keyOld, keyNew, force); err != nil {
 		return err
 	}
 	return s.waitForSlabPruneLoop(ts)
@@ -212,6 +214,97 @@ func TestPrunableContractRoots(t *testing.T) {
 	}
 }
 
+func TestPrunableContractRootsStream(t *testing.T) {
+	// create a SQL store
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a contract
+	hks, err := ss.addTestHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add an object
+	if _, err := ss.addTestObject(t.Name(), object.Object{
+		Key: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+		Slabs: []object.SlabSlice{
+			{
+				Slab: object.Slab{
+					EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+					MinShards:     1,
+					Shards:        newTestShards(hks[0], fcids[0], types.Hash256{1}),
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert streaming roots for an unknown contract fails
+	_, _, err = ss.PrunableContractRootsStream(context.Background(), types.FileContractID{9}, make(chan types.Hash256))
+	if !errors.Is(err, api.ErrContractNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// stream enough roots to span multiple batches, only the root at index 1
+	// is referenced by the object
+	n := prunableRootsBatchSize + 2
+	roots := make(chan types.Hash256)
+	indices, errs, err := ss.PrunableContractRootsStream(context.Background(), fcids[0], roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		defer close(roots)
+		for i := 0; i < n; i++ {
+			if i == 1 {
+				roots <- types.Hash256{1}
+			} else {
+				roots <- frand.Entropy256()
+			}
+		}
+	}()
+
+	var got []uint64
+	for idx := range indices {
+		got = append(got, idx)
+	}
+	if len(got) != n-1 {
+		t.Fatalf("expected %v indices, got %v", n-1, len(got))
+	}
+	seen := make(map[uint64]struct{})
+	for _, idx := range got {
+		if idx == 1 || idx >= uint64(n) {
+			t.Fatal("unexpected index", idx)
+		}
+		seen[idx] = struct{}{}
+	}
+	if len(seen) != n-1 {
+		t.Fatal("expected unique indices")
+	} else if err := <-errs; err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	// assert the error is returned if the stream is interrupted
+	ctx, cancel := context.WithCancel(context.Background())
+	indices, errs, err = ss.PrunableContractRootsStream(ctx, fcids[0], make(chan types.Hash256))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	for range indices {
+		t.Fatal("unexpected index")
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
 // TestObjectBasic tests the hydration of raw objects works when we fetch
 // objects from the metadata store.
 func TestObjectBasic(t *testing.T) {
@@ -250,7 +343,7 @@ func TestObjectBasic(t *testing.T) {
 				Slab: object.Slab{
 					Health:        1.0,
 					EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
-					MinShards:     2,
+					MinShards:     1,
 					Shards:        newTestShards(hk2, fcid2, types.Hash256{2}),
 				},
 				Offset: 20,
@@ -284,6 +377,54 @@ func TestObjectBasic(t *testing.T) {
 	}
 }
 
+// TestUpdateObjectMinShards asserts objects are only stored if every slab has
+// at least MinShards shards stored on a host.
+func TestUpdateObjectMinShards(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create 2 hosts with a contract each
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create an object with a slab that has one of its two shards stored
+	obj := object.Object{
+		Key: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+		Slabs: []object.SlabSlice{
+			{
+				Slab: object.Slab{
+					Health:        1.0,
+					EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+					MinShards:     2,
+					Shards: []object.Sector{
+						newTestShard(hks[0], fcids[0], types.Hash256{1}),
+						{Root: types.Hash256{2}},
+						{Root: types.Hash256{3}},
+					},
+				},
+				Length: 100,
+			},
+		},
+	}
+
+	// assert the object is rejected
+	if _, err := ss.addTestObject(t.Name(), obj); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// store another shard and assert the object is accepted
+	obj.Slabs[0].Shards[1] = newTestShard(hks[1], fcids[1], types.Hash256{2})
+	if _, err := ss.addTestObject(t.Name(), obj); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestObjectMetadata(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -320,7 +461,7 @@ func TestObjectMetadata(t *testing.T) {
 				Slab: object.Slab{
 					Health:        1.0,
 					EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
-					MinShards:     2,
+					MinShards:     1,
 					Shards:        newTestShards(hk2, fcid2, types.Hash256{2}),
 				},
 				Offset: 20,
@@ -390,25 +531,28 @@ func TestSQLContractStore(t *testing.T) {
 		ID:      fcid,
 		HostKey: hk,
 
-		ProofHeight:    1,
-		RenewedFrom:    types.FileContractID{1},
-		RevisionHeight: 2,
-		RevisionNumber: 3,
-		Size:           4,
-		StartHeight:    5,
-		State:          api.ContractStateActive,
-		Usability:      api.ContractUsabilityGood,
-		WindowStart:    6,
-		WindowEnd:      7,
+		ProofHeight:        1,
+		RenewedFrom:        types.FileContractID{1},
+		RevisionHeight:     2,
+		RevisionNumber:     3,
+		Size:               4,
+		StartHeight:        5,
+		State:              api.ContractStateActive,
+		Usability:          api.ContractUsabilityGood,
+		VerificationStatus: api.ContractVerificationStatusUnverified,
+		WindowStart:        6,
+		WindowEnd:          7,
 
 		ContractPrice:      types.NewCurrency64(1),
 		InitialRenterFunds: types.NewCurrency64(2),
+		NetworkFees:        types.NewCurrency64(7),
 
 		Spending: api.ContractSpending{
 			Deletions:   types.NewCurrency64(3),
 			FundAccount: types.NewCurrency64(4),
 			SectorRoots: types.NewCurrency64(5),
 			Uploads:     types.NewCurrency64(6),
+			NetworkFees: types.NewCurrency64(7),
 		},
 	}
 	if err := ss.PutContract(context.Background(), c); err != nil {
@@ -688,12 +832,213 @@ func TestArchiveContracts(t *testing.T) {
 	}
 }
 
+func TestUnarchiveContracts(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add 2 hosts with a contract each
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// unarchiving an active contract fails
+	if err := ss.UnarchiveContracts(context.Background(), fcids[:1]); !errors.Is(err, api.ErrContractNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// archive both contracts
+	if err := ss.ArchiveContracts(context.Background(), map[types.FileContractID]string{
+		fcids[0]: api.ContractArchivalReasonRemoved,
+		fcids[1]: api.ContractArchivalReasonRemoved,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// unarchive the first one
+	if err := ss.UnarchiveContracts(context.Background(), fcids[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert it's active again
+	active, err := ss.Contracts(context.Background(), api.ContractsOpts{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(active) != 1 || active[0].ID != fcids[0] || active[0].ArchivalReason != "" || active[0].HostKey != hks[0] {
+		t.Fatal("unexpected contracts", active)
+	}
+
+	// assert its state was reset to unknown
+	if active[0].State != api.ContractStateUnknown {
+		t.Fatalf("expected state %v, got %v", api.ContractStateUnknown, active[0].State)
+	} else if history, err := ss.ContractStateHistory(context.Background(), fcids[0]); err != nil {
+		t.Fatal(err)
+	} else if len(history) == 0 || history[len(history)-1].ToState != api.ContractStateUnknown {
+		t.Fatal("expected state change to unknown", history)
+	}
+
+	// assert the event was recorded
+	events, err := ss.ContractEvents(context.Background(), fcids[0])
+	if err != nil {
+		t.Fatal(err)
+	} else if len(events) == 0 || events[len(events)-1].Type != api.ContractEventTypeUnarchived {
+		t.Fatal("expected unarchived event", events)
+	}
+
+	// unarchiving a contract whose host was removed fails
+	if _, err := ss.DB().Exec(context.Background(), "DELETE FROM hosts WHERE public_key = ?", sql.PublicKey(hks[1])); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UnarchiveContracts(context.Background(), fcids[1:]); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+// TestContractsFilters tests filtering contracts by their remaining funds,
+// spending ratio, expiration height and host version.
+func TestContractsFilters(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// set the current height
+	if _, err := ss.ChainIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.DB().Exec(context.Background(), "UPDATE consensus_infos SET height = ?", 100); err != nil {
+		t.Fatal(err)
+	}
+
+	// add three contracts with varying spending and expiration heights
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range []struct {
+		spent       uint64
+		windowStart uint64
+	}{
+		{spent: 10, windowStart: 110},  // 90% remaining
+		{spent: 50, windowStart: 150},  // 50% remaining
+		{spent: 100, windowStart: 200}, // exhausted
+	} {
+		contract := newTestContract(types.FileContractID{byte(i + 1)}, hks[i])
+		contract.InitialRenterFunds = types.NewCurrency64(100)
+		contract.Spending.Uploads = types.NewCurrency64(c.spent)
+		contract.WindowStart = c.windowStart
+		contract.WindowEnd = c.windowStart + 10
+		if err := ss.PutContract(context.Background(), contract); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// scan the hosts to populate the host versions, the third host doesn't
+	// report a version and a failed scan doesn't reset the version
+	for i, release := range []string{"hostd v1.5.2", "hostd v2.0.0", ""} {
+		if err := ss.RecordHostScans(context.Background(), []api.HostScan{
+			newTestScan(hks[i], time.Now(), rhp4.HostSettings{HostSettings: rhpv4.HostSettings{Release: release}}, true),
+			newTestScan(hks[i], time.Now(), rhp4.HostSettings{}, false),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c, err := ss.Contract(context.Background(), types.FileContractID{1}); err != nil {
+		t.Fatal(err)
+	} else if c.HostVersion != "hostd v1.5.2" {
+		t.Fatalf("unexpected host version %q", c.HostVersion)
+	}
+
+	tests := []struct {
+		name     string
+		opts     api.ContractsOpts
+		expected []types.FileContractID
+	}{
+		{
+			name:     "none",
+			opts:     api.ContractsOpts{},
+			expected: []types.FileContractID{{1}, {2}, {3}},
+		},
+		{
+			name:     "min remaining funds",
+			opts:     api.ContractsOpts{MinRemainingFunds: types.NewCurrency64(50)},
+			expected: []types.FileContractID{{1}, {2}},
+		},
+		{
+			name:     "min remaining funds exceeded",
+			opts:     api.ContractsOpts{MinRemainingFunds: types.NewCurrency64(91)},
+			expected: nil,
+		},
+		{
+			name:     "max spending ratio",
+			opts:     api.ContractsOpts{MaxSpendingRatio: 0.9},
+			expected: []types.FileContractID{{3}},
+		},
+		{
+			name:     "max spending ratio inclusive",
+			opts:     api.ContractsOpts{MaxSpendingRatio: 0.5},
+			expected: []types.FileContractID{{2}, {3}},
+		},
+		{
+			name:     "expiring within blocks",
+			opts:     api.ContractsOpts{ExpiringWithinBlocks: 50},
+			expected: []types.FileContractID{{1}, {2}},
+		},
+		{
+			name:     "expiring within blocks none",
+			opts:     api.ContractsOpts{ExpiringWithinBlocks: 9},
+			expected: nil,
+		},
+		{
+			name:     "combined",
+			opts:     api.ContractsOpts{MinRemainingFunds: types.NewCurrency64(1), ExpiringWithinBlocks: 100},
+			expected: []types.FileContractID{{1}, {2}},
+		},
+		{
+			name:     "host version below",
+			opts:     api.ContractsOpts{HostVersionBelow: "1.6.0"},
+			expected: []types.FileContractID{{1}},
+		},
+		{
+			name:     "host version below exclusive",
+			opts:     api.ContractsOpts{HostVersionBelow: "2.0"},
+			expected: []types.FileContractID{{1}},
+		},
+		{
+			name:     "host version below newer",
+			opts:     api.ContractsOpts{HostVersionBelow: "v2.1.0"},
+			expected: []types.FileContractID{{1}, {2}},
+		},
+		{
+			name:     "host key",
+			opts:     api.ContractsOpts{HostKey: hks[1]},
+			expected: []types.FileContractID{{2}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			contracts, err := ss.Contracts(context.Background(), test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []types.FileContractID
+			for _, c := range contracts {
+				ids = append(ids, c.ID)
+			}
+			if !reflect.DeepEqual(ids, test.expected) {
+				t.Fatalf("unexpected contracts, %v != %v", ids, test.expected)
+			}
+		})
+	}
+}
+
 func newTestContract(fcid types.FileContractID, hk types.PublicKey) api.ContractMetadata {
 	return api.ContractMetadata{
 		ID:                 fcid,
 		HostKey:            hk,
 		State:              api.ContractStatePending,
 		Usability:          api.ContractUsabilityGood,
+		VerificationStatus: api.ContractVerificationStatusUnverified,
 		ContractPrice:      types.NewCurrency64(1),
 		InitialRenterFunds: types.NewCurrency64(2),
 	}
@@ -736,7 +1081,7 @@ func TestSQLMetadataStore(t *testing.T) {
 				Slab: object.Slab{
 					Health:        1,
 					EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
-					MinShards:     2,
+					MinShards:     1,
 					Shards:        newTestShards(hk2, fcid2, types.Hash256{2}),
 				},
 				Offset: 20,
@@ -804,7 +1149,7 @@ func TestSQLMetadataStore(t *testing.T) {
 					Slab: object.Slab{
 						Health:        1,
 						EncryptionKey: obj1Slab1Key,
-						MinShards:     2,
+						MinShards:     1,
 						Shards: []object.Sector{
 							{
 								Root: types.Hash256{2},
@@ -872,6 +1217,7 @@ func TestSQLMetadataStore(t *testing.T) {
 		HostKey:            hk1,
 		State:              api.ContractStatePending,
 		Usability:          api.ContractUsabilityGood,
+		VerificationStatus: api.ContractVerificationStatusUnverified,
 		ContractPrice:      types.NewCurrency64(1),
 		InitialRenterFunds: types.NewCurrency64(2),
 	}
@@ -879,7 +1225,7 @@ func TestSQLMetadataStore(t *testing.T) {
 	expectedObjSlab2 := object.Slab{
 		Health:        1,
 		EncryptionKey: obj1Slab1Key,
-		MinShards:     2,
+		MinShards:     1,
 		Shards: []object.Sector{
 			{
 				Contracts: map[types.PublicKey][]types.FileContractID{
@@ -895,6 +1241,7 @@ func TestSQLMetadataStore(t *testing.T) {
 		HostKey:            hk2,
 		State:              api.ContractStatePending,
 		Usability:          api.ContractUsabilityGood,
+		VerificationStatus: api.ContractVerificationStatusUnverified,
 		ContractPrice:      types.NewCurrency64(1),
 		InitialRenterFunds: types.NewCurrency64(2),
 	}
@@ -1062,7 +1409,7 @@ func TestObjectHealth(t *testing.T) {
 	}
 
 	// assert health is returned correctly by ObjectEntries
-	resp, err := ss.Objects(context.Background(), testBucket, "/", "", "", "", "", "", -1, object.EncryptionKey{})
+	resp, err := ss.Objects(context.Background(), testBucket, "/", "", "", "", "", "", -1, object.EncryptionKey{}, nil)
 	entries := resp.Objects
 	if err != nil {
 		t.Fatal(err)
@@ -1073,7 +1420,7 @@ func TestObjectHealth(t *testing.T) {
 	}
 
 	// assert health is returned correctly by SearchObject
-	resp, err = ss.Objects(context.Background(), testBucket, "/", "foo", "", "", "", "", -1, object.EncryptionKey{})
+	resp, err = ss.Objects(context.Background(), testBucket, "/", "foo", "", "", "", "", -1, object.EncryptionKey{}, nil)
 	if err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 1 {
@@ -1221,7 +1568,7 @@ func TestObjectsWithDelimiterSlash(t *testing.T) {
 		}
 	}
 	for _, test := range tests {
-		resp, err := ss.Objects(ctx, testBucket, test.path+test.prefix, "", "/", test.sortBy, test.sortDir, "", -1, object.EncryptionKey{})
+		resp, err := ss.Objects(ctx, testBucket, test.path+test.prefix, "", "/", test.sortBy, test.sortDir, "", -1, object.EncryptionKey{}, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1234,7 +1581,7 @@ func TestObjectsWithDelimiterSlash(t *testing.T) {
 
 		var marker string
 		for offset := 0; offset < len(test.want); offset++ {
-			resp, err := ss.Objects(ctx, testBucket, test.path+test.prefix, "", "/", test.sortBy, test.sortDir, marker, 1, object.EncryptionKey{})
+			resp, err := ss.Objects(ctx, testBucket, test.path+test.prefix, "", "/", test.sortBy, test.sortDir, marker, 1, object.EncryptionKey{}, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1256,7 +1603,7 @@ func TestObjectsWithDelimiterSlash(t *testing.T) {
 				continue
 			}
 
-			resp, err = ss.Objects(ctx, testBucket, test.path+test.prefix, "", "/", test.sortBy, test.sortDir, test.want[offset].Key, 1, object.EncryptionKey{})
+			resp, err = ss.Objects(ctx, testBucket, test.path+test.prefix, "", "/", test.sortBy, test.sortDir, test.want[offset].Key, 1, object.EncryptionKey{}, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1329,7 +1676,7 @@ func TestObjectsExplicitDir(t *testing.T) {
 		}
 	}
 	for _, test := range tests {
-		got, err := ss.Objects(ctx, testBucket, test.path+test.prefix, "", "/", test.sortBy, test.sortDir, "", -1, object.EncryptionKey{})
+		got, err := ss.Objects(ctx, testBucket, test.path+test.prefix, "", "/", test.sortBy, test.sortDir, "", -1, object.EncryptionKey{}, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1388,7 +1735,7 @@ func TestListObjectsSlabEncryptionKey(t *testing.T) {
 	}
 
 	// Fetch the objects by slab.
-	res, err := ss.Objects(context.Background(), "", "", "", "", "", "", "", -1, slab.EncryptionKey)
+	res, err := ss.Objects(context.Background(), "", "", "", "", "", "", "", -1, slab.EncryptionKey, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1460,7 +1807,7 @@ func TestObjectsSubstring(t *testing.T) {
 		{"uu", []api.ObjectMetadata{{Key: "/foo/baz/quux", Size: 3, Health: 1}, {Key: "/foo/baz/quuz", Size: 4, Health: 1}, {Key: "/gab/guub", Size: 5, Health: 1}}},
 	}
 	for _, test := range tests {
-		resp, err := ss.Objects(ctx, testBucket, "", test.key, "", "", "", "", -1, object.EncryptionKey{})
+		resp, err := ss.Objects(ctx, testBucket, "", test.key, "", "", "", "", -1, object.EncryptionKey{}, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1468,7 +1815,7 @@ func TestObjectsSubstring(t *testing.T) {
 		assertEqual(got, test.want)
 		var marker string
 		for offset := 0; offset < len(test.want); offset++ {
-			if resp, err := ss.Objects(ctx, testBucket, "", test.key, "", "", "", marker, 1, object.EncryptionKey{}); err != nil {
+			if resp, err := ss.Objects(ctx, testBucket, "", test.key, "", "", "", marker, 1, object.EncryptionKey{}, nil); err != nil {
 				t.Fatal(err)
 			} else if got := resp.Objects; len(got) != 1 {
 				t.Errorf("\nkey: %v unexpected number of objects, %d != 1", test.key, len(got))
@@ -1491,7 +1838,7 @@ func TestObjectsDelimiterRegression(t *testing.T) {
 	// create two buckets
 	buckets := []string{"foo", "bar"}
 	for _, b := range buckets {
-		if err := ss.CreateBucket(context.Background(), b, api.BucketPolicy{}); err != nil {
+		if err := ss.CreateBucket(context.Background(), b, api.BucketPolicy{}, nil); err != nil {
 			t.Fatal(err)
 		}
 	}
@@ -1506,7 +1853,7 @@ func TestObjectsDelimiterRegression(t *testing.T) {
 	// assert both files show up if no delimiter is specified
 	var delimiter string
 	for _, b := range buckets {
-		if res, err := ss.Objects(context.Background(), b, "", "", delimiter, "", "", "", -1, object.EncryptionKey{}); err != nil {
+		if res, err := ss.Objects(context.Background(), b, "", "", delimiter, "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 			t.Fatal(err)
 		} else if len(res.Objects) != 1 {
 			t.Fatal("expected 1 object, got", len(res.Objects))
@@ -1518,7 +1865,7 @@ func TestObjectsDelimiterRegression(t *testing.T) {
 	// assert both files show up if the delimiter is set to /
 	delimiter = "/"
 	for _, b := range buckets {
-		if res, err := ss.Objects(context.Background(), b, "", "", delimiter, "", "", "", -1, object.EncryptionKey{}); err != nil {
+		if res, err := ss.Objects(context.Background(), b, "", "", delimiter, "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 			t.Fatal(err)
 		} else if len(res.Objects) != 1 {
 			t.Fatal("expected 1 object, got", len(res.Objects), b)
@@ -1654,10 +2001,10 @@ func TestSlabsForMigration(t *testing.T) {
 	}
 
 	expected := []api.UnhealthySlab{
-		{EncryptionKey: obj.Slabs[2].EncryptionKey, Health: 0},
-		{EncryptionKey: obj.Slabs[4].EncryptionKey, Health: 0},
-		{EncryptionKey: obj.Slabs[1].EncryptionKey, Health: 0.5},
-		{EncryptionKey: obj.Slabs[3].EncryptionKey, Health: 0.5},
+		{EncryptionKey: obj.Slabs[2].EncryptionKey, Health: 0, HealthyShards: 1, TotalShards: 3},
+		{EncryptionKey: obj.Slabs[4].EncryptionKey, Health: 0, HealthyShards: 1, TotalShards: 3},
+		{EncryptionKey: obj.Slabs[1].EncryptionKey, Health: 0.5, HealthyShards: 2, TotalShards: 3},
+		{EncryptionKey: obj.Slabs[3].EncryptionKey, Health: 0.5, HealthyShards: 2, TotalShards: 3},
 	}
 	if !reflect.DeepEqual(slabs, expected) {
 		t.Fatal("slabs are not returned in the correct order")
@@ -1675,8 +2022,8 @@ func TestSlabsForMigration(t *testing.T) {
 	}
 
 	expected = []api.UnhealthySlab{
-		{EncryptionKey: obj.Slabs[2].EncryptionKey, Health: 0},
-		{EncryptionKey: obj.Slabs[4].EncryptionKey, Health: 0},
+		{EncryptionKey: obj.Slabs[2].EncryptionKey, Health: 0, HealthyShards: 1, TotalShards: 3},
+		{EncryptionKey: obj.Slabs[4].EncryptionKey, Health: 0, HealthyShards: 1, TotalShards: 3},
 	}
 	if !reflect.DeepEqual(slabs, expected) {
 		t.Fatal("slabs are not returned in the correct order", slabs, expected)
@@ -1880,7 +2227,7 @@ func TestUnhealthySlabsNoRedundancy(t *testing.T) {
 	}
 
 	expected := []api.UnhealthySlab{
-		{EncryptionKey: obj.Slabs[1].Slab.EncryptionKey, Health: -1},
+		{EncryptionKey: obj.Slabs[1].Slab.EncryptionKey, Health: -1, TotalShards: 2},
 	}
 	if !reflect.DeepEqual(slabs, expected) {
 		t.Fatal("slabs are not returned in the correct order")
@@ -2134,6 +2481,61 @@ func newTestObject(slabs int) object.Object {
 	return obj
 }
 
+// TestRecordContractSpendingCap asserts spending that exceeds a contract's cap
+// is recorded but marks the contract as bad, and that the cap carries over to
+// the renewal.
+func TestRecordContractSpendingCap(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a contract with a spending cap
+	hk := types.PublicKey{1}
+	fcid := types.FileContractID{1}
+	if err := ss.addTestHost(hk); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.addTestContract(fcid, hk); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateContractSpendingCap(context.Background(), fcid, types.Siacoins(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// record spending that exceeds the cap
+	spending := api.ContractSpending{Uploads: types.Siacoins(2)}
+	err := ss.RecordContractSpending(context.Background(), []api.ContractSpendingRecord{
+		{
+			ContractID:       fcid,
+			ContractSpending: spending,
+			RevisionNumber:   1,
+			Size:             2,
+		},
+	})
+	if !errors.Is(err, api.ErrContractSpendingCapExceeded) {
+		t.Fatal("expected ErrContractSpendingCapExceeded, got", err)
+	}
+
+	// assert the spending and revision were recorded and the contract is bad
+	c, err := ss.Contract(context.Background(), fcid)
+	if err != nil {
+		t.Fatal(err)
+	} else if c.Spending != spending {
+		t.Fatalf("expected spending %v, got %v", spending, c.Spending)
+	} else if c.RevisionNumber != 1 || c.Size != 2 {
+		t.Fatalf("unexpected revision number or size, %v %v", c.RevisionNumber, c.Size)
+	} else if c.Usability != api.ContractUsabilityBad {
+		t.Fatalf("expected contract to be bad, got %v", c.Usability)
+	}
+
+	// renew the contract and assert the cap carries over
+	renewal := types.FileContractID{2}
+	if err := ss.renewTestContract(hk, fcid, renewal, 1); err != nil {
+		t.Fatal(err)
+	} else if c, err := ss.Contract(context.Background(), renewal); err != nil {
+		t.Fatal(err)
+	} else if !c.SpendingCap.Equals(types.Siacoins(1)) {
+		t.Fatalf("expected cap %v, got %v", types.Siacoins(1), c.SpendingCap)
+	}
+}
+
 // TestRecordContractSpending tests RecordContractSpending.
 func TestRecordContractSpending(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
@@ -2217,34 +2619,293 @@ func TestRecordContractSpending(t *testing.T) {
 	}
 }
 
-// TestRenameObjects is a unit test for RenameObject and RenameObjects.
-func TestRenameObjects(t *testing.T) {
+func TestContractRevisions(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
 
-	// Create a few objects.
-	objects := []string{
-		"/fileś/1a",
-		"/fileś/2a",
-		"/fileś/3a",
-		"/fileś/CASE",
-		"/fileś/case",
-		"/fileś/dir/1b",
-		"/fileś/dir/2b",
-		"/fileś/dir/3b",
-		"/folder/file1",
-		"/folder/foo/file2",
-		"/foo",
-		"/bar",
-		"/baz",
-		"/baz2",
-		"/baz3",
+	// add a contract
+	hk := types.PublicKey{1}
+	fcid := types.FileContractID{1}
+	if err := ss.addTestHost(hk); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.addTestContract(fcid, hk); err != nil {
+		t.Fatal(err)
 	}
-	ctx := context.Background()
-	for _, path := range objects {
-		if _, err := ss.addTestObject(path, newTestObject(1)); err != nil {
-			t.Fatal(err)
-		}
+
+	// assert unknown contracts are reported as not found
+	_, err := ss.ContractRevisions(context.Background(), types.FileContractID{2}, api.ContractRevisionsOpts{End: math.MaxUint64})
+	if !errors.Is(err, api.ErrContractNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// record spending for a couple of revisions, recording the same revision
+	// twice should only add it to the history once
+	for _, rev := range []uint64{1, 2, 2, 3, 4} {
+		if err := ss.RecordContractSpending(context.Background(), []api.ContractSpendingRecord{
+			{
+				ContractID:     fcid,
+				RevisionNumber: rev,
+				Size:           rev * 10,
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertRevisions := func(start, end uint64, expected ...uint64) {
+		t.Helper()
+		revisions, err := ss.ContractRevisions(context.Background(), fcid, api.ContractRevisionsOpts{Start: start, End: end})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(revisions) != len(expected) {
+			t.Fatalf("expected %d revisions, got %d", len(expected), len(revisions))
+		}
+		for i, rev := range revisions {
+			if rev.RevisionNumber != expected[i] {
+				t.Fatalf("expected revision %d, got %d", expected[i], rev.RevisionNumber)
+			} else if rev.Size != expected[i]*10 {
+				t.Fatalf("expected size %d, got %d", expected[i]*10, rev.Size)
+			} else if time.Time(rev.Timestamp).IsZero() {
+				t.Fatal("expected timestamp to be set")
+			}
+		}
+	}
+	assertRevisions(0, math.MaxUint64, 1, 2, 3, 4)
+	assertRevisions(2, 3, 2, 3)
+	assertRevisions(5, 10)
+
+	// renew the contract and assert the history stays with the renewed contract
+	renewal := types.FileContractID{3}
+	if err := ss.renewTestContract(hk, fcid, renewal, 1); err != nil {
+		t.Fatal(err)
+	}
+	assertRevisions(0, math.MaxUint64, 1, 2, 3, 4)
+	if revisions, err := ss.ContractRevisions(context.Background(), renewal, api.ContractRevisionsOpts{End: math.MaxUint64}); err != nil {
+		t.Fatal(err)
+	} else if len(revisions) != 0 {
+		t.Fatalf("expected no revisions for the renewal, got %d", len(revisions))
+	}
+}
+
+func TestContractEvents(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a contract
+	hk := types.PublicKey{1}
+	fcid := types.FileContractID{1}
+	if err := ss.addTestHost(hk); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.addTestContract(fcid, hk); err != nil {
+		t.Fatal(err)
+	} else if err := ss.RecordContractEvent(context.Background(), fcid, api.ContractEventTypeFormed, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// renew it and archive the renewal
+	renewal := types.FileContractID{2}
+	if err := ss.renewTestContract(hk, fcid, renewal, 1); err != nil {
+		t.Fatal(err)
+	} else if err := ss.ArchiveContract(context.Background(), renewal, api.ContractArchivalReasonRemoved); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEvents := func(fcid types.FileContractID, expected ...string) []api.ContractEvent {
+		t.Helper()
+		events, err := ss.ContractEvents(context.Background(), fcid)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(events) != len(expected) {
+			t.Fatalf("expected %d events, got %d", len(expected), len(events))
+		}
+		for i, event := range events {
+			if event.Type != expected[i] {
+				t.Fatalf("expected event %v, got %v", expected[i], event.Type)
+			} else if event.ContractID != fcid {
+				t.Fatalf("unexpected contract id %v", event.ContractID)
+			}
+		}
+		return events
+	}
+	events := assertEvents(fcid, api.ContractEventTypeFormed, api.ContractEventTypeRenewed)
+	if events[0].Details != nil {
+		t.Fatal("expected no details", string(events[0].Details))
+	} else if !strings.Contains(string(events[1].Details), renewal.String()) {
+		t.Fatal("expected details to contain the renewal", string(events[1].Details))
+	}
+	events = assertEvents(renewal, api.ContractEventTypeFormed, api.ContractEventTypeArchived)
+	if string(events[1].Details) != `{"reason":"removed"}` {
+		t.Fatal("unexpected details", string(events[1].Details))
+	}
+
+	// assert events can't be recorded for unknown contracts
+	if err := ss.RecordContractEvent(context.Background(), types.FileContractID{3}, api.ContractEventTypeFormed, nil); !errors.Is(err, api.ErrContractNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// assert the events are deleted together with their contract
+	var n int
+	if _, err := ss.DB().Exec(context.Background(), "DELETE FROM contracts WHERE fcid = ?", sql.FileContractID(renewal)); err != nil {
+		t.Fatal(err)
+	} else if err := ss.DB().QueryRow(context.Background(), "SELECT COUNT(*) FROM contract_events").Scan(&n); err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatalf("expected 2 events, got %d", n)
+	}
+}
+
+func TestRecordContractFormation(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a host
+	hk := types.PublicKey{1}
+	if err := ss.addTestHost(hk); err != nil {
+		t.Fatal(err)
+	}
+
+	// record the formation of a contract
+	fcid := types.FileContractID{1}
+	spending := api.ContractSpending{FundAccount: types.Siacoins(1)}
+	c := newTestContract(fcid, hk)
+	c.Spending = spending
+	if err := ss.RecordContractFormation(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the contract was added with its initial spending
+	if c, err := ss.Contract(context.Background(), fcid); err != nil {
+		t.Fatal(err)
+	} else if c.Spending != spending {
+		t.Fatalf("unexpected spending %+v", c.Spending)
+	}
+
+	// assert the formation was recorded
+	if events, err := ss.ContractEvents(context.Background(), fcid); err != nil {
+		t.Fatal(err)
+	} else if len(events) != 1 || events[0].Type != api.ContractEventTypeFormed {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	// assert nothing is recorded if the contract can't be added
+	fcid2 := types.FileContractID{2}
+	if err := ss.RecordContractFormation(context.Background(), newTestContract(fcid2, types.PublicKey{2})); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if _, err := ss.Contract(context.Background(), fcid2); !errors.Is(err, api.ErrContractNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if _, err := ss.ContractEvents(context.Background(), fcid2); !errors.Is(err, api.ErrContractNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestFailPendingContracts(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a host
+	hk := types.PublicKey{1}
+	if err := ss.addTestHost(hk); err != nil {
+		t.Fatal(err)
+	}
+
+	// add three pending contracts with increasing start heights, one active
+	// contract and one archived pending contract
+	for i := 1; i <= 5; i++ {
+		c := newTestContract(types.FileContractID{byte(i)}, hk)
+		c.StartHeight = uint64(i * 10)
+		if i == 4 {
+			c.State = api.ContractStateActive
+			c.StartHeight = 0
+		}
+		if err := ss.PutContract(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ss.ArchiveContract(context.Background(), types.FileContractID{5}, api.ContractArchivalReasonRemoved); err != nil {
+		t.Fatal(err)
+	}
+
+	// fail the pending contracts that started at or before height 20
+	failed, err := ss.FailPendingContracts(context.Background(), 20, 30)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(failed) != 2 {
+		t.Fatalf("expected 2 failed contracts, got %d", len(failed))
+	}
+
+	// assert the contract states
+	for i, expected := range []string{
+		api.ContractStateFailed,
+		api.ContractStateFailed,
+		api.ContractStatePending,
+		api.ContractStateActive,
+	} {
+		c, err := ss.Contract(context.Background(), types.FileContractID{byte(i + 1)})
+		if err != nil {
+			t.Fatal(err)
+		} else if c.State != expected {
+			t.Fatalf("expected contract %d to be %v, got %v", i+1, expected, c.State)
+		}
+	}
+	archived, err := ss.Contracts(context.Background(), api.ContractsOpts{FilterMode: api.ContractFilterModeArchived})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(archived) != 1 || archived[0].State != api.ContractStatePending {
+		t.Fatalf("expected archived contract to remain pending, got %+v", archived)
+	}
+
+	// assert a state change event was recorded
+	events, err := ss.ContractEvents(context.Background(), types.FileContractID{1})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(events) != 1 || events[0].Type != api.ContractEventTypeStateChanged {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	// assert the state transition was recorded
+	history, err := ss.ContractStateHistory(context.Background(), types.FileContractID{1})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(history) != 1 || history[0].FromState != api.ContractStatePending || history[0].ToState != api.ContractStateFailed || history[0].Height != 30 {
+		t.Fatalf("unexpected history %+v", history)
+	}
+
+	// failing again is a no-op
+	if failed, err := ss.FailPendingContracts(context.Background(), 20, 30); err != nil {
+		t.Fatal(err)
+	} else if len(failed) != 0 {
+		t.Fatalf("expected no failed contracts, got %d", len(failed))
+	}
+}
+
+// TestRenameObjects is a unit test for RenameObject and RenameObjects.
+func TestRenameObjects(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// Create a few objects.
+	objects := []string{
+		"/fileś/1a",
+		"/fileś/2a",
+		"/fileś/3a",
+		"/fileś/CASE",
+		"/fileś/case",
+		"/fileś/dir/1b",
+		"/fileś/dir/2b",
+		"/fileś/dir/3b",
+		"/folder/file1",
+		"/folder/foo/file2",
+		"/foo",
+		"/bar",
+		"/baz",
+		"/baz2",
+		"/baz3",
+	}
+	ctx := context.Background()
+	for _, path := range objects {
+		if _, err := ss.addTestObject(path, newTestObject(1)); err != nil {
+			t.Fatal(err)
+		}
 	}
 
 	// Try renaming objects that don't exist.
@@ -2307,7 +2968,7 @@ func TestRenameObjects(t *testing.T) {
 	}
 
 	// Assert that number of objects matches.
-	resp, err := ss.Objects(ctx, testBucket, "", "/", "", "", "", "", 100, object.EncryptionKey{})
+	resp, err := ss.Objects(ctx, testBucket, "", "/", "", "", "", "", 100, object.EncryptionKey{}, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -2323,6 +2984,73 @@ func TestRenameObjects(t *testing.T) {
 	}
 }
 
+func TestRenameObjectAcrossBuckets(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	ctx := context.Background()
+	if err := ss.CreateBucket(ctx, "other", api.BucketPolicy{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// add objects to both buckets
+	obj, err := ss.addTestObject("/foo", newTestObject(1))
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.addTestObject("/bar", newTestObject(1)); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateObjectBlocking(ctx, "other", "/bar", testETag, testMimeType, testMetadata, newTestObject(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// moving to a bucket that doesn't exist fails
+	if err := ss.RenameObject(ctx, testBucket, "missing", "/foo", "/foo", false); !errors.Is(err, api.ErrBucketNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// moving onto an existing object fails unless forced
+	if err := ss.RenameObject(ctx, testBucket, "other", "/bar", "/bar", false); !errors.Is(err, api.ErrObjectExists) {
+		t.Fatal("unexpected error", err)
+	} else if err := ss.RenameObject(ctx, testBucket, "other", "/bar", "/bar", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// move an object to the other bucket and assert it's only found there
+	if err := ss.RenameObject(ctx, testBucket, "other", "/foo", "/baz", false); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.Object(ctx, testBucket, "/foo"); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if moved, err := ss.Object(ctx, "other", "/baz"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(moved.Object, obj.Object) {
+		t.Fatal("unexpected object")
+	}
+
+	// the source bucket is empty now
+	if resp, err := ss.Objects(ctx, testBucket, "", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
+		t.Fatal(err)
+	} else if len(resp.Objects) != 0 {
+		t.Fatal("unexpected number of objects", len(resp.Objects))
+	}
+
+	// assert the usage of both buckets was updated
+	for bucket, want := range map[string]int64{testBucket: 0, "other": 2} {
+		usage, err := ss.BucketUsage(ctx, bucket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recounted, err := ss.RecountBucketUsage(ctx, bucket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if usage.ObjectCount != want {
+			t.Fatalf("expected %d objects in bucket %v, got %d", want, bucket, usage.ObjectCount)
+		} else if usage.ObjectCount != recounted.ObjectCount || usage.TotalBytes != recounted.TotalBytes {
+			t.Fatalf("usage of bucket %v doesn't match recount, %+v != %+v", bucket, usage, recounted)
+		}
+	}
+}
+
 func TestRenameObjectsRegression(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -2351,7 +3079,7 @@ func TestRenameObjectsRegression(t *testing.T) {
 			delimiter = "/"
 		}
 
-		res, err := ss.Objects(ctx, testBucket, path, "", delimiter, "", "", "", -1, object.EncryptionKey{})
+		res, err := ss.Objects(ctx, testBucket, path, "", delimiter, "", "", "", -1, object.EncryptionKey{}, nil)
 		if err != nil {
 			t.Fatal(err)
 		} else if len(res.Objects) != n {
@@ -2516,7 +3244,7 @@ func TestObjectsStats(t *testing.T) {
 	}
 
 	// Check other bucket.
-	if err := ss.CreateBucket(context.Background(), "other", api.BucketPolicy{}); err != nil {
+	if err := ss.CreateBucket(context.Background(), "other", api.BucketPolicy{}, nil); err != nil {
 		t.Fatal(err)
 	} else if info, err := ss.ObjectsStats(context.Background(), api.ObjectsStatsOpts{Bucket: "other"}); err != nil {
 		t.Fatal(err)
@@ -2853,6 +3581,56 @@ func TestPartialSlab(t *testing.T) {
 	}
 }
 
+func TestListSlabBuffers(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// helper to add a partial slab and an object that references it
+	ctx := context.Background()
+	addPartialObject := func(key string, data []byte, minShards, totalShards uint8) {
+		t.Helper()
+		slices, _, err := ss.AddPartialSlab(ctx, data, minShards, totalShards)
+		if err != nil {
+			t.Fatal(err)
+		} else if _, err := ss.addTestObject(key, object.Object{
+			Key:   object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+			Slabs: slices,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// add two buffers by using different redundancy settings, then write to
+	// the first buffer again to make it the most recently written one
+	addPartialObject("/a", []byte{1, 2, 3}, 1, 2)
+	addPartialObject("/b", []byte{4, 5}, 1, 3)
+	addPartialObject("/c", []byte{6}, 1, 2)
+
+	buffers, err := ss.ListSlabBuffers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(buffers) != 2 {
+		t.Fatalf("expected 2 buffers, got %d", len(buffers))
+	}
+
+	buf1, buf2 := buffers[0], buffers[1]
+	if time.Time(buf1.LastWrittenAt).Before(time.Time(buf2.LastWrittenAt)) {
+		t.Fatal("expected buffers to be sorted by last write", buf1.LastWrittenAt, buf2.LastWrittenAt)
+	} else if !time.Time(buf1.CreatedAt).Before(time.Time(buf2.CreatedAt)) {
+		t.Fatal("expected first buffer to be created first", buf1.CreatedAt, buf2.CreatedAt)
+	} else if buf1.SizeBytesOnDisk != 4 {
+		t.Fatal("unexpected size on disk", buf1.SizeBytesOnDisk)
+	} else if buf2.SizeBytesOnDisk != 2 {
+		t.Fatal("unexpected size on disk", buf2.SizeBytesOnDisk)
+	} else if buf1.CompletionThreshold != ss.slabBufferMgr.bufferedSlabCompletionThreshold {
+		t.Fatal("unexpected completion threshold", buf1.CompletionThreshold)
+	} else if !reflect.DeepEqual(buf1.AssociatedObjectKeys, []string{"/a", "/c"}) {
+		t.Fatal("unexpected object keys", buf1.AssociatedObjectKeys)
+	} else if !reflect.DeepEqual(buf2.AssociatedObjectKeys, []string{"/b"}) {
+		t.Fatal("unexpected object keys", buf2.AssociatedObjectKeys)
+	}
+}
+
 func TestContractSizes(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -2972,9 +3750,296 @@ func TestContractSizes(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// assert there's no data to be pruned
-	if n := prunableData(nil); n != 0 {
-		t.Fatal("expected no prunable data", n)
+	// assert there's no data to be pruned
+	if n := prunableData(nil); n != 0 {
+		t.Fatal("expected no prunable data", n)
+	}
+}
+
+func TestContractSizesHostSectorSize(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create a host with a contract that holds two sectors worth of data but
+	// only a single sector is referenced by an object
+	hks, err := ss.addTestHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ss.addTestObject("/obj", object.Object{
+		Key: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+		Slabs: []object.SlabSlice{
+			{
+				Slab: object.Slab{
+					EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+					MinShards:     1,
+					Shards:        newTestShards(hks[0], fcids[0], types.Hash256{1}),
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.RecordContractSpending(context.Background(), []api.ContractSpendingRecord{
+		{
+			ContractID:     fcids[0],
+			RevisionNumber: 1,
+			Size:           2 * rhpv4.SectorSize,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertPrunable := func(expected uint64) {
+		t.Helper()
+		if size, err := ss.ContractSize(context.Background(), fcids[0]); err != nil {
+			t.Fatal(err)
+		} else if size.Prunable != expected {
+			t.Fatalf("expected %d prunable bytes, got %d", expected, size.Prunable)
+		}
+		if sizes, err := ss.ContractSizes(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if sizes[fcids[0]].Prunable != expected {
+			t.Fatalf("expected %d prunable bytes, got %d", expected, sizes[fcids[0]].Prunable)
+		}
+		if resp, err := ss.ContractsPrunableData(context.Background(), api.ContractsPrunableDataOpts{Limit: -1}); err != nil {
+			t.Fatal(err)
+		} else if resp.TotalPrunable != expected {
+			t.Fatalf("expected %d prunable bytes, got %d", expected, resp.TotalPrunable)
+		}
+	}
+
+	// by default hosts use the standard sector size
+	if h, err := ss.Host(context.Background(), hks[0]); err != nil {
+		t.Fatal(err)
+	} else if h.SectorSize != rhpv4.SectorSize {
+		t.Fatalf("expected sector size %d, got %d", rhpv4.SectorSize, h.SectorSize)
+	}
+	assertPrunable(rhpv4.SectorSize)
+
+	// override the host's sector size, the referenced sector now covers the
+	// whole contract
+	if err := ss.UpdateHostSectorSize(context.Background(), hks[0], 2*rhpv4.SectorSize); err != nil {
+		t.Fatal(err)
+	} else if h, err := ss.Host(context.Background(), hks[0]); err != nil {
+		t.Fatal(err)
+	} else if h.SectorSize != 2*rhpv4.SectorSize {
+		t.Fatalf("expected sector size %d, got %d", 2*rhpv4.SectorSize, h.SectorSize)
+	}
+	assertPrunable(0)
+
+	// updating an unknown host fails
+	if err := ss.UpdateHostSectorSize(context.Background(), types.GeneratePrivateKey().PublicKey(), rhpv4.SectorSize); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("expected ErrHostNotFound", err)
+	}
+}
+
+func TestContractUsabilityOverride(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	hks, err := ss.addTestHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcid := fcids[0]
+
+	assertUsability := func(usability string, override bool) {
+		t.Helper()
+		c, err := ss.Contract(context.Background(), fcid)
+		if err != nil {
+			t.Fatal(err)
+		} else if c.Usability != usability {
+			t.Fatalf("expected usability %v, got %v", usability, c.Usability)
+		} else if c.UsabilityOverride != override {
+			t.Fatalf("expected override %v, got %v", override, c.UsabilityOverride)
+		}
+	}
+	assertUsability(api.ContractUsabilityGood, false)
+
+	// override the usability
+	if err := ss.UpdateContractUsabilityOverride(context.Background(), fcid, api.ContractUsabilityBad); err != nil {
+		t.Fatal(err)
+	}
+	assertUsability(api.ContractUsabilityBad, true)
+
+	// assert regular updates are ignored
+	if err := ss.UpdateContractUsability(context.Background(), fcid, api.ContractUsabilityGood); err != nil {
+		t.Fatal(err)
+	}
+	assertUsability(api.ContractUsabilityBad, true)
+
+	// assert the filter mode respects the override
+	if contracts, err := ss.Contracts(context.Background(), api.ContractsOpts{FilterMode: api.ContractFilterModeGood}); err != nil {
+		t.Fatal(err)
+	} else if len(contracts) != 0 {
+		t.Fatalf("expected no good contracts, got %d", len(contracts))
+	}
+
+	// remove the override and assert regular updates are applied again
+	if err := ss.DeleteContractUsabilityOverride(context.Background(), fcid); err != nil {
+		t.Fatal(err)
+	}
+	assertUsability(api.ContractUsabilityBad, false)
+	if err := ss.UpdateContractUsability(context.Background(), fcid, api.ContractUsabilityGood); err != nil {
+		t.Fatal(err)
+	}
+	assertUsability(api.ContractUsabilityGood, false)
+
+	// assert unknown contracts and invalid usabilities are rejected
+	unknown := types.FileContractID{1, 2, 3}
+	if err := ss.UpdateContractUsabilityOverride(context.Background(), unknown, api.ContractUsabilityBad); !errors.Is(err, api.ErrContractNotFound) {
+		t.Fatal("expected ErrContractNotFound", err)
+	} else if err := ss.DeleteContractUsabilityOverride(context.Background(), unknown); !errors.Is(err, api.ErrContractNotFound) {
+		t.Fatal("expected ErrContractNotFound", err)
+	} else if err := ss.UpdateContractUsabilityOverride(context.Background(), fcid, "foo"); !errors.Is(err, sql.ErrInvalidContractUsability) {
+		t.Fatal("expected ErrInvalidContractUsability", err)
+	}
+
+	// override the usability again and assert it carries over on renewal
+	if err := ss.UpdateContractUsabilityOverride(context.Background(), fcid, api.ContractUsabilityBad); err != nil {
+		t.Fatal(err)
+	}
+	renewal := types.FileContractID{4, 5, 6}
+	if err := ss.renewTestContract(hks[0], fcid, renewal, 1); err != nil {
+		t.Fatal(err)
+	}
+	fcid = renewal
+	assertUsability(api.ContractUsabilityBad, true)
+}
+
+func TestContractVerificationStatus(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	hks, err := ss.addTestHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcid := fcids[0]
+
+	assertStatus := func(status string) {
+		t.Helper()
+		c, err := ss.Contract(context.Background(), fcid)
+		if err != nil {
+			t.Fatal(err)
+		} else if c.VerificationStatus != status {
+			t.Fatalf("expected status %v, got %v", status, c.VerificationStatus)
+		}
+	}
+	assertStatus(api.ContractVerificationStatusUnverified)
+
+	// update the status
+	for _, status := range []string{api.ContractVerificationStatusMismatch, api.ContractVerificationStatusVerified} {
+		if err := ss.UpdateContractVerificationStatus(context.Background(), fcid, status); err != nil {
+			t.Fatal(err)
+		}
+		assertStatus(status)
+	}
+
+	// assert unknown contracts and invalid statuses are rejected
+	if err := ss.UpdateContractVerificationStatus(context.Background(), types.FileContractID{1, 2, 3}, api.ContractVerificationStatusVerified); !errors.Is(err, api.ErrContractNotFound) {
+		t.Fatal("expected ErrContractNotFound", err)
+	} else if err := ss.UpdateContractVerificationStatus(context.Background(), fcid, "foo"); !errors.Is(err, sql.ErrInvalidContractVerificationStatus) {
+		t.Fatal("expected ErrInvalidContractVerificationStatus", err)
+	}
+}
+
+func TestContractsPrunableData(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create three contracts
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add a sector to the first contract
+	if _, err := ss.addTestObject("/obj", object.Object{
+		Key: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+		Slabs: []object.SlabSlice{
+			{
+				Slab: object.Slab{
+					EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+					MinShards:     1,
+					Shards:        newTestShards(hks[0], fcids[0], types.Hash256{1}),
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// update the sizes so that the first contract is the biggest but the
+	// second one has the most prunable data
+	for i, size := range []uint64{2 * rhpv4.SectorSize, 3 * rhpv4.SectorSize / 2, rhpv4.SectorSize / 2} {
+		if err := ss.RecordContractSpending(context.Background(), []api.ContractSpendingRecord{
+			{
+				ContractID:     fcids[i],
+				RevisionNumber: 1,
+				Size:           size,
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertOrder := func(opts api.ContractsPrunableDataOpts, expected ...types.FileContractID) {
+		t.Helper()
+		res, err := ss.ContractsPrunableData(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		} else if res.TotalSize != 4*rhpv4.SectorSize || res.TotalPrunable != 3*rhpv4.SectorSize {
+			t.Fatalf("unexpected totals %d %d", res.TotalSize, res.TotalPrunable)
+		} else if len(res.Contracts) != len(expected) {
+			t.Fatalf("expected %d contracts, got %d", len(expected), len(res.Contracts))
+		}
+		for i, c := range res.Contracts {
+			if c.ID != expected[i] {
+				t.Fatalf("unexpected contract at index %d, %v != %v", i, c.ID, expected[i])
+			}
+		}
+	}
+
+	// assert sorting
+	assertOrder(api.ContractsPrunableDataOpts{Limit: -1}, fcids[1], fcids[0], fcids[2])
+	assertOrder(api.ContractsPrunableDataOpts{SortBy: api.ContractsPrunableSortBySize, Limit: -1}, fcids[0], fcids[1], fcids[2])
+
+	res, err := ss.ContractsPrunableData(context.Background(), api.ContractsPrunableDataOpts{SortBy: api.ContractsPrunableSortByHostKey, Limit: -1})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(res.Contracts) != 3 {
+		t.Fatal("unexpected number of contracts", len(res.Contracts))
+	}
+	for i := 1; i < len(res.Contracts); i++ {
+		if bytes.Compare(res.Contracts[i-1].HostKey[:], res.Contracts[i].HostKey[:]) > 0 {
+			t.Fatal("contracts not sorted by host key")
+		}
+	}
+
+	// assert pagination
+	assertOrder(api.ContractsPrunableDataOpts{Offset: 1, Limit: 1}, fcids[0])
+	assertOrder(api.ContractsPrunableDataOpts{Offset: 3, Limit: 1})
+
+	// assert invalid sort option
+	if _, err := ss.ContractsPrunableData(context.Background(), api.ContractsPrunableDataOpts{SortBy: "foo", Limit: -1}); err == nil {
+		t.Fatal("expected error")
 	}
 }
 
@@ -3023,7 +4088,7 @@ func TestObjectsBySlabKey(t *testing.T) {
 	}
 
 	// Fetch the objects by slab.
-	res, err := ss.Objects(context.Background(), testBucket, "", "", "/", "", "", "", -1, slab.EncryptionKey)
+	res, err := ss.Objects(context.Background(), testBucket, "", "", "/", "", "", "", -1, slab.EncryptionKey, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -3057,9 +4122,9 @@ func TestBuckets(t *testing.T) {
 	// Create 2 more buckets and delete the default one. This should result in
 	// 2 buckets.
 	b1, b2 := "bucket1", "bucket2"
-	if err := ss.CreateBucket(context.Background(), b1, api.BucketPolicy{}); err != nil {
+	if err := ss.CreateBucket(context.Background(), b1, api.BucketPolicy{}, nil); err != nil {
 		t.Fatal(err)
-	} else if err := ss.CreateBucket(context.Background(), b2, api.BucketPolicy{}); err != nil {
+	} else if err := ss.CreateBucket(context.Background(), b2, api.BucketPolicy{}, nil); err != nil {
 		t.Fatal(err)
 	} else if err := ss.DeleteBucket(context.Background(), testBucket); err != nil {
 		t.Fatal(err)
@@ -3075,7 +4140,7 @@ func TestBuckets(t *testing.T) {
 
 	// Creating an existing buckets shouldn't work and neither should deleting
 	// one that doesn't exist.
-	if err := ss.CreateBucket(context.Background(), b1, api.BucketPolicy{}); !errors.Is(err, api.ErrBucketExists) {
+	if err := ss.CreateBucket(context.Background(), b1, api.BucketPolicy{}, nil); !errors.Is(err, api.ErrBucketExists) {
 		t.Fatal("expected ErrBucketExists", err)
 	} else if err := ss.DeleteBucket(context.Background(), "foo"); !errors.Is(err, api.ErrBucketNotFound) {
 		t.Fatal("expected ErrBucketNotFound", err)
@@ -3095,11 +4160,11 @@ func TestBucketObjects(t *testing.T) {
 
 	// Create buckest for the test.
 	b1, b2 := "bucket1", "bucket2"
-	if err := ss.CreateBucket(context.Background(), b1, api.BucketPolicy{}); err != nil {
+	if err := ss.CreateBucket(context.Background(), b1, api.BucketPolicy{}, nil); err != nil {
 		t.Fatal(err)
-	} else if err := ss.CreateBucket(context.Background(), b2, api.BucketPolicy{}); err != nil {
+	} else if err := ss.CreateBucket(context.Background(), b2, api.BucketPolicy{}, nil); err != nil {
 		t.Fatal(err)
-	} else if err := ss.CreateBucket(context.Background(), b2, api.BucketPolicy{}); !errors.Is(err, api.ErrBucketExists) {
+	} else if err := ss.CreateBucket(context.Background(), b2, api.BucketPolicy{}, nil); !errors.Is(err, api.ErrBucketExists) {
 		t.Fatal(err)
 	}
 
@@ -3131,38 +4196,38 @@ func TestBucketObjects(t *testing.T) {
 	}
 
 	// List the objects in the buckets.
-	if resp, err := ss.Objects(context.Background(), b1, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	if resp, err := ss.Objects(context.Background(), b1, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 1 {
 		t.Fatal("expected 1 entry", len(entries))
 	} else if entries[0].Size != 1 {
 		t.Fatal("unexpected size", entries[0].Size)
-	} else if resp, err := ss.Objects(context.Background(), b2, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), b2, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 1 {
 		t.Fatal("expected 1 entry", len(entries))
 	} else if entries[0].Size != 2 {
 		t.Fatal("unexpected size", entries[0].Size)
-	} else if resp, err := ss.Objects(context.Background(), "", "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), "", "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 2 {
 		t.Fatal("expected 2 entries", len(entries))
 	}
 
 	// Search the objects in the buckets.
-	if resp, err := ss.Objects(context.Background(), b1, "", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	if resp, err := ss.Objects(context.Background(), b1, "", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if objects := resp.Objects; len(objects) != 2 {
 		t.Fatal("expected 2 objects", len(objects))
 	} else if objects[0].Size != 3 || objects[1].Size != 1 {
 		t.Fatal("unexpected size", objects[0].Size, objects[1].Size)
-	} else if resp, err := ss.Objects(context.Background(), b2, "", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), b2, "", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if objects := resp.Objects; len(objects) != 2 {
 		t.Fatal("expected 2 objects", len(objects))
 	} else if objects[0].Size != 4 || objects[1].Size != 2 {
 		t.Fatal("unexpected size", objects[0].Size, objects[1].Size)
-	} else if resp, err := ss.Objects(context.Background(), "", "", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), "", "", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if objects := resp.Objects; len(objects) != 4 {
 		t.Fatal("expected 4 objects", len(objects))
@@ -3171,13 +4236,13 @@ func TestBucketObjects(t *testing.T) {
 	// Rename object foo/bar in bucket 1 to foo/baz but not in bucket 2.
 	if err := ss.RenameObjectBlocking(context.Background(), b1, "/foo/bar", "/foo/baz", false); err != nil {
 		t.Fatal(err)
-	} else if resp, err := ss.Objects(context.Background(), b1, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), b1, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 1 {
 		t.Fatal("expected 2 entries", len(entries))
 	} else if entries[0].Key != "/foo/baz" {
 		t.Fatal("unexpected name", entries[0].Key)
-	} else if resp, err := ss.Objects(context.Background(), b2, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), b2, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 1 {
 		t.Fatal("expected 2 entries", len(entries))
@@ -3188,13 +4253,13 @@ func TestBucketObjects(t *testing.T) {
 	// Rename foo/bar in bucket 2 using the batch rename.
 	if err := ss.RenameObjectsBlocking(context.Background(), b2, "/foo/bar", "/foo/bam", false); err != nil {
 		t.Fatal(err)
-	} else if resp, err := ss.Objects(context.Background(), b1, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), b1, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 1 {
 		t.Fatal("expected 2 entries", len(entries))
 	} else if entries[0].Key != "/foo/baz" {
 		t.Fatal("unexpected name", entries[0].Key)
-	} else if resp, err := ss.Objects(context.Background(), b2, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), b2, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 1 {
 		t.Fatal("expected 2 entries", len(entries))
@@ -3207,28 +4272,28 @@ func TestBucketObjects(t *testing.T) {
 		t.Fatal(err)
 	} else if err := ss.RemoveObjectBlocking(context.Background(), b1, "/foo/baz"); err != nil {
 		t.Fatal(err)
-	} else if resp, err := ss.Objects(context.Background(), b1, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), b1, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) > 0 {
 		t.Fatal("expected 0 entries", len(entries))
-	} else if resp, err := ss.Objects(context.Background(), b2, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), b2, "/foo/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 1 {
 		t.Fatal("expected 1 entry", len(entries))
 	}
 
 	// Delete all files in bucket 2.
-	if resp, err := ss.Objects(context.Background(), b2, "/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	if resp, err := ss.Objects(context.Background(), b2, "/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 2 {
 		t.Fatal("expected 2 entries", len(entries))
 	} else if err := ss.RemoveObjectsBlocking(context.Background(), b2, "/"); err != nil {
 		t.Fatal(err)
-	} else if resp, err := ss.Objects(context.Background(), b2, "/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), b2, "/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 0 {
 		t.Fatal("expected 0 entries", len(entries))
-	} else if resp, err := ss.Objects(context.Background(), b1, "/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(context.Background(), b1, "/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 1 {
 		t.Fatal("expected 1 entry", len(entries))
@@ -3246,11 +4311,11 @@ func TestBucketObjects(t *testing.T) {
 	// See if we can fetch the object by slab.
 	if obj, err := ss.Object(context.Background(), b1, "/bar"); err != nil {
 		t.Fatal(err)
-	} else if res, err := ss.Objects(context.Background(), b1, "", "", "", "", "", "", -1, obj.Slabs[0].EncryptionKey); err != nil {
+	} else if res, err := ss.Objects(context.Background(), b1, "", "", "", "", "", "", -1, obj.Slabs[0].EncryptionKey, nil); err != nil {
 		t.Fatal(err)
 	} else if len(res.Objects) != 1 {
 		t.Fatal("expected 1 object", len(objects))
-	} else if res, err := ss.Objects(context.Background(), b2, "", "", "", "", "", "", -1, obj.Slabs[0].EncryptionKey); err != nil {
+	} else if res, err := ss.Objects(context.Background(), b2, "", "", "", "", "", "", -1, obj.Slabs[0].EncryptionKey, nil); err != nil {
 		t.Fatal(err)
 	} else if len(res.Objects) != 0 {
 		t.Fatal("expected 0 objects", len(objects))
@@ -3263,9 +4328,9 @@ func TestCopyObject(t *testing.T) {
 
 	// Create the buckets.
 	ctx := context.Background()
-	if err := ss.CreateBucket(ctx, "src", api.BucketPolicy{}); err != nil {
+	if err := ss.CreateBucket(ctx, "src", api.BucketPolicy{}, nil); err != nil {
 		t.Fatal(err)
-	} else if err := ss.CreateBucket(ctx, "dst", api.BucketPolicy{}); err != nil {
+	} else if err := ss.CreateBucket(ctx, "dst", api.BucketPolicy{}, nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -3277,9 +4342,9 @@ func TestCopyObject(t *testing.T) {
 	}
 
 	// Copy it within the same bucket.
-	if om, err := ss.CopyObject(ctx, "src", "src", "/foo", "/bar", "", nil); err != nil {
+	if om, err := ss.CopyObject(ctx, "src", "src", "/foo", "/bar", "", nil, false); err != nil {
 		t.Fatal(err)
-	} else if resp, err := ss.Objects(ctx, "src", "/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(ctx, "src", "/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 2 {
 		t.Fatal("expected 2 entries", len(entries))
@@ -3290,9 +4355,9 @@ func TestCopyObject(t *testing.T) {
 	}
 
 	// Copy it cross buckets.
-	if om, err := ss.CopyObject(ctx, "src", "dst", "/foo", "/bar", "", nil); err != nil {
+	if om, err := ss.CopyObject(ctx, "src", "dst", "/foo", "/bar", "", nil, false); err != nil {
 		t.Fatal(err)
-	} else if resp, err := ss.Objects(ctx, "dst", "/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+	} else if resp, err := ss.Objects(ctx, "dst", "/", "", "", "", "", "", -1, object.EncryptionKey{}, nil); err != nil {
 		t.Fatal(err)
 	} else if entries := resp.Objects; len(entries) != 1 {
 		t.Fatal("expected 1 entry", len(entries))
@@ -3301,6 +4366,114 @@ func TestCopyObject(t *testing.T) {
 	} else if om.ModTime.IsZero() {
 		t.Fatal("expected mod time to be set")
 	}
+
+	// Copying onto an existing object fails unless overwrite is set.
+	if _, err := ss.CopyObject(ctx, "src", "dst", "/foo", "/bar", "", nil, false); !errors.Is(err, api.ErrObjectExists) {
+		t.Fatal("expected ErrObjectExists", err)
+	} else if _, err := ss.CopyObject(ctx, "src", "dst", "/foo", "/bar", testMimeType, nil, true); err != nil {
+		t.Fatal(err)
+	} else if om, err := ss.ObjectMetadata(ctx, "dst", "/bar"); err != nil {
+		t.Fatal(err)
+	} else if om.MimeType != testMimeType {
+		t.Fatal("expected object to be overwritten", om.MimeType)
+	}
+
+	// Assert the bucket usage wasn't affected by overwriting the object.
+	if usage, err := ss.BucketUsage(ctx, "dst"); err != nil {
+		t.Fatal(err)
+	} else if usage.ObjectCount != 1 || usage.TotalBytes != int64(obj.TotalSize()) {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+}
+
+func TestBucketUsage(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	ctx := context.Background()
+	if err := ss.CreateBucket(ctx, "dst", api.BucketPolicy{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// assertUsage asserts the usage of a bucket and that it matches a recount
+	assertUsage := func(bucket string, objects, bytes int64) {
+		t.Helper()
+		if usage, err := ss.BucketUsage(ctx, bucket); err != nil {
+			t.Fatal(err)
+		} else if usage.ObjectCount != objects || usage.TotalBytes != bytes {
+			t.Fatalf("unexpected usage for bucket %q, %d != %d || %d != %d", bucket, usage.ObjectCount, objects, usage.TotalBytes, bytes)
+		} else if usage.LastUpdated.IsZero() {
+			t.Fatal("expected last updated to be set")
+		}
+		if usage, err := ss.RecountBucketUsage(ctx, bucket); err != nil {
+			t.Fatal(err)
+		} else if usage.ObjectCount != objects || usage.TotalBytes != bytes {
+			t.Fatalf("unexpected recount for bucket %q, %d != %d || %d != %d", bucket, usage.ObjectCount, objects, usage.TotalBytes, bytes)
+		}
+	}
+
+	// empty buckets have no usage
+	assertUsage(testBucket, 0, 0)
+
+	// add some objects
+	obj := newTestObject(1)
+	size := int64(obj.TotalSize())
+	for _, key := range []string{"/foo", "/dir/foo", "/dir/bar"} {
+		if _, err := ss.addTestObject(key, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertUsage(testBucket, 3, 3*size)
+
+	// overwriting an object doesn't change the usage
+	if _, err := ss.addTestObject("/foo", obj); err != nil {
+		t.Fatal(err)
+	}
+	assertUsage(testBucket, 3, 3*size)
+
+	// copy an object to another bucket
+	if _, err := ss.CopyObject(ctx, testBucket, "dst", "/foo", "/foo", "", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	assertUsage(testBucket, 3, 3*size)
+	assertUsage("dst", 1, size)
+
+	// force rename a directory onto an existing object
+	if err := ss.RenameObjects(ctx, testBucket, "/dir/", "/", true); err != nil {
+		t.Fatal(err)
+	}
+	assertUsage(testBucket, 2, 2*size)
+
+	// remove objects
+	if err := ss.RemoveObject(ctx, testBucket, "/foo"); err != nil {
+		t.Fatal(err)
+	}
+	assertUsage(testBucket, 1, size)
+	if err := ss.RemoveObjects(ctx, testBucket, "/"); err != nil {
+		t.Fatal(err)
+	}
+	assertUsage(testBucket, 0, 0)
+
+	// corrupt the counters and recount
+	if _, err := ss.DB().Exec(ctx, "UPDATE bucket_usage SET object_count = 100, total_bytes = 100"); err != nil {
+		t.Fatal(err)
+	} else if usage, err := ss.BucketUsage(ctx, "dst"); err != nil {
+		t.Fatal(err)
+	} else if usage.ObjectCount != 100 {
+		t.Fatal("expected corrupted counters", usage.ObjectCount)
+	} else if usage, err := ss.RecountBucketUsage(ctx, "dst"); err != nil {
+		t.Fatal(err)
+	} else if usage.ObjectCount != 1 || usage.TotalBytes != size {
+		t.Fatal("unexpected recount", usage)
+	}
+	assertUsage("dst", 1, size)
+
+	// unknown buckets return an error
+	if _, err := ss.BucketUsage(ctx, "unknown"); !errors.Is(err, api.ErrBucketNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if _, err := ss.RecountBucketUsage(ctx, "unknown"); !errors.Is(err, api.ErrBucketNotFound) {
+		t.Fatal("unexpected error", err)
+	}
 }
 
 func TestMarkSlabUploadedAfterRenew(t *testing.T) {
@@ -3444,7 +4617,7 @@ func TestObjectsNoDelimiter(t *testing.T) {
 		}
 	}
 	for _, test := range tests {
-		res, err := ss.Objects(ctx, testBucket, test.prefix, "", "", test.sortBy, test.sortDir, "", -1, object.EncryptionKey{})
+		res, err := ss.Objects(ctx, testBucket, test.prefix, "", "", test.sortBy, test.sortDir, "", -1, object.EncryptionKey{}, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -3459,7 +4632,7 @@ func TestObjectsNoDelimiter(t *testing.T) {
 		if len(res.Objects) > 0 {
 			marker := ""
 			for offset := 0; offset < len(test.want); offset++ {
-				res, err := ss.Objects(ctx, testBucket, test.prefix, "", "", test.sortBy, test.sortDir, marker, 1, object.EncryptionKey{})
+				res, err := ss.Objects(ctx, testBucket, test.prefix, "", "", test.sortBy, test.sortDir, marker, 1, object.EncryptionKey{}, nil)
 				if err != nil {
 					t.Fatal(err)
 				}
@@ -3479,6 +4652,92 @@ func TestObjectsNoDelimiter(t *testing.T) {
 	}
 }
 
+// TestObjectsPagination asserts that paging through a large number of objects
+// using the marker returned by the previous page yields every object exactly
+// once, both with and without a delimiter and for every sort order.
+func TestObjectsPagination(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add objects, some of which are nested in directories
+	ctx := context.Background()
+	want := make(map[string]struct{})
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("/%03d", i)
+		if i%5 == 0 {
+			key = fmt.Sprintf("/dir%d/%03d", i%3, i)
+		}
+		obj := newTestObject(1)
+		obj.Slabs[0].Length = uint32(frand.Intn(10) + 1) // force size collisions
+		if _, err := ss.addTestObject(key, obj); err != nil {
+			t.Fatal(err)
+		}
+		want[key] = struct{}{}
+	}
+
+	// list all entries in a single request to get the expected entries when
+	// using the delimiter
+	res, err := ss.Objects(ctx, testBucket, "/", "", "/", "", "", "", -1, object.EncryptionKey{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if res.HasMore {
+		t.Fatal("expected no more entries")
+	}
+	wantDelim := make(map[string]struct{})
+	for _, entry := range res.Objects {
+		wantDelim[entry.Key] = struct{}{}
+	}
+
+	for _, delim := range []string{"", "/"} {
+		expected := want
+		if delim == "/" {
+			expected = wantDelim
+		}
+		for _, sortBy := range []string{api.ObjectSortByName, api.ObjectSortBySize, api.ObjectSortByHealth} {
+			for _, sortDir := range []string{api.SortDirAsc, api.SortDirDesc} {
+				seen := make(map[string]struct{})
+				var marker string
+				for pages := 0; ; pages++ {
+					if pages > len(expected) {
+						t.Fatalf("too many pages, delim %q, sortBy %v, sortDir %v", delim, sortBy, sortDir)
+					}
+
+					res, err := ss.Objects(ctx, testBucket, "/", "", delim, sortBy, sortDir, marker, 7, object.EncryptionKey{}, nil)
+					if err != nil {
+						t.Fatal(err)
+					} else if len(res.Objects) > 7 {
+						t.Fatalf("expected at most 7 entries, got %v", len(res.Objects))
+					} else if res.HasMore && len(res.Objects) != 7 {
+						t.Fatalf("expected a full page when there are more entries, got %v", len(res.Objects))
+					}
+
+					for _, entry := range res.Objects {
+						if _, ok := seen[entry.Key]; ok {
+							t.Fatalf("duplicate entry %v, delim %q, sortBy %v, sortDir %v", entry.Key, delim, sortBy, sortDir)
+						}
+						seen[entry.Key] = struct{}{}
+					}
+					if !res.HasMore {
+						break
+					} else if res.NextMarker == "" {
+						t.Fatal("expected next marker to be set")
+					}
+					marker = res.NextMarker
+				}
+
+				if len(seen) != len(expected) {
+					t.Fatalf("expected %v entries, got %v, delim %q, sortBy %v, sortDir %v", len(expected), len(seen), delim, sortBy, sortDir)
+				}
+				for key := range expected {
+					if _, ok := seen[key]; !ok {
+						t.Fatalf("missing entry %v, delim %q, sortBy %v, sortDir %v", key, delim, sortBy, sortDir)
+					}
+				}
+			}
+		}
+	}
+}
+
 func TestDeleteHostSector(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -4197,6 +5456,242 @@ func TestRefreshHealth(t *testing.T) {
 	}
 }
 
+func TestObjectCopyOnWrite(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create a bucket with copy-on-write enabled
+	ctx := context.Background()
+	if err := ss.CreateBucket(ctx, "cow", api.BucketPolicy{CopyOnWrite: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// upload an object to both buckets and overwrite it
+	for _, bucket := range []string{testBucket, "cow"} {
+		for i := 0; i < 2; i++ {
+			if err := ss.UpdateObjectBlocking(ctx, bucket, "foo", testETag, testMimeType, testMetadata, newTestObject(1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// the overwritten slab of the copy-on-write bucket should be preserved
+	if n := ss.Count("slabs"); n != 3 {
+		t.Fatalf("expected 3 slabs, got %v", n)
+	} else if n := ss.Count("object_versions"); n != 1 {
+		t.Fatalf("expected 1 object version, got %v", n)
+	} else if n := ss.Count("objects"); n != 2 {
+		t.Fatalf("expected 2 objects, got %v", n)
+	}
+
+	// the current object should be unaffected
+	if _, err := ss.Object(ctx, "cow", "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	// garbage collect
+	ts := time.Now()
+	time.Sleep(time.Millisecond)
+	if deleted, err := ss.GarbageCollect(ctx); err != nil {
+		t.Fatal(err)
+	} else if deleted != 1 {
+		t.Fatalf("expected 1 deleted version, got %v", deleted)
+	} else if err := ss.waitForSlabPruneLoop(ts); err != nil {
+		t.Fatal(err)
+	}
+
+	// the preserved slab should be pruned
+	if n := ss.Count("slabs"); n != 2 {
+		t.Fatalf("expected 2 slabs, got %v", n)
+	} else if n := ss.Count("object_versions"); n != 0 {
+		t.Fatalf("expected 0 object versions, got %v", n)
+	}
+
+	// garbage collecting again is a no-op
+	if deleted, err := ss.GarbageCollect(ctx); err != nil {
+		t.Fatal(err)
+	} else if deleted != 0 {
+		t.Fatalf("expected 0 deleted versions, got %v", deleted)
+	}
+}
+
+func TestObjectVersionRetention(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create two copy-on-write buckets, one of which retains versions
+	ctx := context.Background()
+	if err := ss.CreateBucket(ctx, "cow", api.BucketPolicy{CopyOnWrite: true}, nil); err != nil {
+		t.Fatal(err)
+	} else if err := ss.CreateBucket(ctx, "retained", api.BucketPolicy{CopyOnWrite: true, VersionRetention: api.DurationMS(time.Hour)}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bucket := range []string{"cow", "retained"} {
+		// upload two objects
+		for _, key := range []string{"foo", "bar"} {
+			if err := ss.UpdateObjectBlocking(ctx, bucket, key, testETag, testMimeType, testMetadata, newTestObject(1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// overwrite one by copying the other onto it
+		if _, err := ss.CopyObject(ctx, bucket, bucket, "bar", "foo", testMimeType, testMetadata, true); err != nil {
+			t.Fatal(err)
+		}
+
+		// overwrite the other by completing a multipart upload
+		resp, err := ss.CreateMultipartUpload(ctx, bucket, "bar", object.NoOpKey, testMimeType, testMetadata)
+		if err != nil {
+			t.Fatal(err)
+		} else if _, err := ss.CompleteMultipartUpload(ctx, bucket, "bar", resp.UploadID, []api.MultipartCompletedPart{}, api.CompleteMultipartOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// both overwrites should have preserved a version in both buckets
+	if n := ss.Count("object_versions"); n != 4 {
+		t.Fatalf("expected 4 object versions, got %v", n)
+	}
+
+	// garbage collecting should only delete the versions that aren't retained
+	if deleted, err := ss.GarbageCollect(ctx); err != nil {
+		t.Fatal(err)
+	} else if deleted != 2 {
+		t.Fatalf("expected 2 deleted versions, got %v", deleted)
+	} else if n := ss.Count("object_versions"); n != 2 {
+		t.Fatalf("expected 2 object versions, got %v", n)
+	}
+
+	// once the retention is lowered, the remaining versions are deleted too
+	if err := ss.UpdateBucketPolicy(ctx, "retained", api.BucketPolicy{CopyOnWrite: true}); err != nil {
+		t.Fatal(err)
+	} else if deleted, err := ss.GarbageCollect(ctx); err != nil {
+		t.Fatal(err)
+	} else if deleted != 2 {
+		t.Fatalf("expected 2 deleted versions, got %v", deleted)
+	}
+}
+
+func TestObjectVersionRestore(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create a copy-on-write bucket that retains its versions
+	ctx := context.Background()
+	if err := ss.CreateBucket(ctx, "cow", api.BucketPolicy{CopyOnWrite: true, VersionRetention: api.DurationMS(time.Hour)}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// upload an object and overwrite it
+	obj1, obj2 := newTestObject(1), newTestObject(1)
+	for _, o := range []object.Object{obj1, obj2} {
+		if err := ss.UpdateObjectBlocking(ctx, "cow", "foo", testETag, testMimeType, testMetadata, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the first upload should be listed as a version
+	versions, err := ss.ObjectVersions(ctx, "cow", "foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(versions) != 1 {
+		t.Fatalf("expected 1 version, got %v", len(versions))
+	} else if v := versions[0]; v.Bucket != "cow" || v.Key != "foo" || v.Size != int64(obj1.TotalSize()) || v.ETag != testETag || v.MimeType != testMimeType || v.CreatedAt.Std().IsZero() {
+		t.Fatalf("unexpected version %+v", v)
+	}
+
+	// other objects and unknown buckets have no versions
+	if versions, err := ss.ObjectVersions(ctx, "cow", "bar"); err != nil {
+		t.Fatal(err)
+	} else if len(versions) != 0 {
+		t.Fatalf("expected no versions, got %v", len(versions))
+	} else if _, err := ss.ObjectVersions(ctx, "unknown", "foo"); !errors.Is(err, api.ErrBucketNotFound) {
+		t.Fatalf("expected ErrBucketNotFound, got %v", err)
+	}
+
+	// restoring an unknown version should leave the object untouched
+	if err := ss.RestoreObjectVersion(ctx, "cow", "foo", math.MaxInt64); !errors.Is(err, api.ErrObjectVersionNotFound) {
+		t.Fatalf("expected ErrObjectVersionNotFound, got %v", err)
+	} else if err := ss.RestoreObjectVersion(ctx, "cow", "bar", versions[0].ID); !errors.Is(err, api.ErrObjectVersionNotFound) {
+		t.Fatalf("expected ErrObjectVersionNotFound, got %v", err)
+	} else if n := ss.Count("object_versions"); n != 1 {
+		t.Fatalf("expected 1 object version, got %v", n)
+	}
+
+	// restore the first upload
+	if err := ss.RestoreObjectVersion(ctx, "cow", "foo", versions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ss.Object(ctx, "cow", "foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if got.Object.Key.String() != obj1.Key.String() {
+		t.Fatal("unexpected object key")
+	} else if len(got.Slabs) != 1 || got.Slabs[0].EncryptionKey.String() != obj1.Slabs[0].EncryptionKey.String() {
+		t.Fatal("unexpected slabs")
+	} else if got.Size != int64(obj1.TotalSize()) {
+		t.Fatalf("unexpected size %v", got.Size)
+	}
+
+	// the second upload should have been preserved in turn
+	restored := versions[0]
+	if versions, err := ss.ObjectVersions(ctx, "cow", "foo"); err != nil {
+		t.Fatal(err)
+	} else if len(versions) != 1 {
+		t.Fatalf("expected 1 version, got %v", len(versions))
+	} else if versions[0].ID == restored.ID || versions[0].Size != int64(obj2.TotalSize()) {
+		t.Fatalf("unexpected version %+v", versions[0])
+	}
+
+	// no slabs should have been pruned and the usage should be accurate
+	if err := ss.waitForSlabPruneLoop(time.Now()); err != nil {
+		t.Fatal(err)
+	} else if n := ss.Count("slabs"); n != 2 {
+		t.Fatalf("expected 2 slabs, got %v", n)
+	} else if n := ss.Count("objects"); n != 1 {
+		t.Fatalf("expected 1 object, got %v", n)
+	} else if usage, err := ss.BucketUsage(ctx, "cow"); err != nil {
+		t.Fatal(err)
+	} else if usage.ObjectCount != 1 || usage.TotalBytes != int64(obj1.TotalSize()) {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+}
+
+func TestObjectVersionGCLoop(t *testing.T) {
+	cfg := defaultTestSQLStoreConfig
+	cfg.objectVersionGCInterval = 100 * time.Millisecond
+	ss := newTestSQLStore(t, cfg)
+	defer ss.Close()
+
+	// create a copy-on-write bucket
+	ctx := context.Background()
+	if err := ss.CreateBucket(ctx, "cow", api.BucketPolicy{CopyOnWrite: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// upload an object and overwrite it
+	for i := 0; i < 2; i++ {
+		if err := ss.UpdateObjectBlocking(ctx, "cow", "foo", testETag, testMimeType, testMetadata, newTestObject(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the version should be garbage collected and its slab pruned without
+	// anyone calling GarbageCollect
+	err := test.Retry(100, 100*time.Millisecond, func() error {
+		if n := ss.Count("object_versions"); n != 0 {
+			return fmt.Errorf("expected 0 object versions, got %v", n)
+		} else if n := ss.Count("slabs"); n != 1 {
+			return fmt.Errorf("expected 1 slab, got %v", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestSlabCleanup(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -4737,16 +6232,17 @@ func TestPutContract(t *testing.T) {
 		ID:      types.FileContractID{1},
 		HostKey: hk,
 
-		ProofHeight:    2,
-		RenewedFrom:    types.FileContractID{3},
-		RevisionHeight: 4,
-		RevisionNumber: 5,
-		Size:           6,
-		StartHeight:    7,
-		State:          api.ContractStateComplete,
-		Usability:      api.ContractUsabilityGood,
-		WindowStart:    8,
-		WindowEnd:      9,
+		ProofHeight:        2,
+		RenewedFrom:        types.FileContractID{3},
+		RevisionHeight:     4,
+		RevisionNumber:     5,
+		Size:               6,
+		StartHeight:        7,
+		State:              api.ContractStateComplete,
+		Usability:          api.ContractUsabilityGood,
+		VerificationStatus: api.ContractVerificationStatusUnverified,
+		WindowStart:        8,
+		WindowEnd:          9,
 
 		ContractPrice:      types.NewCurrency64(10),
 		InitialRenterFunds: types.NewCurrency64(11),
@@ -4777,16 +6273,17 @@ func TestPutContract(t *testing.T) {
 		ID:      types.FileContractID{1},
 		HostKey: hk,
 
-		ProofHeight:    17,
-		RenewedFrom:    types.FileContractID{18},
-		RevisionHeight: 19,
-		RevisionNumber: 20,
-		Size:           21,
-		StartHeight:    22,
-		State:          api.ContractStateFailed,
-		Usability:      api.ContractUsabilityGood,
-		WindowStart:    23,
-		WindowEnd:      24,
+		ProofHeight:        17,
+		RenewedFrom:        types.FileContractID{18},
+		RevisionHeight:     19,
+		RevisionNumber:     20,
+		Size:               21,
+		StartHeight:        22,
+		State:              api.ContractStateFailed,
+		Usability:          api.ContractUsabilityGood,
+		VerificationStatus: api.ContractVerificationStatusUnverified,
+		WindowStart:        23,
+		WindowEnd:          24,
 
 		ContractPrice:      types.NewCurrency64(25),
 		InitialRenterFunds: types.NewCurrency64(26),
@@ -4939,3 +6436,109 @@ func TestHostSectors(t *testing.T) {
 		t.Fatal("expected updated at to change")
 	}
 }
+
+// TestPruneHostSectorsCursor asserts host sectors that share a sector with the
+// last host sector of a batch aren't skipped by the next batch.
+func TestPruneHostSectorsCursor(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add three hosts and a contract with the first one
+	hks := []types.PublicKey{{1}, {2}, {3}}
+	for _, hk := range hks {
+		if err := insertHost(ss.DB(), hk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fcid := types.FileContractID{1}
+	if _, err := insertContract(ss.DB(), hks[0], fcid, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	// store every sector on all three hosts
+	for hostID := 2; hostID <= len(hks); hostID++ {
+		if _, err := ss.DB().Exec(context.Background(), "INSERT INTO host_sectors (updated_at, db_sector_id, db_host_id) SELECT updated_at, db_sector_id, ? FROM host_sectors WHERE db_host_id = 1", hostID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := ss.Count("host_sectors"); n != 12 {
+		t.Fatalf("expected 12 host sectors, got %d", n)
+	}
+
+	// archive the contract to make all host sectors prunable
+	if err := ss.db.Transaction(context.Background(), func(tx sql.DatabaseTx) error {
+		return tx.ArchiveContract(context.Background(), fcid, api.ContractArchivalReasonRenewed)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// prune in batches that don't align with the sectors, every batch but
+	// the last one should be full
+	var cursor sql.HostSectorCursor
+	for remaining := int64(12); remaining > 0; remaining -= 5 {
+		var deleted int64
+		if err := ss.db.Transaction(context.Background(), func(tx sql.DatabaseTx) (err error) {
+			deleted, cursor, err = tx.PruneHostSectors(context.Background(), cursor, 5)
+			return
+		}); err != nil {
+			t.Fatal(err)
+		} else if deleted != min(remaining, 5) {
+			t.Fatalf("expected %d deleted host sectors, got %d", min(remaining, 5), deleted)
+		}
+	}
+	if cursor != (sql.HostSectorCursor{}) {
+		t.Fatal("expected cursor to be reset", cursor)
+	} else if n := ss.Count("host_sectors"); n != 0 {
+		t.Fatalf("expected 0 host sectors, got %d", n)
+	}
+}
+
+func TestPruneHostSectorsPerRun(t *testing.T) {
+	cfg := defaultTestSQLStoreConfig
+	cfg.maxHostSectorPrunePerRun = 3
+	ss := newTestSQLStore(t, cfg)
+	defer ss.Close()
+
+	// add two hosts with a contract each
+	hk1, hk2 := types.PublicKey{1}, types.PublicKey{2}
+	fcid1, fcid2 := types.FileContractID{1}, types.FileContractID{2}
+	for _, hk := range []types.PublicKey{hk1, hk2} {
+		if err := insertHost(ss.DB(), hk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := insertContract(ss.DB(), hk1, fcid1, 5); err != nil {
+		t.Fatal(err)
+	} else if _, err := insertContract(ss.DB(), hk2, fcid2, 2); err != nil {
+		t.Fatal(err)
+	} else if n := ss.Count("host_sectors"); n != 7 {
+		t.Fatalf("expected 7 host sectors, got %d", n)
+	}
+
+	// archive the first contract, the first run should only prune 3 host
+	// sectors before yielding
+	ts := time.Now()
+	time.Sleep(time.Millisecond)
+	if err := ss.ArchiveContract(context.Background(), fcid1, api.ContractArchivalReasonRenewed); err != nil {
+		t.Fatal(err)
+	}
+	if err := test.Retry(100, 10*time.Millisecond, func() error {
+		if stats := ss.HostSectorPruneStats(); stats.PrunedThisCycle != 3 || stats.Remaining != 2 {
+			return fmt.Errorf("unexpected stats %+v", stats)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	} else if n := ss.Count("host_sectors"); n != 4 {
+		t.Fatalf("expected 4 host sectors, got %d", n)
+	}
+
+	// the loop continues where it left off after yielding
+	if err := ss.waitForHostSectorPruneLoop(ts); err != nil {
+		t.Fatal(err)
+	} else if stats := ss.HostSectorPruneStats(); stats.PrunedThisCycle != 5 || stats.Remaining != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	} else if n := ss.Count("host_sectors"); n != 2 {
+		t.Fatalf("expected 2 host sectors, got %d", n)
+	}
+}
@@ -4,12 +4,13 @@ import (
 	"context"
 	"time"
 
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/api"
 	sql "go.sia.tech/renterd/v2/stores/sql"
 )
 
 func (s *SQLStore) ContractMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractMetricsQueryOpts) (metrics []api.ContractMetric, err error) {
-	err = s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
+	err = s.metricsTransaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
 		metrics, txErr = tx.ContractMetrics(ctx, start, n, interval, opts)
 		return
 	})
@@ -17,33 +18,41 @@ func (s *SQLStore) ContractMetrics(ctx context.Context, start time.Time, n uint6
 }
 
 func (s *SQLStore) ContractPruneMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractPruneMetricsQueryOpts) (metrics []api.ContractPruneMetric, err error) {
-	err = s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
+	err = s.metricsTransaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
 		metrics, txErr = tx.ContractPruneMetrics(ctx, start, n, interval, opts)
 		return
 	})
 	return
 }
 
+func (s *SQLStore) ContractSpendingHistory(ctx context.Context, fcid types.FileContractID, from, to time.Time, interval time.Duration) (buckets []api.ContractSpendingBucket, err error) {
+	err = s.metricsTransaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
+		buckets, txErr = tx.ContractSpendingHistory(ctx, fcid, from, to, interval)
+		return
+	})
+	return
+}
+
 func (s *SQLStore) RecordContractMetric(ctx context.Context, metrics ...api.ContractMetric) error {
-	return s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) error {
+	return s.metricsTransaction(ctx, func(tx sql.MetricsDatabaseTx) error {
 		return tx.RecordContractMetric(ctx, metrics...)
 	})
 }
 
 func (s *SQLStore) RecordContractPruneMetric(ctx context.Context, metrics ...api.ContractPruneMetric) error {
-	return s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) error {
+	return s.metricsTransaction(ctx, func(tx sql.MetricsDatabaseTx) error {
 		return tx.RecordContractPruneMetric(ctx, metrics...)
 	})
 }
 
 func (s *SQLStore) RecordWalletMetric(ctx context.Context, metrics ...api.WalletMetric) error {
-	return s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) error {
+	return s.metricsTransaction(ctx, func(tx sql.MetricsDatabaseTx) error {
 		return tx.RecordWalletMetric(ctx, metrics...)
 	})
 }
 
 func (s *SQLStore) WalletMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.WalletMetricsQueryOpts) (metrics []api.WalletMetric, err error) {
-	err = s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
+	err = s.metricsTransaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
 		metrics, txErr = tx.WalletMetrics(ctx, start, n, interval, opts)
 		return
 	})
@@ -51,7 +60,7 @@ func (s *SQLStore) WalletMetrics(ctx context.Context, start time.Time, n uint64,
 }
 
 func (s *SQLStore) PruneMetrics(ctx context.Context, metric string, cutoff time.Time) error {
-	return s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) error {
+	return s.metricsTransaction(ctx, func(tx sql.MetricsDatabaseTx) error {
 		return tx.PruneMetrics(ctx, metric, cutoff)
 	})
 }
@@ -2,7 +2,9 @@ package stores
 
 import (
 	"context"
+	"errors"
 	"math"
+	"reflect"
 	"sort"
 	"testing"
 	"time"
@@ -235,6 +237,80 @@ func TestContractPruneMetrics(t *testing.T) {
 	}
 }
 
+func TestContractSpendingHistory(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// record cumulative spending for a contract, the first metric is recorded
+	// before the queried range and serves as the baseline
+	fcid := types.FileContractID{1}
+	start := time.Now().Truncate(time.Hour).Add(-24 * time.Hour)
+	for _, m := range []struct {
+		offset  time.Duration
+		uploads uint64
+		deletes uint64
+	}{
+		{-time.Hour, 10, 1},
+		{10 * time.Minute, 15, 1},
+		{20 * time.Minute, 20, 2},
+		{2*time.Hour + 10*time.Minute, 50, 5},
+	} {
+		if err := ss.RecordContractMetric(context.Background(), api.ContractMetric{
+			Timestamp:      api.TimeRFC3339(start.Add(m.offset)),
+			ContractID:     fcid,
+			UploadSpending: types.NewCurrency64(m.uploads),
+			DeleteSpending: types.NewCurrency64(m.deletes),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// record spending for another contract which should be ignored
+	if err := ss.RecordContractMetric(context.Background(), api.ContractMetric{
+		Timestamp:      api.TimeRFC3339(start.Add(10 * time.Minute)),
+		ContractID:     types.FileContractID{2},
+		UploadSpending: types.NewCurrency64(100),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// fetch hourly buckets
+	buckets, err := ss.ContractSpendingHistory(context.Background(), fcid, start, start.Add(3*time.Hour), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(buckets) != 3 {
+		t.Fatalf("expected 3 buckets, got %v", len(buckets))
+	}
+	expected := []api.ContractSpending{
+		{Uploads: types.NewCurrency64(10), Deletions: types.NewCurrency64(1)},
+		{},
+		{Uploads: types.NewCurrency64(30), Deletions: types.NewCurrency64(3)},
+	}
+	for i, b := range buckets {
+		if !b.Timestamp.Std().Equal(start.Add(time.Duration(i) * time.Hour)) {
+			t.Fatalf("unexpected timestamp for bucket %d: %v", i, b.Timestamp)
+		} else if !reflect.DeepEqual(b.ContractSpending, expected[i]) {
+			t.Fatalf("unexpected spending for bucket %d: %+v != %+v", i, b.ContractSpending, expected[i])
+		}
+	}
+
+	// fetch a single daily bucket
+	buckets, err = ss.ContractSpendingHistory(context.Background(), fcid, start, start.Add(24*time.Hour), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(buckets) != 1 {
+		t.Fatalf("expected 1 bucket, got %v", len(buckets))
+	} else if !buckets[0].Uploads.Equals(types.NewCurrency64(40)) {
+		t.Fatalf("unexpected upload spending %v", buckets[0].Uploads)
+	}
+
+	// assert too many buckets are rejected
+	_, err = ss.ContractSpendingHistory(context.Background(), fcid, start, start.Add(api.MetricMaxIntervals*time.Hour+time.Minute), time.Hour)
+	if !errors.Is(err, api.ErrMaxIntervalsExceeded) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
 func TestNormaliseTimestamp(t *testing.T) {
 	tests := []struct {
 		start    time.Time
@@ -12,7 +12,7 @@ import (
 
 func (s *SQLStore) CreateMultipartUpload(ctx context.Context, bucket, key string, ec object.EncryptionKey, mimeType string, metadata api.ObjectUserMetadata) (api.MultipartCreateResponse, error) {
 	var uploadID string
-	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	err := s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		uploadID, err = tx.InsertMultipartUpload(ctx, bucket, key, ec, mimeType, metadata)
 		return
 	})
@@ -25,13 +25,13 @@ func (s *SQLStore) CreateMultipartUpload(ctx context.Context, bucket, key string
 }
 
 func (s *SQLStore) AddMultipartPart(ctx context.Context, bucket, key, eTag, uploadID string, partNumber int, slices []object.SlabSlice) (err error) {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.AddMultipartPart(ctx, bucket, key, eTag, uploadID, partNumber, slices)
 	})
 }
 
 func (s *SQLStore) MultipartUpload(ctx context.Context, uploadID string) (resp api.MultipartUpload, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		resp, err = tx.MultipartUpload(ctx, uploadID)
 		return
 	})
@@ -39,7 +39,7 @@ func (s *SQLStore) MultipartUpload(ctx context.Context, uploadID string) (resp a
 }
 
 func (s *SQLStore) MultipartUploads(ctx context.Context, bucket, prefix, keyMarker, uploadIDMarker string, limit int) (resp api.MultipartListUploadsResponse, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		resp, err = tx.MultipartUploads(ctx, bucket, prefix, keyMarker, uploadIDMarker, limit)
 		return
 	})
@@ -47,7 +47,7 @@ func (s *SQLStore) MultipartUploads(ctx context.Context, bucket, prefix, keyMark
 }
 
 func (s *SQLStore) MultipartUploadParts(ctx context.Context, bucket, object string, uploadID string, marker int, limit int64) (resp api.MultipartListPartsResponse, _ error) {
-	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+	err := s.transaction(ctx, func(tx sql.DatabaseTx) (err error) {
 		resp, err = tx.MultipartUploadParts(ctx, bucket, object, uploadID, marker, limit)
 		return
 	})
@@ -55,7 +55,7 @@ func (s *SQLStore) MultipartUploadParts(ctx context.Context, bucket, object stri
 }
 
 func (s *SQLStore) AbortMultipartUpload(ctx context.Context, bucket, key string, uploadID string) error {
-	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err := s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.AbortMultipartUpload(ctx, bucket, key, uploadID)
 	})
 	if err != nil {
@@ -80,9 +80,9 @@ func (s *SQLStore) CompleteMultipartUpload(ctx context.Context, bucket, key stri
 
 	var eTag string
 	var prune bool
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		// Delete potentially existing object.
-		prune, err = tx.DeleteObject(ctx, bucket, key)
+		prune, err = overwriteObject(ctx, tx, bucket, key)
 		if err != nil {
 			return fmt.Errorf("failed to delete object: %w", err)
 		}
@@ -65,7 +65,7 @@ func (s *SQLStore) fetchSetting(ctx context.Context, key string, out interface{}
 
 	// fetch setting from database
 	var err error
-	if err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	if err := s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		value, err = tx.Setting(ctx, key)
 		return err
 	}); err != nil {
@@ -94,7 +94,7 @@ func (s *SQLStore) updateSetting(ctx context.Context, key string, value any) err
 	}
 
 	// update db first
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.UpdateSetting(ctx, key, string(b))
 	})
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -30,11 +31,13 @@ type SlabBuffer struct {
 	slabKey  object.EncryptionKey
 	maxSize  int64
 
-	mu          sync.Mutex
-	file        *os.File
-	lockedUntil time.Time
-	size        int64
-	syncErr     error
+	mu            sync.Mutex
+	file          *os.File
+	createdAt     time.Time
+	lastWrittenAt time.Time
+	lockedUntil   time.Time
+	size          int64
+	syncErr       error
 }
 
 type bufferGroupID [2]byte
@@ -92,9 +95,11 @@ func newSlabBufferManager(ctx context.Context, a alerts.Alerter, db sql.Database
 		file, err := os.OpenFile(filepath.Join(partialSlabDir, buffer.Filename), os.O_RDWR, 0600)
 		if err != nil {
 			_ = a.RegisterAlert(ctx, alerts.Alert{
-				ID:       types.HashBytes([]byte(buffer.Filename)),
-				Severity: alerts.SeverityCritical,
-				Message:  "failed to read buffer file on startup",
+				ID:          types.HashBytes([]byte(buffer.Filename)),
+				Severity:    alerts.SeverityCritical,
+				Message:     "failed to read buffer file on startup",
+				Description: fmt.Sprintf("Buffer file %v holding partial slab data for slab %v could not be opened on startup.", buffer.Filename, buffer.Key),
+				Suggestion:  "Make sure the file exists in the partial slab directory and is readable by renterd, otherwise the data of the partial slab is lost.",
 				Data: map[string]interface{}{
 					"filename": buffer.Filename,
 					"slabKey":  buffer.Key,
@@ -105,14 +110,23 @@ func newSlabBufferManager(ctx context.Context, a alerts.Alerter, db sql.Database
 			continue
 		}
 
+		// Use the file's modification time as the last time it was written
+		// to.
+		lastWrittenAt := buffer.CreatedAt
+		if fi, err := file.Stat(); err == nil {
+			lastWrittenAt = fi.ModTime()
+		}
+
 		// Create the slab buffer.
 		sb := &SlabBuffer{
-			dbID:     uint(buffer.ID),
-			filename: buffer.Filename,
-			slabKey:  buffer.Key,
-			maxSize:  int64(bufferedSlabSize(buffer.MinShards)),
-			file:     file,
-			size:     buffer.Size,
+			dbID:          uint(buffer.ID),
+			filename:      buffer.Filename,
+			slabKey:       buffer.Key,
+			maxSize:       int64(bufferedSlabSize(buffer.MinShards)),
+			file:          file,
+			createdAt:     buffer.CreatedAt,
+			lastWrittenAt: lastWrittenAt,
+			size:          buffer.Size,
 		}
 		// Add the buffer to the manager.
 		gid := bufferGID(buffer.MinShards, buffer.TotalShards)
@@ -289,6 +303,56 @@ func (mgr *SlabBufferManager) SlabBuffers() (sbs []api.SlabBuffer) {
 	return sbs
 }
 
+// ListBuffers returns metadata about all slab buffers, sorted by the time they
+// were last written to in descending order.
+func (mgr *SlabBufferManager) ListBuffers(ctx context.Context) ([]api.SlabBufferInfo, error) {
+	// Fetch buffers.
+	mgr.mu.Lock()
+	buffers := make([]*SlabBuffer, 0, len(mgr.buffersByKey))
+	for _, buffer := range mgr.buffersByKey {
+		buffers = append(buffers, buffer)
+	}
+	mgr.mu.Unlock()
+
+	// Fetch the objects referencing the buffers.
+	var objects map[int64][]string
+	if err := mgr.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+		objects, err = tx.SlabBufferObjects(ctx)
+		return
+	}); err != nil {
+		return nil, fmt.Errorf("failed to fetch slab buffer objects: %w", err)
+	}
+
+	// Convert them.
+	infos := make([]api.SlabBufferInfo, 0, len(buffers))
+	for _, buffer := range buffers {
+		buffer.mu.Lock()
+		info := api.SlabBufferInfo{
+			ID:                   buffer.dbID,
+			Filename:             buffer.filename,
+			CompletionThreshold:  mgr.bufferedSlabCompletionThreshold,
+			CreatedAt:            api.TimeRFC3339(buffer.createdAt),
+			LastWrittenAt:        api.TimeRFC3339(buffer.lastWrittenAt),
+			AssociatedObjectKeys: objects[int64(buffer.dbID)],
+		}
+		buffer.mu.Unlock()
+		if info.AssociatedObjectKeys == nil {
+			info.AssociatedObjectKeys = []string{}
+		}
+
+		fi, err := buffer.file.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat buffer %v: %w", buffer.filename, err)
+		}
+		info.SizeBytesOnDisk = fi.Size()
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return time.Time(infos[i].LastWrittenAt).After(time.Time(infos[j].LastWrittenAt))
+	})
+	return infos, nil
+}
+
 func (mgr *SlabBufferManager) SlabsForUpload(ctx context.Context, lockingDuration time.Duration, minShards, totalShards uint8, limit int) (slabs []api.PackedSlab, _ error) {
 	// Deep copy complete buffers. We don't want to block the manager while we
 	// perform disk I/O.
@@ -304,9 +368,11 @@ func (mgr *SlabBufferManager) SlabsForUpload(ctx context.Context, lockingDuratio
 		_, err := buffer.file.ReadAt(data, 0)
 		if err != nil {
 			mgr.alerts.RegisterAlert(ctx, alerts.Alert{
-				ID:       types.HashBytes([]byte(buffer.filename)),
-				Severity: alerts.SeverityCritical,
-				Message:  "failed to read data from buffer",
+				ID:          types.HashBytes([]byte(buffer.filename)),
+				Severity:    alerts.SeverityCritical,
+				Message:     "failed to read data from buffer",
+				Description: fmt.Sprintf("Buffer file %v holding partial slab data for slab %v could not be read.", buffer.filename, buffer.slabKey),
+				Suggestion:  "Check the disk holding the partial slab directory for errors, the partial slab will not be uploaded until its buffer can be read.",
 				Data: map[string]interface{}{
 					"filename": buffer.filename,
 					"slabKey":  buffer.slabKey,
@@ -389,6 +455,7 @@ func (buf *SlabBuffer) recordAppend(data []byte, mustFit bool, minShards uint8,
 			Length: uint32(len(data)),
 		}
 		buf.size += int64(len(data))
+		buf.lastWrittenAt = time.Now()
 		return slab, nil, true, nil
 	} else if !mustFit {
 		_, err := buf.file.WriteAt(data[:remainingSpace], buf.size)
@@ -401,6 +468,7 @@ func (buf *SlabBuffer) recordAppend(data []byte, mustFit bool, minShards uint8,
 			Length: uint32(remainingSpace),
 		}
 		buf.size += remainingSpace
+		buf.lastWrittenAt = time.Now()
 		return slab, data[remainingSpace:], true, nil
 	} else {
 		return object.SlabSlice{}, data, false, nil
@@ -469,11 +537,14 @@ func createSlabBuffer(ctx context.Context, tx sql.DatabaseTx, dir string, minSha
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert buffered slab: %w", err)
 	}
+	now := time.Now()
 	return &SlabBuffer{
-		dbID:     uint(bufferedSlabID),
-		filename: fileName,
-		slabKey:  ec,
-		maxSize:  int64(bufferedSlabSize(minShards)),
-		file:     file,
+		dbID:          uint(bufferedSlabID),
+		filename:      fileName,
+		slabKey:       ec,
+		maxSize:       int64(bufferedSlabSize(minShards)),
+		file:          file,
+		createdAt:     now,
+		lastWrittenAt: now,
 	}, err
 }
@@ -2,6 +2,7 @@ package stores
 
 import (
 	"context"
+	dsql "database/sql"
 	"fmt"
 	"os"
 	"sync"
@@ -9,6 +10,8 @@ import (
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/alerts"
+	"go.sia.tech/renterd/v2/api"
+	isql "go.sia.tech/renterd/v2/internal/sql"
 	"go.sia.tech/renterd/v2/stores/sql"
 	"go.uber.org/zap"
 )
@@ -26,7 +29,36 @@ type (
 		SlabBufferCompletionThreshold int64
 		Logger                        *zap.Logger
 		LongQueryDuration             time.Duration
-		LongTxDuration                time.Duration
+		Pool                          PoolConfig
+
+		// LongTxDuration is the maximum duration of a transaction executed
+		// by the store, transactions that take longer are rolled back and
+		// fail with isql.ErrTransactionTimeout. Zero disables the timeout.
+		LongTxDuration time.Duration
+
+		// MaxHostSectorPrunePerRun is the maximum number of host sectors
+		// that are pruned per run of the host sector prune loop, 0 means
+		// there is no limit.
+		MaxHostSectorPrunePerRun int
+
+		// ExemptActiveContractHosts prevents offline hosts with pending or
+		// active contracts from being removed.
+		ExemptActiveContractHosts bool
+
+		// ObjectVersionGCInterval is the interval at which the slab prune
+		// loop deletes the object versions that exceeded the version
+		// retention of their bucket, 0 disables it.
+		ObjectVersionGCInterval time.Duration
+	}
+
+	// PoolConfig contains the connection pool settings applied to both the
+	// main and the metrics database. Zero values leave the defaults of the
+	// underlying sql.DB in place.
+	PoolConfig struct {
+		MaxOpenConns    int
+		MaxIdleConns    int
+		ConnMaxLifetime time.Duration
+		ConnMaxIdleTime time.Duration
 	}
 
 	Explorer interface {
@@ -42,6 +74,11 @@ type (
 
 		walletAddress types.Address
 
+		longTxDuration            time.Duration
+		maxHostSectorPrunePerRun  int64
+		exemptActiveContractHosts bool
+		objectVersionGCInterval   time.Duration
+
 		// ObjectDB related fields
 		slabBufferMgr *SlabBufferManager
 
@@ -59,6 +96,7 @@ type (
 		mu                      sync.Mutex
 		lastPrunedHostSectorsAt time.Time
 		lastPrunedSlabsAt       time.Time
+		hostSectorPruneStats    api.HostSectorPruneStats
 		closed                  bool
 	}
 )
@@ -81,6 +119,10 @@ func NewSQLStore(cfg Config) (*SQLStore, error) {
 	}
 	l.Sugar().Infof("Using %s version %s", dbName, dbVersion)
 
+	// Apply connection pool settings.
+	cfg.Pool.apply(dbMain.DB().DB())
+	cfg.Pool.apply(dbMetrics.DB().DB())
+
 	// Perform migrations.
 	if cfg.Migrate {
 		if err := dbMain.Migrate(context.Background()); err != nil {
@@ -100,6 +142,11 @@ func NewSQLStore(cfg Config) (*SQLStore, error) {
 		settings:      make(map[string]string),
 		walletAddress: cfg.WalletAddress,
 
+		longTxDuration:            cfg.LongTxDuration,
+		maxHostSectorPrunePerRun:  int64(cfg.MaxHostSectorPrunePerRun),
+		exemptActiveContractHosts: cfg.ExemptActiveContractHosts,
+		objectVersionGCInterval:   cfg.ObjectVersionGCInterval,
+
 		hostSectorPruneSigChan: make(chan struct{}, 1),
 		slabPruneSigChan:       make(chan struct{}, 1),
 
@@ -124,6 +171,21 @@ func NewSQLStore(cfg Config) (*SQLStore, error) {
 	return ss, nil
 }
 
+func (cfg PoolConfig) apply(db *dsql.DB) {
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+}
+
 func (s *SQLStore) initPruneLoops() {
 	s.wg.Add(1)
 	go func() {
@@ -160,3 +222,17 @@ func (s *SQLStore) Close() error {
 	s.mu.Unlock()
 	return nil
 }
+
+// transaction executes fn within a transaction on the main database, every
+// attempt of the transaction is rolled back if it exceeds the store's
+// LongTxDuration.
+func (s *SQLStore) transaction(ctx context.Context, fn func(tx sql.DatabaseTx) error) error {
+	return s.db.Transaction(isql.WithTransactionTimeout(ctx, s.longTxDuration), fn)
+}
+
+// metricsTransaction executes fn within a transaction on the metrics
+// database, every attempt of the transaction is rolled back if it exceeds the
+// store's LongTxDuration.
+func (s *SQLStore) metricsTransaction(ctx context.Context, fn func(tx sql.MetricsDatabaseTx) error) error {
+	return s.dbMetrics.Transaction(isql.WithTransactionTimeout(ctx, s.longTxDuration), fn)
+}
@@ -18,6 +18,69 @@ var (
 	ErrOutputNotFound = errors.New("output not found")
 )
 
+// ArchiveExpiredContracts archives the contracts whose window ended before the
+// given height. Active contracts are marked as complete if a proof was seen and
+// as failed otherwise. The IDs of failed contracts that still contained data
+// are returned.
+func ArchiveExpiredContracts(ctx context.Context, tx sql.Tx, height uint64, l *zap.SugaredLogger) (failed []types.FileContractID, _ error) {
+	rows, err := tx.Query(ctx, `
+SELECT fcid, state, proof_height, COALESCE(size, 0), archival_reason IS NOT NULL
+FROM contracts
+WHERE window_end < ? AND (state = ? OR (archival_reason IS NULL AND state IN (?, ?, ?)))`,
+		height,
+		ContractStateFromString(api.ContractStateActive),
+		ContractStateFromString(api.ContractStateComplete),
+		ContractStateFromString(api.ContractStateFailed),
+		ContractStateFromString(api.ContractStateUnknown),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch expired contracts: %w", err)
+	}
+	defer rows.Close()
+
+	type expiredContract struct {
+		fcid        types.FileContractID
+		state       ContractState
+		proofHeight uint64
+		size        uint64
+		archived    bool
+	}
+	var expired []expiredContract
+	for rows.Next() {
+		var c expiredContract
+		if err := rows.Scan((*FileContractID)(&c.fcid), &c.state, &c.proofHeight, &c.size, &c.archived); err != nil {
+			return nil, fmt.Errorf("failed to scan expired contract: %w", err)
+		}
+		expired = append(expired, c)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close rows: %w", err)
+	}
+
+	for _, c := range expired {
+		if c.state == contractStateActive {
+			state := api.ContractState(api.ContractStateFailed)
+			if c.proofHeight > 0 {
+				state = api.ContractStateComplete
+			}
+			if err := UpdateContractState(ctx, tx, c.fcid, state, "contract window ended", height, l); err != nil {
+				return nil, fmt.Errorf("failed to update state of expired contract %v: %w", c.fcid, err)
+			} else if state == api.ContractStateFailed && c.size > 0 {
+				failed = append(failed, c.fcid)
+			}
+		}
+		if !c.archived {
+			if err := ArchiveContract(ctx, tx, c.fcid, api.ContractArchivalReasonExpired); err != nil {
+				return nil, fmt.Errorf("failed to archive expired contract %v: %w", c.fcid, err)
+			}
+		}
+	}
+	if len(expired) > 0 {
+		l.Debugw(fmt.Sprintf("processed %d expired contracts", len(expired)), "height", height)
+	}
+	return failed, nil
+}
+
 func GetContractState(ctx context.Context, tx sql.Tx, fcid types.FileContractID) (api.ContractState, error) {
 	var cse ContractState
 	err := tx.
@@ -77,33 +140,126 @@ func UpdateContractProofHeight(ctx context.Context, tx sql.Tx, fcid types.FileCo
 	return err
 }
 
-func UpdateContractState(ctx context.Context, tx sql.Tx, fcid types.FileContractID, state api.ContractState, l *zap.SugaredLogger) error {
-	l.Debugw("update contract state", "fcid", fcid, "state", state)
+func UpdateContractState(ctx context.Context, tx sql.Tx, fcid types.FileContractID, state api.ContractState, reason string, height uint64, l *zap.SugaredLogger) error {
+	l.Debugw("update contract state", "fcid", fcid, "state", state, "reason", reason)
 
 	var cs ContractState
 	if err := cs.LoadString(string(state)); err != nil {
 		return err
 	}
-	_, err := tx.Exec(ctx, `UPDATE contracts SET state = ? WHERE fcid = ?`, cs, FileContractID(fcid))
-	return err
+
+	// fetch the current state
+	prev, err := GetContractState(ctx, tx, fcid)
+	if errors.Is(err, api.ErrContractNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if prev == state {
+		return nil
+	}
+
+	res, err := tx.Exec(ctx, `UPDATE contracts SET state = ? WHERE fcid = ? AND state != ?`, cs, FileContractID(fcid), cs)
+	if err != nil {
+		return err
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n == 0 {
+		return nil
+	}
+
+	// the chain is the source of truth, an invalid transition is logged
+	// rather than failing the chain update, which would stall the subscriber
+	if err := api.ValidateStateTransition(prev, state); err != nil {
+		l.Warnw("unexpected contract state transition", "fcid", fcid, "reason", reason, zap.Error(err))
+	}
+	if err := insertContractStateChange(ctx, tx, fcid, prev, state, reason, height); err != nil {
+		return err
+	}
+	return RecordContractEvent(ctx, tx, fcid, api.ContractEventTypeStateChanged, map[string]string{"state": string(state)})
 }
 
-func UpdateFailedContracts(ctx context.Context, tx sql.Tx, blockHeight uint64, l *zap.SugaredLogger) error {
-	l.Debugw("update failed contracts", "block_height", blockHeight)
+func UpdateFailedContracts(ctx context.Context, tx sql.Tx, windowEnd, height uint64, l *zap.SugaredLogger) error {
+	l.Debugw("update failed contracts", "window_end", windowEnd, "height", height)
+
+	// fetch the contracts that are about to fail
+	rows, err := tx.Query(ctx, "SELECT fcid FROM contracts WHERE window_end <= ? AND state = ?",
+		windowEnd,
+		ContractStateFromString(api.ContractStateActive),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to fetch failed contracts: %w", err)
+	}
+	defer rows.Close()
+
+	var failed []types.FileContractID
+	for rows.Next() {
+		var fcid types.FileContractID
+		if err := rows.Scan((*FileContractID)(&fcid)); err != nil {
+			return fmt.Errorf("failed to scan contract id: %w", err)
+		}
+		failed = append(failed, fcid)
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("failed to close rows: %w", err)
+	}
 
 	if res, err := tx.Exec(ctx,
 		"UPDATE contracts SET state = ? WHERE window_end <= ? AND state = ?",
 		ContractStateFromString(api.ContractStateFailed),
-		blockHeight,
+		windowEnd,
 		ContractStateFromString(api.ContractStateActive),
 	); err != nil {
 		return fmt.Errorf("failed to update failed contracts: %w", err)
 	} else if n, err := res.RowsAffected(); err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	} else if n > 0 {
-		l.Debugw(fmt.Sprintf("marked %d active contracts as failed", n), "window_end", blockHeight)
+		l.Debugw(fmt.Sprintf("marked %d active contracts as failed", n), "window_end", windowEnd)
 	}
 
+	for _, fcid := range failed {
+		if err := RecordContractStateChange(ctx, tx, fcid, api.ContractStateActive, api.ContractStateFailed, "proof window ended without a resolution", height); err != nil {
+			return err
+		} else if err := RecordContractEvent(ctx, tx, fcid, api.ContractEventTypeStateChanged, map[string]string{"state": api.ContractStateFailed}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateUnknownContracts resolves the state of contracts that were reset to
+// unknown when they were unarchived. A contract with a file contract element
+// but no resolution is still active on chain, contracts that were resolved or
+// aren't known to be on chain remain unknown until they expire.
+func UpdateUnknownContracts(ctx context.Context, tx sql.Tx, height uint64, l *zap.SugaredLogger) error {
+	rows, err := tx.Query(ctx, `
+SELECT c.fcid
+FROM contracts c
+INNER JOIN contract_elements ce ON ce.db_contract_id = c.id
+WHERE c.state = ? AND c.archival_reason IS NULL AND COALESCE(c.proof_height, 0) = 0`,
+		ContractStateFromString(api.ContractStateUnknown),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to fetch unknown contracts: %w", err)
+	}
+	defer rows.Close()
+
+	var active []types.FileContractID
+	for rows.Next() {
+		var fcid types.FileContractID
+		if err := rows.Scan((*FileContractID)(&fcid)); err != nil {
+			return fmt.Errorf("failed to scan contract id: %w", err)
+		}
+		active = append(active, fcid)
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("failed to close rows: %w", err)
+	}
+
+	for _, fcid := range active {
+		if err := UpdateContractState(ctx, tx, fcid, api.ContractStateActive, "contract is active on chain", height, l); err != nil {
+			return fmt.Errorf("failed to update state of unknown contract %v: %w", fcid, err)
+		}
+	}
 	return nil
 }
 
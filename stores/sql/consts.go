@@ -8,8 +8,9 @@ import (
 )
 
 var (
-	ErrInvalidContractState     = errors.New("invalid contract state")
-	ErrInvalidContractUsability = errors.New("invalid contract usability")
+	ErrInvalidContractState              = errors.New("invalid contract state")
+	ErrInvalidContractUsability          = errors.New("invalid contract usability")
+	ErrInvalidContractVerificationStatus = errors.New("invalid contract verification status")
 )
 
 type ContractState uint8
@@ -20,6 +21,7 @@ const (
 	contractStateActive
 	contractStateComplete
 	contractStateFailed
+	contractStateUnknown
 )
 
 func ContractStateFromString(state string) ContractState {
@@ -34,6 +36,8 @@ func ContractStateFromString(state string) ContractState {
 		return contractStateComplete
 	case api.ContractStateFailed:
 		return contractStateFailed
+	case api.ContractStateUnknown:
+		return contractStateUnknown
 	default:
 		return contractStateInvalid
 	}
@@ -49,6 +53,8 @@ func (s *ContractState) LoadString(state string) error {
 		*s = contractStateComplete
 	case api.ContractStateFailed:
 		*s = contractStateFailed
+	case api.ContractStateUnknown:
+		*s = contractStateUnknown
 	default:
 		*s = contractStateInvalid
 		return ErrInvalidContractState
@@ -68,6 +74,8 @@ func (s ContractState) String() string {
 		return api.ContractStateComplete
 	case contractStateFailed:
 		return api.ContractStateFailed
+	case contractStateUnknown:
+		return api.ContractStateUnknown
 	default:
 		return api.ContractStateUnknown
 	}
@@ -104,3 +112,39 @@ func (s ContractUsability) String() string {
 		return "invalid"
 	}
 }
+
+type ContractVerificationStatus uint8
+
+const (
+	contractVerificationStatusUnverified ContractVerificationStatus = iota
+	contractVerificationStatusVerified
+	contractVerificationStatusMismatch
+)
+
+func (s *ContractVerificationStatus) LoadString(status string) error {
+	switch strings.ToLower(status) {
+	case api.ContractVerificationStatusUnverified:
+		*s = contractVerificationStatusUnverified
+	case api.ContractVerificationStatusVerified:
+		*s = contractVerificationStatusVerified
+	case api.ContractVerificationStatusMismatch:
+		*s = contractVerificationStatusMismatch
+	default:
+		*s = contractVerificationStatusUnverified
+		return ErrInvalidContractVerificationStatus
+	}
+	return nil
+}
+
+func (s ContractVerificationStatus) String() string {
+	switch s {
+	case contractVerificationStatusUnverified:
+		return api.ContractVerificationStatusUnverified
+	case contractVerificationStatusVerified:
+		return api.ContractVerificationStatusVerified
+	case contractVerificationStatusMismatch:
+		return api.ContractVerificationStatusMismatch
+	default:
+		return "invalid"
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"go.sia.tech/coreutils/chain"
 	"go.sia.tech/coreutils/syncer"
 	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/internal/sql"
 	"go.sia.tech/renterd/v2/object"
 )
 
@@ -17,6 +19,7 @@ import (
 // to be used by the SQLStore.
 type (
 	ChainUpdateTx interface {
+		ArchiveExpiredContracts(height uint64) ([]types.FileContractID, error)
 		ContractState(fcid types.FileContractID) (api.ContractState, error)
 		DeleteFileContractElement(fcid types.FileContractID) error
 		ExpiredFileContractElements(bh uint64) ([]types.V2FileContractElement, error)
@@ -29,9 +32,10 @@ type (
 		UpdateFileContractElementProofs(updater wallet.ProofUpdater) error
 		UpdateContractProofHeight(fcid types.FileContractID, proofHeight uint64) error
 		UpdateContractRevision(fcid types.FileContractID, revisionHeight, revisionNumber, size uint64) error
-		UpdateContractState(fcid types.FileContractID, state api.ContractState) error
-		UpdateFailedContracts(blockHeight uint64) error
+		UpdateContractState(fcid types.FileContractID, state api.ContractState, reason string, height uint64) error
+		UpdateFailedContracts(windowEnd, height uint64) error
 		UpdateHost(hk types.PublicKey, v2Ha chain.V2HostAnnouncement, bh uint64, blockID types.BlockID, ts time.Time) error
+		UpdateUnknownContracts(height uint64) error
 
 		wallet.UpdateTx
 	}
@@ -39,6 +43,9 @@ type (
 	Database interface {
 		io.Closer
 
+		// DB returns the underlying database.
+		DB() *sql.DB
+
 		// Migrate runs all missing migrations on the database.
 		Migrate(ctx context.Context) error
 
@@ -86,6 +93,11 @@ type (
 		// exist, it returns api.ErrBucketNotFound.
 		Bucket(ctx context.Context, bucket string) (api.Bucket, error)
 
+		// BucketUsage returns the number of objects in a bucket and their
+		// combined size. If the bucket doesn't exist, it returns
+		// api.ErrBucketNotFound.
+		BucketUsage(ctx context.Context, bucket string) (api.BucketUsage, error)
+
 		// Buckets returns a list of all buckets in the database.
 		Buckets(ctx context.Context) ([]api.Bucket, error)
 
@@ -103,10 +115,29 @@ type (
 		// ContractRoots returns the roots of the contract with the given ID.
 		ContractRoots(ctx context.Context, fcid types.FileContractID) ([]types.Hash256, error)
 
+		// ContractEvents returns the lifecycle events of the contract with the
+		// given ID in chronological order.
+		ContractEvents(ctx context.Context, fcid types.FileContractID) ([]api.ContractEvent, error)
+
+		// ContractRevisions returns the recorded revision history of the
+		// contract with the given ID, filtered to revision numbers within
+		// [start, end].
+		ContractRevisions(ctx context.Context, fcid types.FileContractID, start, end uint64) ([]api.ContractRevisionRecord, error)
+
 		// Contracts returns contract metadata for all active contracts. The
 		// opts argument can be used to filter the result.
 		Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error)
 
+		// ContractsPrunableData returns the sizes of a page of contracts as well
+		// as the estimated number of bytes that can be pruned from them,
+		// sorted according to the given options. The totals in the response
+		// cover all contracts.
+		ContractsPrunableData(ctx context.Context, opts api.ContractsPrunableDataOpts) (api.ContractsPrunableDataResponse, error)
+
+		// ContractStateHistory returns the state transitions of the contract
+		// with the given id in chronological order.
+		ContractStateHistory(ctx context.Context, fcid types.FileContractID) ([]api.ContractStateEvent, error)
+
 		// ContractSize returns the size of the contract with the given ID as
 		// well as the estimated number of bytes that can be pruned from it.
 		ContractSize(ctx context.Context, id types.FileContractID) (api.ContractSize, error)
@@ -129,6 +160,10 @@ type (
 		// api.ErrBucketNotFound.
 		DeleteBucket(ctx context.Context, bucket string) error
 
+		// DeleteContractUsabilityOverride removes the usability override of
+		// the given contract, its usability is left as is.
+		DeleteContractUsabilityOverride(ctx context.Context, fcid types.FileContractID) error
+
 		// DeleteHostSector deletes all contract sector links that a host has
 		// with the given root incrementing the lost sector count in the
 		// process.
@@ -142,9 +177,23 @@ type (
 		// prefix and returns 'true' if any object was deleted.
 		DeleteObjects(ctx context.Context, bucket, prefix string, limit int64) (bool, error)
 
+		// DeleteObjectVersions deletes the object versions of the given
+		// bucket that were created before the given time and returns the
+		// number of versions that were deleted.
+		DeleteObjectVersions(ctx context.Context, bucket string, before time.Time) (int64, error)
+
 		// DeleteSetting deletes the setting with the given key.
 		DeleteSetting(ctx context.Context, key string) error
 
+		// DismissedAlerts returns the recorded alert dismissals, the most
+		// recent ones first.
+		DismissedAlerts(ctx context.Context, offset, limit int) ([]alerts.DismissedAlert, error)
+
+		// FailPendingContracts marks all unarchived contracts that are still
+		// pending and have a start height at or below the given height as
+		// failed and returns their ids.
+		FailPendingContracts(ctx context.Context, maxStartHeight, height uint64) ([]types.FileContractID, error)
+
 		// FileContractElement returns the up-to-date file contract element for
 		// a given contract id.
 		FileContractElement(ctx context.Context, fcid types.FileContractID) (types.V2FileContractElement, error)
@@ -175,6 +224,10 @@ type (
 		// InsertObject inserts a new object into the database.
 		InsertObject(ctx context.Context, bucket, key string, o object.Object, mimeType, eTag string, md api.ObjectUserMetadata) error
 
+		// InsertObjectVersion moves the slices of an existing object to a new
+		// object version and returns true if the object existed.
+		InsertObjectVersion(ctx context.Context, bucket, key string) (bool, error)
+
 		// InvalidateSlabHealthByFCID invalidates the health of all slabs that
 		// are associated with any of the provided contracts.
 		InvalidateSlabHealthByFCID(ctx context.Context, fcids []types.FileContractID, limit int64) (int64, error)
@@ -193,6 +246,18 @@ type (
 		// The returned string contains the filename of the slab buffer on disk.
 		MarkPackedSlabUploaded(ctx context.Context, slab api.UploadedPackedSlab) (string, error)
 
+		// MoveContractEvents moves the recorded events of the contract 'from'
+		// to the contract 'to'.
+		MoveContractEvents(ctx context.Context, from, to types.FileContractID) error
+
+		// MoveContractRevisions moves the recorded revision history of the
+		// contract 'from' to the contract 'to'.
+		MoveContractRevisions(ctx context.Context, from, to types.FileContractID) error
+
+		// MoveContractStateChanges moves the recorded state history of the
+		// contract 'from' to the contract 'to'.
+		MoveContractStateChanges(ctx context.Context, from, to types.FileContractID) error
+
 		// MultipartUpload returns the multipart upload with the given ID or
 		// api.ErrMultipartUploadNotFound if the upload doesn't exist.
 		MultipartUpload(ctx context.Context, uploadID string) (api.MultipartUpload, error)
@@ -207,12 +272,18 @@ type (
 		// Object returns an object from the database.
 		Object(ctx context.Context, bucket, key string) (api.Object, error)
 
-		// Objects returns a list of objects from the given bucket.
-		Objects(ctx context.Context, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, encryptionKey object.EncryptionKey) (resp api.ObjectsResponse, err error)
+		// Objects returns a list of objects from the given bucket. If metadata
+		// is set, only objects with all given user metadata entries are
+		// returned.
+		Objects(ctx context.Context, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, encryptionKey object.EncryptionKey, metadata api.ObjectUserMetadata) (resp api.ObjectsResponse, err error)
 
 		// ObjectMetadata returns an object's metadata.
 		ObjectMetadata(ctx context.Context, bucket, key string) (api.Object, error)
 
+		// ObjectVersions returns the versions that were preserved when the
+		// given object was overwritten, the most recent version comes first.
+		ObjectVersions(ctx context.Context, bucket, key string) ([]api.ObjectVersion, error)
+
 		// ObjectsStats returns overall stats about stored objects
 		ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts) (api.ObjectsStatsResponse, error)
 
@@ -233,9 +304,15 @@ type (
 		// the contract.
 		PrunableContractRoots(ctx context.Context, fcid types.FileContractID, roots []types.Hash256) (indices []uint64, err error)
 
-		// PruneHostSectors deletes host-sector links for sectors that are no
-		// longer linked to an active contract.
-		PruneHostSectors(ctx context.Context, limit int64) (int64, error)
+		// PrunableHostSectors returns the number of host-sector links that
+		// are no longer linked to an active contract.
+		PrunableHostSectors(ctx context.Context) (int64, error)
+
+		// PruneHostSectors deletes up to 'limit' host-sector links for
+		// sectors that are no longer linked to an active contract, starting
+		// after the given cursor. It returns the number of deleted links and
+		// the cursor to continue from.
+		PruneHostSectors(ctx context.Context, cursor HostSectorCursor, limit int64) (int64, HostSectorCursor, error)
 
 		// PruneSlabs deletes slabs that are no longer referenced by any slice
 		// or slab buffer.
@@ -245,9 +322,16 @@ type (
 		// will overwrite all fields.
 		PutContract(ctx context.Context, c api.ContractMetadata) error
 
+		// RecordContractEvent records a lifecycle event for a contract, the
+		// details are stored as JSON.
+		RecordContractEvent(ctx context.Context, fcid types.FileContractID, eventType string, details any) error
+
 		// RecordContractSpending records new spending for a contract
 		RecordContractSpending(ctx context.Context, fcid types.FileContractID, revisionNumber, size uint64, newSpending api.ContractSpending) error
 
+		// RecordDismissedAlerts records the given alert dismissals.
+		RecordDismissedAlerts(ctx context.Context, dismissed ...alerts.DismissedAlert) error
+
 		// RecordHostScans records the results of host scans in the database
 		// such as recording the settings and price table of a host in case of
 		// success and updating the uptime and downtime of a host.
@@ -256,10 +340,19 @@ type (
 		// therefore only useful for gouging checks.
 		RecordHostScans(ctx context.Context, scans []api.HostScan) error
 
+		// RecountBucketUsage rebuilds the usage counters of a bucket by
+		// counting its objects.
+		RecountBucketUsage(ctx context.Context, bucket string) (api.BucketUsage, error)
+
 		// RemoveOfflineHosts removes all hosts that have been offline for
 		// longer than maxDownTime and been scanned at least minRecentFailures
-		// times. The contracts of those hosts are also removed.
-		RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDownTime time.Duration) (int64, error)
+		// times. The contracts of those hosts are also removed. If
+		// exemptActiveContractHosts is set, hosts with pending or active
+		// contracts are not removed but returned instead. At most limit hosts
+		// are removed, if there are more than maxEligible hosts eligible for
+		// removal no hosts are removed at all. Both are ignored when zero. The
+		// number of hosts eligible for removal is returned as well.
+		RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDownTime time.Duration, exemptActiveContractHosts bool, limit, maxEligible int) (removed, eligible int64, exempted []types.PublicKey, _ error)
 
 		// RenameObject renames an object in the database from keyOld to keyNew
 		// and the new directory dirID. returns api.ErrObjectExists if the an
@@ -267,7 +360,7 @@ type (
 		// if the object at keyOld doesn't exist. If force is true, the instead
 		// of returning api.ErrObjectExists, the existing object will be
 		// deleted.
-		RenameObject(ctx context.Context, bucket, keyOld, keyNew string, force bool) error
+		RenameObject(ctx context.Context, srcBucket, dstBucket, keyOld, keyNew string, force bool) error
 
 		// RenameObjects renames all objects in the database with the given
 		// prefix to the new prefix. If 'force' is true, it will overwrite any
@@ -287,6 +380,10 @@ type (
 		// ResetLostSectors resets the lost sector count for the given host.
 		ResetLostSectors(ctx context.Context, hk types.PublicKey) error
 
+		// RestoreObjectVersion turns the given object version back into an
+		// object, no object may exist under the same key.
+		RestoreObjectVersion(ctx context.Context, bucket, key string, id int64) error
+
 		// SaveAccounts saves the given accounts in the db, overwriting any
 		// existing ones.
 		SaveAccounts(ctx context.Context, accounts []api.Account) error
@@ -297,6 +394,10 @@ type (
 		// Slab returns the slab with the given ID or api.ErrSlabNotFound.
 		Slab(ctx context.Context, key object.EncryptionKey) (object.Slab, error)
 
+		// SlabBufferObjects returns the keys of the objects that reference
+		// each slab buffer, indexed by the buffer's ID.
+		SlabBufferObjects(ctx context.Context) (map[int64][]string, error)
+
 		// SlabsForMigration returns up to 'limit' slabs with a health smaller
 		// than or equal to 'healthCutoff'
 		SlabsForMigration(ctx context.Context, healthCutoff float64, limit int) ([]api.UnhealthySlab, error)
@@ -304,6 +405,10 @@ type (
 		// Tip returns the sync height.
 		Tip(ctx context.Context) (types.ChainIndex, error)
 
+		// UnarchiveContract moves an archived contract back to the regular
+		// contracts.
+		UnarchiveContract(ctx context.Context, fcid types.FileContractID) error
+
 		// UnspentSiacoinElements returns all wallet outputs in the database.
 		UnspentSiacoinElements(ctx context.Context) (types.ChainIndex, []types.SiacoinElement, error)
 
@@ -314,12 +419,30 @@ type (
 		// one, fully overwriting the existing policy.
 		UpdateBucketPolicy(ctx context.Context, bucket string, policy api.BucketPolicy) error
 
+		// UpdateBucketRedundancy updates the redundancy settings of the bucket
+		// with the provided name, nil clears them.
+		UpdateBucketRedundancy(ctx context.Context, bucket string, rs *api.RedundancySettings) error
+
 		// UpdateContract sets the given metadata on the contract with given fcid.
 		UpdateContract(ctx context.Context, fcid types.FileContractID, c api.ContractMetadata) error
 
-		// UpdateContractUsability updates the usability of the given contract.
+		// UpdateContractSpendingCap updates the spending cap of the given
+		// contract.
+		UpdateContractSpendingCap(ctx context.Context, fcid types.FileContractID, spendingCap types.Currency) error
+
+		// UpdateContractUsability updates the usability of the given contract
+		// unless it was overridden.
 		UpdateContractUsability(ctx context.Context, fcid types.FileContractID, usability string) error
 
+		// UpdateContractUsabilityOverride sets the usability of the given
+		// contract and prevents it from being updated through
+		// UpdateContractUsability until the override is deleted.
+		UpdateContractUsabilityOverride(ctx context.Context, fcid types.FileContractID, usability string) error
+
+		// UpdateContractVerificationStatus updates the verification status of
+		// the given contract.
+		UpdateContractVerificationStatus(ctx context.Context, fcid types.FileContractID, status string) error
+
 		// UpdateHostAllowlistEntries updates the allowlist in the database
 		UpdateHostAllowlistEntries(ctx context.Context, add, remove []types.PublicKey, clear bool) error
 
@@ -329,6 +452,17 @@ type (
 		// UpdateHostCheck updates the host check for the given host.
 		UpdateHostCheck(ctx context.Context, hk types.PublicKey, hc api.HostChecks) error
 
+		// UpdateHostSectorSize updates the sector size of the given host.
+		UpdateHostSectorSize(ctx context.Context, hk types.PublicKey, sectorSize uint64) error
+
+		// UpdateObjectMimeType updates the mime type of the object with given
+		// key.
+		UpdateObjectMimeType(ctx context.Context, bucket, key, mimeType string) error
+
+		// UpdateObjectUserMetadata replaces the user metadata of the object
+		// with given key.
+		UpdateObjectUserMetadata(ctx context.Context, bucket, key string, md api.ObjectUserMetadata) error
+
 		// UpdatePeerInfo updates the metadata for the specified peer.
 		UpdatePeerInfo(ctx context.Context, addr string, fn func(*syncer.PeerInfo)) error
 
@@ -381,6 +515,9 @@ type (
 	MetricsDatabase interface {
 		io.Closer
 
+		// DB returns the underlying database.
+		DB() *sql.DB
+
 		// Migrate runs all missing migrations on the database.
 		Migrate(ctx context.Context) error
 
@@ -400,6 +537,10 @@ type (
 		// time range and options.
 		ContractPruneMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractPruneMetricsQueryOpts) ([]api.ContractPruneMetric, error)
 
+		// ContractSpendingHistory returns the spending of the given contract
+		// between 'from' and 'to' in buckets of the given interval.
+		ContractSpendingHistory(ctx context.Context, fcid types.FileContractID, from, to time.Time, interval time.Duration) ([]api.ContractSpendingBucket, error)
+
 		// PruneMetrics deletes metrics of a certain type older than the given
 		// cutoff time.
 		PruneMetrics(ctx context.Context, metric string, cutoff time.Time) error
@@ -419,6 +560,7 @@ type (
 
 	LoadedSlabBuffer struct {
 		ID          int64
+		CreatedAt   time.Time
 		Filename    string
 		Key         object.EncryptionKey
 		MinShards   uint8
@@ -9,6 +9,7 @@ import (
 	"math"
 	"math/big"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -22,6 +23,7 @@ import (
 	"go.sia.tech/coreutils/rhp/v4/siamux"
 	"go.sia.tech/coreutils/syncer"
 	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/internal/rhp/v4"
 	"go.sia.tech/renterd/v2/internal/sql"
@@ -148,8 +150,9 @@ func AncestorContracts(ctx context.Context, tx sql.Tx, fcid types.FileContractID
 		SELECT
 			c.fcid, c.host_id, c.host_key,
 			c.archival_reason, c.proof_height, c.renewed_from, c.renewed_to, c.revision_height, c.revision_number, c.size, c.start_height, c.state, c.usability, c.window_start, c.window_end,
-			c.contract_price, c.initial_renter_funds,
-			c.delete_spending, c.fund_account_spending, c.sector_roots_spending, c.upload_spending
+			c.contract_price, c.initial_renter_funds, c.network_fees,
+			c.delete_spending, c.fund_account_spending, c.sector_roots_spending, c.upload_spending,
+			COALESCE(c.spending_cap, '0'), c.usability_override, c.verification_status, c.host_version
 		FROM contracts AS c
 		WHERE start_height >= ? AND archival_reason IS NOT NULL
 		ORDER BY start_height DESC
@@ -177,9 +180,15 @@ func ArchiveContract(ctx context.Context, tx sql.Tx, fcid types.FileContractID,
 	}
 
 	// archive contract
-	_, err := tx.Exec(ctx, "UPDATE contracts SET host_id = NULL, archival_reason = ?, usability = ? WHERE fcid = ?", reason, contractUsabilityBad, FileContractID(fcid))
+	res, err := tx.Exec(ctx, "UPDATE contracts SET host_id = NULL, archival_reason = ?, usability = ? WHERE fcid = ?", reason, contractUsabilityBad, FileContractID(fcid))
 	if err != nil {
 		return fmt.Errorf("failed to archive contract: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n > 0 {
+		if err := RecordContractEvent(ctx, tx, fcid, api.ContractEventTypeArchived, map[string]string{"reason": reason}); err != nil {
+			return err
+		}
 	}
 
 	// delete its sectors
@@ -191,6 +200,49 @@ func ArchiveContract(ctx context.Context, tx sql.Tx, fcid types.FileContractID,
 	return nil
 }
 
+// UnarchiveContract moves an archived contract back to the regular contracts,
+// the sectors that were stored in the contract are not restored.
+func UnarchiveContract(ctx context.Context, tx sql.Tx, fcid types.FileContractID) error {
+	var hk PublicKey
+	var archived bool
+	err := tx.QueryRow(ctx, "SELECT host_key, archival_reason IS NOT NULL FROM contracts WHERE fcid = ?", FileContractID(fcid)).
+		Scan(&hk, &archived)
+	if errors.Is(err, dsql.ErrNoRows) || (err == nil && !archived) {
+		return fmt.Errorf("%w: archived contract %v", api.ErrContractNotFound, fcid)
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch contract: %w", err)
+	}
+
+	var hostID int64
+	err = tx.QueryRow(ctx, "SELECT id FROM hosts WHERE public_key = ?", hk).Scan(&hostID)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return fmt.Errorf("%w: %v", api.ErrHostNotFound, types.PublicKey(hk))
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch host: %w", err)
+	}
+
+	// the state of the contract might have changed while it was archived, so
+	// it is reset to unknown until the next chain update resolves it, see
+	// UpdateUnknownContracts
+	prev, err := GetContractState(ctx, tx, fcid)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "UPDATE contracts SET host_id = ?, archival_reason = NULL, state = ? WHERE fcid = ?", hostID, contractStateUnknown, FileContractID(fcid))
+	if err != nil {
+		return fmt.Errorf("failed to unarchive contract: %w", err)
+	}
+	if prev != api.ContractStateUnknown {
+		var height uint64
+		if err := tx.QueryRow(ctx, "SELECT COALESCE((SELECT height FROM consensus_infos WHERE id = ?), 0)", sql.ConsensusInfoID).Scan(&height); err != nil {
+			return fmt.Errorf("failed to fetch chain height: %w", err)
+		} else if err := insertContractStateChange(ctx, tx, fcid, prev, api.ContractStateUnknown, "contract was unarchived", height); err != nil {
+			return err
+		}
+	}
+	return RecordContractEvent(ctx, tx, fcid, api.ContractEventTypeUnarchived, nil)
+}
+
 func AutopilotConfig(ctx context.Context, tx sql.Tx) (cfg api.AutopilotConfig, err error) {
 	err = tx.QueryRow(ctx, `
 SELECT
@@ -202,6 +254,14 @@ SELECT
 	contracts_upload,
 	contracts_storage,
 	contracts_prune,
+	contracts_max_per_subnet,
+	contracts_max_per_host,
+	contracts_prune_alert_threshold,
+	COALESCE(contracts_min_wallet_balance, '0'),
+	contracts_storage_projection,
+	contracts_min_contract_lifetime,
+	contracts_period_multiplier,
+	contracts_period_multiplier_growth_factor,
 	hosts_max_downtime_hours,
 	hosts_min_protocol_version,
 	hosts_max_consecutive_scan_failures
@@ -215,6 +275,14 @@ WHERE id = ?`, sql.AutopilotID).Scan(
 		&cfg.Contracts.Upload,
 		&cfg.Contracts.Storage,
 		&cfg.Contracts.Prune,
+		&cfg.Contracts.MaxContractsPerSubnet,
+		&cfg.Contracts.MaxContractsPerHost,
+		&cfg.Contracts.PruneAlertThreshold,
+		(*Currency)(&cfg.Contracts.MinWalletBalance),
+		(*StorageProjection)(&cfg.Contracts.StorageProjection),
+		&cfg.Contracts.MinContractLifetimeBlocks,
+		&cfg.Contracts.PeriodMultiplier,
+		&cfg.Contracts.PeriodMultiplierGrowthFactor,
 		&cfg.Hosts.MaxDowntimeHours,
 		&cfg.Hosts.MinProtocolVersion,
 		&cfg.Hosts.MaxConsecutiveScanFailures,
@@ -223,15 +291,37 @@ WHERE id = ?`, sql.AutopilotID).Scan(
 }
 
 func Bucket(ctx context.Context, tx sql.Tx, bucket string) (api.Bucket, error) {
-	b, err := scanBucket(tx.QueryRow(ctx, "SELECT created_at, name, COALESCE(policy, '{}') FROM buckets WHERE name = ?", bucket))
+	b, err := scanBucket(tx.QueryRow(ctx, "SELECT created_at, name, COALESCE(policy, '{}'), COALESCE(redundancy, '') FROM buckets WHERE name = ?", bucket))
 	if err != nil {
 		return api.Bucket{}, fmt.Errorf("failed to fetch bucket: %w", err)
 	}
 	return b, nil
 }
 
+// BucketUsage returns the usage counters of a bucket. If the bucket doesn't
+// have counters yet, they are initialised by counting its objects.
+func BucketUsage(ctx context.Context, tx sql.Tx, bucket string) (api.BucketUsage, error) {
+	var bucketID int64
+	err := tx.QueryRow(ctx, "SELECT id FROM buckets WHERE name = ?", bucket).Scan(&bucketID)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return api.BucketUsage{}, api.ErrBucketNotFound
+	} else if err != nil {
+		return api.BucketUsage{}, fmt.Errorf("failed to fetch bucket id: %w", err)
+	}
+
+	var usage api.BucketUsage
+	err = tx.QueryRow(ctx, "SELECT object_count, total_bytes, last_updated FROM bucket_usage WHERE db_bucket_id = ?", bucketID).
+		Scan(&usage.ObjectCount, &usage.TotalBytes, (*time.Time)(&usage.LastUpdated))
+	if errors.Is(err, dsql.ErrNoRows) {
+		return recountBucketUsage(ctx, tx, bucketID)
+	} else if err != nil {
+		return api.BucketUsage{}, fmt.Errorf("failed to fetch bucket usage: %w", err)
+	}
+	return usage, nil
+}
+
 func Buckets(ctx context.Context, tx sql.Tx) ([]api.Bucket, error) {
-	rows, err := tx.Query(ctx, "SELECT created_at, name, COALESCE(policy, '{}') FROM buckets")
+	rows, err := tx.Query(ctx, "SELECT created_at, name, COALESCE(policy, '{}'), COALESCE(redundancy, '') FROM buckets")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch buckets: %w", err)
 	}
@@ -282,6 +372,136 @@ func ContractRoots(ctx context.Context, tx sql.Tx, fcid types.FileContractID) ([
 	return roots, nil
 }
 
+func ContractEvents(ctx context.Context, tx sql.Tx, fcid types.FileContractID) ([]api.ContractEvent, error) {
+	var exists bool
+	if err := tx.QueryRow(ctx, "SELECT 1 FROM contracts WHERE fcid = ?", FileContractID(fcid)).
+		Scan(&exists); errors.Is(err, dsql.ErrNoRows) {
+		return nil, api.ErrContractNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT ce.timestamp, ce.event_type, ce.details
+		FROM contract_events ce
+		INNER JOIN contracts c ON c.id = ce.db_contract_id
+		WHERE c.fcid = ?
+		ORDER BY ce.timestamp ASC, ce.id ASC
+	`, FileContractID(fcid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []api.ContractEvent{}
+	for rows.Next() {
+		event := api.ContractEvent{ContractID: fcid}
+		var details dsql.NullString
+		if err := rows.Scan((*UnixTimeMS)(&event.Timestamp), &event.Type, &details); err != nil {
+			return nil, fmt.Errorf("failed to scan contract event: %w", err)
+		} else if details.Valid {
+			event.Details = json.RawMessage(details.String)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func ContractStateHistory(ctx context.Context, tx sql.Tx, fcid types.FileContractID) ([]api.ContractStateEvent, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT csc.from_state, csc.to_state, csc.reason, csc.height, csc.timestamp
+		FROM contract_state_changes csc
+		INNER JOIN contracts c ON c.id = csc.db_contract_id
+		WHERE c.fcid = ?
+		ORDER BY csc.timestamp ASC, csc.id ASC
+	`, FileContractID(fcid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract state changes: %w", err)
+	}
+	defer rows.Close()
+
+	events := []api.ContractStateEvent{}
+	for rows.Next() {
+		event := api.ContractStateEvent{ContractID: fcid}
+		if err := rows.Scan(&event.FromState, &event.ToState, &event.Reason, &event.Height, (*UnixTimeMS)(&event.Timestamp)); err != nil {
+			return nil, fmt.Errorf("failed to scan contract state change: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func ContractRevisions(ctx context.Context, tx sql.Tx, fcid types.FileContractID, start, end uint64) ([]api.ContractRevisionRecord, error) {
+	var contractID int64
+	if err := tx.QueryRow(ctx, "SELECT id FROM contracts WHERE fcid = ?", FileContractID(fcid)).
+		Scan(&contractID); errors.Is(err, dsql.ErrNoRows) {
+		return nil, api.ErrContractNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract id: %w", err)
+	}
+
+	// revision numbers are stored as text so we can't filter them in the
+	// query, instead we filter them after scanning
+	rows, err := tx.Query(ctx, `
+		SELECT revision_number, size, timestamp
+		FROM contract_revisions
+		WHERE db_contract_id = ?
+		ORDER BY id ASC
+	`, contractID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []api.ContractRevisionRecord{}
+	for rows.Next() {
+		var rev api.ContractRevisionRecord
+		if err := rows.Scan((*Uint64Str)(&rev.RevisionNumber), &rev.Size, (*UnixTimeMS)(&rev.Timestamp)); err != nil {
+			return nil, fmt.Errorf("failed to scan contract revision: %w", err)
+		} else if rev.RevisionNumber < start || rev.RevisionNumber > end {
+			continue
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
+func MoveContractEvents(ctx context.Context, tx sql.Tx, from, to types.FileContractID) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE contract_events
+		SET db_contract_id = (SELECT id FROM contracts WHERE fcid = ?)
+		WHERE db_contract_id = (SELECT id FROM contracts WHERE fcid = ?)
+	`, FileContractID(to), FileContractID(from))
+	if err != nil {
+		return fmt.Errorf("failed to move contract events: %w", err)
+	}
+	return nil
+}
+
+func MoveContractStateChanges(ctx context.Context, tx sql.Tx, from, to types.FileContractID) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE contract_state_changes
+		SET db_contract_id = (SELECT id FROM contracts WHERE fcid = ?)
+		WHERE db_contract_id = (SELECT id FROM contracts WHERE fcid = ?)
+	`, FileContractID(to), FileContractID(from))
+	if err != nil {
+		return fmt.Errorf("failed to move contract state changes: %w", err)
+	}
+	return nil
+}
+
+func MoveContractRevisions(ctx context.Context, tx sql.Tx, from, to types.FileContractID) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE contract_revisions
+		SET db_contract_id = (SELECT id FROM contracts WHERE fcid = ?)
+		WHERE db_contract_id = (SELECT id FROM contracts WHERE fcid = ?)
+	`, FileContractID(to), FileContractID(from))
+	if err != nil {
+		return fmt.Errorf("failed to move contract revisions: %w", err)
+	}
+	return nil
+}
+
 func Contracts(ctx context.Context, tx sql.Tx, opts api.ContractsOpts) ([]api.ContractMetadata, error) {
 	var whereExprs []string
 	var whereArgs []any
@@ -303,14 +523,56 @@ func Contracts(ctx context.Context, tx sql.Tx, opts api.ContractsOpts) ([]api.Co
 		// default to active contracts
 		whereExprs = append(whereExprs, "c.archival_reason IS NULL")
 	}
+	if opts.ExpiringWithinBlocks > 0 {
+		whereExprs = append(whereExprs, "c.window_start <= COALESCE((SELECT height FROM consensus_infos WHERE id = ?), 0) + ?")
+		whereArgs = append(whereArgs, sql.ConsensusInfoID, opts.ExpiringWithinBlocks)
+	}
+	if opts.HostKey != (types.PublicKey{}) {
+		whereExprs = append(whereExprs, "c.host_key = ?")
+		whereArgs = append(whereArgs, PublicKey(opts.HostKey))
+	}
+
+	var hostVersionBelow api.HostVersion
+	if opts.HostVersionBelow != "" {
+		var err error
+		hostVersionBelow, err = api.ParseHostVersion(opts.HostVersionBelow)
+		if err != nil {
+			return nil, fmt.Errorf("invalid host version: %w", err)
+		}
+	}
+
+	contracts, err := QueryContracts(ctx, tx, whereExprs, whereArgs)
+	if err != nil {
+		return nil, err
+	} else if opts.MinRemainingFunds.IsZero() && opts.MaxSpendingRatio == 0 && opts.HostVersionBelow == "" {
+		return contracts, nil
+	}
 
-	return QueryContracts(ctx, tx, whereExprs, whereArgs)
+	// NOTE: currencies are stored as strings so filtering by funds has to
+	// happen after fetching the contracts, the same goes for host versions
+	// which can't be compared lexicographically
+	filtered := contracts[:0]
+	for _, c := range contracts {
+		if !opts.MinRemainingFunds.IsZero() && c.RemainingFunds().Cmp(opts.MinRemainingFunds) < 0 {
+			continue
+		} else if opts.MaxSpendingRatio > 0 && c.SpendingRatio() < opts.MaxSpendingRatio {
+			continue
+		} else if opts.HostVersionBelow != "" && !c.HostVersionBelow(hostVersionBelow) {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered, nil
 }
 
 func ContractSize(ctx context.Context, tx sql.Tx, id types.FileContractID) (api.ContractSize, error) {
-	var contractID, size uint64
-	if err := tx.QueryRow(ctx, "SELECT id, size FROM contracts WHERE fcid = ? AND archival_reason IS NULL", FileContractID(id)).
-		Scan(&contractID, &size); errors.Is(err, dsql.ErrNoRows) {
+	var contractID, size, sectorSize uint64
+	if err := tx.QueryRow(ctx, `
+		SELECT c.id, c.size, COALESCE(h.sector_size, ?)
+		FROM contracts c
+		LEFT JOIN hosts h ON h.id = c.host_id
+		WHERE c.fcid = ? AND c.archival_reason IS NULL`, rhpv4.SectorSize, FileContractID(id)).
+		Scan(&contractID, &size, &sectorSize); errors.Is(err, dsql.ErrNoRows) {
 		return api.ContractSize{}, api.ErrContractNotFound
 	} else if err != nil {
 		return api.ContractSize{}, err
@@ -321,7 +583,7 @@ func ContractSize(ctx context.Context, tx sql.Tx, id types.FileContractID) (api.
 		Scan(&nSectors); err != nil {
 		return api.ContractSize{}, err
 	}
-	sectorsSize := nSectors * rhpv4.SectorSize
+	sectorsSize := nSectors * sectorSize
 
 	var prunable uint64
 	if size > sectorsSize {
@@ -350,9 +612,10 @@ func ContractSizes(ctx context.Context, tx sql.Tx) (map[types.FileContractID]api
 		UNION ALL
 		SELECT fcid, size, CASE WHEN contract_size > sector_size THEN contract_size - sector_size ELSE 0 END
 		FROM (
-			SELECT c.fcid, c.size, MAX(c.size) as contract_size, COUNT(*) * ? as sector_size
+			SELECT c.fcid, c.size, MAX(c.size) as contract_size, COUNT(*) * COALESCE(MAX(h.sector_size), ?) as sector_size
 			FROM contracts c
 			INNER JOIN contract_sectors cs ON cs.db_contract_id = c.id
+			LEFT JOIN hosts h ON h.id = c.host_id
 			WHERE archival_reason IS NULL
 			GROUP BY c.fcid
 		) i
@@ -374,6 +637,68 @@ func ContractSizes(ctx context.Context, tx sql.Tx) (map[types.FileContractID]api
 	return sizes, nil
 }
 
+func ContractsPrunableData(ctx context.Context, tx sql.Tx, opts api.ContractsPrunableDataOpts) (resp api.ContractsPrunableDataResponse, _ error) {
+	var orderBy string
+	switch opts.SortBy {
+	case "", api.ContractsPrunableSortByPrunable:
+		orderBy = "prunable DESC, size DESC"
+	case api.ContractsPrunableSortBySize:
+		orderBy = "size DESC, prunable DESC"
+	case api.ContractsPrunableSortByHostKey:
+		orderBy = "host_key ASC, prunable DESC"
+	default:
+		return api.ContractsPrunableDataResponse{}, fmt.Errorf("invalid sortBy '%v'", opts.SortBy)
+	}
+	if opts.Offset < 0 {
+		return api.ContractsPrunableDataResponse{}, ErrNegativeOffset
+	} else if opts.Limit == -1 {
+		opts.Limit = math.MaxInt64
+	}
+
+	// same query as in ContractSizes
+	sizesQuery := `
+		SELECT c.fcid, c.host_key, c.size, c.size AS prunable
+		FROM contracts c
+		WHERE archival_reason IS NULL AND NOT EXISTS (
+			SELECT 1
+			FROM contract_sectors cs
+			WHERE cs.db_contract_id = c.id
+		)
+		UNION ALL
+		SELECT fcid, host_key, size, CASE WHEN contract_size > sector_size THEN contract_size - sector_size ELSE 0 END
+		FROM (
+			SELECT c.fcid, c.host_key, c.size, MAX(c.size) as contract_size, COUNT(*) * COALESCE(MAX(h.sector_size), ?) as sector_size
+			FROM contracts c
+			INNER JOIN contract_sectors cs ON cs.db_contract_id = c.id
+			LEFT JOIN hosts h ON h.id = c.host_id
+			WHERE archival_reason IS NULL
+			GROUP BY c.fcid
+		) i`
+
+	// fetch totals
+	if err := tx.QueryRow(ctx, fmt.Sprintf("SELECT COALESCE(SUM(size), 0), COALESCE(SUM(prunable), 0) FROM (%s) s", sizesQuery), rhpv4.SectorSize).
+		Scan(&resp.TotalSize, &resp.TotalPrunable); err != nil {
+		return api.ContractsPrunableDataResponse{}, fmt.Errorf("failed to fetch totals: %w", err)
+	}
+
+	// fetch contracts
+	rows, err := tx.Query(ctx, fmt.Sprintf("SELECT fcid, host_key, size, prunable FROM (%s) s ORDER BY %s, fcid ASC LIMIT ? OFFSET ?", sizesQuery, orderBy), rhpv4.SectorSize, opts.Limit, opts.Offset)
+	if err != nil {
+		return api.ContractsPrunableDataResponse{}, fmt.Errorf("failed to fetch contract sizes: %w", err)
+	}
+	defer rows.Close()
+
+	resp.Contracts = []api.ContractPrunableData{}
+	for rows.Next() {
+		var c api.ContractPrunableData
+		if err := rows.Scan((*FileContractID)(&c.ID), (*PublicKey)(&c.HostKey), &c.Size, &c.Prunable); err != nil {
+			return api.ContractsPrunableDataResponse{}, fmt.Errorf("failed to scan contract size: %w", err)
+		}
+		resp.Contracts = append(resp.Contracts, c)
+	}
+	return resp, nil
+}
+
 func CopyObject(ctx context.Context, tx sql.Tx, srcBucket, dstBucket, srcKey, dstKey, mimeType string, metadata api.ObjectUserMetadata) (api.ObjectMetadata, error) {
 	// stmt to fetch bucket id
 	bucketIDStmt, err := tx.Prepare(ctx, "SELECT id FROM buckets WHERE name = ?")
@@ -444,6 +769,14 @@ func CopyObject(ctx context.Context, tx sql.Tx, srcBucket, dstBucket, srcKey, ds
 		return api.ObjectMetadata{}, fmt.Errorf("failed to fetch object id: %w", err)
 	}
 
+	// update usage of destination bucket
+	_, _, size, err := ObjectsUsage(ctx, tx, "SELECT db_bucket_id, size FROM objects WHERE id = ?", dstObjID)
+	if err != nil {
+		return api.ObjectMetadata{}, err
+	} else if err := UpdateBucketUsage(ctx, tx, dstBID, 1, size); err != nil {
+		return api.ObjectMetadata{}, err
+	}
+
 	// copy slices
 	_, err = tx.Exec(ctx, `INSERT INTO slices (created_at, db_object_id, object_index, db_slab_id, offset, length)
 				SELECT ?, ?, object_index, db_slab_id, offset, length
@@ -565,6 +898,77 @@ func DeleteSetting(ctx context.Context, tx sql.Tx, key string) error {
 	return nil
 }
 
+func DismissedAlerts(ctx context.Context, tx sql.Tx, offset, limit int) ([]alerts.DismissedAlert, error) {
+	if limit == -1 {
+		limit = math.MaxInt64
+	}
+
+	rows, err := tx.Query(ctx, "SELECT alert_id, timestamp, reason, dismissed_by, alert FROM dismissed_alerts ORDER BY timestamp DESC, id DESC LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dismissed alerts: %w", err)
+	}
+	defer rows.Close()
+
+	dismissed := make([]alerts.DismissedAlert, 0)
+	for rows.Next() {
+		var da alerts.DismissedAlert
+		var alert dsql.NullString
+		if err := rows.Scan((*Hash256)(&da.AlertID), (*UnixTimeMS)(&da.Timestamp), &da.Reason, &da.DismissedBy, &alert); err != nil {
+			return nil, fmt.Errorf("failed to scan dismissed alert: %w", err)
+		} else if alert.Valid {
+			da.Alert = new(alerts.Alert)
+			if err := json.Unmarshal([]byte(alert.String), da.Alert); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal dismissed alert: %w", err)
+			}
+		}
+		dismissed = append(dismissed, da)
+	}
+	return dismissed, rows.Err()
+}
+
+func FailPendingContracts(ctx context.Context, tx sql.Tx, maxStartHeight, height uint64) ([]types.FileContractID, error) {
+	// fetch the contracts that are about to fail
+	rows, err := tx.Query(ctx, "SELECT fcid FROM contracts WHERE archival_reason IS NULL AND state = ? AND start_height <= ?",
+		ContractStateFromString(api.ContractStatePending),
+		maxStartHeight,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending contracts: %w", err)
+	}
+	defer rows.Close()
+
+	var failed []types.FileContractID
+	for rows.Next() {
+		var fcid types.FileContractID
+		if err := rows.Scan((*FileContractID)(&fcid)); err != nil {
+			return nil, fmt.Errorf("failed to scan contract id: %w", err)
+		}
+		failed = append(failed, fcid)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close rows: %w", err)
+	}
+
+	// mark them as failed
+	if _, err := tx.Exec(ctx,
+		"UPDATE contracts SET state = ? WHERE archival_reason IS NULL AND state = ? AND start_height <= ?",
+		ContractStateFromString(api.ContractStateFailed),
+		ContractStateFromString(api.ContractStatePending),
+		maxStartHeight,
+	); err != nil {
+		return nil, fmt.Errorf("failed to update pending contracts: %w", err)
+	}
+
+	for _, fcid := range failed {
+		if err := RecordContractStateChange(ctx, tx, fcid, api.ContractStatePending, api.ContractStateFailed, "contract was not confirmed in time", height); err != nil {
+			return nil, err
+		} else if err := RecordContractEvent(ctx, tx, fcid, api.ContractEventTypeStateChanged, map[string]string{"state": api.ContractStateFailed}); err != nil {
+			return nil, err
+		}
+	}
+	return failed, nil
+}
+
 func FetchUsedContracts(ctx context.Context, tx sql.Tx, fcids []types.FileContractID) (map[types.FileContractID]UsedContract, error) {
 	if len(fcids) == 0 {
 		return make(map[types.FileContractID]UsedContract), nil
@@ -794,6 +1198,7 @@ SELECT
 	h.failed_interactions,
 	COALESCE(h.lost_sectors, 0),
 	h.scanned,
+	h.sector_size,
 
 	%s,
 
@@ -838,7 +1243,7 @@ LEFT JOIN host_checks hc ON hc.db_host_id = h.id
 			(*HostSettings)(&h.V2Settings), &h.Interactions.TotalScans, (*UnixTimeMS)(&h.Interactions.LastScan), &h.Interactions.LastScanSuccess,
 			&h.Interactions.SecondToLastScanSuccess, (*DurationMS)(&h.Interactions.Uptime), (*DurationMS)(&h.Interactions.Downtime),
 			&h.Interactions.SuccessfulInteractions, &h.Interactions.FailedInteractions, &h.Interactions.LostSectors,
-			&h.Scanned, &h.Blocked, &h.Checks.UsabilityBreakdown.Blocked, &h.Checks.UsabilityBreakdown.Offline, &h.Checks.UsabilityBreakdown.LowScore, &h.Checks.UsabilityBreakdown.RedundantIP,
+			&h.Scanned, &h.SectorSize, &h.Blocked, &h.Checks.UsabilityBreakdown.Blocked, &h.Checks.UsabilityBreakdown.Offline, &h.Checks.UsabilityBreakdown.LowScore, &h.Checks.UsabilityBreakdown.RedundantIP,
 			&h.Checks.UsabilityBreakdown.Gouging, &h.Checks.UsabilityBreakdown.LowMaxDuration, &h.Checks.UsabilityBreakdown.NotAcceptingContracts, &h.Checks.UsabilityBreakdown.NotAnnounced, &h.Checks.UsabilityBreakdown.NotCompletingScan,
 			&h.Checks.ScoreBreakdown.Age, &h.Checks.ScoreBreakdown.Collateral, &h.Checks.ScoreBreakdown.Interactions, &h.Checks.ScoreBreakdown.StorageRemaining, &h.Checks.ScoreBreakdown.Uptime,
 			&h.Checks.ScoreBreakdown.Version, &h.Checks.ScoreBreakdown.Prices, &h.Checks.GougingBreakdown.DownloadErr, &h.Checks.GougingBreakdown.GougingErr,
@@ -950,13 +1355,117 @@ func InsertObject(ctx context.Context, tx sql.Tx, key string, bucketID, size int
 	if err != nil {
 		return 0, err
 	}
-	return res.LastInsertId()
+	objID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := UpdateBucketUsage(ctx, tx, bucketID, 1, size); err != nil {
+		return 0, err
+	}
+	return objID, nil
+}
+
+// InsertObjectVersion preserves the slab references of the given object by
+// moving its slices to a new object version that is kept around until the
+// next garbage collection.
+func InsertObjectVersion(ctx context.Context, tx sql.Tx, bucket, key string) (bool, error) {
+	var objID int64
+	err := tx.QueryRow(ctx, "SELECT o.id FROM objects o INNER JOIN buckets b ON b.id = o.db_bucket_id WHERE b.name = ? AND o.object_id = ?", bucket, key).Scan(&objID)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to fetch object id: %w", err)
+	}
+
+	res, err := tx.Exec(ctx, `INSERT INTO object_versions (created_at, db_bucket_id, object_id, `+"`key`"+`, size, mime_type, etag)
+		SELECT ?, db_bucket_id, object_id, `+"`key`"+`, size, mime_type, etag FROM objects WHERE id = ?`, time.Now(), objID)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert object version: %w", err)
+	}
+	versionID, err := res.LastInsertId()
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE slices SET db_object_id = NULL, db_object_version_id = ? WHERE db_object_id = ?", versionID, objID)
+	if err != nil {
+		return false, fmt.Errorf("failed to move slices to object version: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteObjectVersions deletes the object versions of the given bucket that
+// were created before the given time, releasing their slab references, and
+// returns the number of versions that were deleted.
+func DeleteObjectVersions(ctx context.Context, tx sql.Tx, bucket string, before time.Time) (int64, error) {
+	res, err := tx.Exec(ctx, "DELETE FROM object_versions WHERE db_bucket_id = (SELECT id FROM buckets WHERE name = ?) AND created_at < ?", bucket, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete object versions: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ObjectVersions returns the versions of the given object, the most recent
+// version comes first.
+func ObjectVersions(ctx context.Context, tx sql.Tx, bucket, key string) ([]api.ObjectVersion, error) {
+	rows, err := tx.Query(ctx, `SELECT ov.id, ov.created_at, ov.etag, ov.mime_type, ov.size
+		FROM object_versions ov
+		INNER JOIN buckets b ON b.id = ov.db_bucket_id
+		WHERE b.name = ? AND ov.object_id = ?
+		ORDER BY ov.created_at DESC, ov.id DESC`, bucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch object versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make([]api.ObjectVersion, 0)
+	for rows.Next() {
+		v := api.ObjectVersion{Bucket: bucket, Key: key}
+		if err := rows.Scan(&v.ID, (*time.Time)(&v.CreatedAt), &v.ETag, &v.MimeType, &v.Size); err != nil {
+			return nil, fmt.Errorf("failed to scan object version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// RestoreObjectVersion turns the given object version back into an object by
+// moving its slices to a newly inserted object, the caller is responsible for
+// making sure no object exists under the same key.
+func RestoreObjectVersion(ctx context.Context, tx sql.Tx, bucket, key string, id int64) error {
+	var bucketID, size int64
+	var ec EncryptionKey
+	var mimeType, eTag string
+	err := tx.QueryRow(ctx, `SELECT ov.db_bucket_id, ov.`+"`key`"+`, ov.size, ov.mime_type, ov.etag
+		FROM object_versions ov
+		INNER JOIN buckets b ON b.id = ov.db_bucket_id
+		WHERE ov.id = ? AND b.name = ? AND ov.object_id = ?`, id, bucket, key).
+		Scan(&bucketID, &ec, &size, &mimeType, &eTag)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return api.ErrObjectVersionNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch object version: %w", err)
+	}
+
+	objID, err := InsertObject(ctx, tx, key, bucketID, size, object.EncryptionKey(ec), mimeType, eTag)
+	if err != nil {
+		return fmt.Errorf("failed to insert object: %w", err)
+	}
+	_, err = tx.Exec(ctx, "UPDATE slices SET db_object_id = ?, db_object_version_id = NULL WHERE db_object_version_id = ?", objID, id)
+	if err != nil {
+		return fmt.Errorf("failed to move slices to object: %w", err)
+	}
+	_, err = tx.Exec(ctx, "DELETE FROM object_versions WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete object version: %w", err)
+	}
+	return nil
 }
 
 func LoadSlabBuffers(ctx context.Context, tx sql.Tx) (bufferedSlabs []LoadedSlabBuffer, orphanedBuffers []string, err error) {
 	// collect all buffers
 	rows, err := tx.Query(ctx, `
-			SELECT bs.id, bs.filename, sla.key, sla.min_shards, sla.total_shards
+			SELECT bs.id, bs.created_at, bs.filename, sla.key, sla.min_shards, sla.total_shards
 			FROM buffered_slabs bs
 			INNER JOIN slabs sla ON sla.db_buffered_slab_id = bs.id
 		`)
@@ -967,7 +1476,7 @@ func LoadSlabBuffers(ctx context.Context, tx sql.Tx) (bufferedSlabs []LoadedSlab
 
 	for rows.Next() {
 		var bs LoadedSlabBuffer
-		if err := rows.Scan(&bs.ID, &bs.Filename, (*EncryptionKey)(&bs.Key), &bs.MinShards, &bs.TotalShards); err != nil {
+		if err := rows.Scan(&bs.ID, &bs.CreatedAt, &bs.Filename, (*EncryptionKey)(&bs.Key), &bs.MinShards, &bs.TotalShards); err != nil {
 			return nil, nil, fmt.Errorf("failed to scan buffered slab: %w", err)
 		}
 		bufferedSlabs = append(bufferedSlabs, bs)
@@ -1023,6 +1532,38 @@ func UpdateMetadata(ctx context.Context, tx sql.Tx, objID int64, md api.ObjectUs
 	return nil
 }
 
+func UpdateObjectUserMetadata(ctx context.Context, tx sql.Tx, bucket, key string, md api.ObjectUserMetadata) error {
+	var objID int64
+	err := tx.QueryRow(ctx, `
+		SELECT o.id
+		FROM objects o
+		INNER JOIN buckets b ON b.id = o.db_bucket_id
+		WHERE o.object_id = ? AND b.name = ?
+	`, key, bucket).Scan(&objID)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return api.ErrObjectNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch object id: %w", err)
+	}
+	return UpdateMetadata(ctx, tx, objID, md)
+}
+
+func UpdateObjectMimeType(ctx context.Context, tx sql.Tx, bucket, key, mimeType string) error {
+	res, err := tx.Exec(ctx, `
+		UPDATE objects
+		SET mime_type = ?
+		WHERE object_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?)
+	`, mimeType, key, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to update mime type: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return api.ErrObjectNotFound
+	}
+	return nil
+}
+
 func PrepareSlabHealth(ctx context.Context, tx sql.Tx, limit int64, now time.Time) error {
 	_, err := tx.Exec(ctx, "DROP TABLE IF EXISTS slabs_health")
 	if err != nil {
@@ -1356,12 +1897,12 @@ func NormalizePeer(peer string) (string, error) {
 	return normalized.String(), nil
 }
 
-func Objects(ctx context.Context, tx Tx, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey) (resp api.ObjectsResponse, err error) {
+func Objects(ctx context.Context, tx Tx, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey, metadata api.ObjectUserMetadata) (resp api.ObjectsResponse, err error) {
 	switch delim {
 	case "":
-		resp, err = listObjectsNoDelim(ctx, tx, bucket, prefix, substring, sortBy, sortDir, marker, limit, slabEncryptionKey)
+		resp, err = listObjectsNoDelim(ctx, tx, bucket, prefix, substring, sortBy, sortDir, marker, limit, slabEncryptionKey, metadata)
 	case "/":
-		resp, err = listObjectsSlashDelim(ctx, tx, bucket, prefix, sortBy, sortDir, marker, limit, slabEncryptionKey)
+		resp, err = listObjectsSlashDelim(ctx, tx, bucket, prefix, sortBy, sortDir, marker, limit, slabEncryptionKey, metadata)
 	default:
 		err = fmt.Errorf("unsupported delimiter: '%s'", delim)
 	}
@@ -1573,6 +2114,64 @@ func Peers(ctx context.Context, tx sql.Tx) ([]syncer.PeerInfo, error) {
 	return peers, nil
 }
 
+// prunableHostSectorsQuery matches host-sector links that are no longer linked
+// to an active contract.
+const prunableHostSectorsQuery = `db_host_id NOT IN (
+	SELECT h.id
+	FROM contracts c
+	INNER JOIN hosts h ON c.host_id = h.id
+	WHERE c.archival_reason IS NULL
+)`
+
+func PrunableHostSectors(ctx context.Context, tx sql.Tx) (n int64, err error) {
+	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM host_sectors WHERE "+prunableHostSectorsQuery).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count prunable host sectors: %w", err)
+	}
+	return
+}
+
+func PruneHostSectors(ctx context.Context, tx sql.Tx, cursor HostSectorCursor, limit int64) (int64, HostSectorCursor, error) {
+	if limit <= 0 {
+		return 0, cursor, nil
+	}
+	afterCursor := "(db_sector_id > ? OR (db_sector_id = ? AND db_host_id > ?))"
+
+	// find the last host sector of the batch
+	var last HostSectorCursor
+	err := tx.QueryRow(ctx, fmt.Sprintf(`
+		SELECT db_sector_id, db_host_id
+		FROM host_sectors
+		WHERE %s AND %s
+		ORDER BY db_sector_id, db_host_id
+		LIMIT 1 OFFSET ?`, afterCursor, prunableHostSectorsQuery),
+		cursor.SectorID, cursor.SectorID, cursor.HostID, limit-1).
+		Scan(&last.SectorID, &last.HostID)
+	if errors.Is(err, dsql.ErrNoRows) {
+		// less than 'limit' host sectors left, delete them all
+		res, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM host_sectors WHERE %s AND %s", afterCursor, prunableHostSectorsQuery),
+			cursor.SectorID, cursor.SectorID, cursor.HostID)
+		if err != nil {
+			return 0, cursor, fmt.Errorf("failed to delete host sectors: %w", err)
+		}
+		deleted, err := res.RowsAffected()
+		return deleted, HostSectorCursor{}, err
+	} else if err != nil {
+		return 0, cursor, fmt.Errorf("failed to fetch last host sector of batch: %w", err)
+	}
+
+	// delete the batch
+	res, err := tx.Exec(ctx, fmt.Sprintf(`
+		DELETE FROM host_sectors
+		WHERE %s AND (db_sector_id < ? OR (db_sector_id = ? AND db_host_id <= ?)) AND %s`, afterCursor, prunableHostSectorsQuery),
+		cursor.SectorID, cursor.SectorID, cursor.HostID, last.SectorID, last.SectorID, last.HostID)
+	if err != nil {
+		return 0, cursor, fmt.Errorf("failed to delete host sectors: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	return deleted, last, err
+}
+
 func PruneSlabs(ctx context.Context, tx sql.Tx, limit int64) (int64, error) {
 	res, err := tx.Exec(ctx, `
 	DELETE FROM slabs
@@ -1619,6 +2218,12 @@ func RecordHostScans(ctx context.Context, tx sql.Tx, scans []api.HostScan) error
 	}
 	defer stmt.Close()
 
+	versionStmt, err := tx.Prepare(ctx, "UPDATE contracts SET host_version = ? WHERE host_key = ? AND archival_reason IS NULL")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement to update host version of contracts: %w", err)
+	}
+	defer versionStmt.Close()
+
 	for _, scan := range scans {
 		scanTime := scan.Timestamp.UnixMilli()
 		_, err = stmt.Exec(ctx,
@@ -1637,44 +2242,115 @@ func RecordHostScans(ctx context.Context, tx sql.Tx, scans []api.HostScan) error
 		if err != nil {
 			return fmt.Errorf("failed to update host with scan: %w", err)
 		}
+
+		// update the host version of the host's contracts
+		if scan.Success {
+			_, err = versionStmt.Exec(ctx, scan.V2Settings.Release, PublicKey(scan.HostKey))
+			if err != nil {
+				return fmt.Errorf("failed to update host version of contracts: %w", err)
+			}
+		}
 	}
 	return nil
 }
 
-func RemoveOfflineHosts(ctx context.Context, tx sql.Tx, minRecentFailures uint64, maxDownTime time.Duration) (int64, error) {
-	// fetch contracts belonging to offline hosts
+func RemoveOfflineHosts(ctx context.Context, tx sql.Tx, minRecentFailures uint64, maxDownTime time.Duration, exemptActiveContractHosts bool, limit, maxEligible int) (removed, eligible int64, exempted []types.PublicKey, _ error) {
+	// fetch offline hosts and whether they have pending or active contracts
 	rows, err := tx.Query(ctx, `
-SELECT fcid
-FROM contracts c
-INNER JOIN hosts h ON h.public_key = c.host_key
-WHERE h.recent_downtime >= ? AND h.recent_scan_failures >= ?`, DurationMS(maxDownTime), minRecentFailures)
+SELECT h.public_key, EXISTS (
+	SELECT 1
+	FROM contracts c
+	WHERE c.host_key = h.public_key AND c.archival_reason IS NULL AND c.state IN (?, ?)
+)
+FROM hosts h
+WHERE h.recent_downtime >= ? AND h.recent_scan_failures >= ?
+ORDER BY h.recent_downtime DESC`,
+		ContractStateFromString(api.ContractStatePending),
+		ContractStateFromString(api.ContractStateActive),
+		DurationMS(maxDownTime),
+		minRecentFailures,
+	)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch contracts: %w", err)
+		return 0, 0, nil, fmt.Errorf("failed to fetch offline hosts: %w", err)
 	}
 	defer rows.Close()
 
-	var fcids []types.FileContractID
+	var hks []types.PublicKey
 	for rows.Next() {
-		var fcid FileContractID
-		if err := rows.Scan(&fcid); err != nil {
-			return 0, fmt.Errorf("failed to scan contract: %w", err)
+		var hk types.PublicKey
+		var hasActiveContracts bool
+		if err := rows.Scan((*PublicKey)(&hk), &hasActiveContracts); err != nil {
+			return 0, 0, nil, fmt.Errorf("failed to scan host: %w", err)
+		} else if exemptActiveContractHosts && hasActiveContracts {
+			exempted = append(exempted, hk)
+		} else {
+			hks = append(hks, hk)
 		}
-		fcids = append(fcids, types.FileContractID(fcid))
+	}
+	if err := rows.Close(); err != nil {
+		return 0, 0, nil, fmt.Errorf("failed to close rows: %w", err)
 	}
 
-	// archive those contracts
-	for _, fcid := range fcids {
-		if err := ArchiveContract(ctx, tx, fcid, api.ContractArchivalReasonHostPruned); err != nil {
-			return 0, fmt.Errorf("failed to archive contract %v: %w", fcid, err)
+	// don't remove any hosts if there are too many of them, and only remove
+	// up to 'limit' hosts otherwise
+	eligible = int64(len(hks))
+	if maxEligible > 0 && len(hks) > maxEligible {
+		return 0, eligible, exempted, nil
+	} else if limit > 0 && len(hks) > limit {
+		hks = hks[:limit]
+	}
+
+	for _, hk := range hks {
+		// fetch contracts belonging to the host
+		rows, err := tx.Query(ctx, "SELECT fcid FROM contracts WHERE host_key = ?", PublicKey(hk))
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("failed to fetch contracts: %w", err)
+		}
+		var fcids []types.FileContractID
+		for rows.Next() {
+			var fcid FileContractID
+			if err := rows.Scan(&fcid); err != nil {
+				rows.Close()
+				return 0, 0, nil, fmt.Errorf("failed to scan contract: %w", err)
+			}
+			fcids = append(fcids, types.FileContractID(fcid))
+		}
+		if err := rows.Close(); err != nil {
+			return 0, 0, nil, fmt.Errorf("failed to close rows: %w", err)
+		}
+
+		// archive those contracts
+		for _, fcid := range fcids {
+			if err := ArchiveContract(ctx, tx, fcid, api.ContractArchivalReasonHostPruned); err != nil {
+				return 0, 0, nil, fmt.Errorf("failed to archive contract %v: %w", fcid, err)
+			}
+		}
+
+		// delete the host
+		res, err := tx.Exec(ctx, "DELETE FROM hosts WHERE public_key = ?", PublicKey(hk))
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("failed to delete host %v: %w", hk, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("failed to get rows affected: %w", err)
 		}
+		removed += n
 	}
+	return removed, eligible, exempted, nil
+}
 
-	// delete hosts
-	res, err := tx.Exec(ctx, `DELETE FROM hosts WHERE recent_downtime >= ? AND recent_scan_failures >= ?`, DurationMS(maxDownTime), minRecentFailures)
+// ObjectsUsage returns the bucket, the number of objects and their combined
+// size for the objects selected by the given query. The query is expected to
+// select the 'db_bucket_id' and 'size' columns of objects within a single
+// bucket, it's used to update the bucket's usage before deleting the objects.
+func ObjectsUsage(ctx context.Context, tx sql.Tx, query string, args ...any) (bucketID, objects, bytes int64, _ error) {
+	err := tx.QueryRow(ctx, fmt.Sprintf("SELECT COALESCE(MIN(db_bucket_id), 0), COUNT(*), COALESCE(SUM(size), 0) FROM (%s) AS u", query), args...).
+		Scan(&bucketID, &objects, &bytes)
 	if err != nil {
-		return 0, fmt.Errorf("failed to delete hosts: %w", err)
+		return 0, 0, 0, fmt.Errorf("failed to fetch objects usage: %w", err)
 	}
-	return res.RowsAffected()
+	return
 }
 
 func QueryContracts(ctx context.Context, tx sql.Tx, whereExprs []string, whereArgs []any) ([]api.ContractMetadata, error) {
@@ -1687,8 +2363,9 @@ func QueryContracts(ctx context.Context, tx sql.Tx, whereExprs []string, whereAr
 SELECT
 	c.fcid, c.host_id, c.host_key,
 	c.archival_reason, c.proof_height, c.renewed_from, c.renewed_to, c.revision_height, c.revision_number, c.size, c.start_height, c.state, c.usability, c.window_start, c.window_end,
-	c.contract_price, c.initial_renter_funds,
-	c.delete_spending, c.fund_account_spending, c.sector_roots_spending, c.upload_spending
+	c.contract_price, c.initial_renter_funds, c.network_fees,
+	c.delete_spending, c.fund_account_spending, c.sector_roots_spending, c.upload_spending,
+	COALESCE(c.spending_cap, '0'), c.usability_override, c.verification_status, c.host_version
 FROM contracts AS c
 %s
 ORDER BY c.id ASC`, whereExpr), whereArgs...)
@@ -1756,6 +2433,22 @@ func ResetLostSectors(ctx context.Context, tx sql.Tx, hk types.PublicKey) error
 	return nil
 }
 
+func UpdateHostSectorSize(ctx context.Context, tx sql.Tx, hk types.PublicKey, sectorSize uint64) error {
+	var id int64
+	err := tx.QueryRow(ctx, "SELECT id FROM hosts WHERE public_key = ?", PublicKey(hk)).Scan(&id)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return api.ErrHostNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch host: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE hosts SET sector_size = ? WHERE id = ?", sectorSize, id)
+	if err != nil {
+		return fmt.Errorf("failed to update sector size for host %v: %w", hk, err)
+	}
+	return nil
+}
+
 func Setting(ctx context.Context, tx sql.Tx, key string) (string, error) {
 	var value string
 	err := tx.QueryRow(ctx, "SELECT value FROM settings WHERE `key` = ?", key).Scan((*BusSetting)(&value))
@@ -1905,9 +2598,37 @@ func Tip(ctx context.Context, tx sql.Tx) (types.ChainIndex, error) {
 	}, nil
 }
 
+func SlabBufferObjects(ctx context.Context, tx sql.Tx) (map[int64][]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT sla.db_buffered_slab_id, o.object_id
+		FROM slabs sla
+		INNER JOIN slices sli ON sli.db_slab_id = sla.id
+		INNER JOIN objects o ON o.id = sli.db_object_id
+		WHERE sla.db_buffered_slab_id IS NOT NULL
+		ORDER BY o.object_id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch slab buffer objects: %w", err)
+	}
+	defer rows.Close()
+
+	objects := make(map[int64][]string)
+	for rows.Next() {
+		var bufferID int64
+		var key string
+		if err := rows.Scan(&bufferID, &key); err != nil {
+			return nil, fmt.Errorf("failed to scan slab buffer object: %w", err)
+		}
+		objects[bufferID] = append(objects[bufferID], key)
+	}
+	return objects, rows.Err()
+}
+
+// SlabsForMigration returns the slabs whose health is below the cutoff. The
+// number of healthy shards is derived from the slab's cached health.
 func SlabsForMigration(ctx context.Context, tx sql.Tx, healthCutoff float64, limit int) ([]api.UnhealthySlab, error) {
 	rows, err := tx.Query(ctx, `
-		SELECT sla.key, sla.health
+		SELECT sla.key, sla.health, sla.min_shards, sla.total_shards
 		FROM slabs sla
 		WHERE sla.health <= ? AND sla.health_valid_until > ? AND sla.db_buffered_slab_id IS NULL
 		ORDER BY sla.health ASC
@@ -1921,14 +2642,29 @@ func SlabsForMigration(ctx context.Context, tx sql.Tx, healthCutoff float64, lim
 	var slabs []api.UnhealthySlab
 	for rows.Next() {
 		var slab api.UnhealthySlab
-		if err := rows.Scan((*EncryptionKey)(&slab.EncryptionKey), &slab.Health); err != nil {
+		var minShards uint8
+		if err := rows.Scan((*EncryptionKey)(&slab.EncryptionKey), &slab.Health, &minShards, &slab.TotalShards); err != nil {
 			return nil, fmt.Errorf("failed to scan unhealthy slab: %w", err)
 		}
+		slab.HealthyShards = healthyShards(slab.Health, minShards, slab.TotalShards)
 		slabs = append(slabs, slab)
 	}
 	return slabs, nil
 }
 
+// healthyShards is the inverse of the health computation in PrepareSlabHealth,
+// slabs without redundancy are either fully healthy or lost.
+func healthyShards(health float64, minShards, totalShards uint8) uint8 {
+	if minShards == totalShards {
+		if health < 0 {
+			return 0
+		}
+		return totalShards
+	}
+	healthy := math.Round(health*float64(totalShards-minShards) + float64(minShards))
+	return uint8(max(0, min(healthy, float64(totalShards))))
+}
+
 func UpdateBucketPolicy(ctx context.Context, tx sql.Tx, bucket string, bp api.BucketPolicy) error {
 	policy, err := json.Marshal(bp)
 	if err != nil {
@@ -1945,6 +2681,59 @@ func UpdateBucketPolicy(ctx context.Context, tx sql.Tx, bucket string, bp api.Bu
 	return nil
 }
 
+// UpdateBucketRedundancy updates the redundancy settings of a bucket, nil
+// clears them.
+func UpdateBucketRedundancy(ctx context.Context, tx sql.Tx, bucket string, rs *api.RedundancySettings) error {
+	var redundancy any
+	if rs != nil {
+		b, err := json.Marshal(rs)
+		if err != nil {
+			return err
+		}
+		redundancy = string(b)
+	}
+	res, err := tx.Exec(ctx, "UPDATE buckets SET redundancy = ? WHERE name = ?", redundancy, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to update bucket redundancy: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	} else if n == 0 {
+		return api.ErrBucketNotFound
+	}
+	return nil
+}
+
+// MoveObjectBucketUsage moves the usage of the object with the given key in the
+// destination bucket from the source bucket over to the destination bucket.
+func MoveObjectBucketUsage(ctx context.Context, tx sql.Tx, srcBucket string, dstBucketID int64, key string) error {
+	var srcBucketID, size int64
+	if err := tx.QueryRow(ctx, "SELECT id FROM buckets WHERE name = ?", srcBucket).Scan(&srcBucketID); err != nil {
+		return fmt.Errorf("failed to fetch src bucket id: %w", err)
+	} else if err := tx.QueryRow(ctx, "SELECT size FROM objects WHERE object_id = ? AND db_bucket_id = ?", key, dstBucketID).Scan(&size); err != nil {
+		return fmt.Errorf("failed to fetch object size: %w", err)
+	} else if err := UpdateBucketUsage(ctx, tx, srcBucketID, -1, -size); err != nil {
+		return err
+	}
+	return UpdateBucketUsage(ctx, tx, dstBucketID, 1, size)
+}
+
+// UpdateBucketUsage adds the given deltas to the usage counters of a bucket.
+// It's expected to be called after the objects were inserted or deleted since
+// a bucket without counters is recounted, which already covers the change.
+func UpdateBucketUsage(ctx context.Context, tx sql.Tx, bucketID, objects, bytes int64) error {
+	res, err := tx.Exec(ctx, "UPDATE bucket_usage SET object_count = object_count + ?, total_bytes = total_bytes + ?, last_updated = ? WHERE db_bucket_id = ?",
+		objects, bytes, time.Now(), bucketID)
+	if err != nil {
+		return fmt.Errorf("failed to update bucket usage: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to fetch rows affected: %w", err)
+	} else if n == 0 {
+		_, err = recountBucketUsage(ctx, tx, bucketID)
+		return err
+	}
+	return nil
+}
+
 func UpdateContract(ctx context.Context, tx sql.Tx, fcid types.FileContractID, c api.ContractMetadata) error {
 	// validate metadata
 	var state ContractState
@@ -1961,18 +2750,20 @@ func UpdateContract(ctx context.Context, tx sql.Tx, fcid types.FileContractID, c
 		return errors.New("host key is required")
 	}
 
-	// update contract
+	// update contract, an overridden usability is carried over to the renewal
 	_, err := tx.Exec(ctx, `
 UPDATE contracts SET
 	created_at = ?, fcid = ?,
-	proof_height = ?, renewed_from = ?, revision_height = ?, revision_number = ?, size = ?, start_height = ?, state = ?, usability = ?, window_start = ?, window_end = ?,
-	contract_price = ?, initial_renter_funds = ?,
-	delete_spending = ?, fund_account_spending = ?, sector_roots_spending = ?, upload_spending = ?
+	proof_height = ?, renewed_from = ?, revision_height = ?, revision_number = ?, size = ?, start_height = ?, state = ?, usability = CASE WHEN usability_override THEN usability ELSE ? END, window_start = ?, window_end = ?,
+	contract_price = ?, initial_renter_funds = ?, network_fees = ?,
+	delete_spending = ?, fund_account_spending = ?, sector_roots_spending = ?, upload_spending = ?, spending_cap = ?,
+	host_version = CASE WHEN ? = '' THEN host_version ELSE ? END
 WHERE fcid = ?`,
 		time.Now(), FileContractID(c.ID),
 		0, FileContractID(c.RenewedFrom), 0, fmt.Sprint(c.RevisionNumber), c.Size, c.StartHeight, state, usability, c.WindowStart, c.WindowEnd,
-		Currency(c.ContractPrice), Currency(c.InitialRenterFunds),
-		ZeroCurrency, ZeroCurrency, ZeroCurrency, ZeroCurrency,
+		Currency(c.ContractPrice), Currency(c.InitialRenterFunds), Currency(c.NetworkFees),
+		ZeroCurrency, ZeroCurrency, ZeroCurrency, ZeroCurrency, Currency(c.SpendingCap),
+		c.HostVersion, c.HostVersion,
 		FileContractID(c.RenewedFrom),
 	)
 	if err != nil {
@@ -1981,19 +2772,93 @@ WHERE fcid = ?`,
 	return nil
 }
 
+func UpdateContractSpendingCap(ctx context.Context, tx sql.Tx, fcid types.FileContractID, spendingCap types.Currency) error {
+	id, err := contractID(ctx, tx, fcid)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE contracts SET spending_cap = ? WHERE id = ?", Currency(spendingCap), id)
+	if err != nil {
+		return fmt.Errorf("failed to update spending cap: %w", err)
+	}
+	return nil
+}
+
+// UpdateContractUsability updates the usability of the given contract unless
+// the user overrode it.
 func UpdateContractUsability(ctx context.Context, tx sql.Tx, fcid types.FileContractID, usability string) error {
 	var u ContractUsability
 	if err := u.LoadString(usability); err != nil {
 		return err
 	}
 
-	_, err := tx.Exec(ctx, `UPDATE contracts SET usability = ? WHERE fcid = ?`, u, FileContractID(fcid))
+	_, err := tx.Exec(ctx, `UPDATE contracts SET usability = ? WHERE fcid = ? AND usability_override = ?`, u, FileContractID(fcid), false)
 	if errors.Is(err, dsql.ErrNoRows) {
 		return api.ErrContractNotFound
 	}
 	return err
 }
 
+func UpdateContractUsabilityOverride(ctx context.Context, tx sql.Tx, fcid types.FileContractID, usability string) error {
+	var u ContractUsability
+	if err := u.LoadString(usability); err != nil {
+		return err
+	}
+
+	id, err := contractID(ctx, tx, fcid)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "UPDATE contracts SET usability = ?, usability_override = ? WHERE id = ?", u, true, id)
+	if err != nil {
+		return fmt.Errorf("failed to override contract usability: %w", err)
+	}
+	return nil
+}
+
+func DeleteContractUsabilityOverride(ctx context.Context, tx sql.Tx, fcid types.FileContractID) error {
+	id, err := contractID(ctx, tx, fcid)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "UPDATE contracts SET usability_override = ? WHERE id = ?", false, id)
+	if err != nil {
+		return fmt.Errorf("failed to remove contract usability override: %w", err)
+	}
+	return nil
+}
+
+func UpdateContractVerificationStatus(ctx context.Context, tx sql.Tx, fcid types.FileContractID, status string) error {
+	var s ContractVerificationStatus
+	if err := s.LoadString(status); err != nil {
+		return err
+	}
+
+	id, err := contractID(ctx, tx, fcid)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "UPDATE contracts SET verification_status = ? WHERE id = ?", s, id)
+	if err != nil {
+		return fmt.Errorf("failed to update contract verification status: %w", err)
+	}
+	return nil
+}
+
+// contractID returns the id of the contract with given fcid, we don't rely on
+// the number of affected rows to detect missing contracts since MySQL doesn't
+// count rows that didn't change
+func contractID(ctx context.Context, tx sql.Tx, fcid types.FileContractID) (id int64, err error) {
+	err = tx.QueryRow(ctx, "SELECT id FROM contracts WHERE fcid = ?", FileContractID(fcid)).Scan(&id)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return 0, api.ErrContractNotFound
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to fetch contract: %w", err)
+	}
+	return id, nil
+}
+
 func UpdateAutopilotConfig(ctx context.Context, tx sql.Tx, cfg api.AutopilotConfig) error {
 	_, err := tx.Exec(ctx, `
 UPDATE autopilot_config
@@ -2005,6 +2870,14 @@ SET enabled = ?,
 	contracts_upload = ?,
 	contracts_storage = ?,
 	contracts_prune = ?,
+	contracts_max_per_subnet = ?,
+	contracts_max_per_host = ?,
+	contracts_prune_alert_threshold = ?,
+	contracts_min_wallet_balance = ?,
+	contracts_storage_projection = ?,
+	contracts_min_contract_lifetime = ?,
+	contracts_period_multiplier = ?,
+	contracts_period_multiplier_growth_factor = ?,
 	hosts_max_downtime_hours = ?,
 	hosts_min_protocol_version = ?,
 	hosts_max_consecutive_scan_failures = ?
@@ -2017,6 +2890,14 @@ WHERE id = ?`,
 		cfg.Contracts.Upload,
 		cfg.Contracts.Storage,
 		cfg.Contracts.Prune,
+		cfg.Contracts.MaxContractsPerSubnet,
+		cfg.Contracts.MaxContractsPerHost,
+		cfg.Contracts.PruneAlertThreshold,
+		Currency(cfg.Contracts.MinWalletBalance),
+		StorageProjection(cfg.Contracts.StorageProjection),
+		cfg.Contracts.MinContractLifetimeBlocks,
+		cfg.Contracts.PeriodMultiplier,
+		cfg.Contracts.PeriodMultiplierGrowthFactor,
 		cfg.Hosts.MaxDowntimeHours,
 		cfg.Hosts.MinProtocolVersion,
 		cfg.Hosts.MaxConsecutiveScanFailures,
@@ -2287,10 +3168,29 @@ func WalletReleaseOutputs(ctx context.Context, tx sql.Tx, scois []types.SiacoinO
 	return err
 }
 
+func recountBucketUsage(ctx context.Context, tx sql.Tx, bucketID int64) (api.BucketUsage, error) {
+	usage := api.BucketUsage{LastUpdated: api.TimeRFC3339(time.Now())}
+	err := tx.QueryRow(ctx, "SELECT COUNT(*), COALESCE(SUM(size), 0) FROM objects WHERE db_bucket_id = ?", bucketID).
+		Scan(&usage.ObjectCount, &usage.TotalBytes)
+	if err != nil {
+		return api.BucketUsage{}, fmt.Errorf("failed to count objects: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM bucket_usage WHERE db_bucket_id = ?", bucketID); err != nil {
+		return api.BucketUsage{}, fmt.Errorf("failed to delete bucket usage: %w", err)
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO bucket_usage (created_at, db_bucket_id, object_count, total_bytes, last_updated) VALUES (?, ?, ?, ?, ?)",
+		time.Now(), bucketID, usage.ObjectCount, usage.TotalBytes, time.Time(usage.LastUpdated))
+	if err != nil {
+		return api.BucketUsage{}, fmt.Errorf("failed to insert bucket usage: %w", err)
+	}
+	return usage, nil
+}
+
 func scanBucket(s Scanner) (api.Bucket, error) {
 	var createdAt time.Time
-	var name, policy string
-	err := s.Scan(&createdAt, &name, &policy)
+	var name, policy, redundancy string
+	err := s.Scan(&createdAt, &name, &policy, &redundancy)
 	if errors.Is(err, dsql.ErrNoRows) {
 		return api.Bucket{}, api.ErrBucketNotFound
 	} else if err != nil {
@@ -2300,10 +3200,18 @@ func scanBucket(s Scanner) (api.Bucket, error) {
 	if err := json.Unmarshal([]byte(policy), &bp); err != nil {
 		return api.Bucket{}, err
 	}
+	var rs *api.RedundancySettings
+	if redundancy != "" {
+		rs = new(api.RedundancySettings)
+		if err := json.Unmarshal([]byte(redundancy), rs); err != nil {
+			return api.Bucket{}, err
+		}
+	}
 	return api.Bucket{
-		CreatedAt: api.TimeRFC3339(createdAt),
-		Name:      name,
-		Policy:    bp,
+		CreatedAt:  api.TimeRFC3339(createdAt),
+		Name:       name,
+		Policy:     bp,
+		Redundancy: rs,
 	}, nil
 }
 
@@ -2474,6 +3382,92 @@ func MarkPackedSlabUploaded(ctx context.Context, tx Tx, slab api.UploadedPackedS
 	return bufferFileName, nil
 }
 
+func RecordDismissedAlerts(ctx context.Context, tx sql.Tx, dismissed ...alerts.DismissedAlert) error {
+	insertStmt, err := tx.Prepare(ctx, "INSERT INTO dismissed_alerts (created_at, alert_id, timestamp, reason, dismissed_by, alert) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement to insert dismissed alert: %w", err)
+	}
+	defer insertStmt.Close()
+
+	for _, da := range dismissed {
+		var alert dsql.NullString
+		if da.Alert != nil {
+			b, err := json.Marshal(da.Alert)
+			if err != nil {
+				return fmt.Errorf("failed to marshal dismissed alert: %w", err)
+			}
+			alert = dsql.NullString{String: string(b), Valid: true}
+		}
+		if _, err := insertStmt.Exec(ctx, time.Now(), Hash256(da.AlertID), UnixTimeMS(da.Timestamp), da.Reason, da.DismissedBy, alert); err != nil {
+			return fmt.Errorf("failed to insert dismissed alert: %w", err)
+		}
+	}
+	return nil
+}
+
+// RecountBucketUsage rebuilds the usage counters of a bucket from its objects.
+func RecountBucketUsage(ctx context.Context, tx sql.Tx, bucket string) (api.BucketUsage, error) {
+	var bucketID int64
+	err := tx.QueryRow(ctx, "SELECT id FROM buckets WHERE name = ?", bucket).Scan(&bucketID)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return api.BucketUsage{}, api.ErrBucketNotFound
+	} else if err != nil {
+		return api.BucketUsage{}, fmt.Errorf("failed to fetch bucket id: %w", err)
+	}
+	return recountBucketUsage(ctx, tx, bucketID)
+}
+
+func RecordContractEvent(ctx context.Context, tx sql.Tx, fcid types.FileContractID, eventType string, details any) error {
+	var detailsStr dsql.NullString
+	if details != nil {
+		b, err := json.Marshal(details)
+		if err != nil {
+			return fmt.Errorf("failed to marshal contract event details: %w", err)
+		}
+		detailsStr = dsql.NullString{String: string(b), Valid: true}
+	}
+
+	res, err := tx.Exec(ctx, `
+		INSERT INTO contract_events (created_at, db_contract_id, timestamp, event_type, details)
+		SELECT ?, id, ?, ?, ? FROM contracts WHERE fcid = ?`,
+		time.Now(), UnixTimeMS(time.Now()), eventType, detailsStr, FileContractID(fcid))
+	if err != nil {
+		return fmt.Errorf("failed to record contract event: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	} else if n == 0 {
+		return api.ErrContractNotFound
+	}
+	return nil
+}
+
+// RecordContractStateChange records the transition of a contract from one state
+// to another. The transition is validated and an error is returned if it's
+// invalid, which reverts the update. Transitions caused by the chain are
+// recorded by UpdateContractState instead, which doesn't fail on invalid
+// transitions.
+func RecordContractStateChange(ctx context.Context, tx sql.Tx, fcid types.FileContractID, from, to api.ContractState, reason string, height uint64) error {
+	if err := api.ValidateStateTransition(from, to); err != nil {
+		return err
+	}
+	return insertContractStateChange(ctx, tx, fcid, from, to, reason, height)
+}
+
+func insertContractStateChange(ctx context.Context, tx sql.Tx, fcid types.FileContractID, from, to api.ContractState, reason string, height uint64) error {
+	res, err := tx.Exec(ctx, `
+		INSERT INTO contract_state_changes (created_at, db_contract_id, from_state, to_state, reason, height, timestamp)
+		SELECT ?, id, ?, ?, ?, ?, ? FROM contracts WHERE fcid = ?`,
+		time.Now(), string(from), string(to), reason, height, UnixTimeMS(time.Now()), FileContractID(fcid))
+	if err != nil {
+		return fmt.Errorf("failed to record contract state change: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	} else if n == 0 {
+		return api.ErrContractNotFound
+	}
+	return nil
+}
+
 func RecordContractSpending(ctx context.Context, tx Tx, fcid types.FileContractID, revisionNumber, size uint64, newSpending api.ContractSpending) error {
 	var updateKeys []string
 	var updateValues []interface{}
@@ -2506,6 +3500,19 @@ func RecordContractSpending(ctx context.Context, tx Tx, fcid types.FileContractI
 	if err != nil {
 		return fmt.Errorf("failed to record contract spending: %w", err)
 	}
+
+	// keep track of the revision history, spending can be recorded multiple
+	// times for the same revision so we only insert unseen revisions
+	_, err = tx.Exec(ctx, `
+		INSERT INTO contract_revisions (created_at, db_contract_id, revision_number, size, timestamp)
+		SELECT ?, c.id, ?, ?, ? FROM contracts c
+		WHERE c.fcid = ? AND NOT EXISTS (
+			SELECT 1 FROM contract_revisions cr WHERE cr.db_contract_id = c.id AND cr.revision_number = ?
+		)
+	`, time.Now(), Uint64Str(revisionNumber), size, UnixTimeMS(time.Now()), FileContractID(fcid), Uint64Str(revisionNumber))
+	if err != nil {
+		return fmt.Errorf("failed to record contract revision: %w", err)
+	}
 	return nil
 }
 
@@ -2628,7 +3635,7 @@ func fillInV2Addresses(ctx context.Context, tx sql.Tx, hostIDs []int64, assignFn
 	return nil
 }
 
-func listObjectsNoDelim(ctx context.Context, tx Tx, bucket, prefix, substring, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey) (api.ObjectsResponse, error) {
+func listObjectsNoDelim(ctx context.Context, tx Tx, bucket, prefix, substring, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey, metadata api.ObjectUserMetadata) (api.ObjectsResponse, error) {
 	// fetch one more to see if there are more entries
 	if limit <= -1 {
 		limit = math.MaxInt
@@ -2705,6 +3712,11 @@ func listObjectsNoDelim(ctx context.Context, tx Tx, bucket, prefix, substring, s
 		whereArgs = append(whereArgs, EncryptionKey(slabEncryptionKey))
 	}
 
+	// apply user metadata
+	metadataExprs, metadataArgs := whereObjectUserMetadata(metadata)
+	whereExprs = append(whereExprs, metadataExprs...)
+	whereArgs = append(whereArgs, metadataArgs...)
+
 	// apply limit
 	whereArgs = append(whereArgs, limit)
 
@@ -2758,7 +3770,22 @@ func listObjectsNoDelim(ctx context.Context, tx Tx, bucket, prefix, substring, s
 	}, nil
 }
 
-func listObjectsSlashDelim(ctx context.Context, tx Tx, bucket, prefix, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey) (api.ObjectsResponse, error) {
+// whereObjectUserMetadata returns the expressions and arguments to filter
+// objects, aliased as 'o', by the given user metadata entries.
+func whereObjectUserMetadata(metadata api.ObjectUserMetadata) (exprs []string, args []any) {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		exprs = append(exprs, "EXISTS(SELECT 1 FROM object_user_metadata oum WHERE oum.db_object_id = o.id AND oum.key = ? AND oum.value = ?)")
+		args = append(args, k, metadata[k])
+	}
+	return
+}
+
+func listObjectsSlashDelim(ctx context.Context, tx Tx, bucket, prefix, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey, metadata api.ObjectUserMetadata) (api.ObjectsResponse, error) {
 	// split prefix into path and object prefix
 	path := "/" // root of bucket
 	if idx := strings.LastIndex(prefix, "/"); idx != -1 {
@@ -2800,6 +3827,11 @@ func listObjectsSlashDelim(ctx context.Context, tx Tx, bucket, prefix, sortBy, s
 		slabKeyObjExpr = "AND EXISTS(SELECT 1 FROM objects o2 INNER JOIN slices sli ON sli.db_object_id = o2.id INNER JOIN slabs sla ON sla.id = sli.db_slab_id WHERE o2.id = o.id AND sla.key = ?)"
 		args = append(args, EncryptionKey(slabEncryptionKey))
 	}
+	metadataExprs, metadataArgs := whereObjectUserMetadata(metadata)
+	for _, expr := range metadataExprs {
+		slabKeyObjExpr += " AND " + expr
+	}
+	args = append(args, metadataArgs...)
 
 	// add directory query args
 	args = append(args,
@@ -2814,8 +3846,8 @@ func listObjectsSlashDelim(ctx context.Context, tx Tx, bucket, prefix, sortBy, s
 		utf8.RuneCountInString(path), utf8.RuneCountInString(path)+1,
 	)
 	var slabKeyDirExpr string
-	if slabEncryptionKey != (object.EncryptionKey{}) {
-		slabKeyDirExpr = "AND 1=0" // no directories when filtering by slab key
+	if slabEncryptionKey != (object.EncryptionKey{}) || len(metadata) > 0 {
+		slabKeyDirExpr = "AND 1=0" // no directories when filtering by slab key or metadata
 	}
 
 	// apply marker
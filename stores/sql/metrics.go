@@ -2,6 +2,7 @@ package sql
 
 import (
 	"context"
+	dsql "database/sql"
 	"errors"
 	"fmt"
 	"math"
@@ -99,6 +100,81 @@ func ContractPruneMetrics(ctx context.Context, tx sql.Tx, start time.Time, n uin
 	})
 }
 
+// ContractSpendingHistory returns the amount spent on the given contract in
+// consecutive buckets of the given interval between 'from' and 'to'. Contract
+// metrics contain the cumulative spending at the time they were recorded, so
+// the spending within a bucket is the difference between the latest metric in
+// that bucket and the latest metric before it.
+func ContractSpendingHistory(ctx context.Context, tx sql.Tx, fcid types.FileContractID, from, to time.Time, interval time.Duration) ([]api.ContractSpendingBucket, error) {
+	if interval <= 0 {
+		return nil, errors.New("interval has to be greater than zero")
+	} else if !from.Before(to) {
+		return nil, errors.New("'from' has to be before 'to'")
+	}
+
+	n := uint64((to.Sub(from) + interval - 1) / interval)
+	if n > api.MetricMaxIntervals {
+		return nil, api.ErrMaxIntervalsExceeded
+	}
+
+	scanSpending := func(s Scanner, dst ...any) (cs api.ContractSpending, err error) {
+		err = s.Scan(append(dst,
+			(*Unsigned64)(&cs.Uploads.Lo), (*Unsigned64)(&cs.Uploads.Hi),
+			(*Unsigned64)(&cs.FundAccount.Lo), (*Unsigned64)(&cs.FundAccount.Hi),
+			(*Unsigned64)(&cs.Deletions.Lo), (*Unsigned64)(&cs.Deletions.Hi),
+			(*Unsigned64)(&cs.SectorRoots.Lo), (*Unsigned64)(&cs.SectorRoots.Hi),
+		)...)
+		return
+	}
+
+	// fetch the cumulative spending before the first bucket
+	prev, err := scanSpending(tx.QueryRow(ctx, `
+SELECT upload_spending_lo, upload_spending_hi, fund_account_spending_lo, fund_account_spending_hi, delete_spending_lo, delete_spending_hi, sector_roots_spending_lo, sector_roots_spending_hi
+FROM contracts
+WHERE fcid = ? AND timestamp < ?
+ORDER BY timestamp DESC
+LIMIT 1`, FileContractID(fcid), UnixTimeMS(from)))
+	if err != nil && !errors.Is(err, dsql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch initial contract spending: %w", err)
+	}
+
+	buckets := make([]api.ContractSpendingBucket, n)
+	for i := range buckets {
+		buckets[i].Timestamp = api.TimeRFC3339(from.Add(time.Duration(i) * interval))
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT timestamp, upload_spending_lo, upload_spending_hi, fund_account_spending_lo, fund_account_spending_hi, delete_spending_lo, delete_spending_hi, sector_roots_spending_lo, sector_roots_spending_hi
+FROM contracts
+WHERE fcid = ? AND timestamp >= ? AND timestamp < ?
+ORDER BY timestamp ASC`, FileContractID(fcid), UnixTimeMS(from), UnixTimeMS(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract spending: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var timestamp UnixTimeMS
+		cs, err := scanSpending(rows, &timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan contract spending: %w", err)
+		}
+
+		i := time.Time(timestamp).Sub(from) / interval
+		buckets[i].ContractSpending = buckets[i].Add(api.ContractSpending{
+			Uploads:     subCurrency(cs.Uploads, prev.Uploads),
+			FundAccount: subCurrency(cs.FundAccount, prev.FundAccount),
+			Deletions:   subCurrency(cs.Deletions, prev.Deletions),
+			SectorRoots: subCurrency(cs.SectorRoots, prev.SectorRoots),
+		})
+		prev = cs
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate contract spending: %w", err)
+	}
+	return buckets, nil
+}
+
 func PruneMetrics(ctx context.Context, tx sql.Tx, metric string, cutoff time.Time) error {
 	if metric == "" {
 		return errors.New("metric must be set")
@@ -446,3 +522,11 @@ func normaliseTimestamp(start time.Time, interval time.Duration, t UnixTimeMS) U
 	normalizedMS := (toNormaliseMS-startMS)/intervalMS*intervalMS + start.UnixMilli()
 	return UnixTimeMS(time.UnixMilli(normalizedMS))
 }
+
+// subCurrency returns a-b or zero if b is greater than a.
+func subCurrency(a, b types.Currency) types.Currency {
+	if a.Cmp(b) < 0 {
+		return types.ZeroCurrency
+	}
+	return a.Sub(b)
+}
@@ -126,6 +126,10 @@ func (c chainUpdateTx) WalletApplyIndex(index types.ChainIndex, created, spent [
 	return nil
 }
 
+func (c chainUpdateTx) ArchiveExpiredContracts(height uint64) ([]types.FileContractID, error) {
+	return ssql.ArchiveExpiredContracts(c.ctx, c.tx, height, c.l)
+}
+
 func (c chainUpdateTx) ContractState(fcid types.FileContractID) (api.ContractState, error) {
 	return ssql.GetContractState(c.ctx, c.tx, fcid)
 }
@@ -203,8 +207,8 @@ func (c chainUpdateTx) UpdateContractRevision(fcid types.FileContractID, revisio
 	return ssql.UpdateContractRevision(c.ctx, c.tx, fcid, revisionHeight, revisionNumber, size, c.l)
 }
 
-func (c chainUpdateTx) UpdateContractState(fcid types.FileContractID, state api.ContractState) error {
-	return ssql.UpdateContractState(c.ctx, c.tx, fcid, state, c.l)
+func (c chainUpdateTx) UpdateContractState(fcid types.FileContractID, state api.ContractState, reason string, height uint64) error {
+	return ssql.UpdateContractState(c.ctx, c.tx, fcid, state, reason, height, c.l)
 }
 
 func (c chainUpdateTx) ExpiredFileContractElements(bh uint64) ([]types.V2FileContractElement, error) {
@@ -274,8 +278,12 @@ func (c chainUpdateTx) UpdateFileContractElementProofs(updater wallet.ProofUpdat
 	return ssql.UpdateFileContractElementProofs(c.ctx, c.tx, updater)
 }
 
-func (c chainUpdateTx) UpdateFailedContracts(blockHeight uint64) error {
-	return ssql.UpdateFailedContracts(c.ctx, c.tx, blockHeight, c.l)
+func (c chainUpdateTx) UpdateFailedContracts(windowEnd, height uint64) error {
+	return ssql.UpdateFailedContracts(c.ctx, c.tx, windowEnd, height, c.l)
+}
+
+func (c chainUpdateTx) UpdateUnknownContracts(height uint64) error {
+	return ssql.UpdateUnknownContracts(c.ctx, c.tx, height, c.l)
 }
 
 func (c chainUpdateTx) UpdateHost(hk types.PublicKey, v2Ha chain.V2HostAnnouncement, bh uint64, blockID types.BlockID, ts time.Time) error { //
@@ -15,6 +15,7 @@ import (
 	"go.sia.tech/core/types"
 	"go.sia.tech/coreutils/syncer"
 	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/object"
 	ssql "go.sia.tech/renterd/v2/stores/sql"
@@ -211,6 +212,10 @@ func (tx *MainDatabaseTx) Bucket(ctx context.Context, bucket string) (api.Bucket
 	return ssql.Bucket(ctx, tx, bucket)
 }
 
+func (tx *MainDatabaseTx) BucketUsage(ctx context.Context, bucket string) (api.BucketUsage, error) {
+	return ssql.BucketUsage(ctx, tx, bucket)
+}
+
 func (tx *MainDatabaseTx) Buckets(ctx context.Context) ([]api.Bucket, error) {
 	return ssql.Buckets(ctx, tx)
 }
@@ -284,6 +289,14 @@ func (tx *MainDatabaseTx) ContractRoots(ctx context.Context, fcid types.FileCont
 	return ssql.ContractRoots(ctx, tx, fcid)
 }
 
+func (tx *MainDatabaseTx) ContractEvents(ctx context.Context, fcid types.FileContractID) ([]api.ContractEvent, error) {
+	return ssql.ContractEvents(ctx, tx, fcid)
+}
+
+func (tx *MainDatabaseTx) ContractRevisions(ctx context.Context, fcid types.FileContractID, start, end uint64) ([]api.ContractRevisionRecord, error) {
+	return ssql.ContractRevisions(ctx, tx, fcid, start, end)
+}
+
 func (tx *MainDatabaseTx) Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error) {
 	return ssql.Contracts(ctx, tx, opts)
 }
@@ -292,6 +305,14 @@ func (tx *MainDatabaseTx) ContractSize(ctx context.Context, id types.FileContrac
 	return ssql.ContractSize(ctx, tx, id)
 }
 
+func (tx *MainDatabaseTx) ContractStateHistory(ctx context.Context, fcid types.FileContractID) ([]api.ContractStateEvent, error) {
+	return ssql.ContractStateHistory(ctx, tx, fcid)
+}
+
+func (tx *MainDatabaseTx) ContractsPrunableData(ctx context.Context, opts api.ContractsPrunableDataOpts) (api.ContractsPrunableDataResponse, error) {
+	return ssql.ContractsPrunableData(ctx, tx, opts)
+}
+
 func (tx *MainDatabaseTx) ContractSizes(ctx context.Context) (map[types.FileContractID]api.ContractSize, error) {
 	return ssql.ContractSizes(ctx, tx)
 }
@@ -317,6 +338,10 @@ func (tx *MainDatabaseTx) CreateBucket(ctx context.Context, bucket string, bp ap
 	return nil
 }
 
+func (tx *MainDatabaseTx) DeleteContractUsabilityOverride(ctx context.Context, fcid types.FileContractID) error {
+	return ssql.DeleteContractUsabilityOverride(ctx, tx, fcid)
+}
+
 func (tx *MainDatabaseTx) DeleteHostSector(ctx context.Context, hk types.PublicKey, root types.Hash256) (int, error) {
 	return ssql.DeleteHostSector(ctx, tx, hk, root)
 }
@@ -325,6 +350,14 @@ func (tx *MainDatabaseTx) DeleteSetting(ctx context.Context, key string) error {
 	return ssql.DeleteSetting(ctx, tx, key)
 }
 
+func (tx *MainDatabaseTx) DismissedAlerts(ctx context.Context, offset, limit int) ([]alerts.DismissedAlert, error) {
+	return ssql.DismissedAlerts(ctx, tx, offset, limit)
+}
+
+func (tx *MainDatabaseTx) FailPendingContracts(ctx context.Context, maxStartHeight, height uint64) ([]types.FileContractID, error) {
+	return ssql.FailPendingContracts(ctx, tx, maxStartHeight, height)
+}
+
 func (tx *MainDatabaseTx) FileContractElement(ctx context.Context, fcid types.FileContractID) (types.V2FileContractElement, error) {
 	return ssql.FileContractElement(ctx, tx, fcid)
 }
@@ -344,19 +377,11 @@ func (tx *MainDatabaseTx) DeleteObject(ctx context.Context, bucket string, key s
 		return false, err
 	}
 
-	resp, err := tx.Exec(ctx, "DELETE FROM objects WHERE id = ?", objID)
-	if err != nil {
-		return false, err
-	} else if n, err := resp.RowsAffected(); err != nil {
-		return false, err
-	} else {
-		return n != 0, nil
-	}
+	return tx.deleteObjects(ctx, "FROM objects o WHERE o.id = ?", objID)
 }
 
 func (tx *MainDatabaseTx) DeleteObjects(ctx context.Context, bucket string, key string, limit int64) (bool, error) {
-	resp, err := tx.Exec(ctx, `
-	DELETE o
+	return tx.deleteObjects(ctx, `
 	FROM objects o
 	JOIN (
 		SELECT id
@@ -367,13 +392,10 @@ func (tx *MainDatabaseTx) DeleteObjects(ctx context.Context, bucket string, key
 		LIMIT ?
 	) AS limited ON o.id = limited.id`,
 		key+"%", bucket, limit)
-	if err != nil {
-		return false, err
-	} else if n, err := resp.RowsAffected(); err != nil {
-		return false, err
-	} else {
-		return n != 0, nil
-	}
+}
+
+func (tx *MainDatabaseTx) DeleteObjectVersions(ctx context.Context, bucket string, before time.Time) (int64, error) {
+	return ssql.DeleteObjectVersions(ctx, tx, bucket, before)
 }
 
 func (tx *MainDatabaseTx) HostAllowlist(ctx context.Context) ([]types.PublicKey, error) {
@@ -400,10 +422,13 @@ INSERT IGNORE INTO autopilot_config (
 	contracts_upload,
 	contracts_storage,
 	contracts_prune,
+	contracts_max_per_subnet,
+	contracts_max_per_host,
+	contracts_prune_alert_threshold,
 	hosts_max_consecutive_scan_failures,
 	hosts_max_downtime_hours,
 	hosts_min_protocol_version
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
 		sql.AutopilotID,
 		time.Now(),
 		api.DefaultAutopilotConfig.Contracts.Amount,
@@ -413,6 +438,9 @@ INSERT IGNORE INTO autopilot_config (
 		api.DefaultAutopilotConfig.Contracts.Upload,
 		api.DefaultAutopilotConfig.Contracts.Storage,
 		api.DefaultAutopilotConfig.Contracts.Prune,
+		api.DefaultAutopilotConfig.Contracts.MaxContractsPerSubnet,
+		api.DefaultAutopilotConfig.Contracts.MaxContractsPerHost,
+		api.DefaultAutopilotConfig.Contracts.PruneAlertThreshold,
 		api.DefaultAutopilotConfig.Hosts.MaxConsecutiveScanFailures,
 		api.DefaultAutopilotConfig.Hosts.MaxDowntimeHours,
 		api.DefaultAutopilotConfig.Hosts.MinProtocolVersion,
@@ -456,6 +484,10 @@ func (tx *MainDatabaseTx) InsertObject(ctx context.Context, bucket, key string,
 	return nil
 }
 
+func (tx *MainDatabaseTx) InsertObjectVersion(ctx context.Context, bucket, key string) (bool, error) {
+	return ssql.InsertObjectVersion(ctx, tx, bucket, key)
+}
+
 func (tx *MainDatabaseTx) InvalidateSlabHealthByFCID(ctx context.Context, fcids []types.FileContractID, limit int64) (int64, error) {
 	if len(fcids) == 0 {
 		return 0, nil
@@ -587,6 +619,18 @@ func (tx *MainDatabaseTx) MarkPackedSlabUploaded(ctx context.Context, slab api.U
 	return ssql.MarkPackedSlabUploaded(ctx, tx, slab)
 }
 
+func (tx *MainDatabaseTx) MoveContractEvents(ctx context.Context, from, to types.FileContractID) error {
+	return ssql.MoveContractEvents(ctx, tx, from, to)
+}
+
+func (tx *MainDatabaseTx) MoveContractRevisions(ctx context.Context, from, to types.FileContractID) error {
+	return ssql.MoveContractRevisions(ctx, tx, from, to)
+}
+
+func (tx *MainDatabaseTx) MoveContractStateChanges(ctx context.Context, from, to types.FileContractID) error {
+	return ssql.MoveContractStateChanges(ctx, tx, from, to)
+}
+
 func (tx *MainDatabaseTx) MultipartUpload(ctx context.Context, uploadID string) (api.MultipartUpload, error) {
 	return ssql.MultipartUpload(ctx, tx, uploadID)
 }
@@ -603,14 +647,18 @@ func (tx *MainDatabaseTx) Object(ctx context.Context, bucket, key string) (api.O
 	return ssql.Object(ctx, tx, bucket, key)
 }
 
-func (tx *MainDatabaseTx) Objects(ctx context.Context, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey) (api.ObjectsResponse, error) {
-	return ssql.Objects(ctx, tx, bucket, prefix, substring, delim, sortBy, sortDir, marker, limit, slabEncryptionKey)
+func (tx *MainDatabaseTx) Objects(ctx context.Context, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey, metadata api.ObjectUserMetadata) (api.ObjectsResponse, error) {
+	return ssql.Objects(ctx, tx, bucket, prefix, substring, delim, sortBy, sortDir, marker, limit, slabEncryptionKey, metadata)
 }
 
 func (tx *MainDatabaseTx) ObjectMetadata(ctx context.Context, bucket, key string) (api.Object, error) {
 	return ssql.ObjectMetadata(ctx, tx, bucket, key)
 }
 
+func (tx *MainDatabaseTx) ObjectVersions(ctx context.Context, bucket, key string) ([]api.ObjectVersion, error) {
+	return ssql.ObjectVersions(ctx, tx, bucket, key)
+}
+
 func (tx *MainDatabaseTx) ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts) (api.ObjectsStatsResponse, error) {
 	return ssql.ObjectsStats(ctx, tx, opts)
 }
@@ -690,18 +738,12 @@ CREATE INDEX %s_idx ON %s (root(32));`, tmpTable, tmpTable, tmpTable, tmpTable,
 	return
 }
 
-func (tx *MainDatabaseTx) PruneHostSectors(ctx context.Context, limit int64) (int64, error) {
-	res, err := tx.Exec(ctx, `DELETE FROM host_sectors
-WHERE db_host_id NOT IN (
-	SELECT h.id
-	FROM contracts c
-	INNER JOIN hosts h ON c.host_id = h.id
-	WHERE c.archival_reason IS NULL
-) LIMIT ?`, limit)
-	if err != nil {
-		return 0, err
-	}
-	return res.RowsAffected()
+func (tx *MainDatabaseTx) PrunableHostSectors(ctx context.Context) (int64, error) {
+	return ssql.PrunableHostSectors(ctx, tx)
+}
+
+func (tx *MainDatabaseTx) PruneHostSectors(ctx context.Context, cursor ssql.HostSectorCursor, limit int64) (int64, ssql.HostSectorCursor, error) {
+	return ssql.PruneHostSectors(ctx, tx, cursor, limit)
 }
 
 func (tx *MainDatabaseTx) PruneSlabs(ctx context.Context, limit int64) (int64, error) {
@@ -736,18 +778,21 @@ func (tx *MainDatabaseTx) PutContract(ctx context.Context, c api.ContractMetadat
 INSERT INTO contracts (
 	created_at, fcid, host_id, host_key,
 	archival_reason, proof_height, renewed_from, renewed_to, revision_height, revision_number, size, start_height, state, usability, window_start, window_end,
-	contract_price, initial_renter_funds,
-	delete_spending, fund_account_spending, sector_roots_spending, upload_spending
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	contract_price, initial_renter_funds, network_fees,
+	delete_spending, fund_account_spending, sector_roots_spending, upload_spending,
+	host_version
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON DUPLICATE KEY UPDATE
 	created_at = VALUES(created_at), fcid = VALUES(fcid), host_id = VALUES(host_id), host_key = VALUES(host_key),
 	archival_reason = VALUES(archival_reason), proof_height = VALUES(proof_height), renewed_from = VALUES(renewed_from), renewed_to = VALUES(renewed_to), revision_height = VALUES(revision_height), revision_number = VALUES(revision_number), size = VALUES(size), start_height = VALUES(start_height), state = VALUES(state), usability = VALUES(usability), window_start = VALUES(window_start), window_end = VALUES(window_end),
-	contract_price = VALUES(contract_price), initial_renter_funds = VALUES(initial_renter_funds),
-	delete_spending = VALUES(delete_spending), fund_account_spending = VALUES(fund_account_spending), sector_roots_spending = VALUES(sector_roots_spending), upload_spending = VALUES(upload_spending)`,
+	contract_price = VALUES(contract_price), initial_renter_funds = VALUES(initial_renter_funds), network_fees = VALUES(network_fees),
+	delete_spending = VALUES(delete_spending), fund_account_spending = VALUES(fund_account_spending), sector_roots_spending = VALUES(sector_roots_spending), upload_spending = VALUES(upload_spending),
+	host_version = VALUES(host_version)`,
 		time.Now(), ssql.FileContractID(c.ID), hostID, ssql.PublicKey(c.HostKey),
 		ssql.NullableString(c.ArchivalReason), c.ProofHeight, ssql.FileContractID(c.RenewedFrom), ssql.FileContractID(c.RenewedTo), c.RevisionHeight, c.RevisionNumber, c.Size, c.StartHeight, state, usability, c.WindowStart, c.WindowEnd,
-		ssql.Currency(c.ContractPrice), ssql.Currency(c.InitialRenterFunds),
+		ssql.Currency(c.ContractPrice), ssql.Currency(c.InitialRenterFunds), ssql.Currency(c.NetworkFees),
 		ssql.Currency(c.Spending.Deletions), ssql.Currency(c.Spending.FundAccount), ssql.Currency(c.Spending.SectorRoots), ssql.Currency(c.Spending.Uploads),
+		c.HostVersion,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update contract: %w", err)
@@ -755,39 +800,62 @@ ON DUPLICATE KEY UPDATE
 	return nil
 }
 
+func (tx *MainDatabaseTx) RecordContractEvent(ctx context.Context, fcid types.FileContractID, eventType string, details any) error {
+	return ssql.RecordContractEvent(ctx, tx, fcid, eventType, details)
+}
+
 func (tx *MainDatabaseTx) RecordContractSpending(ctx context.Context, fcid types.FileContractID, revisionNumber, size uint64, newSpending api.ContractSpending) error {
 	return ssql.RecordContractSpending(ctx, tx, fcid, revisionNumber, size, newSpending)
 }
 
+func (tx *MainDatabaseTx) RecordDismissedAlerts(ctx context.Context, dismissed ...alerts.DismissedAlert) error {
+	return ssql.RecordDismissedAlerts(ctx, tx, dismissed...)
+}
+
 func (tx *MainDatabaseTx) RecordHostScans(ctx context.Context, scans []api.HostScan) error {
 	return ssql.RecordHostScans(ctx, tx, scans)
 }
 
-func (tx *MainDatabaseTx) RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDownTime time.Duration) (int64, error) {
-	return ssql.RemoveOfflineHosts(ctx, tx, minRecentFailures, maxDownTime)
+func (tx *MainDatabaseTx) RecountBucketUsage(ctx context.Context, bucket string) (api.BucketUsage, error) {
+	return ssql.RecountBucketUsage(ctx, tx, bucket)
+}
+
+func (tx *MainDatabaseTx) RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDownTime time.Duration, exemptActiveContractHosts bool, limit, maxEligible int) (int64, int64, []types.PublicKey, error) {
+	return ssql.RemoveOfflineHosts(ctx, tx, minRecentFailures, maxDownTime, exemptActiveContractHosts, limit, maxEligible)
 }
 
-func (tx *MainDatabaseTx) RenameObject(ctx context.Context, bucket, keyOld, keyNew string, force bool) error {
+func (tx *MainDatabaseTx) RenameObject(ctx context.Context, srcBucket, dstBucket, keyOld, keyNew string, force bool) error {
+	// fetch destination bucket
+	var dstBID int64
+	err := tx.QueryRow(ctx, "SELECT id FROM buckets WHERE name = ?", dstBucket).Scan(&dstBID)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return fmt.Errorf("%w: destination bucket", api.ErrBucketNotFound)
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch dst bucket id: %w", err)
+	}
+
 	if force {
 		// delete potentially existing object at destination
-		if _, err := tx.DeleteObject(ctx, bucket, keyNew); err != nil {
+		if _, err := tx.DeleteObject(ctx, dstBucket, keyNew); err != nil {
 			return fmt.Errorf("RenameObject: failed to delete object: %w", err)
 		}
 	} else {
 		var exists bool
-		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM objects WHERE object_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?))", keyNew, bucket).Scan(&exists); err != nil {
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM objects WHERE object_id = ? AND db_bucket_id = ?)", keyNew, dstBID).Scan(&exists); err != nil {
 			return err
 		} else if exists {
 			return api.ErrObjectExists
 		}
 	}
-	resp, err := tx.Exec(ctx, `UPDATE objects SET object_id = ? WHERE object_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?)`, keyNew, keyOld, bucket)
+	resp, err := tx.Exec(ctx, `UPDATE objects SET object_id = ?, db_bucket_id = ? WHERE object_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?)`, keyNew, dstBID, keyOld, srcBucket)
 	if err != nil {
 		return err
 	} else if n, err := resp.RowsAffected(); err != nil {
 		return err
 	} else if n == 0 {
 		return fmt.Errorf("%w: key %v", api.ErrObjectNotFound, keyOld)
+	} else if srcBucket != dstBucket {
+		return ssql.MoveObjectBucketUsage(ctx, tx, srcBucket, dstBID, keyNew)
 	}
 	return nil
 }
@@ -797,11 +865,10 @@ func (tx *MainDatabaseTx) RenameObjects(ctx context.Context, bucket, prefixOld,
 		// to avoid a conflict on update, we delete objects that would conflict
 		// with objects being renamed, within the scope of the bucket of course
 		query := `
-		DELETE
-		FROM objects
+		FROM objects o
 		WHERE
-			db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?) AND
-			object_id IN (
+			o.db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?) AND
+			o.object_id IN (
 				SELECT *
 				FROM (
 					SELECT CONCAT(?, SUBSTR(object_id, ?))
@@ -814,7 +881,7 @@ func (tx *MainDatabaseTx) RenameObjects(ctx context.Context, bucket, prefixOld,
 			prefixNew, utf8.RuneCountInString(prefixOld) + 1,
 			prefixOld + "%", utf8.RuneCountInString(prefixOld), prefixOld,
 		}
-		_, err := tx.Exec(ctx, query, args...)
+		_, err := tx.deleteObjects(ctx, query, args...)
 		if err != nil {
 			return err
 		}
@@ -861,6 +928,14 @@ func (tx *MainDatabaseTx) ResetLostSectors(ctx context.Context, hk types.PublicK
 	return ssql.ResetLostSectors(ctx, tx, hk)
 }
 
+func (tx *MainDatabaseTx) UpdateHostSectorSize(ctx context.Context, hk types.PublicKey, sectorSize uint64) error {
+	return ssql.UpdateHostSectorSize(ctx, tx, hk, sectorSize)
+}
+
+func (tx *MainDatabaseTx) RestoreObjectVersion(ctx context.Context, bucket, key string, id int64) error {
+	return ssql.RestoreObjectVersion(ctx, tx, bucket, key, id)
+}
+
 func (tx MainDatabaseTx) SaveAccounts(ctx context.Context, accounts []api.Account) error {
 	// clean_shutdown = 1 after save
 	stmt, err := tx.Prepare(ctx, `
@@ -911,6 +986,10 @@ func (tx *MainDatabaseTx) Slab(ctx context.Context, key object.EncryptionKey) (o
 	return ssql.Slab(ctx, tx, key)
 }
 
+func (tx *MainDatabaseTx) SlabBufferObjects(ctx context.Context) (map[int64][]string, error) {
+	return ssql.SlabBufferObjects(ctx, tx)
+}
+
 func (tx *MainDatabaseTx) SlabsForMigration(ctx context.Context, healthCutoff float64, limit int) ([]api.UnhealthySlab, error) {
 	return ssql.SlabsForMigration(ctx, tx, healthCutoff, limit)
 }
@@ -919,6 +998,10 @@ func (tx *MainDatabaseTx) Tip(ctx context.Context) (types.ChainIndex, error) {
 	return ssql.Tip(ctx, tx.Tx)
 }
 
+func (tx *MainDatabaseTx) UnarchiveContract(ctx context.Context, fcid types.FileContractID) error {
+	return ssql.UnarchiveContract(ctx, tx, fcid)
+}
+
 func (tx *MainDatabaseTx) UnspentSiacoinElements(ctx context.Context) (ci types.ChainIndex, elements []types.SiacoinElement, err error) {
 	return ssql.UnspentSiacoinElements(ctx, tx.Tx)
 }
@@ -931,14 +1014,30 @@ func (tx *MainDatabaseTx) UpdateBucketPolicy(ctx context.Context, bucket string,
 	return ssql.UpdateBucketPolicy(ctx, tx, bucket, bp)
 }
 
+func (tx *MainDatabaseTx) UpdateBucketRedundancy(ctx context.Context, bucket string, rs *api.RedundancySettings) error {
+	return ssql.UpdateBucketRedundancy(ctx, tx, bucket, rs)
+}
+
 func (tx *MainDatabaseTx) UpdateContract(ctx context.Context, fcid types.FileContractID, c api.ContractMetadata) error {
 	return ssql.UpdateContract(ctx, tx, fcid, c)
 }
 
+func (tx *MainDatabaseTx) UpdateContractSpendingCap(ctx context.Context, fcid types.FileContractID, spendingCap types.Currency) error {
+	return ssql.UpdateContractSpendingCap(ctx, tx, fcid, spendingCap)
+}
+
 func (tx *MainDatabaseTx) UpdateContractUsability(ctx context.Context, fcid types.FileContractID, usability string) error {
 	return ssql.UpdateContractUsability(ctx, tx, fcid, usability)
 }
 
+func (tx *MainDatabaseTx) UpdateContractUsabilityOverride(ctx context.Context, fcid types.FileContractID, usability string) error {
+	return ssql.UpdateContractUsabilityOverride(ctx, tx, fcid, usability)
+}
+
+func (tx *MainDatabaseTx) UpdateContractVerificationStatus(ctx context.Context, fcid types.FileContractID, status string) error {
+	return ssql.UpdateContractVerificationStatus(ctx, tx, fcid, status)
+}
+
 func (tx *MainDatabaseTx) UpdateHostAllowlistEntries(ctx context.Context, add, remove []types.PublicKey, clear bool) error {
 	if clear {
 		if _, err := tx.Exec(ctx, "DELETE FROM host_allowlist_entries"); err != nil {
@@ -1074,6 +1173,14 @@ func (tx *MainDatabaseTx) UpdateHostCheck(ctx context.Context, hk types.PublicKe
 	return nil
 }
 
+func (tx *MainDatabaseTx) UpdateObjectMimeType(ctx context.Context, bucket, key, mimeType string) error {
+	return ssql.UpdateObjectMimeType(ctx, tx, bucket, key, mimeType)
+}
+
+func (tx *MainDatabaseTx) UpdateObjectUserMetadata(ctx context.Context, bucket, key string, md api.ObjectUserMetadata) error {
+	return ssql.UpdateObjectUserMetadata(ctx, tx, bucket, key, md)
+}
+
 func (tx *MainDatabaseTx) UpdatePeerInfo(ctx context.Context, addr string, fn func(*syncer.PeerInfo)) error {
 	return ssql.UpdatePeerInfo(ctx, tx, addr, fn)
 }
@@ -1194,6 +1301,28 @@ func (tx *MainDatabaseTx) WalletReleaseOutputs(ctx context.Context, scois []type
 	return ssql.WalletReleaseOutputs(ctx, tx.Tx, scois)
 }
 
+// deleteObjects deletes the objects selected by the given FROM clause, which
+// has to alias the objects table as 'o', and updates the usage of the bucket
+// they belonged to.
+func (tx *MainDatabaseTx) deleteObjects(ctx context.Context, from string, args ...any) (bool, error) {
+	bucketID, objects, size, err := ssql.ObjectsUsage(ctx, tx, "SELECT o.db_bucket_id, o.size "+from, args...)
+	if err != nil {
+		return false, err
+	} else if objects == 0 {
+		return false, nil
+	}
+
+	resp, err := tx.Exec(ctx, "DELETE o "+from, args...)
+	if err != nil {
+		return false, err
+	} else if n, err := resp.RowsAffected(); err != nil {
+		return false, err
+	} else if n == 0 {
+		return false, nil
+	}
+	return true, ssql.UpdateBucketUsage(ctx, tx, bucketID, -objects, -size)
+}
+
 func (tx *MainDatabaseTx) insertSlabs(ctx context.Context, objID, partID *int64, slices object.SlabSlices) error {
 	if (objID == nil) == (partID == nil) {
 		return errors.New("exactly one of objID and partID must be set")
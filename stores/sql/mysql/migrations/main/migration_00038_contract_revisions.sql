@@ -0,0 +1,11 @@
+CREATE TABLE `contract_revisions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_contract_id` bigint unsigned NOT NULL,
+  `revision_number` varchar(191) NOT NULL,
+  `size` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_contract_revisions_db_contract_id` (`db_contract_id`),
+  CONSTRAINT `fk_contract_revisions_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
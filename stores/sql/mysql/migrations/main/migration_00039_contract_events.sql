@@ -0,0 +1,10 @@
+CREATE TABLE `contract_events` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `fcid` varbinary(32) NOT NULL,
+  `timestamp` bigint NOT NULL,
+  `event_type` varchar(191) NOT NULL,
+  `details` longtext,
+  PRIMARY KEY (`id`),
+  KEY `idx_contract_events_fcid_timestamp` (`fcid`,`timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1 @@
+ALTER TABLE `autopilot_config` ADD COLUMN `contracts_max_per_host` bigint unsigned NOT NULL DEFAULT 1;
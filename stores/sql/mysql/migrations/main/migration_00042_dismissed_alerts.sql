@@ -0,0 +1,11 @@
+CREATE TABLE `dismissed_alerts` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `alert_id` varbinary(32) NOT NULL,
+  `timestamp` bigint NOT NULL,
+  `reason` longtext NOT NULL,
+  `dismissed_by` varchar(191) NOT NULL,
+  `alert` longtext,
+  PRIMARY KEY (`id`),
+  KEY `idx_dismissed_alerts_timestamp` (`timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
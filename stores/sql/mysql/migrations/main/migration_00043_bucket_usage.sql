@@ -0,0 +1,11 @@
+CREATE TABLE `bucket_usage` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_bucket_id` bigint unsigned NOT NULL,
+  `object_count` bigint NOT NULL DEFAULT 0,
+  `total_bytes` bigint NOT NULL DEFAULT 0,
+  `last_updated` datetime(3) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_bucket_usage_db_bucket_id` (`db_bucket_id`),
+  CONSTRAINT `fk_bucket_usage_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,19 @@
+CREATE TABLE `object_versions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_bucket_id` bigint unsigned NOT NULL,
+  `object_id` varchar(766) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin DEFAULT NULL,
+  `key` binary(33) NOT NULL,
+  `size` bigint DEFAULT NULL,
+  `mime_type` longtext,
+  `etag` varchar(191) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_object_versions_db_bucket_id_object_id` (`db_bucket_id`,`object_id`),
+  KEY `idx_object_versions_created_at` (`created_at`),
+  CONSTRAINT `fk_object_versions_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+ALTER TABLE `slices`
+  ADD COLUMN `db_object_version_id` bigint unsigned DEFAULT NULL,
+  ADD KEY `idx_slices_db_object_version_id` (`db_object_version_id`),
+  ADD CONSTRAINT `fk_object_versions_slabs` FOREIGN KEY (`db_object_version_id`) REFERENCES `object_versions` (`id`) ON DELETE CASCADE;
@@ -0,0 +1,12 @@
+CREATE TABLE `contract_state_changes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `fcid` varbinary(32) NOT NULL,
+  `from_state` varchar(191) NOT NULL,
+  `to_state` varchar(191) NOT NULL,
+  `reason` longtext NOT NULL,
+  `height` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_contract_state_changes_fcid_timestamp` (`fcid`,`timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
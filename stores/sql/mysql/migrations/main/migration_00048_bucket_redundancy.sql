@@ -0,0 +1 @@
+ALTER TABLE `buckets` ADD COLUMN `redundancy` JSON;
@@ -0,0 +1 @@
+ALTER TABLE `contracts` ADD COLUMN `spending_cap` longtext;
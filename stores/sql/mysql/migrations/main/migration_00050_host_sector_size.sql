@@ -0,0 +1 @@
+ALTER TABLE `hosts` ADD COLUMN `sector_size` bigint unsigned NOT NULL DEFAULT 4194304;
@@ -0,0 +1 @@
+ALTER TABLE `autopilot_config` ADD COLUMN `contracts_prune_alert_threshold` bigint unsigned NOT NULL DEFAULT 10000000000;
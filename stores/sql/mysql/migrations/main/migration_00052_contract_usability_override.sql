@@ -0,0 +1 @@
+ALTER TABLE `contracts` ADD COLUMN `usability_override` boolean NOT NULL DEFAULT false;
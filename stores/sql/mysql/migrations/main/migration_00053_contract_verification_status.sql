@@ -0,0 +1 @@
+ALTER TABLE `contracts` ADD COLUMN `verification_status` tinyint unsigned NOT NULL DEFAULT '0';
@@ -0,0 +1 @@
+ALTER TABLE `autopilot_config` ADD COLUMN `contracts_min_contract_lifetime` bigint unsigned NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE `contracts` ADD COLUMN `host_version` varchar(191) NOT NULL DEFAULT '';
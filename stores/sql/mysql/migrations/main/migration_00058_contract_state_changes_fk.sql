@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS contract_state_changes_temp;
+CREATE TABLE `contract_state_changes_temp` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_contract_id` bigint unsigned NOT NULL,
+  `from_state` varchar(191) NOT NULL,
+  `to_state` varchar(191) NOT NULL,
+  `reason` longtext NOT NULL,
+  `height` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_contract_state_changes_db_contract_id_timestamp` (`db_contract_id`,`timestamp`),
+  CONSTRAINT `fk_contract_state_changes_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+INSERT INTO contract_state_changes_temp (`id`, `created_at`, `db_contract_id`, `from_state`, `to_state`, `reason`, `height`, `timestamp`)
+SELECT csc.`id`, csc.`created_at`, c.`id`, csc.`from_state`, csc.`to_state`, csc.`reason`, csc.`height`, csc.`timestamp`
+FROM contract_state_changes csc
+INNER JOIN contracts c ON c.`fcid` = csc.`fcid`;
+DROP TABLE contract_state_changes;
+RENAME TABLE contract_state_changes_temp TO contract_state_changes;
@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS contract_events_temp;
+CREATE TABLE `contract_events_temp` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_contract_id` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  `event_type` varchar(191) NOT NULL,
+  `details` longtext,
+  PRIMARY KEY (`id`),
+  KEY `idx_contract_events_db_contract_id_timestamp` (`db_contract_id`,`timestamp`),
+  CONSTRAINT `fk_contract_events_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+INSERT INTO contract_events_temp (`id`, `created_at`, `db_contract_id`, `timestamp`, `event_type`, `details`)
+SELECT ce.`id`, ce.`created_at`, c.`id`, ce.`timestamp`, ce.`event_type`, ce.`details`
+FROM contract_events ce
+INNER JOIN contracts c ON c.`fcid` = ce.`fcid`;
+DROP TABLE contract_events;
+RENAME TABLE contract_events_temp TO contract_events;
@@ -4,6 +4,7 @@ CREATE TABLE `buckets` (
   `created_at` datetime(3) DEFAULT NULL,
   `policy` JSON,
   `name` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin DEFAULT NULL,
+  `redundancy` JSON,
   PRIMARY KEY (`id`),
   UNIQUE KEY `name` (`name`),
   KEY `idx_buckets_name` (`name`)
@@ -45,6 +46,7 @@ CREATE TABLE `hosts` (
   `failed_interactions` double DEFAULT NULL,
   `lost_sectors` bigint unsigned DEFAULT NULL,
   `last_announcement` datetime(3) DEFAULT NULL,
+  `sector_size` bigint unsigned NOT NULL DEFAULT 4194304,
   PRIMARY KEY (`id`),
   UNIQUE KEY `public_key` (`public_key`),
   KEY `idx_hosts_public_key` (`public_key`),
@@ -77,11 +79,16 @@ CREATE TABLE `contracts` (
 
   `contract_price` longtext,
   `initial_renter_funds` longtext,
+  `network_fees` longtext,
 
   `delete_spending` longtext,
   `fund_account_spending` longtext,
   `sector_roots_spending` longtext,
   `upload_spending` longtext,
+  `spending_cap` longtext,
+  `usability_override` boolean NOT NULL DEFAULT false,
+  `verification_status` tinyint unsigned NOT NULL DEFAULT '0',
+  `host_version` varchar(191) NOT NULL DEFAULT '',
   PRIMARY KEY (`id`),
   UNIQUE KEY `fcid` (`fcid`),
   KEY `idx_contracts_archival_reason` (`archival_reason`),
@@ -294,6 +301,22 @@ CREATE TABLE `settings` (
   KEY `idx_settings_key` (`key`)
 ) ENGINE=InnoDB AUTO_INCREMENT=5 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+-- dbObjectVersion
+CREATE TABLE `object_versions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_bucket_id` bigint unsigned NOT NULL,
+  `object_id` varchar(766) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin DEFAULT NULL,
+  `key` binary(33) NOT NULL,
+  `size` bigint DEFAULT NULL,
+  `mime_type` longtext,
+  `etag` varchar(191) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_object_versions_db_bucket_id_object_id` (`db_bucket_id`,`object_id`),
+  KEY `idx_object_versions_created_at` (`created_at`),
+  CONSTRAINT `fk_object_versions_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 -- dbSlice
 CREATE TABLE `slices` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT,
@@ -304,14 +327,17 @@ CREATE TABLE `slices` (
   `db_slab_id` bigint unsigned DEFAULT NULL,
   `offset` int unsigned DEFAULT NULL,
   `length` int unsigned DEFAULT NULL,
+  `db_object_version_id` bigint unsigned DEFAULT NULL,
   PRIMARY KEY (`id`),
   KEY `idx_slices_db_object_id` (`db_object_id`),
   KEY `idx_slices_object_index` (`object_index`),
   KEY `idx_slices_db_multipart_part_id` (`db_multipart_part_id`),
   KEY `idx_slices_db_slab_id` (`db_slab_id`),
+  KEY `idx_slices_db_object_version_id` (`db_object_version_id`),
   CONSTRAINT `fk_multipart_parts_slabs` FOREIGN KEY (`db_multipart_part_id`) REFERENCES `multipart_parts` (`id`) ON DELETE CASCADE,
   CONSTRAINT `fk_objects_slabs` FOREIGN KEY (`db_object_id`) REFERENCES `objects` (`id`) ON DELETE CASCADE,
-  CONSTRAINT `fk_slabs_slices` FOREIGN KEY (`db_slab_id`) REFERENCES `slabs` (`id`)
+  CONSTRAINT `fk_slabs_slices` FOREIGN KEY (`db_slab_id`) REFERENCES `slabs` (`id`),
+  CONSTRAINT `fk_object_versions_slabs` FOREIGN KEY (`db_object_version_id`) REFERENCES `object_versions` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 -- dbWebhook
@@ -477,6 +503,73 @@ CREATE TABLE `contract_elements` (
   CONSTRAINT `fk_contract_elements_contracts` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+-- contract revisions
+CREATE TABLE `contract_revisions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_contract_id` bigint unsigned NOT NULL,
+  `revision_number` varchar(191) NOT NULL,
+  `size` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_contract_revisions_db_contract_id` (`db_contract_id`),
+  CONSTRAINT `fk_contract_revisions_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- contract events
+CREATE TABLE `contract_events` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_contract_id` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  `event_type` varchar(191) NOT NULL,
+  `details` longtext,
+  PRIMARY KEY (`id`),
+  KEY `idx_contract_events_db_contract_id_timestamp` (`db_contract_id`,`timestamp`),
+  CONSTRAINT `fk_contract_events_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- contract state changes
+CREATE TABLE `contract_state_changes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_contract_id` bigint unsigned NOT NULL,
+  `from_state` varchar(191) NOT NULL,
+  `to_state` varchar(191) NOT NULL,
+  `reason` longtext NOT NULL,
+  `height` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_contract_state_changes_db_contract_id_timestamp` (`db_contract_id`,`timestamp`),
+  CONSTRAINT `fk_contract_state_changes_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- dismissed alerts
+CREATE TABLE `dismissed_alerts` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `alert_id` varbinary(32) NOT NULL,
+  `timestamp` bigint NOT NULL,
+  `reason` longtext NOT NULL,
+  `dismissed_by` varchar(191) NOT NULL,
+  `alert` longtext,
+  PRIMARY KEY (`id`),
+  KEY `idx_dismissed_alerts_timestamp` (`timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- bucket usage
+CREATE TABLE `bucket_usage` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_bucket_id` bigint unsigned NOT NULL,
+  `object_count` bigint NOT NULL DEFAULT 0,
+  `total_bytes` bigint NOT NULL DEFAULT 0,
+  `last_updated` datetime(3) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_bucket_usage_db_bucket_id` (`db_bucket_id`),
+  CONSTRAINT `fk_bucket_usage_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 -- autopilot config
 CREATE TABLE `autopilot_config` (
   `id` bigint unsigned NOT NULL DEFAULT 1,
@@ -490,6 +583,14 @@ CREATE TABLE `autopilot_config` (
   `contracts_upload` bigint unsigned DEFAULT NULL,
   `contracts_storage` bigint unsigned DEFAULT NULL,
   `contracts_prune` boolean NOT NULL DEFAULT false,
+  `contracts_max_per_subnet` bigint unsigned NOT NULL DEFAULT 1,
+  `contracts_max_per_host` bigint unsigned NOT NULL DEFAULT 1,
+  `contracts_prune_alert_threshold` bigint unsigned NOT NULL DEFAULT 10000000000,
+  `contracts_min_wallet_balance` longtext,
+  `contracts_storage_projection` JSON DEFAULT ('{}'),
+  `contracts_min_contract_lifetime` bigint unsigned NOT NULL DEFAULT 0,
+  `contracts_period_multiplier` double NOT NULL DEFAULT 1,
+  `contracts_period_multiplier_growth_factor` double NOT NULL DEFAULT 2,
 
   `hosts_max_downtime_hours` bigint unsigned DEFAULT NULL,
   `hosts_min_protocol_version` varchar(191) DEFAULT NULL,
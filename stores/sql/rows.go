@@ -31,20 +31,36 @@ type ContractRow struct {
 	// cost fields
 	ContractPrice      Currency
 	InitialRenterFunds Currency
+	NetworkFees        Currency
 
 	// spending fields
 	DeleteSpending      Currency
 	FundAccountSpending Currency
 	SectorRootsSpending Currency
 	UploadSpending      Currency
+
+	// SpendingCap is the maximum total spending allowed on the contract,
+	// zero means unlimited
+	SpendingCap Currency
+
+	// UsabilityOverride is true if the usability was set by the user
+	UsabilityOverride bool
+
+	// VerificationStatus is the result of the last comparison of the
+	// revision number with the one found on chain
+	VerificationStatus ContractVerificationStatus
+
+	// HostVersion is the release of the host's software
+	HostVersion string
 }
 
 func (r *ContractRow) Scan(s Scanner) error {
 	return s.Scan(
 		&r.FCID, &r.HostID, &r.HostKey,
 		&r.ArchivalReason, &r.ProofHeight, &r.RenewedFrom, &r.RenewedTo, &r.RevisionHeight, &r.RevisionNumber, &r.Size, &r.StartHeight, &r.State, &r.Usability, &r.WindowStart, &r.WindowEnd,
-		&r.ContractPrice, &r.InitialRenterFunds,
+		&r.ContractPrice, &r.InitialRenterFunds, &r.NetworkFees,
 		&r.DeleteSpending, &r.FundAccountSpending, &r.SectorRootsSpending, &r.UploadSpending,
+		&r.SpendingCap, &r.UsabilityOverride, &r.VerificationStatus, &r.HostVersion,
 	)
 }
 
@@ -54,6 +70,7 @@ func (r *ContractRow) ContractMetadata() api.ContractMetadata {
 		FundAccount: types.Currency(r.FundAccountSpending),
 		Deletions:   types.Currency(r.DeleteSpending),
 		SectorRoots: types.Currency(r.SectorRootsSpending),
+		NetworkFees: types.Currency(r.NetworkFees),
 	}
 
 	return api.ContractMetadata{
@@ -62,6 +79,7 @@ func (r *ContractRow) ContractMetadata() api.ContractMetadata {
 
 		ContractPrice:      types.Currency(r.ContractPrice),
 		InitialRenterFunds: types.Currency(r.InitialRenterFunds),
+		NetworkFees:        types.Currency(r.NetworkFees),
 
 		ArchivalReason: string(r.ArchivalReason),
 		ProofHeight:    r.ProofHeight,
@@ -71,10 +89,15 @@ func (r *ContractRow) ContractMetadata() api.ContractMetadata {
 		RevisionNumber: r.RevisionNumber,
 		Size:           r.Size,
 		Spending:       spending,
+		SpendingCap:    types.Currency(r.SpendingCap),
 		StartHeight:    r.StartHeight,
 		State:          r.State.String(),
 		Usability:      r.Usability.String(),
 		WindowStart:    r.WindowStart,
 		WindowEnd:      r.WindowEnd,
+
+		UsabilityOverride:  r.UsabilityOverride,
+		VerificationStatus: r.VerificationStatus.String(),
+		HostVersion:        r.HostVersion,
 	}
 }
@@ -15,6 +15,7 @@ import (
 	"go.sia.tech/core/types"
 	"go.sia.tech/coreutils/syncer"
 	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/renterd/v2/alerts"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/internal/sql"
 	"go.sia.tech/renterd/v2/object"
@@ -210,6 +211,10 @@ func (tx *MainDatabaseTx) Bucket(ctx context.Context, bucket string) (api.Bucket
 	return ssql.Bucket(ctx, tx, bucket)
 }
 
+func (tx *MainDatabaseTx) BucketUsage(ctx context.Context, bucket string) (api.BucketUsage, error) {
+	return ssql.BucketUsage(ctx, tx, bucket)
+}
+
 func (tx *MainDatabaseTx) Buckets(ctx context.Context) ([]api.Bucket, error) {
 	return ssql.Buckets(ctx, tx)
 }
@@ -288,6 +293,14 @@ func (tx *MainDatabaseTx) ContractRoots(ctx context.Context, fcid types.FileCont
 	return ssql.ContractRoots(ctx, tx, fcid)
 }
 
+func (tx *MainDatabaseTx) ContractEvents(ctx context.Context, fcid types.FileContractID) ([]api.ContractEvent, error) {
+	return ssql.ContractEvents(ctx, tx, fcid)
+}
+
+func (tx *MainDatabaseTx) ContractRevisions(ctx context.Context, fcid types.FileContractID, start, end uint64) ([]api.ContractRevisionRecord, error) {
+	return ssql.ContractRevisions(ctx, tx, fcid, start, end)
+}
+
 func (tx *MainDatabaseTx) Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error) {
 	return ssql.Contracts(ctx, tx, opts)
 }
@@ -296,6 +309,14 @@ func (tx *MainDatabaseTx) ContractSize(ctx context.Context, id types.FileContrac
 	return ssql.ContractSize(ctx, tx, id)
 }
 
+func (tx *MainDatabaseTx) ContractStateHistory(ctx context.Context, fcid types.FileContractID) ([]api.ContractStateEvent, error) {
+	return ssql.ContractStateHistory(ctx, tx, fcid)
+}
+
+func (tx *MainDatabaseTx) ContractsPrunableData(ctx context.Context, opts api.ContractsPrunableDataOpts) (api.ContractsPrunableDataResponse, error) {
+	return ssql.ContractsPrunableData(ctx, tx, opts)
+}
+
 func (tx *MainDatabaseTx) ContractSizes(ctx context.Context) (map[types.FileContractID]api.ContractSize, error) {
 	return ssql.ContractSizes(ctx, tx)
 }
@@ -321,6 +342,10 @@ func (tx *MainDatabaseTx) CreateBucket(ctx context.Context, bucket string, bp ap
 	return nil
 }
 
+func (tx *MainDatabaseTx) DeleteContractUsabilityOverride(ctx context.Context, fcid types.FileContractID) error {
+	return ssql.DeleteContractUsabilityOverride(ctx, tx, fcid)
+}
+
 func (tx *MainDatabaseTx) DeleteHostSector(ctx context.Context, hk types.PublicKey, root types.Hash256) (int, error) {
 	return ssql.DeleteHostSector(ctx, tx, hk, root)
 }
@@ -329,6 +354,14 @@ func (tx *MainDatabaseTx) DeleteSetting(ctx context.Context, key string) error {
 	return ssql.DeleteSetting(ctx, tx, key)
 }
 
+func (tx *MainDatabaseTx) DismissedAlerts(ctx context.Context, offset, limit int) ([]alerts.DismissedAlert, error) {
+	return ssql.DismissedAlerts(ctx, tx, offset, limit)
+}
+
+func (tx *MainDatabaseTx) FailPendingContracts(ctx context.Context, maxStartHeight, height uint64) ([]types.FileContractID, error) {
+	return ssql.FailPendingContracts(ctx, tx, maxStartHeight, height)
+}
+
 func (tx *MainDatabaseTx) FileContractElement(ctx context.Context, fcid types.FileContractID) (types.V2FileContractElement, error) {
 	return ssql.FileContractElement(ctx, tx, fcid)
 }
@@ -338,31 +371,21 @@ func (tx *MainDatabaseTx) DeleteBucket(ctx context.Context, bucket string) error
 }
 
 func (tx *MainDatabaseTx) DeleteObject(ctx context.Context, bucket string, key string) (bool, error) {
-	resp, err := tx.Exec(ctx, "DELETE FROM objects WHERE object_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?)", key, bucket)
-	if err != nil {
-		return false, err
-	} else if n, err := resp.RowsAffected(); err != nil {
-		return false, err
-	} else {
-		return n != 0, nil
-	}
+	where := "object_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?)"
+	return tx.deleteObjects(ctx, where, key, bucket)
 }
 
 func (tx *MainDatabaseTx) DeleteObjects(ctx context.Context, bucket string, key string, limit int64) (bool, error) {
-	resp, err := tx.Exec(ctx, `
-	DELETE FROM objects
-	WHERE id IN (
+	where := `id IN (
 		SELECT id FROM objects
 		WHERE object_id LIKE ? AND SUBSTR(object_id, 1, ?) = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?)
 		LIMIT ?
-	)`, key+"%", utf8.RuneCountInString(key), key, bucket, limit)
-	if err != nil {
-		return false, err
-	} else if n, err := resp.RowsAffected(); err != nil {
-		return false, err
-	} else {
-		return n != 0, nil
-	}
+	)`
+	return tx.deleteObjects(ctx, where, key+"%", utf8.RuneCountInString(key), key, bucket, limit)
+}
+
+func (tx *MainDatabaseTx) DeleteObjectVersions(ctx context.Context, bucket string, before time.Time) (int64, error) {
+	return ssql.DeleteObjectVersions(ctx, tx, bucket, before)
 }
 
 func (tx *MainDatabaseTx) HostAllowlist(ctx context.Context) ([]types.PublicKey, error) {
@@ -389,10 +412,13 @@ INSERT OR IGNORE INTO autopilot_config (
 	contracts_upload,
 	contracts_storage,
 	contracts_prune,
+	contracts_max_per_subnet,
+	contracts_max_per_host,
+	contracts_prune_alert_threshold,
 	hosts_max_consecutive_scan_failures,
 	hosts_max_downtime_hours,
 	hosts_min_protocol_version
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
 		sql.AutopilotID,
 		time.Now(),
 		api.DefaultAutopilotConfig.Contracts.Amount,
@@ -402,6 +428,9 @@ INSERT OR IGNORE INTO autopilot_config (
 		api.DefaultAutopilotConfig.Contracts.Upload,
 		api.DefaultAutopilotConfig.Contracts.Storage,
 		api.DefaultAutopilotConfig.Contracts.Prune,
+		api.DefaultAutopilotConfig.Contracts.MaxContractsPerSubnet,
+		api.DefaultAutopilotConfig.Contracts.MaxContractsPerHost,
+		api.DefaultAutopilotConfig.Contracts.PruneAlertThreshold,
 		api.DefaultAutopilotConfig.Hosts.MaxConsecutiveScanFailures,
 		api.DefaultAutopilotConfig.Hosts.MaxDowntimeHours,
 		api.DefaultAutopilotConfig.Hosts.MinProtocolVersion,
@@ -501,6 +530,10 @@ func (tx *MainDatabaseTx) InsertObject(ctx context.Context, bucket, key string,
 	return nil
 }
 
+func (tx *MainDatabaseTx) InsertObjectVersion(ctx context.Context, bucket, key string) (bool, error) {
+	return ssql.InsertObjectVersion(ctx, tx, bucket, key)
+}
+
 func (tx *MainDatabaseTx) InvalidateSlabHealthByFCID(ctx context.Context, fcids []types.FileContractID, limit int64) (int64, error) {
 	if len(fcids) == 0 {
 		return 0, nil
@@ -583,6 +616,18 @@ func (tx *MainDatabaseTx) MarkPackedSlabUploaded(ctx context.Context, slab api.U
 	return ssql.MarkPackedSlabUploaded(ctx, tx, slab)
 }
 
+func (tx *MainDatabaseTx) MoveContractEvents(ctx context.Context, from, to types.FileContractID) error {
+	return ssql.MoveContractEvents(ctx, tx, from, to)
+}
+
+func (tx *MainDatabaseTx) MoveContractRevisions(ctx context.Context, from, to types.FileContractID) error {
+	return ssql.MoveContractRevisions(ctx, tx, from, to)
+}
+
+func (tx *MainDatabaseTx) MoveContractStateChanges(ctx context.Context, from, to types.FileContractID) error {
+	return ssql.MoveContractStateChanges(ctx, tx, from, to)
+}
+
 func (tx *MainDatabaseTx) MultipartUpload(ctx context.Context, uploadID string) (api.MultipartUpload, error) {
 	return ssql.MultipartUpload(ctx, tx, uploadID)
 }
@@ -599,14 +644,18 @@ func (tx *MainDatabaseTx) Object(ctx context.Context, bucket, key string) (api.O
 	return ssql.Object(ctx, tx, bucket, key)
 }
 
-func (tx *MainDatabaseTx) Objects(ctx context.Context, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey) (api.ObjectsResponse, error) {
-	return ssql.Objects(ctx, tx, bucket, prefix, substring, delim, sortBy, sortDir, marker, limit, slabEncryptionKey)
+func (tx *MainDatabaseTx) Objects(ctx context.Context, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey, metadata api.ObjectUserMetadata) (api.ObjectsResponse, error) {
+	return ssql.Objects(ctx, tx, bucket, prefix, substring, delim, sortBy, sortDir, marker, limit, slabEncryptionKey, metadata)
 }
 
 func (tx *MainDatabaseTx) ObjectMetadata(ctx context.Context, bucket, key string) (api.Object, error) {
 	return ssql.ObjectMetadata(ctx, tx, bucket, key)
 }
 
+func (tx *MainDatabaseTx) ObjectVersions(ctx context.Context, bucket, key string) ([]api.ObjectVersion, error) {
+	return ssql.ObjectVersions(ctx, tx, bucket, key)
+}
+
 func (tx *MainDatabaseTx) ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts) (api.ObjectsStatsResponse, error) {
 	return ssql.ObjectsStats(ctx, tx, opts)
 }
@@ -686,23 +735,12 @@ CREATE INDEX %s_idx ON %s (root);`, tmpTable, tmpTable, tmpTable, tmpTable, tmpT
 	return
 }
 
-func (tx *MainDatabaseTx) PruneHostSectors(ctx context.Context, limit int64) (int64, error) {
-	res, err := tx.Exec(ctx, `DELETE FROM host_sectors
-WHERE rowid IN (
-	SELECT rowid
-	FROM host_sectors
-	WHERE db_host_id NOT IN (
-		SELECT h.id
-		FROM contracts c
-		INNER JOIN hosts h ON c.host_id = h.id
-		WHERE c.archival_reason IS NULL
-	)
-	LIMIT ?
-)`, limit)
-	if err != nil {
-		return 0, err
-	}
-	return res.RowsAffected()
+func (tx *MainDatabaseTx) PrunableHostSectors(ctx context.Context) (int64, error) {
+	return ssql.PrunableHostSectors(ctx, tx)
+}
+
+func (tx *MainDatabaseTx) PruneHostSectors(ctx context.Context, cursor ssql.HostSectorCursor, limit int64) (int64, ssql.HostSectorCursor, error) {
+	return ssql.PruneHostSectors(ctx, tx, cursor, limit)
 }
 
 func (tx *MainDatabaseTx) PruneSlabs(ctx context.Context, limit int64) (int64, error) {
@@ -737,18 +775,21 @@ func (tx *MainDatabaseTx) PutContract(ctx context.Context, c api.ContractMetadat
 INSERT INTO contracts (
 	created_at, fcid, host_id, host_key,
 	archival_reason, proof_height, renewed_from, renewed_to, revision_height, revision_number, size, start_height, state, usability, window_start, window_end,
-	contract_price, initial_renter_funds,
-	delete_spending, fund_account_spending, sector_roots_spending, upload_spending
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	contract_price, initial_renter_funds, network_fees,
+	delete_spending, fund_account_spending, sector_roots_spending, upload_spending,
+	host_version
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(fcid) DO UPDATE SET
 	fcid = EXCLUDED.fcid, host_id = EXCLUDED.host_id, host_key = EXCLUDED.host_key,
 	archival_reason = EXCLUDED.archival_reason, proof_height = EXCLUDED.proof_height, renewed_from = EXCLUDED.renewed_from, renewed_to = EXCLUDED.renewed_to, revision_height = EXCLUDED.revision_height, revision_number = EXCLUDED.revision_number, size = EXCLUDED.size, start_height = EXCLUDED.start_height, state = EXCLUDED.state, usability = EXCLUDED.usability, window_start = EXCLUDED.window_start, window_end = EXCLUDED.window_end,
-	contract_price = EXCLUDED.contract_price, initial_renter_funds = EXCLUDED.initial_renter_funds,
-	delete_spending = EXCLUDED.delete_spending, fund_account_spending = EXCLUDED.fund_account_spending, sector_roots_spending = EXCLUDED.sector_roots_spending, upload_spending = EXCLUDED.upload_spending`,
+	contract_price = EXCLUDED.contract_price, initial_renter_funds = EXCLUDED.initial_renter_funds, network_fees = EXCLUDED.network_fees,
+	delete_spending = EXCLUDED.delete_spending, fund_account_spending = EXCLUDED.fund_account_spending, sector_roots_spending = EXCLUDED.sector_roots_spending, upload_spending = EXCLUDED.upload_spending,
+	host_version = EXCLUDED.host_version`,
 		time.Now(), ssql.FileContractID(c.ID), hostID, ssql.PublicKey(c.HostKey),
 		ssql.NullableString(c.ArchivalReason), c.ProofHeight, ssql.FileContractID(c.RenewedFrom), ssql.FileContractID(c.RenewedTo), c.RevisionHeight, c.RevisionNumber, c.Size, c.StartHeight, state, usability, c.WindowStart, c.WindowEnd,
-		ssql.Currency(c.ContractPrice), ssql.Currency(c.InitialRenterFunds),
+		ssql.Currency(c.ContractPrice), ssql.Currency(c.InitialRenterFunds), ssql.Currency(c.NetworkFees),
 		ssql.Currency(c.Spending.Deletions), ssql.Currency(c.Spending.FundAccount), ssql.Currency(c.Spending.SectorRoots), ssql.Currency(c.Spending.Uploads),
+		c.HostVersion,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update contract: %w", err)
@@ -756,39 +797,62 @@ ON CONFLICT(fcid) DO UPDATE SET
 	return nil
 }
 
+func (tx *MainDatabaseTx) RecordContractEvent(ctx context.Context, fcid types.FileContractID, eventType string, details any) error {
+	return ssql.RecordContractEvent(ctx, tx, fcid, eventType, details)
+}
+
 func (tx *MainDatabaseTx) RecordContractSpending(ctx context.Context, fcid types.FileContractID, revisionNumber, size uint64, newSpending api.ContractSpending) error {
 	return ssql.RecordContractSpending(ctx, tx, fcid, revisionNumber, size, newSpending)
 }
 
+func (tx *MainDatabaseTx) RecordDismissedAlerts(ctx context.Context, dismissed ...alerts.DismissedAlert) error {
+	return ssql.RecordDismissedAlerts(ctx, tx, dismissed...)
+}
+
 func (tx *MainDatabaseTx) RecordHostScans(ctx context.Context, scans []api.HostScan) error {
 	return ssql.RecordHostScans(ctx, tx, scans)
 }
 
-func (tx *MainDatabaseTx) RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDownTime time.Duration) (int64, error) {
-	return ssql.RemoveOfflineHosts(ctx, tx, minRecentFailures, maxDownTime)
+func (tx *MainDatabaseTx) RecountBucketUsage(ctx context.Context, bucket string) (api.BucketUsage, error) {
+	return ssql.RecountBucketUsage(ctx, tx, bucket)
 }
 
-func (tx *MainDatabaseTx) RenameObject(ctx context.Context, bucket, keyOld, keyNew string, force bool) error {
+func (tx *MainDatabaseTx) RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDownTime time.Duration, exemptActiveContractHosts bool, limit, maxEligible int) (int64, int64, []types.PublicKey, error) {
+	return ssql.RemoveOfflineHosts(ctx, tx, minRecentFailures, maxDownTime, exemptActiveContractHosts, limit, maxEligible)
+}
+
+func (tx *MainDatabaseTx) RenameObject(ctx context.Context, srcBucket, dstBucket, keyOld, keyNew string, force bool) error {
+	// fetch destination bucket
+	var dstBID int64
+	err := tx.QueryRow(ctx, "SELECT id FROM buckets WHERE name = ?", dstBucket).Scan(&dstBID)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return fmt.Errorf("%w: destination bucket", api.ErrBucketNotFound)
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch dst bucket id: %w", err)
+	}
+
 	if force {
 		// delete potentially existing object at destination
-		if _, err := tx.DeleteObject(ctx, bucket, keyNew); err != nil {
+		if _, err := tx.DeleteObject(ctx, dstBucket, keyNew); err != nil {
 			return fmt.Errorf("RenameObject: failed to delete object: %w", err)
 		}
 	} else {
 		var exists bool
-		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM objects WHERE object_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?))", keyNew, bucket).Scan(&exists); err != nil {
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM objects WHERE object_id = ? AND db_bucket_id = ?)", keyNew, dstBID).Scan(&exists); err != nil {
 			return err
 		} else if exists {
 			return api.ErrObjectExists
 		}
 	}
-	resp, err := tx.Exec(ctx, `UPDATE objects SET object_id = ? WHERE object_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?)`, keyNew, keyOld, bucket)
+	resp, err := tx.Exec(ctx, `UPDATE objects SET object_id = ?, db_bucket_id = ? WHERE object_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?)`, keyNew, dstBID, keyOld, srcBucket)
 	if err != nil {
 		return err
 	} else if n, err := resp.RowsAffected(); err != nil {
 		return err
 	} else if n == 0 {
 		return fmt.Errorf("%w: key %v", api.ErrObjectNotFound, keyOld)
+	} else if srcBucket != dstBucket {
+		return ssql.MoveObjectBucketUsage(ctx, tx, srcBucket, dstBID, keyNew)
 	}
 	return nil
 }
@@ -797,10 +861,7 @@ func (tx *MainDatabaseTx) RenameObjects(ctx context.Context, bucket, prefixOld,
 	if force {
 		// to avoid a conflict on update, we delete objects that would conflict
 		// with objects being renamed, within the scope of the bucket of course
-		query := `
-		DELETE
-		FROM objects
-		WHERE
+		where := `
 			db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?) AND
 			object_id IN (
 				SELECT ? || SUBSTR(object_id, ?)
@@ -812,7 +873,7 @@ func (tx *MainDatabaseTx) RenameObjects(ctx context.Context, bucket, prefixOld,
 			prefixNew, utf8.RuneCountInString(prefixOld) + 1,
 			prefixOld + "%", utf8.RuneCountInString(prefixOld), prefixOld,
 		}
-		_, err := tx.Exec(ctx, query, args...)
+		_, err := tx.deleteObjects(ctx, where, args...)
 		if err != nil {
 			return err
 		}
@@ -859,6 +920,14 @@ func (tx *MainDatabaseTx) ResetLostSectors(ctx context.Context, hk types.PublicK
 	return ssql.ResetLostSectors(ctx, tx, hk)
 }
 
+func (tx *MainDatabaseTx) UpdateHostSectorSize(ctx context.Context, hk types.PublicKey, sectorSize uint64) error {
+	return ssql.UpdateHostSectorSize(ctx, tx, hk, sectorSize)
+}
+
+func (tx *MainDatabaseTx) RestoreObjectVersion(ctx context.Context, bucket, key string, id int64) error {
+	return ssql.RestoreObjectVersion(ctx, tx, bucket, key, id)
+}
+
 func (tx *MainDatabaseTx) SaveAccounts(ctx context.Context, accounts []api.Account) error {
 	// clean_shutdown = 1 after save
 	stmt, err := tx.Prepare(ctx, `
@@ -904,10 +973,22 @@ func (tx *MainDatabaseTx) SelectObjectMetadataExpr() string {
 	return "o.object_id, o.size, o.health, o.mime_type, DATETIME(o.created_at), o.etag, b.name"
 }
 
+func (tx *MainDatabaseTx) UpdateContractSpendingCap(ctx context.Context, fcid types.FileContractID, spendingCap types.Currency) error {
+	return ssql.UpdateContractSpendingCap(ctx, tx, fcid, spendingCap)
+}
+
 func (tx *MainDatabaseTx) UpdateContractUsability(ctx context.Context, fcid types.FileContractID, usability string) error {
 	return ssql.UpdateContractUsability(ctx, tx, fcid, usability)
 }
 
+func (tx *MainDatabaseTx) UpdateContractUsabilityOverride(ctx context.Context, fcid types.FileContractID, usability string) error {
+	return ssql.UpdateContractUsabilityOverride(ctx, tx, fcid, usability)
+}
+
+func (tx *MainDatabaseTx) UpdateContractVerificationStatus(ctx context.Context, fcid types.FileContractID, status string) error {
+	return ssql.UpdateContractVerificationStatus(ctx, tx, fcid, status)
+}
+
 func (tx *MainDatabaseTx) Setting(ctx context.Context, key string) (string, error) {
 	return ssql.Setting(ctx, tx, key)
 }
@@ -916,6 +997,10 @@ func (tx *MainDatabaseTx) Slab(ctx context.Context, key object.EncryptionKey) (o
 	return ssql.Slab(ctx, tx, key)
 }
 
+func (tx *MainDatabaseTx) SlabBufferObjects(ctx context.Context) (map[int64][]string, error) {
+	return ssql.SlabBufferObjects(ctx, tx)
+}
+
 func (tx *MainDatabaseTx) SlabsForMigration(ctx context.Context, healthCutoff float64, limit int) ([]api.UnhealthySlab, error) {
 	return ssql.SlabsForMigration(ctx, tx, healthCutoff, limit)
 }
@@ -924,6 +1009,10 @@ func (tx *MainDatabaseTx) Tip(ctx context.Context) (types.ChainIndex, error) {
 	return ssql.Tip(ctx, tx.Tx)
 }
 
+func (tx *MainDatabaseTx) UnarchiveContract(ctx context.Context, fcid types.FileContractID) error {
+	return ssql.UnarchiveContract(ctx, tx, fcid)
+}
+
 func (tx *MainDatabaseTx) UnspentSiacoinElements(ctx context.Context) (ci types.ChainIndex, elements []types.SiacoinElement, err error) {
 	return ssql.UnspentSiacoinElements(ctx, tx.Tx)
 }
@@ -936,6 +1025,10 @@ func (tx *MainDatabaseTx) UpdateBucketPolicy(ctx context.Context, bucket string,
 	return ssql.UpdateBucketPolicy(ctx, tx, bucket, policy)
 }
 
+func (tx *MainDatabaseTx) UpdateBucketRedundancy(ctx context.Context, bucket string, rs *api.RedundancySettings) error {
+	return ssql.UpdateBucketRedundancy(ctx, tx, bucket, rs)
+}
+
 func (tx *MainDatabaseTx) UpdateContract(ctx context.Context, fcid types.FileContractID, c api.ContractMetadata) error {
 	return ssql.UpdateContract(ctx, tx, fcid, c)
 }
@@ -1076,6 +1169,14 @@ func (tx *MainDatabaseTx) UpdateHostCheck(ctx context.Context, hk types.PublicKe
 	return nil
 }
 
+func (tx *MainDatabaseTx) UpdateObjectMimeType(ctx context.Context, bucket, key, mimeType string) error {
+	return ssql.UpdateObjectMimeType(ctx, tx, bucket, key, mimeType)
+}
+
+func (tx *MainDatabaseTx) UpdateObjectUserMetadata(ctx context.Context, bucket, key string, md api.ObjectUserMetadata) error {
+	return ssql.UpdateObjectUserMetadata(ctx, tx, bucket, key, md)
+}
+
 func (tx *MainDatabaseTx) UpdatePeerInfo(ctx context.Context, addr string, fn func(*syncer.PeerInfo)) error {
 	return ssql.UpdatePeerInfo(ctx, tx, addr, fn)
 }
@@ -1192,6 +1293,27 @@ func (tx *MainDatabaseTx) WalletReleaseOutputs(ctx context.Context, scois []type
 	return ssql.WalletReleaseOutputs(ctx, tx.Tx, scois)
 }
 
+// deleteObjects deletes the objects matching the given where clause and
+// updates the usage of the bucket they belonged to.
+func (tx *MainDatabaseTx) deleteObjects(ctx context.Context, where string, args ...any) (bool, error) {
+	bucketID, objects, size, err := ssql.ObjectsUsage(ctx, tx, "SELECT db_bucket_id, size FROM objects WHERE "+where, args...)
+	if err != nil {
+		return false, err
+	} else if objects == 0 {
+		return false, nil
+	}
+
+	resp, err := tx.Exec(ctx, "DELETE FROM objects WHERE "+where, args...)
+	if err != nil {
+		return false, err
+	} else if n, err := resp.RowsAffected(); err != nil {
+		return false, err
+	} else if n == 0 {
+		return false, nil
+	}
+	return true, ssql.UpdateBucketUsage(ctx, tx, bucketID, -objects, -size)
+}
+
 func (tx *MainDatabaseTx) insertSlabs(ctx context.Context, objID, partID *int64, slices object.SlabSlices) error {
 	if (objID == nil) == (partID == nil) {
 		return errors.New("exactly one of objID and partID must be set")
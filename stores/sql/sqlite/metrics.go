@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"time"
 
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/internal/sql"
 	ssql "go.sia.tech/renterd/v2/stores/sql"
@@ -84,6 +85,10 @@ func (tx *MetricsDatabaseTx) ContractPruneMetrics(ctx context.Context, start tim
 	return ssql.ContractPruneMetrics(ctx, tx, start, n, interval, opts)
 }
 
+func (tx *MetricsDatabaseTx) ContractSpendingHistory(ctx context.Context, fcid types.FileContractID, from, to time.Time, interval time.Duration) ([]api.ContractSpendingBucket, error) {
+	return ssql.ContractSpendingHistory(ctx, tx, fcid, from, to, interval)
+}
+
 func (tx *MetricsDatabaseTx) PruneMetrics(ctx context.Context, metric string, cutoff time.Time) error {
 	return ssql.PruneMetrics(ctx, tx, metric, cutoff)
 }
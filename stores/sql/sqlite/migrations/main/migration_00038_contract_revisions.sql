@@ -0,0 +1,2 @@
+CREATE TABLE `contract_revisions` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_contract_id` integer NOT NULL,`revision_number` text NOT NULL,`size` integer NOT NULL,`timestamp` integer NOT NULL,CONSTRAINT `fk_contract_revisions_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_contract_revisions_db_contract_id` ON `contract_revisions`(`db_contract_id`);
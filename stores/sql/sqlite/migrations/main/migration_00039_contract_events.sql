@@ -0,0 +1,2 @@
+CREATE TABLE `contract_events` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`fcid` blob NOT NULL,`timestamp` integer NOT NULL,`event_type` text NOT NULL,`details` text);
+CREATE INDEX `idx_contract_events_fcid_timestamp` ON `contract_events`(`fcid`,`timestamp`);
@@ -0,0 +1 @@
+ALTER TABLE autopilot_config ADD COLUMN contracts_max_per_host integer NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+CREATE TABLE `dismissed_alerts` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`alert_id` blob NOT NULL,`timestamp` integer NOT NULL,`reason` text NOT NULL,`dismissed_by` text NOT NULL,`alert` text);
+CREATE INDEX `idx_dismissed_alerts_timestamp` ON `dismissed_alerts`(`timestamp`);
@@ -0,0 +1 @@
+CREATE TABLE `bucket_usage` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_bucket_id` integer NOT NULL UNIQUE,`object_count` integer NOT NULL DEFAULT 0,`total_bytes` integer NOT NULL DEFAULT 0,`last_updated` datetime NOT NULL,CONSTRAINT `fk_bucket_usage_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`) ON DELETE CASCADE);
@@ -0,0 +1,2 @@
+ALTER TABLE contracts ADD COLUMN network_fees text;
+UPDATE contracts SET network_fees = '0';
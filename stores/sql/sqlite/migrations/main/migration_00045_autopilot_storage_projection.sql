@@ -0,0 +1 @@
+ALTER TABLE autopilot_config ADD COLUMN contracts_storage_projection text NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+CREATE TABLE `object_versions` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_bucket_id` integer NOT NULL,`object_id` text,`key` blob,`size` integer,`mime_type` text,`etag` text,CONSTRAINT `fk_object_versions_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_object_versions_db_bucket_id_object_id` ON `object_versions`(`db_bucket_id`,`object_id`);
+CREATE INDEX `idx_object_versions_created_at` ON `object_versions`(`created_at`);
+ALTER TABLE `slices` ADD COLUMN `db_object_version_id` integer DEFAULT NULL REFERENCES `object_versions`(`id`) ON DELETE CASCADE;
+CREATE INDEX `idx_slices_db_object_version_id` ON `slices`(`db_object_version_id`);
@@ -0,0 +1,2 @@
+CREATE TABLE `contract_state_changes` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`fcid` blob NOT NULL,`from_state` text NOT NULL,`to_state` text NOT NULL,`reason` text NOT NULL,`height` integer NOT NULL,`timestamp` integer NOT NULL);
+CREATE INDEX `idx_contract_state_changes_fcid_timestamp` ON `contract_state_changes`(`fcid`,`timestamp`);
@@ -0,0 +1 @@
+ALTER TABLE contracts ADD COLUMN usability_override integer NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE contracts ADD COLUMN verification_status integer NOT NULL DEFAULT 0;
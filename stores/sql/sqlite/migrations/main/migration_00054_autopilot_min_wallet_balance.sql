@@ -0,0 +1 @@
+ALTER TABLE autopilot_config ADD COLUMN contracts_min_wallet_balance text;
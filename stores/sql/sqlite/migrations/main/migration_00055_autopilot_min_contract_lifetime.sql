@@ -0,0 +1 @@
+ALTER TABLE autopilot_config ADD COLUMN contracts_min_contract_lifetime integer NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE contracts ADD COLUMN host_version text NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE autopilot_config ADD COLUMN contracts_period_multiplier real NOT NULL DEFAULT 1;
+ALTER TABLE autopilot_config ADD COLUMN contracts_period_multiplier_growth_factor real NOT NULL DEFAULT 2;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS contract_state_changes_temp;
+CREATE TABLE `contract_state_changes_temp` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_contract_id` integer NOT NULL,`from_state` text NOT NULL,`to_state` text NOT NULL,`reason` text NOT NULL,`height` integer NOT NULL,`timestamp` integer NOT NULL,CONSTRAINT `fk_contract_state_changes_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts`(`id`) ON DELETE CASCADE);
+INSERT INTO contract_state_changes_temp (`id`, `created_at`, `db_contract_id`, `from_state`, `to_state`, `reason`, `height`, `timestamp`)
+SELECT csc.`id`, csc.`created_at`, c.`id`, csc.`from_state`, csc.`to_state`, csc.`reason`, csc.`height`, csc.`timestamp`
+FROM contract_state_changes csc
+INNER JOIN contracts c ON c.`fcid` = csc.`fcid`;
+DROP TABLE contract_state_changes;
+ALTER TABLE contract_state_changes_temp RENAME TO contract_state_changes;
+CREATE INDEX `idx_contract_state_changes_db_contract_id_timestamp` ON `contract_state_changes`(`db_contract_id`,`timestamp`);
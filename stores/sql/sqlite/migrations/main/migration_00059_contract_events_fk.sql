@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS contract_events_temp;
+CREATE TABLE `contract_events_temp` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_contract_id` integer NOT NULL,`timestamp` integer NOT NULL,`event_type` text NOT NULL,`details` text,CONSTRAINT `fk_contract_events_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts`(`id`) ON DELETE CASCADE);
+INSERT INTO contract_events_temp (`id`, `created_at`, `db_contract_id`, `timestamp`, `event_type`, `details`)
+SELECT ce.`id`, ce.`created_at`, c.`id`, ce.`timestamp`, ce.`event_type`, ce.`details`
+FROM contract_events ce
+INNER JOIN contracts c ON c.`fcid` = ce.`fcid`;
+DROP TABLE contract_events;
+ALTER TABLE contract_events_temp RENAME TO contract_events;
+CREATE INDEX `idx_contract_events_db_contract_id_timestamp` ON `contract_events`(`db_contract_id`,`timestamp`);
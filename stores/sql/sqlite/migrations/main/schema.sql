@@ -17,7 +17,8 @@ CREATE TABLE `hosts` (
 `successful_interactions` real,
 `failed_interactions` real,
 `lost_sectors` integer,
-`last_announcement` datetime);
+`last_announcement` datetime,
+`sector_size` integer NOT NULL DEFAULT 4194304);
 CREATE INDEX `idx_hosts_recent_scan_failures` ON `hosts`(`recent_scan_failures`);
 CREATE INDEX `idx_hosts_recent_downtime` ON `hosts`(`recent_downtime`);
 CREATE INDEX `idx_hosts_scanned` ON `hosts`(`scanned`);
@@ -25,7 +26,7 @@ CREATE INDEX `idx_hosts_last_scan` ON `hosts`(`last_scan`);
 CREATE INDEX `idx_hosts_public_key` ON `hosts`(`public_key`);
 
 -- dbContract
-CREATE TABLE contracts (`id` integer PRIMARY KEY AUTOINCREMENT, `created_at` datetime, `fcid` blob NOT NULL UNIQUE, `host_id` integer, `host_key` blob NOT NULL, `archival_reason` text DEFAULT NULL, `proof_height` integer DEFAULT 0, `renewed_from` blob, `renewed_to` blob, `revision_height` integer DEFAULT 0, `revision_number` text NOT NULL DEFAULT "0", `size` integer, `start_height` integer NOT NULL, `state` integer NOT NULL DEFAULT 0, `usability` integer NOT NULL, `window_start` integer NOT NULL DEFAULT 0, `window_end` integer NOT NULL DEFAULT 0, `contract_price` text, `initial_renter_funds` text, `delete_spending` text, `fund_account_spending` text, `sector_roots_spending` text, `upload_spending` text, CONSTRAINT `fk_contracts_host` FOREIGN KEY (`host_id`) REFERENCES `hosts`(`id`));
+CREATE TABLE contracts (`id` integer PRIMARY KEY AUTOINCREMENT, `created_at` datetime, `fcid` blob NOT NULL UNIQUE, `host_id` integer, `host_key` blob NOT NULL, `archival_reason` text DEFAULT NULL, `proof_height` integer DEFAULT 0, `renewed_from` blob, `renewed_to` blob, `revision_height` integer DEFAULT 0, `revision_number` text NOT NULL DEFAULT "0", `size` integer, `start_height` integer NOT NULL, `state` integer NOT NULL DEFAULT 0, `usability` integer NOT NULL, `window_start` integer NOT NULL DEFAULT 0, `window_end` integer NOT NULL DEFAULT 0, `contract_price` text, `initial_renter_funds` text, `network_fees` text, `delete_spending` text, `fund_account_spending` text, `sector_roots_spending` text, `upload_spending` text, `spending_cap` text, `usability_override` integer NOT NULL DEFAULT 0, `verification_status` integer NOT NULL DEFAULT 0, `host_version` text NOT NULL DEFAULT '', CONSTRAINT `fk_contracts_host` FOREIGN KEY (`host_id`) REFERENCES `hosts`(`id`));
 CREATE INDEX `idx_contracts_archival_reason` ON `contracts`(`archival_reason`);
 CREATE INDEX `idx_contracts_fcid` ON `contracts`(`fcid`);
 CREATE INDEX `idx_contracts_host_id` ON `contracts`(`host_id`);
@@ -41,7 +42,7 @@ CREATE INDEX `idx_contracts_window_end` ON `contracts`(`window_end`);
 CREATE INDEX `idx_contracts_window_start` ON `contracts`(`window_start`);
 
 -- dbBucket
-CREATE TABLE `buckets` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`policy` text,`name` text NOT NULL UNIQUE);
+CREATE TABLE `buckets` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`policy` text,`name` text NOT NULL UNIQUE,`redundancy` text);
 CREATE INDEX `idx_buckets_name` ON `buckets`(`name`);
 
 -- dbObject
@@ -96,12 +97,18 @@ CREATE INDEX `idx_multipart_parts_db_multipart_upload_id` ON `multipart_parts`(`
 CREATE INDEX `idx_multipart_parts_part_number` ON `multipart_parts`(`part_number`);
 CREATE INDEX `idx_multipart_parts_etag` ON `multipart_parts`(`etag`);
 
+-- dbObjectVersion
+CREATE TABLE `object_versions` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_bucket_id` integer NOT NULL,`object_id` text,`key` blob,`size` integer,`mime_type` text,`etag` text,CONSTRAINT `fk_object_versions_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_object_versions_db_bucket_id_object_id` ON `object_versions`(`db_bucket_id`,`object_id`);
+CREATE INDEX `idx_object_versions_created_at` ON `object_versions`(`created_at`);
+
 -- dbSlice
-CREATE TABLE `slices` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_object_id` integer,`object_index` integer,`db_multipart_part_id` integer,`db_slab_id` integer,`offset` integer,`length` integer,CONSTRAINT `fk_objects_slabs` FOREIGN KEY (`db_object_id`) REFERENCES `objects`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_multipart_parts_slabs` FOREIGN KEY (`db_multipart_part_id`) REFERENCES `multipart_parts`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_slabs_slices` FOREIGN KEY (`db_slab_id`) REFERENCES `slabs`(`id`));
+CREATE TABLE `slices` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_object_id` integer,`object_index` integer,`db_multipart_part_id` integer,`db_slab_id` integer,`offset` integer,`length` integer,`db_object_version_id` integer DEFAULT NULL,CONSTRAINT `fk_objects_slabs` FOREIGN KEY (`db_object_id`) REFERENCES `objects`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_multipart_parts_slabs` FOREIGN KEY (`db_multipart_part_id`) REFERENCES `multipart_parts`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_slabs_slices` FOREIGN KEY (`db_slab_id`) REFERENCES `slabs`(`id`),CONSTRAINT `fk_object_versions_slabs` FOREIGN KEY (`db_object_version_id`) REFERENCES `object_versions`(`id`) ON DELETE CASCADE);
 CREATE INDEX `idx_slices_object_index` ON `slices`(`object_index`);
 CREATE INDEX `idx_slices_db_object_id` ON `slices`(`db_object_id`);
 CREATE INDEX `idx_slices_db_slab_id` ON `slices`(`db_slab_id`);
 CREATE INDEX `idx_slices_db_multipart_part_id` ON `slices`(`db_multipart_part_id`);
+CREATE INDEX `idx_slices_db_object_version_id` ON `slices`(`db_object_version_id`);
 
 -- host_addresses contains addresses that the host announced itself with
 CREATE TABLE `host_addresses` (
@@ -232,5 +239,24 @@ CREATE TABLE `contract_elements` (
     CONSTRAINT `fk_contract_elements_contracts` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts`(`id`) ON DELETE CASCADE);
 CREATE UNIQUE INDEX `idx_contract_elements_db_contract_id` ON `contract_elements`(`db_contract_id`);
 
+-- contract revisions
+CREATE TABLE `contract_revisions` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_contract_id` integer NOT NULL,`revision_number` text NOT NULL,`size` integer NOT NULL,`timestamp` integer NOT NULL,CONSTRAINT `fk_contract_revisions_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_contract_revisions_db_contract_id` ON `contract_revisions`(`db_contract_id`);
+
+-- contract events
+CREATE TABLE `contract_events` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_contract_id` integer NOT NULL,`timestamp` integer NOT NULL,`event_type` text NOT NULL,`details` text,CONSTRAINT `fk_contract_events_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_contract_events_db_contract_id_timestamp` ON `contract_events`(`db_contract_id`,`timestamp`);
+
+-- contract state changes
+CREATE TABLE `contract_state_changes` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_contract_id` integer NOT NULL,`from_state` text NOT NULL,`to_state` text NOT NULL,`reason` text NOT NULL,`height` integer NOT NULL,`timestamp` integer NOT NULL,CONSTRAINT `fk_contract_state_changes_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_contract_state_changes_db_contract_id_timestamp` ON `contract_state_changes`(`db_contract_id`,`timestamp`);
+
+-- dismissed alerts
+CREATE TABLE `dismissed_alerts` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`alert_id` blob NOT NULL,`timestamp` integer NOT NULL,`reason` text NOT NULL,`dismissed_by` text NOT NULL,`alert` text);
+CREATE INDEX `idx_dismissed_alerts_timestamp` ON `dismissed_alerts`(`timestamp`);
+
+-- bucket usage
+CREATE TABLE `bucket_usage` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_bucket_id` integer NOT NULL UNIQUE,`object_count` integer NOT NULL DEFAULT 0,`total_bytes` integer NOT NULL DEFAULT 0,`last_updated` datetime NOT NULL,CONSTRAINT `fk_bucket_usage_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`) ON DELETE CASCADE);
+
 -- autopilot config
-CREATE TABLE autopilot_config (id INTEGER PRIMARY KEY CHECK (id = 1), created_at datetime, enabled integer NOT NULL DEFAULT 0, contracts_amount integer, contracts_period integer, contracts_renew_window integer, contracts_download integer, contracts_upload integer, contracts_storage integer, contracts_prune integer NOT NULL DEFAULT 0, hosts_max_downtime_hours integer, hosts_min_protocol_version text, hosts_max_consecutive_scan_failures integer);
+CREATE TABLE autopilot_config (id INTEGER PRIMARY KEY CHECK (id = 1), created_at datetime, enabled integer NOT NULL DEFAULT 0, contracts_amount integer, contracts_period integer, contracts_renew_window integer, contracts_download integer, contracts_upload integer, contracts_storage integer, contracts_prune integer NOT NULL DEFAULT 0, contracts_max_per_subnet integer NOT NULL DEFAULT 1, contracts_max_per_host integer NOT NULL DEFAULT 1, contracts_prune_alert_threshold integer NOT NULL DEFAULT 10000000000, contracts_min_wallet_balance text, contracts_storage_projection text NOT NULL DEFAULT '{}', contracts_min_contract_lifetime integer NOT NULL DEFAULT 0, contracts_period_multiplier real NOT NULL DEFAULT 1, contracts_period_multiplier_growth_factor real NOT NULL DEFAULT 2, hosts_max_downtime_hours integer, hosts_min_protocol_version text, hosts_max_consecutive_scan_failures integer);
@@ -48,6 +48,8 @@ type (
 	ChainProtocol  chain.Protocol
 	HostSettings   rhp.HostSettings
 
+	StorageProjection map[string]uint64
+
 	FileContractStateElement struct {
 		ID int64 // db_contract_id
 		types.StateElement
@@ -57,6 +59,15 @@ type (
 		ID Hash256 // output_id
 		types.StateElement
 	}
+
+	// HostSectorCursor points at the last host sector that was pruned, host
+	// sectors are pruned in the order of their primary key so the host id
+	// breaks ties between links to the same sector. The zero value points at
+	// the start of the table.
+	HostSectorCursor struct {
+		SectorID int64 // db_sector_id
+		HostID   int64 // db_host_id
+	}
 )
 
 type scannerValuer interface {
@@ -81,6 +92,7 @@ var (
 	_ scannerValuer = (*FileContract)(nil)
 	_ scannerValuer = (*ChainProtocol)(nil)
 	_ scannerValuer = (*HostSettings)(nil)
+	_ scannerValuer = (*StorageProjection)(nil)
 )
 
 // Scan implements the sql.Scanner interface.
@@ -529,3 +541,32 @@ func (hs HostSettings) Value() (driver.Value, error) {
 	}
 	return json.Marshal(hs)
 }
+
+// Scan scan value into StorageProjection, implements sql.Scanner interface.
+func (sp *StorageProjection) Scan(value interface{}) error {
+	var bytes []byte
+	switch value := value.(type) {
+	case string:
+		bytes = []byte(value)
+	case []byte:
+		bytes = value
+	default:
+		return errors.New(fmt.Sprint("failed to unmarshal StorageProjection value:", value))
+	}
+	var m map[string]uint64
+	if err := json.Unmarshal(bytes, &m); err != nil {
+		return err
+	} else if len(m) == 0 {
+		m = nil
+	}
+	*sp = m
+	return nil
+}
+
+// Value returns a StorageProjection value, implements driver.Valuer interface.
+func (sp StorageProjection) Value() (driver.Value, error) {
+	if len(sp) == 0 {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(sp)
+}
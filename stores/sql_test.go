@@ -46,7 +46,12 @@ type testSQLStoreConfig struct {
 	dbMetricsName string
 	dir           string
 	persistent    bool
+	pool          PoolConfig
 	skipMigrate   bool
+
+	exemptActiveContractHosts bool
+	maxHostSectorPrunePerRun  int
+	objectVersionGCInterval   time.Duration
 }
 
 var defaultTestSQLStoreConfig = testSQLStoreConfig{}
@@ -166,7 +171,7 @@ func newTestSQLStore(t *testing.T, cfg testSQLStoreConfig) *testSQLStore {
 		t.Fatal("failed to create db connections", err)
 	}
 
-	alerts := alerts.WithOrigin(alerts.NewManager(), "test")
+	alerts := alerts.WithOrigin(alerts.NewManager(alerts.Config{}), "test")
 	sqlStore, err := NewSQLStore(Config{
 		Alerts:                        alerts,
 		DB:                            dbMain,
@@ -176,13 +181,16 @@ func newTestSQLStore(t *testing.T, cfg testSQLStoreConfig) *testSQLStore {
 		SlabBufferCompletionThreshold: 0,
 		Logger:                        zap.NewNop(),
 		LongQueryDuration:             100 * time.Millisecond,
-		LongTxDuration:                100 * time.Millisecond,
+		Pool:                          cfg.pool,
+		MaxHostSectorPrunePerRun:      cfg.maxHostSectorPrunePerRun,
+		ExemptActiveContractHosts:     cfg.exemptActiveContractHosts,
+		ObjectVersionGCInterval:       cfg.objectVersionGCInterval,
 	})
 	if err != nil {
 		t.Fatal("failed to create SQLStore", err)
 	}
 
-	err = sqlStore.CreateBucket(context.Background(), testBucket, api.BucketPolicy{})
+	err = sqlStore.CreateBucket(context.Background(), testBucket, api.BucketPolicy{}, nil)
 	if err != nil && !errors.Is(err, api.ErrBucketExists) {
 		t.Fatal("failed to create test bucket", err)
 	}
@@ -336,3 +344,56 @@ func (s *testSQLStore) renewTestContract(hk types.PublicKey, renewedFrom, renewe
 	renewal.RenewedFrom = renewedFrom
 	return s.AddRenewal(context.Background(), renewal)
 }
+
+func TestTransactionTimeout(t *testing.T) {
+	// a timed out transaction discards its connection, which would drop an
+	// in-memory SQLite database
+	cfg := defaultTestSQLStoreConfig
+	cfg.persistent = config.MySQLConfigFromEnv().URI == ""
+	ss := newTestSQLStore(t, cfg)
+	defer ss.Close()
+	ss.longTxDuration = 100 * time.Millisecond
+
+	// assert a fast transaction succeeds
+	if err := ss.transaction(context.Background(), func(tx sql.DatabaseTx) error {
+		_, err := tx.Bucket(context.Background(), testBucket)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert a slow transaction is rolled back
+	err := ss.transaction(context.Background(), func(tx sql.DatabaseTx) error {
+		time.Sleep(200 * time.Millisecond)
+		return tx.CreateBucket(context.Background(), "slow", api.BucketPolicy{})
+	})
+	if !errors.Is(err, isql.ErrTransactionTimeout) {
+		t.Fatal("expected ErrTransactionTimeout, got", err)
+	} else if _, err := ss.Bucket(context.Background(), "slow"); !errors.Is(err, api.ErrBucketNotFound) {
+		t.Fatal("expected bucket to not exist, got", err)
+	}
+
+	// assert the timeout applies to every attempt rather than all of them
+	var attempts int
+	if err := ss.transaction(context.Background(), func(tx sql.DatabaseTx) error {
+		attempts++
+		time.Sleep(60 * time.Millisecond)
+		if attempts == 1 {
+			return errors.New("database is locked; Deadlock found when trying to get lock")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	} else if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+
+	// assert the timeout can be disabled
+	ss.longTxDuration = 0
+	if err := ss.transaction(context.Background(), func(tx sql.DatabaseTx) error {
+		time.Sleep(200 * time.Millisecond)
+		return tx.CreateBucket(context.Background(), "slow", api.BucketPolicy{})
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
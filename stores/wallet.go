@@ -16,7 +16,7 @@ var (
 // Tip returns the consensus change ID and block height of the last wallet
 // change.
 func (s *SQLStore) Tip() (ci types.ChainIndex, err error) {
-	err = s.db.Transaction(s.shutdownCtx, func(tx sql.DatabaseTx) error {
+	err = s.transaction(s.shutdownCtx, func(tx sql.DatabaseTx) error {
 		ci, err = tx.Tip(s.shutdownCtx)
 		return err
 	})
@@ -25,7 +25,7 @@ func (s *SQLStore) Tip() (ci types.ChainIndex, err error) {
 
 // UnspentSiacoinElements returns a list of all unspent siacoin outputs
 func (s *SQLStore) UnspentSiacoinElements() (ci types.ChainIndex, elements []types.SiacoinElement, err error) {
-	err = s.db.Transaction(context.Background(), func(tx sql.DatabaseTx) (err error) {
+	err = s.transaction(context.Background(), func(tx sql.DatabaseTx) (err error) {
 		ci, elements, err = tx.UnspentSiacoinElements(context.Background())
 		return
 	})
@@ -35,7 +35,7 @@ func (s *SQLStore) UnspentSiacoinElements() (ci types.ChainIndex, elements []typ
 // WalletEvents returns a paginated list of events, ordered by maturity height,
 // descending. If no more events are available, (nil, nil) is returned.
 func (s *SQLStore) WalletEvents(offset, limit int) (events []wallet.Event, err error) {
-	err = s.db.Transaction(context.Background(), func(tx sql.DatabaseTx) (err error) {
+	err = s.transaction(context.Background(), func(tx sql.DatabaseTx) (err error) {
 		events, err = tx.WalletEvents(context.Background(), offset, limit)
 		return
 	})
@@ -44,7 +44,7 @@ func (s *SQLStore) WalletEvents(offset, limit int) (events []wallet.Event, err e
 
 // WalletEventCount returns the number of events relevant to the wallet.
 func (s *SQLStore) WalletEventCount() (count uint64, err error) {
-	err = s.db.Transaction(context.Background(), func(tx sql.DatabaseTx) (err error) {
+	err = s.transaction(context.Background(), func(tx sql.DatabaseTx) (err error) {
 		count, err = tx.WalletEventCount(context.Background())
 		return
 	})
@@ -53,7 +53,7 @@ func (s *SQLStore) WalletEventCount() (count uint64, err error) {
 
 // LockUTXOs locks the specified UTXOs until the specified time.
 func (s *SQLStore) LockUTXOs(scois []types.SiacoinOutputID, until time.Time) error {
-	return s.db.Transaction(s.shutdownCtx, func(tx sql.DatabaseTx) error {
+	return s.transaction(s.shutdownCtx, func(tx sql.DatabaseTx) error {
 		return tx.WalletLockOutputs(s.shutdownCtx, scois, until)
 	})
 }
@@ -61,7 +61,7 @@ func (s *SQLStore) LockUTXOs(scois []types.SiacoinOutputID, until time.Time) err
 // LockedUTXOs returns a list of UTXOs that are currently locked until the
 // specified time.
 func (s *SQLStore) LockedUTXOs(t time.Time) (utxos []types.SiacoinOutputID, err error) {
-	err = s.db.Transaction(s.shutdownCtx, func(tx sql.DatabaseTx) (err error) {
+	err = s.transaction(s.shutdownCtx, func(tx sql.DatabaseTx) (err error) {
 		utxos, err = tx.WalletLockedOutputs(s.shutdownCtx, t)
 		return
 	})
@@ -71,7 +71,7 @@ func (s *SQLStore) LockedUTXOs(t time.Time) (utxos []types.SiacoinOutputID, err
 // ReleaseUTXOs releases the specified UTXOs, making them available for use
 // again. If the UTXOs are not locked, this is a no-op.
 func (s *SQLStore) ReleaseUTXOs(scois []types.SiacoinOutputID) error {
-	return s.db.Transaction(s.shutdownCtx, func(tx sql.DatabaseTx) error {
+	return s.transaction(s.shutdownCtx, func(tx sql.DatabaseTx) error {
 		return tx.WalletReleaseOutputs(s.shutdownCtx, scois)
 	})
 }
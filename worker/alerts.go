@@ -2,6 +2,7 @@ package worker
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"go.sia.tech/core/types"
@@ -16,9 +17,11 @@ func randomAlertID() types.Hash256 {
 
 func newDownloadFailedAlert(bucket, key string, offset, length, contracts int64, err error) alerts.Alert {
 	return alerts.Alert{
-		ID:       randomAlertID(),
-		Severity: alerts.SeverityError,
-		Message:  "Download failed",
+		ID:          randomAlertID(),
+		Severity:    alerts.SeverityError,
+		Message:     "Download failed",
+		Description: fmt.Sprintf("Downloading %d bytes at offset %d of object %q in bucket %q using %d contracts failed: %v", length, offset, key, bucket, contracts, err),
+		Suggestion:  "Download failures can be temporary, if they persist check the object's health and make sure enough of the hosts storing its data are online.",
 		Data: map[string]any{
 			"bucket":    bucket,
 			"key":       key,
@@ -28,6 +31,7 @@ func newDownloadFailedAlert(bucket, key string, offset, length, contracts int64,
 			"error":     err.Error(),
 		},
 		Timestamp: time.Now(),
+		GroupKey:  fmt.Sprintf("download failed %v/%v", bucket, key),
 	}
 }
 
@@ -61,10 +65,13 @@ func newUploadFailedAlert(bucket, path, mimeType string, minShards, totalShards,
 	}
 
 	return alerts.Alert{
-		ID:        randomAlertID(),
-		Severity:  alerts.SeverityError,
-		Message:   "Upload failed",
-		Data:      data,
-		Timestamp: time.Now(),
+		ID:          randomAlertID(),
+		Severity:    alerts.SeverityError,
+		Message:     "Upload failed",
+		Description: fmt.Sprintf("Uploading object %q to bucket %q with %d-of-%d redundancy using %d contracts failed: %v", path, bucket, minShards, totalShards, contracts, err),
+		Suggestion:  "Make sure there are at least as many usable contracts as the total number of shards, the errors returned by the individual hosts are listed in the alert's data.",
+		Data:        data,
+		Timestamp:   time.Now(),
+		GroupKey:    fmt.Sprintf("upload failed %v/%v", bucket, path),
 	}
 }
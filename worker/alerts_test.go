@@ -27,9 +27,11 @@ func TestUploadFailedAlertErrorSet(t *testing.T) {
 	alert.Timestamp = time.Time{}
 
 	expectedAlert := alerts.Alert{
-		ID:       types.Hash256{1, 2, 3},
-		Severity: alerts.SeverityError,
-		Message:  "Upload failed",
+		ID:          types.Hash256{1, 2, 3},
+		Severity:    alerts.SeverityError,
+		Message:     "Upload failed",
+		Description: fmt.Sprintf("Uploading object \"path\" to bucket \"bucket\" with 1-of-2 redundancy using 3 contracts failed: %v", wrapped),
+		Suggestion:  "Make sure there are at least as many usable contracts as the total number of shards, the errors returned by the individual hosts are listed in the alert's data.",
 		Data: map[string]any{
 			"bucket":    "bucket",
 			"contracts": 3,
@@ -43,6 +45,7 @@ func TestUploadFailedAlertErrorSet(t *testing.T) {
 			"path":        "path",
 			"totalShards": 2,
 		},
+		GroupKey: "upload failed bucket/path",
 	}
 	if !cmp.Equal(alert, expectedAlert) {
 		t.Fatal(cmp.Diff(alert, expectedAlert))
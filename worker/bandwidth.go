@@ -0,0 +1,181 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"go.sia.tech/renterd/v2/api"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// bandwidthRateWindow is the window over which the current download rate
+	// of a bucket is measured.
+	bandwidthRateWindow = 5 * time.Second
+)
+
+type (
+	// bucketBandwidthLimiters keeps track of the download rate limiters of
+	// all buckets that have been downloaded from.
+	bucketBandwidthLimiters struct {
+		mu       sync.Mutex
+		limiters map[string]*bucketBandwidthLimiter
+	}
+
+	// bucketBandwidthLimiter limits the download bandwidth of a single bucket
+	// and measures its current download rate.
+	bucketBandwidthLimiter struct {
+		limiter *rate.Limiter
+
+		mu          sync.Mutex
+		limit       uint64
+		windowStart time.Time
+		windowBytes uint64
+		lastRate    float64
+		lastUpdate  time.Time
+	}
+
+	// rateLimitedWriter blocks writes until the limiter allows them, applying
+	// back-pressure to the download that writes to it.
+	rateLimitedWriter struct {
+		ctx context.Context
+		w   io.Writer
+		l   *bucketBandwidthLimiter
+	}
+)
+
+func newBucketBandwidthLimiters() *bucketBandwidthLimiters {
+	return &bucketBandwidthLimiters{
+		limiters: make(map[string]*bucketBandwidthLimiter),
+	}
+}
+
+// Limiter returns the limiter for the given bucket, updating its limit to the
+// given number of bytes per second. A limit of 0 means unlimited.
+func (b *bucketBandwidthLimiters) Limiter(bucket string, bytesPerSec uint64) *bucketBandwidthLimiter {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	l, ok := b.limiters[bucket]
+	if !ok {
+		l = &bucketBandwidthLimiter{limiter: rate.NewLimiter(rate.Inf, 0)}
+		b.limiters[bucket] = l
+	}
+	l.setLimit(bytesPerSec)
+	return l
+}
+
+// Stats returns the current download rate of every bucket.
+func (b *bucketBandwidthLimiters) Stats() []api.BucketDownloadStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make([]api.BucketDownloadStats, 0, len(b.limiters))
+	for bucket, l := range b.limiters {
+		stats = append(stats, l.stats(bucket))
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Bucket < stats[j].Bucket
+	})
+	return stats
+}
+
+// Writer wraps the given writer so that writes to it are limited by the
+// bucket's bandwidth limit.
+func (l *bucketBandwidthLimiter) Writer(ctx context.Context, w io.Writer) io.Writer {
+	return &rateLimitedWriter{ctx: ctx, w: w, l: l}
+}
+
+func (l *bucketBandwidthLimiter) setLimit(bytesPerSec uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit == bytesPerSec {
+		return
+	}
+	l.limit = bytesPerSec
+
+	if bytesPerSec == 0 {
+		l.limiter.SetLimit(rate.Inf)
+		l.limiter.SetBurst(0)
+		return
+	}
+
+	// allow bursting up to a second's worth of bandwidth
+	burst := bytesPerSec
+	if burst > math.MaxInt32 {
+		burst = math.MaxInt32
+	}
+	l.limiter.SetLimit(rate.Limit(bytesPerSec))
+	l.limiter.SetBurst(int(burst))
+}
+
+// wait blocks until n bytes may be written, it returns the number of bytes
+// that were granted which might be less than n if n exceeds the burst.
+func (l *bucketBandwidthLimiter) wait(ctx context.Context, n int) (int, error) {
+	if l.limiter.Limit() == rate.Inf {
+		return n, nil
+	} else if burst := l.limiter.Burst(); n > burst {
+		n = burst
+	}
+	return n, l.limiter.WaitN(ctx, n)
+}
+
+func (l *bucketBandwidthLimiter) track(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.windowStart.IsZero() {
+		l.windowStart = now
+	} else if elapsed := now.Sub(l.windowStart); elapsed >= bandwidthRateWindow {
+		l.lastRate = float64(l.windowBytes) / elapsed.Seconds()
+		l.windowStart = now
+		l.windowBytes = 0
+	}
+	l.windowBytes += uint64(n)
+	l.lastUpdate = now
+}
+
+func (l *bucketBandwidthLimiter) stats(bucket string) api.BucketDownloadStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// the rate of the current window is used until a full window has passed,
+	// a bucket that hasn't been downloaded from for a while has a rate of 0
+	var bytesPerSec float64
+	if elapsed := time.Since(l.windowStart); l.windowStart.IsZero() || time.Since(l.lastUpdate) > bandwidthRateWindow {
+		bytesPerSec = 0
+	} else if elapsed < bandwidthRateWindow && l.lastRate == 0 {
+		bytesPerSec = float64(l.windowBytes) / math.Max(elapsed.Seconds(), 1)
+	} else {
+		bytesPerSec = l.lastRate
+	}
+
+	return api.BucketDownloadStats{
+		Bucket:                          bucket,
+		DownloadSpeedBytesPerSec:        math.Round(bytesPerSec),
+		MaxDownloadBandwidthBytesPerSec: l.limit,
+	}
+}
+
+func (w *rateLimitedWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		n, err := w.l.wait(w.ctx, len(p))
+		if err != nil {
+			return written, err
+		}
+		n, err = w.w.Write(p[:n])
+		written += n
+		w.l.track(n)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"lukechampine.com/frand"
+)
+
+func TestBucketBandwidthLimiter(t *testing.T) {
+	limiters := newBucketBandwidthLimiters()
+
+	// assert writes to an unlimited bucket aren't throttled
+	data := frand.Bytes(1 << 20)
+	var buf bytes.Buffer
+	start := time.Now()
+	if n, err := limiters.Limiter("unlimited", 0).Writer(context.Background(), &buf).Write(data); err != nil {
+		t.Fatal(err)
+	} else if n != len(data) || !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("unexpected write", n)
+	} else if time.Since(start) > time.Second {
+		t.Fatal("unlimited write was throttled")
+	}
+
+	// assert writes to a limited bucket are throttled, the burst allows for a
+	// second's worth of data to be written immediately
+	const limit = 1 << 16
+	data = frand.Bytes(limit + limit/2)
+	buf.Reset()
+	start = time.Now()
+	if n, err := limiters.Limiter("limited", limit).Writer(context.Background(), &buf).Write(data); err != nil {
+		t.Fatal(err)
+	} else if n != len(data) || !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("unexpected write", n)
+	} else if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatal("limited write wasn't throttled", elapsed)
+	}
+
+	// assert a blocked write is interrupted when the context is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := limiters.Limiter("limited", limit).Writer(ctx, &buf).Write(data); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// assert the stats contain both buckets
+	stats := limiters.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(stats))
+	} else if stats[0].Bucket != "limited" || stats[0].MaxDownloadBandwidthBytesPerSec != limit || stats[0].DownloadSpeedBytesPerSec == 0 {
+		t.Fatalf("unexpected stats %+v", stats[0])
+	} else if stats[1].Bucket != "unlimited" || stats[1].MaxDownloadBandwidthBytesPerSec != 0 || stats[1].DownloadSpeedBytesPerSec == 0 {
+		t.Fatalf("unexpected stats %+v", stats[1])
+	}
+
+	// assert the limit is updated
+	if limiters.Limiter("limited", 0); limiters.Stats()[0].MaxDownloadBandwidthBytesPerSec != 0 {
+		t.Fatal("limit wasn't updated")
+	}
+}
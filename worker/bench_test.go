@@ -45,7 +45,7 @@ func BenchmarkDownloaderSingleObject(b *testing.B) {
 	b.SetBytes(o.Size)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		err = w.downloadManager.DownloadObject(context.Background(), io.Discard, *o.Object, 0, uint64(o.Size), w.UsableHosts())
+		err = w.downloadManager.DownloadObject(context.Background(), io.Discard, *o.Object, 0, uint64(o.Size), w.UsableHosts(), 0)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -80,6 +81,13 @@ func (c *Client) DownloadStats(ctx context.Context) (resp api.DownloadStatsRespo
 	return
 }
 
+// ObjectCacheStats returns the hit and miss counters of the worker's object
+// metadata cache.
+func (c *Client) ObjectCacheStats(ctx context.Context) (resp api.ObjectCacheStatsResponse, err error) {
+	err = c.c.GET(ctx, "/stats/cache", &resp)
+	return
+}
+
 // HeadObject returns the metadata of the object at the given key.
 func (c *Client) HeadObject(ctx context.Context, bucket, key string, opts api.HeadObjectOptions) (*api.HeadObjectResponse, error) {
 	c.c.Custom("HEAD", fmt.Sprintf("/object/%s", key), nil, nil)
@@ -156,6 +164,41 @@ func (c *Client) RemoveObjects(ctx context.Context, bucket, prefix string) (err
 	return
 }
 
+// VerifyObject probes the hosts that store the shards of the given object and
+// reports how many of its shards are retrievable.
+func (c *Client) VerifyObject(ctx context.Context, bucket, key string, timeout time.Duration) (resp api.ObjectVerifyResponse, err error) {
+	err = c.c.POST(ctx, "/objects/verify", api.ObjectsVerifyRequest{
+		Bucket:  bucket,
+		Key:     key,
+		Timeout: api.DurationMS(timeout),
+	}, &resp)
+	return
+}
+
+// Ready returns the outcome of the worker's readiness checks. A worker that
+// isn't ready doesn't result in an error, instead the response describes which
+// checks failed.
+func (c *Client) Ready(ctx context.Context) (resp api.WorkerReadyResponse, err error) {
+	c.c.Custom("GET", "/ready", nil, &resp)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/ready", c.c.BaseURL), http.NoBody)
+	if err != nil {
+		panic(err)
+	}
+	req.SetBasicAuth("", c.c.Password)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return api.WorkerReadyResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusServiceUnavailable {
+		msg, _ := io.ReadAll(res.Body)
+		return api.WorkerReadyResponse{}, errors.New(string(msg))
+	}
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	return
+}
+
 // State returns the current state of the worker.
 func (c *Client) State(ctx context.Context) (state api.WorkerStateResponse, err error) {
 	err = c.c.GET(ctx, "/state", &state)
@@ -238,6 +281,9 @@ func (c *Client) object(ctx context.Context, bucket, key string, opts api.Downlo
 	if opts.Download != nil {
 		values.Set("dl", fmt.Sprint(*opts.Download))
 	}
+	if opts.RedundancyFactor > 0 {
+		values.Set("redundancyfactor", fmt.Sprint(opts.RedundancyFactor))
+	}
 	key += "?" + values.Encode()
 
 	c.c.Custom("GET", fmt.Sprintf("/object/%s", key), nil, (*[]api.ObjectMetadata)(nil))
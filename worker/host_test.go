@@ -22,8 +22,11 @@ type (
 	testHost struct {
 		*mocks.Host
 		*mocks.Contract
-		pFn         func() rhpv4.HostPrices
-		uploadDelay time.Duration
+		pFn           func() rhpv4.HostPrices
+		downloadDelay time.Duration
+		downloadErr   error
+		uploadDelay   time.Duration
+		uploadErr     error
 	}
 
 	testHostManager struct {
@@ -109,11 +112,24 @@ func (h *testHost) DownloadSector(ctx context.Context, w io.Writer, root types.H
 	if offset+length > rhpv4.SectorSize {
 		return mocks.ErrSectorOutOfBounds
 	}
+	if h.downloadErr != nil {
+		return h.downloadErr
+	}
+	if h.downloadDelay > 0 {
+		select {
+		case <-time.After(h.downloadDelay):
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
 	_, err := w.Write(sector[offset : offset+length])
 	return err
 }
 
 func (h *testHost) UploadSector(ctx context.Context, sectorRoot types.Hash256, sector *[rhpv4.SectorSize]byte) error {
+	if h.uploadErr != nil {
+		return h.uploadErr
+	}
 	h.Contract.AddSector(sectorRoot, sector)
 	if h.uploadDelay > 0 {
 		select {
@@ -125,6 +141,13 @@ func (h *testHost) UploadSector(ctx context.Context, sectorRoot types.Hash256, s
 	return nil
 }
 
+func (h *testHost) VerifySector(ctx context.Context, sectorRoot types.Hash256) error {
+	if _, exist := h.Contract.Sector(sectorRoot); !exist {
+		return rhpv4.ErrSectorNotFound
+	}
+	return nil
+}
+
 func (h *testHost) FetchRevision(ctx context.Context, fcid types.FileContractID) (rev types.FileContractRevision, _ error) {
 	return h.Contract.Revision(), nil
 }
@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/api"
+)
+
+const (
+	// prewarmDialTimeout is the maximum amount of time the worker waits for a
+	// prewarmed connection to be established.
+	prewarmDialTimeout = 30 * time.Second
+)
+
+// prewarmHostConnectionsLoop keeps idle connections to the top n hosts open,
+// the connections are replaced every interval until the worker shuts down.
+func (w *Worker) prewarmHostConnectionsLoop(n int, interval time.Duration) {
+	var releases []func()
+	releaseAll := func() {
+		for _, release := range releases {
+			release()
+		}
+		releases = nil
+	}
+	defer releaseAll()
+
+	for {
+		// release the previous connections before dialing new ones, otherwise
+		// the existing connections would be reused
+		releaseAll()
+		releases = w.prewarmHostConnections(w.shutdownCtx, n)
+
+		select {
+		case <-w.shutdownCtx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// prewarmHostConnections establishes connections to the n hosts the worker has
+// the most contracts with and returns the functions that release them.
+func (w *Worker) prewarmHostConnections(ctx context.Context, n int) (releases []func()) {
+	hosts, err := w.bus.UsableHosts(ctx)
+	if err != nil {
+		w.logger.Warnw("failed to fetch hosts to prewarm connections to", "error", err)
+		return nil
+	}
+	contracts, err := w.bus.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeActive})
+	if err != nil {
+		w.logger.Warnw("failed to fetch contracts to prewarm connections", "error", err)
+		return nil
+	}
+	hosts = topHostsByContracts(hosts, contracts, n)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, h := range hosts {
+		wg.Add(1)
+		go func(h api.HostInfo) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, prewarmDialTimeout)
+			defer cancel()
+			release, err := w.rhp4Client.Prewarm(ctx, h.PublicKey, h.SiamuxAddr())
+			if err != nil {
+				w.logger.Debugw("failed to prewarm host connection", "hostKey", h.PublicKey, "error", err)
+				return
+			}
+			mu.Lock()
+			releases = append(releases, release)
+			mu.Unlock()
+		}(h)
+	}
+	wg.Wait()
+
+	w.logger.Infow("prewarmed host connections", "attempted", len(hosts), "succeeded", len(releases))
+	return releases
+}
+
+// topHostsByContracts returns the n hosts with the most contracts, hosts
+// without contracts are skipped.
+func topHostsByContracts(hosts []api.HostInfo, contracts []api.ContractMetadata, n int) []api.HostInfo {
+	counts := make(map[types.PublicKey]int)
+	for _, c := range contracts {
+		counts[c.HostKey]++
+	}
+
+	var ranked []api.HostInfo
+	for _, h := range hosts {
+		if counts[h.PublicKey] > 0 {
+			ranked = append(ranked, h)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		ci, cj := counts[ranked[i].PublicKey], counts[ranked[j].PublicKey]
+		if ci != cj {
+			return ci > cj
+		}
+		return ranked[i].PublicKey.String() < ranked[j].PublicKey.String()
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
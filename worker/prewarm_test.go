@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/api"
+)
+
+func TestTopHostsByContracts(t *testing.T) {
+	hk1, hk2, hk3, hk4 := types.PublicKey{1}, types.PublicKey{2}, types.PublicKey{3}, types.PublicKey{4}
+	hosts := []api.HostInfo{{PublicKey: hk1}, {PublicKey: hk2}, {PublicKey: hk3}, {PublicKey: hk4}}
+	contracts := []api.ContractMetadata{
+		{HostKey: hk2},
+		{HostKey: hk3},
+		{HostKey: hk3},
+		{HostKey: hk4},
+	}
+
+	// assert hosts are ranked by contract count, ties are broken by host key
+	// and hosts without contracts are skipped
+	top := topHostsByContracts(hosts, contracts, 3)
+	if len(top) != 3 {
+		t.Fatalf("expected 3 hosts, got %d", len(top))
+	} else if top[0].PublicKey != hk3 || top[1].PublicKey != hk2 || top[2].PublicKey != hk4 {
+		t.Fatalf("unexpected ranking %v %v %v", top[0].PublicKey, top[1].PublicKey, top[2].PublicKey)
+	}
+
+	// assert n is capped
+	if top := topHostsByContracts(hosts, contracts, 1); len(top) != 1 || top[0].PublicKey != hk3 {
+		t.Fatalf("unexpected hosts %v", top)
+	} else if top := topHostsByContracts(hosts, contracts, 10); len(top) != 3 {
+		t.Fatalf("expected 3 hosts, got %d", len(top))
+	}
+}
@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.sia.tech/jape"
+	"go.sia.tech/renterd/v2/api"
+)
+
+const (
+	readyCheckContracts     = "contracts"
+	readyCheckSlabBuffers   = "slabBuffers"
+	readyCheckLastOperation = "lastOperation"
+)
+
+// recordSuccessfulOperation updates the time of the last successful upload or
+// download, it's used to determine whether the worker is ready.
+func (w *Worker) recordSuccessfulOperation() {
+	w.lastSuccessfulOperation.Store(time.Now().UnixNano())
+}
+
+// lastOperation returns the time of the last successful upload or download,
+// if the worker hasn't performed an operation yet its start time is returned.
+func (w *Worker) lastOperation() time.Time {
+	if ts := w.lastSuccessfulOperation.Load(); ts > 0 {
+		return time.Unix(0, ts)
+	}
+	return w.startTime
+}
+
+func (w *Worker) readyHandlerGET(jc jape.Context) {
+	ctx := jc.Request.Context()
+
+	var resp api.WorkerReadyResponse
+	check := func(name string, err error) {
+		c := api.WorkerReadyCheck{Name: name, Passed: err == nil}
+		if err != nil {
+			c.Error = err.Error()
+		}
+		resp.Checks = append(resp.Checks, c)
+	}
+
+	// check whether we have at least one usable contract
+	contracts, err := w.bus.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeGood})
+	if err != nil {
+		check(readyCheckContracts, fmt.Errorf("failed to fetch contracts: %w", err))
+	} else if len(contracts) == 0 {
+		check(readyCheckContracts, errors.New("no usable contracts"))
+	} else {
+		check(readyCheckContracts, nil)
+	}
+
+	// check whether the slab buffer manager is running
+	if _, err := w.bus.SlabBuffers(ctx); err != nil {
+		check(readyCheckSlabBuffers, fmt.Errorf("failed to fetch slab buffers: %w", err))
+	} else {
+		check(readyCheckSlabBuffers, nil)
+	}
+
+	// check whether the last operation succeeded recently enough
+	if w.maxLastOperationAge > 0 {
+		if age := time.Since(w.lastOperation()); age > w.maxLastOperationAge {
+			check(readyCheckLastOperation, fmt.Errorf("last successful operation was %v ago, exceeding the max age of %v", age.Round(time.Second), w.maxLastOperationAge))
+		} else {
+			check(readyCheckLastOperation, nil)
+		}
+	}
+
+	resp.Ready = true
+	for _, c := range resp.Checks {
+		resp.Ready = resp.Ready && c.Passed
+	}
+	if !resp.Ready {
+		jc.ResponseWriter.WriteHeader(http.StatusServiceUnavailable)
+	}
+	jc.Encode(resp)
+}
@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/v2/api"
+)
+
+func TestReadyHandler(t *testing.T) {
+	cfg := newTestWorkerCfg()
+	cfg.MaxLastOperationAge = time.Hour
+	w := newTestWorker(t, cfg)
+
+	ready := func() (int, api.WorkerReadyResponse) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		w.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		var resp api.WorkerReadyResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, resp
+	}
+	failed := func(resp api.WorkerReadyResponse) (names []string) {
+		for _, c := range resp.Checks {
+			if !c.Passed {
+				if c.Error == "" {
+					t.Fatalf("check %q failed without an error", c.Name)
+				}
+				names = append(names, c.Name)
+			}
+		}
+		return
+	}
+
+	// without contracts the worker isn't ready
+	if code, resp := ready(); code != http.StatusServiceUnavailable || resp.Ready {
+		t.Fatalf("expected worker not to be ready, got %v %+v", code, resp)
+	} else if names := failed(resp); len(names) != 1 || names[0] != readyCheckContracts {
+		t.Fatalf("unexpected failed checks %v", names)
+	}
+
+	// add a host, the worker should now be ready
+	w.AddHost()
+	if code, resp := ready(); code != http.StatusOK || !resp.Ready {
+		t.Fatalf("expected worker to be ready, got %v %+v", code, resp)
+	} else if len(resp.Checks) != 3 {
+		t.Fatalf("expected 3 checks, got %d", len(resp.Checks))
+	}
+
+	// pretend the last operation happened too long ago
+	w.lastSuccessfulOperation.Store(time.Now().Add(-2 * time.Hour).UnixNano())
+	if code, resp := ready(); code != http.StatusServiceUnavailable || resp.Ready {
+		t.Fatalf("expected worker not to be ready, got %v %+v", code, resp)
+	} else if names := failed(resp); len(names) != 1 || names[0] != readyCheckLastOperation {
+		t.Fatalf("unexpected failed checks %v", names)
+	}
+
+	// record a successful operation, the worker should be ready again
+	w.recordSuccessfulOperation()
+	if code, resp := ready(); code != http.StatusOK || !resp.Ready {
+		t.Fatalf("expected worker to be ready, got %v %+v", code, resp)
+	}
+}
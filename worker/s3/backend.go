@@ -315,7 +315,7 @@ func (s *s3) HeadObject(ctx context.Context, bucketName, key string) (*gofakes3.
 //	delete marker, which becomes the latest version of the object. If there
 //	isn't a null version, Amazon S3 does not remove any objects.
 func (s *s3) DeleteObject(ctx context.Context, bucketName, key string) (gofakes3.ObjectDeleteResult, error) {
-	err := s.b.DeleteObject(ctx, bucketName, key)
+	err := s.w.DeleteObject(ctx, bucketName, key)
 	if utils.IsErr(err, api.ErrBucketNotFound) {
 		return gofakes3.ObjectDeleteResult{}, gofakes3.BucketNotFound(bucketName)
 	} else if utils.IsErr(err, api.ErrObjectNotFound) {
@@ -343,6 +343,8 @@ func (s *s3) PutObject(ctx context.Context, bucketName, key string, meta map[str
 	ur, err := s.w.UploadObject(ctx, input, bucketName, key, opts)
 	if utils.IsErr(err, api.ErrBucketNotFound) {
 		return gofakes3.PutObjectResult{}, gofakes3.BucketNotFound(bucketName)
+	} else if utils.IsErr(err, api.ErrInvalidObjectUserMetadata) {
+		return gofakes3.PutObjectResult{}, gofakes3.ErrorMessage(gofakes3.ErrMetadataTooLarge, err.Error())
 	} else if err != nil {
 		return gofakes3.PutObjectResult{}, gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
 	}
@@ -356,7 +358,7 @@ func (s *s3) PutObject(ctx context.Context, bucketName, key string, meta map[str
 func (s *s3) DeleteMulti(ctx context.Context, bucketName string, objects ...string) (gofakes3.MultiDeleteResult, error) {
 	var res gofakes3.MultiDeleteResult
 	for _, key := range objects {
-		err := s.b.DeleteObject(ctx, bucketName, key)
+		err := s.w.DeleteObject(ctx, bucketName, key)
 		if err != nil && !utils.IsErr(err, api.ErrObjectNotFound) {
 			res.Error = append(res.Error, gofakes3.ErrorResult{
 				Key:     key,
@@ -375,11 +377,14 @@ func (s *s3) DeleteMulti(ctx context.Context, bucketName string, objects ...stri
 
 func (s *s3) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, meta map[string]string) (gofakes3.CopyObjectResult, error) {
 	convertToSiaMetadataHeaders(meta)
-	obj, err := s.b.CopyObject(ctx, srcBucket, dstBucket, "/"+srcKey, "/"+dstKey, api.CopyObjectOptions{
-		MimeType: meta["Content-Type"],
-		Metadata: api.ExtractObjectUserMetadataFrom(meta),
+	obj, err := s.w.CopyObject(ctx, srcBucket, dstBucket, "/"+srcKey, "/"+dstKey, api.CopyObjectOptions{
+		MimeType:  meta["Content-Type"],
+		Metadata:  api.ExtractObjectUserMetadataFrom(meta),
+		Overwrite: true,
 	})
-	if err != nil {
+	if utils.IsErr(err, api.ErrInvalidObjectUserMetadata) {
+		return gofakes3.CopyObjectResult{}, gofakes3.ErrorMessage(gofakes3.ErrMetadataTooLarge, err.Error())
+	} else if err != nil {
 		return gofakes3.CopyObjectResult{}, gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
 	}
 
@@ -396,7 +401,9 @@ func (s *s3) CreateMultipartUpload(ctx context.Context, bucket, key string, meta
 		MimeType:                    meta["Content-Type"],
 		Metadata:                    api.ExtractObjectUserMetadataFrom(meta),
 	})
-	if err != nil {
+	if utils.IsErr(err, api.ErrInvalidObjectUserMetadata) {
+		return "", gofakes3.ErrorMessage(gofakes3.ErrMetadataTooLarge, err.Error())
+	} else if err != nil {
 		return "", gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
 	}
 
@@ -497,7 +504,7 @@ func (s *s3) CompleteMultipartUpload(ctx context.Context, bucket, object string,
 			PartNumber: part.PartNumber,
 		})
 	}
-	resp, err := s.b.CompleteMultipartUpload(ctx, bucket, "/"+object, string(id), parts, api.CompleteMultipartOptions{
+	resp, err := s.w.CompleteMultipartUpload(ctx, bucket, "/"+object, string(id), parts, api.CompleteMultipartOptions{
 		Metadata: api.ExtractObjectUserMetadataFrom(meta),
 	})
 	if err != nil {
@@ -31,12 +31,9 @@ type Bus interface {
 	ListBuckets(ctx context.Context) (buckets []api.Bucket, err error)
 
 	AddObject(ctx context.Context, bucket, key string, o object.Object, opts api.AddObjectOptions) (err error)
-	CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, dstKey string, opts api.CopyObjectOptions) (om api.ObjectMetadata, err error)
-	DeleteObject(ctx context.Context, bucket, key string) (err error)
 	Objects(ctx context.Context, prefix string, opts api.ListObjectOptions) (resp api.ObjectsResponse, err error)
 
 	AbortMultipartUpload(ctx context.Context, bucket, key string, uploadID string) (err error)
-	CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []api.MultipartCompletedPart, opts api.CompleteMultipartOptions) (_ api.MultipartCompleteResponse, err error)
 	CreateMultipartUpload(ctx context.Context, bucket, key string, opts api.CreateMultipartOptions) (api.MultipartCreateResponse, error)
 	MultipartUploads(ctx context.Context, bucket, prefix, keyMarker, uploadIDMarker string, maxUploads int) (resp api.MultipartListUploadsResponse, _ error)
 	MultipartUploadParts(ctx context.Context, bucket, object string, uploadID string, marker int, limit int64) (resp api.MultipartListPartsResponse, _ error)
@@ -45,7 +42,12 @@ type Bus interface {
 	UploadParams(ctx context.Context) (api.UploadParams, error)
 }
 
+// Worker performs the object operations that affect the worker's object
+// metadata cache, so the cache can be invalidated when they succeed.
 type Worker interface {
+	CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []api.MultipartCompletedPart, opts api.CompleteMultipartOptions) (api.MultipartCompleteResponse, error)
+	CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, dstKey string, opts api.CopyObjectOptions) (api.ObjectMetadata, error)
+	DeleteObject(ctx context.Context, bucket, key string) error
 	GetObject(ctx context.Context, bucket, key string, opts api.DownloadObjectOptions) (*api.GetObjectResponse, error)
 	HeadObject(ctx context.Context, bucket, key string, opts api.HeadObjectOptions) (*api.HeadObjectResponse, error)
 	UploadObject(ctx context.Context, r io.Reader, bucket, key string, opts api.UploadObjectOptions) (*api.UploadObjectResponse, error)
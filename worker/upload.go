@@ -47,6 +47,7 @@ func (w *Worker) upload(ctx context.Context, bucket, key string, rs api.Redundan
 	if err != nil {
 		return "", err
 	}
+	w.recordSuccessfulOperation()
 
 	// return early if worker was shut down or if we don't have to consider
 	// packed uploads
@@ -162,6 +163,9 @@ func (w *Worker) hostContracts(ctx context.Context) (hosts []upload.HostInfo, _
 	}
 
 	for _, c := range contracts {
+		if c.SpendingCapReached() {
+			continue // the bus won't record any more spending
+		}
 		if h, ok := hmap[c.HostKey]; ok {
 			hosts = append(hosts, upload.HostInfo{
 				HostInfo:            h,
@@ -195,6 +199,7 @@ func (w *Worker) uploadPackedSlab(ctx context.Context, mem memory.Memory, ps api
 	if err != nil {
 		return fmt.Errorf("couldn't upload packed slab, err: %v", err)
 	}
+	w.recordSuccessfulOperation()
 
 	return nil
 }
@@ -10,6 +10,7 @@ import (
 
 	rhpv4 "go.sia.tech/core/rhp/v4"
 	"go.sia.tech/core/types"
+	rhp "go.sia.tech/coreutils/rhp/v4"
 	"go.sia.tech/renterd/v2/api"
 	"go.sia.tech/renterd/v2/internal/download"
 	"go.sia.tech/renterd/v2/internal/test"
@@ -63,7 +64,7 @@ func TestUpload(t *testing.T) {
 
 	// download the data and assert it matches
 	var buf bytes.Buffer
-	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), w.UsableHosts())
+	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), w.UsableHosts(), 0)
 	if err != nil {
 		t.Fatal(err)
 	} else if !bytes.Equal(data, buf.Bytes()) {
@@ -93,7 +94,17 @@ func TestUpload(t *testing.T) {
 
 	// download the data again and assert it matches
 	buf.Reset()
-	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), filtered)
+	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), filtered, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(data, buf.Bytes()) {
+		t.Fatal("data mismatch")
+	}
+
+	// download the data with a redundancy factor that exceeds the number of
+	// available hosts and assert it matches
+	buf.Reset()
+	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), filtered, int(params.RS.TotalShards))
 	if err != nil {
 		t.Fatal(err)
 	} else if !bytes.Equal(data, buf.Bytes()) {
@@ -110,7 +121,7 @@ func TestUpload(t *testing.T) {
 
 	// download the data again and assert it fails
 	buf.Reset()
-	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), filtered)
+	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), filtered, 0)
 	if !errors.Is(err, download.ErrDownloadNotEnoughHosts) {
 		t.Fatal("expected not enough hosts error", err)
 	}
@@ -131,6 +142,118 @@ func TestUpload(t *testing.T) {
 	}
 }
 
+func TestDownloadRepairInvalidSector(t *testing.T) {
+	// create test worker
+	cfg := newTestWorkerCfg()
+	cfg.DownloadOverdriveTimeout = 100 * time.Millisecond
+	cfg.UploadMaxOverdrive = 1 // bounds the number of concurrent repairs
+	w := newTestWorker(t, cfg)
+
+	// add hosts to worker
+	hosts := w.AddHosts(testRedundancySettings.TotalShards + 1)
+
+	// convenience variables
+	os := w.os
+	dl := w.downloadManager
+	ul := w.uploadManager
+
+	// upload data
+	params := testParameters(t.Name())
+	_, _, err := ul.Upload(context.Background(), bytes.NewReader(frand.Bytes(128)), w.UploadHosts(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := os.Object(context.Background(), testBucket, t.Name(), api.GetObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slab := o.Object.Slabs[0].Slab
+
+	// find the host of the first shard and the unused host
+	var bad, spare *testHost
+	for _, h := range hosts {
+		if _, ok := slab.Shards[0].Contracts[h.PublicKey()]; ok {
+			bad = h
+		}
+		var used bool
+		for _, shard := range slab.Shards {
+			if _, ok := shard.Contracts[h.PublicKey()]; ok {
+				used = true
+			}
+		}
+		if !used {
+			spare = h
+		}
+	}
+
+	// store a copy of the first shard on the spare host
+	sector, ok := bad.Sector(slab.Shards[0].Root)
+	if !ok {
+		t.Fatal("sector not found")
+	}
+	var spareHosts []upload.HostInfo
+	for _, h := range w.UploadHosts() {
+		if h.PublicKey == spare.PublicKey() {
+			spareHosts = append(spareHosts, h)
+		}
+	}
+	mem := w.ulmm.AcquireMemory(context.Background(), rhpv4.SectorSize)
+	if err := ul.UploadShards(context.Background(), slab, [][]byte{sector[:]}, spareHosts, 0, mem); err != nil {
+		t.Fatal(err)
+	}
+	o, err = os.Object(context.Background(), testBucket, t.Name(), api.GetObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slab = o.Object.Slabs[0].Slab
+
+	// prepare a set of hosts that forces the first shard to be downloaded
+	forced := func(exclude types.PublicKey) (forced []api.HostInfo) {
+		for _, h := range w.UsableHosts() {
+			if h.PublicKey == exclude {
+				continue
+			} else if _, ok := slab.Shards[0].Contracts[h.PublicKey]; ok {
+				forced = append(forced, h)
+			} else if _, ok := slab.Shards[1].Contracts[h.PublicKey]; ok {
+				forced = append(forced, h)
+			}
+		}
+		return
+	}
+
+	// make the spare host slow so the bad host is tried first
+	spare.downloadDelay = 2 * cfg.DownloadOverdriveTimeout
+	if _, err := dl.DownloadSlab(context.Background(), slab, forced(bad.PublicKey())); err != nil {
+		t.Fatal(err)
+	}
+	spare.downloadDelay = 0
+
+	// have the bad host return invalid data, the sector should be downloaded
+	// from the spare host and re-uploaded to the bad host
+	corrupted := *sector
+	corrupted[0]++
+	bad.AddSector(slab.Shards[0].Root, &corrupted)
+	bad.downloadErr = rhp.ErrInvalidProof
+	if _, err := dl.DownloadSlab(context.Background(), slab, forced(types.PublicKey{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := test.Retry(100, 10*time.Millisecond, func() error {
+		if n := dl.Stats().AutoRepairedSectors; n != 1 {
+			return fmt.Errorf("expected 1 repaired sector, got %d", n)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the bad host received the sector
+	if repaired, ok := bad.Sector(slab.Shards[0].Root); !ok {
+		t.Fatal("sector wasn't repaired")
+	} else if *repaired != *sector {
+		t.Fatal("repaired sector mismatch")
+	}
+}
+
 func TestUploadPackedSlab(t *testing.T) {
 	// create test worker
 	w := newTestWorker(t, newTestWorkerCfg())
@@ -170,7 +293,7 @@ func TestUploadPackedSlab(t *testing.T) {
 
 	// download the data and assert it matches
 	var buf bytes.Buffer
-	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), w.UsableHosts())
+	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), w.UsableHosts(), 0)
 	if err != nil {
 		t.Fatal(err)
 	} else if !bytes.Equal(data, buf.Bytes()) {
@@ -206,7 +329,7 @@ func TestUploadPackedSlab(t *testing.T) {
 
 	// download the data again and assert it matches
 	buf.Reset()
-	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), w.UsableHosts())
+	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), w.UsableHosts(), 0)
 	if err != nil {
 		t.Fatal(err)
 	} else if !bytes.Equal(data, buf.Bytes()) {
@@ -515,7 +638,7 @@ func TestUploadShards(t *testing.T) {
 
 	// download the data and assert it matches
 	var buf bytes.Buffer
-	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), infos)
+	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), infos, 0)
 	if err != nil {
 		t.Fatal(err)
 	} else if !bytes.Equal(data, buf.Bytes()) {
@@ -555,6 +678,79 @@ func TestUploadSingleSectorSlowHosts(t *testing.T) {
 	}
 }
 
+func TestUploadSlabTimeout(t *testing.T) {
+	// create test worker
+	cfg := newTestWorkerCfg()
+	cfg.SlabUploadTimeout = 100 * time.Millisecond
+	w := newTestWorker(t, cfg)
+
+	// add hosts to worker
+	hosts := w.AddHosts(testRedundancySettings.TotalShards + 1)
+
+	// upload data and assert a successful upload isn't counted as timeout
+	params := testParameters(t.Name())
+	_, _, err := w.uploadManager.Upload(context.Background(), bytes.NewReader(frand.Bytes(128)), w.UploadHosts(), params)
+	if err != nil {
+		t.Fatal(err)
+	} else if stats := w.uploadManager.Stats(); stats.SlabUploadTimeouts != 0 {
+		t.Fatalf("expected no slab upload timeouts, got %d", stats.SlabUploadTimeouts)
+	}
+
+	// grab the slab and download its shards
+	o, err := w.os.Object(context.Background(), testBucket, t.Name(), api.GetObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slab := o.Object.Slabs[0].Slab
+	shards, err := w.downloadManager.DownloadSlab(context.Background(), slab, w.UsableHosts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	slab.Encrypt(shards)
+
+	// find the host that doesn't store a shard and make it slow
+	var spare *testHost
+	for _, h := range hosts {
+		var used bool
+		for _, shard := range slab.Shards {
+			if _, ok := shard.Contracts[h.PublicKey()]; ok {
+				used = true
+			}
+		}
+		if !used {
+			spare = h
+		}
+	}
+	spare.uploadDelay = 3 * cfg.SlabUploadTimeout
+
+	// assert migrating a shard to the slow host doesn't time out
+	var spareHosts []upload.HostInfo
+	for _, h := range w.UploadHosts() {
+		if h.PublicKey == spare.PublicKey() {
+			spareHosts = append(spareHosts, h)
+		}
+	}
+	mem := w.ulmm.AcquireMemory(context.Background(), rhpv4.SectorSize)
+	if err := w.uploadManager.UploadShards(context.Background(), slab, shards[:1], spareHosts, 0, mem); err != nil {
+		t.Fatal(err)
+	} else if stats := w.uploadManager.Stats(); stats.SlabUploadTimeouts != 0 {
+		t.Fatalf("expected no slab upload timeouts, got %d", stats.SlabUploadTimeouts)
+	}
+
+	// make all hosts slow
+	for _, h := range hosts {
+		h.uploadDelay = time.Hour
+	}
+
+	// upload data and assert the slab upload times out
+	_, _, err = w.uploadManager.Upload(context.Background(), bytes.NewReader(frand.Bytes(128)), w.UploadHosts(), params)
+	if !errors.Is(err, upload.ErrSlabUploadTimeout) {
+		t.Fatal("expected ErrSlabUploadTimeout", err)
+	} else if stats := w.uploadManager.Stats(); stats.SlabUploadTimeouts != 1 {
+		t.Fatalf("expected 1 slab upload timeout, got %d", stats.SlabUploadTimeouts)
+	}
+}
+
 func TestUploadRegression(t *testing.T) {
 	// create test worker
 	w := newTestWorker(t, newTestWorkerCfg())
@@ -600,7 +796,7 @@ func TestUploadRegression(t *testing.T) {
 
 	// download data for good measure
 	var buf bytes.Buffer
-	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), w.UsableHosts())
+	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), w.UsableHosts(), 0)
 	if err != nil {
 		t.Fatal(err)
 	} else if !bytes.Equal(data, buf.Bytes()) {
@@ -608,6 +804,71 @@ func TestUploadRegression(t *testing.T) {
 	}
 }
 
+func TestUploadMinConfirmations(t *testing.T) {
+	data := frand.Bytes(128)
+	failing := testRedundancySettings.TotalShards - testRedundancySettings.MinShards
+
+	newWorker := func(minConfirmations int) *testWorker {
+		cfg := newTestWorkerCfg()
+		cfg.MinUploadConfirmations = minConfirmations
+		w := newTestWorker(t, cfg)
+
+		// add exactly as many hosts as shards and make some of them fail
+		for i, h := range w.AddHosts(testRedundancySettings.TotalShards) {
+			if i < failing {
+				h.uploadErr = errors.New("upload failed")
+			}
+		}
+		return w
+	}
+
+	// by default all hosts have to confirm the upload
+	w := newWorker(0)
+	params := testParameters(t.Name())
+	if _, _, err := w.uploadManager.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params); err == nil {
+		t.Fatal("expected upload to fail")
+	}
+
+	// requiring MinShards hosts to confirm the upload should succeed
+	w = newWorker(testRedundancySettings.MinShards)
+	if _, _, err := w.uploadManager.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the slab contains all shards but only some are stored
+	o, err := w.os.Object(context.Background(), testBucket, t.Name(), api.GetObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shards := o.Object.Slabs[0].Shards
+	var stored int
+	for _, shard := range shards {
+		if len(shard.Contracts) > 0 {
+			stored++
+		}
+	}
+	if len(shards) != testRedundancySettings.TotalShards {
+		t.Fatalf("expected %d shards, got %d", testRedundancySettings.TotalShards, len(shards))
+	} else if stored != testRedundancySettings.TotalShards-failing {
+		t.Fatalf("expected %d stored shards, got %d", testRedundancySettings.TotalShards-failing, stored)
+	}
+
+	// assert the data can be downloaded
+	var buf bytes.Buffer
+	err = w.downloadManager.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), w.UsableHosts(), 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(data, buf.Bytes()) {
+		t.Fatal("data mismatch")
+	}
+
+	// requiring more hosts than MinShards to confirm the upload should fail it
+	w = newWorker(testRedundancySettings.MinShards + 1)
+	if _, _, err := w.uploadManager.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params); err == nil {
+		t.Fatal("expected upload to fail")
+	}
+}
+
 func testParameters(key string) upload.Parameters {
 	return upload.Parameters{
 		Bucket: testBucket,
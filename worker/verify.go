@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	rhpv4 "go.sia.tech/core/rhp/v4"
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/v2/api"
+	"go.sia.tech/renterd/v2/internal/gouging"
+)
+
+var (
+	// errHostNotUsable is returned when a shard is stored on a host that is
+	// not usable and can therefore not be probed.
+	errHostNotUsable = errors.New("host is not usable")
+)
+
+type shardProbe struct {
+	slab int
+	root types.Hash256
+}
+
+// VerifyObject probes every host that holds a shard of the given object by
+// downloading a single leaf of the shard. The object's data is not decoded.
+func (w *Worker) VerifyObject(ctx context.Context, bucket, key string) (api.ObjectVerifyResponse, error) {
+	// fetch object
+	res, err := w.bus.Object(ctx, bucket, key, api.GetObjectOptions{})
+	if err != nil {
+		return api.ObjectVerifyResponse{}, fmt.Errorf("couldn't fetch object: %w", err)
+	}
+
+	// fetch gouging params
+	gp, err := w.bus.GougingParams(ctx)
+	if err != nil {
+		return api.ObjectVerifyResponse{}, fmt.Errorf("couldn't fetch gouging parameters from bus: %w", err)
+	}
+	ctx = gouging.WithChecker(ctx, w.bus, gp)
+
+	// fetch usable hosts
+	hosts, err := w.cache.UsableHosts(ctx)
+	if err != nil {
+		return api.ObjectVerifyResponse{}, fmt.Errorf("couldn't fetch usable hosts from bus: %w", err)
+	}
+	usable := make(map[types.PublicKey]api.HostInfo)
+	for _, h := range hosts {
+		usable[h.PublicKey] = h
+	}
+
+	// group the probes by host, partial slabs are skipped since they are
+	// stored on the bus
+	var slabs []api.ObjectVerifyResponse
+	probes := make(map[types.PublicKey][]shardProbe)
+	for _, ss := range res.Object.Slabs {
+		if ss.IsPartial() {
+			continue
+		}
+		for _, shard := range ss.Shards {
+			for hk := range shard.Contracts {
+				probes[hk] = append(probes[hk], shardProbe{slab: len(slabs), root: shard.Root})
+			}
+		}
+		slabs = append(slabs, api.ObjectVerifyResponse{
+			Total:     len(ss.Shards),
+			MinShards: int(ss.MinShards),
+		})
+	}
+
+	// probe every host in parallel, a host's shards are probed sequentially
+	var mu sync.Mutex
+	var failed []api.ShardHealth
+	healthy := make(map[shardProbe]struct{})
+	var wg sync.WaitGroup
+	for hk, hostProbes := range probes {
+		wg.Add(1)
+		go func(hk types.PublicKey, hostProbes []shardProbe) {
+			defer wg.Done()
+
+			hi, ok := usable[hk]
+			for _, probe := range hostProbes {
+				err := errHostNotUsable
+				if ok {
+					err = w.hostManager.Downloader(hi).DownloadSector(ctx, io.Discard, probe.root, 0, rhpv4.LeafSize)
+				}
+
+				mu.Lock()
+				if err != nil {
+					failed = append(failed, api.ShardHealth{
+						HostKey: hk,
+						Root:    probe.root,
+						Error:   err.Error(),
+					})
+				} else {
+					healthy[probe] = struct{}{}
+				}
+				mu.Unlock()
+			}
+		}(hk, hostProbes)
+	}
+	wg.Wait()
+
+	// a shard is healthy if at least one host returned it
+	for probe := range healthy {
+		slabs[probe.slab].Healthy++
+	}
+
+	// the object is only as healthy as its least healthy slab
+	resp := api.ObjectVerifyResponse{FailedShards: failed}
+	for i, slab := range slabs {
+		if i == 0 || slab.Healthy-slab.MinShards < resp.Healthy-resp.MinShards {
+			resp.Healthy = slab.Healthy
+			resp.Total = slab.Total
+			resp.MinShards = slab.MinShards
+		}
+	}
+	if resp.FailedShards == nil {
+		resp.FailedShards = []api.ShardHealth{}
+	}
+	return resp, nil
+}
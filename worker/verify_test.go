@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"go.sia.tech/renterd/v2/api"
+	"lukechampine.com/frand"
+)
+
+func TestVerifyObject(t *testing.T) {
+	// create test worker
+	w := newTestWorker(t, newTestWorkerCfg())
+
+	// add hosts to worker
+	w.AddHosts(testRedundancySettings.TotalShards)
+
+	// upload an object
+	params := testParameters(t.Name())
+	params.Packing = false
+	_, _, err := w.uploadManager.Upload(context.Background(), bytes.NewReader(frand.Bytes(128)), w.UploadHosts(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the object is fully healthy
+	res, err := w.VerifyObject(context.Background(), testBucket, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	} else if res.Healthy != res.Total || res.Total != testRedundancySettings.TotalShards {
+		t.Fatalf("unexpected healthy shards %d/%d", res.Healthy, res.Total)
+	} else if res.MinShards != testRedundancySettings.MinShards || !res.IsHealthy() {
+		t.Fatal("expected object to be healthy")
+	} else if len(res.FailedShards) != 0 {
+		t.Fatal("unexpected failed shards", res.FailedShards)
+	}
+
+	// grab the object
+	o, err := w.os.Object(context.Background(), testBucket, t.Name(), api.GetObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lose enough sectors to render the object unhealthy
+	lost := testRedundancySettings.TotalShards - testRedundancySettings.MinShards + 1
+	for _, shard := range o.Object.Slabs[0].Shards[:lost] {
+		for hk := range shard.Contracts {
+			w.hm.hosts[hk].DeleteSector(shard.Root)
+		}
+	}
+
+	// assert the lost sectors are reported
+	res, err = w.VerifyObject(context.Background(), testBucket, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	} else if res.Healthy != res.Total-lost {
+		t.Fatalf("unexpected healthy shards %d/%d", res.Healthy, res.Total)
+	} else if res.IsHealthy() {
+		t.Fatal("expected object to be unhealthy")
+	} else if len(res.FailedShards) != lost {
+		t.Fatalf("expected %d failed shards, got %d", lost, len(res.FailedShards))
+	}
+	for _, shard := range res.FailedShards {
+		if shard.Error == "" {
+			t.Fatal("expected error to be set")
+		}
+	}
+}
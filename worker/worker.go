@@ -13,6 +13,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gotd/contrib/http_range"
@@ -32,6 +33,7 @@ import (
 	"go.sia.tech/renterd/v2/internal/rhp"
 	rhp4 "go.sia.tech/renterd/v2/internal/rhp/v4"
 	"go.sia.tech/renterd/v2/internal/upload"
+	"go.sia.tech/renterd/v2/internal/upload/uploader"
 	"go.sia.tech/renterd/v2/internal/utils"
 	iworker "go.sia.tech/renterd/v2/internal/worker"
 	"go.sia.tech/renterd/v2/object"
@@ -39,6 +41,12 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// maxObjectCacheEntries is the maximum number of objects for which the
+	// worker caches metadata to serve HEAD requests.
+	maxObjectCacheEntries = 10000
+)
+
 var (
 	ErrShuttingDown = errors.New("worker is shutting down")
 )
@@ -110,10 +118,13 @@ type (
 		// NOTE: used by worker
 		Bucket(_ context.Context, bucket string) (api.Bucket, error)
 		Object(ctx context.Context, bucket, key string, opts api.GetObjectOptions) (api.Object, error)
+		CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []api.MultipartCompletedPart, opts api.CompleteMultipartOptions) (api.MultipartCompleteResponse, error)
+		CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, dstKey string, opts api.CopyObjectOptions) (api.ObjectMetadata, error)
 		DeleteObject(ctx context.Context, bucket, key string) error
 		MultipartUpload(ctx context.Context, uploadID string) (resp api.MultipartUpload, err error)
 		PackedSlabsForUpload(ctx context.Context, lockingDuration time.Duration, minShards, totalShards uint8, limit int) ([]api.PackedSlab, error)
 		RemoveObjects(ctx context.Context, bucket, prefix string) error
+		SlabBuffers(ctx context.Context) ([]api.SlabBuffer, error)
 	}
 
 	SettingStore interface {
@@ -138,12 +149,18 @@ type Worker struct {
 	masterKey utils.MasterKey
 	startTime time.Time
 
+	maxLastOperationAge     time.Duration
+	lastSuccessfulOperation atomic.Int64 // unix nano
+
 	downloadManager *download.Manager
 	uploadManager   *upload.Manager
 	hostManager     hosts.Manager
 
-	accounts *accounts.Manager
-	cache    iworker.WorkerCache
+	accounts    *accounts.Manager
+	cache       iworker.WorkerCache
+	objectCache *iworker.ObjectCache
+
+	downloadLimiters *bucketBandwidthLimiters
 
 	uploadsMu            sync.Mutex
 	uploadingPackedSlabs map[string]struct{}
@@ -182,6 +199,7 @@ func (w *Worker) downloadsStatsHandlerGET(jc jape.Context) {
 		dss = append(dss, api.DownloaderStats{
 			HostKey:                    hk,
 			AvgSectorDownloadSpeedMBPS: mbps,
+			Deprioritized:              stats.Deprioritized[hk],
 		})
 	}
 	sort.SliceStable(dss, func(i, j int) bool {
@@ -192,12 +210,18 @@ func (w *Worker) downloadsStatsHandlerGET(jc jape.Context) {
 	api.WriteResponse(jc, api.DownloadStatsResponse{
 		AvgDownloadSpeedMBPS: math.Ceil(stats.AvgDownloadSpeedMBPS*100) / 100,
 		AvgOverdrivePct:      math.Floor(stats.AvgOverdrivePct*100*100) / 100,
+		AutoRepairedSectors:  stats.AutoRepairedSectors,
 		HealthyDownloaders:   stats.HealthyDownloaders,
 		NumDownloaders:       stats.NumDownloaders,
 		DownloadersStats:     dss,
+		BucketsStats:         w.downloadLimiters.Stats(),
 	})
 }
 
+func (w *Worker) objectCacheStatsHandlerGET(jc jape.Context) {
+	api.WriteResponse(jc, w.objectCache.Stats())
+}
+
 func (w *Worker) uploadsStatsHandlerGET(jc jape.Context) {
 	stats := w.uploadManager.Stats()
 
@@ -213,13 +237,24 @@ func (w *Worker) uploadsStatsHandlerGET(jc jape.Context) {
 		return uss[i].AvgSectorUploadSpeedMBPS > uss[j].AvgSectorUploadSpeedMBPS
 	})
 
+	// prepare sector filter stats
+	var sfs *api.SectorFilterStats
+	if stats.SectorFilter != nil {
+		sfs = &api.SectorFilterStats{
+			Lookups: stats.SectorFilter.Lookups,
+			Hits:    stats.SectorFilter.Hits,
+		}
+	}
+
 	// encode response
 	api.WriteResponse(jc, api.UploadStatsResponse{
 		AvgSlabUploadSpeedMBPS: math.Ceil(stats.AvgSlabUploadSpeedMBPS*100) / 100,
 		AvgOverdrivePct:        math.Floor(stats.AvgOverdrivePct*100*100) / 100,
 		HealthyUploaders:       stats.HealthyUploaders,
 		NumUploaders:           stats.NumUploaders,
+		SlabUploadTimeouts:     stats.SlabUploadTimeouts,
 		UploadersStats:         uss,
+		SectorFilter:           sfs,
 	})
 }
 
@@ -249,10 +284,22 @@ func (w *Worker) objectHandlerHEAD(jc jape.Context) {
 	// parse key
 	path := jc.PathParam("key")
 
-	// fetch object metadata
-	hor, err := w.HeadObject(jc.Request.Context(), bucket, path, api.HeadObjectOptions{
-		Range: &dr,
-	})
+	// fetch object metadata, preferring the cache
+	res, cached := w.objectCache.Get(bucket, path)
+	if !cached {
+		res, err = w.bus.Object(jc.Request.Context(), bucket, path, api.GetObjectOptions{
+			OnlyMetadata: true,
+		})
+		if err == nil {
+			w.objectCache.Set(bucket, path, res)
+		} else {
+			err = fmt.Errorf("couldn't fetch object: %w", err)
+		}
+	}
+	var hor *api.HeadObjectResponse
+	if err == nil {
+		hor, err = headObjectResponse(res, api.HeadObjectOptions{Range: &dr})
+	}
 	if utils.IsErr(err, api.ErrObjectNotFound) {
 		jc.Error(err, http.StatusNotFound)
 		return
@@ -307,8 +354,17 @@ func (w *Worker) objectHandlerGET(jc jape.Context) {
 		return
 	}
 
+	var redundancyFactor int
+	if jc.DecodeForm("redundancyfactor", &redundancyFactor) != nil {
+		return
+	} else if redundancyFactor < 0 {
+		jc.Error(errors.New("redundancy factor can't be negative"), http.StatusBadRequest)
+		return
+	}
+
 	gor, err := w.GetObject(ctx, bucket, key, api.DownloadObjectOptions{
-		Range: &dr,
+		Range:            &dr,
+		RedundancyFactor: redundancyFactor,
 	})
 	if utils.IsErr(err, api.ErrObjectNotFound) {
 		jc.Error(err, http.StatusNotFound)
@@ -380,7 +436,7 @@ func (w *Worker) objectHandlerPUT(jc jape.Context) {
 		MimeType:      mimeType,
 		Metadata:      metadata,
 	})
-	if utils.IsErr(err, api.ErrInvalidRedundancySettings) {
+	if utils.IsErr(err, api.ErrInvalidRedundancySettings) || utils.IsErr(err, api.ErrInvalidObjectUserMetadata) {
 		jc.Error(err, http.StatusBadRequest)
 		return
 	} else if utils.IsErr(err, api.ErrBucketNotFound) {
@@ -483,7 +539,7 @@ func (w *Worker) objectHandlerDELETE(jc jape.Context) {
 	if jc.DecodeForm("bucket", &bucket) != nil {
 		return
 	}
-	err := w.bus.DeleteObject(jc.Request.Context(), bucket, jc.PathParam("key"))
+	err := w.DeleteObject(jc.Request.Context(), bucket, jc.PathParam("key"))
 	if utils.IsErr(err, api.ErrObjectNotFound) {
 		jc.Error(err, http.StatusNotFound)
 		return
@@ -505,7 +561,38 @@ func (w *Worker) objectsRemoveHandlerPOST(jc jape.Context) {
 		return
 	}
 
-	jc.Check("couldn't remove objects", w.bus.RemoveObjects(jc.Request.Context(), orr.Bucket, orr.Prefix))
+	err := w.bus.RemoveObjects(jc.Request.Context(), orr.Bucket, orr.Prefix)
+	w.objectCache.InvalidatePrefix(orr.Bucket, orr.Prefix)
+	jc.Check("couldn't remove objects", err)
+}
+
+func (w *Worker) objectsVerifyHandlerPOST(jc jape.Context) {
+	var ovr api.ObjectsVerifyRequest
+	if jc.Decode(&ovr) != nil {
+		return
+	} else if ovr.Bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	} else if ovr.Key == "" {
+		jc.Error(errors.New("'key' parameter is required"), http.StatusBadRequest)
+		return
+	}
+
+	ctx := jc.Request.Context()
+	if ovr.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(ovr.Timeout))
+		defer cancel()
+	}
+
+	res, err := w.VerifyObject(ctx, ovr.Bucket, ovr.Key)
+	if utils.IsErr(err, api.ErrObjectNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't verify object", err) != nil {
+		return
+	}
+	jc.Encode(res)
 }
 
 func (w *Worker) memoryGET(jc jape.Context) {
@@ -581,6 +668,14 @@ func New(cfg config.Worker, masterKey [32]byte, b Bus, l *zap.Logger) (*Worker,
 	if cfg.CacheExpiry == 0 {
 		return nil, errors.New("cache expiry cannot be 0")
 	}
+	if cfg.MinUploadConfirmations < 0 {
+		return nil, errors.New("minUploadConfirmations cannot be negative")
+	}
+	if cfg.PrewarmHostConnections < 0 {
+		return nil, errors.New("prewarmHostConnections cannot be negative")
+	} else if cfg.PrewarmHostConnections > 0 && cfg.ConnPrewarmInterval <= 0 {
+		return nil, errors.New("connPrewarmInterval must be positive when prewarming host connections")
+	}
 
 	a := alerts.WithOrigin(b, fmt.Sprintf("worker.%s", cfg.ID))
 	shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
@@ -589,9 +684,12 @@ func New(cfg config.Worker, masterKey [32]byte, b Bus, l *zap.Logger) (*Worker,
 	w := &Worker{
 		alerts:               a,
 		cache:                iworker.NewCache(b, cfg.CacheExpiry, l),
+		downloadLimiters:     newBucketBandwidthLimiters(),
 		id:                   cfg.ID,
 		bus:                  b,
 		masterKey:            masterKey,
+		maxLastOperationAge:  cfg.MaxLastOperationAge,
+		objectCache:          iworker.NewObjectCache(cfg.ObjectCacheTTL, maxObjectCacheEntries),
 		logger:               l.Sugar(),
 		rhp4Client:           rhp4.New(dialer),
 		startTime:            time.Now(),
@@ -607,14 +705,22 @@ func New(cfg config.Worker, masterKey [32]byte, b Bus, l *zap.Logger) (*Worker,
 	uploadKey := w.masterKey.DeriveUploadKey()
 
 	w.contractSpendingRecorder = contracts.NewSpendingRecorder(w.shutdownCtx, w.bus, cfg.BusFlushInterval, l)
-	hm := hosts.NewManager(w.masterKey, w.accounts, w.contractSpendingRecorder, dialer, l)
+	hm := hosts.NewManager(w.masterKey, w.accounts, w.contractSpendingRecorder, w.rhp4Client, l)
 	w.hostManager = hm
 
 	dlmm := memory.NewManager(cfg.DownloadMaxMemory, l.Named("downloadmanager"))
-	w.downloadManager = download.NewManager(w.shutdownCtx, &uploadKey, hm, dlmm, w.bus, cfg.DownloadMaxOverdrive, cfg.DownloadOverdriveTimeout, l)
+	w.downloadManager = download.NewManager(w.shutdownCtx, &uploadKey, hm, dlmm, w.bus, cfg.DownloadMaxOverdrive, cfg.DownloadOverdriveTimeout, cfg.UploadMaxOverdrive, l)
 
 	ulmm := memory.NewManager(cfg.UploadMaxMemory, l.Named("uploadmanager"))
-	w.uploadManager = upload.NewManager(w.shutdownCtx, &uploadKey, hm, ulmm, w.bus, w.bus, w.bus, cfg.UploadMaxOverdrive, cfg.UploadOverdriveTimeout, l)
+	var sectors *uploader.SectorFilter
+	if cfg.UploadSectorFilterCapacity > 0 {
+		sectors = uploader.NewSectorFilter(cfg.UploadSectorFilterCapacity, cfg.UploadSectorFilterFPRate)
+	}
+	w.uploadManager = upload.NewManager(w.shutdownCtx, &uploadKey, hm, ulmm, w.bus, w.bus, w.bus, sectors, cfg.UploadMaxOverdrive, cfg.UploadOverdriveTimeout, cfg.SlabUploadTimeout, cfg.MinUploadConfirmations, l)
+
+	if cfg.PrewarmHostConnections > 0 {
+		go w.prewarmHostConnectionsLoop(cfg.PrewarmHostConnections, cfg.ConnPrewarmInterval)
+	}
 
 	return w, nil
 }
@@ -635,9 +741,12 @@ func (w *Worker) Handler() http.Handler {
 		"PUT    /object/*key":    w.objectHandlerPUT,
 		"DELETE /object/*key":    w.objectHandlerDELETE,
 		"POST   /objects/remove": w.objectsRemoveHandlerPOST,
+		"POST   /objects/verify": w.objectsVerifyHandlerPOST,
 
+		"GET    /ready": w.readyHandlerGET,
 		"GET    /state": w.stateHandlerGET,
 
+		"GET    /stats/cache":     w.objectCacheStatsHandlerGET,
 		"GET    /stats/downloads": w.downloadsStatsHandlerGET,
 		"GET    /stats/uploads":   w.uploadsStatsHandlerGET,
 	})
@@ -670,6 +779,16 @@ func (w *Worker) headObject(ctx context.Context, bucket, key string, onlyMetadat
 		return nil, api.Object{}, fmt.Errorf("couldn't fetch object: %w", err)
 	}
 
+	hor, err := headObjectResponse(res, opts)
+	if err != nil {
+		return nil, api.Object{}, err
+	}
+	return hor, res, nil
+}
+
+// headObjectResponse builds the response to a HEAD request for the given object
+// and range.
+func headObjectResponse(res api.Object, opts api.HeadObjectOptions) (*api.HeadObjectResponse, error) {
 	// adjust length
 	if opts.Range == nil {
 		opts.Range = &api.DownloadRange{Offset: 0, Length: -1}
@@ -680,17 +799,17 @@ func (w *Worker) headObject(ctx context.Context, bucket, key string, onlyMetadat
 
 	// check size of object against range
 	if opts.Range.Offset+opts.Range.Length > res.Size {
-		return nil, api.Object{}, http_range.ErrInvalid
+		return nil, http_range.ErrInvalid
 	}
 
 	return &api.HeadObjectResponse{
-		ContentType:  res.MimeType,
+		ContentType:  res.ContentType(),
 		Etag:         res.ETag,
 		LastModified: res.ModTime,
 		Range:        opts.Range.ContentRange(res.Size),
 		Size:         res.Size,
 		Metadata:     res.Metadata,
-	}, res, nil
+	}, nil
 }
 
 func (w *Worker) FundAccount(ctx context.Context, fcid types.FileContractID, hk types.PublicKey, desired types.Currency) error {
@@ -727,6 +846,16 @@ func (w *Worker) GetObject(ctx context.Context, bucket, key string, opts api.Dow
 	}
 	obj := *res.Object
 
+	// fetch the bucket to apply its bandwidth limit, the download isn't
+	// limited if the bucket can't be fetched
+	var maxBandwidth uint64
+	if b, err := w.cache.Bucket(ctx, bucket); err != nil {
+		w.logger.Warnw("failed to fetch bucket, download bandwidth is not limited", "bucket", bucket, zap.Error(err))
+	} else {
+		maxBandwidth = b.Policy.MaxDownloadBandwidthBytesPerSec
+	}
+	limiter := w.downloadLimiters.Limiter(bucket, maxBandwidth)
+
 	// adjust range
 	if opts.Range == nil {
 		opts.Range = &api.DownloadRange{}
@@ -756,7 +885,7 @@ func (w *Worker) GetObject(ctx context.Context, bucket, key string, opts api.Dow
 		// otherwise return a pipe reader
 		downloadFn := func(wr io.Writer, offset, length int64) error {
 			ctx = gouging.WithChecker(ctx, w.bus, gp)
-			err = w.downloadManager.DownloadObject(ctx, wr, obj, uint64(offset), uint64(length), hosts)
+			err = w.downloadManager.DownloadObject(ctx, limiter.Writer(ctx, wr), obj, uint64(offset), uint64(length), hosts, opts.RedundancyFactor)
 			if err != nil {
 				w.logger.Error(err)
 				if !errors.Is(err, download.ErrShuttingDown) &&
@@ -766,6 +895,7 @@ func (w *Worker) GetObject(ctx context.Context, bucket, key string, opts api.Dow
 				}
 				return fmt.Errorf("failed to download object: %w", err)
 			}
+			w.recordSuccessfulOperation()
 			return nil
 		}
 		pr, pw := io.Pipe()
@@ -795,8 +925,13 @@ func (w *Worker) SyncAccount(ctx context.Context, fcid types.FileContractID, hos
 }
 
 func (w *Worker) UploadObject(ctx context.Context, r io.Reader, bucket, key string, opts api.UploadObjectOptions) (*api.UploadObjectResponse, error) {
+	// validate the metadata before uploading any data
+	if err := opts.Metadata.Validate(); err != nil {
+		return nil, err
+	}
+
 	// prepare upload params
-	up, err := w.prepareUploadParams(ctx, bucket, opts.MinShards, opts.TotalShards)
+	up, policy, err := w.prepareUploadParams(ctx, bucket, opts.MinShards, opts.TotalShards)
 	if err != nil {
 		return nil, err
 	}
@@ -809,18 +944,21 @@ func (w *Worker) UploadObject(ctx context.Context, r io.Reader, bucket, key stri
 	if err != nil {
 		return nil, fmt.Errorf("couldn't fetch contracts from bus: %w", err)
 	}
+	contracts, packing := applyBucketPolicy(policy, contracts, up.UploadPacking)
 
-	// upload
+	// upload, the cached metadata is invalidated regardless of the outcome
+	// since a failed upload might have replaced the object
+	defer w.objectCache.Invalidate(bucket, key)
 	eTag, err := w.upload(ctx, bucket, key, up.RedundancySettings, r, contracts,
 		upload.WithBlockHeight(up.CurrentHeight),
 		upload.WithMimeType(opts.MimeType),
-		upload.WithPacking(up.UploadPacking),
+		upload.WithPacking(packing),
 		upload.WithObjectUserMetadata(opts.Metadata),
 	)
 	if err != nil {
 		w.logger.With(zap.Error(err)).With("key", key).With("bucket", bucket).Error("failed to upload object")
 		if !errors.Is(err, ErrShuttingDown) && !errors.Is(err, upload.ErrUploadCancelled) && !errors.Is(err, context.Canceled) {
-			w.registerAlert(newUploadFailedAlert(bucket, key, opts.MimeType, up.RedundancySettings.MinShards, up.RedundancySettings.TotalShards, len(contracts), up.UploadPacking, false, err))
+			w.registerAlert(newUploadFailedAlert(bucket, key, opts.MimeType, up.RedundancySettings.MinShards, up.RedundancySettings.TotalShards, len(contracts), packing, false, err))
 		}
 		return nil, fmt.Errorf("couldn't upload object: %w", err)
 	}
@@ -829,9 +967,29 @@ func (w *Worker) UploadObject(ctx context.Context, r io.Reader, bucket, key stri
 	}, nil
 }
 
+// CompleteMultipartUpload completes the multipart upload and invalidates the
+// cached metadata of the object it replaces.
+func (w *Worker) CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []api.MultipartCompletedPart, opts api.CompleteMultipartOptions) (api.MultipartCompleteResponse, error) {
+	defer w.objectCache.Invalidate(bucket, key)
+	return w.bus.CompleteMultipartUpload(ctx, bucket, key, uploadID, parts, opts)
+}
+
+// CopyObject copies the object and invalidates the cached metadata of the
+// destination object.
+func (w *Worker) CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, dstKey string, opts api.CopyObjectOptions) (api.ObjectMetadata, error) {
+	defer w.objectCache.Invalidate(dstBucket, dstKey)
+	return w.bus.CopyObject(ctx, srcBucket, dstBucket, srcKey, dstKey, opts)
+}
+
+// DeleteObject deletes the object and invalidates its cached metadata.
+func (w *Worker) DeleteObject(ctx context.Context, bucket, key string) error {
+	defer w.objectCache.Invalidate(bucket, key)
+	return w.bus.DeleteObject(ctx, bucket, key)
+}
+
 func (w *Worker) UploadMultipartUploadPart(ctx context.Context, r io.Reader, bucket, path, uploadID string, partNumber int, opts api.UploadMultipartUploadPartOptions) (*api.UploadMultipartUploadPartResponse, error) {
 	// prepare upload params
-	up, err := w.prepareUploadParams(ctx, bucket, opts.MinShards, opts.TotalShards)
+	up, policy, err := w.prepareUploadParams(ctx, bucket, opts.MinShards, opts.TotalShards)
 	if err != nil {
 		return nil, err
 	}
@@ -845,10 +1003,17 @@ func (w *Worker) UploadMultipartUploadPart(ctx context.Context, r io.Reader, buc
 	// attach gouging checker to the context
 	ctx = gouging.WithChecker(ctx, w.bus, up.GougingParams)
 
+	// fetch host & contract info
+	contracts, err := w.hostContracts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't fetch contracts from bus: %w", err)
+	}
+	contracts, packing := applyBucketPolicy(policy, contracts, up.UploadPacking)
+
 	// prepare opts
 	uploadOpts := []upload.Option{
 		upload.WithBlockHeight(up.CurrentHeight),
-		upload.WithPacking(up.UploadPacking),
+		upload.WithPacking(packing),
 		upload.WithCustomKey(mu.EncryptionKey),
 		upload.WithPartNumber(partNumber),
 		upload.WithUploadID(uploadID),
@@ -863,18 +1028,12 @@ func (w *Worker) UploadMultipartUploadPart(ctx context.Context, r io.Reader, buc
 		uploadOpts = append(uploadOpts, upload.WithCustomEncryptionOffset(uint64(*opts.EncryptionOffset)))
 	}
 
-	// fetch host & contract info
-	contracts, err := w.hostContracts(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't fetch contracts from bus: %w", err)
-	}
-
 	// upload
 	eTag, err := w.upload(ctx, bucket, path, up.RedundancySettings, r, contracts, uploadOpts...)
 	if err != nil {
 		w.logger.With(zap.Error(err)).With("path", path).With("bucket", bucket).Error("failed to upload object")
 		if !errors.Is(err, ErrShuttingDown) && !errors.Is(err, upload.ErrUploadCancelled) && !errors.Is(err, context.Canceled) {
-			w.registerAlert(newUploadFailedAlert(bucket, path, "", up.RedundancySettings.MinShards, up.RedundancySettings.TotalShards, len(contracts), up.UploadPacking, false, err))
+			w.registerAlert(newUploadFailedAlert(bucket, path, "", up.RedundancySettings.MinShards, up.RedundancySettings.TotalShards, len(contracts), packing, false, err))
 		}
 		return nil, fmt.Errorf("couldn't upload object: %w", err)
 	}
@@ -891,22 +1050,27 @@ func (w *Worker) initAccounts(refillInterval time.Duration) (err error) {
 	return err
 }
 
-func (w *Worker) prepareUploadParams(ctx context.Context, bucket string, minShards, totalShards int) (api.UploadParams, error) {
+func (w *Worker) prepareUploadParams(ctx context.Context, bucket string, minShards, totalShards int) (api.UploadParams, api.BucketPolicy, error) {
 	// return early if the bucket does not exist
-	_, err := w.bus.Bucket(ctx, bucket)
+	b, err := w.bus.Bucket(ctx, bucket)
 	if err != nil {
-		return api.UploadParams{}, fmt.Errorf("bucket '%s' not found; %w", bucket, err)
+		return api.UploadParams{}, api.BucketPolicy{}, fmt.Errorf("bucket '%s' not found; %w", bucket, err)
 	}
 
 	// fetch the upload parameters
 	up, err := w.bus.UploadParams(ctx)
 	if err != nil {
-		return api.UploadParams{}, fmt.Errorf("couldn't fetch upload parameters from bus: %w", err)
+		return api.UploadParams{}, api.BucketPolicy{}, fmt.Errorf("couldn't fetch upload parameters from bus: %w", err)
 	}
 
 	// cancel the upload if consensus is not synced
 	if !up.ConsensusState.Synced {
-		return api.UploadParams{}, api.ErrConsensusNotSynced
+		return api.UploadParams{}, api.BucketPolicy{}, api.ErrConsensusNotSynced
+	}
+
+	// use the bucket's redundancy settings if it has any
+	if b.Redundancy != nil {
+		up.RedundancySettings = *b.Redundancy
 	}
 
 	// allow overriding the redundancy settings
@@ -918,7 +1082,17 @@ func (w *Worker) prepareUploadParams(ctx context.Context, bucket string, minShar
 	}
 	err = api.RedundancySettings{MinShards: up.RedundancySettings.MinShards, TotalShards: up.RedundancySettings.TotalShards}.Validate()
 	if err != nil {
-		return api.UploadParams{}, err
+		return api.UploadParams{}, api.BucketPolicy{}, err
+	}
+	return up, b.Policy, nil
+}
+
+// applyBucketPolicy filters the upload hosts according to the bucket's host
+// restrictions. Packed slabs are shared between buckets and uploaded to any
+// host, so packing is disabled for buckets that restrict their hosts.
+func applyBucketPolicy(policy api.BucketPolicy, hosts []upload.HostInfo, packing bool) ([]upload.HostInfo, bool) {
+	if !policy.RestrictsHosts() {
+		return hosts, packing
 	}
-	return up, nil
+	return upload.FilterHosts(hosts, policy.HostAllowed), false
 }
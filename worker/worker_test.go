@@ -55,9 +55,10 @@ func newTestWorker(t test.TestingCommon, cfg config.Worker) *testWorker {
 
 	// override managers
 	hm := newTestHostManager(t)
+	w.hostManager = hm
 	uploadKey := mk.DeriveUploadKey()
-	w.downloadManager = download.NewManager(context.Background(), &uploadKey, hm, dlmm, b, cfg.DownloadMaxOverdrive, cfg.DownloadOverdriveTimeout, zap.NewNop())
-	w.uploadManager = upload.NewManager(context.Background(), &uploadKey, hm, ulmm, b, b, b, cfg.UploadMaxMemory, cfg.UploadOverdriveTimeout, zap.NewNop())
+	w.downloadManager = download.NewManager(context.Background(), &uploadKey, hm, dlmm, b, cfg.DownloadMaxOverdrive, cfg.DownloadOverdriveTimeout, cfg.UploadMaxOverdrive, zap.NewNop())
+	w.uploadManager = upload.NewManager(context.Background(), &uploadKey, hm, ulmm, b, b, b, nil, cfg.UploadMaxMemory, cfg.UploadOverdriveTimeout, cfg.SlabUploadTimeout, cfg.MinUploadConfirmations, zap.NewNop())
 
 	return &testWorker{
 		test.NewTT(t),